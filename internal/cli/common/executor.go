@@ -17,6 +17,7 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"io"
 
 	"github.com/spf13/cobra"
@@ -27,3 +28,11 @@ type CommandExecutor interface {
 	Execute(cmd *cobra.Command, args []string) error
 	SetOutput(w io.Writer)
 }
+
+// Get the context a command was executed with, or a background context when it is run directly (eg, in tests)
+func GetContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
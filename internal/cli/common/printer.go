@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	OutputFlag = "output"
+
+	OutputTable = "table"
+	OutputWide  = "wide"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+var outputFormats = []string{OutputTable, OutputWide, OutputJSON, OutputYAML}
+
+// Add the --output flag which selects how a command prints its results
+func AddOutputFlag(flags *pflag.FlagSet) {
+	flags.StringP(OutputFlag, "o", OutputTable, fmt.Sprintf("Output format (%s)", strings.Join(outputFormats, ", ")))
+}
+
+// Get the output format a command was run with, or the table format if the command has no --output flag (eg, in tests)
+func GetOutputFormat(cmd *cobra.Command) (string, error) {
+	if cmd.Flags().Lookup(OutputFlag) == nil {
+		return OutputTable, nil
+	}
+	format, err := cmd.Flags().GetString(OutputFlag)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range outputFormats {
+		if format == f {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported output format %s: expected one of %s", format, strings.Join(outputFormats, ", "))
+}
+
+// Whether results are printed as a table (rather than json or yaml) in the given format
+func IsTableFormat(format string) bool {
+	return format != OutputJSON && format != OutputYAML
+}
+
+// A table of results, where the wide columns are only printed in the wide format
+type Table struct {
+	Headers     []string
+	WideHeaders []string
+	rows        [][]string
+}
+
+// Add a row with a cell for each header followed by a cell for each wide header
+func (t *Table) AddRow(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+func (t *Table) print(w io.Writer, wide bool) error {
+	columns := len(t.Headers)
+	headers := t.Headers
+	if wide {
+		columns += len(t.WideHeaders)
+		headers = append(append([]string{}, t.Headers...), t.WideHeaders...)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range t.rows {
+		if len(row) > columns {
+			row = row[:columns]
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// Proto messages keep their zero values (eg, INBOUND rules) and the field names of the API, so the output has a stable schema
+var protoMarshalOptions = protojson.MarshalOptions{EmitUnpopulated: true, UseProtoNames: true}
+
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+// Marshal the results of a command as JSON, using protojson for proto messages and lists of them
+func marshalJSON(obj any) ([]byte, error) {
+	if msg, ok := obj.(proto.Message); ok {
+		return protoMarshalOptions.Marshal(msg)
+	}
+	value := reflect.ValueOf(obj)
+	if value.Kind() == reflect.Slice && value.Type().Elem().Implements(protoMessageType) {
+		items := make([]json.RawMessage, value.Len())
+		for i := range items {
+			item, err := protoMarshalOptions.Marshal(value.Index(i).Interface().(proto.Message))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return json.Marshal(items)
+	}
+	return json.Marshal(obj)
+}
+
+// Print the results of a command in the given format
+// Proto messages are marshalled with protojson and other objects as is, so their JSON field names make up the schema of the output
+func Print(w io.Writer, format string, obj any, table *Table) error {
+	switch format {
+	case OutputJSON, OutputYAML:
+		out, err := marshalJSON(obj)
+		if err != nil {
+			return err
+		}
+		if format == OutputYAML {
+			out, err = yaml.JSONToYAML(out)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(out)
+			return err
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, out, "", "  "); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, indented.String())
+		return err
+	case OutputWide:
+		return table.print(w, true)
+	default:
+		return table.print(w, false)
+	}
+}
@@ -0,0 +1,100 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+)
+
+type printed struct {
+	Name string `json:"name"`
+	IP   string `json:"ip"`
+}
+
+func TestGetOutputFormat(t *testing.T) {
+	// Commands without the flag print tables
+	format, err := GetOutputFormat(&cobra.Command{})
+	require.Nil(t, err)
+	assert.Equal(t, OutputTable, format)
+
+	cmd := &cobra.Command{}
+	AddOutputFlag(cmd.Flags())
+	require.Nil(t, cmd.Flags().Set(OutputFlag, OutputJSON))
+	format, err = GetOutputFormat(cmd)
+	require.Nil(t, err)
+	assert.Equal(t, OutputJSON, format)
+
+	require.Nil(t, cmd.Flags().Set(OutputFlag, "xml"))
+	_, err = GetOutputFormat(cmd)
+	assert.NotNil(t, err)
+}
+
+func TestPrint(t *testing.T) {
+	obj := []printed{{Name: "vm1", IP: "10.0.0.1"}}
+	table := &Table{Headers: []string{"NAME"}, WideHeaders: []string{"IP"}}
+	table.AddRow("vm1", "10.0.0.1")
+
+	// Table only includes the wide columns in the wide format
+	var output bytes.Buffer
+	require.Nil(t, Print(&output, OutputTable, obj, table))
+	assert.Equal(t, "NAME\nvm1\n", output.String())
+
+	output.Reset()
+	require.Nil(t, Print(&output, OutputWide, obj, table))
+	assert.Contains(t, output.String(), "NAME  IP")
+	assert.Contains(t, output.String(), "vm1   10.0.0.1")
+
+	// JSON and YAML use the object's JSON field names
+	output.Reset()
+	require.Nil(t, Print(&output, OutputJSON, obj, table))
+	parsed := []printed{}
+	require.Nil(t, json.Unmarshal(output.Bytes(), &parsed))
+	assert.Equal(t, obj, parsed)
+
+	output.Reset()
+	require.Nil(t, Print(&output, OutputYAML, obj, table))
+	assert.Equal(t, "- ip: 10.0.0.1\n  name: vm1\n", output.String())
+}
+
+func TestPrintProto(t *testing.T) {
+	rules := []*paragliderpb.PermitListRule{{Name: "rule", Direction: paragliderpb.Direction_INBOUND, SrcPort: -1}}
+
+	// Zero values are kept and fields use the proto names
+	var output bytes.Buffer
+	require.Nil(t, Print(&output, OutputJSON, rules, &Table{}))
+	parsed := []map[string]any{}
+	require.Nil(t, json.Unmarshal(output.Bytes(), &parsed))
+	require.Len(t, parsed, 1)
+	assert.Equal(t, "INBOUND", parsed[0]["direction"])
+	assert.Equal(t, float64(0), parsed[0]["dst_port"])
+	assert.Equal(t, []any{}, parsed[0]["tags"])
+
+	output.Reset()
+	require.Nil(t, Print(&output, OutputYAML, rules[0], &Table{}))
+	assert.Contains(t, output.String(), "direction: INBOUND\n")
+	assert.Contains(t, output.String(), "protocol: 0\n")
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
+)
+
+// Table of tag mappings
+func NewTagTable(tags []*tagservicepb.TagMapping) *Table {
+	table := &Table{Headers: []string{"NAME", "URI", "IP", "CHILDREN"}, WideHeaders: []string{"LABELS", "SELECTOR", "VERSION"}}
+	for _, tag := range tags {
+		labels := make([]string, 0, len(tag.Labels))
+		for key, value := range tag.Labels {
+			labels = append(labels, key+"="+value)
+		}
+		sort.Strings(labels)
+		table.AddRow(tag.Name, tag.GetUri(), tag.GetIp(), strings.Join(tag.ChildTags, ","), strings.Join(labels, ","), tag.GetSelector(), formatOptionalInt(tag.Version))
+	}
+	return table
+}
+
+// Table of permit list rules
+func NewRuleTable(rules []*paragliderpb.PermitListRule) *Table {
+	table := &Table{Headers: []string{"NAME", "DIRECTION", "PROTOCOL", "SRC PORT", "DST PORT", "TAGS"}, WideHeaders: []string{"TARGETS"}}
+	for _, rule := range rules {
+		table.AddRow(rule.Name, rule.Direction.String(), strconv.Itoa(int(rule.Protocol)), strconv.Itoa(int(rule.SrcPort)), strconv.Itoa(int(rule.DstPort)),
+			strings.Join(rule.Tags, ","), strings.Join(rule.Targets, ","))
+	}
+	return table
+}
+
+// Table of Paraglider resources
+func NewResourceTable(resources []*paragliderpb.Resource) *Table {
+	table := &Table{Headers: []string{"NAME", "TAG", "IP", "STATE", "REGION"}, WideHeaders: []string{"NETWORK", "SUBNET", "RULES", "URI"}}
+	for _, resource := range resources {
+		table.AddRow(resource.Name, resource.Tag, resource.Ip, resource.State, resource.Region, resource.Network, resource.Subnet, strconv.Itoa(int(resource.RuleCount)), resource.Uri)
+	}
+	return table
+}
+
+func formatOptionalInt(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(*value)
+}
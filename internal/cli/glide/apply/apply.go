@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apply
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	common "github.com/paraglider-project/paraglider/internal/cli/common"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/manifest"
+	"github.com/spf13/cobra"
+)
+
+func NewCommand() (*cobra.Command, *executor) {
+	executor := &executor{writer: os.Stdout, errWriter: os.Stderr, cliSettings: config.ActiveConfig.Settings}
+	cmd := &cobra.Command{
+		Use:     "apply -f <manifest file> [--prune]",
+		Short:   "Create or update the resources, tags and rules declared in a manifest",
+		Args:    cobra.NoArgs,
+		PreRunE: executor.Validate,
+		RunE:    executor.Execute,
+	}
+	cmd.Flags().StringP("file", "f", "", "The YAML or JSON manifest to apply")
+	cmd.Flags().Bool("prune", false, "Delete tags, tag members and rules which are not declared in the manifest")
+	cmd.MarkFlagRequired("file")
+	return cmd, executor
+}
+
+type executor struct {
+	common.CommandExecutor
+	writer      io.Writer
+	errWriter   io.Writer // Human-only notes, kept out of the json and yaml output
+	cliSettings config.CliSettings
+	manifest    *manifest.Manifest
+	prune       bool
+	output      string
+}
+
+func (e *executor) SetOutput(w io.Writer) {
+	e.writer = w
+}
+
+func (e *executor) Validate(cmd *cobra.Command, args []string) error {
+	file, err := cmd.Flags().GetString("file")
+	if err != nil {
+		return err
+	}
+	e.manifest, err = manifest.Load(file)
+	if err != nil {
+		return err
+	}
+	e.prune, err = cmd.Flags().GetBool("prune")
+	if err != nil {
+		return err
+	}
+	e.output, err = common.GetOutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *executor) Execute(cmd *cobra.Command, args []string) error {
+	c, err := e.cliSettings.NewClient()
+	if err != nil {
+		return err
+	}
+	ctx := common.GetContext(cmd)
+	plan, err := manifest.Diff(ctx, e.manifest, c, e.prune)
+	if err != nil {
+		return err
+	}
+
+	err = plan.Apply(ctx, c)
+	if err != nil {
+		return err
+	}
+	err = common.Print(e.writer, e.output, plan, plan.Table())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(e.errWriter, plan.Summary())
+	if len(plan.Changes) > 0 {
+		fmt.Fprintln(e.errWriter, "Applied.")
+	}
+	return nil
+}
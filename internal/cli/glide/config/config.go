@@ -17,15 +17,24 @@ limitations under the License.
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/paraglider-project/paraglider/pkg/client"
 )
 
 const (
 	DefaultConfigLocation = "/.paraglider/settings.json"
 	DefaultServerAddr     = "http://localhost:8080"
 	DefaultNamespace      = "default"
+	DefaultContext        = "default"
+
+	// Environment variable which overrides the current context
+	ContextEnvVar = "PARAGLIDER_CONTEXT"
 )
 
 var (
@@ -33,13 +42,37 @@ var (
 )
 
 type CliConfig struct {
+	// Settings of the context in use, which are written back to it by SaveActiveConfig
 	Settings CliSettings
 	Path     string
+	// Name of the context in use, which is the current context unless overridden by PARAGLIDER_CONTEXT or --context
+	Context        string
+	CurrentContext string
+	Contexts       []*Context
 }
 
 type CliSettings struct {
 	ServerAddr      string `json:"serverAddr"`
 	ActiveNamespace string `json:"activeNamespace"`
+	// Bearer token sent to the controller
+	Token string `json:"token,omitempty"`
+	// PEM files of the CA which signed the controller's certificate and of the client certificate and key
+	CACertFile            string `json:"caCertFile,omitempty"`
+	ClientCertFile        string `json:"clientCertFile,omitempty"`
+	ClientKeyFile         string `json:"clientKeyFile,omitempty"`
+	InsecureSkipTLSVerify bool   `json:"insecureSkipTLSVerify,omitempty"`
+}
+
+// A named set of settings for one controller (eg, dev, staging or prod)
+type Context struct {
+	Name string `json:"name"`
+	CliSettings
+}
+
+// Layout of the settings file
+type settingsFile struct {
+	CurrentContext string     `json:"currentContext"`
+	Contexts       []*Context `json:"contexts"`
 }
 
 func ReadOrCreateConfig() error {
@@ -48,11 +81,10 @@ func ReadOrCreateConfig() error {
 		return err
 	}
 	path := filepath.Join(homeDir, DefaultConfigLocation)
-	newConfig := &CliConfig{Path: path}
 
-	_, err = os.Stat(newConfig.Path)
+	_, err = os.Stat(path)
 	if os.IsNotExist(err) {
-		parentDir := filepath.Dir(newConfig.Path)
+		parentDir := filepath.Dir(path)
 		_, err := os.Stat(parentDir)
 		if os.IsNotExist(err) {
 			err := os.MkdirAll(parentDir, 0755)
@@ -60,33 +92,164 @@ func ReadOrCreateConfig() error {
 				return err
 			}
 		}
-		newConfig.Settings = CliSettings{ServerAddr: DefaultServerAddr, ActiveNamespace: DefaultNamespace}
-	} else {
-		data, err := os.ReadFile(newConfig.Path)
+	}
+
+	newConfig, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	if name := os.Getenv(ContextEnvVar); name != "" {
+		err = newConfig.UseContext(name)
 		if err != nil {
-			return err
+			return fmt.Errorf("%s: %w", ContextEnvVar, err)
 		}
+	}
+
+	ActiveConfig = newConfig
+	return nil
+}
 
-		err = json.Unmarshal(data, &newConfig.Settings)
+// Load the settings file at the given path, using the default settings if it does not exist
+// Settings files from before contexts were introduced are migrated to a single default context and rewritten
+func LoadConfig(path string) (*CliConfig, error) {
+	newConfig := &CliConfig{Path: path}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		newConfig.Contexts = []*Context{{Name: DefaultContext, CliSettings: CliSettings{ServerAddr: DefaultServerAddr, ActiveNamespace: DefaultNamespace}}}
+		newConfig.CurrentContext = DefaultContext
+		return newConfig, newConfig.UseContext(DefaultContext)
+	} else if err != nil {
+		return nil, err
+	}
+
+	file := &settingsFile{}
+	err = json.Unmarshal(data, file)
+	if err != nil {
+		return nil, err
+	}
+
+	migrate := len(file.Contexts) == 0
+	if migrate {
+		legacy := CliSettings{}
+		err = json.Unmarshal(data, &legacy)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		file.Contexts = []*Context{{Name: DefaultContext, CliSettings: legacy}}
+		file.CurrentContext = DefaultContext
 	}
 
-	ActiveConfig = newConfig
+	newConfig.CurrentContext = file.CurrentContext
+	newConfig.Contexts = file.Contexts
+	err = newConfig.UseContext(file.CurrentContext)
+	if err != nil {
+		return nil, err
+	}
+
+	if migrate {
+		err = newConfig.Save()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return newConfig, nil
+}
+
+// Get a context by name, or nil if there is none
+func (c *CliConfig) GetContext(name string) *Context {
+	for _, context := range c.Contexts {
+		if context.Name == name {
+			return context
+		}
+	}
 	return nil
 }
 
-func SaveActiveConfig() error {
-	data, err := json.Marshal(&ActiveConfig.Settings)
+// Use the settings of the named context for this invocation without changing the current context
+func (c *CliConfig) UseContext(name string) error {
+	context := c.GetContext(name)
+	if context == nil {
+		return fmt.Errorf("context %s does not exist", name)
+	}
+	c.Context = name
+	c.Settings = context.CliSettings
+	return nil
+}
+
+// Add a new context
+func (c *CliConfig) AddContext(context *Context) error {
+	if c.GetContext(context.Name) != nil {
+		return fmt.Errorf("context %s already exists", context.Name)
+	}
+	c.Contexts = append(c.Contexts, context)
+	return nil
+}
+
+// Delete a context other than the current one
+func (c *CliConfig) DeleteContext(name string) error {
+	if name == c.CurrentContext {
+		return fmt.Errorf("cannot delete the current context %s", name)
+	}
+	for i, context := range c.Contexts {
+		if context.Name == name {
+			c.Contexts = append(c.Contexts[:i], c.Contexts[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("context %s does not exist", name)
+}
+
+// Write the settings in use back to their context and save all contexts
+func (c *CliConfig) Save() error {
+	if context := c.GetContext(c.Context); context != nil {
+		context.CliSettings = c.Settings
+	}
+
+	data, err := json.MarshalIndent(&settingsFile{CurrentContext: c.CurrentContext, Contexts: c.Contexts}, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	err = os.WriteFile(ActiveConfig.Path, data, 0644)
+	// The settings may contain a token, so new settings files are only readable by the user
+	err = os.WriteFile(c.Path, data, 0600)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func SaveActiveConfig() error {
+	return ActiveConfig.Save()
+}
+
+// Create a client for the controller with the credentials and TLS settings
+func (s CliSettings) NewClient() (*client.Client, error) {
+	opts := []client.Option{}
+	if s.Token != "" {
+		opts = append(opts, client.WithToken(s.Token))
+	}
+	if s.CACertFile != "" || s.ClientCertFile != "" || s.InsecureSkipTLSVerify {
+		tlsConfig := &tls.Config{InsecureSkipVerify: s.InsecureSkipTLSVerify}
+		if s.CACertFile != "" {
+			caCert, err := os.ReadFile(s.CACertFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+				return nil, fmt.Errorf("no certificates found in %s", s.CACertFile)
+			}
+		}
+		if s.ClientCertFile != "" {
+			cert, err := tls.LoadX509KeyPair(s.ClientCertFile, s.ClientKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		opts = append(opts, client.WithTLSConfig(tlsConfig))
+	}
+	return client.NewClient(s.ServerAddr, opts...), nil
+}
@@ -0,0 +1,106 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigDefault(t *testing.T) {
+	cliConfig, err := LoadConfig(filepath.Join(t.TempDir(), "settings.json"))
+	require.Nil(t, err)
+
+	assert.Equal(t, DefaultContext, cliConfig.CurrentContext)
+	assert.Equal(t, DefaultContext, cliConfig.Context)
+	assert.Equal(t, CliSettings{ServerAddr: DefaultServerAddr, ActiveNamespace: DefaultNamespace}, cliConfig.Settings)
+}
+
+func TestLoadConfigMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	require.Nil(t, os.WriteFile(path, []byte(`{"serverAddr": "http://controller:8080", "activeNamespace": "ns"}`), 0644))
+
+	cliConfig, err := LoadConfig(path)
+	require.Nil(t, err)
+	assert.Equal(t, DefaultContext, cliConfig.CurrentContext)
+	assert.Equal(t, "http://controller:8080", cliConfig.Settings.ServerAddr)
+	assert.Equal(t, "ns", cliConfig.Settings.ActiveNamespace)
+
+	// The file is rewritten with contexts
+	cliConfig, err = LoadConfig(path)
+	require.Nil(t, err)
+	require.Len(t, cliConfig.Contexts, 1)
+	assert.Equal(t, "http://controller:8080", cliConfig.Contexts[0].ServerAddr)
+}
+
+func TestContexts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	cliConfig, err := LoadConfig(path)
+	require.Nil(t, err)
+
+	require.Nil(t, cliConfig.AddContext(&Context{Name: "prod", CliSettings: CliSettings{ServerAddr: "https://prod", ActiveNamespace: "ns"}}))
+	assert.NotNil(t, cliConfig.AddContext(&Context{Name: "prod"}))
+
+	// Using a context only changes the settings in use, which are saved back to it
+	require.Nil(t, cliConfig.UseContext("prod"))
+	assert.Equal(t, DefaultContext, cliConfig.CurrentContext)
+	assert.Equal(t, "https://prod", cliConfig.Settings.ServerAddr)
+	cliConfig.Settings.ActiveNamespace = "other"
+	require.Nil(t, cliConfig.Save())
+
+	cliConfig, err = LoadConfig(path)
+	require.Nil(t, err)
+	assert.Equal(t, DefaultServerAddr, cliConfig.Settings.ServerAddr)
+	assert.Equal(t, "other", cliConfig.GetContext("prod").ActiveNamespace)
+	assert.NotNil(t, cliConfig.UseContext("missing"))
+
+	// The current context cannot be deleted
+	assert.NotNil(t, cliConfig.DeleteContext(DefaultContext))
+	assert.NotNil(t, cliConfig.DeleteContext("missing"))
+	require.Nil(t, cliConfig.DeleteContext("prod"))
+	assert.Nil(t, cliConfig.GetContext("prod"))
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"name": "tag"}`))
+	}))
+	defer server.Close()
+
+	c, err := CliSettings{ServerAddr: server.URL, Token: "secret"}.NewClient()
+	require.Nil(t, err)
+	tag, err := c.GetTag(context.Background(), "tag")
+	require.Nil(t, err)
+	assert.Equal(t, "tag", tag.Name)
+
+	// Missing certificate files are reported
+	_, err = CliSettings{ServerAddr: server.URL, CACertFile: filepath.Join(t.TempDir(), "ca.pem")}.NewClient()
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package add
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	common "github.com/paraglider-project/paraglider/internal/cli/common"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
+	"github.com/spf13/cobra"
+)
+
+func NewCommand() (*cobra.Command, *executor) {
+	executor := &executor{writer: os.Stdout, cliConfig: config.ActiveConfig}
+	cmd := &cobra.Command{
+		Use:     "add <context name> [--server <address>] [--namespace <namespace>] [--token <token>] [--ca-cert <file>] [--client-cert <file> --client-key <file>] [--insecure-skip-tls-verify] [--use]",
+		Short:   "Add a context",
+		Args:    cobra.ExactArgs(1),
+		PreRunE: executor.Validate,
+		RunE:    executor.Execute,
+	}
+	cmd.Flags().String("server", config.DefaultServerAddr, "Address of the controller")
+	cmd.Flags().String("namespace", config.DefaultNamespace, "Active namespace")
+	cmd.Flags().String("token", "", "Bearer token to authenticate to the controller with")
+	cmd.Flags().String("ca-cert", "", "PEM file of the CA which signed the controller's certificate")
+	cmd.Flags().String("client-cert", "", "PEM file of the client certificate")
+	cmd.Flags().String("client-key", "", "PEM file of the client certificate's key")
+	cmd.Flags().Bool("insecure-skip-tls-verify", false, "Do not verify the controller's certificate")
+	cmd.Flags().Bool("use", false, "Make the new context the current context")
+	return cmd, executor
+}
+
+type executor struct {
+	common.CommandExecutor
+	writer    io.Writer
+	cliConfig *config.CliConfig
+	settings  config.CliSettings
+	use       bool
+}
+
+func (e *executor) SetOutput(w io.Writer) {
+	e.writer = w
+}
+
+func (e *executor) Validate(cmd *cobra.Command, args []string) error {
+	var err error
+	e.settings = config.CliSettings{}
+	e.settings.ServerAddr, err = cmd.Flags().GetString("server")
+	if err != nil {
+		return err
+	}
+	e.settings.ActiveNamespace, err = cmd.Flags().GetString("namespace")
+	if err != nil {
+		return err
+	}
+	e.settings.Token, err = cmd.Flags().GetString("token")
+	if err != nil {
+		return err
+	}
+	e.settings.CACertFile, err = cmd.Flags().GetString("ca-cert")
+	if err != nil {
+		return err
+	}
+	e.settings.ClientCertFile, err = cmd.Flags().GetString("client-cert")
+	if err != nil {
+		return err
+	}
+	e.settings.ClientKeyFile, err = cmd.Flags().GetString("client-key")
+	if err != nil {
+		return err
+	}
+	if (e.settings.ClientCertFile == "") != (e.settings.ClientKeyFile == "") {
+		return fmt.Errorf("--client-cert and --client-key must be set together")
+	}
+	e.settings.InsecureSkipTLSVerify, err = cmd.Flags().GetBool("insecure-skip-tls-verify")
+	if err != nil {
+		return err
+	}
+	e.use, err = cmd.Flags().GetBool("use")
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *executor) Execute(cmd *cobra.Command, args []string) error {
+	err := e.cliConfig.AddContext(&config.Context{Name: args[0], CliSettings: e.settings})
+	if err != nil {
+		return err
+	}
+	if e.use {
+		err = e.cliConfig.Save()
+		if err != nil {
+			return err
+		}
+		err = e.cliConfig.UseContext(args[0])
+		if err != nil {
+			return err
+		}
+		e.cliConfig.CurrentContext = args[0]
+	}
+	return e.cliConfig.Save()
+}
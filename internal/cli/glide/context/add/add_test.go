@@ -0,0 +1,69 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package add
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextAddValidate(t *testing.T) {
+	err := config.ReadOrCreateConfig()
+	assert.Nil(t, err)
+
+	cmd, executor := NewCommand()
+	require.Nil(t, cmd.Flags().Set("server", "https://prod"))
+	require.Nil(t, cmd.Flags().Set("token", "secret"))
+
+	err = executor.Validate(cmd, []string{"prod"})
+	assert.Nil(t, err)
+	assert.Equal(t, config.CliSettings{ServerAddr: "https://prod", ActiveNamespace: config.DefaultNamespace, Token: "secret"}, executor.settings)
+
+	// Client certificate without a key
+	require.Nil(t, cmd.Flags().Set("client-cert", "cert.pem"))
+	err = executor.Validate(cmd, []string{"prod"})
+	assert.NotNil(t, err)
+}
+
+func TestContextAddExecute(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	cliConfig, err := config.LoadConfig(path)
+	require.Nil(t, err)
+
+	cmd, executor := NewCommand()
+	executor.cliConfig = cliConfig
+	executor.settings = config.CliSettings{ServerAddr: "https://prod", ActiveNamespace: "ns"}
+	executor.use = true
+
+	err = executor.Execute(cmd, []string{"prod"})
+	assert.Nil(t, err)
+
+	cliConfig, err = config.LoadConfig(path)
+	require.Nil(t, err)
+	assert.Equal(t, "prod", cliConfig.CurrentContext)
+	assert.Equal(t, executor.settings, cliConfig.Settings)
+
+	// Existing context
+	err = executor.Execute(cmd, []string{"prod"})
+	assert.NotNil(t, err)
+}
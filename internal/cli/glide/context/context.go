@@ -0,0 +1,44 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"github.com/paraglider-project/paraglider/internal/cli/glide/context/add"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/context/delete"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/context/list"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/context/use"
+
+	"github.com/spf13/cobra"
+)
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "context",
+		Short: "Perform operations on the contexts (controller settings) of the CLI",
+	}
+
+	listCmd, _ := list.NewCommand()
+	cmd.AddCommand(listCmd)
+	useCmd, _ := use.NewCommand()
+	cmd.AddCommand(useCmd)
+	addCmd, _ := add.NewCommand()
+	cmd.AddCommand(addCmd)
+	deleteCmd, _ := delete.NewCommand()
+	cmd.AddCommand(deleteCmd)
+
+	return cmd
+}
@@ -0,0 +1,49 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package delete
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextDeleteExecute(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	cliConfig, err := config.LoadConfig(path)
+	require.Nil(t, err)
+	require.Nil(t, cliConfig.AddContext(&config.Context{Name: "prod"}))
+
+	cmd, executor := NewCommand()
+	executor.cliConfig = cliConfig
+
+	err = executor.Execute(cmd, []string{"prod"})
+	assert.Nil(t, err)
+
+	cliConfig, err = config.LoadConfig(path)
+	require.Nil(t, err)
+	assert.Nil(t, cliConfig.GetContext("prod"))
+
+	// Current context
+	err = executor.Execute(cmd, []string{config.DefaultContext})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package list
+
+import (
+	"io"
+	"os"
+	"strconv"
+
+	common "github.com/paraglider-project/paraglider/internal/cli/common"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
+	"github.com/spf13/cobra"
+)
+
+// Context as printed, leaving out the token
+type contextOutput struct {
+	Name                  string `json:"name"`
+	ServerAddr            string `json:"serverAddr"`
+	ActiveNamespace       string `json:"activeNamespace"`
+	CACertFile            string `json:"caCertFile"`
+	ClientCertFile        string `json:"clientCertFile"`
+	InsecureSkipTLSVerify bool   `json:"insecureSkipTLSVerify"`
+	Current               bool   `json:"current"`
+}
+
+func NewCommand() (*cobra.Command, *executor) {
+	executor := &executor{writer: os.Stdout, cliConfig: config.ActiveConfig}
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the contexts",
+		Args:    cobra.NoArgs,
+		PreRunE: executor.Validate,
+		RunE:    executor.Execute,
+	}
+	return cmd, executor
+}
+
+type executor struct {
+	common.CommandExecutor
+	writer    io.Writer
+	cliConfig *config.CliConfig
+	output    string
+}
+
+func (e *executor) SetOutput(w io.Writer) {
+	e.writer = w
+}
+
+func (e *executor) Validate(cmd *cobra.Command, args []string) error {
+	var err error
+	e.output, err = common.GetOutputFormat(cmd)
+	return err
+}
+
+func (e *executor) Execute(cmd *cobra.Command, args []string) error {
+	output := make([]contextOutput, 0, len(e.cliConfig.Contexts))
+	table := &common.Table{Headers: []string{"CURRENT", "NAME", "SERVER", "NAMESPACE"}, WideHeaders: []string{"CA CERT", "CLIENT CERT", "INSECURE SKIP TLS VERIFY"}}
+	for _, context := range e.cliConfig.Contexts {
+		current := context.Name == e.cliConfig.CurrentContext
+		output = append(output, contextOutput{
+			Name:                  context.Name,
+			ServerAddr:            context.ServerAddr,
+			ActiveNamespace:       context.ActiveNamespace,
+			CACertFile:            context.CACertFile,
+			ClientCertFile:        context.ClientCertFile,
+			InsecureSkipTLSVerify: context.InsecureSkipTLSVerify,
+			Current:               current,
+		})
+		marker := ""
+		if current {
+			marker = "*"
+		}
+		table.AddRow(marker, context.Name, context.ServerAddr, context.ActiveNamespace, context.CACertFile, context.ClientCertFile, strconv.FormatBool(context.InsecureSkipTLSVerify))
+	}
+	return common.Print(e.writer, e.output, output, table)
+}
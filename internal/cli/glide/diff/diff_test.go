@@ -0,0 +1,89 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
+	fake "github.com/paraglider-project/paraglider/pkg/fake/orchestrator/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exampleManifest = `
+tags:
+  - name: tag1
+    members: [member1]
+`
+
+func TestDiffValidate(t *testing.T) {
+	err := config.ReadOrCreateConfig()
+	assert.Nil(t, err)
+
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	require.Nil(t, os.WriteFile(path, []byte(exampleManifest), 0644))
+
+	cmd, executor := NewCommand()
+	require.Nil(t, cmd.Flags().Set("file", path))
+	err = executor.Validate(cmd, []string{})
+
+	assert.Nil(t, err)
+	assert.False(t, executor.prune)
+	assert.Equal(t, []string{"member1"}, executor.manifest.Tags[0].Members)
+}
+
+func TestDiffExecute(t *testing.T) {
+	server := &fake.FakeOrchestratorRESTServer{}
+	serverAddr := server.SetupFakeOrchestratorRESTServer()
+
+	err := config.ReadOrCreateConfig()
+	assert.Nil(t, err)
+
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	require.Nil(t, os.WriteFile(path, []byte(exampleManifest), 0644))
+
+	cmd, executor := NewCommand()
+	executor.cliSettings = config.CliSettings{ServerAddr: serverAddr, ActiveNamespace: fake.Namespace}
+	require.Nil(t, cmd.Flags().Set("file", path))
+	require.Nil(t, executor.Validate(cmd, []string{}))
+
+	var output, notes bytes.Buffer
+	executor.writer = &output
+	executor.errWriter = &notes
+
+	// Nothing is missing
+	err = executor.Execute(cmd, []string{})
+	assert.Nil(t, err)
+	assert.Equal(t, "ACTION  KIND  NAME  DETAIL\n", output.String())
+	assert.Equal(t, "No changes.\n", notes.String())
+
+	// Pruning removes the undeclared member and the tag's rule
+	executor.prune = true
+	output.Reset()
+	notes.Reset()
+	err = executor.Execute(cmd, []string{})
+	assert.Nil(t, err)
+	assert.Regexp(t, `(?m)^- +member +member2 +tag tag1 *$`, output.String())
+	assert.Regexp(t, `(?m)^- +rule +name +tag tag1 *$`, output.String())
+	assert.Equal(t, "0 to create, 0 to update, 2 to delete.\n", notes.String())
+}
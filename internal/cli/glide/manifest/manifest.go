@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"sigs.k8s.io/yaml"
+)
+
+// Declared state of the resources, tags and permit lists managed by glide apply
+type Manifest struct {
+	Namespaces []*Namespace `json:"namespaces,omitempty"`
+	Tags       []*Tag       `json:"tags,omitempty"`
+}
+
+type Namespace struct {
+	Name      string      `json:"name"`
+	Resources []*Resource `json:"resources,omitempty"`
+}
+
+// A resource and its permit list
+// The description is the same JSON as for glide resource create, either inline or in a file relative to the manifest
+type Resource struct {
+	Cloud           string                         `json:"cloud"`
+	Name            string                         `json:"name"`
+	Description     json.RawMessage                `json:"description,omitempty"`
+	DescriptionFile string                         `json:"description_file,omitempty"`
+	Rules           []*paragliderpb.PermitListRule `json:"rules,omitempty"`
+}
+
+// A tag and the rules attached to it
+type Tag struct {
+	Name     string                         `json:"name"`
+	Members  []string                       `json:"members,omitempty"`
+	Uri      *string                        `json:"uri,omitempty"`
+	Ip       *string                        `json:"ip,omitempty"`
+	Labels   map[string]string              `json:"labels,omitempty"`
+	Selector *string                        `json:"selector,omitempty"`
+	Rules    []*paragliderpb.PermitListRule `json:"rules,omitempty"`
+}
+
+// Read a YAML or JSON manifest
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	err = yaml.UnmarshalStrict(data, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse manifest %s: %w", path, err)
+	}
+
+	// Inline the descriptions kept in separate files
+	for _, namespace := range manifest.Namespaces {
+		for _, resource := range namespace.Resources {
+			if resource.DescriptionFile == "" {
+				continue
+			}
+			descriptionPath := resource.DescriptionFile
+			if !filepath.IsAbs(descriptionPath) {
+				descriptionPath = filepath.Join(filepath.Dir(path), descriptionPath)
+			}
+			resource.Description, err = os.ReadFile(descriptionPath)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = manifest.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// Check that every object is named once and that resources have a description
+func (m *Manifest) Validate() error {
+	seen := map[string]bool{}
+	for _, namespace := range m.Namespaces {
+		if namespace.Name == "" {
+			return fmt.Errorf("namespace without a name")
+		}
+		for _, resource := range namespace.Resources {
+			if resource.Cloud == "" || resource.Name == "" {
+				return fmt.Errorf("resource in namespace %s without a cloud or name", namespace.Name)
+			}
+			name := resourceTagName(namespace.Name, resource)
+			if seen[name] {
+				return fmt.Errorf("resource %s is declared more than once", name)
+			}
+			seen[name] = true
+			if len(resource.Description) == 0 {
+				return fmt.Errorf("resource %s has no description", name)
+			}
+			if err := validateRules(resource.Rules, "resource "+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, tag := range m.Tags {
+		if tag.Name == "" {
+			return fmt.Errorf("tag without a name")
+		}
+		if seen[tag.Name] {
+			return fmt.Errorf("tag %s is declared more than once or is a resource", tag.Name)
+		}
+		seen[tag.Name] = true
+		if err := validateRules(tag.Rules, "tag "+tag.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateRules(rules []*paragliderpb.PermitListRule, owner string) error {
+	names := map[string]bool{}
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("%s has a rule without a name", owner)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("%s has more than one rule named %s", owner, rule.Name)
+		}
+		names[rule.Name] = true
+	}
+	return nil
+}
+
+// Name of the tag the controller creates for a resource
+func resourceTagName(namespace string, resource *Resource) string {
+	return namespace + "." + resource.Cloud + "." + resource.Name
+}
@@ -0,0 +1,174 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	common "github.com/paraglider-project/paraglider/internal/cli/common"
+	"github.com/paraglider-project/paraglider/pkg/client"
+	fake "github.com/paraglider-project/paraglider/pkg/fake/orchestrator/rest"
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exampleManifest = `
+namespaces:
+  - name: fakenamespace
+    resources:
+      - cloud: fakecloud
+        name: vm1
+        description_file: vm1.json
+        rules:
+          - name: ssh
+            tags: [tag1]
+            protocol: 6
+            dst_port: 22
+            src_port: -1
+tags:
+  - name: tag1
+    members: [member1, fakenamespace.fakecloud.vm1]
+    rules:
+      - name: name
+        tags: [tag1, tag2]
+        direction: 0
+        src_port: 1
+        dst_port: 1
+        protocol: 1
+      - name: new
+        tags: [tag2]
+        protocol: 1
+`
+
+func writeManifest(t *testing.T, manifest string) string {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "vm1.json"), []byte(`{"name": "vm1"}`), 0644))
+	path := filepath.Join(dir, "manifest.yaml")
+	require.Nil(t, os.WriteFile(path, []byte(manifest), 0644))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	m, err := Load(writeManifest(t, exampleManifest))
+	require.Nil(t, err)
+	require.Len(t, m.Namespaces, 1)
+	assert.JSONEq(t, `{"name": "vm1"}`, string(m.Namespaces[0].Resources[0].Description))
+	assert.Equal(t, int32(22), m.Namespaces[0].Resources[0].Rules[0].DstPort)
+	assert.Len(t, m.Tags[0].Members, 2)
+
+	// Unknown fields are rejected
+	_, err = Load(writeManifest(t, "tags:\n  - name: a\n    children: [b]\n"))
+	assert.NotNil(t, err)
+
+	// Duplicate names
+	_, err = Load(writeManifest(t, "tags:\n  - name: a\n  - name: a\n"))
+	assert.NotNil(t, err)
+
+	// Missing description
+	_, err = Load(writeManifest(t, "namespaces:\n  - name: default\n    resources:\n      - cloud: gcp\n        name: vm\n"))
+	assert.NotNil(t, err)
+}
+
+func TestSortTags(t *testing.T) {
+	sorted, err := sortTags([]*Tag{{Name: "a", Members: []string{"b"}}, {Name: "b", Members: []string{"c"}}, {Name: "c"}})
+	require.Nil(t, err)
+	assert.Equal(t, "c", sorted[0].Name)
+	assert.Equal(t, "b", sorted[1].Name)
+	assert.Equal(t, "a", sorted[2].Name)
+
+	_, err = sortTags([]*Tag{{Name: "a", Members: []string{"b"}}, {Name: "b", Members: []string{"a"}}})
+	assert.NotNil(t, err)
+}
+
+func TestRulesEqual(t *testing.T) {
+	a := &paragliderpb.PermitListRule{Name: "a", Tags: []string{"x", "y"}, Targets: []string{"1.1.1.1"}, Protocol: 6}
+	b := &paragliderpb.PermitListRule{Name: "a", Tags: []string{"y", "x"}, Protocol: 6}
+	assert.True(t, rulesEqual(a, b))
+
+	b.Protocol = 17
+	assert.False(t, rulesEqual(a, b))
+	assert.Equal(t, []string{"x", "y"}, a.Tags)
+}
+
+func TestDiff(t *testing.T) {
+	s := fake.FakeOrchestratorRESTServer{}
+	c := client.NewClient(s.SetupFakeOrchestratorRESTServer())
+	m, err := Load(writeManifest(t, exampleManifest))
+	require.Nil(t, err)
+
+	plan, err := Diff(context.Background(), m, c, false)
+	require.Nil(t, err)
+	changes := []string{}
+	for _, change := range plan.Changes {
+		changes = append(changes, change.String())
+	}
+	assert.Equal(t, []string{
+		"+ resource fakenamespace.fakecloud.vm1",
+		"~ tag tag1 (members +fakenamespace.fakecloud.vm1)",
+		"+ rule ssh (resource fakenamespace.fakecloud.vm1)",
+		"+ rule new (tag tag1)",
+	}, changes)
+
+	// Pruning also removes the undeclared member and tag
+	plan, err = Diff(context.Background(), m, c, true)
+	require.Nil(t, err)
+	changes = []string{}
+	for _, change := range plan.Changes[4:] {
+		changes = append(changes, change.String())
+	}
+	assert.Equal(t, []string{"- member member2 (tag tag1)"}, changes)
+
+	m.Tags = nil
+	plan, err = Diff(context.Background(), m, c, true)
+	require.Nil(t, err)
+	changes = []string{}
+	for _, change := range plan.Changes {
+		changes = append(changes, change.String())
+	}
+	assert.Equal(t, []string{
+		"+ resource fakenamespace.fakecloud.vm1",
+		"+ rule ssh (resource fakenamespace.fakecloud.vm1)",
+		"- rule name (tag tag1)",
+		"- tag tag1",
+	}, changes)
+}
+
+func TestPlanPrintAndApply(t *testing.T) {
+	s := fake.FakeOrchestratorRESTServer{}
+	c := client.NewClient(s.SetupFakeOrchestratorRESTServer())
+	m, err := Load(writeManifest(t, exampleManifest))
+	require.Nil(t, err)
+
+	plan, err := Diff(context.Background(), m, c, true)
+	require.Nil(t, err)
+
+	var output bytes.Buffer
+	require.Nil(t, common.Print(&output, common.OutputTable, plan, plan.Table()))
+	assert.Regexp(t, `(?m)^\+ +resource +fakenamespace\.fakecloud\.vm1 *$`, output.String())
+	assert.Equal(t, "3 to create, 1 to update, 1 to delete.", plan.Summary())
+
+	assert.Nil(t, plan.Apply(context.Background(), c))
+
+	assert.Equal(t, "No changes.", (&Plan{}).Summary())
+}
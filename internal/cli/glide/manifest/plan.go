@@ -0,0 +1,400 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	common "github.com/paraglider-project/paraglider/internal/cli/common"
+	"github.com/paraglider-project/paraglider/pkg/client"
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
+	"google.golang.org/protobuf/proto"
+)
+
+type Action string
+
+const (
+	ActionCreate Action = "+"
+	ActionUpdate Action = "~"
+	ActionDelete Action = "-"
+)
+
+// A single change needed to bring the controller to the declared state
+type Change struct {
+	Action Action `json:"action"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Detail string `json:"detail"`
+	apply  func(ctx context.Context, c *client.Client) error
+}
+
+func (ch *Change) String() string {
+	if ch.Detail == "" {
+		return fmt.Sprintf("%s %s %s", ch.Action, ch.Kind, ch.Name)
+	}
+	return fmt.Sprintf("%s %s %s (%s)", ch.Action, ch.Kind, ch.Name, ch.Detail)
+}
+
+// Changes in the order they are applied: resources, then tags, then rules, followed by any deletions in reverse
+type Plan struct {
+	Changes []*Change `json:"changes"`
+}
+
+func (p *Plan) count(action Action) int {
+	n := 0
+	for _, change := range p.Changes {
+		if change.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Get the changes as a table for common.Print
+func (p *Plan) Table() *common.Table {
+	table := &common.Table{Headers: []string{"ACTION", "KIND", "NAME", "DETAIL"}}
+	for _, change := range p.Changes {
+		table.AddRow(string(change.Action), change.Kind, change.Name, change.Detail)
+	}
+	return table
+}
+
+// Get a one line summary of the changes for humans
+func (p *Plan) Summary() string {
+	if len(p.Changes) == 0 {
+		return "No changes."
+	}
+	return fmt.Sprintf("%d to create, %d to update, %d to delete.", p.count(ActionCreate), p.count(ActionUpdate), p.count(ActionDelete))
+}
+
+// Apply the changes in order, stopping at the first error
+func (p *Plan) Apply(ctx context.Context, c *client.Client) error {
+	for _, change := range p.Changes {
+		if err := change.apply(ctx, c); err != nil {
+			return fmt.Errorf("could not apply %s: %w", change.String(), err)
+		}
+	}
+	return nil
+}
+
+// Compute the changes between the controller's current state and the manifest
+// Without prune, only missing or changed objects are written and nothing is deleted
+func Diff(ctx context.Context, m *Manifest, c *client.Client, prune bool) (*Plan, error) {
+	state, err := c.ExportSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]*tagservicepb.TagMapping, len(state.Tags))
+	for _, tag := range state.Tags {
+		tags[tag.Name] = tag
+	}
+	tagRules := map[string][]*paragliderpb.PermitListRule{}
+	for _, rules := range state.TagRules {
+		tagRules[rules.Tag] = rules.Rules
+	}
+	resourceRules := map[string][]*paragliderpb.PermitListRule{}
+	for _, rules := range state.ResourceRules {
+		resourceRules[rules.Namespace+"."+rules.Cloud+"."+rules.Name] = rules.Rules
+	}
+
+	creates := []*Change{}
+	deletes := []*Change{}
+	declared := map[string]bool{}
+
+	// Resources are created if missing, but existing resources are not modified
+	for _, namespace := range m.Namespaces {
+		for _, resource := range namespace.Resources {
+			name := resourceTagName(namespace.Name, resource)
+			declared[name] = true
+			if _, ok := tags[name]; !ok {
+				creates = append(creates, createResourceChange(namespace.Name, resource))
+			}
+		}
+	}
+
+	// Tags are written children first
+	sortedTags, err := sortTags(m.Tags)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range sortedTags {
+		declared[tag.Name] = true
+		current, ok := tags[tag.Name]
+		if !ok {
+			creates = append(creates, setTagChange(ActionCreate, tag, tag.Members, ""))
+			continue
+		}
+		added, removed := diffMembers(current.ChildTags, tag.Members)
+		if details := tagDetails(current, tag, added); len(details) > 0 {
+			creates = append(creates, setTagChange(ActionUpdate, tag, added, strings.Join(details, "; ")))
+		}
+		if prune {
+			for _, member := range removed {
+				deletes = append(deletes, deleteMemberChange(tag.Name, member))
+			}
+		}
+	}
+
+	// Rules
+	for _, namespace := range m.Namespaces {
+		for _, resource := range namespace.Resources {
+			name := resourceTagName(namespace.Name, resource)
+			changes, ruleDeletes := diffRules(resourceRules[name], resource.Rules, prune, "resource "+name, resourceRuleWriter(namespace.Name, resource))
+			creates = append(creates, changes...)
+			deletes = append(deletes, ruleDeletes...)
+		}
+	}
+	for _, tag := range sortedTags {
+		changes, ruleDeletes := diffRules(tagRules[tag.Name], tag.Rules, prune, "tag "+tag.Name, tagRuleWriter(tag.Name))
+		creates = append(creates, changes...)
+		deletes = append(deletes, ruleDeletes...)
+	}
+
+	if !prune {
+		return &Plan{Changes: creates}, nil
+	}
+
+	// Rules on undeclared resources and tags
+	for _, rules := range state.ResourceRules {
+		name := rules.Namespace + "." + rules.Cloud + "." + rules.Name
+		if declared[name] {
+			continue
+		}
+		resource := &Resource{Cloud: rules.Cloud, Name: rules.Name}
+		_, ruleDeletes := diffRules(rules.Rules, nil, prune, "resource "+name, resourceRuleWriter(rules.Namespace, resource))
+		deletes = append(deletes, ruleDeletes...)
+	}
+	for _, rules := range state.TagRules {
+		if declared[rules.Tag] {
+			continue
+		}
+		_, ruleDeletes := diffRules(rules.Rules, nil, prune, "tag "+rules.Tag, tagRuleWriter(rules.Tag))
+		deletes = append(deletes, ruleDeletes...)
+	}
+
+	// Undeclared tags, except those of resources since resources cannot be deleted
+	for _, tag := range state.Tags {
+		if declared[tag.Name] || tag.Uri != nil {
+			continue
+		}
+		deletes = append(deletes, deleteTagChange(tag.Name))
+	}
+
+	// Rules are removed before members, and members before tags
+	sort.SliceStable(deletes, func(i, j int) bool { return deleteOrder(deletes[i]) < deleteOrder(deletes[j]) })
+	return &Plan{Changes: append(creates, deletes...)}, nil
+}
+
+func deleteOrder(change *Change) int {
+	switch change.Kind {
+	case "rule":
+		return 0
+	case "member":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Order tags so that members declared in the manifest come before the tags containing them
+func sortTags(tags []*Tag) ([]*Tag, error) {
+	byName := make(map[string]*Tag, len(tags))
+	for _, tag := range tags {
+		byName[tag.Name] = tag
+	}
+
+	sorted := make([]*Tag, 0, len(tags))
+	state := map[string]int{} // 1 while visiting, 2 once sorted
+	var visit func(tag *Tag) error
+	visit = func(tag *Tag) error {
+		switch state[tag.Name] {
+		case 1:
+			return fmt.Errorf("tag %s contains itself", tag.Name)
+		case 2:
+			return nil
+		}
+		state[tag.Name] = 1
+		for _, member := range tag.Members {
+			if child, ok := byName[member]; ok {
+				if err := visit(child); err != nil {
+					return err
+				}
+			}
+		}
+		state[tag.Name] = 2
+		sorted = append(sorted, tag)
+		return nil
+	}
+	for _, tag := range tags {
+		if err := visit(tag); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// Members which are declared but missing, and members which exist but are not declared
+func diffMembers(current []string, declared []string) ([]string, []string) {
+	currentSet := map[string]bool{}
+	for _, member := range current {
+		currentSet[member] = true
+	}
+	declaredSet := map[string]bool{}
+	added := []string{}
+	for _, member := range declared {
+		declaredSet[member] = true
+		if !currentSet[member] {
+			added = append(added, member)
+		}
+	}
+	removed := []string{}
+	for _, member := range current {
+		if !declaredSet[member] {
+			removed = append(removed, member)
+		}
+	}
+	return added, removed
+}
+
+// Describe what needs to be written to an existing tag
+// Labels are only compared when some are declared since setting a tag cannot remove them
+func tagDetails(current *tagservicepb.TagMapping, tag *Tag, added []string) []string {
+	details := []string{}
+	if len(added) > 0 {
+		details = append(details, "members +"+strings.Join(added, ",+"))
+	}
+	if tag.Uri != nil && current.GetUri() != *tag.Uri {
+		details = append(details, "uri "+*tag.Uri)
+	}
+	if tag.Ip != nil && current.GetIp() != *tag.Ip {
+		details = append(details, "ip "+*tag.Ip)
+	}
+	if len(tag.Labels) > 0 && !reflect.DeepEqual(current.Labels, tag.Labels) {
+		details = append(details, "labels")
+	}
+	if tag.Selector != nil && current.GetSelector() != *tag.Selector {
+		details = append(details, "selector "+*tag.Selector)
+	}
+	return details
+}
+
+// Rules which are missing or changed, and rules which are not declared (when pruning)
+func diffRules(current []*paragliderpb.PermitListRule, declared []*paragliderpb.PermitListRule, prune bool, owner string, writer ruleWriter) ([]*Change, []*Change) {
+	currentByName := make(map[string]*paragliderpb.PermitListRule, len(current))
+	for _, rule := range current {
+		currentByName[rule.Name] = rule
+	}
+	declaredNames := map[string]bool{}
+	changes := []*Change{}
+	for _, rule := range declared {
+		declaredNames[rule.Name] = true
+		existing, ok := currentByName[rule.Name]
+		switch {
+		case !ok:
+			changes = append(changes, writer.add(ActionCreate, rule, owner))
+		case !rulesEqual(existing, rule):
+			changes = append(changes, writer.add(ActionUpdate, rule, owner))
+		}
+	}
+
+	deletes := []*Change{}
+	if prune {
+		for _, rule := range current {
+			if !declaredNames[rule.Name] {
+				deletes = append(deletes, writer.delete(rule.Name, owner))
+			}
+		}
+	}
+	return changes, deletes
+}
+
+// Compare rules without the targets their tags resolve to
+func rulesEqual(a *paragliderpb.PermitListRule, b *paragliderpb.PermitListRule) bool {
+	a = proto.Clone(a).(*paragliderpb.PermitListRule)
+	b = proto.Clone(b).(*paragliderpb.PermitListRule)
+	a.Targets, b.Targets = nil, nil
+	sort.Strings(a.Tags)
+	sort.Strings(b.Tags)
+	return proto.Equal(a, b)
+}
+
+type ruleWriter struct {
+	add    func(action Action, rule *paragliderpb.PermitListRule, owner string) *Change
+	delete func(ruleName string, owner string) *Change
+}
+
+func resourceRuleWriter(namespace string, resource *Resource) ruleWriter {
+	return ruleWriter{
+		add: func(action Action, rule *paragliderpb.PermitListRule, owner string) *Change {
+			return &Change{Action: action, Kind: "rule", Name: rule.Name, Detail: owner, apply: func(ctx context.Context, c *client.Client) error {
+				return c.AddPermitListRules(ctx, namespace, resource.Cloud, resource.Name, []*paragliderpb.PermitListRule{rule})
+			}}
+		},
+		delete: func(ruleName string, owner string) *Change {
+			return &Change{Action: ActionDelete, Kind: "rule", Name: ruleName, Detail: owner, apply: func(ctx context.Context, c *client.Client) error {
+				return c.DeletePermitListRules(ctx, namespace, resource.Cloud, resource.Name, []string{ruleName})
+			}}
+		},
+	}
+}
+
+func tagRuleWriter(tagName string) ruleWriter {
+	return ruleWriter{
+		add: func(action Action, rule *paragliderpb.PermitListRule, owner string) *Change {
+			return &Change{Action: action, Kind: "rule", Name: rule.Name, Detail: owner, apply: func(ctx context.Context, c *client.Client) error {
+				return c.AddPermitListRulesTag(ctx, tagName, []*paragliderpb.PermitListRule{rule})
+			}}
+		},
+		delete: func(ruleName string, owner string) *Change {
+			return &Change{Action: ActionDelete, Kind: "rule", Name: ruleName, Detail: owner, apply: func(ctx context.Context, c *client.Client) error {
+				return c.DeletePermitListRulesTag(ctx, tagName, []string{ruleName})
+			}}
+		},
+	}
+}
+
+func createResourceChange(namespace string, resource *Resource) *Change {
+	return &Change{Action: ActionCreate, Kind: "resource", Name: resourceTagName(namespace, resource), apply: func(ctx context.Context, c *client.Client) error {
+		_, err := c.CreateResource(ctx, namespace, resource.Cloud, resource.Name, &paragliderpb.ResourceDescriptionString{Description: string(resource.Description)})
+		return err
+	}}
+}
+
+func setTagChange(action Action, tag *Tag, members []string, detail string) *Change {
+	mapping := &tagservicepb.TagMapping{Name: tag.Name, ChildTags: members, Uri: tag.Uri, Ip: tag.Ip, Labels: tag.Labels, Selector: tag.Selector}
+	return &Change{Action: action, Kind: "tag", Name: tag.Name, Detail: detail, apply: func(ctx context.Context, c *client.Client) error {
+		return c.SetTag(ctx, tag.Name, mapping)
+	}}
+}
+
+func deleteMemberChange(tagName string, member string) *Change {
+	return &Change{Action: ActionDelete, Kind: "member", Name: member, Detail: "tag " + tagName, apply: func(ctx context.Context, c *client.Client) error {
+		return c.DeleteTagMembers(ctx, tagName, member)
+	}}
+}
+
+func deleteTagChange(tagName string) *Change {
+	return &Change{Action: ActionDelete, Kind: "tag", Name: tagName, apply: func(ctx context.Context, c *client.Client) error {
+		return c.DeleteTag(ctx, tagName)
+	}}
+}
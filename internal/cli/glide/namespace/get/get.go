@@ -17,7 +17,6 @@ limitations under the License.
 package get
 
 import (
-	"fmt"
 	"io"
 	"os"
 
@@ -42,6 +41,7 @@ type executor struct {
 	common.CommandExecutor
 	writer      io.Writer
 	cliSettings config.CliSettings
+	output      string
 }
 
 func (e *executor) SetOutput(w io.Writer) {
@@ -49,11 +49,13 @@ func (e *executor) SetOutput(w io.Writer) {
 }
 
 func (e *executor) Validate(cmd *cobra.Command, args []string) error {
-	return nil
+	var err error
+	e.output, err = common.GetOutputFormat(cmd)
+	return err
 }
 
 func (e *executor) Execute(cmd *cobra.Command, args []string) error {
-	fmt.Fprintf(e.writer, "Active namespace: %s\n", e.cliSettings.ActiveNamespace)
-
-	return nil
+	table := &common.Table{Headers: []string{"NAMESPACE"}}
+	table.AddRow(e.cliSettings.ActiveNamespace)
+	return common.Print(e.writer, e.output, map[string]string{"namespace": e.cliSettings.ActiveNamespace}, table)
 }
@@ -17,16 +17,25 @@ limitations under the License.
 package list
 
 import (
-	"fmt"
 	"io"
 	"os"
+	"sort"
 
 	common "github.com/paraglider-project/paraglider/internal/cli/common"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
-	"github.com/paraglider-project/paraglider/pkg/client"
 	"github.com/spf13/cobra"
 )
 
+type cloudOutput struct {
+	Name       string `json:"name"`
+	Deployment string `json:"deployment"`
+}
+
+type namespaceOutput struct {
+	Name   string        `json:"name"`
+	Clouds []cloudOutput `json:"clouds"`
+}
+
 func NewCommand() (*cobra.Command, *executor) {
 	executor := &executor{writer: os.Stdout, cliSettings: config.ActiveConfig.Settings}
 	cmd := &cobra.Command{
@@ -43,6 +52,7 @@ type executor struct {
 	common.CommandExecutor
 	writer      io.Writer
 	cliSettings config.CliSettings
+	output      string
 }
 
 func (e *executor) SetOutput(w io.Writer) {
@@ -50,18 +60,39 @@ func (e *executor) SetOutput(w io.Writer) {
 }
 
 func (e *executor) Validate(cmd *cobra.Command, args []string) error {
-	return nil
+	var err error
+	e.output, err = common.GetOutputFormat(cmd)
+	return err
 }
 
 func (e *executor) Execute(cmd *cobra.Command, args []string) error {
-	c := client.Client{ControllerAddress: e.cliSettings.ServerAddr}
-	namespaces, err := c.ListNamespaces()
+	c, err := e.cliSettings.NewClient()
+	if err != nil {
+		return err
+	}
+	ctx := common.GetContext(cmd)
+	namespaces, err := c.ListNamespaces(ctx)
 
 	if err != nil {
 		return err
 	}
 
-	fmt.Fprintf(e.writer, "Namespaces: %v", namespaces)
+	// Print namespaces in name order, with one row per cloud in the table formats
+	names := make([]string, 0, len(namespaces))
+	for name := range namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	return nil
+	output := make([]namespaceOutput, 0, len(names))
+	table := &common.Table{Headers: []string{"NAMESPACE", "CLOUD", "DEPLOYMENT"}}
+	for _, name := range names {
+		namespace := namespaceOutput{Name: name, Clouds: make([]cloudOutput, 0, len(namespaces[name]))}
+		for _, cloud := range namespaces[name] {
+			namespace.Clouds = append(namespace.Clouds, cloudOutput{Name: cloud.Name, Deployment: cloud.Deployment})
+			table.AddRow(name, cloud.Name, cloud.Deployment)
+		}
+		output = append(output, namespace)
+	}
+	return common.Print(e.writer, e.output, output, table)
 }
@@ -23,7 +23,6 @@ import (
 
 	common "github.com/paraglider-project/paraglider/internal/cli/common"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
-	"github.com/paraglider-project/paraglider/pkg/client"
 	"github.com/spf13/cobra"
 )
 
@@ -51,8 +50,12 @@ func (e *executor) SetOutput(w io.Writer) {
 
 func (e *executor) Validate(cmd *cobra.Command, args []string) error {
 	// Get all namespaces from the orchestrator and confirm that the given string is one of them
-	c := &client.Client{ControllerAddress: e.cliSettings.ServerAddr}
-	namespaces, err := c.ListNamespaces()
+	c, err := e.cliSettings.NewClient()
+	if err != nil {
+		return err
+	}
+	ctx := common.GetContext(cmd)
+	namespaces, err := c.ListNamespaces(ctx)
 
 	if err != nil {
 		return err
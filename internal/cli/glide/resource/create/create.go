@@ -23,7 +23,6 @@ import (
 
 	common "github.com/paraglider-project/paraglider/internal/cli/common"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
-	"github.com/paraglider-project/paraglider/pkg/client"
 	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
 	"github.com/spf13/cobra"
 )
@@ -47,6 +46,7 @@ type executor struct {
 	cliSettings config.CliSettings
 	description []byte
 	uri         string
+	output      string
 }
 
 func (e *executor) SetOutput(w io.Writer) {
@@ -69,21 +69,27 @@ func (e *executor) Validate(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	e.output, err = common.GetOutputFormat(cmd)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
 func (e *executor) Execute(cmd *cobra.Command, args []string) error {
 	resource := &paragliderpb.ResourceDescriptionString{Description: string(e.description)}
 
-	c := client.Client{ControllerAddress: e.cliSettings.ServerAddr}
-	resourceInfo, err := c.CreateResource(e.cliSettings.ActiveNamespace, args[0], args[1], resource)
-
+	c, err := e.cliSettings.NewClient()
 	if err != nil {
-		fmt.Fprintf(e.writer, "Failed to create resource: %v\n", err)
 		return err
 	}
+	ctx := common.GetContext(cmd)
+	resourceInfo, err := c.CreateResource(ctx, e.cliSettings.ActiveNamespace, args[0], args[1], resource)
+	if err != nil {
+		return fmt.Errorf("failed to create resource: %w", err)
+	}
 
-	fmt.Fprintf(e.writer, "Resource Created.\ntag: %s\nuri: %s\nip: %s\n", resourceInfo["name"], resourceInfo["uri"], resourceInfo["ip"])
-
-	return nil
+	table := &common.Table{Headers: []string{"TAG", "URI", "IP"}}
+	table.AddRow(resourceInfo["name"], resourceInfo["uri"], resourceInfo["ip"])
+	return common.Print(e.writer, e.output, resourceInfo, table)
 }
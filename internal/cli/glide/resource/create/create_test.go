@@ -60,4 +60,11 @@ func TestResourceCreateExecute(t *testing.T) {
 
 	assert.Nil(t, err)
 	assert.Contains(t, output.String(), "resourceName")
+
+	// Failures are only reported through the returned error
+	output.Reset()
+	err = executor.Execute(cmd, []string{"wrongcloud", "resourceName"})
+
+	assert.ErrorContains(t, err, "failed to create resource")
+	assert.Empty(t, output.String())
 }
@@ -0,0 +1,70 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package get
+
+import (
+	"io"
+	"os"
+
+	common "github.com/paraglider-project/paraglider/internal/cli/common"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/spf13/cobra"
+)
+
+func NewCommand() (*cobra.Command, *executor) {
+	executor := &executor{writer: os.Stdout, cliSettings: config.ActiveConfig.Settings}
+	cmd := &cobra.Command{
+		Use:     "get <cloud> <resource_name>",
+		Short:   "Get a Paraglider resource",
+		Args:    cobra.ExactArgs(2),
+		PreRunE: executor.Validate,
+		RunE:    executor.Execute,
+	}
+	return cmd, executor
+}
+
+type executor struct {
+	common.CommandExecutor
+	writer      io.Writer
+	cliSettings config.CliSettings
+	output      string
+}
+
+func (e *executor) SetOutput(w io.Writer) {
+	e.writer = w
+}
+
+func (e *executor) Validate(cmd *cobra.Command, args []string) error {
+	var err error
+	e.output, err = common.GetOutputFormat(cmd)
+	return err
+}
+
+func (e *executor) Execute(cmd *cobra.Command, args []string) error {
+	c, err := e.cliSettings.NewClient()
+	if err != nil {
+		return err
+	}
+	ctx := common.GetContext(cmd)
+	resource, err := c.GetResource(ctx, e.cliSettings.ActiveNamespace, args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	return common.Print(e.writer, e.output, resource, common.NewResourceTable([]*paragliderpb.Resource{resource}))
+}
@@ -18,6 +18,8 @@ package resource
 
 import (
 	"github.com/paraglider-project/paraglider/internal/cli/glide/resource/create"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/resource/get"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/resource/list"
 	"github.com/spf13/cobra"
 )
 
@@ -29,6 +31,10 @@ func NewCommand() *cobra.Command {
 
 	createCmd, _ := create.NewCommand()
 	cmd.AddCommand(createCmd)
+	listCmd, _ := list.NewCommand()
+	cmd.AddCommand(listCmd)
+	getCmd, _ := get.NewCommand()
+	cmd.AddCommand(getCmd)
 
 	return cmd
 }
@@ -21,18 +21,42 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	common "github.com/paraglider-project/paraglider/internal/cli/common"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/apply"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
+	glidecontext "github.com/paraglider-project/paraglider/internal/cli/glide/context"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/diff"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/namespace"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/resource"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/rule"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/server"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/snapshot/export"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/snapshot/importer"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/tag"
 	"github.com/spf13/cobra"
 )
 
+const contextFlag = "context"
+
+// Get the value of the --context flag from the command line arguments, if given
+func getContextFlag(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--"+contextFlag && i+1 < len(args) {
+			return args[i+1]
+		}
+		if value, found := strings.CutPrefix(arg, "--"+contextFlag+"="); found {
+			return value
+		}
+	}
+	return ""
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "glide",
 	Short: "Paraglider CLI",
@@ -47,12 +71,34 @@ func init() {
 		os.Exit(1)
 	}
 
+	// Commands take their settings when they are created, so the context is selected before flags are parsed
+	if name := getContextFlag(os.Args[1:]); name != "" {
+		err = config.ActiveConfig.UseContext(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading configuration: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	common.AddOutputFlag(rootCmd.PersistentFlags())
+	rootCmd.PersistentFlags().String(contextFlag, "", fmt.Sprintf("The context to use instead of the current context (overrides %s)", config.ContextEnvVar))
+
 	rootCmd.AddCommand(resource.NewCommand())
 	rootCmd.AddCommand(rule.NewCommand())
 	rootCmd.AddCommand(tag.NewCommand())
 	rootCmd.AddCommand(common.NewVersionCommand())
 	rootCmd.AddCommand(server.NewCommand())
 	rootCmd.AddCommand(namespace.NewCommand())
+	rootCmd.AddCommand(glidecontext.NewCommand())
+
+	exportCmd, _ := export.NewCommand()
+	rootCmd.AddCommand(exportCmd)
+	importCmd, _ := importer.NewCommand()
+	rootCmd.AddCommand(importCmd)
+	applyCmd, _ := apply.NewCommand()
+	rootCmd.AddCommand(applyCmd)
+	diffCmd, _ := diff.NewCommand()
+	rootCmd.AddCommand(diffCmd)
 }
 
 func Execute() {
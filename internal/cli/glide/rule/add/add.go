@@ -24,7 +24,6 @@ import (
 
 	common "github.com/paraglider-project/paraglider/internal/cli/common"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
-	"github.com/paraglider-project/paraglider/pkg/client"
 	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
 	"github.com/spf13/cobra"
 )
@@ -106,13 +105,16 @@ func (e *executor) Execute(cmd *cobra.Command, args []string) error {
 		rules = append(rules, &paragliderpb.PermitListRule{Name: "ssh-out-" + ruleName, Tags: []string{e.sshTag}, Protocol: 6, Direction: 1, DstPort: -1, SrcPort: 22})
 	}
 
-	c := client.Client{ControllerAddress: e.cliSettings.ServerAddr}
+	c, err := e.cliSettings.NewClient()
+	if err != nil {
+		return err
+	}
+	ctx := common.GetContext(cmd)
 
-	var err error
 	if len(args) == 1 {
-		err = c.AddPermitListRulesTag(args[0], rules)
+		err = c.AddPermitListRulesTag(ctx, args[0], rules)
 	} else {
-		err = c.AddPermitListRules(e.cliSettings.ActiveNamespace, args[0], args[1], rules)
+		err = c.AddPermitListRules(ctx, e.cliSettings.ActiveNamespace, args[0], args[1], rules)
 	}
 
 	return err
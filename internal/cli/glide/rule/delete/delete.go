@@ -22,16 +22,15 @@ import (
 
 	common "github.com/paraglider-project/paraglider/internal/cli/common"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
-	"github.com/paraglider-project/paraglider/pkg/client"
 	"github.com/spf13/cobra"
 )
 
 func NewCommand() (*cobra.Command, *executor) {
 	executor := &executor{writer: os.Stdout, cliSettings: config.ActiveConfig.Settings}
 	cmd := &cobra.Command{
-		Use:     "delete <cloud> <resource name> --rules <rule names>",
-		Short:   "Delete a rule from a resource permit list",
-		Args:    cobra.ExactArgs(2),
+		Use:     "delete [<cloud> <resource name> | <tag>] --rules <rule names>",
+		Short:   "Delete a rule from a resource permit list or from the permit list of every resource within a tag",
+		Args:    cobra.RangeArgs(1, 2),
 		PreRunE: executor.Validate,
 		RunE:    executor.Execute,
 	}
@@ -61,7 +60,16 @@ func (e *executor) Validate(cmd *cobra.Command, args []string) error {
 
 func (e *executor) Execute(cmd *cobra.Command, args []string) error {
 	// Send the rules to the server
-	c := client.Client{ControllerAddress: e.cliSettings.ServerAddr}
-	err := c.DeletePermitListRules(e.cliSettings.ActiveNamespace, args[0], args[1], e.ruleNames)
+	c, err := e.cliSettings.NewClient()
+	if err != nil {
+		return err
+	}
+	ctx := common.GetContext(cmd)
+
+	if len(args) == 1 {
+		err = c.DeletePermitListRulesTag(ctx, args[0], e.ruleNames)
+	} else {
+		err = c.DeletePermitListRules(ctx, e.cliSettings.ActiveNamespace, args[0], args[1], e.ruleNames)
+	}
 	return err
 }
@@ -59,4 +59,10 @@ func TestRuleDeleteExecute(t *testing.T) {
 	err = executor.Execute(cmd, args)
 
 	assert.Nil(t, err)
+
+	// Delete from a tag
+	args = []string{"tag"}
+	err = executor.Execute(cmd, args)
+
+	assert.Nil(t, err)
 }
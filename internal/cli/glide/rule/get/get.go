@@ -17,22 +17,21 @@ limitations under the License.
 package get
 
 import (
-	"fmt"
 	"io"
 	"os"
 
 	common "github.com/paraglider-project/paraglider/internal/cli/common"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
-	"github.com/paraglider-project/paraglider/pkg/client"
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
 	"github.com/spf13/cobra"
 )
 
 func NewCommand() (*cobra.Command, *executor) {
 	executor := &executor{writer: os.Stdout, cliSettings: config.ActiveConfig.Settings}
 	cmd := &cobra.Command{
-		Use:     "get <cloud> <resource name>",
-		Short:   "Get rules of a resource permit list",
-		Args:    cobra.ExactArgs(2),
+		Use:     "get [<cloud> <resource name> | <tag>]",
+		Short:   "Get rules of a resource permit list or the rules attached to a tag",
+		Args:    cobra.RangeArgs(1, 2),
 		PreRunE: executor.Validate,
 		RunE:    executor.Execute,
 	}
@@ -43,6 +42,7 @@ type executor struct {
 	common.CommandExecutor
 	writer      io.Writer
 	cliSettings config.CliSettings
+	output      string
 }
 
 func (e *executor) SetOutput(w io.Writer) {
@@ -50,22 +50,33 @@ func (e *executor) SetOutput(w io.Writer) {
 }
 
 func (e *executor) Validate(cmd *cobra.Command, args []string) error {
-	return nil
+	var err error
+	e.output, err = common.GetOutputFormat(cmd)
+	return err
 }
 
 func (e *executor) Execute(cmd *cobra.Command, args []string) error {
 	// Get the rules from the server
-	c := client.Client{ControllerAddress: e.cliSettings.ServerAddr}
-	permitList, err := c.GetPermitList(e.cliSettings.ActiveNamespace, args[0], args[1])
+	c, err := e.cliSettings.NewClient()
 	if err != nil {
 		return err
 	}
+	ctx := common.GetContext(cmd)
 
-	// Print the rules
-	fmt.Fprintf(e.writer, "Permit list for %s:\n", args[1])
-	for i, rule := range permitList {
-		fmt.Fprintf(e.writer, "%d. %v\n", i, rule)
+	var permitList []*paragliderpb.PermitListRule
+	if len(args) == 1 {
+		permitList, err = c.GetPermitListRulesTag(ctx, args[0])
+	} else {
+		permitList, err = c.GetPermitList(ctx, e.cliSettings.ActiveNamespace, args[0], args[1])
+	}
+	if err != nil {
+		return err
 	}
 
-	return nil
+	if permitList == nil {
+		permitList = []*paragliderpb.PermitListRule{}
+	}
+
+	// Print the rules
+	return common.Print(e.writer, e.output, permitList, common.NewRuleTable(permitList))
 }
@@ -20,11 +20,15 @@ package get
 
 import (
 	"bytes"
+	"encoding/json"
 	"testing"
 
+	common "github.com/paraglider-project/paraglider/internal/cli/common"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
 	fake "github.com/paraglider-project/paraglider/pkg/fake/orchestrator/rest"
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRuleGetExecute(t *testing.T) {
@@ -44,4 +48,36 @@ func TestRuleGetExecute(t *testing.T) {
 
 	assert.Nil(t, err)
 	assert.Contains(t, output.String(), fake.GetFakePermitListRules()[0].Name)
+
+	// JSON output is the list of rules
+	output.Reset()
+	executor.output = common.OutputJSON
+	err = executor.Execute(cmd, args)
+	require.Nil(t, err)
+
+	rules := []map[string]any{}
+	require.Nil(t, json.Unmarshal(output.Bytes(), &rules))
+	require.Len(t, rules, len(fake.GetFakePermitListRules()))
+	assert.Equal(t, fake.GetFakePermitListRules()[0].Name, rules[0]["name"])
+	// INBOUND is the zero value of the direction but is still printed
+	assert.Equal(t, paragliderpb.Direction_INBOUND.String(), rules[0]["direction"])
+}
+
+func TestRuleGetTagExecute(t *testing.T) {
+	server := &fake.FakeOrchestratorRESTServer{}
+	serverAddr := server.SetupFakeOrchestratorRESTServer()
+
+	err := config.ReadOrCreateConfig()
+	assert.Nil(t, err)
+
+	cmd, executor := NewCommand()
+	executor.cliSettings = config.CliSettings{ServerAddr: serverAddr, ActiveNamespace: fake.Namespace}
+	var output bytes.Buffer
+	executor.writer = &output
+
+	args := []string{"tag"}
+	err = executor.Execute(cmd, args)
+
+	assert.Nil(t, err)
+	assert.Contains(t, output.String(), fake.GetFakePermitListRules()[0].Name)
 }
@@ -17,7 +17,6 @@ limitations under the License.
 package get
 
 import (
-	"fmt"
 	"io"
 	"os"
 
@@ -43,6 +42,7 @@ type executor struct {
 	common.CommandExecutor
 	writer      io.Writer
 	cliSettings config.CliSettings
+	output      string
 }
 
 func (e *executor) SetOutput(w io.Writer) {
@@ -50,10 +50,13 @@ func (e *executor) SetOutput(w io.Writer) {
 }
 
 func (e *executor) Validate(cmd *cobra.Command, args []string) error {
-	return nil
+	var err error
+	e.output, err = common.GetOutputFormat(cmd)
+	return err
 }
 
 func (e *executor) Execute(cmd *cobra.Command, args []string) error {
-	fmt.Fprintln(e.writer, "Server address: ", e.cliSettings.ServerAddr)
-	return nil
+	table := &common.Table{Headers: []string{"SERVER"}}
+	table.AddRow(e.cliSettings.ServerAddr)
+	return common.Print(e.writer, e.output, map[string]string{"server": e.cliSettings.ServerAddr}, table)
 }
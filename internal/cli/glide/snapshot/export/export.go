@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	common "github.com/paraglider-project/paraglider/internal/cli/common"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// Snapshots are always written as YAML or JSON, so the command has its own --output flag in place of the global one
+const (
+	formatYAML = common.OutputYAML
+	formatJSON = common.OutputJSON
+)
+
+func NewCommand() (*cobra.Command, *executor) {
+	executor := &executor{writer: os.Stdout, cliSettings: config.ActiveConfig.Settings}
+	cmd := &cobra.Command{
+		Use:     "export [--output yaml|json] [--file <path>]",
+		Short:   "Export all tags and rules as a snapshot",
+		Args:    cobra.NoArgs,
+		PreRunE: executor.Validate,
+		RunE:    executor.Execute,
+	}
+	cmd.Flags().StringP("output", "o", formatYAML, "The format of the snapshot (yaml or json)")
+	cmd.Flags().StringP("file", "f", "", "The file to write the snapshot to instead of stdout")
+	return cmd, executor
+}
+
+type executor struct {
+	common.CommandExecutor
+	writer      io.Writer
+	cliSettings config.CliSettings
+	format      string
+	file        string
+}
+
+func (e *executor) SetOutput(w io.Writer) {
+	e.writer = w
+}
+
+func (e *executor) Validate(cmd *cobra.Command, args []string) error {
+	var err error
+	e.format, err = cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	if e.format != formatYAML && e.format != formatJSON {
+		return fmt.Errorf("unsupported output format %s", e.format)
+	}
+	e.file, err = cmd.Flags().GetString("file")
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *executor) Execute(cmd *cobra.Command, args []string) error {
+	c, err := e.cliSettings.NewClient()
+	if err != nil {
+		return err
+	}
+	ctx := common.GetContext(cmd)
+	snapshot, err := c.ExportSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	var out []byte
+	if e.format == formatJSON {
+		out, err = json.MarshalIndent(snapshot, "", "  ")
+		out = append(out, '\n')
+	} else {
+		out, err = yaml.Marshal(snapshot)
+	}
+	if err != nil {
+		return err
+	}
+
+	if e.file != "" {
+		return os.WriteFile(e.file, out, 0644)
+	}
+	_, err = e.writer.Write(out)
+	return err
+}
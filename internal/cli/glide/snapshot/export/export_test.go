@@ -0,0 +1,89 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
+	fake "github.com/paraglider-project/paraglider/pkg/fake/orchestrator/rest"
+	"github.com/paraglider-project/paraglider/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestExportValidate(t *testing.T) {
+	err := config.ReadOrCreateConfig()
+	assert.Nil(t, err)
+
+	cmd, executor := NewCommand()
+	err = executor.Validate(cmd, []string{})
+	assert.Nil(t, err)
+	assert.Equal(t, formatYAML, executor.format)
+
+	require.Nil(t, cmd.Flags().Set("output", formatJSON))
+	require.Nil(t, cmd.Flags().Set("file", "snapshot.json"))
+	err = executor.Validate(cmd, []string{})
+	assert.Nil(t, err)
+	assert.Equal(t, formatJSON, executor.format)
+	assert.Equal(t, "snapshot.json", executor.file)
+
+	require.Nil(t, cmd.Flags().Set("output", "xml"))
+	err = executor.Validate(cmd, []string{})
+	assert.NotNil(t, err)
+}
+
+func TestExportExecute(t *testing.T) {
+	server := &fake.FakeOrchestratorRESTServer{}
+	serverAddr := server.SetupFakeOrchestratorRESTServer()
+
+	err := config.ReadOrCreateConfig()
+	assert.Nil(t, err)
+
+	cmd, executor := NewCommand()
+	executor.cliSettings = config.CliSettings{ServerAddr: serverAddr, ActiveNamespace: fake.Namespace}
+
+	// YAML to stdout
+	var output bytes.Buffer
+	executor.writer = &output
+	executor.format = formatYAML
+	err = executor.Execute(cmd, []string{})
+	require.Nil(t, err)
+
+	snapshot := &orchestrator.Snapshot{}
+	require.Nil(t, yaml.Unmarshal(output.Bytes(), snapshot))
+	assert.Equal(t, len(fake.GetFakeSnapshot().Tags), len(snapshot.Tags))
+
+	// JSON to a file
+	executor.format = formatJSON
+	executor.file = filepath.Join(t.TempDir(), "snapshot.json")
+	err = executor.Execute(cmd, []string{})
+	require.Nil(t, err)
+
+	data, err := os.ReadFile(executor.file)
+	require.Nil(t, err)
+	snapshot = &orchestrator.Snapshot{}
+	require.Nil(t, json.Unmarshal(data, snapshot))
+	assert.Equal(t, fake.GetFakeSnapshot().TagRules[0].Tag, snapshot.TagRules[0].Tag)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	common "github.com/paraglider-project/paraglider/internal/cli/common"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
+	"github.com/paraglider-project/paraglider/pkg/orchestrator"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+func NewCommand() (*cobra.Command, *executor) {
+	executor := &executor{writer: os.Stdout, errWriter: os.Stderr, cliSettings: config.ActiveConfig.Settings}
+	cmd := &cobra.Command{
+		Use:     "import <snapshot file> [--mode merge|replace]",
+		Short:   "Import tags and rules from a YAML or JSON snapshot",
+		Args:    cobra.ExactArgs(1),
+		PreRunE: executor.Validate,
+		RunE:    executor.Execute,
+	}
+	cmd.Flags().String("mode", orchestrator.ImportModeMerge, "Whether to merge the snapshot with the current state or replace it")
+	return cmd, executor
+}
+
+// Summary of an import
+type importResult struct {
+	Mode         string `json:"mode"`
+	ImportedTags int    `json:"imported_tags"`
+}
+
+type executor struct {
+	common.CommandExecutor
+	writer      io.Writer
+	errWriter   io.Writer // Human-only notes, kept out of the json and yaml output
+	cliSettings config.CliSettings
+	mode        string
+	output      string
+}
+
+func (e *executor) SetOutput(w io.Writer) {
+	e.writer = w
+}
+
+func (e *executor) Validate(cmd *cobra.Command, args []string) error {
+	var err error
+	e.mode, err = cmd.Flags().GetString("mode")
+	if err != nil {
+		return err
+	}
+	if e.mode != orchestrator.ImportModeMerge && e.mode != orchestrator.ImportModeReplace {
+		return fmt.Errorf("unsupported import mode %s", e.mode)
+	}
+	e.output, err = common.GetOutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *executor) Execute(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	// YAML is a superset of JSON, so either format can be read
+	snapshot := &orchestrator.Snapshot{}
+	err = yaml.Unmarshal(data, snapshot)
+	if err != nil {
+		return err
+	}
+
+	c, err := e.cliSettings.NewClient()
+	if err != nil {
+		return err
+	}
+	ctx := common.GetContext(cmd)
+	err = c.ImportSnapshot(ctx, snapshot, e.mode)
+	if err != nil {
+		return err
+	}
+
+	result := &importResult{Mode: e.mode, ImportedTags: len(snapshot.Tags)}
+	table := &common.Table{Headers: []string{"MODE", "TAGS"}}
+	table.AddRow(result.Mode, fmt.Sprint(result.ImportedTags))
+	if err := common.Print(e.writer, e.output, result, table); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.errWriter, "Imported %d tags\n", result.ImportedTags)
+	return nil
+}
@@ -0,0 +1,96 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	common "github.com/paraglider-project/paraglider/internal/cli/common"
+	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
+	fake "github.com/paraglider-project/paraglider/pkg/fake/orchestrator/rest"
+	"github.com/paraglider-project/paraglider/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestImportValidate(t *testing.T) {
+	err := config.ReadOrCreateConfig()
+	assert.Nil(t, err)
+
+	cmd, executor := NewCommand()
+	err = executor.Validate(cmd, []string{"snapshot.yaml"})
+	assert.Nil(t, err)
+	assert.Equal(t, orchestrator.ImportModeMerge, executor.mode)
+
+	require.Nil(t, cmd.Flags().Set("mode", orchestrator.ImportModeReplace))
+	err = executor.Validate(cmd, []string{"snapshot.yaml"})
+	assert.Nil(t, err)
+	assert.Equal(t, orchestrator.ImportModeReplace, executor.mode)
+
+	require.Nil(t, cmd.Flags().Set("mode", "wrong"))
+	err = executor.Validate(cmd, []string{"snapshot.yaml"})
+	assert.NotNil(t, err)
+}
+
+func TestImportExecute(t *testing.T) {
+	server := &fake.FakeOrchestratorRESTServer{}
+	serverAddr := server.SetupFakeOrchestratorRESTServer()
+
+	err := config.ReadOrCreateConfig()
+	assert.Nil(t, err)
+
+	cmd, executor := NewCommand()
+	executor.cliSettings = config.CliSettings{ServerAddr: serverAddr, ActiveNamespace: fake.Namespace}
+	executor.mode = orchestrator.ImportModeMerge
+	var output, notes bytes.Buffer
+	executor.writer = &output
+	executor.errWriter = &notes
+
+	dir := t.TempDir()
+	jsonBytes, err := json.Marshal(fake.GetFakeSnapshot())
+	require.Nil(t, err)
+	yamlBytes, err := yaml.Marshal(fake.GetFakeSnapshot())
+	require.Nil(t, err)
+
+	for name, data := range map[string][]byte{"snapshot.json": jsonBytes, "snapshot.yaml": yamlBytes} {
+		path := filepath.Join(dir, name)
+		require.Nil(t, os.WriteFile(path, data, 0644))
+
+		output.Reset()
+		notes.Reset()
+		err = executor.Execute(cmd, []string{path})
+		assert.Nil(t, err)
+		assert.Equal(t, "MODE   TAGS\nmerge  3\n", output.String())
+		assert.Equal(t, "Imported 3 tags\n", notes.String())
+	}
+
+	// The json output only holds the result
+	executor.output = common.OutputJSON
+	output.Reset()
+	err = executor.Execute(cmd, []string{filepath.Join(dir, "snapshot.json")})
+	assert.Nil(t, err)
+	result := &importResult{}
+	require.Nil(t, json.Unmarshal(output.Bytes(), result))
+	assert.Equal(t, &importResult{Mode: orchestrator.ImportModeMerge, ImportedTags: 3}, result)
+}
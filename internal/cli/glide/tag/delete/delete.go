@@ -22,7 +22,6 @@ import (
 
 	common "github.com/paraglider-project/paraglider/internal/cli/common"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
-	"github.com/paraglider-project/paraglider/pkg/client"
 	"github.com/spf13/cobra"
 )
 
@@ -61,12 +60,16 @@ func (e *executor) Validate(cmd *cobra.Command, args []string) error {
 
 func (e *executor) Execute(cmd *cobra.Command, args []string) error {
 	// Delete the tag from the server
-	c := client.Client{ControllerAddress: e.cliSettings.ServerAddr}
+	c, err := e.cliSettings.NewClient()
+	if err != nil {
+		return err
+	}
+	ctx := common.GetContext(cmd)
 	if e.member == "" {
-		err := c.DeleteTag(args[0])
+		err := c.DeleteTag(ctx, args[0])
 		return err
 	} else {
-		err := c.DeleteTagMembers(args[0], e.member)
+		err := c.DeleteTagMembers(ctx, args[0], e.member)
 		return err
 	}
 }
@@ -17,13 +17,12 @@ limitations under the License.
 package get
 
 import (
-	"fmt"
 	"io"
 	"os"
 
 	common "github.com/paraglider-project/paraglider/internal/cli/common"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
-	"github.com/paraglider-project/paraglider/pkg/client"
+	"github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
 	"github.com/spf13/cobra"
 )
 
@@ -45,6 +44,7 @@ type executor struct {
 	writer      io.Writer
 	cliSettings config.CliSettings
 	resolveFlag bool
+	output      string
 }
 
 func (e *executor) SetOutput(w io.Writer) {
@@ -57,30 +57,39 @@ func (e *executor) Validate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	e.output, err = common.GetOutputFormat(cmd)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
 func (e *executor) Execute(cmd *cobra.Command, args []string) error {
 	// Get the tag from the server
-	c := client.Client{ControllerAddress: e.cliSettings.ServerAddr}
+	c, err := e.cliSettings.NewClient()
+	if err != nil {
+		return err
+	}
+	ctx := common.GetContext(cmd)
 
 	if e.resolveFlag {
-		tagMappings, err := c.ResolveTag(args[0])
+		tagMappings, err := c.ResolveTag(ctx, args[0])
 		if err != nil {
 			return err
 		}
-
-		// Print the tag
-		fmt.Fprintf(e.writer, "Tag %s:\n %v\n", args[0], tagMappings)
-	} else {
-		tagMapping, err := c.GetTag(args[0])
-		if err != nil {
-			return err
+		if tagMappings == nil {
+			tagMappings = []*tagservicepb.TagMapping{}
 		}
 
-		// Print the tag
-		fmt.Fprintln(e.writer, tagMapping)
+		// Print the leaf tags the tag resolves to
+		return common.Print(e.writer, e.output, tagMappings, common.NewTagTable(tagMappings))
 	}
 
-	return nil
+	tagMapping, err := c.GetTag(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	// Print the tag
+	return common.Print(e.writer, e.output, tagMapping, common.NewTagTable([]*tagservicepb.TagMapping{tagMapping}))
 }
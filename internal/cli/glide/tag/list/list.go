@@ -23,37 +23,91 @@ import (
 
 	common "github.com/paraglider-project/paraglider/internal/cli/common"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
-	"github.com/paraglider-project/paraglider/pkg/client"
+	"github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
 	"github.com/spf13/cobra"
 )
 
 func NewCommand() (*cobra.Command, *executor) {
-	executor := &executor{writer: os.Stdout, cliSettings: config.ActiveConfig.Settings}
+	executor := &executor{writer: os.Stdout, errWriter: os.Stderr, cliSettings: config.ActiveConfig.Settings}
 	cmd := &cobra.Command{
-		Use:   "list",
-		Short: "List tags with their mappings",
-		Args:  cobra.NoArgs,
-		RunE:  executor.Execute,
+		Use:     "list [--prefix <prefix>] [--label <key=value>] [--limit <limit>] [--page-token <token>]",
+		Short:   "List tags with their mappings",
+		Args:    cobra.NoArgs,
+		PreRunE: executor.Validate,
+		RunE:    executor.Execute,
 	}
+	cmd.Flags().String("prefix", "", "Only list tags whose names start with the prefix")
+	cmd.Flags().StringToString("label", map[string]string{}, "Only list tags with the label (key=value)")
+	cmd.Flags().Int32("limit", 0, "Maximum number of tags to list (0 lists all tags)")
+	cmd.Flags().String("page-token", "", "Token of the page to list, printed by a previous list")
 	return cmd, executor
 }
 
 type executor struct {
 	common.CommandExecutor
 	writer      io.Writer
+	errWriter   io.Writer // Human-only notes, kept out of the json and yaml output
 	cliSettings config.CliSettings
+	prefix      string
+	labels      map[string]string
+	limit       int32
+	pageToken   string
+	output      string
+}
+
+func (e *executor) SetOutput(w io.Writer) {
+	e.writer = w
+}
+
+func (e *executor) Validate(cmd *cobra.Command, args []string) error {
+	var err error
+	e.prefix, err = cmd.Flags().GetString("prefix")
+	if err != nil {
+		return err
+	}
+	e.labels, err = cmd.Flags().GetStringToString("label")
+	if err != nil {
+		return err
+	}
+	e.limit, err = cmd.Flags().GetInt32("limit")
+	if err != nil {
+		return err
+	}
+	if e.limit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+	e.pageToken, err = cmd.Flags().GetString("page-token")
+	if err != nil {
+		return err
+	}
+	e.output, err = common.GetOutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	return nil
 }
 
 func (e *executor) Execute(cmd *cobra.Command, args []string) error {
+	c, err := e.cliSettings.NewClient()
+	if err != nil {
+		return err
+	}
+	ctx := common.GetContext(cmd)
+	listResp, err := c.ListTags(ctx, &tagservicepb.ListTagsRequest{Prefix: e.prefix, Labels: e.labels, Limit: e.limit, PageToken: e.pageToken})
+	if err != nil {
+		return err
+	}
+	if listResp.Tags == nil {
+		listResp.Tags = []*tagservicepb.TagMapping{}
+	}
 
-	c := client.Client{ControllerAddress: e.cliSettings.ServerAddr}
-	tagMappings, err := c.ListTags()
+	// json and yaml include the token of the next page as next_page_token, while table formats note it on stderr
+	err = common.Print(e.writer, e.output, listResp, common.NewTagTable(listResp.Tags))
 	if err != nil {
 		return err
 	}
-	// Print the tags
-	for i, tagMap := range tagMappings {
-		fmt.Fprintf(e.writer, "%d). %v\n", i, tagMap)
+	if listResp.NextPageToken != "" && common.IsTableFormat(e.output) {
+		fmt.Fprintf(e.errWriter, "Next page token: %s\n", listResp.NextPageToken)
 	}
 	return nil
 }
@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"testing"
 
+	common "github.com/paraglider-project/paraglider/internal/cli/common"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
 	fake "github.com/paraglider-project/paraglider/pkg/fake/orchestrator/rest"
 	"github.com/stretchr/testify/assert"
@@ -47,3 +48,52 @@ func TestTagListExecute(t *testing.T) {
 	assert.Contains(t, output.String(), fake.ListFakeTagMapping()[1].Name)
 	assert.Contains(t, output.String(), fake.ListFakeTagMapping()[2].Name)
 }
+
+func TestTagListValidate(t *testing.T) {
+	cmd, executor := NewCommand()
+
+	_ = cmd.Flags().Set("prefix", "tag")
+	_ = cmd.Flags().Set("label", "env=prod")
+	_ = cmd.Flags().Set("limit", "1")
+	err := executor.Validate(cmd, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "tag", executor.prefix)
+	assert.Equal(t, map[string]string{"env": "prod"}, executor.labels)
+	assert.Equal(t, int32(1), executor.limit)
+
+	_ = cmd.Flags().Set("limit", "-1")
+	err = executor.Validate(cmd, nil)
+
+	assert.NotNil(t, err)
+}
+
+func TestTagListExecutePage(t *testing.T) {
+	server := &fake.FakeOrchestratorRESTServer{}
+	serverAddr := server.SetupFakeOrchestratorRESTServer()
+
+	cmd, executor := NewCommand()
+	executor.cliSettings = config.CliSettings{ServerAddr: serverAddr}
+	executor.limit = 1
+	var output, notes bytes.Buffer
+	executor.writer = &output
+	executor.errWriter = &notes
+
+	err := executor.Execute(cmd, nil)
+
+	assert.Nil(t, err)
+	assert.Contains(t, output.String(), fake.ListFakeTagMapping()[0].Name)
+	assert.NotContains(t, output.String(), *fake.ListFakeTagMapping()[2].Ip)
+	assert.NotContains(t, output.String(), "Next page token")
+	assert.Equal(t, "Next page token: next\n", notes.String())
+
+	// The json output holds the token instead
+	executor.output = common.OutputJSON
+	output.Reset()
+	notes.Reset()
+	err = executor.Execute(cmd, nil)
+
+	assert.Nil(t, err)
+	assert.Contains(t, output.String(), `"next_page_token": "next"`)
+	assert.Empty(t, notes.String())
+}
@@ -23,7 +23,6 @@ import (
 
 	common "github.com/paraglider-project/paraglider/internal/cli/common"
 	"github.com/paraglider-project/paraglider/internal/cli/glide/config"
-	"github.com/paraglider-project/paraglider/pkg/client"
 	"github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
 	"github.com/spf13/cobra"
 )
@@ -31,8 +30,9 @@ import (
 func NewCommand() (*cobra.Command, *executor) {
 	executor := &executor{writer: os.Stdout, cliSettings: config.ActiveConfig.Settings}
 	cmd := &cobra.Command{
-		Use:     "set <tag name> [--children <child tag list> | --uri <uri> | --ip <ip>]",
+		Use:     "set <tag name> [--children <child tag list> | --uri <uri> | --ip <ip> [--version <version>] | --selector <key=value,...>] [--label <key=value>]",
 		Short:   "Set a tag",
+		Long:    "Set a tag. Setting the URI or IP of an existing last-level tag updates it, and --version only applies the update if the tag is still at that version. Selector tags contain all tags with the labels in the selector. Setting a label to an empty value removes it.",
 		Args:    cobra.ExactArgs(1),
 		PreRunE: executor.Validate,
 		RunE:    executor.Execute,
@@ -40,6 +40,9 @@ func NewCommand() (*cobra.Command, *executor) {
 	cmd.Flags().StringSlice("children", []string{}, "List of child tags")
 	cmd.Flags().String("uri", "", "URI of the tag")
 	cmd.Flags().String("ip", "", "IP of the tag")
+	cmd.Flags().StringToString("label", map[string]string{}, "Labels of the tag (key=value)")
+	cmd.Flags().String("selector", "", "Labels which members of the tag must have (key=value,...)")
+	cmd.Flags().Int64("version", 0, "Version of the last-level tag the update is based on")
 	return cmd, executor
 }
 
@@ -50,6 +53,9 @@ type executor struct {
 	children    []string
 	uri         string
 	ip          string
+	labels      map[string]string
+	selector    string
+	version     *int64
 }
 
 func (e *executor) Validate(cmd *cobra.Command, args []string) error {
@@ -69,12 +75,40 @@ func (e *executor) Validate(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if len(e.children) == 0 && e.uri == "" && e.ip == "" {
-		return fmt.Errorf("must specify at least one of --children, --uri, or --ip")
+	e.labels, err = cmd.Flags().GetStringToString("label")
+	if err != nil {
+		return err
+	}
+
+	e.selector, err = cmd.Flags().GetString("selector")
+	if err != nil {
+		return err
+	}
+
+	e.version = nil
+	if cmd.Flags().Changed("version") {
+		version, err := cmd.Flags().GetInt64("version")
+		if err != nil {
+			return err
+		}
+		if e.uri == "" && e.ip == "" {
+			return fmt.Errorf("can only specify --version with --uri or --ip")
+		}
+		e.version = &version
+	}
+
+	if len(e.children) == 0 && e.uri == "" && e.ip == "" && e.selector == "" && len(e.labels) == 0 {
+		return fmt.Errorf("must specify at least one of --children, --uri, --ip, --selector, or --label")
 	}
 	if len(e.children) > 0 && (e.uri != "" || e.ip != "") {
 		return fmt.Errorf("cannot specify --children with --uri or --ip")
 	}
+	if e.selector != "" && (len(e.children) > 0 || e.uri != "" || e.ip != "") {
+		return fmt.Errorf("cannot specify --selector with --children, --uri, or --ip")
+	}
+	if e.selector != "" && len(e.labels) > 0 {
+		return fmt.Errorf("cannot specify --selector with --label")
+	}
 
 	return nil
 }
@@ -93,9 +127,18 @@ func (e *executor) Execute(cmd *cobra.Command, args []string) error {
 		ip = &e.ip
 	}
 
-	tagMapping := &tagservicepb.TagMapping{Name: args[0], ChildTags: e.children, Uri: uri, Ip: ip}
+	var selector *string
+	if e.selector != "" {
+		selector = &e.selector
+	}
+
+	tagMapping := &tagservicepb.TagMapping{Name: args[0], ChildTags: e.children, Uri: uri, Ip: ip, Labels: e.labels, Selector: selector, Version: e.version}
 
-	c := client.Client{ControllerAddress: e.cliSettings.ServerAddr}
-	err := c.SetTag(args[0], tagMapping)
+	c, err := e.cliSettings.NewClient()
+	if err != nil {
+		return err
+	}
+	ctx := common.GetContext(cmd)
+	err = c.SetTag(ctx, args[0], tagMapping)
 	return err
 }
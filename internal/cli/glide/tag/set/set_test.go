@@ -57,6 +57,30 @@ func TestTagSetValidate(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "uri", executor.uri)
 	assert.Equal(t, "ip", executor.ip)
+	assert.Nil(t, executor.version)
+
+	// IP update based on a version
+	cmd, executor = NewCommand()
+	err = cmd.Flags().Set("ip", "ip")
+	require.Nil(t, err)
+	err = cmd.Flags().Set("version", "2")
+	require.Nil(t, err)
+
+	err = executor.Validate(cmd, args)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), *executor.version)
+
+	// Version without URI/IP
+	cmd, executor = NewCommand()
+	err = cmd.Flags().Set("children", "child1")
+	require.Nil(t, err)
+	err = cmd.Flags().Set("version", "2")
+	require.Nil(t, err)
+
+	err = executor.Validate(cmd, args)
+
+	assert.NotNil(t, err)
 
 	// Both children and URI/IP specified
 	cmd, executor = NewCommand()
@@ -71,6 +95,50 @@ func TestTagSetValidate(t *testing.T) {
 	err = executor.Validate(cmd, args)
 
 	assert.NotNil(t, err)
+
+	// Just labels specified
+	cmd, executor = NewCommand()
+	err = cmd.Flags().Set("label", "env=prod")
+	require.Nil(t, err)
+	err = cmd.Flags().Set("label", "team=")
+	require.Nil(t, err)
+
+	err = executor.Validate(cmd, args)
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"env": "prod", "team": ""}, executor.labels)
+
+	// Just the selector specified
+	cmd, executor = NewCommand()
+	err = cmd.Flags().Set("selector", "env=prod,cloud=azure")
+	require.Nil(t, err)
+
+	err = executor.Validate(cmd, args)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "env=prod,cloud=azure", executor.selector)
+
+	// Both selector and children specified
+	cmd, executor = NewCommand()
+	err = cmd.Flags().Set("selector", "env=prod")
+	require.Nil(t, err)
+	err = cmd.Flags().Set("children", "child1")
+	require.Nil(t, err)
+
+	err = executor.Validate(cmd, args)
+
+	assert.NotNil(t, err)
+
+	// Both selector and labels specified
+	cmd, executor = NewCommand()
+	err = cmd.Flags().Set("selector", "env=prod")
+	require.Nil(t, err)
+	err = cmd.Flags().Set("label", "env=prod")
+	require.Nil(t, err)
+
+	err = executor.Validate(cmd, args)
+
+	assert.NotNil(t, err)
 }
 
 func TestTagSetExecute(t *testing.T) {
@@ -97,4 +165,19 @@ func TestTagSetExecute(t *testing.T) {
 
 	err = executor.Execute(cmd, args)
 	assert.Nil(t, err)
+
+	// Just selector set
+	executor.uri = ""
+	executor.ip = ""
+	executor.selector = "env=prod"
+
+	err = executor.Execute(cmd, args)
+	assert.Nil(t, err)
+
+	// Just labels set
+	executor.selector = ""
+	executor.labels = map[string]string{"env": "prod"}
+
+	err = executor.Execute(cmd, args)
+	assert.Nil(t, err)
 }
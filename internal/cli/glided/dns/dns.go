@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"github.com/spf13/cobra"
+
+	dns "github.com/paraglider-project/paraglider/pkg/dns"
+)
+
+func NewCommand() *cobra.Command {
+	executor := &executor{}
+	cmd := &cobra.Command{
+		Use:     "dns <server address> <tag service address> [--zone <zone>] [--ttl <seconds>] [--upstream <address>]",
+		Aliases: []string{"dns"},
+		Short:   "Starts the DNS server which resolves tag names to resource IPs",
+		Args:    cobra.ExactArgs(2),
+		PreRunE: executor.Validate,
+		RunE:    executor.Execute,
+	}
+	cmd.Flags().String("zone", dns.DefaultZone, "Zone the tags are served under")
+	cmd.Flags().Uint32("ttl", dns.DefaultTtl, "TTL of answers in seconds")
+	cmd.Flags().StringSlice("upstream", []string{}, "Servers that queries outside of the zone are forwarded to (can be repeated)")
+	return cmd
+}
+
+type executor struct {
+	zone      string
+	ttl       uint32
+	upstreams []string
+}
+
+func (e *executor) Validate(cmd *cobra.Command, args []string) error {
+	var err error
+	e.zone, err = cmd.Flags().GetString("zone")
+	if err != nil {
+		return err
+	}
+	e.ttl, err = cmd.Flags().GetUint32("ttl")
+	if err != nil {
+		return err
+	}
+	e.upstreams, err = cmd.Flags().GetStringSlice("upstream")
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *executor) Execute(cmd *cobra.Command, args []string) error {
+	server, err := dns.NewServer(args[1], e.zone, e.ttl, e.upstreams)
+	if err != nil {
+		return err
+	}
+	if _, err := dns.Setup(args[0], server); err != nil {
+		return err
+	}
+	select {}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+
+	"github.com/paraglider-project/paraglider/pkg/fake/simcloud"
+)
+
+func NewCommand() *cobra.Command {
+	executor := &executor{}
+	cmd := &cobra.Command{
+		Use:     "fake <port> [orchestrator address]",
+		Short:   "Starts a simulated in-memory cloud plugin on given port",
+		Long:    "Starts a simulated in-memory cloud plugin on given port. Without an orchestrator address, address spaces and ASNs are allocated locally.",
+		Args:    cobra.RangeArgs(1, 2),
+		PreRunE: executor.Validate,
+		RunE:    executor.Execute,
+	}
+	cmd.Flags().String("cloud", simcloud.DefaultCloud, "Name of the simulated cloud")
+	cmd.Flags().String("quirks", "", "Cloud whose quirks are simulated (aws, azure, gcp, or ibm; defaults to --cloud)")
+	cmd.Flags().Duration("latency", 0, "Latency added to every RPC")
+	cmd.Flags().Float64("error-rate", 0, "Probability of an RPC failing")
+	cmd.Flags().StringSlice("fail", []string{}, "RPCs which always fail, as <rpc>=<code> (e.g., CreateResource=UNAVAILABLE)")
+	cmd.Flags().Bool("partial-failure", false, "Mutating RPCs apply only part of their changes before failing")
+	cmd.Flags().Int64("seed", 0, "Seed of the random error injection")
+	return cmd
+}
+
+type executor struct {
+	port             int
+	orchestratorAddr string
+	settings         simcloud.Settings
+}
+
+// Parse <rpc>=<code> pairs into the RPCs which always fail
+func parseFailures(failures []string) (map[string]codes.Code, error) {
+	errors := make(map[string]codes.Code)
+	for _, failure := range failures {
+		method, codeName, ok := strings.Cut(failure, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid failure %s: expected <rpc>=<code>", failure)
+		}
+		var code codes.Code
+		if err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(codeName)))); err != nil {
+			return nil, fmt.Errorf("invalid code %s for %s", codeName, method)
+		}
+		errors[method] = code
+	}
+	return errors, nil
+}
+
+func (e *executor) Validate(cmd *cobra.Command, args []string) error {
+	var err error
+	e.port, err = strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid port")
+	}
+	if len(args) > 1 {
+		e.orchestratorAddr = args[1]
+	}
+
+	cloud, err := cmd.Flags().GetString("cloud")
+	if err != nil {
+		return err
+	}
+	quirks, err := cmd.Flags().GetString("quirks")
+	if err != nil {
+		return err
+	}
+	if quirks == "" {
+		quirks = cloud
+	}
+	e.settings = simcloud.Settings{Cloud: cloud, Quirks: simcloud.QuirksFor(quirks)}
+
+	e.settings.Faults.Latency, err = cmd.Flags().GetDuration("latency")
+	if err != nil {
+		return err
+	}
+	e.settings.Faults.ErrorRate, err = cmd.Flags().GetFloat64("error-rate")
+	if err != nil {
+		return err
+	}
+	if e.settings.Faults.ErrorRate < 0 || e.settings.Faults.ErrorRate > 1 {
+		return fmt.Errorf("error rate must be between 0 and 1")
+	}
+	failures, err := cmd.Flags().GetStringSlice("fail")
+	if err != nil {
+		return err
+	}
+	e.settings.Faults.Errors, err = parseFailures(failures)
+	if err != nil {
+		return err
+	}
+	e.settings.Faults.PartialFailure, err = cmd.Flags().GetBool("partial-failure")
+	if err != nil {
+		return err
+	}
+	e.settings.Seed, err = cmd.Flags().GetInt64("seed")
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *executor) Execute(cmd *cobra.Command, args []string) error {
+	addr, err := simcloud.Setup(e.port, simcloud.NewSimCloudPluginServer(e.orchestratorAddr, e.settings))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Simulated cloud %s listening on %s\n", e.settings.Cloud, addr)
+	select {}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	kubernetes "github.com/paraglider-project/paraglider/pkg/kubernetes"
+)
+
+func NewCommand() *cobra.Command {
+	executor := &executor{}
+	return &cobra.Command{
+		Use:     "kubernetes <port> <orchestrator address> <path to config>",
+		Aliases: []string{"k8s"},
+		Short:   "Starts the Kubernetes plugin server with given config file",
+		Args:    cobra.ExactArgs(3),
+		PreRunE: executor.Validate,
+		RunE:    executor.Execute,
+	}
+}
+
+type executor struct {
+	port   int
+	config *kubernetes.Config
+}
+
+func (e *executor) Validate(cmd *cobra.Command, args []string) error {
+	var err error
+	e.port, err = strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid port")
+	}
+	e.config, err = kubernetes.LoadConfig(args[2])
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *executor) Execute(cmd *cobra.Command, args []string) error {
+	clients, err := e.config.NewClients()
+	if err != nil {
+		return err
+	}
+	server := kubernetes.NewKubernetesPluginServer(args[1], e.config, clients)
+	if _, err := kubernetes.Setup(e.port, server); err != nil {
+		return err
+	}
+	select {}
+}
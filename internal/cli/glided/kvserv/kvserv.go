@@ -17,29 +17,36 @@ limitations under the License.
 package kvserv
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/spf13/cobra"
 
-	tagservice "github.com/paraglider-project/paraglider/pkg/tag_service"
+	kvservice "github.com/paraglider-project/paraglider/pkg/kvstore"
+	storage "github.com/paraglider-project/paraglider/pkg/storage"
 )
 
 func NewCommand() *cobra.Command {
 	executor := &executor{}
-	return &cobra.Command{
-		Use:     "kvserv <database port> <server port> <clear keys>",
+	cmd := &cobra.Command{
+		Use:     "kvserv <database port> <server port> <clear keys> [--backend <redis|memory|bolt>] [--path <database file>]",
 		Aliases: []string{"kvserv"},
 		Short:   "Starts the key-value store server on given ports",
 		Args:    cobra.ExactArgs(3),
 		PreRunE: executor.Validate,
 		RunE:    executor.Execute,
 	}
+	cmd.Flags().String("backend", storage.RedisBackend, "Storage backend (redis, memory, or bolt)")
+	cmd.Flags().String("path", "", "Database file for the bolt backend")
+	return cmd
 }
 
 type executor struct {
 	dbPort     int
 	serverPort int
 	clearKeys  bool
+	backend    string
+	path       string
 }
 
 func (e *executor) Validate(cmd *cobra.Command, args []string) error {
@@ -56,11 +63,23 @@ func (e *executor) Validate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	e.backend, err = cmd.Flags().GetString("backend")
+	if err != nil {
+		return err
+	}
+	e.path, err = cmd.Flags().GetString("path")
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
 
 func (e *executor) Execute(cmd *cobra.Command, args []string) error {
-	tagservice.Setup(e.dbPort, e.serverPort, e.clearKeys)
+	store, err := storage.Open(e.backend, fmt.Sprintf("localhost:%d", e.dbPort), e.path)
+	if err != nil {
+		return err
+	}
+	kvservice.Setup(store, e.serverPort, e.clearKeys)
 	return nil
 }
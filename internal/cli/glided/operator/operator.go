@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/paraglider-project/paraglider/pkg/operator"
+)
+
+func NewCommand() *cobra.Command {
+	executor := &executor{}
+	cmd := &cobra.Command{
+		Use:     "operator <controller address> [--namespace <namespace>] [--metrics-address <address>]",
+		Short:   "Starts the Kubernetes operator which reconciles Paraglider custom resources",
+		Args:    cobra.ExactArgs(1),
+		PreRunE: executor.Validate,
+		RunE:    executor.Execute,
+	}
+	cmd.Flags().String("namespace", "default", "Paraglider namespace of resources which do not set one")
+	cmd.Flags().String("metrics-address", "0", "Address to serve metrics on (0 disables metrics)")
+	return cmd
+}
+
+type executor struct {
+	controllerAddress string
+	namespace         string
+	metricsAddress    string
+}
+
+func (e *executor) Validate(cmd *cobra.Command, args []string) error {
+	var err error
+	e.controllerAddress = args[0]
+	e.namespace, err = cmd.Flags().GetString("namespace")
+	if err != nil {
+		return err
+	}
+	e.metricsAddress, err = cmd.Flags().GetString("metrics-address")
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *executor) Execute(cmd *cobra.Command, args []string) error {
+	return operator.Setup(e.controllerAddress, e.namespace, e.metricsAddress)
+}
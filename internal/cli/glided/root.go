@@ -21,12 +21,18 @@ import (
 	"os"
 
 	common "github.com/paraglider-project/paraglider/internal/cli/common"
+	"github.com/paraglider-project/paraglider/internal/cli/glided/aws"
 	"github.com/paraglider-project/paraglider/internal/cli/glided/az"
+	"github.com/paraglider-project/paraglider/internal/cli/glided/dns"
+	"github.com/paraglider-project/paraglider/internal/cli/glided/fake"
 	"github.com/paraglider-project/paraglider/internal/cli/glided/gcp"
 	"github.com/paraglider-project/paraglider/internal/cli/glided/ibm"
+	"github.com/paraglider-project/paraglider/internal/cli/glided/kubernetes"
 	"github.com/paraglider-project/paraglider/internal/cli/glided/kvserv"
+	"github.com/paraglider-project/paraglider/internal/cli/glided/operator"
 	"github.com/paraglider-project/paraglider/internal/cli/glided/orchestrator"
 	"github.com/paraglider-project/paraglider/internal/cli/glided/startup"
+	"github.com/paraglider-project/paraglider/internal/cli/glided/static"
 	"github.com/paraglider-project/paraglider/internal/cli/glided/tagserv"
 	"github.com/spf13/cobra"
 )
@@ -38,12 +44,18 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
+	rootCmd.AddCommand(aws.NewCommand())
 	rootCmd.AddCommand(az.NewCommand())
 	rootCmd.AddCommand(gcp.NewCommand())
 	rootCmd.AddCommand(ibm.NewCommand())
+	rootCmd.AddCommand(static.NewCommand())
+	rootCmd.AddCommand(kubernetes.NewCommand())
+	rootCmd.AddCommand(fake.NewCommand())
 	rootCmd.AddCommand(orchestrator.NewCommand())
 	rootCmd.AddCommand(tagserv.NewCommand())
 	rootCmd.AddCommand(kvserv.NewCommand())
+	rootCmd.AddCommand(dns.NewCommand())
+	rootCmd.AddCommand(operator.NewCommand())
 	rootCmd.AddCommand(startup.NewCommand())
 	rootCmd.AddCommand(common.NewVersionCommand())
 }
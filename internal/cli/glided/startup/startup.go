@@ -23,13 +23,18 @@ import (
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
+	aws "github.com/paraglider-project/paraglider/pkg/aws"
 	az "github.com/paraglider-project/paraglider/pkg/azure"
+	dns "github.com/paraglider-project/paraglider/pkg/dns"
 	gcp "github.com/paraglider-project/paraglider/pkg/gcp"
 	ibm "github.com/paraglider-project/paraglider/pkg/ibm"
+	kubernetes "github.com/paraglider-project/paraglider/pkg/kubernetes"
+	static "github.com/paraglider-project/paraglider/pkg/static"
 
 	kvservice "github.com/paraglider-project/paraglider/pkg/kvstore"
 	orchestrator "github.com/paraglider-project/paraglider/pkg/orchestrator"
 	"github.com/paraglider-project/paraglider/pkg/orchestrator/config"
+	storage "github.com/paraglider-project/paraglider/pkg/storage"
 	tagservice "github.com/paraglider-project/paraglider/pkg/tag_service"
 )
 
@@ -43,7 +48,7 @@ func NewCommand() *cobra.Command {
 		PreRunE: executor.Validate,
 		RunE:    executor.Execute,
 	}
-	cmd.Flags().Bool("clearkeys", false, "Clears all the keys in the redis database")
+	cmd.Flags().Bool("clearkeys", false, "Clears all the keys in the storage backend")
 	return cmd
 }
 
@@ -53,8 +58,16 @@ type executor struct {
 	azPort           int
 	gcpPort          int
 	ibmPort          int
+	awsPort          int
+	staticPort       int
+	staticConfig     *static.Config // Only set if the static plugin is configured
+	kubernetesPort   int
+	kubernetesConfig *kubernetes.Config // Only set if the kubernetes plugin is configured
 	orchestratorAddr string
 	clearKeys        bool
+	storage          config.Storage
+	tagServiceAddr   string
+	dnsServer        config.DnsServer
 }
 
 func (e *executor) Validate(cmd *cobra.Command, args []string) error {
@@ -73,6 +86,9 @@ func (e *executor) Validate(cmd *cobra.Command, args []string) error {
 	}
 
 	e.orchestratorAddr = cfg.Server.Host + ":" + cfg.Server.RpcPort
+	e.storage = cfg.Storage
+	e.tagServiceAddr = cfg.TagService.Host + ":" + cfg.TagService.Port
+	e.dnsServer = cfg.DnsServer
 
 	e.tagPort, err = strconv.Atoi(cfg.TagService.Port)
 	if err != nil {
@@ -102,6 +118,29 @@ func (e *executor) Validate(cmd *cobra.Command, args []string) error {
 			if err != nil {
 				return err
 			}
+		} else if cloud.Name == "aws" {
+			e.awsPort, err = strconv.Atoi(cloud.Port)
+			if err != nil {
+				return err
+			}
+		} else if cloud.Name == "static" {
+			e.staticPort, err = strconv.Atoi(cloud.Port)
+			if err != nil {
+				return err
+			}
+			e.staticConfig, err = static.LoadConfig(cloud.Config)
+			if err != nil {
+				return err
+			}
+		} else if cloud.Name == "kubernetes" {
+			e.kubernetesPort, err = strconv.Atoi(cloud.Port)
+			if err != nil {
+				return err
+			}
+			e.kubernetesConfig, err = kubernetes.LoadConfig(cloud.Config)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -114,12 +153,26 @@ func (e *executor) Validate(cmd *cobra.Command, args []string) error {
 }
 
 func (e *executor) Execute(cmd *cobra.Command, args []string) error {
+	// The tag service and KV store share one store since embedded backends cannot be opened twice
+	host := e.storage.Host
+	if host == "" {
+		host = "localhost"
+	}
+	port := e.storage.Port
+	if port == "" {
+		port = "6379"
+	}
+	store, err := storage.Open(e.storage.Backend, host+":"+port, e.storage.Path)
+	if err != nil {
+		return err
+	}
+
 	go func() {
-		tagservice.Setup(6379, e.tagPort, e.clearKeys)
+		tagservice.Setup(store, e.tagPort, e.clearKeys)
 	}()
 
 	go func() {
-		kvservice.Setup(6379, e.kvPort, e.clearKeys)
+		kvservice.Setup(store, e.kvPort, e.clearKeys)
 	}()
 
 	go func() {
@@ -134,6 +187,48 @@ func (e *executor) Execute(cmd *cobra.Command, args []string) error {
 		ibm.Setup(e.ibmPort, e.orchestratorAddr)
 	}()
 
+	go func() {
+		aws.Setup(e.awsPort, e.orchestratorAddr)
+	}()
+
+	// Unlike the clouds, the static plugin can't start without the sites declared in its config
+	if e.staticConfig != nil {
+		staticServer, err := static.NewStaticPluginServer(e.orchestratorAddr, e.staticConfig)
+		if err != nil {
+			return err
+		}
+		if _, err := static.Setup(e.staticPort, staticServer); err != nil {
+			return err
+		}
+	}
+
+	// Likewise, the kubernetes plugin needs the clusters declared in its config
+	if e.kubernetesConfig != nil {
+		clients, err := e.kubernetesConfig.NewClients()
+		if err != nil {
+			return err
+		}
+		kubernetesServer := kubernetes.NewKubernetesPluginServer(e.orchestratorAddr, e.kubernetesConfig, clients)
+		if _, err := kubernetes.Setup(e.kubernetesPort, kubernetesServer); err != nil {
+			return err
+		}
+	}
+
+	// The DNS server is optional and only started if it has a port
+	if e.dnsServer.Port != "" {
+		host := e.dnsServer.Host
+		if host == "" {
+			host = "localhost"
+		}
+		dnsServer, err := dns.NewServer(e.tagServiceAddr, e.dnsServer.Zone, e.dnsServer.Ttl, e.dnsServer.Upstreams)
+		if err != nil {
+			return err
+		}
+		if _, err := dns.Setup(host+":"+e.dnsServer.Port, dnsServer); err != nil {
+			return err
+		}
+	}
+
 	orchestrator.SetupWithFile(args[0], false)
 
 	return nil
@@ -0,0 +1,293 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+)
+
+// Number of bits added to the prefix of a VPC's address space for its subnets (i.e., up to 16 availability zones)
+const subnetPrefixBits = 4
+
+// Maximum prefix length AWS allows for subnets
+const maxSubnetPrefixLength = 28
+
+// A Paraglider VPC of a namespace in a region
+type vpcInfo struct {
+	Id         string
+	Region     string
+	CidrBlocks []string
+}
+
+// Gets the Paraglider VPC of a namespace in the client's region (nil if it does not exist)
+func getVpc(ctx context.Context, client *ec2.Client, namespace string) (*types.Vpc, error) {
+	describeVpcsResp, err := client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{
+		Filters: []types.Filter{tagFilter(namespaceTagKey, namespace), tagFilter(nameTagKey, getVpcName(namespace))},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe vpcs: %w", err)
+	}
+	if len(describeVpcsResp.Vpcs) == 0 {
+		return nil, nil
+	}
+	return &describeVpcsResp.Vpcs[0], nil
+}
+
+// Gets the IPv4 address spaces of a VPC
+func getVpcCidrBlocks(vpc *types.Vpc) []string {
+	cidrBlocks := []string{}
+	for _, association := range vpc.CidrBlockAssociationSet {
+		if association.CidrBlockState == nil || association.CidrBlockState.State == types.VpcCidrBlockStateCodeAssociated {
+			cidrBlocks = append(cidrBlocks, aws.ToString(association.CidrBlock))
+		}
+	}
+	if len(cidrBlocks) == 0 && vpc.CidrBlock != nil {
+		cidrBlocks = append(cidrBlocks, *vpc.CidrBlock)
+	}
+	return cidrBlocks
+}
+
+// Gets the Paraglider VPCs of a namespace across all regions
+func (s *AWSPluginServer) getNamespaceVpcs(ctx context.Context, namespace string) ([]*vpcInfo, error) {
+	vpcs := []*vpcInfo{}
+	err := s.forEachRegion(ctx, func(region string, client *ec2.Client) error {
+		vpc, err := getVpc(ctx, client, namespace)
+		if err != nil {
+			return err
+		}
+		if vpc != nil {
+			vpcs = append(vpcs, &vpcInfo{Id: aws.ToString(vpc.VpcId), Region: region, CidrBlocks: getVpcCidrBlocks(vpc)})
+		}
+		return nil
+	})
+	return vpcs, err
+}
+
+// Gets the Paraglider VPC of a namespace containing an address space
+func (s *AWSPluginServer) findVpc(ctx context.Context, namespace string, addressSpace string) (*vpcInfo, error) {
+	vpcs, err := s.getNamespaceVpcs(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, vpc := range vpcs {
+		contained, err := utils.IsPermitListRuleTagInAddressSpace(addressSpace, vpc.CidrBlocks)
+		if err != nil {
+			return nil, fmt.Errorf("unable to determine if %s is in vpc %s: %w", addressSpace, vpc.Id, err)
+		}
+		if contained {
+			return vpc, nil
+		}
+	}
+	return nil, nil
+}
+
+// Creates the Paraglider VPC of a namespace in the client's region, pointing its DHCP options at the DNS servers if any are given
+func createVpc(ctx context.Context, client *ec2.Client, namespace string, addressSpace string, dnsServers []string) (*types.Vpc, error) {
+	createVpcResp, err := client.CreateVpc(ctx, &ec2.CreateVpcInput{
+		CidrBlock:         aws.String(addressSpace),
+		TagSpecifications: getTagSpecifications(types.ResourceTypeVpc, namespace, getVpcName(namespace)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create vpc: %w", err)
+	}
+	if len(dnsServers) == 0 {
+		return createVpcResp.Vpc, nil
+	}
+
+	createDhcpOptionsResp, err := client.CreateDhcpOptions(ctx, &ec2.CreateDhcpOptionsInput{
+		DhcpConfigurations: []types.NewDhcpConfiguration{{Key: aws.String("domain-name-servers"), Values: dnsServers}},
+		TagSpecifications:  getTagSpecifications(types.ResourceTypeDhcpOptions, namespace, getVpcName(namespace)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create dhcp options: %w", err)
+	}
+	_, err = client.AssociateDhcpOptions(ctx, &ec2.AssociateDhcpOptionsInput{
+		DhcpOptionsId: createDhcpOptionsResp.DhcpOptions.DhcpOptionsId,
+		VpcId:         createVpcResp.Vpc.VpcId,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to associate dhcp options with vpc: %w", err)
+	}
+	createVpcResp.Vpc.DhcpOptionsId = createDhcpOptionsResp.DhcpOptions.DhcpOptionsId
+	return createVpcResp.Vpc, nil
+}
+
+// Gets the Paraglider subnet of a VPC in an availability zone, creating it from the next unused slice of the VPC's address space if needed
+func getOrCreateSubnet(ctx context.Context, client *ec2.Client, namespace string, vpc *types.Vpc, zone string) (*types.Subnet, error) {
+	describeSubnetsResp, err := client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+		Filters: []types.Filter{filter("vpc-id", aws.ToString(vpc.VpcId))},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe subnets: %w", err)
+	}
+	for _, subnet := range describeSubnetsResp.Subnets {
+		if aws.ToString(subnet.AvailabilityZone) == zone {
+			return &subnet, nil
+		}
+	}
+
+	cidrBlock, err := getSubnetCidrBlock(aws.ToString(vpc.CidrBlock), len(describeSubnetsResp.Subnets))
+	if err != nil {
+		return nil, err
+	}
+	createSubnetResp, err := client.CreateSubnet(ctx, &ec2.CreateSubnetInput{
+		VpcId:             vpc.VpcId,
+		CidrBlock:         aws.String(cidrBlock),
+		AvailabilityZone:  aws.String(zone),
+		TagSpecifications: getTagSpecifications(types.ResourceTypeSubnet, namespace, getSubnetName(namespace, zone)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create subnet: %w", err)
+	}
+	return createSubnetResp.Subnet, nil
+}
+
+// Gets the index-th subnet of a VPC's address space
+func getSubnetCidrBlock(vpcCidrBlock string, index int) (string, error) {
+	vpcPrefix, err := netip.ParsePrefix(vpcCidrBlock)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse vpc address space: %w", err)
+	}
+	bits := vpcPrefix.Bits() + subnetPrefixBits
+	if bits > maxSubnetPrefixLength {
+		bits = maxSubnetPrefixLength
+	}
+	if index >= 1<<(bits-vpcPrefix.Bits()) {
+		return "", fmt.Errorf("vpc address space %s has no room for another subnet", vpcCidrBlock)
+	}
+	addr := vpcPrefix.Masked().Addr().As4()
+	offset := uint32(index) << (32 - bits)
+	base := uint32(addr[0])<<24 | uint32(addr[1])<<16 | uint32(addr[2])<<8 | uint32(addr[3])
+	base += offset
+	subnetAddr := netip.AddrFrom4([4]byte{byte(base >> 24), byte(base >> 16), byte(base >> 8), byte(base)})
+	return netip.PrefixFrom(subnetAddr, bits).String(), nil
+}
+
+// Gets the main route table of a VPC (used by all Paraglider subnets)
+func getMainRouteTable(ctx context.Context, client *ec2.Client, vpcId string) (*types.RouteTable, error) {
+	describeRouteTablesResp, err := client.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{
+		Filters: []types.Filter{filter("vpc-id", vpcId), filter("association.main", "true")},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe route tables: %w", err)
+	}
+	if len(describeRouteTablesResp.RouteTables) == 0 {
+		return nil, fmt.Errorf("vpc %s has no main route table", vpcId)
+	}
+	return &describeRouteTablesResp.RouteTables[0], nil
+}
+
+// Routes the address spaces of a peer VPC through a VPC peering connection (existing routes are kept)
+func addPeeringRoutes(ctx context.Context, client *ec2.Client, vpcId string, destinations []string, peeringId string) error {
+	routeTable, err := getMainRouteTable(ctx, client, vpcId)
+	if err != nil {
+		return err
+	}
+	existingRoutes := map[string]bool{}
+	for _, route := range routeTable.Routes {
+		existingRoutes[aws.ToString(route.DestinationCidrBlock)] = true
+	}
+	for _, destination := range destinations {
+		if existingRoutes[destination] {
+			continue
+		}
+		_, err := client.CreateRoute(ctx, &ec2.CreateRouteInput{
+			RouteTableId:           routeTable.RouteTableId,
+			DestinationCidrBlock:   aws.String(destination),
+			VpcPeeringConnectionId: aws.String(peeringId),
+		})
+		if err != nil {
+			return fmt.Errorf("unable to create route to %s: %w", destination, err)
+		}
+	}
+	return nil
+}
+
+// Peers two Paraglider VPCs (possibly in different regions or namespaces) and routes traffic between them
+func (s *AWSPluginServer) peerVpcs(ctx context.Context, namespace string, vpc *vpcInfo, peerNamespace string, peerVpc *vpcInfo, peerAccount string) error {
+	client, err := s.getEC2Client(ctx, vpc.Region)
+	if err != nil {
+		return err
+	}
+	peerClient, err := s.getEC2Client(ctx, peerVpc.Region)
+	if err != nil {
+		return err
+	}
+
+	// Check if the VPCs are already peered (in either direction)
+	vpcIds := []string{vpc.Id, peerVpc.Id}
+	describePeeringsResp, err := client.DescribeVpcPeeringConnections(ctx, &ec2.DescribeVpcPeeringConnectionsInput{
+		Filters: []types.Filter{
+			filter("requester-vpc-info.vpc-id", vpcIds...),
+			filter("accepter-vpc-info.vpc-id", vpcIds...),
+			filter("status-code", string(types.VpcPeeringConnectionStateReasonCodeInitiatingRequest), string(types.VpcPeeringConnectionStateReasonCodePendingAcceptance), string(types.VpcPeeringConnectionStateReasonCodeProvisioning), string(types.VpcPeeringConnectionStateReasonCodeActive)),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to describe vpc peering connections: %w", err)
+	}
+
+	var peering *types.VpcPeeringConnection
+	if len(describePeeringsResp.VpcPeeringConnections) > 0 {
+		peering = &describePeeringsResp.VpcPeeringConnections[0]
+	} else {
+		createPeeringInput := &ec2.CreateVpcPeeringConnectionInput{
+			VpcId:             aws.String(vpc.Id),
+			PeerVpcId:         aws.String(peerVpc.Id),
+			PeerRegion:        aws.String(peerVpc.Region),
+			TagSpecifications: getTagSpecifications(types.ResourceTypeVpcPeeringConnection, namespace, getVpcPeeringName(namespace, peerNamespace)),
+		}
+		if peerAccount != "" {
+			createPeeringInput.PeerOwnerId = aws.String(peerAccount)
+		}
+		createPeeringResp, err := client.CreateVpcPeeringConnection(ctx, createPeeringInput)
+		if err != nil {
+			return fmt.Errorf("unable to create vpc peering connection: %w", err)
+		}
+		peering = createPeeringResp.VpcPeeringConnection
+	}
+	peeringId := aws.ToString(peering.VpcPeeringConnectionId)
+
+	if peering.Status == nil || peering.Status.Code != types.VpcPeeringConnectionStateReasonCodeActive {
+		// The peering connection is accepted in the accepter's region (which may be either VPC if the peering already existed)
+		accepterClient := peerClient
+		if peering.AccepterVpcInfo != nil && aws.ToString(peering.AccepterVpcInfo.VpcId) == vpc.Id {
+			accepterClient = client
+		}
+		// Cross-region peering connections only become visible in the accepter's region after a while
+		err = ec2.NewVpcPeeringConnectionExistsWaiter(accepterClient).Wait(ctx, &ec2.DescribeVpcPeeringConnectionsInput{VpcPeeringConnectionIds: []string{peeringId}}, peeringWaitTimeout)
+		if err != nil {
+			return fmt.Errorf("unable to wait for vpc peering connection %s: %w", peeringId, err)
+		}
+		_, err = accepterClient.AcceptVpcPeeringConnection(ctx, &ec2.AcceptVpcPeeringConnectionInput{VpcPeeringConnectionId: aws.String(peeringId)})
+		if err != nil {
+			return fmt.Errorf("unable to accept vpc peering connection %s: %w", peeringId, err)
+		}
+	}
+
+	if err := addPeeringRoutes(ctx, client, vpc.Id, peerVpc.CidrBlocks, peeringId); err != nil {
+		return err
+	}
+	return addPeeringRoutes(ctx, peerClient, peerVpc.Id, vpc.CidrBlocks, peeringId)
+}
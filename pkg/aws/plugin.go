@@ -0,0 +1,773 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	instanceWaitTimeout = 10 * time.Minute
+	peeringWaitTimeout  = 2 * time.Minute
+)
+
+// AWSPluginServer manages Paraglider resources in the account of its AWS credentials (deployments are accounts/<account>)
+type AWSPluginServer struct {
+	paragliderpb.UnimplementedCloudPluginServer
+	orchestratorServerAddr string
+	ec2Options             []func(*ec2.Options) // Overrides of the EC2 client options (e.g., the endpoint of a fake EC2 server)
+}
+
+// Gets an instance and checks that it is in the namespace
+func getNamespacedInstance(ctx context.Context, client *ec2.Client, namespace string, instanceId string) (*types.Instance, error) {
+	describeInstancesResp, err := client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{instanceId}})
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe instance: %w", err)
+	}
+	for _, reservation := range describeInstancesResp.Reservations {
+		for _, instance := range reservation.Instances {
+			if getTagValue(instance.Tags, namespaceTagKey) != namespace {
+				return nil, status.Errorf(codes.InvalidArgument, "resource is not in namespace %s", namespace)
+			}
+			return &instance, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "instance %s not found", instanceId)
+}
+
+// Gets the security group holding the permit list of an instance
+func getInstanceSecurityGroupId(instance *types.Instance, namespace string) (string, error) {
+	for _, group := range instance.SecurityGroups {
+		if strings.HasPrefix(aws.ToString(group.GroupName), getSecurityGroupNamePrefix(namespace)) {
+			return aws.ToString(group.GroupId), nil
+		}
+	}
+	return "", fmt.Errorf("instance %s has no paraglider security group", aws.ToString(instance.InstanceId))
+}
+
+// Gets the VPC of an instance
+func getInstanceVpc(ctx context.Context, client *ec2.Client, region string, instance *types.Instance) (*vpcInfo, error) {
+	describeVpcsResp, err := client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{VpcIds: []string{aws.ToString(instance.VpcId)}})
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe vpc: %w", err)
+	}
+	if len(describeVpcsResp.Vpcs) == 0 {
+		return nil, fmt.Errorf("vpc %s of instance %s not found", aws.ToString(instance.VpcId), aws.ToString(instance.InstanceId))
+	}
+	vpc := &describeVpcsResp.Vpcs[0]
+	return &vpcInfo{Id: aws.ToString(vpc.VpcId), Region: region, CidrBlocks: getVpcCidrBlocks(vpc)}, nil
+}
+
+// Convert an instance to its inventory entry
+func getResource(uri string, region string, instance *types.Instance, ruleCount int) *paragliderpb.Resource {
+	resource := &paragliderpb.Resource{
+		Name:      getTagValue(instance.Tags, nameTagKey),
+		Uri:       uri,
+		Ip:        aws.ToString(instance.PrivateIpAddress),
+		Region:    region,
+		Network:   aws.ToString(instance.VpcId),
+		Subnet:    aws.ToString(instance.SubnetId),
+		RuleCount: int32(ruleCount),
+	}
+	if instance.State != nil {
+		resource.State = string(instance.State.Name)
+	}
+	return resource
+}
+
+// Gets all private IPs of an instance, starting with its primary IP
+// Secondary IPs and IPv6 addresses of all its network interfaces follow
+func getInstanceIps(instance *types.Instance) []string {
+	ips := []string{}
+	if instance.PrivateIpAddress != nil {
+		ips = append(ips, *instance.PrivateIpAddress)
+	}
+	for _, networkInterface := range instance.NetworkInterfaces {
+		for _, address := range networkInterface.PrivateIpAddresses {
+			if ip := aws.ToString(address.PrivateIpAddress); ip != "" && !slices.Contains(ips, ip) {
+				ips = append(ips, ip)
+			}
+		}
+		for _, address := range networkInterface.Ipv6Addresses {
+			if ip := aws.ToString(address.Ipv6Address); ip != "" && !slices.Contains(ips, ip) {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	return ips
+}
+
+// Gets the instance of a resource URI with a client for its region
+func (s *AWSPluginServer) getResourceInstance(ctx context.Context, namespace string, uri string) (*ec2.Client, *resourceInfo, *types.Instance, error) {
+	resourceInfo, err := parseResourceUri(uri)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	client, err := s.getEC2Client(ctx, resourceInfo.Region)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	instance, err := getNamespacedInstance(ctx, client, namespace, resourceInfo.InstanceId)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return client, resourceInfo, instance, nil
+}
+
+func (s *AWSPluginServer) GetPermitList(ctx context.Context, req *paragliderpb.GetPermitListRequest) (*paragliderpb.GetPermitListResponse, error) {
+	client, _, instance, err := s.getResourceInstance(ctx, req.Namespace, req.Resource)
+	if err != nil {
+		return nil, err
+	}
+	groupId, err := getInstanceSecurityGroupId(instance, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	sgRules, err := getPermitListSecurityGroupRules(ctx, client, groupId)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(sgRules))
+	for name := range sgRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	permitListRules := []*paragliderpb.PermitListRule{}
+	for _, name := range names {
+		rule, err := securityGroupRulesToParagliderRule(name, sgRules[name])
+		if err != nil {
+			return nil, fmt.Errorf("could not convert security group rules to permit list rule: %w", err)
+		}
+		permitListRules = append(permitListRules, rule)
+	}
+	return &paragliderpb.GetPermitListResponse{Rules: permitListRules}, nil
+}
+
+// GetResourceInfo returns the current private IP and state of the instance
+func (s *AWSPluginServer) GetResourceInfo(ctx context.Context, req *paragliderpb.GetResourceInfoRequest) (*paragliderpb.GetResourceInfoResponse, error) {
+	_, resourceInfo, instance, err := s.getResourceInstance(ctx, req.Namespace, req.Uri)
+	if err != nil {
+		return nil, err
+	}
+	resource := getResource(req.Uri, resourceInfo.Region, instance, 0)
+	return &paragliderpb.GetResourceInfoResponse{Uri: req.Uri, Ip: resource.Ip, Ips: getInstanceIps(instance), State: resource.State}, nil
+}
+
+// GetResource returns the inventory entry of a Paraglider-managed instance
+func (s *AWSPluginServer) GetResource(ctx context.Context, req *paragliderpb.GetResourceRequest) (*paragliderpb.GetResourceResponse, error) {
+	client, resourceInfo, instance, err := s.getResourceInstance(ctx, req.Namespace, req.Uri)
+	if err != nil {
+		return nil, err
+	}
+	groupId, err := getInstanceSecurityGroupId(instance, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	sgRules, err := getPermitListSecurityGroupRules(ctx, client, groupId)
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.GetResourceResponse{Resource: getResource(req.Uri, resourceInfo.Region, instance, len(sgRules))}, nil
+}
+
+// ListResources returns the inventory of Paraglider-managed instances in the deployment across all regions
+func (s *AWSPluginServer) ListResources(ctx context.Context, req *paragliderpb.ListResourcesRequest) (*paragliderpb.ListResourcesResponse, error) {
+	account, err := getAccount(req.Deployment.Id)
+	if err != nil {
+		return nil, err
+	}
+	resources := []*paragliderpb.Resource{}
+	err = s.forEachRegion(ctx, func(region string, client *ec2.Client) error {
+		paginator := ec2.NewDescribeInstancesPaginator(client, &ec2.DescribeInstancesInput{
+			Filters: []types.Filter{
+				tagFilter(namespaceTagKey, req.Deployment.Namespace),
+				filter("instance-state-name", string(types.InstanceStateNamePending), string(types.InstanceStateNameRunning), string(types.InstanceStateNameStopping), string(types.InstanceStateNameStopped)),
+			},
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("unable to describe instances: %w", err)
+			}
+			for _, reservation := range page.Reservations {
+				for _, instance := range reservation.Instances {
+					groupId, err := getInstanceSecurityGroupId(&instance, req.Deployment.Namespace)
+					if err != nil {
+						return err
+					}
+					sgRules, err := getPermitListSecurityGroupRules(ctx, client, groupId)
+					if err != nil {
+						return err
+					}
+					uri := getInstanceUri(account, region, aws.ToString(instance.InstanceId))
+					resources = append(resources, getResource(uri, region, &instance, len(sgRules)))
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.ListResourcesResponse{Resources: resources}, nil
+}
+
+func (s *AWSPluginServer) AddPermitListRules(ctx context.Context, req *paragliderpb.AddPermitListRulesRequest) (*paragliderpb.AddPermitListRulesResponse, error) {
+	client, resourceInfo, instance, err := s.getResourceInstance(ctx, req.Namespace, req.Resource)
+	if err != nil {
+		return nil, err
+	}
+	groupId, err := getInstanceSecurityGroupId(instance, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get existing security group rules
+	existingRules, err := getPermitListSecurityGroupRules(ctx, client, groupId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get used address spaces of all clouds
+	orchestratorConn, err := grpc.NewClient(s.orchestratorServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("unable to establish connection with orchestrator: %w", err)
+	}
+	defer orchestratorConn.Close()
+	orchestratorClient := paragliderpb.NewControllerClient(orchestratorConn)
+	getUsedAddressSpacesResp, err := orchestratorClient.GetUsedAddressSpaces(context.Background(), &emptypb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get used address spaces: %w", err)
+	}
+
+	var vpc *vpcInfo               // VPC of the instance (only looked up when peering is needed)
+	connected := map[string]bool{} // Clouds and VPCs already connected by this request
+	for _, permitListRule := range req.Rules {
+		existingSgRules, exists := existingRules[permitListRule.Name]
+		if exists {
+			equivalent, err := isSecurityGroupRulesEqPermitListRule(existingSgRules, permitListRule)
+			if err != nil {
+				return nil, fmt.Errorf("unable to check if security group rules are equivalent to permit list rule: %w", err)
+			}
+			if equivalent {
+				continue
+			}
+		}
+
+		// Get all peering cloud infos
+		peeringCloudInfos, err := utils.GetPermitListRulePeeringCloudInfo(permitListRule, getUsedAddressSpacesResp.AddressSpaceMappings)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get peering cloud infos: %w", err)
+		}
+
+		for i, peeringCloudInfo := range peeringCloudInfos {
+			if peeringCloudInfo == nil {
+				continue
+			}
+			if vpc == nil {
+				vpc, err = getInstanceVpc(ctx, client, resourceInfo.Region, instance)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if peeringCloudInfo.Cloud != utils.AWS {
+				if connected[peeringCloudInfo.Cloud+"/"+peeringCloudInfo.Namespace] {
+					continue
+				}
+				// Create VPN connections
+				connectCloudsReq := &paragliderpb.ConnectCloudsRequest{
+					CloudA:              utils.AWS,
+					CloudANamespace:     req.Namespace,
+					CloudB:              peeringCloudInfo.Cloud,
+					CloudBNamespace:     peeringCloudInfo.Namespace,
+					AddressSpacesCloudA: vpc.CidrBlocks, // identifies the VPC served by the VPN gateway
+				}
+				_, err := orchestratorClient.ConnectClouds(ctx, connectCloudsReq)
+				if err != nil {
+					return nil, fmt.Errorf("unable to connect clouds : %w", err)
+				}
+				connected[peeringCloudInfo.Cloud+"/"+peeringCloudInfo.Namespace] = true
+			} else {
+				// Create VPC peering for targets in other VPCs (i.e., other namespaces or regions)
+				peerVpc, err := s.findVpc(ctx, peeringCloudInfo.Namespace, permitListRule.Targets[i])
+				if err != nil {
+					return nil, err
+				}
+				if peerVpc == nil {
+					return nil, fmt.Errorf("no vpc in namespace %s contains %s", peeringCloudInfo.Namespace, permitListRule.Targets[i])
+				}
+				if peerVpc.Id == vpc.Id || connected[peerVpc.Id] {
+					continue
+				}
+				err = s.peerVpcs(ctx, req.Namespace, vpc, peeringCloudInfo.Namespace, peerVpc, parseUri(peeringCloudInfo.Deployment)["accounts"])
+				if err != nil {
+					return nil, fmt.Errorf("unable to peer vpc %s with %s: %w", vpc.Id, peerVpc.Id, err)
+				}
+				connected[peerVpc.Id] = true
+			}
+		}
+
+		// Security group rules can't be modified in place, so rules which changed are replaced
+		if exists {
+			if err := revokeSecurityGroupRules(ctx, client, groupId, existingSgRules); err != nil {
+				return nil, err
+			}
+		}
+		if err := authorizePermitListRule(ctx, client, groupId, permitListRule); err != nil {
+			return nil, err
+		}
+	}
+
+	return &paragliderpb.AddPermitListRulesResponse{}, nil
+}
+
+func (s *AWSPluginServer) DeletePermitListRules(ctx context.Context, req *paragliderpb.DeletePermitListRulesRequest) (*paragliderpb.DeletePermitListRulesResponse, error) {
+	client, _, instance, err := s.getResourceInstance(ctx, req.Namespace, req.Resource)
+	if err != nil {
+		return nil, err
+	}
+	groupId, err := getInstanceSecurityGroupId(instance, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	existingRules, err := getPermitListSecurityGroupRules(ctx, client, groupId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Revoke security group rules corresponding to provided permit list rules
+	for _, ruleName := range req.RuleNames {
+		if sgRules, ok := existingRules[ruleName]; ok {
+			if err := revokeSecurityGroupRules(ctx, client, groupId, sgRules); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &paragliderpb.DeletePermitListRulesResponse{}, nil
+}
+
+func (s *AWSPluginServer) CreateResource(ctx context.Context, resourceDescription *paragliderpb.CreateResourceRequest) (*paragliderpb.CreateResourceResponse, error) {
+	account, err := getAccount(resourceDescription.Deployment.Id)
+	if err != nil {
+		return nil, err
+	}
+	namespace := resourceDescription.Deployment.Namespace
+
+	// Translate cloud-agnostic descriptions into AWS ones
+	resourceDescription, err = translateResourceDescription(resourceDescription)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read and validate user-provided description
+	runInstancesInput := &ec2.RunInstancesInput{}
+	if err := json.Unmarshal(resourceDescription.Description, runInstancesInput); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "unable to parse resource description: %v", err)
+	}
+	if runInstancesInput.Placement == nil || aws.ToString(runInstancesInput.Placement.AvailabilityZone) == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "resource description must specify an availability zone in its placement")
+	}
+	if runInstancesInput.SubnetId != nil || len(runInstancesInput.NetworkInterfaces) > 0 || len(runInstancesInput.SecurityGroupIds) > 0 || len(runInstancesInput.SecurityGroups) > 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "resource description cannot specify subnets, network interfaces or security groups since Paraglider manages them")
+	}
+	zone := aws.ToString(runInstancesInput.Placement.AvailabilityZone)
+	region := getRegionFromZone(zone)
+
+	client, err := s.getEC2Client(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if Paraglider specific VPC already exists
+	vpc, err := getVpc(ctx, client, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if vpc == nil {
+		// Find unused address space
+		conn, err := grpc.NewClient(s.orchestratorServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, fmt.Errorf("unable to establish connection with orchestrator: %w", err)
+		}
+		defer conn.Close()
+		orchestratorClient := paragliderpb.NewControllerClient(conn)
+		numAddressSpaces := int32(1)
+		findUnusedAddressSpacesResp, err := orchestratorClient.FindUnusedAddressSpaces(context.Background(), &paragliderpb.FindUnusedAddressSpacesRequest{Num: &numAddressSpaces})
+		if err != nil {
+			return nil, fmt.Errorf("unable to find unused address space: %w", err)
+		}
+
+		dnsServers, err := utils.GetDnsServers(context.Background(), orchestratorClient)
+		if err != nil {
+			return nil, err
+		}
+
+		vpc, err = createVpc(ctx, client, namespace, findUnusedAddressSpacesResp.AddressSpaces[0], dnsServers)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	subnet, err := getOrCreateSubnet(ctx, client, namespace, vpc, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the security group holding the instance's permit list
+	createSecurityGroupResp, err := client.CreateSecurityGroup(ctx, &ec2.CreateSecurityGroupInput{
+		GroupName:         aws.String(getSecurityGroupName(namespace, resourceDescription.Name)),
+		Description:       aws.String("Paraglider permit list of " + resourceDescription.Name),
+		VpcId:             vpc.VpcId,
+		TagSpecifications: getTagSpecifications(types.ResourceTypeSecurityGroup, namespace, getSecurityGroupName(namespace, resourceDescription.Name)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create security group: %w", err)
+	}
+	groupId := aws.ToString(createSecurityGroupResp.GroupId)
+
+	// Deny all egress traffic since security groups implicitly allow all egress traffic
+	_, err = client.RevokeSecurityGroupEgress(ctx, &ec2.RevokeSecurityGroupEgressInput{
+		GroupId: aws.String(groupId),
+		IpPermissions: []types.IpPermission{
+			{IpProtocol: aws.String("-1"), IpRanges: []types.IpRange{{CidrIp: aws.String("0.0.0.0/0")}}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to revoke default egress rule: %w", err)
+	}
+
+	// Launch the instance in the Paraglider subnet
+	runInstancesInput.MinCount = aws.Int32(1)
+	runInstancesInput.MaxCount = aws.Int32(1)
+	runInstancesInput.SubnetId = subnet.SubnetId
+	runInstancesInput.SecurityGroupIds = []string{groupId}
+	instanceTags := getTagSpecifications(types.ResourceTypeInstance, namespace, resourceDescription.Name)[0]
+	tagged := false
+	for i := range runInstancesInput.TagSpecifications {
+		if runInstancesInput.TagSpecifications[i].ResourceType == types.ResourceTypeInstance {
+			runInstancesInput.TagSpecifications[i].Tags = append(runInstancesInput.TagSpecifications[i].Tags, instanceTags.Tags...)
+			tagged = true
+		}
+	}
+	if !tagged {
+		runInstancesInput.TagSpecifications = append(runInstancesInput.TagSpecifications, instanceTags)
+	}
+	runInstancesResp, err := client.RunInstances(ctx, runInstancesInput)
+	if err != nil {
+		return nil, fmt.Errorf("unable to run instance: %w", err)
+	}
+	if len(runInstancesResp.Instances) == 0 {
+		return nil, fmt.Errorf("no instance was launched")
+	}
+	instanceId := aws.ToString(runInstancesResp.Instances[0].InstanceId)
+
+	err = ec2.NewInstanceRunningWaiter(client).Wait(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{instanceId}}, instanceWaitTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("unable to wait for instance %s: %w", instanceId, err)
+	}
+
+	return &paragliderpb.CreateResourceResponse{
+		Name: resourceDescription.Name,
+		Uri:  getInstanceUri(account, region, instanceId),
+		Ip:   aws.ToString(runInstancesResp.Instances[0].PrivateIpAddress),
+	}, nil
+}
+
+func (s *AWSPluginServer) GetUsedAddressSpaces(ctx context.Context, req *paragliderpb.GetUsedAddressSpacesRequest) (*paragliderpb.GetUsedAddressSpacesResponse, error) {
+	resp := &paragliderpb.GetUsedAddressSpacesResponse{}
+	resp.AddressSpaceMappings = make([]*paragliderpb.AddressSpaceMapping, len(req.Deployments))
+	for i, deployment := range req.Deployments {
+		resp.AddressSpaceMappings[i] = &paragliderpb.AddressSpaceMapping{
+			Cloud:     utils.AWS,
+			Namespace: deployment.Namespace,
+		}
+		vpcs, err := s.getNamespaceVpcs(ctx, deployment.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, vpc := range vpcs {
+			resp.AddressSpaceMappings[i].AddressSpaces = append(resp.AddressSpaceMappings[i].AddressSpaces, vpc.CidrBlocks...)
+		}
+	}
+	return resp, nil
+}
+
+func (s *AWSPluginServer) GetUsedAsns(ctx context.Context, req *paragliderpb.GetUsedAsnsRequest) (*paragliderpb.GetUsedAsnsResponse, error) {
+	resp := &paragliderpb.GetUsedAsnsResponse{}
+	for _, deployment := range req.Deployments {
+		err := s.forEachRegion(ctx, func(region string, client *ec2.Client) error {
+			vpnGateway, err := getVpnGateway(ctx, client, deployment.Namespace)
+			if err != nil {
+				return err
+			}
+			if vpnGateway != nil {
+				resp.Asns = append(resp.Asns, uint32(aws.ToInt64(vpnGateway.AmazonSideAsn)))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func (s *AWSPluginServer) GetUsedBgpPeeringIpAddresses(ctx context.Context, req *paragliderpb.GetUsedBgpPeeringIpAddressesRequest) (*paragliderpb.GetUsedBgpPeeringIpAddressesResponse, error) {
+	resp := &paragliderpb.GetUsedBgpPeeringIpAddressesResponse{}
+	for _, deployment := range req.Deployments {
+		err := s.forEachRegion(ctx, func(region string, client *ec2.Client) error {
+			describeVpnConnectionsResp, err := client.DescribeVpnConnections(ctx, &ec2.DescribeVpnConnectionsInput{
+				Filters: []types.Filter{tagFilter(namespaceTagKey, deployment.Namespace), filter("state", vpnActiveStates...)},
+			})
+			if err != nil {
+				return fmt.Errorf("unable to describe vpn connections: %w", err)
+			}
+			// Both tunnels of each connection are reported since AWS picks the inside address space of unused tunnels
+			for _, vpnConnection := range describeVpnConnectionsResp.VpnConnections {
+				if vpnConnection.Options == nil {
+					continue
+				}
+				for _, tunnel := range vpnConnection.Options.TunnelOptions {
+					if tunnel.TunnelInsideCidr == nil {
+						continue
+					}
+					ipAddress, err := getTunnelInsideIpAddress(*tunnel.TunnelInsideCidr)
+					if err != nil {
+						return err
+					}
+					resp.IpAddresses = append(resp.IpAddresses, ipAddress)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// CreateVpnGateway creates a virtual private gateway for the VPC containing the request's address space
+// AWS only allocates tunnel IP addresses with VPN connections, so they are returned by CreateVpnConnections instead
+func (s *AWSPluginServer) CreateVpnGateway(ctx context.Context, req *paragliderpb.CreateVpnGatewayRequest) (*paragliderpb.CreateVpnGatewayResponse, error) {
+	vpc, err := s.getVpnVpc(ctx, req.Deployment.Namespace, req.AddressSpace)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.getEC2Client(ctx, vpc.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	vpnGateway, err := getVpnGateway(ctx, client, req.Deployment.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if vpnGateway == nil {
+		// Find unused ASN
+		conn, err := grpc.NewClient(s.orchestratorServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, fmt.Errorf("unable to establish connection with orchestrator: %w", err)
+		}
+		defer conn.Close()
+		orchestratorClient := paragliderpb.NewControllerClient(conn)
+		findUnusedAsnResp, err := orchestratorClient.FindUnusedAsn(ctx, &paragliderpb.FindUnusedAsnRequest{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to find unused asn: %w", err)
+		}
+
+		createVpnGatewayResp, err := client.CreateVpnGateway(ctx, &ec2.CreateVpnGatewayInput{
+			Type:              types.GatewayTypeIpsec1,
+			AmazonSideAsn:     aws.Int64(int64(findUnusedAsnResp.Asn)),
+			TagSpecifications: getTagSpecifications(types.ResourceTypeVpnGateway, req.Deployment.Namespace, getVpnGatewayName(req.Deployment.Namespace)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to create vpn gateway: %w", err)
+		}
+		vpnGateway = createVpnGatewayResp.VpnGateway
+	}
+
+	// Attach the VPN gateway to the VPC
+	attached := false
+	for _, attachment := range vpnGateway.VpcAttachments {
+		if aws.ToString(attachment.VpcId) == vpc.Id && (attachment.State == types.AttachmentStatusAttaching || attachment.State == types.AttachmentStatusAttached) {
+			attached = true
+		}
+	}
+	if !attached {
+		_, err = client.AttachVpnGateway(ctx, &ec2.AttachVpnGatewayInput{VpcId: aws.String(vpc.Id), VpnGatewayId: vpnGateway.VpnGatewayId})
+		if err != nil {
+			return nil, fmt.Errorf("unable to attach vpn gateway: %w", err)
+		}
+	}
+
+	// Propagate the routes learned over BGP to the VPC
+	routeTable, err := getMainRouteTable(ctx, client, vpc.Id)
+	if err != nil {
+		return nil, err
+	}
+	propagating := false
+	for _, propagatingVgw := range routeTable.PropagatingVgws {
+		if aws.ToString(propagatingVgw.GatewayId) == aws.ToString(vpnGateway.VpnGatewayId) {
+			propagating = true
+		}
+	}
+	if !propagating {
+		_, err = client.EnableVgwRoutePropagation(ctx, &ec2.EnableVgwRoutePropagationInput{GatewayId: vpnGateway.VpnGatewayId, RouteTableId: routeTable.RouteTableId})
+		if err != nil {
+			return nil, fmt.Errorf("unable to enable route propagation: %w", err)
+		}
+	}
+
+	return &paragliderpb.CreateVpnGatewayResponse{Asn: uint32(aws.ToInt64(vpnGateway.AmazonSideAsn))}, nil
+}
+
+// CreateVpnConnections creates a customer gateway and a VPN connection for each VPN gateway interface of the other cloud
+func (s *AWSPluginServer) CreateVpnConnections(ctx context.Context, req *paragliderpb.CreateVpnConnectionsRequest) (*paragliderpb.CreateVpnConnectionsResponse, error) {
+	if req.IsBgpDisabled {
+		return nil, status.Errorf(codes.Unimplemented, "AWS only supports VPN connections with BGP")
+	}
+	vpnNumConnections := utils.GetNumVpnConnections(req.Cloud, utils.AWS)
+	if len(req.GatewayIpAddresses) < vpnNumConnections || len(req.BgpIpAddresses) < vpnNumConnections {
+		return nil, status.Errorf(codes.InvalidArgument, "%d gateway and bgp ip addresses are required for connections to %s", vpnNumConnections, req.Cloud)
+	}
+
+	vpc, err := s.getVpnVpc(ctx, req.Deployment.Namespace, req.AddressSpace)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.getEC2Client(ctx, vpc.Region)
+	if err != nil {
+		return nil, err
+	}
+	vpnGateway, err := getVpnGateway(ctx, client, req.Deployment.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if vpnGateway == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "vpn gateway of namespace %s does not exist in %s", req.Deployment.Namespace, vpc.Region)
+	}
+
+	resp := &paragliderpb.CreateVpnConnectionsResponse{GatewayIpAddresses: make([]string, vpnNumConnections)}
+	for i := 0; i < vpnNumConnections; i++ {
+		// Create customer gateway for the VPN gateway interface of the other cloud
+		customerGatewayName := getCustomerGatewayName(req.Deployment.Namespace, req.Cloud, i)
+		customerGateway, err := getCustomerGateway(ctx, client, customerGatewayName)
+		if err != nil {
+			return nil, err
+		}
+		if customerGateway == nil {
+			createCustomerGatewayInput := &ec2.CreateCustomerGatewayInput{
+				Type:              types.GatewayTypeIpsec1,
+				IpAddress:         aws.String(req.GatewayIpAddresses[i]),
+				TagSpecifications: getTagSpecifications(types.ResourceTypeCustomerGateway, req.Deployment.Namespace, customerGatewayName),
+			}
+			if req.Asn > math.MaxInt32 {
+				createCustomerGatewayInput.BgpAsnExtended = aws.Int64(int64(req.Asn))
+			} else {
+				createCustomerGatewayInput.BgpAsn = aws.Int32(int32(req.Asn))
+			}
+			createCustomerGatewayResp, err := client.CreateCustomerGateway(ctx, createCustomerGatewayInput)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create customer gateway: %w", err)
+			}
+			customerGateway = createCustomerGatewayResp.CustomerGateway
+		}
+
+		// Create VPN connection whose first tunnel uses the BGP peering subnet allocated by the orchestrator
+		vpnConnectionName := getVpnConnectionName(req.Deployment.Namespace, req.Cloud, i)
+		vpnConnection, err := getVpnConnection(ctx, client, vpnConnectionName)
+		if err != nil {
+			return nil, err
+		}
+		if vpnConnection == nil {
+			tunnelInsideCidr, err := getBgpPeeringSubnet(req.BgpIpAddresses[i])
+			if err != nil {
+				return nil, err
+			}
+			createVpnConnectionResp, err := client.CreateVpnConnection(ctx, &ec2.CreateVpnConnectionInput{
+				CustomerGatewayId: customerGateway.CustomerGatewayId,
+				VpnGatewayId:      vpnGateway.VpnGatewayId,
+				Type:              aws.String(vpnType),
+				Options: &types.VpnConnectionOptionsSpecification{
+					StaticRoutesOnly: aws.Bool(false),
+					TunnelOptions: []types.VpnTunnelOptionsSpecification{
+						{TunnelInsideCidr: aws.String(tunnelInsideCidr), PreSharedKey: aws.String(req.SharedKey)},
+					},
+				},
+				TagSpecifications: getTagSpecifications(types.ResourceTypeVpnConnection, req.Deployment.Namespace, vpnConnectionName),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("unable to create vpn connection: %w", err)
+			}
+			vpnConnection = createVpnConnectionResp.VpnConnection
+		}
+
+		resp.GatewayIpAddresses[i], err = getTunnelOutsideIpAddress(vpnConnection)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// GetNetworkAddressSpaces returns the address spaces in the virtual network containing the provided address space
+func (s *AWSPluginServer) GetNetworkAddressSpaces(ctx context.Context, req *paragliderpb.GetNetworkAddressSpacesRequest) (*paragliderpb.GetNetworkAddressSpacesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "GetNetworkAddressSpaces is currently not implemented by AWS, implying plugin does not support BGP disabled VPN connections")
+}
+
+func Setup(port int, orchestratorServerAddr string) *AWSPluginServer {
+	lis, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(utils.StatusErrorInterceptor(utils.AWS, getErrorStatusCode)))
+	awsServer := &AWSPluginServer{}
+	awsServer.orchestratorServerAddr = orchestratorServerAddr
+	paragliderpb.RegisterCloudPluginServer(grpcServer, awsServer)
+	fmt.Println("Starting server on port :", port)
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			fmt.Println(err.Error())
+		}
+	}()
+	return awsServer
+}
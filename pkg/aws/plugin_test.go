@@ -0,0 +1,534 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	fake "github.com/paraglider-project/paraglider/pkg/fake/orchestrator/rpc"
+	"github.com/paraglider-project/paraglider/pkg/orchestrator"
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var fakeDeployment = &paragliderpb.ParagliderDeployment{Id: fakeDeploymentId, Namespace: fakeNamespace}
+
+// Sets up a fake EC2 server with a fake orchestrator and returns a plugin server using both
+func setupPluginServer(t *testing.T) (*fakeServerState, *fake.FakeOrchestratorRPCServer, *AWSPluginServer) {
+	fakeServerState := newFakeServerState(fakeRegion, fakeOtherRegion)
+	fakeOrchestratorServer, fakeOrchestratorServerAddr, err := fake.SetupFakeOrchestratorRPCServer(utils.AWS)
+	require.NoError(t, err)
+	_, s := setup(t, fakeServerState, fakeOrchestratorServerAddr)
+	return fakeServerState, fakeOrchestratorServer, s
+}
+
+// Creates a fake instance through the plugin
+func createFakeInstance(t *testing.T, s *AWSPluginServer, name string, zone string) *paragliderpb.CreateResourceResponse {
+	resp, err := s.CreateResource(context.Background(), getFakeInstanceDescription(name, zone))
+	require.NoError(t, err)
+	return resp
+}
+
+func TestCreateResource(t *testing.T) {
+	fakeServerState, _, s := setupPluginServer(t)
+
+	resp := createFakeInstance(t, s, fakeInstanceName, fakeZone)
+	require.Len(t, fakeServerState.instances, 1)
+	instance := fakeServerState.instances[0]
+	assert.Equal(t, fakeInstanceName, resp.Name)
+	assert.Equal(t, getInstanceUri(fakeAccount, fakeRegion, instance.InstanceId), resp.Uri)
+	assert.Equal(t, instance.PrivateIpAddress, resp.Ip)
+	assert.Equal(t, fakeImageId, instance.ImageId)
+	assert.Contains(t, instance.Tags, fakeTag{Key: namespaceTagKey, Value: fakeNamespace})
+	assert.Contains(t, instance.Tags, fakeTag{Key: nameTagKey, Value: fakeInstanceName})
+
+	// The VPC gets the address space found by the orchestrator and a subnet in the instance's zone
+	require.Len(t, fakeServerState.vpcs, 1)
+	assert.Equal(t, "10.0.0.0/16", fakeServerState.vpcs[0].CidrBlock)
+	require.Len(t, fakeServerState.subnets, 1)
+	assert.Equal(t, "10.0.0.0/20", fakeServerState.subnets[0].CidrBlock)
+	assert.Equal(t, fakeZone, fakeServerState.subnets[0].AvailabilityZone)
+	assert.Equal(t, "10.0.0.4", resp.Ip)
+
+	// The security group denies all traffic until rules are added
+	require.Len(t, instance.Groups, 1)
+	assert.Equal(t, getSecurityGroupName(fakeNamespace, fakeInstanceName), instance.Groups[0].GroupName)
+	assert.Empty(t, fakeServerState.sgRules)
+}
+
+func TestCreateResourceDnsServers(t *testing.T) {
+	fakeServerState, fakeOrchestratorServer, s := setupPluginServer(t)
+
+	// Without DNS servers the VPC keeps the default DHCP options
+	createFakeInstance(t, s, "vm-1", fakeZone)
+	require.Len(t, fakeServerState.vpcs, 1)
+	assert.Empty(t, fakeServerState.vpcs[0].DhcpOptionsId)
+	assert.Empty(t, fakeServerState.dhcpOptions)
+
+	// New VPCs point their DHCP options at the DNS servers of the orchestrator
+	fakeOrchestratorServer.DnsServers = []string{"10.0.0.53", "10.0.0.54"}
+	createFakeInstance(t, s, "vm-2", fakeOtherRegion+"a")
+	require.Len(t, fakeServerState.vpcs, 2)
+	require.Len(t, fakeServerState.dhcpOptions, 1)
+	dhcpOptions := fakeServerState.dhcpOptions[0]
+	assert.Equal(t, dhcpOptions.DhcpOptionsId, fakeServerState.vpcs[1].DhcpOptionsId)
+	assert.Equal(t, []fakeDhcpConfiguration{{Key: "domain-name-servers", Values: []fakeAttributeValue{{"10.0.0.53"}, {"10.0.0.54"}}}}, dhcpOptions.DhcpConfigurations)
+	assert.Contains(t, dhcpOptions.Tags, fakeTag{Key: namespaceTagKey, Value: fakeNamespace})
+}
+
+func TestCreateResourceReusesNetwork(t *testing.T) {
+	fakeServerState, _, s := setupPluginServer(t)
+
+	first := createFakeInstance(t, s, "vm-1", fakeZone)
+	second := createFakeInstance(t, s, "vm-2", fakeZone)
+	third := createFakeInstance(t, s, "vm-3", fakeRegion+"b")
+
+	assert.Equal(t, "10.0.0.4", first.Ip)
+	assert.Equal(t, "10.0.0.5", second.Ip)
+	assert.Equal(t, "10.0.16.4", third.Ip)
+	assert.Len(t, fakeServerState.vpcs, 1)
+	assert.Len(t, fakeServerState.subnets, 2)
+}
+
+func TestCreateResourceInvalidDescription(t *testing.T) {
+	_, _, s := setupPluginServer(t)
+
+	// Missing availability zone
+	_, err := s.CreateResource(context.Background(), &paragliderpb.CreateResourceRequest{
+		Deployment:  fakeDeployment,
+		Name:        fakeInstanceName,
+		Description: []byte(`{"ImageId": "ami-0123456789abcdef0", "InstanceType": "t3.micro"}`),
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Network settings managed by Paraglider
+	_, err = s.CreateResource(context.Background(), &paragliderpb.CreateResourceRequest{
+		Deployment:  fakeDeployment,
+		Name:        fakeInstanceName,
+		Description: []byte(`{"ImageId": "ami-0123456789abcdef0", "Placement": {"AvailabilityZone": "us-east-1a"}, "SubnetId": "subnet-1"}`),
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Invalid deployment
+	req := getFakeInstanceDescription(fakeInstanceName, fakeZone)
+	req.Deployment = &paragliderpb.ParagliderDeployment{Id: "projects/" + fakeAccount, Namespace: fakeNamespace}
+	_, err = s.CreateResource(context.Background(), req)
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCreateResourceParagliderVM(t *testing.T) {
+	fakeServerState, _, s := setupPluginServer(t)
+
+	description, err := json.Marshal(getFakeParagliderVM())
+	require.NoError(t, err)
+	resp, err := s.CreateResource(context.Background(), &paragliderpb.CreateResourceRequest{Deployment: fakeDeployment, Name: fakeInstanceName, Description: description})
+	require.NoError(t, err)
+	assert.Equal(t, fakeInstanceName, resp.Name)
+	require.Len(t, fakeServerState.instances, 1)
+	assert.Equal(t, "t3.xlarge", fakeServerState.instances[0].InstanceType)
+	assert.Equal(t, fakeZone, fakeServerState.instances[0].AvailabilityZone)
+}
+
+func TestGetResourceInfo(t *testing.T) {
+	fakeServerState, _, s := setupPluginServer(t)
+	created := createFakeInstance(t, s, fakeInstanceName, fakeZone)
+
+	resp, err := s.GetResourceInfo(context.Background(), &paragliderpb.GetResourceInfoRequest{Namespace: fakeNamespace, Uri: created.Uri})
+	require.NoError(t, err)
+	assert.Equal(t, created.Uri, resp.Uri)
+	assert.Equal(t, created.Ip, resp.Ip)
+	assert.Equal(t, []string{created.Ip}, resp.Ips)
+	assert.Equal(t, "running", resp.State)
+
+	// Secondary and IPv6 addresses of all network interfaces are reported after the primary IP
+	instance := fakeServerState.instances[0]
+	instance.NetworkInterfaces[0].PrivateIpAddresses = append(instance.NetworkInterfaces[0].PrivateIpAddresses, fakePrivateIpAddress{PrivateIpAddress: "10.0.0.10"})
+	instance.NetworkInterfaces = append(instance.NetworkInterfaces, fakeInstanceNetworkInterface{
+		NetworkInterfaceId: "eni-secondary",
+		PrivateIpAddresses: []fakePrivateIpAddress{{PrivateIpAddress: "10.0.1.4", Primary: true}},
+		Ipv6Addresses:      []fakeIpv6Address{{Ipv6Address: "2001:db8::4"}},
+	})
+	resp, err = s.GetResourceInfo(context.Background(), &paragliderpb.GetResourceInfoRequest{Namespace: fakeNamespace, Uri: created.Uri})
+	require.NoError(t, err)
+	assert.Equal(t, created.Ip, resp.Ip)
+	assert.Equal(t, []string{created.Ip, "10.0.0.10", "10.0.1.4", "2001:db8::4"}, resp.Ips)
+
+	// Wrong namespace
+	_, err = s.GetResourceInfo(context.Background(), &paragliderpb.GetResourceInfoRequest{Namespace: "other", Uri: created.Uri})
+	require.Error(t, err)
+
+	// Missing instance
+	_, err = s.GetResourceInfo(context.Background(), &paragliderpb.GetResourceInfoRequest{Namespace: fakeNamespace, Uri: getInstanceUri(fakeAccount, fakeRegion, "i-missing")})
+	require.Error(t, err)
+	assert.True(t, isErrorNotFound(err))
+
+	// Invalid URI
+	_, err = s.GetResourceInfo(context.Background(), &paragliderpb.GetResourceInfoRequest{Namespace: fakeNamespace, Uri: "i-missing"})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestListResources(t *testing.T) {
+	_, _, s := setupPluginServer(t)
+	first := createFakeInstance(t, s, "vm-1", fakeZone)
+	second := createFakeInstance(t, s, "vm-2", fakeOtherZone)
+
+	_, err := s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{
+		Namespace: fakeNamespace,
+		Resource:  first.Uri,
+		Rules:     []*paragliderpb.PermitListRule{{Name: "ssh", Direction: paragliderpb.Direction_INBOUND, SrcPort: -1, DstPort: 22, Protocol: 6, Targets: []string{"192.0.2.0/24"}}},
+	})
+	require.NoError(t, err)
+
+	resp, err := s.ListResources(context.Background(), &paragliderpb.ListResourcesRequest{Deployment: fakeDeployment})
+	require.NoError(t, err)
+	require.Len(t, resp.Resources, 2)
+	resources := map[string]*paragliderpb.Resource{}
+	for _, resource := range resp.Resources {
+		resources[resource.Name] = resource
+	}
+	assert.Equal(t, first.Uri, resources["vm-1"].Uri)
+	assert.Equal(t, fakeRegion, resources["vm-1"].Region)
+	assert.Equal(t, int32(1), resources["vm-1"].RuleCount)
+	assert.Equal(t, second.Uri, resources["vm-2"].Uri)
+	assert.Equal(t, fakeOtherRegion, resources["vm-2"].Region)
+	assert.Equal(t, int32(0), resources["vm-2"].RuleCount)
+
+	getResp, err := s.GetResource(context.Background(), &paragliderpb.GetResourceRequest{Namespace: fakeNamespace, Uri: first.Uri})
+	require.NoError(t, err)
+	assert.Equal(t, resources["vm-1"], getResp.Resource)
+
+	// Other namespaces have no resources
+	resp, err = s.ListResources(context.Background(), &paragliderpb.ListResourcesRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: fakeDeploymentId, Namespace: "other"}})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Resources)
+}
+
+func TestPermitListRules(t *testing.T) {
+	fakeServerState, _, s := setupPluginServer(t)
+	created := createFakeInstance(t, s, fakeInstanceName, fakeZone)
+
+	rules := []*paragliderpb.PermitListRule{
+		{Name: "https", Direction: paragliderpb.Direction_INBOUND, SrcPort: -1, DstPort: 443, Protocol: 6, Targets: []string{"192.0.2.0/24", "198.51.100.1/32"}, Tags: []string{"web"}},
+		{Name: "dns", Direction: paragliderpb.Direction_OUTBOUND, SrcPort: -1, DstPort: 53, Protocol: 17, Targets: []string{"198.51.100.0/24"}},
+		{Name: "ping", Direction: paragliderpb.Direction_INBOUND, SrcPort: -1, DstPort: -1, Protocol: 1, Targets: []string{"192.0.2.0/24"}},
+	}
+	_, err := s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{Namespace: fakeNamespace, Resource: created.Uri, Rules: rules})
+	require.NoError(t, err)
+
+	// One security group rule per target
+	require.Len(t, fakeServerState.sgRules, 4)
+	for _, sgRule := range fakeServerState.sgRules {
+		if sgRule.IpProtocol == "6" {
+			assert.False(t, sgRule.IsEgress)
+			assert.Equal(t, int32(443), sgRule.FromPort)
+			assert.Equal(t, int32(443), sgRule.ToPort)
+		}
+	}
+
+	getResp, err := s.GetPermitList(context.Background(), &paragliderpb.GetPermitListRequest{Namespace: fakeNamespace, Resource: created.Uri})
+	require.NoError(t, err)
+	require.Len(t, getResp.Rules, 3)
+	for _, expected := range rules {
+		found := false
+		for _, actual := range getResp.Rules {
+			if actual.Name == expected.Name {
+				found = true
+				equivalent, err := isSecurityGroupRulesEqPermitListRule(getPermitListSgRules(fakeServerState, expected.Name), actual)
+				require.NoError(t, err)
+				assert.True(t, equivalent)
+				assert.Equal(t, expected.DstPort, actual.DstPort)
+				assert.Equal(t, expected.Protocol, actual.Protocol)
+				assert.Equal(t, expected.Direction, actual.Direction)
+				assert.ElementsMatch(t, expected.Targets, actual.Targets)
+				assert.ElementsMatch(t, expected.Tags, actual.Tags)
+			}
+		}
+		assert.True(t, found, "rule %s not found", expected.Name)
+	}
+
+	// Changed rules are replaced while unchanged ones are kept
+	rules[0].Targets = []string{"192.0.2.0/24"}
+	_, err = s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{Namespace: fakeNamespace, Resource: created.Uri, Rules: rules})
+	require.NoError(t, err)
+	require.Len(t, fakeServerState.sgRules, 3)
+
+	_, err = s.DeletePermitListRules(context.Background(), &paragliderpb.DeletePermitListRulesRequest{Namespace: fakeNamespace, Resource: created.Uri, RuleNames: []string{"https", "dns", "missing"}})
+	require.NoError(t, err)
+	require.Len(t, fakeServerState.sgRules, 1)
+	assert.Equal(t, "ping", getTagValueOfFakeTags(fakeServerState.sgRules[0].Tags, ruleTagKey))
+}
+
+func TestPermitListRulesWrongNamespace(t *testing.T) {
+	_, _, s := setupPluginServer(t)
+	created := createFakeInstance(t, s, fakeInstanceName, fakeZone)
+
+	_, err := s.GetPermitList(context.Background(), &paragliderpb.GetPermitListRequest{Namespace: "other", Resource: created.Uri})
+	require.Error(t, err)
+	_, err = s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{Namespace: "other", Resource: created.Uri})
+	require.Error(t, err)
+	_, err = s.DeletePermitListRules(context.Background(), &paragliderpb.DeletePermitListRulesRequest{Namespace: "other", Resource: created.Uri})
+	require.Error(t, err)
+}
+
+func TestAddPermitListRulesPeering(t *testing.T) {
+	fakeServerState, _, s := setupPluginServer(t)
+	east := createFakeInstance(t, s, "vm-east", fakeZone)
+	eastPeer := createFakeInstance(t, s, "vm-east-peer", fakeZone)
+	west := createFakeInstance(t, s, "vm-west", fakeOtherZone)
+
+	rules := []*paragliderpb.PermitListRule{
+		{Name: "ssh", Direction: paragliderpb.Direction_OUTBOUND, SrcPort: -1, DstPort: 22, Protocol: 6, Targets: []string{eastPeer.Ip, west.Ip}},
+	}
+	_, err := s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{Namespace: fakeNamespace, Resource: east.Uri, Rules: rules})
+	require.NoError(t, err)
+
+	// Only the VPC in the other region is peered
+	require.Len(t, fakeServerState.vpcs, 2)
+	eastVpc, westVpc := fakeServerState.vpcs[0], fakeServerState.vpcs[1]
+	require.Len(t, fakeServerState.peerings, 1)
+	peering := fakeServerState.peerings[0]
+	assert.Equal(t, "active", peering.Status.Code)
+	assert.Equal(t, eastVpc.VpcId, peering.RequesterVpcInfo.VpcId)
+	assert.Equal(t, westVpc.VpcId, peering.AccepterVpcInfo.VpcId)
+	assert.Equal(t, fakeOtherRegion, peering.AccepterVpcInfo.Region)
+
+	// Both VPCs route the other's address space through the peering connection
+	for _, routeTable := range fakeServerState.routeTables {
+		destination := westVpc.CidrBlock
+		if routeTable.VpcId == westVpc.VpcId {
+			destination = eastVpc.CidrBlock
+		}
+		assert.Contains(t, routeTable.Routes, fakeRoute{DestinationCidrBlock: destination, VpcPeeringConnectionId: peering.VpcPeeringConnectionId, State: "active"})
+	}
+
+	// Peering is only set up once
+	rules = append(rules, &paragliderpb.PermitListRule{Name: "http", Direction: paragliderpb.Direction_INBOUND, SrcPort: -1, DstPort: 80, Protocol: 6, Targets: []string{west.Ip}})
+	_, err = s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{Namespace: fakeNamespace, Resource: west.Uri, Rules: rules[1:]})
+	require.NoError(t, err)
+	assert.Len(t, fakeServerState.peerings, 1)
+}
+
+func TestAddPermitListRulesMissingTarget(t *testing.T) {
+	_, fakeOrchestratorServer, s := setupPluginServer(t)
+	created := createFakeInstance(t, s, fakeInstanceName, fakeZone)
+
+	// The orchestrator reports an address space which no VPC contains
+	fakeOrchestratorServer.Counter = 2
+	rules := []*paragliderpb.PermitListRule{
+		{Name: "ssh", Direction: paragliderpb.Direction_INBOUND, SrcPort: -1, DstPort: 22, Protocol: 6, Targets: []string{"10.1.0.4"}},
+	}
+	_, err := s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{Namespace: fakeNamespace, Resource: created.Uri, Rules: rules})
+	require.Error(t, err)
+}
+
+func TestGetUsedAddressSpaces(t *testing.T) {
+	_, _, s := setupPluginServer(t)
+	createFakeInstance(t, s, "vm-east", fakeZone)
+	createFakeInstance(t, s, "vm-west", fakeOtherZone)
+
+	req := &paragliderpb.GetUsedAddressSpacesRequest{
+		Deployments: []*paragliderpb.ParagliderDeployment{fakeDeployment, {Id: fakeDeploymentId, Namespace: "other"}},
+	}
+	resp, err := s.GetUsedAddressSpaces(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, resp.AddressSpaceMappings, 2)
+	assert.Equal(t, utils.AWS, resp.AddressSpaceMappings[0].Cloud)
+	assert.Equal(t, fakeNamespace, resp.AddressSpaceMappings[0].Namespace)
+	assert.ElementsMatch(t, []string{"10.0.0.0/16", "10.1.0.0/16"}, resp.AddressSpaceMappings[0].AddressSpaces)
+	assert.Equal(t, "other", resp.AddressSpaceMappings[1].Namespace)
+	assert.Empty(t, resp.AddressSpaceMappings[1].AddressSpaces)
+}
+
+func TestCreateVpnGateway(t *testing.T) {
+	fakeServerState, _, s := setupPluginServer(t)
+	createFakeInstance(t, s, fakeInstanceName, fakeZone)
+
+	req := &paragliderpb.CreateVpnGatewayRequest{Deployment: fakeDeployment, Cloud: utils.AZURE}
+	resp, err := s.CreateVpnGateway(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, orchestrator.MIN_PRIVATE_ASN_2BYTE, resp.Asn)
+	assert.Empty(t, resp.GatewayIpAddresses)
+
+	require.Len(t, fakeServerState.vpnGateways, 1)
+	vpnGateway := fakeServerState.vpnGateways[0]
+	assert.Equal(t, []fakeVpcAttachment{{VpcId: fakeServerState.vpcs[0].VpcId, State: "attached"}}, vpnGateway.Attachments)
+	assert.Equal(t, []fakePropagatingVgw{{GatewayId: vpnGateway.VpnGatewayId}}, fakeServerState.routeTables[0].PropagatingVgws)
+
+	// Creating the VPN gateway again reuses it
+	resp, err = s.CreateVpnGateway(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, orchestrator.MIN_PRIVATE_ASN_2BYTE, resp.Asn)
+	assert.Len(t, fakeServerState.vpnGateways, 1)
+	assert.Len(t, fakeServerState.routeTables[0].PropagatingVgws, 1)
+
+	asnsResp, err := s.GetUsedAsns(context.Background(), &paragliderpb.GetUsedAsnsRequest{Deployments: []*paragliderpb.ParagliderDeployment{fakeDeployment}})
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{orchestrator.MIN_PRIVATE_ASN_2BYTE}, asnsResp.Asns)
+}
+
+func TestCreateVpnGatewayAmbiguousVpc(t *testing.T) {
+	_, _, s := setupPluginServer(t)
+	createFakeInstance(t, s, "vm-east", fakeZone)
+	createFakeInstance(t, s, "vm-west", fakeOtherZone)
+
+	_, err := s.CreateVpnGateway(context.Background(), &paragliderpb.CreateVpnGatewayRequest{Deployment: fakeDeployment, Cloud: utils.AZURE})
+	require.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// The address space picks the VPC
+	_, err = s.CreateVpnGateway(context.Background(), &paragliderpb.CreateVpnGatewayRequest{Deployment: fakeDeployment, Cloud: utils.AZURE, AddressSpace: "10.1.0.0/16"})
+	require.NoError(t, err)
+}
+
+func TestCreateVpnConnections(t *testing.T) {
+	fakeServerState, _, s := setupPluginServer(t)
+	createFakeInstance(t, s, fakeInstanceName, fakeZone)
+	_, err := s.CreateVpnGateway(context.Background(), &paragliderpb.CreateVpnGatewayRequest{Deployment: fakeDeployment, Cloud: utils.GCP})
+	require.NoError(t, err)
+
+	req := &paragliderpb.CreateVpnConnectionsRequest{
+		Deployment:         fakeDeployment,
+		Cloud:              utils.GCP,
+		Asn:                65555,
+		GatewayIpAddresses: []string{"1.1.1.1", "2.2.2.2"},
+		BgpIpAddresses:     []string{"169.254.21.2", "169.254.22.2"},
+		SharedKey:          "abcd",
+	}
+	resp, err := s.CreateVpnConnections(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, resp.GatewayIpAddresses, 2)
+
+	require.Len(t, fakeServerState.customerGateways, 2)
+	for i, customerGateway := range fakeServerState.customerGateways {
+		assert.Equal(t, req.GatewayIpAddresses[i], customerGateway.IpAddress)
+		assert.Equal(t, "65555", customerGateway.BgpAsn)
+	}
+	require.Len(t, fakeServerState.vpnConnections, 2)
+	for i, vpnConnection := range fakeServerState.vpnConnections {
+		assert.False(t, vpnConnection.Options.StaticRoutesOnly)
+		assert.Equal(t, resp.GatewayIpAddresses[i], vpnConnection.Options.TunnelOptions[0].OutsideIpAddress)
+		assert.Equal(t, "abcd", vpnConnection.Options.TunnelOptions[0].PreSharedKey)
+	}
+	assert.Equal(t, "169.254.21.0/30", fakeServerState.vpnConnections[0].Options.TunnelOptions[0].TunnelInsideCidr)
+	assert.Equal(t, "169.254.22.0/30", fakeServerState.vpnConnections[1].Options.TunnelOptions[0].TunnelInsideCidr)
+
+	// Creating the VPN connections again reuses them
+	again, err := s.CreateVpnConnections(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, resp.GatewayIpAddresses, again.GatewayIpAddresses)
+	assert.Len(t, fakeServerState.customerGateways, 2)
+	assert.Len(t, fakeServerState.vpnConnections, 2)
+
+	// Both tunnels of each connection are reported as used
+	bgpResp, err := s.GetUsedBgpPeeringIpAddresses(context.Background(), &paragliderpb.GetUsedBgpPeeringIpAddressesRequest{Deployments: []*paragliderpb.ParagliderDeployment{fakeDeployment}})
+	require.NoError(t, err)
+	require.Len(t, bgpResp.IpAddresses, 4)
+	assert.Contains(t, bgpResp.IpAddresses, "169.254.21.1")
+	assert.Contains(t, bgpResp.IpAddresses, "169.254.22.1")
+}
+
+func TestCreateVpnConnectionsExtendedAsn(t *testing.T) {
+	fakeServerState, _, s := setupPluginServer(t)
+	createFakeInstance(t, s, fakeInstanceName, fakeZone)
+	_, err := s.CreateVpnGateway(context.Background(), &paragliderpb.CreateVpnGatewayRequest{Deployment: fakeDeployment, Cloud: utils.AZURE})
+	require.NoError(t, err)
+
+	_, err = s.CreateVpnConnections(context.Background(), &paragliderpb.CreateVpnConnectionsRequest{
+		Deployment:         fakeDeployment,
+		Cloud:              utils.AZURE,
+		Asn:                4200000000,
+		GatewayIpAddresses: []string{"1.1.1.1", "2.2.2.2"},
+		BgpIpAddresses:     []string{"169.254.21.2", "169.254.22.2"},
+		SharedKey:          "abcd",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "4200000000", fakeServerState.customerGateways[0].BgpAsn)
+}
+
+func TestCreateVpnConnectionsInvalid(t *testing.T) {
+	_, _, s := setupPluginServer(t)
+	createFakeInstance(t, s, fakeInstanceName, fakeZone)
+
+	req := &paragliderpb.CreateVpnConnectionsRequest{
+		Deployment:         fakeDeployment,
+		Cloud:              utils.GCP,
+		Asn:                65555,
+		GatewayIpAddresses: []string{"1.1.1.1", "2.2.2.2"},
+		BgpIpAddresses:     []string{"169.254.21.2", "169.254.22.2"},
+		SharedKey:          "abcd",
+	}
+
+	// The VPN gateway must be created first
+	_, err := s.CreateVpnConnections(context.Background(), req)
+	require.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// BGP is required
+	req.IsBgpDisabled = true
+	_, err = s.CreateVpnConnections(context.Background(), req)
+	require.Error(t, err)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	// One gateway and BGP IP address per connection
+	req.IsBgpDisabled = false
+	req.GatewayIpAddresses = req.GatewayIpAddresses[:1]
+	_, err = s.CreateVpnConnections(context.Background(), req)
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// Gets the security group rules of a permit list rule kept by the fake server
+func getPermitListSgRules(fakeServerState *fakeServerState, name string) []types.SecurityGroupRule {
+	sgRules := []types.SecurityGroupRule{}
+	for _, sgRule := range fakeServerState.sgRules {
+		if getTagValueOfFakeTags(sgRule.Tags, ruleTagKey) == name {
+			sgRules = append(sgRules, types.SecurityGroupRule{
+				IsEgress:    aws.Bool(sgRule.IsEgress),
+				IpProtocol:  aws.String(sgRule.IpProtocol),
+				FromPort:    aws.Int32(sgRule.FromPort),
+				ToPort:      aws.Int32(sgRule.ToPort),
+				CidrIpv4:    aws.String(sgRule.CidrIpv4),
+				Description: aws.String(sgRule.Description),
+			})
+		}
+	}
+	return sgRules
+}
+
+func getTagValueOfFakeTags(tags []fakeTag, key string) string {
+	for _, tag := range tags {
+		if tag.Key == key {
+			return tag.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+)
+
+const ruleDescriptionPrefix = "paraglider rule" // Description prefix of security group rules keeping the tags of their permit list rule
+
+// Maps protocol names that can appear in security group rules to IANA numbers
+var awsProtocolNumberMap = map[string]int32{
+	"tcp":    6,
+	"udp":    17,
+	"icmp":   1,
+	"icmpv6": 58,
+}
+
+// Gets protocol number from an AWS specification (either a name like "tcp" or an int-string like "6")
+func getProtocolNumber(ipProtocol string) (int32, error) {
+	if protocolNumber, ok := awsProtocolNumberMap[ipProtocol]; ok {
+		return protocolNumber, nil
+	}
+	protocolNumber, err := strconv.Atoi(ipProtocol)
+	if err != nil {
+		return 0, fmt.Errorf("could not convert AWS protocol %q to protocol number", ipProtocol)
+	}
+	return int32(protocolNumber), nil
+}
+
+// Checks if ports apply to a protocol in security group rules (all other protocols always apply to all ports)
+func hasPorts(protocol int32) bool {
+	return protocol == 6 || protocol == 17 || protocol == 1 || protocol == 58
+}
+
+// Gets the port range of a security group rule for a permit list rule
+// Security groups only filter on destination ports, so the source port is ignored like in GCP firewalls
+func getPortRange(rule *paragliderpb.PermitListRule) (int32, int32) {
+	if !hasPorts(rule.Protocol) {
+		return -1, -1
+	}
+	if rule.DstPort == -1 {
+		if rule.Protocol == 1 || rule.Protocol == 58 {
+			return -1, -1 // All ICMP types and codes
+		}
+		return 0, 65535
+	}
+	return rule.DstPort, rule.DstPort
+}
+
+// Gets the destination port of a permit list rule from the port range of a security group rule
+func getDstPort(fromPort int32, toPort int32) int32 {
+	if fromPort == -1 || (fromPort == 0 && toPort == 65535) {
+		return -1
+	}
+	return fromPort
+}
+
+// Format the description to keep metadata about tags
+func getRuleDescription(tags []string) string {
+	if len(tags) == 0 {
+		return ruleDescriptionPrefix
+	}
+	return fmt.Sprintf("%s:%v", ruleDescriptionPrefix, tags)
+}
+
+// Parses description string to get tags
+func parseDescriptionTags(description string) []string {
+	var tags []string
+	if strings.HasPrefix(description, ruleDescriptionPrefix+":[") {
+		trimmedDescription := strings.TrimPrefix(description, ruleDescriptionPrefix+":")
+		trimmedDescription = strings.Trim(trimmedDescription, "[]")
+		tags = strings.Split(trimmedDescription, " ")
+	}
+	return tags
+}
+
+// Converts a Paraglider permit list rule to the IP permission of security group rules (one per target)
+func paragliderRuleToIpPermission(rule *paragliderpb.PermitListRule) types.IpPermission {
+	fromPort, toPort := getPortRange(rule)
+	permission := types.IpPermission{
+		IpProtocol: aws.String(strconv.Itoa(int(rule.Protocol))),
+		FromPort:   aws.Int32(fromPort),
+		ToPort:     aws.Int32(toPort),
+	}
+	for _, target := range rule.Targets {
+		permission.IpRanges = append(permission.IpRanges, types.IpRange{CidrIp: aws.String(target), Description: aws.String(getRuleDescription(rule.Tags))})
+	}
+	return permission
+}
+
+// Converts the security group rules of a Paraglider permit list rule back to the permit list rule
+func securityGroupRulesToParagliderRule(name string, sgRules []types.SecurityGroupRule) (*paragliderpb.PermitListRule, error) {
+	if len(sgRules) == 0 {
+		return nil, fmt.Errorf("permit list rule %s has no security group rules", name)
+	}
+	protocol, err := getProtocolNumber(aws.ToString(sgRules[0].IpProtocol))
+	if err != nil {
+		return nil, err
+	}
+	direction := paragliderpb.Direction_INBOUND
+	if aws.ToBool(sgRules[0].IsEgress) {
+		direction = paragliderpb.Direction_OUTBOUND
+	}
+	rule := &paragliderpb.PermitListRule{
+		Name:      name,
+		Direction: direction,
+		SrcPort:   -1,
+		DstPort:   getDstPort(aws.ToInt32(sgRules[0].FromPort), aws.ToInt32(sgRules[0].ToPort)),
+		Protocol:  protocol,
+		Tags:      parseDescriptionTags(aws.ToString(sgRules[0].Description)),
+	} // SrcPort not specified since security groups don't support rules based on source ports
+	for _, sgRule := range sgRules {
+		rule.Targets = append(rule.Targets, aws.ToString(sgRule.CidrIpv4))
+	}
+	return rule, nil
+}
+
+// Determine if the security group rules of a permit list rule are equivalent to a permit list rule
+func isSecurityGroupRulesEqPermitListRule(sgRules []types.SecurityGroupRule, rule *paragliderpb.PermitListRule) (bool, error) {
+	existing, err := securityGroupRulesToParagliderRule(rule.Name, sgRules)
+	if err != nil {
+		return false, err
+	}
+	fromPort, toPort := getPortRange(rule)
+	return existing.Direction == rule.Direction &&
+		existing.Protocol == rule.Protocol &&
+		existing.DstPort == getDstPort(fromPort, toPort) &&
+		equalSets(existing.Targets, rule.Targets) &&
+		equalSets(existing.Tags, rule.Tags), nil
+}
+
+// Checks if two string slices contain the same elements regardless of order
+func equalSets(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string{}, a...)
+	sortedB := append([]string{}, b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Gets the security group rules of a security group grouped by the names of their Paraglider permit list rules
+func getPermitListSecurityGroupRules(ctx context.Context, client *ec2.Client, groupId string) (map[string][]types.SecurityGroupRule, error) {
+	sgRules := map[string][]types.SecurityGroupRule{}
+	paginator := ec2.NewDescribeSecurityGroupRulesPaginator(client, &ec2.DescribeSecurityGroupRulesInput{
+		Filters: []types.Filter{filter("group-id", groupId)},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe security group rules: %w", err)
+		}
+		for _, sgRule := range page.SecurityGroupRules {
+			if name := getTagValue(sgRule.Tags, ruleTagKey); name != "" {
+				sgRules[name] = append(sgRules[name], sgRule)
+			}
+		}
+	}
+	return sgRules, nil
+}
+
+// Authorizes the security group rules of a permit list rule, tagging each with the name of the permit list rule
+func authorizePermitListRule(ctx context.Context, client *ec2.Client, groupId string, rule *paragliderpb.PermitListRule) error {
+	permissions := []types.IpPermission{paragliderRuleToIpPermission(rule)}
+	tagSpecifications := []types.TagSpecification{
+		{
+			ResourceType: types.ResourceTypeSecurityGroupRule,
+			Tags:         []types.Tag{{Key: aws.String(ruleTagKey), Value: aws.String(rule.Name)}},
+		},
+	}
+	var err error
+	if rule.Direction == paragliderpb.Direction_INBOUND {
+		_, err = client.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{GroupId: aws.String(groupId), IpPermissions: permissions, TagSpecifications: tagSpecifications})
+	} else {
+		_, err = client.AuthorizeSecurityGroupEgress(ctx, &ec2.AuthorizeSecurityGroupEgressInput{GroupId: aws.String(groupId), IpPermissions: permissions, TagSpecifications: tagSpecifications})
+	}
+	if err != nil {
+		return fmt.Errorf("unable to authorize security group rules of %s: %w", rule.Name, err)
+	}
+	return nil
+}
+
+// Revokes security group rules
+func revokeSecurityGroupRules(ctx context.Context, client *ec2.Client, groupId string, sgRules []types.SecurityGroupRule) error {
+	ingressIds, egressIds := []string{}, []string{}
+	for _, sgRule := range sgRules {
+		if aws.ToBool(sgRule.IsEgress) {
+			egressIds = append(egressIds, aws.ToString(sgRule.SecurityGroupRuleId))
+		} else {
+			ingressIds = append(ingressIds, aws.ToString(sgRule.SecurityGroupRuleId))
+		}
+	}
+	if len(ingressIds) > 0 {
+		if _, err := client.RevokeSecurityGroupIngress(ctx, &ec2.RevokeSecurityGroupIngressInput{GroupId: aws.String(groupId), SecurityGroupRuleIds: ingressIds}); err != nil {
+			return fmt.Errorf("unable to revoke security group ingress rules: %w", err)
+		}
+	}
+	if len(egressIds) > 0 {
+		if _, err := client.RevokeSecurityGroupEgress(ctx, &ec2.RevokeSecurityGroupEgressInput{GroupId: aws.String(groupId), SecurityGroupRuleIds: egressIds}); err != nil {
+			return fmt.Errorf("unable to revoke security group egress rules: %w", err)
+		}
+	}
+	return nil
+}
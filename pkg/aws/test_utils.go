@@ -0,0 +1,1108 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+)
+
+// Fake account and placement
+const (
+	fakeAccount      = "123456789012"
+	fakeDeploymentId = "accounts/" + fakeAccount
+	fakeRegion       = "us-east-1"
+	fakeZone         = fakeRegion + "a"
+	fakeOtherRegion  = "us-west-2"
+	fakeOtherZone    = fakeOtherRegion + "b"
+	fakeNamespace    = "default"
+	fakeInstanceName = "vm-paraglider-fake"
+	fakeImageId      = "ami-0123456789abcdef0"
+	fakeInstanceType = "t3.micro"
+)
+
+// Region of a request from the credential scope of its SigV4 signature
+var credentialScopeRegexp = regexp.MustCompile(`Credential=[^/]+/[^/]+/([^/]+)/ec2/`)
+
+// XML representations of EC2 objects kept by the fake server
+type fakeTag struct {
+	Key   string `xml:"key"`
+	Value string `xml:"value"`
+}
+
+type fakeVpc struct {
+	Region        string    `xml:"-"`
+	VpcId         string    `xml:"vpcId"`
+	CidrBlock     string    `xml:"cidrBlock"`
+	State         string    `xml:"state"`
+	OwnerId       string    `xml:"ownerId"`
+	DhcpOptionsId string    `xml:"dhcpOptionsId"`
+	Tags          []fakeTag `xml:"tagSet>item"`
+}
+
+type fakeAttributeValue struct {
+	Value string `xml:"value"`
+}
+
+type fakeDhcpConfiguration struct {
+	Key    string               `xml:"key"`
+	Values []fakeAttributeValue `xml:"valueSet>item"`
+}
+
+type fakeDhcpOptions struct {
+	Region             string                  `xml:"-"`
+	DhcpOptionsId      string                  `xml:"dhcpOptionsId"`
+	OwnerId            string                  `xml:"ownerId"`
+	DhcpConfigurations []fakeDhcpConfiguration `xml:"dhcpConfigurationSet>item"`
+	Tags               []fakeTag               `xml:"tagSet>item"`
+}
+
+type fakeSubnet struct {
+	Region           string    `xml:"-"`
+	SubnetId         string    `xml:"subnetId"`
+	VpcId            string    `xml:"vpcId"`
+	CidrBlock        string    `xml:"cidrBlock"`
+	AvailabilityZone string    `xml:"availabilityZone"`
+	State            string    `xml:"state"`
+	Tags             []fakeTag `xml:"tagSet>item"`
+	numInstances     int
+}
+
+type fakeSecurityGroup struct {
+	Region      string    `xml:"-"`
+	GroupId     string    `xml:"groupId"`
+	GroupName   string    `xml:"groupName"`
+	Description string    `xml:"groupDescription"`
+	VpcId       string    `xml:"vpcId"`
+	Tags        []fakeTag `xml:"tagSet>item"`
+}
+
+type fakeSecurityGroupRule struct {
+	Region              string    `xml:"-"`
+	SecurityGroupRuleId string    `xml:"securityGroupRuleId"`
+	GroupId             string    `xml:"groupId"`
+	IsEgress            bool      `xml:"isEgress"`
+	IpProtocol          string    `xml:"ipProtocol"`
+	FromPort            int32     `xml:"fromPort"`
+	ToPort              int32     `xml:"toPort"`
+	CidrIpv4            string    `xml:"cidrIpv4"`
+	Description         string    `xml:"description,omitempty"`
+	Tags                []fakeTag `xml:"tagSet>item"`
+}
+
+type fakeInstanceState struct {
+	Code int32  `xml:"code"`
+	Name string `xml:"name"`
+}
+
+type fakeGroupIdentifier struct {
+	GroupId   string `xml:"groupId"`
+	GroupName string `xml:"groupName"`
+}
+
+type fakePrivateIpAddress struct {
+	PrivateIpAddress string `xml:"privateIpAddress"`
+	Primary          bool   `xml:"primary"`
+}
+
+type fakeIpv6Address struct {
+	Ipv6Address string `xml:"ipv6Address"`
+}
+
+type fakeInstanceNetworkInterface struct {
+	NetworkInterfaceId string                 `xml:"networkInterfaceId"`
+	PrivateIpAddresses []fakePrivateIpAddress `xml:"privateIpAddressesSet>item"`
+	Ipv6Addresses      []fakeIpv6Address      `xml:"ipv6AddressesSet>item"`
+}
+
+type fakeInstance struct {
+	Region            string                         `xml:"-"`
+	InstanceId        string                         `xml:"instanceId"`
+	ImageId           string                         `xml:"imageId"`
+	InstanceType      string                         `xml:"instanceType"`
+	AvailabilityZone  string                         `xml:"placement>availabilityZone"`
+	PrivateIpAddress  string                         `xml:"privateIpAddress"`
+	SubnetId          string                         `xml:"subnetId"`
+	VpcId             string                         `xml:"vpcId"`
+	State             fakeInstanceState              `xml:"instanceState"`
+	Groups            []fakeGroupIdentifier          `xml:"groupSet>item"`
+	NetworkInterfaces []fakeInstanceNetworkInterface `xml:"networkInterfaceSet>item"`
+	Tags              []fakeTag                      `xml:"tagSet>item"`
+}
+
+type fakeRouteTableAssociation struct {
+	Main         bool   `xml:"main"`
+	RouteTableId string `xml:"routeTableId"`
+}
+
+type fakeRoute struct {
+	DestinationCidrBlock   string `xml:"destinationCidrBlock"`
+	GatewayId              string `xml:"gatewayId,omitempty"`
+	VpcPeeringConnectionId string `xml:"vpcPeeringConnectionId,omitempty"`
+	State                  string `xml:"state"`
+}
+
+type fakePropagatingVgw struct {
+	GatewayId string `xml:"gatewayId"`
+}
+
+type fakeRouteTable struct {
+	Region          string                      `xml:"-"`
+	RouteTableId    string                      `xml:"routeTableId"`
+	VpcId           string                      `xml:"vpcId"`
+	Associations    []fakeRouteTableAssociation `xml:"associationSet>item"`
+	Routes          []fakeRoute                 `xml:"routeSet>item"`
+	PropagatingVgws []fakePropagatingVgw        `xml:"propagatingVgwSet>item"`
+}
+
+type fakeVpcAttachment struct {
+	VpcId string `xml:"vpcId"`
+	State string `xml:"state"`
+}
+
+type fakeVpnGateway struct {
+	Region        string              `xml:"-"`
+	VpnGatewayId  string              `xml:"vpnGatewayId"`
+	State         string              `xml:"state"`
+	Type          string              `xml:"type"`
+	AmazonSideAsn int64               `xml:"amazonSideAsn"`
+	Attachments   []fakeVpcAttachment `xml:"attachments>item"`
+	Tags          []fakeTag           `xml:"tagSet>item"`
+}
+
+type fakeCustomerGateway struct {
+	Region            string    `xml:"-"`
+	CustomerGatewayId string    `xml:"customerGatewayId"`
+	BgpAsn            string    `xml:"bgpAsn"`
+	IpAddress         string    `xml:"ipAddress"`
+	State             string    `xml:"state"`
+	Type              string    `xml:"type"`
+	Tags              []fakeTag `xml:"tagSet>item"`
+}
+
+type fakeTunnelOption struct {
+	OutsideIpAddress string `xml:"outsideIpAddress"`
+	TunnelInsideCidr string `xml:"tunnelInsideCidr"`
+	PreSharedKey     string `xml:"preSharedKey"`
+}
+
+type fakeVpnConnectionOptions struct {
+	StaticRoutesOnly bool               `xml:"staticRoutesOnly"`
+	TunnelOptions    []fakeTunnelOption `xml:"tunnelOptionSet>item"`
+}
+
+type fakeVpnConnection struct {
+	Region            string                   `xml:"-"`
+	VpnConnectionId   string                   `xml:"vpnConnectionId"`
+	CustomerGatewayId string                   `xml:"customerGatewayId"`
+	VpnGatewayId      string                   `xml:"vpnGatewayId"`
+	State             string                   `xml:"state"`
+	Type              string                   `xml:"type"`
+	Options           fakeVpnConnectionOptions `xml:"options"`
+	Tags              []fakeTag                `xml:"tagSet>item"`
+}
+
+type fakeVpcPeeringInfo struct {
+	VpcId     string `xml:"vpcId"`
+	OwnerId   string `xml:"ownerId"`
+	Region    string `xml:"region"`
+	CidrBlock string `xml:"cidrBlock"`
+}
+
+type fakeVpcPeeringStatus struct {
+	Code    string `xml:"code"`
+	Message string `xml:"message"`
+}
+
+type fakeVpcPeeringConnection struct {
+	VpcPeeringConnectionId string               `xml:"vpcPeeringConnectionId"`
+	RequesterVpcInfo       fakeVpcPeeringInfo   `xml:"requesterVpcInfo"`
+	AccepterVpcInfo        fakeVpcPeeringInfo   `xml:"accepterVpcInfo"`
+	Status                 fakeVpcPeeringStatus `xml:"status"`
+	Tags                   []fakeTag            `xml:"tagSet>item"`
+}
+
+type fakeRegionInfo struct {
+	RegionName  string `xml:"regionName"`
+	OptInStatus string `xml:"optInStatus"`
+}
+
+// Values of an object for the filters supported by the fake server (false if the filter is not supported)
+type fakeFilterable interface {
+	filterValues(name string) ([]string, bool)
+}
+
+func tagFilterValues(tags []fakeTag, name string) ([]string, bool) {
+	key, ok := strings.CutPrefix(name, "tag:")
+	if !ok {
+		return nil, false
+	}
+	for _, tag := range tags {
+		if tag.Key == key {
+			return []string{tag.Value}, true
+		}
+	}
+	return []string{}, true
+}
+
+func (v *fakeVpc) filterValues(name string) ([]string, bool) {
+	switch name {
+	case "vpc-id":
+		return []string{v.VpcId}, true
+	case "cidr":
+		return []string{v.CidrBlock}, true
+	}
+	return tagFilterValues(v.Tags, name)
+}
+
+func (s *fakeSubnet) filterValues(name string) ([]string, bool) {
+	switch name {
+	case "vpc-id":
+		return []string{s.VpcId}, true
+	case "availability-zone":
+		return []string{s.AvailabilityZone}, true
+	}
+	return tagFilterValues(s.Tags, name)
+}
+
+func (r *fakeSecurityGroupRule) filterValues(name string) ([]string, bool) {
+	if name == "group-id" {
+		return []string{r.GroupId}, true
+	}
+	return tagFilterValues(r.Tags, name)
+}
+
+func (i *fakeInstance) filterValues(name string) ([]string, bool) {
+	switch name {
+	case "instance-id":
+		return []string{i.InstanceId}, true
+	case "instance-state-name":
+		return []string{i.State.Name}, true
+	case "vpc-id":
+		return []string{i.VpcId}, true
+	}
+	return tagFilterValues(i.Tags, name)
+}
+
+func (r *fakeRouteTable) filterValues(name string) ([]string, bool) {
+	switch name {
+	case "vpc-id":
+		return []string{r.VpcId}, true
+	case "association.main":
+		values := []string{}
+		for _, association := range r.Associations {
+			values = append(values, strconv.FormatBool(association.Main))
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+func (g *fakeVpnGateway) filterValues(name string) ([]string, bool) {
+	switch name {
+	case "state":
+		return []string{g.State}, true
+	case "attachment.vpc-id":
+		values := []string{}
+		for _, attachment := range g.Attachments {
+			values = append(values, attachment.VpcId)
+		}
+		return values, true
+	}
+	return tagFilterValues(g.Tags, name)
+}
+
+func (g *fakeCustomerGateway) filterValues(name string) ([]string, bool) {
+	switch name {
+	case "state":
+		return []string{g.State}, true
+	case "ip-address":
+		return []string{g.IpAddress}, true
+	}
+	return tagFilterValues(g.Tags, name)
+}
+
+func (c *fakeVpnConnection) filterValues(name string) ([]string, bool) {
+	switch name {
+	case "state":
+		return []string{c.State}, true
+	case "vpn-gateway-id":
+		return []string{c.VpnGatewayId}, true
+	}
+	return tagFilterValues(c.Tags, name)
+}
+
+func (p *fakeVpcPeeringConnection) filterValues(name string) ([]string, bool) {
+	switch name {
+	case "requester-vpc-info.vpc-id":
+		return []string{p.RequesterVpcInfo.VpcId}, true
+	case "accepter-vpc-info.vpc-id":
+		return []string{p.AccepterVpcInfo.VpcId}, true
+	case "status-code":
+		return []string{p.Status.Code}, true
+	}
+	return tagFilterValues(p.Tags, name)
+}
+
+// Error returned by the fake server in the EC2 error format
+type fakeError struct {
+	Code    string
+	Message string
+}
+
+func (e *fakeError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func newFakeError(code string, format string, a ...any) *fakeError {
+	return &fakeError{Code: code, Message: fmt.Sprintf(format, a...)}
+}
+
+// Stateful fake of the EC2 API shared by all regions
+type fakeServerState struct {
+	mu               sync.Mutex
+	regions          []string
+	counter          int
+	vpcs             []*fakeVpc
+	dhcpOptions      []*fakeDhcpOptions
+	subnets          []*fakeSubnet
+	securityGroups   []*fakeSecurityGroup
+	sgRules          []*fakeSecurityGroupRule
+	instances        []*fakeInstance
+	routeTables      []*fakeRouteTable
+	vpnGateways      []*fakeVpnGateway
+	customerGateways []*fakeCustomerGateway
+	vpnConnections   []*fakeVpnConnection
+	peerings         []*fakeVpcPeeringConnection
+}
+
+func newFakeServerState(regions ...string) *fakeServerState {
+	return &fakeServerState{regions: regions}
+}
+
+func (f *fakeServerState) newId(prefix string) string {
+	f.counter++
+	return fmt.Sprintf("%s-%017x", prefix, f.counter)
+}
+
+// Parses the filters of a request (Filter.N.Name and Filter.N.Value.M)
+func getFormFilters(form url.Values) map[string][]string {
+	filters := map[string][]string{}
+	for i := 1; form.Has(fmt.Sprintf("Filter.%d.Name", i)); i++ {
+		name := form.Get(fmt.Sprintf("Filter.%d.Name", i))
+		filters[name] = getFormList(form, fmt.Sprintf("Filter.%d.Value", i))
+	}
+	return filters
+}
+
+// Parses a flattened list of a request (Prefix.1, Prefix.2, ...)
+func getFormList(form url.Values, prefix string) []string {
+	values := []string{}
+	for i := 1; form.Has(fmt.Sprintf("%s.%d", prefix, i)); i++ {
+		values = append(values, form.Get(fmt.Sprintf("%s.%d", prefix, i)))
+	}
+	return values
+}
+
+// Parses the tags to apply to a resource type from the tag specifications of a request
+func getFormTags(form url.Values, resourceType string) []fakeTag {
+	tags := []fakeTag{}
+	for i := 1; form.Has(fmt.Sprintf("TagSpecification.%d.ResourceType", i)); i++ {
+		if form.Get(fmt.Sprintf("TagSpecification.%d.ResourceType", i)) != resourceType {
+			continue
+		}
+		for j := 1; form.Has(fmt.Sprintf("TagSpecification.%d.Tag.%d.Key", i, j)); j++ {
+			tags = append(tags, fakeTag{
+				Key:   form.Get(fmt.Sprintf("TagSpecification.%d.Tag.%d.Key", i, j)),
+				Value: form.Get(fmt.Sprintf("TagSpecification.%d.Tag.%d.Value", i, j)),
+			})
+		}
+	}
+	return tags
+}
+
+func getFormInt(form url.Values, key string) int32 {
+	value, _ := strconv.Atoi(form.Get(key))
+	return int32(value)
+}
+
+// Checks if an object matches all filters
+func matchesFilters(object fakeFilterable, filters map[string][]string) (bool, error) {
+	for name, filterValues := range filters {
+		values, ok := object.filterValues(name)
+		if !ok {
+			return false, newFakeError("InvalidParameterValue", "the filter '%s' is invalid", name)
+		}
+		matched := false
+		for _, value := range values {
+			for _, filterValue := range filterValues {
+				if value == filterValue {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Selects the objects of a region matching the filters of a request
+func filterObjects[T fakeFilterable](objects []T, region func(T) string, requestRegion string, filters map[string][]string) ([]T, error) {
+	matches := []T{}
+	for _, object := range objects {
+		if region != nil && region(object) != requestRegion {
+			continue
+		}
+		matched, err := matchesFilters(object, filters)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			matches = append(matches, object)
+		}
+	}
+	return matches, nil
+}
+
+func sendResponse(w http.ResponseWriter, action string, resp any) {
+	w.Header().Set("Content-Type", "text/xml;charset=UTF-8")
+	encoder := xml.NewEncoder(w)
+	start := xml.StartElement{Name: xml.Name{Local: action + "Response"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: "http://ec2.amazonaws.com/doc/2016-11-15/"}}}
+	if err := encoder.EncodeElement(resp, start); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func sendError(w http.ResponseWriter, err *fakeError) {
+	w.Header().Set("Content-Type", "text/xml;charset=UTF-8")
+	w.WriteHeader(http.StatusBadRequest)
+	fmt.Fprintf(w, "<Response><Errors><Error><Code>%s</Code><Message>%s</Message></Error></Errors><RequestID>fake</RequestID></Response>", err.Code, err.Message)
+}
+
+type fakeReturn struct {
+	Return bool `xml:"return"`
+}
+
+func getFakeServerHandler(f *fakeServerState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		region := defaultRegion
+		if match := credentialScopeRegexp.FindStringSubmatch(r.Header.Get("Authorization")); match != nil {
+			region = match[1]
+		}
+		action := r.Form.Get("Action")
+
+		f.mu.Lock()
+		resp, err := f.handle(action, region, r.Form)
+		f.mu.Unlock()
+		if err != nil {
+			sendError(w, err)
+			return
+		}
+		sendResponse(w, action, resp)
+	}
+}
+
+// Handles an EC2 action in a region
+func (f *fakeServerState) handle(action string, region string, form url.Values) (any, *fakeError) {
+	filters := getFormFilters(form)
+	switch action {
+	case "DescribeRegions":
+		regions := []fakeRegionInfo{}
+		for _, name := range f.regions {
+			regions = append(regions, fakeRegionInfo{RegionName: name, OptInStatus: "opt-in-not-required"})
+		}
+		return struct {
+			Regions []fakeRegionInfo `xml:"regionInfo>item"`
+		}{regions}, nil
+
+	case "CreateVpc":
+		if _, err := netip.ParsePrefix(form.Get("CidrBlock")); err != nil {
+			return nil, newFakeError("InvalidParameterValue", "invalid cidr block %s", form.Get("CidrBlock"))
+		}
+		vpc := &fakeVpc{Region: region, VpcId: f.newId("vpc"), CidrBlock: form.Get("CidrBlock"), State: "available", OwnerId: fakeAccount, Tags: getFormTags(form, "vpc")}
+		f.vpcs = append(f.vpcs, vpc)
+		f.routeTables = append(f.routeTables, &fakeRouteTable{
+			Region:       region,
+			RouteTableId: f.newId("rtb"),
+			VpcId:        vpc.VpcId,
+			Associations: []fakeRouteTableAssociation{{Main: true}},
+			Routes:       []fakeRoute{{DestinationCidrBlock: vpc.CidrBlock, GatewayId: "local", State: "active"}},
+		})
+		return struct {
+			Vpc *fakeVpc `xml:"vpc"`
+		}{vpc}, nil
+
+	case "DescribeVpcs":
+		vpcs, err := filterObjects(f.vpcs, func(v *fakeVpc) string { return v.Region }, region, filters)
+		if err != nil {
+			return nil, err.(*fakeError)
+		}
+		if ids := getFormList(form, "VpcId"); len(ids) > 0 {
+			vpcs, err = filterObjects(vpcs, nil, region, map[string][]string{"vpc-id": ids})
+			if err != nil {
+				return nil, err.(*fakeError)
+			}
+			if len(vpcs) != len(ids) {
+				return nil, newFakeError("InvalidVpcID.NotFound", "the vpc IDs %v do not exist", ids)
+			}
+		}
+		return struct {
+			Vpcs []*fakeVpc `xml:"vpcSet>item"`
+		}{vpcs}, nil
+
+	case "CreateDhcpOptions":
+		dhcpOptions := &fakeDhcpOptions{Region: region, DhcpOptionsId: f.newId("dopt"), OwnerId: fakeAccount, Tags: getFormTags(form, "dhcp-options")}
+		for i := 1; form.Has(fmt.Sprintf("DhcpConfiguration.%d.Key", i)); i++ {
+			configuration := fakeDhcpConfiguration{Key: form.Get(fmt.Sprintf("DhcpConfiguration.%d.Key", i))}
+			for _, value := range getFormList(form, fmt.Sprintf("DhcpConfiguration.%d.Value", i)) {
+				configuration.Values = append(configuration.Values, fakeAttributeValue{Value: value})
+			}
+			dhcpOptions.DhcpConfigurations = append(dhcpOptions.DhcpConfigurations, configuration)
+		}
+		f.dhcpOptions = append(f.dhcpOptions, dhcpOptions)
+		return struct {
+			DhcpOptions *fakeDhcpOptions `xml:"dhcpOptions"`
+		}{dhcpOptions}, nil
+
+	case "AssociateDhcpOptions":
+		vpc := f.getVpc(region, form.Get("VpcId"))
+		if vpc == nil {
+			return nil, newFakeError("InvalidVpcID.NotFound", "the vpc ID '%s' does not exist", form.Get("VpcId"))
+		}
+		if f.getDhcpOptions(region, form.Get("DhcpOptionsId")) == nil {
+			return nil, newFakeError("InvalidDhcpOptionID.NotFound", "the dhcp options ID '%s' does not exist", form.Get("DhcpOptionsId"))
+		}
+		vpc.DhcpOptionsId = form.Get("DhcpOptionsId")
+		return fakeReturn{true}, nil
+
+	case "CreateSubnet":
+		vpc := f.getVpc(region, form.Get("VpcId"))
+		if vpc == nil {
+			return nil, newFakeError("InvalidVpcID.NotFound", "the vpc ID '%s' does not exist", form.Get("VpcId"))
+		}
+		subnet := &fakeSubnet{Region: region, SubnetId: f.newId("subnet"), VpcId: vpc.VpcId, CidrBlock: form.Get("CidrBlock"), AvailabilityZone: form.Get("AvailabilityZone"), State: "available", Tags: getFormTags(form, "subnet")}
+		f.subnets = append(f.subnets, subnet)
+		return struct {
+			Subnet *fakeSubnet `xml:"subnet"`
+		}{subnet}, nil
+
+	case "DescribeSubnets":
+		subnets, err := filterObjects(f.subnets, func(s *fakeSubnet) string { return s.Region }, region, filters)
+		if err != nil {
+			return nil, err.(*fakeError)
+		}
+		return struct {
+			Subnets []*fakeSubnet `xml:"subnetSet>item"`
+		}{subnets}, nil
+
+	case "CreateSecurityGroup":
+		for _, group := range f.securityGroups {
+			if group.VpcId == form.Get("VpcId") && group.GroupName == form.Get("GroupName") {
+				return nil, newFakeError("InvalidGroup.Duplicate", "the security group '%s' already exists", group.GroupName)
+			}
+		}
+		group := &fakeSecurityGroup{Region: region, GroupId: f.newId("sg"), GroupName: form.Get("GroupName"), Description: form.Get("GroupDescription"), VpcId: form.Get("VpcId"), Tags: getFormTags(form, "security-group")}
+		f.securityGroups = append(f.securityGroups, group)
+		// Security groups allow all egress traffic by default
+		f.sgRules = append(f.sgRules, &fakeSecurityGroupRule{Region: region, SecurityGroupRuleId: f.newId("sgr"), GroupId: group.GroupId, IsEgress: true, IpProtocol: "-1", FromPort: -1, ToPort: -1, CidrIpv4: "0.0.0.0/0"})
+		return struct {
+			GroupId string    `xml:"groupId"`
+			Tags    []fakeTag `xml:"tagSet>item"`
+		}{group.GroupId, group.Tags}, nil
+
+	case "AuthorizeSecurityGroupIngress", "AuthorizeSecurityGroupEgress":
+		isEgress := action == "AuthorizeSecurityGroupEgress"
+		if f.getSecurityGroup(region, form.Get("GroupId")) == nil {
+			return nil, newFakeError("InvalidGroup.NotFound", "the security group '%s' does not exist", form.Get("GroupId"))
+		}
+		added := []*fakeSecurityGroupRule{}
+		for _, sgRule := range getFormIpPermissions(form) {
+			for _, existing := range f.sgRules {
+				if existing.GroupId == form.Get("GroupId") && existing.IsEgress == isEgress && existing.IpProtocol == sgRule.IpProtocol && existing.FromPort == sgRule.FromPort && existing.ToPort == sgRule.ToPort && existing.CidrIpv4 == sgRule.CidrIpv4 {
+					return nil, newFakeError("InvalidPermission.Duplicate", "the specified rule already exists")
+				}
+			}
+			sgRule.Region = region
+			sgRule.SecurityGroupRuleId = f.newId("sgr")
+			sgRule.GroupId = form.Get("GroupId")
+			sgRule.IsEgress = isEgress
+			sgRule.Tags = getFormTags(form, "security-group-rule")
+			added = append(added, sgRule)
+		}
+		f.sgRules = append(f.sgRules, added...)
+		return struct {
+			Return  bool                     `xml:"return"`
+			SgRules []*fakeSecurityGroupRule `xml:"securityGroupRuleSet>item"`
+		}{true, added}, nil
+
+	case "RevokeSecurityGroupIngress", "RevokeSecurityGroupEgress":
+		isEgress := action == "RevokeSecurityGroupEgress"
+		ruleIds := getFormList(form, "SecurityGroupRuleId")
+		permissions := getFormIpPermissions(form)
+		remaining := []*fakeSecurityGroupRule{}
+		revoked := 0
+		for _, existing := range f.sgRules {
+			revoke := false
+			if existing.GroupId == form.Get("GroupId") && existing.IsEgress == isEgress {
+				for _, id := range ruleIds {
+					revoke = revoke || existing.SecurityGroupRuleId == id
+				}
+				for _, permission := range permissions {
+					revoke = revoke || (existing.IpProtocol == permission.IpProtocol && existing.CidrIpv4 == permission.CidrIpv4 && (permission.IpProtocol == "-1" || (existing.FromPort == permission.FromPort && existing.ToPort == permission.ToPort)))
+				}
+			}
+			if revoke {
+				revoked++
+			} else {
+				remaining = append(remaining, existing)
+			}
+		}
+		if revoked != len(ruleIds)+len(permissions) {
+			return nil, newFakeError("InvalidSecurityGroupRuleId.NotFound", "some of the specified rules do not exist")
+		}
+		f.sgRules = remaining
+		return fakeReturn{true}, nil
+
+	case "DescribeSecurityGroupRules":
+		sgRules, err := filterObjects(f.sgRules, func(r *fakeSecurityGroupRule) string { return r.Region }, region, filters)
+		if err != nil {
+			return nil, err.(*fakeError)
+		}
+		return struct {
+			SgRules []*fakeSecurityGroupRule `xml:"securityGroupRuleSet>item"`
+		}{sgRules}, nil
+
+	case "RunInstances":
+		subnet := f.getSubnet(region, form.Get("SubnetId"))
+		if subnet == nil {
+			return nil, newFakeError("InvalidSubnetID.NotFound", "the subnet ID '%s' does not exist", form.Get("SubnetId"))
+		}
+		if zone := form.Get("Placement.AvailabilityZone"); zone != "" && zone != subnet.AvailabilityZone {
+			return nil, newFakeError("InvalidParameterValue", "subnet %s is not in availability zone %s", subnet.SubnetId, zone)
+		}
+		groups := []fakeGroupIdentifier{}
+		for _, groupId := range getFormList(form, "SecurityGroupId") {
+			group := f.getSecurityGroup(region, groupId)
+			if group == nil {
+				return nil, newFakeError("InvalidGroup.NotFound", "the security group '%s' does not exist", groupId)
+			}
+			groups = append(groups, fakeGroupIdentifier{GroupId: group.GroupId, GroupName: group.GroupName})
+		}
+		// Addresses are handed out after the 4 addresses AWS reserves at the start of each subnet
+		prefix, _ := netip.ParsePrefix(subnet.CidrBlock)
+		addr := prefix.Addr()
+		for i := 0; i < 4+subnet.numInstances; i++ {
+			addr = addr.Next()
+		}
+		subnet.numInstances++
+		instance := &fakeInstance{
+			Region:           region,
+			InstanceId:       f.newId("i"),
+			ImageId:          form.Get("ImageId"),
+			InstanceType:     form.Get("InstanceType"),
+			AvailabilityZone: subnet.AvailabilityZone,
+			PrivateIpAddress: addr.String(),
+			SubnetId:         subnet.SubnetId,
+			VpcId:            subnet.VpcId,
+			State:            fakeInstanceState{Code: 16, Name: "running"},
+			Groups:           groups,
+			NetworkInterfaces: []fakeInstanceNetworkInterface{{
+				NetworkInterfaceId: f.newId("eni"),
+				PrivateIpAddresses: []fakePrivateIpAddress{{PrivateIpAddress: addr.String(), Primary: true}},
+			}},
+			Tags: getFormTags(form, "instance"),
+		}
+		f.instances = append(f.instances, instance)
+		return struct {
+			ReservationId string          `xml:"reservationId"`
+			OwnerId       string          `xml:"ownerId"`
+			Instances     []*fakeInstance `xml:"instancesSet>item"`
+		}{f.newId("r"), fakeAccount, []*fakeInstance{instance}}, nil
+
+	case "DescribeInstances":
+		instances, err := filterObjects(f.instances, func(i *fakeInstance) string { return i.Region }, region, filters)
+		if err != nil {
+			return nil, err.(*fakeError)
+		}
+		if ids := getFormList(form, "InstanceId"); len(ids) > 0 {
+			instances, err = filterObjects(instances, nil, region, map[string][]string{"instance-id": ids})
+			if err != nil {
+				return nil, err.(*fakeError)
+			}
+			if len(instances) != len(ids) {
+				return nil, newFakeError("InvalidInstanceID.NotFound", "the instance IDs %v do not exist", ids)
+			}
+		}
+		type reservation struct {
+			ReservationId string          `xml:"reservationId"`
+			OwnerId       string          `xml:"ownerId"`
+			Instances     []*fakeInstance `xml:"instancesSet>item"`
+		}
+		reservations := []reservation{}
+		for _, instance := range instances {
+			reservations = append(reservations, reservation{"r-" + instance.InstanceId, fakeAccount, []*fakeInstance{instance}})
+		}
+		return struct {
+			Reservations []reservation `xml:"reservationSet>item"`
+		}{reservations}, nil
+
+	case "DescribeRouteTables":
+		routeTables, err := filterObjects(f.routeTables, func(r *fakeRouteTable) string { return r.Region }, region, filters)
+		if err != nil {
+			return nil, err.(*fakeError)
+		}
+		return struct {
+			RouteTables []*fakeRouteTable `xml:"routeTableSet>item"`
+		}{routeTables}, nil
+
+	case "CreateRoute":
+		routeTable := f.getRouteTable(region, form.Get("RouteTableId"))
+		if routeTable == nil {
+			return nil, newFakeError("InvalidRouteTableID.NotFound", "the route table '%s' does not exist", form.Get("RouteTableId"))
+		}
+		for _, route := range routeTable.Routes {
+			if route.DestinationCidrBlock == form.Get("DestinationCidrBlock") {
+				return nil, newFakeError("RouteAlreadyExists", "the route identified by %s already exists", route.DestinationCidrBlock)
+			}
+		}
+		routeTable.Routes = append(routeTable.Routes, fakeRoute{DestinationCidrBlock: form.Get("DestinationCidrBlock"), GatewayId: form.Get("GatewayId"), VpcPeeringConnectionId: form.Get("VpcPeeringConnectionId"), State: "active"})
+		return fakeReturn{true}, nil
+
+	case "CreateVpcPeeringConnection":
+		vpc := f.getVpc(region, form.Get("VpcId"))
+		if vpc == nil {
+			return nil, newFakeError("InvalidVpcID.NotFound", "the vpc ID '%s' does not exist", form.Get("VpcId"))
+		}
+		peerRegion := form.Get("PeerRegion")
+		if peerRegion == "" {
+			peerRegion = region
+		}
+		peerVpc := f.getVpc(peerRegion, form.Get("PeerVpcId"))
+		if peerVpc == nil {
+			return nil, newFakeError("InvalidVpcID.NotFound", "the vpc ID '%s' does not exist in %s", form.Get("PeerVpcId"), peerRegion)
+		}
+		peering := &fakeVpcPeeringConnection{
+			VpcPeeringConnectionId: f.newId("pcx"),
+			RequesterVpcInfo:       fakeVpcPeeringInfo{VpcId: vpc.VpcId, OwnerId: fakeAccount, Region: region, CidrBlock: vpc.CidrBlock},
+			AccepterVpcInfo:        fakeVpcPeeringInfo{VpcId: peerVpc.VpcId, OwnerId: fakeAccount, Region: peerRegion, CidrBlock: peerVpc.CidrBlock},
+			Status:                 fakeVpcPeeringStatus{Code: "pending-acceptance", Message: "Pending Acceptance by " + fakeAccount},
+			Tags:                   getFormTags(form, "vpc-peering-connection"),
+		}
+		f.peerings = append(f.peerings, peering)
+		return struct {
+			Peering *fakeVpcPeeringConnection `xml:"vpcPeeringConnection"`
+		}{peering}, nil
+
+	case "AcceptVpcPeeringConnection":
+		peering := f.getPeering(region, form.Get("VpcPeeringConnectionId"))
+		if peering == nil {
+			return nil, newFakeError("InvalidVpcPeeringConnectionID.NotFound", "the vpc peering connection ID '%s' does not exist", form.Get("VpcPeeringConnectionId"))
+		}
+		if peering.AccepterVpcInfo.Region != region {
+			return nil, newFakeError("OperationNotPermitted", "the vpc peering connection must be accepted in %s", peering.AccepterVpcInfo.Region)
+		}
+		peering.Status = fakeVpcPeeringStatus{Code: "active", Message: "Active"}
+		return struct {
+			Peering *fakeVpcPeeringConnection `xml:"vpcPeeringConnection"`
+		}{peering}, nil
+
+	case "DescribeVpcPeeringConnections":
+		peerings := []*fakeVpcPeeringConnection{}
+		for _, peering := range f.peerings {
+			if peering.RequesterVpcInfo.Region == region || peering.AccepterVpcInfo.Region == region {
+				peerings = append(peerings, peering)
+			}
+		}
+		peerings, err := filterObjects(peerings, nil, region, filters)
+		if err != nil {
+			return nil, err.(*fakeError)
+		}
+		if ids := getFormList(form, "VpcPeeringConnectionId"); len(ids) > 0 {
+			matches := []*fakeVpcPeeringConnection{}
+			for _, id := range ids {
+				if peering := f.getPeering(region, id); peering != nil {
+					matches = append(matches, peering)
+				}
+			}
+			if len(matches) != len(ids) {
+				return nil, newFakeError("InvalidVpcPeeringConnectionID.NotFound", "the vpc peering connection IDs %v do not exist", ids)
+			}
+			peerings = matches
+		}
+		return struct {
+			Peerings []*fakeVpcPeeringConnection `xml:"vpcPeeringConnectionSet>item"`
+		}{peerings}, nil
+
+	case "CreateVpnGateway":
+		vpnGateway := &fakeVpnGateway{Region: region, VpnGatewayId: f.newId("vgw"), State: "available", Type: form.Get("Type"), Tags: getFormTags(form, "vpn-gateway")}
+		vpnGateway.AmazonSideAsn, _ = strconv.ParseInt(form.Get("AmazonSideAsn"), 10, 64)
+		if vpnGateway.AmazonSideAsn == 0 {
+			vpnGateway.AmazonSideAsn = 64512
+		}
+		f.vpnGateways = append(f.vpnGateways, vpnGateway)
+		return struct {
+			VpnGateway *fakeVpnGateway `xml:"vpnGateway"`
+		}{vpnGateway}, nil
+
+	case "DescribeVpnGateways":
+		vpnGateways, err := filterObjects(f.vpnGateways, func(g *fakeVpnGateway) string { return g.Region }, region, filters)
+		if err != nil {
+			return nil, err.(*fakeError)
+		}
+		return struct {
+			VpnGateways []*fakeVpnGateway `xml:"vpnGatewaySet>item"`
+		}{vpnGateways}, nil
+
+	case "AttachVpnGateway":
+		vpnGateway := f.getVpnGateway(region, form.Get("VpnGatewayId"))
+		if vpnGateway == nil {
+			return nil, newFakeError("InvalidVpnGatewayID.NotFound", "the vpn gateway ID '%s' does not exist", form.Get("VpnGatewayId"))
+		}
+		if f.getVpc(region, form.Get("VpcId")) == nil {
+			return nil, newFakeError("InvalidVpcID.NotFound", "the vpc ID '%s' does not exist", form.Get("VpcId"))
+		}
+		if len(vpnGateway.Attachments) > 0 {
+			return nil, newFakeError("VpnGatewayAttachmentLimitExceeded", "the vpn gateway '%s' is already attached", vpnGateway.VpnGatewayId)
+		}
+		attachment := fakeVpcAttachment{VpcId: form.Get("VpcId"), State: "attached"}
+		vpnGateway.Attachments = append(vpnGateway.Attachments, attachment)
+		return struct {
+			Attachment fakeVpcAttachment `xml:"attachment"`
+		}{attachment}, nil
+
+	case "EnableVgwRoutePropagation":
+		routeTable := f.getRouteTable(region, form.Get("RouteTableId"))
+		if routeTable == nil {
+			return nil, newFakeError("InvalidRouteTableID.NotFound", "the route table '%s' does not exist", form.Get("RouteTableId"))
+		}
+		if f.getVpnGateway(region, form.Get("GatewayId")) == nil {
+			return nil, newFakeError("InvalidVpnGatewayID.NotFound", "the vpn gateway ID '%s' does not exist", form.Get("GatewayId"))
+		}
+		routeTable.PropagatingVgws = append(routeTable.PropagatingVgws, fakePropagatingVgw{GatewayId: form.Get("GatewayId")})
+		return fakeReturn{true}, nil
+
+	case "CreateCustomerGateway":
+		bgpAsn := form.Get("BgpAsn")
+		if bgpAsn == "" {
+			bgpAsn = form.Get("BgpAsnExtended")
+		}
+		customerGateway := &fakeCustomerGateway{Region: region, CustomerGatewayId: f.newId("cgw"), BgpAsn: bgpAsn, IpAddress: form.Get("IpAddress"), State: "available", Type: form.Get("Type"), Tags: getFormTags(form, "customer-gateway")}
+		f.customerGateways = append(f.customerGateways, customerGateway)
+		return struct {
+			CustomerGateway *fakeCustomerGateway `xml:"customerGateway"`
+		}{customerGateway}, nil
+
+	case "DescribeCustomerGateways":
+		customerGateways, err := filterObjects(f.customerGateways, func(g *fakeCustomerGateway) string { return g.Region }, region, filters)
+		if err != nil {
+			return nil, err.(*fakeError)
+		}
+		return struct {
+			CustomerGateways []*fakeCustomerGateway `xml:"customerGatewaySet>item"`
+		}{customerGateways}, nil
+
+	case "CreateVpnConnection":
+		if f.getVpnGateway(region, form.Get("VpnGatewayId")) == nil {
+			return nil, newFakeError("InvalidVpnGatewayID.NotFound", "the vpn gateway ID '%s' does not exist", form.Get("VpnGatewayId"))
+		}
+		if f.getCustomerGateway(region, form.Get("CustomerGatewayId")) == nil {
+			return nil, newFakeError("InvalidCustomerGatewayID.NotFound", "the customer gateway ID '%s' does not exist", form.Get("CustomerGatewayId"))
+		}
+		vpnConnection := &fakeVpnConnection{
+			Region:            region,
+			VpnConnectionId:   f.newId("vpn"),
+			CustomerGatewayId: form.Get("CustomerGatewayId"),
+			VpnGatewayId:      form.Get("VpnGatewayId"),
+			State:             "available",
+			Type:              form.Get("Type"),
+			Options:           fakeVpnConnectionOptions{StaticRoutesOnly: form.Get("Options.StaticRoutesOnly") == "true"},
+			Tags:              getFormTags(form, "vpn-connection"),
+		}
+		// Every VPN connection has two tunnels whose unspecified options are picked by AWS
+		for i := 1; i <= 2; i++ {
+			f.counter++
+			tunnel := fakeTunnelOption{
+				OutsideIpAddress: fmt.Sprintf("203.0.113.%d", f.counter%256),
+				TunnelInsideCidr: form.Get(fmt.Sprintf("Options.TunnelOptions.%d.TunnelInsideCidr", i)),
+				PreSharedKey:     form.Get(fmt.Sprintf("Options.TunnelOptions.%d.PreSharedKey", i)),
+			}
+			if tunnel.TunnelInsideCidr == "" {
+				tunnel.TunnelInsideCidr = fmt.Sprintf("169.254.200.%d/30", 4*(f.counter%64))
+			}
+			vpnConnection.Options.TunnelOptions = append(vpnConnection.Options.TunnelOptions, tunnel)
+		}
+		f.vpnConnections = append(f.vpnConnections, vpnConnection)
+		return struct {
+			VpnConnection *fakeVpnConnection `xml:"vpnConnection"`
+		}{vpnConnection}, nil
+
+	case "DescribeVpnConnections":
+		vpnConnections, err := filterObjects(f.vpnConnections, func(c *fakeVpnConnection) string { return c.Region }, region, filters)
+		if err != nil {
+			return nil, err.(*fakeError)
+		}
+		return struct {
+			VpnConnections []*fakeVpnConnection `xml:"vpnConnectionSet>item"`
+		}{vpnConnections}, nil
+	}
+	return nil, newFakeError("InvalidAction", "the action %s is not supported by the fake server", action)
+}
+
+// Parses the IP permissions of a request into one security group rule per IP range
+func getFormIpPermissions(form url.Values) []*fakeSecurityGroupRule {
+	sgRules := []*fakeSecurityGroupRule{}
+	for i := 1; form.Has(fmt.Sprintf("IpPermissions.%d.IpProtocol", i)); i++ {
+		prefix := fmt.Sprintf("IpPermissions.%d.", i)
+		fromPort, toPort := int32(-1), int32(-1)
+		if form.Has(prefix + "FromPort") {
+			fromPort = getFormInt(form, prefix+"FromPort")
+		}
+		if form.Has(prefix + "ToPort") {
+			toPort = getFormInt(form, prefix+"ToPort")
+		}
+		for j := 1; form.Has(fmt.Sprintf("%sIpRanges.%d.CidrIp", prefix, j)); j++ {
+			sgRules = append(sgRules, &fakeSecurityGroupRule{
+				IpProtocol:  form.Get(prefix + "IpProtocol"),
+				FromPort:    fromPort,
+				ToPort:      toPort,
+				CidrIpv4:    form.Get(fmt.Sprintf("%sIpRanges.%d.CidrIp", prefix, j)),
+				Description: form.Get(fmt.Sprintf("%sIpRanges.%d.Description", prefix, j)),
+			})
+		}
+	}
+	return sgRules
+}
+
+func (f *fakeServerState) getVpc(region string, id string) *fakeVpc {
+	for _, vpc := range f.vpcs {
+		if vpc.Region == region && vpc.VpcId == id {
+			return vpc
+		}
+	}
+	return nil
+}
+
+func (f *fakeServerState) getDhcpOptions(region string, id string) *fakeDhcpOptions {
+	for _, dhcpOptions := range f.dhcpOptions {
+		if dhcpOptions.Region == region && dhcpOptions.DhcpOptionsId == id {
+			return dhcpOptions
+		}
+	}
+	return nil
+}
+
+func (f *fakeServerState) getSubnet(region string, id string) *fakeSubnet {
+	for _, subnet := range f.subnets {
+		if subnet.Region == region && subnet.SubnetId == id {
+			return subnet
+		}
+	}
+	return nil
+}
+
+func (f *fakeServerState) getSecurityGroup(region string, id string) *fakeSecurityGroup {
+	for _, group := range f.securityGroups {
+		if group.Region == region && group.GroupId == id {
+			return group
+		}
+	}
+	return nil
+}
+
+func (f *fakeServerState) getRouteTable(region string, id string) *fakeRouteTable {
+	for _, routeTable := range f.routeTables {
+		if routeTable.Region == region && routeTable.RouteTableId == id {
+			return routeTable
+		}
+	}
+	return nil
+}
+
+func (f *fakeServerState) getVpnGateway(region string, id string) *fakeVpnGateway {
+	for _, vpnGateway := range f.vpnGateways {
+		if vpnGateway.Region == region && vpnGateway.VpnGatewayId == id {
+			return vpnGateway
+		}
+	}
+	return nil
+}
+
+func (f *fakeServerState) getCustomerGateway(region string, id string) *fakeCustomerGateway {
+	for _, customerGateway := range f.customerGateways {
+		if customerGateway.Region == region && customerGateway.CustomerGatewayId == id {
+			return customerGateway
+		}
+	}
+	return nil
+}
+
+// Gets a VPC peering connection visible in a region (i.e., the region of either VPC)
+func (f *fakeServerState) getPeering(region string, id string) *fakeVpcPeeringConnection {
+	for _, peering := range f.peerings {
+		if peering.VpcPeeringConnectionId == id && (peering.RequesterVpcInfo.Region == region || peering.AccepterVpcInfo.Region == region) {
+			return peering
+		}
+	}
+	return nil
+}
+
+// Sets up a fake EC2 server and a plugin server using it
+func setup(t *testing.T, fakeServerState *fakeServerState, orchestratorServerAddr string) (*httptest.Server, *AWSPluginServer) {
+	fakeServer := httptest.NewServer(getFakeServerHandler(fakeServerState))
+	s := &AWSPluginServer{
+		orchestratorServerAddr: orchestratorServerAddr,
+		ec2Options: []func(*ec2.Options){
+			func(o *ec2.Options) {
+				o.BaseEndpoint = aws.String(fakeServer.URL)
+				o.Credentials = credentials.NewStaticCredentialsProvider("fake", "fake", "")
+				o.RetryMaxAttempts = 1
+			},
+		},
+	}
+	t.Cleanup(fakeServer.Close)
+	return fakeServer, s
+}
+
+// Gets the resource description of a fake instance
+func getFakeInstanceDescription(name string, zone string) *paragliderpb.CreateResourceRequest {
+	description := fmt.Sprintf(`{"ImageId": %q, "InstanceType": %q, "Placement": {"AvailabilityZone": %q}}`, fakeImageId, fakeInstanceType, zone)
+	return &paragliderpb.CreateResourceRequest{
+		Deployment:  &paragliderpb.ParagliderDeployment{Id: fakeDeploymentId, Namespace: fakeNamespace},
+		Name:        name,
+		Description: []byte(description),
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/resourcespec"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Default AWS instance types and images for the cloud-agnostic VM description
+// Images are public SSM parameters which EC2 resolves to the AMI of the instance's region
+var defaultVMMappings = resourcespec.Mappings{
+	Sizes: map[string]string{
+		resourcespec.SizeSmall:  "t3.small",
+		resourcespec.SizeMedium: "t3.medium",
+		resourcespec.SizeLarge:  "t3.xlarge",
+	},
+	Images: map[string]string{
+		resourcespec.ImageUbuntu2204: "resolve:ssm:/aws/service/canonical/ubuntu/server/22.04/stable/current/amd64/hvm/ebs-gp2/ami-id",
+		resourcespec.ImageDebian12:   "resolve:ssm:/aws/service/debian/release/12/latest/amd64",
+	},
+}
+
+// Root device names of the default images, which are needed to resize their boot disks
+var rootDeviceNames = map[string]string{
+	resourcespec.ImageUbuntu2204: "/dev/sda1",
+	resourcespec.ImageDebian12:   "/dev/xvda",
+}
+
+// Translates a cloud-agnostic resource description into an AWS one, leaving native descriptions untouched
+func translateResourceDescription(resource *paragliderpb.CreateResourceRequest) (*paragliderpb.CreateResourceRequest, error) {
+	if !resourcespec.IsVM(resource.Description) {
+		return resource, nil
+	}
+	vm, err := resourcespec.ParseVM(resource.Description)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	runInstancesInput, err := translateVM(vm)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	desc, err := json.Marshal(runInstancesInput)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to marshal translated description: %v", err)
+	}
+	translated := proto.Clone(resource).(*paragliderpb.CreateResourceRequest)
+	translated.Description = desc
+	return translated, nil
+}
+
+// Builds the EC2 instance launch described by a cloud-agnostic VM description (the region is an availability zone)
+func translateVM(vm *resourcespec.VM) (*ec2.RunInstancesInput, error) {
+	instanceType, image, err := vm.Resolve(defaultVMMappings)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &ec2.RunInstancesInput{
+		ImageId:      aws.String(image),
+		InstanceType: types.InstanceType(instanceType),
+		Placement:    &types.Placement{AvailabilityZone: aws.String(vm.Region)},
+		UserData:     aws.String(base64.StdEncoding.EncodeToString([]byte(vm.CloudInit()))),
+	}
+	if vm.DiskSizeGB > 0 {
+		rootDeviceName, ok := rootDeviceNames[vm.Image]
+		if !ok || image != defaultVMMappings.Images[vm.Image] {
+			return nil, fmt.Errorf("disk size can only be set for the default images")
+		}
+		input.BlockDeviceMappings = []types.BlockDeviceMapping{
+			{
+				DeviceName: aws.String(rootDeviceName),
+				Ebs:        &types.EbsBlockDevice{VolumeSize: aws.Int32(vm.DiskSizeGB), DeleteOnTermination: aws.Bool(true)},
+			},
+		}
+	}
+	return input, nil
+}
@@ -0,0 +1,91 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/resourcespec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func getFakeParagliderVM() *resourcespec.VM {
+	return &resourcespec.VM{
+		Kind:       resourcespec.VMKind,
+		Size:       resourcespec.SizeLarge,
+		Image:      resourcespec.ImageUbuntu2204,
+		Region:     fakeZone,
+		SSHKey:     "ssh-ed25519 AAAA",
+		DiskSizeGB: 20,
+	}
+}
+
+func TestTranslateResourceDescription(t *testing.T) {
+	description, err := json.Marshal(getFakeParagliderVM())
+	require.NoError(t, err)
+	resource := &paragliderpb.CreateResourceRequest{Name: fakeInstanceName, Description: description}
+
+	translated, err := translateResourceDescription(resource)
+	require.NoError(t, err)
+	assert.Equal(t, fakeInstanceName, translated.Name)
+
+	runInstancesInput := &ec2.RunInstancesInput{}
+	require.NoError(t, json.Unmarshal(translated.Description, runInstancesInput))
+	assert.Equal(t, fakeZone, *runInstancesInput.Placement.AvailabilityZone)
+	assert.Equal(t, types.InstanceType("t3.xlarge"), runInstancesInput.InstanceType)
+	assert.Equal(t, defaultVMMappings.Images[resourcespec.ImageUbuntu2204], *runInstancesInput.ImageId)
+	require.Len(t, runInstancesInput.BlockDeviceMappings, 1)
+	assert.Equal(t, "/dev/sda1", *runInstancesInput.BlockDeviceMappings[0].DeviceName)
+	assert.Equal(t, int32(20), *runInstancesInput.BlockDeviceMappings[0].Ebs.VolumeSize)
+	userData, err := base64.StdEncoding.DecodeString(*runInstancesInput.UserData)
+	require.NoError(t, err)
+	assert.Contains(t, string(userData), "ssh-ed25519 AAAA")
+
+	// Native descriptions are untouched
+	native := getFakeInstanceDescription(fakeInstanceName, fakeZone)
+	translated, err = translateResourceDescription(native)
+	require.NoError(t, err)
+	assert.Same(t, native, translated)
+
+	// Unknown size class
+	vm := getFakeParagliderVM()
+	vm.Size = "huge"
+	description, err = json.Marshal(vm)
+	require.NoError(t, err)
+	_, err = translateResourceDescription(&paragliderpb.CreateResourceRequest{Description: description})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Disk sizes of images overridden by the user are unknown
+	vm = getFakeParagliderVM()
+	vm.Mappings = &resourcespec.Mappings{Images: map[string]string{resourcespec.ImageUbuntu2204: "ami-custom"}}
+	description, err = json.Marshal(vm)
+	require.NoError(t, err)
+	_, err = translateResourceDescription(&paragliderpb.CreateResourceRequest{Description: description})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AWS naming conventions
+const (
+	paragliderPrefix = "para"
+	namespaceTagKey  = "paraglider-namespace" // Tag marking the namespace of every Paraglider resource
+	ruleTagKey       = "paraglider-rule"      // Tag marking the permit list rule of a security group rule
+	nameTagKey       = "Name"
+	defaultRegion    = "us-east-1" // Region used for calls which are not specific to a region (e.g., listing regions)
+)
+
+// Information about a resource parsed from its URI (accounts/<account>/regions/<region>/instances/<instance ID>)
+type resourceInfo struct {
+	Account    string
+	Region     string
+	InstanceId string
+}
+
+func getParagliderNamespacePrefix(namespace string) string {
+	return paragliderPrefix + "-" + namespace
+}
+
+// Gets the name of the Paraglider VPC of a namespace (one per region)
+func getVpcName(namespace string) string {
+	return getParagliderNamespacePrefix(namespace) + "-vpc"
+}
+
+// Gets the name of the Paraglider subnet of a namespace in an availability zone
+func getSubnetName(namespace string, zone string) string {
+	return getParagliderNamespacePrefix(namespace) + "-" + zone + "-subnet"
+}
+
+// Gets the name of the security group holding the permit list of an instance
+func getSecurityGroupName(namespace string, instanceName string) string {
+	return getSecurityGroupNamePrefix(namespace) + instanceName
+}
+
+func getSecurityGroupNamePrefix(namespace string) string {
+	return getParagliderNamespacePrefix(namespace) + "-sg-"
+}
+
+// Gets the name of a VPC peering connection between two namespaces
+func getVpcPeeringName(namespace string, peerNamespace string) string {
+	return getParagliderNamespacePrefix(namespace) + "-" + peerNamespace + "-peering"
+}
+
+// Gets the account from a deployment ID (accounts/<account>)
+func getAccount(deploymentId string) (string, error) {
+	account := parseUri(deploymentId)["accounts"]
+	if account == "" {
+		return "", status.Errorf(codes.InvalidArgument, "invalid deployment %q (expected accounts/<account>)", deploymentId)
+	}
+	return account, nil
+}
+
+// Gets the URI of an instance
+func getInstanceUri(account string, region string, instanceId string) string {
+	return fmt.Sprintf("accounts/%s/regions/%s/instances/%s", account, region, instanceId)
+}
+
+// Parses a resource URI into its components
+func parseResourceUri(uri string) (*resourceInfo, error) {
+	parsedUri := parseUri(uri)
+	info := &resourceInfo{Account: parsedUri["accounts"], Region: parsedUri["regions"], InstanceId: parsedUri["instances"]}
+	if info.Account == "" || info.Region == "" || info.InstanceId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid resource URI %q (expected accounts/<account>/regions/<region>/instances/<instance ID>)", uri)
+	}
+	return info, nil
+}
+
+// parseUri parses a resource URI into a map of its components (e.g., regions) and their values (e.g., us-east-1)
+func parseUri(uri string) map[string]string {
+	parsedUri := map[string]string{}
+	pathComponents := strings.Split(strings.Trim(uri, "/"), "/")
+	for i := 0; i+1 < len(pathComponents); i += 2 {
+		parsedUri[pathComponents[i]] = pathComponents[i+1]
+	}
+	return parsedUri
+}
+
+// Gets the region of an availability zone (e.g., us-east-1 for us-east-1a)
+func getRegionFromZone(zone string) string {
+	return strings.TrimRight(zone, "abcdefghijklmnopqrstuvwxyz")
+}
+
+// Gets the value of a tag (empty if the tag is not set)
+func getTagValue(tags []types.Tag, key string) string {
+	for _, tag := range tags {
+		if aws.ToString(tag.Key) == key {
+			return aws.ToString(tag.Value)
+		}
+	}
+	return ""
+}
+
+// Gets the tags of a Paraglider resource
+func getTagSpecifications(resourceType types.ResourceType, namespace string, name string) []types.TagSpecification {
+	return []types.TagSpecification{
+		{
+			ResourceType: resourceType,
+			Tags: []types.Tag{
+				{Key: aws.String(nameTagKey), Value: aws.String(name)},
+				{Key: aws.String(namespaceTagKey), Value: aws.String(namespace)},
+			},
+		},
+	}
+}
+
+// Gets a filter matching resources with the given tag
+func tagFilter(key string, values ...string) types.Filter {
+	return types.Filter{Name: aws.String("tag:" + key), Values: values}
+}
+
+// Gets a filter matching resources with the given attribute
+func filter(name string, values ...string) types.Filter {
+	return types.Filter{Name: aws.String(name), Values: values}
+}
+
+// Creates an EC2 client for a region
+func (s *AWSPluginServer) getEC2Client(ctx context.Context, region string) (*ec2.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load AWS config: %w", err)
+	}
+	return ec2.NewFromConfig(cfg, s.ec2Options...), nil
+}
+
+// Gets the regions enabled for the account
+func (s *AWSPluginServer) getRegions(ctx context.Context) ([]string, error) {
+	client, err := s.getEC2Client(ctx, defaultRegion)
+	if err != nil {
+		return nil, err
+	}
+	describeRegionsResp, err := client.DescribeRegions(ctx, &ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe regions: %w", err)
+	}
+	regions := make([]string, len(describeRegionsResp.Regions))
+	for i, region := range describeRegionsResp.Regions {
+		regions[i] = aws.ToString(region.RegionName)
+	}
+	return regions, nil
+}
+
+// Calls fn with an EC2 client for every region enabled for the account
+func (s *AWSPluginServer) forEachRegion(ctx context.Context, fn func(region string, client *ec2.Client) error) error {
+	regions, err := s.getRegions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, region := range regions {
+		client, err := s.getEC2Client(ctx, region)
+		if err != nil {
+			return err
+		}
+		if err := fn(region, client); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Checks if AWS error response is a not found error
+func isErrorNotFound(err error) bool {
+	var e smithy.APIError
+	return errors.As(err, &e) && strings.HasSuffix(e.ErrorCode(), ".NotFound")
+}
+
+// Checks if AWS error response is a duplicate error
+func isErrorDuplicate(err error) bool {
+	var e smithy.APIError
+	return errors.As(err, &e) && (strings.HasSuffix(e.ErrorCode(), ".Duplicate") || strings.HasSuffix(e.ErrorCode(), "AlreadyExists"))
+}
+
+// Gets the HTTP status code of a failed AWS API call
+// EC2 reports missing and duplicate resources as bad requests, so those are mapped to their HTTP equivalents
+func getErrorStatusCode(err error) (int, bool) {
+	if isErrorNotFound(err) {
+		return http.StatusNotFound, true
+	}
+	if isErrorDuplicate(err) {
+		return http.StatusConflict, true
+	}
+	var e *awshttp.ResponseError
+	if errors.As(err, &e) {
+		return e.HTTPStatusCode(), true
+	}
+	return 0, false
+}
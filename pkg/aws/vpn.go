@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const vpnType = "ipsec.1" // Only VPN type supported by AWS
+
+// States of VPN resources which are (or will be) usable
+var vpnActiveStates = []string{"pending", "available"}
+
+// Gets the name of the VPN gateway of a namespace (one per region)
+func getVpnGatewayName(namespace string) string {
+	return getParagliderNamespacePrefix(namespace) + "-vpn-gw"
+}
+
+// Gets the name of a customer gateway representing a VPN gateway interface of another cloud
+func getCustomerGatewayName(namespace string, cloud string, idx int) string {
+	return fmt.Sprintf("%s-%s-cgw-%d", getParagliderNamespacePrefix(namespace), cloud, idx)
+}
+
+// Gets the name of a VPN connection to another cloud
+func getVpnConnectionName(namespace string, cloud string, idx int) string {
+	return fmt.Sprintf("%s-%s-vpn-%d", getParagliderNamespacePrefix(namespace), cloud, idx)
+}
+
+// Gets the VPN gateway of a namespace in the client's region (nil if it does not exist)
+func getVpnGateway(ctx context.Context, client *ec2.Client, namespace string) (*types.VpnGateway, error) {
+	describeVpnGatewaysResp, err := client.DescribeVpnGateways(ctx, &ec2.DescribeVpnGatewaysInput{
+		Filters: []types.Filter{tagFilter(nameTagKey, getVpnGatewayName(namespace)), filter("state", vpnActiveStates...)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe vpn gateways: %w", err)
+	}
+	if len(describeVpnGatewaysResp.VpnGateways) == 0 {
+		return nil, nil
+	}
+	return &describeVpnGatewaysResp.VpnGateways[0], nil
+}
+
+// Gets a customer gateway by name in the client's region (nil if it does not exist)
+func getCustomerGateway(ctx context.Context, client *ec2.Client, name string) (*types.CustomerGateway, error) {
+	describeCustomerGatewaysResp, err := client.DescribeCustomerGateways(ctx, &ec2.DescribeCustomerGatewaysInput{
+		Filters: []types.Filter{tagFilter(nameTagKey, name), filter("state", vpnActiveStates...)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe customer gateways: %w", err)
+	}
+	if len(describeCustomerGatewaysResp.CustomerGateways) == 0 {
+		return nil, nil
+	}
+	return &describeCustomerGatewaysResp.CustomerGateways[0], nil
+}
+
+// Gets a VPN connection by name in the client's region (nil if it does not exist)
+func getVpnConnection(ctx context.Context, client *ec2.Client, name string) (*types.VpnConnection, error) {
+	describeVpnConnectionsResp, err := client.DescribeVpnConnections(ctx, &ec2.DescribeVpnConnectionsInput{
+		Filters: []types.Filter{tagFilter(nameTagKey, name), filter("state", vpnActiveStates...)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe vpn connections: %w", err)
+	}
+	if len(describeVpnConnectionsResp.VpnConnections) == 0 {
+		return nil, nil
+	}
+	return &describeVpnConnectionsResp.VpnConnections[0], nil
+}
+
+// Gets the VPC served by the VPN gateway of a namespace: the VPC containing the given address space, or the namespace's only VPC
+func (s *AWSPluginServer) getVpnVpc(ctx context.Context, namespace string, addressSpace string) (*vpcInfo, error) {
+	if addressSpace != "" {
+		vpc, err := s.findVpc(ctx, namespace, addressSpace)
+		if err != nil {
+			return nil, err
+		}
+		if vpc == nil {
+			return nil, status.Errorf(codes.NotFound, "no vpc in namespace %s contains %s", namespace, addressSpace)
+		}
+		return vpc, nil
+	}
+	vpcs, err := s.getNamespaceVpcs(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(vpcs) != 1 {
+		return nil, status.Errorf(codes.FailedPrecondition, "an address space is required to choose among the %d vpcs of namespace %s", len(vpcs), namespace)
+	}
+	return vpcs[0], nil
+}
+
+// Gets the BGP peering subnet (/30) containing a BGP peering IP address, which becomes the inside address space of a tunnel
+func getBgpPeeringSubnet(ipAddress string) (string, error) {
+	addr, err := netip.ParseAddr(ipAddress)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse bgp peering ip address: %w", err)
+	}
+	return netip.PrefixFrom(addr, 30).Masked().String(), nil
+}
+
+// Gets the IP address AWS uses in the inside address space of a tunnel (the first usable address)
+func getTunnelInsideIpAddress(tunnelInsideCidr string) (string, error) {
+	prefix, err := netip.ParsePrefix(tunnelInsideCidr)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse tunnel inside address space: %w", err)
+	}
+	return prefix.Masked().Addr().Next().String(), nil
+}
+
+// Gets the outside IP address of the tunnel of a VPN connection which is peered with the other cloud
+// Only the first tunnel of each connection is used since other clouds create one tunnel per connection
+func getTunnelOutsideIpAddress(vpnConnection *types.VpnConnection) (string, error) {
+	if vpnConnection.Options == nil || len(vpnConnection.Options.TunnelOptions) == 0 {
+		return "", fmt.Errorf("vpn connection %s has no tunnels", aws.ToString(vpnConnection.VpnConnectionId))
+	}
+	return aws.ToString(vpnConnection.Options.TunnelOptions[0].OutsideIpAddress), nil
+}
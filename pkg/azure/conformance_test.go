@@ -0,0 +1,72 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/plugintest"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	plugintest.Run(t, utils.AZURE, setupConformance)
+}
+
+func setupConformance(t *testing.T, orchestratorAddr string) *plugintest.Target {
+	vnetName := getVnetName(testLocation, namespace)
+	subnetId := uriPrefix + "Microsoft.Network/virtualNetworks/" + vnetName + "/subnets/" + validSubnetName
+	nic := getFakeInterface()
+	nic.Location = to.Ptr(testLocation)
+	nic.Properties.IPConfigurations[0].Properties.PrivateIPAddress = to.Ptr("10.0.0.4")
+	nic.Properties.IPConfigurations[0].Properties.Subnet = &armnetwork.Subnet{ID: to.Ptr(subnetId)}
+	vnet := getFakeVirtualNetwork()
+	vnet.Name = to.Ptr(vnetName)
+	nsg := getFakeNSG()
+	nsg.Properties = &armnetwork.SecurityGroupPropertiesFormat{}
+	vm := getFakeVirtualMachine(true)
+	serverState := &fakeServerState{
+		subId:  subID,
+		rgName: rgName,
+		nsg:    nsg,
+		nic:    nic,
+		vnet:   vnet,
+		vm:     &vm,
+		vpnGw:  &armnetwork.VirtualNetworkGateway{},
+	}
+	fakeServer, _ := SetupFakeAzureServer(t, serverState)
+	t.Cleanup(func() { Teardown(fakeServer) })
+
+	server, _ := setupTestAzurePluginServer()
+	server.orchestratorServerAddr = orchestratorAddr
+
+	createRequest, err := getFakeVMResourceDescription(to.Ptr(getFakeVirtualMachine(false)))
+	require.NoError(t, err)
+
+	return &plugintest.Target{
+		Server:        server,
+		Deployment:    &paragliderpb.ParagliderDeployment{Id: deploymentId, Namespace: namespace},
+		Resource:      vmURI,
+		CreateRequest: createRequest,
+	}
+}
@@ -28,7 +28,9 @@ import (
 	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
 	utils "github.com/paraglider-project/paraglider/pkg/utils"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
@@ -66,6 +68,11 @@ func (s *azurePluginServer) setupAzureHandler(resourceIdInfo ResourceIDInfo, nam
 	return &azureHandler, nil
 }
 
+// Checks whether an NSG rule was created by Paraglider for a permit list rule (rather than the default deny all rules)
+func isPermitListNSGRule(rule *armnetwork.SecurityRule) bool {
+	return !strings.HasPrefix(*rule.Name, denyAllNsgRulePrefix) && strings.HasPrefix(*rule.Name, paragliderPrefix)
+}
+
 // GetPermitList returns the permit list for the given resource by getting the NSG rules
 // associated with the resource and filtering out the Paraglider rules
 func (s *azurePluginServer) GetPermitList(ctx context.Context, req *paragliderpb.GetPermitListRequest) (*paragliderpb.GetPermitListResponse, error) {
@@ -91,7 +98,7 @@ func (s *azurePluginServer) GetPermitList(ctx context.Context, req *paragliderpb
 
 	// get the NSG rules
 	for _, rule := range nsg.Properties.SecurityRules {
-		if !strings.HasPrefix(*rule.Name, denyAllNsgRulePrefix) && strings.HasPrefix(*rule.Name, paragliderPrefix) {
+		if isPermitListNSGRule(rule) {
 			plRule, err := azureHandler.GetPermitListRuleFromNSGRule(rule)
 			if err != nil {
 				utils.Log.Printf("An error occured while getting Paraglider rule from NSG rule: %+v", err)
@@ -104,6 +111,84 @@ func (s *azurePluginServer) GetPermitList(ctx context.Context, req *paragliderpb
 	return &paragliderpb.GetPermitListResponse{Rules: rules}, nil
 }
 
+// GetResourceInfo returns the current private IP and provisioning state of the resource
+func (s *azurePluginServer) GetResourceInfo(ctx context.Context, req *paragliderpb.GetResourceInfoRequest) (*paragliderpb.GetResourceInfoResponse, error) {
+	resourceIdInfo, err := getResourceIDInfo(req.Uri)
+	if err != nil {
+		utils.Log.Printf("An error occured while getting resource ID info: %+v", err)
+		return nil, err
+	}
+	azureHandler, err := s.setupAzureHandler(resourceIdInfo, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	netInfo, err := GetAndCheckResourceState(ctx, azureHandler, req.Uri, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.GetResourceInfoResponse{Uri: req.Uri, Ip: netInfo.Address, Ips: netInfo.Addresses, State: netInfo.State}, nil
+}
+
+// GetResource returns the inventory entry of a Paraglider-managed resource
+func (s *azurePluginServer) GetResource(ctx context.Context, req *paragliderpb.GetResourceRequest) (*paragliderpb.GetResourceResponse, error) {
+	resourceIdInfo, err := getResourceIDInfo(req.Uri)
+	if err != nil {
+		utils.Log.Printf("An error occured while getting resource ID info: %+v", err)
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	azureHandler, err := s.setupAzureHandler(resourceIdInfo, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	netInfo, err := GetAndCheckResourceState(ctx, azureHandler, req.Uri, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	resource, err := getResource(req.Uri, netInfo)
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.GetResourceResponse{Resource: resource}, nil
+}
+
+// ListResources returns the inventory of VMs and AKS clusters tagged with the namespace in the deployment's resource group
+func (s *azurePluginServer) ListResources(ctx context.Context, req *paragliderpb.ListResourcesRequest) (*paragliderpb.ListResourcesResponse, error) {
+	resourceIdInfo, err := getResourceIDInfo(req.Deployment.Id)
+	if err != nil {
+		utils.Log.Printf("An error occured while getting resource ID info: %+v", err)
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	azureHandler, err := s.setupAzureHandler(resourceIdInfo, req.Deployment.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	taggedResources, err := azureHandler.ListNamespaceResources(ctx)
+	if err != nil {
+		utils.Log.Printf("An error occured while listing resources: %+v", err)
+		return nil, err
+	}
+	resources := []*paragliderpb.Resource{}
+	for _, taggedResource := range taggedResources {
+		// Other tagged resources (e.g., VNets and NSGs) are part of the Paraglider network rather than the inventory
+		if taggedResource.Type == nil || (*taggedResource.Type != virtualMachineTypeName && *taggedResource.Type != managedClusterTypeName) {
+			continue
+		}
+		netInfo, err := GetAndCheckResourceState(ctx, azureHandler, *taggedResource.ID, req.Deployment.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		resource, err := getResource(*taggedResource.ID, netInfo)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+	return &paragliderpb.ListResourcesResponse{Resources: resources}, nil
+}
+
 // AddPermitListRules does the mapping from Paraglider to Azure by creating/updating NSG for the given resource.
 // It creates an NSG rule for each permit list rule and applies this NSG to the associated resource (VM)'s NIC (if it doesn't exist).
 // It returns a BasicResponse that includes the nsg ID if successful and an error if it fails.
@@ -264,6 +349,12 @@ func (s *azurePluginServer) DeletePermitListRules(c context.Context, req *paragl
 // which means the resource should be added to a valid paraglider network, the attachement to a paraglider network
 // is determined by the resource's location.
 func (s *azurePluginServer) CreateResource(ctx context.Context, resourceDesc *paragliderpb.CreateResourceRequest) (*paragliderpb.CreateResourceResponse, error) {
+	resourceDesc, err := translateResourceDescription(resourceDesc)
+	if err != nil {
+		utils.Log.Printf("Resource description could not be translated:%+v", err)
+		return nil, err
+	}
+
 	resourceDescInfo, err := GetResourceInfoFromResourceDesc(ctx, resourceDesc)
 	if err != nil {
 		utils.Log.Printf("Resource description is invalid:%+v", err)
@@ -849,7 +940,7 @@ func Setup(port int, orchestratorServerAddr string) *azurePluginServer {
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to listen: %v", err)
 	}
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(utils.StatusErrorInterceptor(utils.AZURE, getErrorStatusCode)))
 	azureServer := &azurePluginServer{
 		orchestratorServerAddr: orchestratorServerAddr,
 		azureCredentialGetter:  &AzureCredentialGetter{},
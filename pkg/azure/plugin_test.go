@@ -248,6 +248,135 @@ func TestGetPermitList(t *testing.T) {
 	})
 }
 
+func TestGetResourceInfo(t *testing.T) {
+	fakeNic := getFakeNIC()
+	fakeNsg := getFakeNsgWithRules(*fakeNic.Properties.NetworkSecurityGroup.ID, "test-nsg-name")
+
+	t.Run("TestGetResourceInfo: Success", func(t *testing.T) {
+		vm := getFakeVirtualMachine(true)
+		vm.Properties.ProvisioningState = to.Ptr("Succeeded")
+		serverState := &fakeServerState{
+			subId:  subID,
+			rgName: rgName,
+			nsg:    fakeNsg,
+			nic:    fakeNic,
+			vm:     to.Ptr(vm),
+		}
+		fakeServer, ctx := SetupFakeAzureServer(t, serverState)
+		defer Teardown(fakeServer)
+
+		server, _ := setupTestAzurePluginServer()
+
+		resp, err := server.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Uri: vmURI, Namespace: namespace})
+		require.NoError(t, err)
+		require.Equal(t, vmURI, resp.Uri)
+		require.Equal(t, *fakeNic.Properties.IPConfigurations[0].Properties.PrivateIPAddress, resp.Ip)
+		require.Equal(t, "Succeeded", resp.State)
+	})
+
+	t.Run("TestGetResourceInfo: SecondaryAddresses", func(t *testing.T) {
+		vm := getFakeVirtualMachine(true)
+		vm.Properties.ProvisioningState = to.Ptr("Succeeded")
+		nic := getFakeNIC()
+		nic.Properties.IPConfigurations[0].Properties.PrivateIPAddress = to.Ptr("10.0.0.4")
+		nic.Properties.IPConfigurations = append(nic.Properties.IPConfigurations, &armnetwork.InterfaceIPConfiguration{
+			Properties: &armnetwork.InterfaceIPConfigurationPropertiesFormat{PrivateIPAddress: to.Ptr("10.0.0.5")},
+		})
+		serverState := &fakeServerState{
+			subId:  subID,
+			rgName: rgName,
+			nsg:    fakeNsg,
+			nic:    nic,
+			vm:     to.Ptr(vm),
+		}
+		fakeServer, ctx := SetupFakeAzureServer(t, serverState)
+		defer Teardown(fakeServer)
+
+		server, _ := setupTestAzurePluginServer()
+
+		resp, err := server.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Uri: vmURI, Namespace: namespace})
+		require.NoError(t, err)
+		require.Equal(t, "10.0.0.4", resp.Ip)
+		require.Equal(t, []string{"10.0.0.4", "10.0.0.5"}, resp.Ips)
+	})
+
+	t.Run("TestGetResourceInfo: Wrong namespace", func(t *testing.T) {
+		serverState := &fakeServerState{
+			subId:  subID,
+			rgName: rgName,
+			nsg:    fakeNsg,
+			nic:    fakeNic,
+			vm:     to.Ptr(getFakeVirtualMachine(true)),
+		}
+		fakeServer, ctx := SetupFakeAzureServer(t, serverState)
+		defer Teardown(fakeServer)
+
+		server, _ := setupTestAzurePluginServer()
+
+		resp, err := server.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Uri: vmURI, Namespace: "otherNamespace"})
+		require.Error(t, err)
+		require.Nil(t, resp)
+	})
+}
+
+func TestGetResourceInventory(t *testing.T) {
+	fakeNic := getFakeNIC()
+	fakeNsg := getFakeNsgWithRules(*fakeNic.Properties.NetworkSecurityGroup.ID, "test-nsg-name")
+	vm := getFakeVirtualMachine(true)
+	vm.Properties.ProvisioningState = to.Ptr("Succeeded")
+	serverState := &fakeServerState{
+		subId:  subID,
+		rgName: rgName,
+		nsg:    fakeNsg,
+		nic:    fakeNic,
+		vm:     to.Ptr(vm),
+	}
+	fakeServer, ctx := SetupFakeAzureServer(t, serverState)
+	defer Teardown(fakeServer)
+
+	server, _ := setupTestAzurePluginServer()
+
+	permitList, err := server.GetPermitList(ctx, &paragliderpb.GetPermitListRequest{Resource: vmURI, Namespace: namespace})
+	require.NoError(t, err)
+
+	resp, err := server.GetResource(ctx, &paragliderpb.GetResourceRequest{Uri: vmURI, Namespace: namespace})
+	require.NoError(t, err)
+	require.Equal(t, validVmName, resp.Resource.Name)
+	require.Equal(t, vmURI, resp.Resource.Uri)
+	require.Equal(t, getVnetName("test-location", namespace), resp.Resource.Network)
+	require.Equal(t, "subnet123", resp.Resource.Subnet)
+	require.Equal(t, testLocation, resp.Resource.Region)
+	require.Equal(t, "Succeeded", resp.Resource.State)
+	require.Equal(t, int32(len(permitList.Rules)), resp.Resource.RuleCount)
+
+	resp, err = server.GetResource(ctx, &paragliderpb.GetResourceRequest{Uri: vmURI, Namespace: "otherNamespace"})
+	require.Error(t, err)
+	require.Nil(t, resp)
+}
+
+func TestListResources(t *testing.T) {
+	fakeNic := getFakeNIC()
+	fakeNsg := getFakeNsgWithRules(*fakeNic.Properties.NetworkSecurityGroup.ID, "test-nsg-name")
+	serverState := &fakeServerState{
+		subId:  subID,
+		rgName: rgName,
+		nsg:    fakeNsg,
+		nic:    fakeNic,
+		vm:     to.Ptr(getFakeVirtualMachine(true)),
+	}
+	fakeServer, ctx := SetupFakeAzureServer(t, serverState)
+	defer Teardown(fakeServer)
+
+	server, _ := setupTestAzurePluginServer()
+
+	// Only the VM is listed, not the NSG which is also tagged with the namespace
+	resp, err := server.ListResources(ctx, &paragliderpb.ListResourcesRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: deploymentId, Namespace: namespace}})
+	require.NoError(t, err)
+	require.Len(t, resp.Resources, 1)
+	require.Equal(t, vmURI, resp.Resources[0].Uri)
+	require.Equal(t, validVmName, resp.Resources[0].Name)
+}
+
 func TestAddPermitListRules(t *testing.T) {
 	fakeOrchestratorServer, fakeOrchestratorServerAddr, err := fake.SetupFakeOrchestratorRPCServer(utils.AZURE)
 	if err != nil {
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
@@ -30,13 +31,17 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v4"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type resourceNetworkInfo struct {
-	SubnetID string
-	Address  string
-	Location string
-	NSG      *armnetwork.SecurityGroup
+	SubnetID  string
+	Address   string
+	Addresses []string // All private IPs of the resource (of every NIC and IP configuration) starting with Address
+	Location  string
+	NSG       *armnetwork.SecurityGroup
+	State     string
 }
 
 type resourceInfo struct {
@@ -54,7 +59,7 @@ func getResourceHandler(resourceID string) (AzureResourceHandler, error) {
 	} else if strings.Contains(resourceID, managedClusterTypeName) {
 		return &azureResourceHandlerAKS{}, nil
 	} else {
-		return nil, fmt.Errorf("resource type %s is not supported", resourceID)
+		return nil, status.Errorf(codes.InvalidArgument, "resource type %s is not supported", resourceID)
 	}
 }
 
@@ -66,14 +71,14 @@ func getResourceHandlerFromDescription(resourceDesc []byte) (AzureResourceHandle
 	} else if err := json.Unmarshal(resourceDesc, aks); err == nil && len(aks.Properties.AgentPoolProfiles) > 0 {
 		return &azureResourceHandlerAKS{}, nil
 	}
-	return nil, fmt.Errorf("resource description contains unsupported resource type")
+	return nil, status.Errorf(codes.InvalidArgument, "resource description contains unsupported resource type")
 }
 
 // Gets the resource and returns relevant networking state. Also checks that the resource is in the correct namespace.
 func GetAndCheckResourceState(ctx context.Context, handler *AzureSDKHandler, resourceID string, namespace string) (*resourceNetworkInfo, error) {
 	// Check the namespace
 	if namespace == "" {
-		return nil, fmt.Errorf("namespace cannot be empty")
+		return nil, status.Errorf(codes.InvalidArgument, "namespace cannot be empty")
 	}
 
 	// Get the resource
@@ -112,9 +117,50 @@ func GetNetworkInfoFromResource(ctx context.Context, handler *AzureSDKHandler, r
 		utils.Log.Printf("An error occured while getting network info for resource %s: %+v", resourceID, err)
 		return nil, err
 	}
+	networkInfo.State = getProvisioningState(resource)
 	return networkInfo, nil
 }
 
+// Converts a resource and its network information to its inventory entry
+func getResource(resourceID string, netInfo *resourceNetworkInfo) (*paragliderpb.Resource, error) {
+	name, err := GetLastSegment(resourceID)
+	if err != nil {
+		return nil, err
+	}
+	subnet, err := GetLastSegment(netInfo.SubnetID)
+	if err != nil {
+		return nil, err
+	}
+	ruleCount := 0
+	if netInfo.NSG != nil && netInfo.NSG.Properties != nil {
+		for _, rule := range netInfo.NSG.Properties.SecurityRules {
+			if isPermitListNSGRule(rule) {
+				ruleCount++
+			}
+		}
+	}
+	return &paragliderpb.Resource{
+		Name:      name,
+		Uri:       resourceID,
+		Ip:        netInfo.Address,
+		Region:    netInfo.Location,
+		Network:   getVnetFromSubnetId(netInfo.SubnetID),
+		Subnet:    subnet,
+		State:     netInfo.State,
+		RuleCount: int32(ruleCount),
+	}, nil
+}
+
+// Gets the provisioning state of a generic resource (empty if it is not reported)
+func getProvisioningState(resource *armresources.GenericResource) string {
+	properties, ok := resource.Properties.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	state, _ := properties["provisioningState"].(string)
+	return state
+}
+
 // Gets basic resource information from the description
 // Returns the resource name, ID, location, and whether the resource will require its own subnet in a struct
 func GetResourceInfoFromResourceDesc(ctx context.Context, resource *paragliderpb.CreateResourceRequest) (*resourceInfo, error) {
@@ -207,7 +253,8 @@ func (r *azureResourceHandlerVM) getNetworkInfo(ctx context.Context, resource *a
 	}
 
 	netprofile := properties["networkProfile"].(map[string]interface{})
-	nicID := netprofile["networkInterfaces"].([]interface{})[0].(map[string]interface{})["id"].(string)
+	nicRefs := netprofile["networkInterfaces"].([]interface{})
+	nicID := nicRefs[0].(map[string]interface{})["id"].(string)
 
 	nicName, err := GetLastSegment(nicID)
 	if err != nil {
@@ -229,15 +276,49 @@ func (r *azureResourceHandlerVM) getNetworkInfo(ctx context.Context, resource *a
 		return nil, err
 	}
 
+	// Secondary NICs only contribute their addresses
+	addresses := getInterfaceAddresses(nic)
+	for _, nicRef := range nicRefs[1:] {
+		secondaryNicName, err := GetLastSegment(nicRef.(map[string]interface{})["id"].(string))
+		if err != nil {
+			return nil, err
+		}
+		secondaryNic, err := sdkHandler.GetNetworkInterface(ctx, secondaryNicName)
+		if err != nil {
+			utils.Log.Printf("An error occured while getting the network interface:%+v", err)
+			return nil, err
+		}
+		for _, address := range getInterfaceAddresses(secondaryNic) {
+			if !slices.Contains(addresses, address) {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+
 	info := resourceNetworkInfo{
-		SubnetID: *nic.Properties.IPConfigurations[0].Properties.Subnet.ID,
-		Address:  *nic.Properties.IPConfigurations[0].Properties.PrivateIPAddress,
-		Location: *resource.Location,
-		NSG:      nsg,
+		SubnetID:  *nic.Properties.IPConfigurations[0].Properties.Subnet.ID,
+		Address:   *nic.Properties.IPConfigurations[0].Properties.PrivateIPAddress,
+		Addresses: addresses,
+		Location:  *resource.Location,
+		NSG:       nsg,
 	}
 	return &info, nil
 }
 
+// Gets the private IPs of all IP configurations of a network interface
+func getInterfaceAddresses(nic *armnetwork.Interface) []string {
+	addresses := []string{}
+	for _, ipConfiguration := range nic.Properties.IPConfigurations {
+		if ipConfiguration.Properties == nil || ipConfiguration.Properties.PrivateIPAddress == nil {
+			continue
+		}
+		if address := *ipConfiguration.Properties.PrivateIPAddress; address != "" && !slices.Contains(addresses, address) {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
 // Gets the resource information from the description
 func (r *azureResourceHandlerVM) getResourceInfoFromDescription(ctx context.Context, resource *paragliderpb.CreateResourceRequest) (*resourceInfo, error) {
 	vm, err := r.fromResourceDecription(resource.Description)
@@ -312,17 +393,17 @@ func (r *azureResourceHandlerVM) fromResourceDecription(resourceDesc []byte) (*a
 	vm := &armcompute.VirtualMachine{}
 	err := json.Unmarshal(resourceDesc, vm)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal resource description:%+v", err)
+		return nil, status.Errorf(codes.InvalidArgument, "failed to unmarshal resource description: %v", err)
 	}
 
 	// Some validations on the VM
 	if vm.Location == nil || vm.Properties == nil {
-		return nil, fmt.Errorf("resource description is missing location or properties")
+		return nil, status.Errorf(codes.InvalidArgument, "resource description is missing location or properties")
 	}
 
 	// Reject VMs that already have network interfaces
 	if vm.Properties.NetworkProfile != nil && vm.Properties.NetworkProfile.NetworkInterfaces != nil {
-		return nil, fmt.Errorf("resource description cannot contain network interface")
+		return nil, status.Errorf(codes.InvalidArgument, "resource description cannot contain network interface")
 	}
 
 	return vm, nil
@@ -390,10 +471,11 @@ func (r *azureResourceHandlerAKS) getNetworkInfo(ctx context.Context, resource *
 	}
 
 	return &resourceNetworkInfo{
-		SubnetID: *subnet.ID,
-		Address:  *subnet.Properties.AddressPrefix,
-		Location: *resource.Location,
-		NSG:      nsg,
+		SubnetID:  *subnet.ID,
+		Address:   *subnet.Properties.AddressPrefix,
+		Addresses: []string{*subnet.Properties.AddressPrefix},
+		Location:  *resource.Location,
+		NSG:       nsg,
 	}, nil
 }
 
@@ -440,30 +522,30 @@ func (r *azureResourceHandlerAKS) fromResourceDecription(resourceDesc []byte) (*
 	aks := &armcontainerservice.ManagedCluster{}
 	err := json.Unmarshal(resourceDesc, aks)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal resource description:%+v", err)
+		return nil, status.Errorf(codes.InvalidArgument, "failed to unmarshal resource description: %v", err)
 	}
 
 	// Some validations on the AKS
 	if aks.Location == nil || aks.Properties == nil {
-		return nil, fmt.Errorf("resource description is missing location or properties")
+		return nil, status.Errorf(codes.InvalidArgument, "resource description is missing location or properties")
 	}
 
 	// Reject AKS that already have virtual networks
 	for _, profile := range aks.Properties.AgentPoolProfiles {
 		if profile.VnetSubnetID != nil {
-			return nil, fmt.Errorf("resource description cannot contain virtual network")
+			return nil, status.Errorf(codes.InvalidArgument, "resource description cannot contain virtual network")
 		}
 	}
 
 	// Reject AKS that already has address spaces specified
 	if aks.Properties.NetworkProfile != nil {
 		if aks.Properties.NetworkProfile.PodCidr != nil || aks.Properties.NetworkProfile.ServiceCidr != nil {
-			return nil, fmt.Errorf("resource description cannot contain address spaces")
+			return nil, status.Errorf(codes.InvalidArgument, "resource description cannot contain address spaces")
 		}
 
 		if aks.Properties.NetworkProfile.NetworkPlugin != nil { // temporary check until we support kubenet
 			if *aks.Properties.NetworkProfile.NetworkPlugin != "azure" {
-				return nil, fmt.Errorf("resource description must have azure network plugin")
+				return nil, status.Errorf(codes.InvalidArgument, "resource description must have azure network plugin")
 			}
 		}
 	}
@@ -471,7 +553,7 @@ func (r *azureResourceHandlerAKS) fromResourceDecription(resourceDesc []byte) (*
 	// Require private cluster TODO @smcclure20: generalize this later
 	if aks.Properties.APIServerAccessProfile != nil {
 		if !(*aks.Properties.APIServerAccessProfile.EnablePrivateCluster) {
-			return nil, fmt.Errorf("resource description must have private cluster enabled")
+			return nil, status.Errorf(codes.InvalidArgument, "resource description must have private cluster enabled")
 		}
 	}
 
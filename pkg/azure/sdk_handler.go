@@ -487,7 +487,12 @@ func (h *AzureSDKHandler) GetParagliderVnet(ctx context.Context, vnetName string
 			if err != nil {
 				return nil, err
 			}
-			vnet, err := h.CreateParagliderVirtualNetwork(ctx, location, vnetName, response.AddressSpaces[0])
+			// Point the DNS settings of the vnet at Paraglider's DNS server if one is configured
+			dnsServers, err := utils.GetDnsServers(context.Background(), client)
+			if err != nil {
+				return nil, err
+			}
+			vnet, err := h.CreateParagliderVirtualNetwork(ctx, location, vnetName, response.AddressSpaces[0], dnsServers)
 			return vnet, err
 		} else {
 			// Return the error if it's not ResourceNotFound
@@ -536,8 +541,8 @@ func (h *AzureSDKHandler) AddSubnetToParagliderVnet(ctx context.Context, namespa
 }
 
 // CreateParagliderVirtualNetwork creates a new paraglider virtual network with a default subnet with the same address
-// space as the vnet
-func (h *AzureSDKHandler) CreateParagliderVirtualNetwork(ctx context.Context, location string, vnetName string, addressSpace string) (*armnetwork.VirtualNetwork, error) {
+// space as the vnet (and the DNS servers of its VMs if any are given)
+func (h *AzureSDKHandler) CreateParagliderVirtualNetwork(ctx context.Context, location string, vnetName string, addressSpace string, dnsServers []string) (*armnetwork.VirtualNetwork, error) {
 	// TODO @seankimkdy: delete and consolidate calls to this method with CreateParagliderVirtualNetwork
 	parameters := armnetwork.VirtualNetwork{
 		Location: to.Ptr(location),
@@ -557,6 +562,9 @@ func (h *AzureSDKHandler) CreateParagliderVirtualNetwork(ctx context.Context, lo
 			},
 		},
 	}
+	if len(dnsServers) > 0 {
+		parameters.Properties.DhcpOptions = &armnetwork.DhcpOptions{DNSServers: to.SliceOfPtrs(dnsServers...)}
+	}
 	h.createParagliderNamespaceTag(&parameters.Tags)
 
 	pollerResponse, err := h.virtualNetworksClient.BeginCreateOrUpdate(ctx, h.resourceGroupName, vnetName, parameters, nil)
@@ -842,6 +850,22 @@ func (h *AzureSDKHandler) GetVirtualNetworkGatewayConnection(ctx context.Context
 	return &resp.VirtualNetworkGatewayConnection, nil
 }
 
+// Lists the resources of the resource group which are tagged with the Paraglider namespace
+func (h *AzureSDKHandler) ListNamespaceResources(ctx context.Context) ([]*armresources.GenericResourceExpanded, error) {
+	resources := []*armresources.GenericResourceExpanded{}
+	pager := h.resourcesClient.NewListByResourceGroupPager(h.resourceGroupName, &armresources.ClientListByResourceGroupOptions{
+		Filter: to.Ptr(fmt.Sprintf("tagName eq '%s' and tagValue eq '%s'", namespaceTagKey, h.paragliderNamespace)),
+	})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, page.Value...)
+	}
+	return resources, nil
+}
+
 // Creates a tag for the Paraglider namespace in the "Tag" field of various resource parameters
 func (h *AzureSDKHandler) createParagliderNamespaceTag(tags *map[string]*string) {
 	if *tags == nil {
@@ -926,6 +950,15 @@ func isErrorNotFound(err error) bool {
 	return ok && azError.StatusCode == http.StatusNotFound
 }
 
+// Gets the HTTP status code of a failed Azure API call
+func getErrorStatusCode(err error) (int, bool) {
+	var azError *azcore.ResponseError
+	if errors.As(err, &azError) {
+		return azError.StatusCode, true
+	}
+	return 0, false
+}
+
 // Returns peering name from local vnet to remote vnet
 func getPeeringName(localVnetName string, remoteVnetName string) string {
 	return localVnetName + "-to-" + remoteVnetName
@@ -480,7 +480,7 @@ func TestCreateParagliderVirtualNetwork(t *testing.T) {
 	// Test case: Success
 	t.Run("CreateParagliderVirtualNetwork: Success", func(t *testing.T) {
 		// Call the function to test
-		vnet, err := handler.CreateParagliderVirtualNetwork(ctx, testLocation, validVnetName, validAddressSpace)
+		vnet, err := handler.CreateParagliderVirtualNetwork(ctx, testLocation, validVnetName, validAddressSpace, nil)
 
 		require.NoError(t, err)
 		require.NotNil(t, vnet)
@@ -83,6 +83,7 @@ func getFakeServerHandler(fakeServerState *fakeServerState) http.HandlerFunc {
 	// The handler should be written as minimally as possible to minimize maintenance overhead. Modifying requests (e.g. POST, DELETE)
 	// should generally not do anything other than return the operation response. Instead, initialize the fakeServerState as necessary.
 	// Keep in mind these unit tests should rely as little as possible on the functionality of this fake server.
+	// The exception is NSG security rules, whose writes are recorded so the conformance suite can read rules back.
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
 		body, err := io.ReadAll(r.Body)
@@ -92,6 +93,19 @@ func getFakeServerHandler(fakeServerState *fakeServerState) http.HandlerFunc {
 		}
 		urlPrefix := fmt.Sprintf(urlFormat, fakeServerState.subId, fakeServerState.rgName)
 		switch {
+		// Resources of the resource group
+		case path == fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/resources", fakeServerState.subId, fakeServerState.rgName):
+			if r.Method == "GET" {
+				resources := []*armresources.GenericResourceExpanded{}
+				if fakeServerState.vm != nil {
+					resources = append(resources, &armresources.GenericResourceExpanded{ID: fakeServerState.vm.ID, Type: to.Ptr(virtualMachineTypeName)})
+				}
+				if fakeServerState.nsg != nil {
+					resources = append(resources, &armresources.GenericResourceExpanded{ID: fakeServerState.nsg.ID, Type: to.Ptr("Microsoft.Network/networkSecurityGroups")})
+				}
+				sendResponse(w, armresources.ResourceListResult{Value: resources})
+				return
+			}
 		// NSGs
 		case strings.HasPrefix(path, urlPrefix+"/Microsoft.Network/networkSecurityGroups/"):
 			if strings.Contains(path, "/securityRules") {
@@ -105,9 +119,16 @@ func getFakeServerHandler(fakeServerState *fakeServerState) http.HandlerFunc {
 					if rule.ID == nil {
 						rule.ID = to.Ptr("rule-id") // Add ID since would be set server-side
 					}
+					if fakeServerState.nsg != nil {
+						rule.Name = to.Ptr(path[strings.LastIndex(path, "/")+1:])
+						fakeServerState.setSecurityRule(rule)
+					}
 					sendResponse(w, rule)
 					return
 				} else if r.Method == "DELETE" {
+					if fakeServerState.nsg != nil {
+						fakeServerState.deleteSecurityRule(path[strings.LastIndex(path, "/")+1:])
+					}
 					w.WriteHeader(http.StatusOK)
 					return
 				}
@@ -363,6 +384,34 @@ type fakeServerState struct {
 	cluster       *armcontainerservice.ManagedCluster
 }
 
+// Adds the security rule to the NSG or replaces the rule with the same name
+func (f *fakeServerState) setSecurityRule(rule *armnetwork.SecurityRule) {
+	if f.nsg.Properties == nil {
+		f.nsg.Properties = &armnetwork.SecurityGroupPropertiesFormat{}
+	}
+	for i, existing := range f.nsg.Properties.SecurityRules {
+		if *existing.Name == *rule.Name {
+			f.nsg.Properties.SecurityRules[i] = rule
+			return
+		}
+	}
+	f.nsg.Properties.SecurityRules = append(f.nsg.Properties.SecurityRules, rule)
+}
+
+// Removes the security rule with the given name from the NSG
+func (f *fakeServerState) deleteSecurityRule(name string) {
+	if f.nsg.Properties == nil {
+		return
+	}
+	rules := f.nsg.Properties.SecurityRules[:0]
+	for _, existing := range f.nsg.Properties.SecurityRules {
+		if *existing.Name != name {
+			rules = append(rules, existing)
+		}
+	}
+	f.nsg.Properties.SecurityRules = rules
+}
+
 // Sets up fake http server
 func SetupFakeAzureServer(t *testing.T, fakeServerState *fakeServerState) (fakeServer *httptest.Server, ctx context.Context) {
 	fakeServer = httptest.NewServer(getFakeServerHandler(fakeServerState))
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v4"
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/resourcespec"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Default Azure VM sizes and image URNs (publisher:offer:sku:version) for the cloud-agnostic VM description
+var defaultVMMappings = resourcespec.Mappings{
+	Sizes: map[string]string{
+		resourcespec.SizeSmall:  "Standard_B1s",
+		resourcespec.SizeMedium: "Standard_B2s",
+		resourcespec.SizeLarge:  "Standard_D4s_v3",
+	},
+	Images: map[string]string{
+		resourcespec.ImageUbuntu2204: "canonical:0001-com-ubuntu-minimal-jammy:minimal-22_04-lts-gen2:latest",
+		resourcespec.ImageDebian12:   "Debian:debian-12:12-gen2:latest",
+	},
+}
+
+// Translates a cloud-agnostic resource description into an Azure one, leaving native descriptions untouched
+func translateResourceDescription(resource *paragliderpb.CreateResourceRequest) (*paragliderpb.CreateResourceRequest, error) {
+	if !resourcespec.IsVM(resource.Description) {
+		return resource, nil
+	}
+	vm, err := resourcespec.ParseVM(resource.Description)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	azureVM, err := translateVM(vm, resource.Name)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	desc, err := json.Marshal(azureVM)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to marshal translated description: %v", err)
+	}
+	translated := proto.Clone(resource).(*paragliderpb.CreateResourceRequest)
+	translated.Description = desc
+	return translated, nil
+}
+
+// Builds the Azure virtual machine described by a cloud-agnostic VM description
+func translateVM(vm *resourcespec.VM, name string) (*armcompute.VirtualMachine, error) {
+	size, image, err := vm.Resolve(defaultVMMappings)
+	if err != nil {
+		return nil, err
+	}
+	urn := strings.Split(image, ":")
+	if len(urn) != 4 {
+		return nil, fmt.Errorf("image %q is not a URN of the form publisher:offer:sku:version", image)
+	}
+
+	osDisk := &armcompute.OSDisk{CreateOption: to.Ptr(armcompute.DiskCreateOptionTypesFromImage)}
+	if vm.DiskSizeGB > 0 {
+		osDisk.DiskSizeGB = to.Ptr(vm.DiskSizeGB)
+	}
+
+	return &armcompute.VirtualMachine{
+		Location: to.Ptr(vm.Region),
+		Properties: &armcompute.VirtualMachineProperties{
+			HardwareProfile: &armcompute.HardwareProfile{
+				VMSize: to.Ptr(armcompute.VirtualMachineSizeTypes(size)),
+			},
+			StorageProfile: &armcompute.StorageProfile{
+				ImageReference: &armcompute.ImageReference{
+					Publisher: to.Ptr(urn[0]),
+					Offer:     to.Ptr(urn[1]),
+					SKU:       to.Ptr(urn[2]),
+					Version:   to.Ptr(urn[3]),
+				},
+				OSDisk: osDisk,
+			},
+			OSProfile: &armcompute.OSProfile{
+				ComputerName:  to.Ptr(name),
+				AdminUsername: to.Ptr(resourcespec.DefaultUsername),
+				LinuxConfiguration: &armcompute.LinuxConfiguration{
+					DisablePasswordAuthentication: to.Ptr(true),
+					SSH: &armcompute.SSHConfiguration{
+						PublicKeys: []*armcompute.SSHPublicKey{
+							{
+								Path:    to.Ptr(fmt.Sprintf("/home/%s/.ssh/authorized_keys", resourcespec.DefaultUsername)),
+								KeyData: to.Ptr(vm.SSHKey),
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,93 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v4"
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/resourcespec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func getFakeParagliderVMRequest(t *testing.T, vm *resourcespec.VM) *paragliderpb.CreateResourceRequest {
+	desc, err := json.Marshal(vm)
+	require.NoError(t, err)
+	return &paragliderpb.CreateResourceRequest{Name: validVmName, Deployment: &paragliderpb.ParagliderDeployment{Id: deploymentId, Namespace: namespace}, Description: desc}
+}
+
+func TestTranslateResourceDescription(t *testing.T) {
+	vm := &resourcespec.VM{Kind: resourcespec.VMKind, Size: resourcespec.SizeMedium, Image: resourcespec.ImageUbuntu2204, Region: testLocation, SSHKey: "ssh-ed25519 AAAA", DiskSizeGB: 64}
+
+	t.Run("ParagliderVM", func(t *testing.T) {
+		request := getFakeParagliderVMRequest(t, vm)
+		translated, err := translateResourceDescription(request)
+		require.NoError(t, err)
+		assert.Equal(t, request.Name, translated.Name)
+
+		azureVM := &armcompute.VirtualMachine{}
+		require.NoError(t, json.Unmarshal(translated.Description, azureVM))
+		assert.Equal(t, testLocation, *azureVM.Location)
+		assert.Equal(t, armcompute.VirtualMachineSizeTypes("Standard_B2s"), *azureVM.Properties.HardwareProfile.VMSize)
+		assert.Equal(t, "canonical", *azureVM.Properties.StorageProfile.ImageReference.Publisher)
+		assert.Equal(t, "minimal-22_04-lts-gen2", *azureVM.Properties.StorageProfile.ImageReference.SKU)
+		assert.Equal(t, int32(64), *azureVM.Properties.StorageProfile.OSDisk.DiskSizeGB)
+		assert.Equal(t, resourcespec.DefaultUsername, *azureVM.Properties.OSProfile.AdminUsername)
+		assert.Equal(t, "ssh-ed25519 AAAA", *azureVM.Properties.OSProfile.LinuxConfiguration.SSH.PublicKeys[0].KeyData)
+
+		// The translated description goes through the existing handlers
+		resourceInfo, err := GetResourceInfoFromResourceDesc(context.Background(), translated)
+		require.NoError(t, err)
+		assert.Equal(t, testLocation, resourceInfo.Location)
+	})
+
+	t.Run("Overrides", func(t *testing.T) {
+		overridden := *vm
+		overridden.Mappings = &resourcespec.Mappings{Sizes: map[string]string{resourcespec.SizeMedium: "Standard_D2s_v5"}}
+		translated, err := translateResourceDescription(getFakeParagliderVMRequest(t, &overridden))
+		require.NoError(t, err)
+
+		azureVM := &armcompute.VirtualMachine{}
+		require.NoError(t, json.Unmarshal(translated.Description, azureVM))
+		assert.Equal(t, armcompute.VirtualMachineSizeTypes("Standard_D2s_v5"), *azureVM.Properties.HardwareProfile.VMSize)
+	})
+
+	t.Run("InvalidImage", func(t *testing.T) {
+		invalid := *vm
+		invalid.Mappings = &resourcespec.Mappings{Images: map[string]string{resourcespec.ImageUbuntu2204: "not-a-urn"}}
+		_, err := translateResourceDescription(getFakeParagliderVMRequest(t, &invalid))
+		require.Error(t, err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Native", func(t *testing.T) {
+		desc, err := json.Marshal(getFakeVirtualMachine(false))
+		require.NoError(t, err)
+		request := &paragliderpb.CreateResourceRequest{Name: validVmName, Description: desc}
+		translated, err := translateResourceDescription(request)
+		require.NoError(t, err)
+		assert.Same(t, request, translated)
+	})
+}
@@ -18,11 +18,17 @@ package client
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/paraglider-project/paraglider/pkg/orchestrator"
 	"github.com/paraglider-project/paraglider/pkg/orchestrator/config"
@@ -31,43 +37,150 @@ import (
 )
 
 type ParagliderControllerClient interface {
-	GetPermitList(namespace string, cloud string, resourceName string) ([]*paragliderpb.PermitListRule, error)
-	AddPermitListRules(namespace string, cloud string, resourceName string, rules []*paragliderpb.PermitListRule) error
-	DeletePermitListRules(namespace string, cloud string, resourceName string, rules []string) error
-	CreateResource(namespace string, cloud string, resourceName string, resource *paragliderpb.ResourceDescriptionString) (map[string]string, error)
-	AddPermitListRulesTag(tag string, rules []*paragliderpb.PermitListRule) error
-	DeletePermitListRulesTag(tag string, rules []string) error
-	GetTag(tag string) (*tagservicepb.TagMapping, error)
-	ResolveTag(tag string) ([]*tagservicepb.TagMapping, error)
-	SetTag(tag string, tagMapping *tagservicepb.TagMapping) error
-	DeleteTag(tag string) error
-	DeleteTagMembers(tag string, members []string) error
-	ListNamespaces() (map[string][]config.CloudDeployment, error)
+	GetPermitList(ctx context.Context, namespace string, cloud string, resourceName string) ([]*paragliderpb.PermitListRule, error)
+	AddPermitListRules(ctx context.Context, namespace string, cloud string, resourceName string, rules []*paragliderpb.PermitListRule) error
+	DeletePermitListRules(ctx context.Context, namespace string, cloud string, resourceName string, rules []string) error
+	CreateResource(ctx context.Context, namespace string, cloud string, resourceName string, resource *paragliderpb.ResourceDescriptionString) (map[string]string, error)
+	GetPermitListRulesTag(ctx context.Context, tag string) ([]*paragliderpb.PermitListRule, error)
+	AddPermitListRulesTag(ctx context.Context, tag string, rules []*paragliderpb.PermitListRule) error
+	DeletePermitListRulesTag(ctx context.Context, tag string, rules []string) error
+	GetTag(ctx context.Context, tag string) (*tagservicepb.TagMapping, error)
+	ResolveTag(ctx context.Context, tag string) ([]*tagservicepb.TagMapping, error)
+	SetTag(ctx context.Context, tag string, tagMapping *tagservicepb.TagMapping) error
+	DeleteTag(ctx context.Context, tag string) error
+	DeleteTagMembers(ctx context.Context, tag string, member string) error
+	ListTags(ctx context.Context, req *tagservicepb.ListTagsRequest) (*tagservicepb.ListTagsResponse, error)
+	ListNamespaces(ctx context.Context) (map[string][]config.CloudDeployment, error)
+	GetSubscriptions(ctx context.Context, namespace string, cloud string, resourceName string) ([]string, error)
+	ExportSnapshot(ctx context.Context) (*orchestrator.Snapshot, error)
+	ImportSnapshot(ctx context.Context, snapshot *orchestrator.Snapshot, mode string) error
 }
 
+const (
+	DefaultMaxRetries   = 3
+	DefaultRetryBackoff = 200 * time.Millisecond
+)
+
+// Shared by clients which do not set their own, so connections are reused across calls.
+// It has no timeout since creating a resource waits for the cloud, so requests are only bounded by their context.
+var defaultHTTPClient = &http.Client{}
+
 type Client struct {
 	ParagliderControllerClient
 	ControllerAddress string
+	// HTTP client used for requests, defaults to one without a timeout
+	HTTPClient *http.Client
+	// Number of times idempotent requests (GET, PUT and DELETE, except creating resources) are retried after a network error or an unavailable controller
+	MaxRetries int
+	// Delay before the first retry, doubled after every attempt
+	RetryBackoff time.Duration
+	// Bearer token sent with every request, if set
+	Token string
+}
+
+type Option func(*Client)
+
+// Use the given HTTP client for requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.HTTPClient = httpClient
+	}
+}
+
+// Set the timeout of every request, including reading the response.
+// This includes creating resources, which can take minutes on real clouds.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		httpClient := *c.getHTTPClient()
+		httpClient.Timeout = timeout
+		c.HTTPClient = &httpClient
+	}
+}
+
+// Use the given TLS configuration (eg, custom root CAs or client certificates) to connect to the controller
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(c *Client) {
+		httpClient := *c.getHTTPClient()
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		httpClient.Transport = transport
+		c.HTTPClient = &httpClient
+	}
+}
+
+// Authenticate every request with the given bearer token (eg, for a controller behind an authenticating proxy)
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.Token = token
+	}
+}
+
+// Retry idempotent requests up to maxRetries times, waiting backoff and then twice as long after each attempt
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.MaxRetries = maxRetries
+		c.RetryBackoff = backoff
+	}
+}
+
+// Create a client for the controller at the given address which retries idempotent requests by default
+func NewClient(controllerAddress string, opts ...Option) *Client {
+	c := &Client{ControllerAddress: controllerAddress, MaxRetries: DefaultMaxRetries, RetryBackoff: DefaultRetryBackoff}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Client) getHTTPClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return defaultHTTPClient
 }
 
 // Proccess the response from the controller and return the body
 func (c *Client) processResponse(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return bodyBytes, fmt.Errorf("Request failed with status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return bodyBytes, parseError(resp.StatusCode, bodyBytes)
 	}
 
 	return bodyBytes, nil
 }
 
-// Send a request to the controller and return the response body
-func (c *Client) sendRequest(url string, method string, body io.Reader) ([]byte, error) {
-	client := &http.Client{}
+// Whether a request can safely be sent again
+func isIdempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodPut || method == http.MethodDelete
+}
+
+// Whether a failed attempt may succeed if retried
+func isRetryable(err error) bool {
+	var clientErr *Error
+	if errors.As(err, &clientErr) {
+		return errors.Is(err, ErrUnavailable)
+	}
+	// Network errors, but not the caller giving up
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// Send a request to the controller and return the response body, retrying it if it is idempotent
+func (c *Client) sendRequest(ctx context.Context, url string, method string, body []byte) ([]byte, error) {
+	return c.doRequest(ctx, url, method, body, isIdempotent(method))
+}
 
+// Send a request to the controller which is never retried, for requests which are not safe to repeat whatever their method
+func (c *Client) sendRequestOnce(ctx context.Context, url string, method string, body []byte) ([]byte, error) {
+	return c.doRequest(ctx, url, method, body, false)
+}
+
+// Send a request, retrying failed attempts if retry is set
+func (c *Client) doRequest(ctx context.Context, url string, method string, body []byte, retry bool) ([]byte, error) {
 	url = c.ControllerAddress + url
 
 	// Prepend with http to make net/http happy
@@ -75,26 +188,62 @@ func (c *Client) sendRequest(url string, method string, body io.Reader) ([]byte,
 		url = "http://" + url
 	}
 
-	req, err := http.NewRequest(method, url, body)
+	attempts := 1
+	if retry && c.MaxRetries > 0 {
+		attempts += c.MaxRetries
+	}
+	backoff := c.RetryBackoff
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		var bodyBytes []byte
+		bodyBytes, err = c.send(ctx, url, method, body)
+		if err == nil || !isRetryable(err) {
+			return bodyBytes, err
+		}
+	}
+	return nil, err
+}
+
+// Make a single attempt at a request
+func (c *Client) send(ctx context.Context, url string, method string, body []byte) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
 	if err != nil {
 		return nil, err
 	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
 
-	resp, err := client.Do(req)
+	resp, err := c.getHTTPClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
 
-	bodyBytes, err := c.processResponse(resp)
-
-	return bodyBytes, err
+	return c.processResponse(resp)
 }
 
 // Get a permit list for a resource
-func (c *Client) GetPermitList(namespace string, cloud string, resourceName string) ([]*paragliderpb.PermitListRule, error) {
+func (c *Client) GetPermitList(ctx context.Context, namespace string, cloud string, resourceName string) ([]*paragliderpb.PermitListRule, error) {
 	path := fmt.Sprintf(orchestrator.GetFormatterString(orchestrator.GetPermitListRulesURL), namespace, cloud, resourceName)
 
-	respBytes, err := c.sendRequest(path, http.MethodGet, nil)
+	respBytes, err := c.sendRequest(ctx, path, http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +258,7 @@ func (c *Client) GetPermitList(namespace string, cloud string, resourceName stri
 }
 
 // Add permit list rules to a resource
-func (c *Client) AddPermitListRules(namespace string, cloud string, resourceName string, rules []*paragliderpb.PermitListRule) error {
+func (c *Client) AddPermitListRules(ctx context.Context, namespace string, cloud string, resourceName string, rules []*paragliderpb.PermitListRule) error {
 	path := fmt.Sprintf(orchestrator.GetFormatterString(orchestrator.AddPermitListRulesURL), namespace, cloud, resourceName)
 
 	reqBody, err := json.Marshal(rules)
@@ -117,7 +266,7 @@ func (c *Client) AddPermitListRules(namespace string, cloud string, resourceName
 		return err
 	}
 
-	_, err = c.sendRequest(path, http.MethodPost, bytes.NewBuffer(reqBody))
+	_, err = c.sendRequest(ctx, path, http.MethodPost, reqBody)
 	if err != nil {
 		return fmt.Errorf("failed to create resource: %w", err)
 	}
@@ -126,7 +275,7 @@ func (c *Client) AddPermitListRules(namespace string, cloud string, resourceName
 }
 
 // Delete permit list rules from a resource
-func (c *Client) DeletePermitListRules(namespace string, cloud string, resourceName string, rules []string) error {
+func (c *Client) DeletePermitListRules(ctx context.Context, namespace string, cloud string, resourceName string, rules []string) error {
 	path := fmt.Sprintf(orchestrator.GetFormatterString(orchestrator.DeletePermitListRulesURL), namespace, cloud, resourceName)
 
 	reqBody, err := json.Marshal(rules)
@@ -134,7 +283,7 @@ func (c *Client) DeletePermitListRules(namespace string, cloud string, resourceN
 		return err
 	}
 
-	_, err = c.sendRequest(path, http.MethodPost, bytes.NewBuffer(reqBody))
+	_, err = c.sendRequest(ctx, path, http.MethodPost, reqBody)
 	if err != nil {
 		return err
 	}
@@ -143,7 +292,7 @@ func (c *Client) DeletePermitListRules(namespace string, cloud string, resourceN
 }
 
 // Create a resource
-func (c *Client) CreateResource(namespace string, cloud string, resourceName string, resource *paragliderpb.ResourceDescriptionString) (map[string]string, error) {
+func (c *Client) CreateResource(ctx context.Context, namespace string, cloud string, resourceName string, resource *paragliderpb.ResourceDescriptionString) (map[string]string, error) {
 	path := fmt.Sprintf(orchestrator.GetFormatterString(orchestrator.CreateResourcePUTURL), namespace, cloud, resourceName)
 
 	reqBody, err := json.Marshal(resource)
@@ -151,7 +300,8 @@ func (c *Client) CreateResource(namespace string, cloud string, resourceName str
 		return nil, err
 	}
 
-	response, err := c.sendRequest(path, http.MethodPut, bytes.NewBuffer(reqBody))
+	// Retrying could create the resource twice in the cloud
+	response, err := c.sendRequestOnce(ctx, path, http.MethodPut, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create resource: %w", err)
 	}
@@ -165,8 +315,62 @@ func (c *Client) CreateResource(namespace string, cloud string, resourceName str
 	return resourceDict, nil
 }
 
+// List the Paraglider resources of a cloud in a namespace
+func (c *Client) ListResources(ctx context.Context, namespace string, cloud string) ([]*paragliderpb.Resource, error) {
+	path := fmt.Sprintf(orchestrator.GetFormatterString(orchestrator.ListResourcesURL), namespace, cloud)
+
+	respBytes, err := c.sendRequest(ctx, path, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &paragliderpb.ListResourcesResponse{}
+	err = json.Unmarshal(respBytes, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Resources, nil
+}
+
+// Get a Paraglider resource
+func (c *Client) GetResource(ctx context.Context, namespace string, cloud string, resourceName string) (*paragliderpb.Resource, error) {
+	path := fmt.Sprintf(orchestrator.GetFormatterString(orchestrator.GetResourceURL), namespace, cloud, resourceName)
+
+	respBytes, err := c.sendRequest(ctx, path, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := &paragliderpb.Resource{}
+	err = json.Unmarshal(respBytes, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// Get the permit list rules attached to a tag
+func (c *Client) GetPermitListRulesTag(ctx context.Context, tag string) ([]*paragliderpb.PermitListRule, error) {
+	path := fmt.Sprintf(orchestrator.GetFormatterString(orchestrator.RuleOnTagURL), tag)
+
+	respBytes, err := c.sendRequest(ctx, path, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []*paragliderpb.PermitListRule
+	err = json.Unmarshal(respBytes, &rules)
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
 // Add permit list rules to a tag
-func (c *Client) AddPermitListRulesTag(tag string, rules []*paragliderpb.PermitListRule) error {
+func (c *Client) AddPermitListRulesTag(ctx context.Context, tag string, rules []*paragliderpb.PermitListRule) error {
 	path := fmt.Sprintf(orchestrator.GetFormatterString(orchestrator.RuleOnTagURL), tag)
 
 	reqBody, err := json.Marshal(rules)
@@ -174,7 +378,7 @@ func (c *Client) AddPermitListRulesTag(tag string, rules []*paragliderpb.PermitL
 		return err
 	}
 
-	_, err = c.sendRequest(path, http.MethodPost, bytes.NewBuffer(reqBody))
+	_, err = c.sendRequest(ctx, path, http.MethodPost, reqBody)
 	if err != nil {
 		return err
 	}
@@ -183,7 +387,7 @@ func (c *Client) AddPermitListRulesTag(tag string, rules []*paragliderpb.PermitL
 }
 
 // Remove permit list rules to a tag
-func (c *Client) DeletePermitListRulesTag(tag string, rules []string) error {
+func (c *Client) DeletePermitListRulesTag(ctx context.Context, tag string, rules []string) error {
 	path := fmt.Sprintf(orchestrator.GetFormatterString(orchestrator.RuleOnTagURL), tag)
 
 	reqBody, err := json.Marshal(rules)
@@ -191,7 +395,7 @@ func (c *Client) DeletePermitListRulesTag(tag string, rules []string) error {
 		return err
 	}
 
-	_, err = c.sendRequest(path, http.MethodDelete, bytes.NewBuffer(reqBody))
+	_, err = c.sendRequest(ctx, path, http.MethodDelete, reqBody)
 	if err != nil {
 		return err
 	}
@@ -200,10 +404,10 @@ func (c *Client) DeletePermitListRulesTag(tag string, rules []string) error {
 }
 
 // Get the members of a tag
-func (c *Client) GetTag(tag string) (*tagservicepb.TagMapping, error) {
+func (c *Client) GetTag(ctx context.Context, tag string) (*tagservicepb.TagMapping, error) {
 	path := fmt.Sprintf(orchestrator.GetFormatterString(orchestrator.GetTagURL), tag)
 
-	respBytes, err := c.sendRequest(path, http.MethodGet, nil)
+	respBytes, err := c.sendRequest(ctx, path, http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -218,10 +422,10 @@ func (c *Client) GetTag(tag string) (*tagservicepb.TagMapping, error) {
 }
 
 // Resolve a tag down to all IP/URI members
-func (c *Client) ResolveTag(tag string) ([]*tagservicepb.TagMapping, error) {
+func (c *Client) ResolveTag(ctx context.Context, tag string) ([]*tagservicepb.TagMapping, error) {
 	path := fmt.Sprintf(orchestrator.GetFormatterString(orchestrator.ResolveTagURL), tag)
 
-	respBytes, err := c.sendRequest(path, http.MethodPost, nil)
+	respBytes, err := c.sendRequest(ctx, path, http.MethodPost, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -235,26 +439,43 @@ func (c *Client) ResolveTag(tag string) ([]*tagservicepb.TagMapping, error) {
 	return tags, nil
 }
 
-// ListTags lists all tags and their mappings
-func (c *Client) ListTags() ([]*tagservicepb.TagMapping, error) {
+// ListTags lists one page of tags and their mappings, filtered by prefix and labels
+func (c *Client) ListTags(ctx context.Context, req *tagservicepb.ListTagsRequest) (*tagservicepb.ListTagsResponse, error) {
+	query := url.Values{}
+	if req.Prefix != "" {
+		query.Set("prefix", req.Prefix)
+	}
+	if req.Limit > 0 {
+		query.Set("limit", strconv.Itoa(int(req.Limit)))
+	}
+	if req.PageToken != "" {
+		query.Set("page_token", req.PageToken)
+	}
+	for key, value := range req.Labels {
+		query.Add("label", key+"="+value)
+	}
+
 	path := orchestrator.GetFormatterString(orchestrator.ListTagURL)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
 
-	respBytes, err := c.sendRequest(path, http.MethodGet, nil)
+	respBytes, err := c.sendRequest(ctx, path, http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	tags := []*tagservicepb.TagMapping{}
-	err = json.Unmarshal(respBytes, &tags)
+	listResp := &tagservicepb.ListTagsResponse{}
+	err = json.Unmarshal(respBytes, listResp)
 	if err != nil {
 		return nil, err
 	}
 
-	return tags, nil
+	return listResp, nil
 }
 
 // Set a tag as a member of a group or as a mapping to a URI/IP
-func (c *Client) SetTag(tag string, tagMapping *tagservicepb.TagMapping) error {
+func (c *Client) SetTag(ctx context.Context, tag string, tagMapping *tagservicepb.TagMapping) error {
 	path := fmt.Sprintf(orchestrator.GetFormatterString(orchestrator.SetTagURL), tag)
 
 	reqBody, err := json.Marshal(tagMapping)
@@ -262,7 +483,7 @@ func (c *Client) SetTag(tag string, tagMapping *tagservicepb.TagMapping) error {
 		return err
 	}
 
-	_, err = c.sendRequest(path, http.MethodPost, bytes.NewBuffer(reqBody))
+	_, err = c.sendRequest(ctx, path, http.MethodPost, reqBody)
 	if err != nil {
 		return err
 	}
@@ -271,10 +492,10 @@ func (c *Client) SetTag(tag string, tagMapping *tagservicepb.TagMapping) error {
 }
 
 // Delete an entire tag and all its member associations under it
-func (c *Client) DeleteTag(tag string) error {
+func (c *Client) DeleteTag(ctx context.Context, tag string) error {
 	path := fmt.Sprintf(orchestrator.GetFormatterString(orchestrator.DeleteTagURL), tag)
 
-	_, err := c.sendRequest(path, http.MethodDelete, nil)
+	_, err := c.sendRequest(ctx, path, http.MethodDelete, nil)
 	if err != nil {
 		return err
 	}
@@ -283,10 +504,10 @@ func (c *Client) DeleteTag(tag string) error {
 }
 
 // Delete member from a tag
-func (c *Client) DeleteTagMembers(tag string, member string) error {
+func (c *Client) DeleteTagMembers(ctx context.Context, tag string, member string) error {
 	path := fmt.Sprintf(orchestrator.GetFormatterString(orchestrator.DeleteTagMemberURL), tag, member)
 
-	_, err := c.sendRequest(path, http.MethodDelete, nil)
+	_, err := c.sendRequest(ctx, path, http.MethodDelete, nil)
 	if err != nil {
 		return err
 	}
@@ -295,8 +516,8 @@ func (c *Client) DeleteTagMembers(tag string, member string) error {
 }
 
 // List all configured namespaces
-func (c *Client) ListNamespaces() (map[string][]config.CloudDeployment, error) {
-	result, err := c.sendRequest(orchestrator.ListNamespacesURL, http.MethodGet, nil)
+func (c *Client) ListNamespaces(ctx context.Context) (map[string][]config.CloudDeployment, error) {
+	result, err := c.sendRequest(ctx, orchestrator.ListNamespacesURL, http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -309,3 +530,54 @@ func (c *Client) ListNamespaces() (map[string][]config.CloudDeployment, error) {
 
 	return namespaces, nil
 }
+
+// Get all tags a resource is subscribed to
+func (c *Client) GetSubscriptions(ctx context.Context, namespace string, cloud string, resourceName string) ([]string, error) {
+	path := fmt.Sprintf(orchestrator.GetFormatterString(orchestrator.GetSubscriptionsURL), namespace, cloud, resourceName)
+
+	respBytes, err := c.sendRequest(ctx, path, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []string{}
+	err = json.Unmarshal(respBytes, &tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// Export all tags and rules as a snapshot
+func (c *Client) ExportSnapshot(ctx context.Context) (*orchestrator.Snapshot, error) {
+	respBytes, err := c.sendRequest(ctx, orchestrator.ExportURL, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &orchestrator.Snapshot{}
+	err = json.Unmarshal(respBytes, snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// Import a snapshot of tags and rules, either merging it with or replacing the current ones
+func (c *Client) ImportSnapshot(ctx context.Context, snapshot *orchestrator.Snapshot, mode string) error {
+	path := orchestrator.ImportURL + "?" + url.Values{"mode": []string{mode}}.Encode()
+
+	reqBody, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.sendRequest(ctx, path, http.MethodPost, reqBody)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -19,20 +19,26 @@ limitations under the License.
 package client
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	fake "github.com/paraglider-project/paraglider/pkg/fake/orchestrator/rest"
+	"github.com/paraglider-project/paraglider/pkg/orchestrator"
 	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestGetPermitList(t *testing.T) {
 	s := fake.FakeOrchestratorRESTServer{}
 	controllerAddress := s.SetupFakeOrchestratorRESTServer()
-	client := Client{ControllerAddress: controllerAddress}
+	client := NewClient(controllerAddress)
 
 	resourceName := "resourceName"
-	rules, err := client.GetPermitList(fake.Namespace, fake.CloudName, resourceName)
+	rules, err := client.GetPermitList(context.Background(), fake.Namespace, fake.CloudName, resourceName)
 
 	assert.Nil(t, err)
 	assert.Equal(t, fake.GetFakePermitListRules()[0].Name, rules[0].Name)
@@ -41,9 +47,9 @@ func TestGetPermitList(t *testing.T) {
 func TestAddPermitListRules(t *testing.T) {
 	s := fake.FakeOrchestratorRESTServer{}
 	controllerAddress := s.SetupFakeOrchestratorRESTServer()
-	client := Client{ControllerAddress: controllerAddress}
+	client := NewClient(controllerAddress)
 
-	err := client.AddPermitListRules(fake.Namespace, fake.CloudName, "resourceName", fake.GetFakePermitListRules())
+	err := client.AddPermitListRules(context.Background(), fake.Namespace, fake.CloudName, "resourceName", fake.GetFakePermitListRules())
 
 	assert.Nil(t, err)
 }
@@ -51,19 +57,30 @@ func TestAddPermitListRules(t *testing.T) {
 func TestDeletePermitListRules(t *testing.T) {
 	s := fake.FakeOrchestratorRESTServer{}
 	controllerAddress := s.SetupFakeOrchestratorRESTServer()
-	client := Client{ControllerAddress: controllerAddress}
+	client := NewClient(controllerAddress)
 
-	err := client.DeletePermitListRules(fake.Namespace, fake.CloudName, "resourceName", fake.GetFakePermitListRuleNames())
+	err := client.DeletePermitListRules(context.Background(), fake.Namespace, fake.CloudName, "resourceName", fake.GetFakePermitListRuleNames())
 
 	assert.Nil(t, err)
 }
 
+func TestTagGetPermitListRules(t *testing.T) {
+	s := fake.FakeOrchestratorRESTServer{}
+	controllerAddress := s.SetupFakeOrchestratorRESTServer()
+	client := NewClient(controllerAddress)
+
+	rules, err := client.GetPermitListRulesTag(context.Background(), "tagName")
+
+	assert.Nil(t, err)
+	assert.Equal(t, fake.GetFakePermitListRules()[0].Name, rules[0].Name)
+}
+
 func TestTagAddPermitListRules(t *testing.T) {
 	s := fake.FakeOrchestratorRESTServer{}
 	controllerAddress := s.SetupFakeOrchestratorRESTServer()
-	client := Client{ControllerAddress: controllerAddress}
+	client := NewClient(controllerAddress)
 
-	err := client.AddPermitListRulesTag("tagName", fake.GetFakePermitListRules())
+	err := client.AddPermitListRulesTag(context.Background(), "tagName", fake.GetFakePermitListRules())
 
 	assert.Nil(t, err)
 }
@@ -71,9 +88,9 @@ func TestTagAddPermitListRules(t *testing.T) {
 func TestTagDeletePermitListRules(t *testing.T) {
 	s := fake.FakeOrchestratorRESTServer{}
 	controllerAddress := s.SetupFakeOrchestratorRESTServer()
-	client := Client{ControllerAddress: controllerAddress}
+	client := NewClient(controllerAddress)
 
-	err := client.DeletePermitListRulesTag("tagName", fake.GetFakePermitListRuleNames())
+	err := client.DeletePermitListRulesTag(context.Background(), "tagName", fake.GetFakePermitListRuleNames())
 
 	assert.Nil(t, err)
 }
@@ -81,33 +98,73 @@ func TestTagDeletePermitListRules(t *testing.T) {
 func TestCreateResource(t *testing.T) {
 	s := fake.FakeOrchestratorRESTServer{}
 	controllerAddress := s.SetupFakeOrchestratorRESTServer()
-	client := Client{ControllerAddress: controllerAddress}
+	client := NewClient(controllerAddress)
 
-	resource, err := client.CreateResource(fake.Namespace, fake.CloudName, "resourceName", &paragliderpb.ResourceDescriptionString{})
+	resource, err := client.CreateResource(context.Background(), fake.Namespace, fake.CloudName, "resourceName", &paragliderpb.ResourceDescriptionString{})
 
 	assert.Nil(t, err)
 	assert.Equal(t, "resourceName", resource["name"])
 }
 
+func TestListResources(t *testing.T) {
+	s := fake.FakeOrchestratorRESTServer{}
+	controllerAddress := s.SetupFakeOrchestratorRESTServer()
+	client := NewClient(controllerAddress)
+
+	resources, err := client.ListResources(context.Background(), fake.Namespace, fake.CloudName)
+
+	assert.Nil(t, err)
+	assert.Equal(t, fake.GetFakeResources(), resources)
+}
+
+func TestGetResource(t *testing.T) {
+	s := fake.FakeOrchestratorRESTServer{}
+	controllerAddress := s.SetupFakeOrchestratorRESTServer()
+	client := NewClient(controllerAddress)
+
+	resource, err := client.GetResource(context.Background(), fake.Namespace, fake.CloudName, "resourceName")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "resourceName", resource.Name)
+}
+
 func TestGetTag(t *testing.T) {
 	s := fake.FakeOrchestratorRESTServer{}
 	controllerAddress := s.SetupFakeOrchestratorRESTServer()
-	client := Client{ControllerAddress: controllerAddress}
+	client := NewClient(controllerAddress)
 
 	tagName := "tag"
-	tag, err := client.GetTag(tagName)
+	tag, err := client.GetTag(context.Background(), tagName)
 
 	assert.Nil(t, err)
 	assert.Equal(t, tagName, tag.Name)
 }
 
+func TestListTags(t *testing.T) {
+	s := fake.FakeOrchestratorRESTServer{}
+	controllerAddress := s.SetupFakeOrchestratorRESTServer()
+	client := NewClient(controllerAddress)
+
+	listResp, err := client.ListTags(context.Background(), &tagservicepb.ListTagsRequest{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, fake.ListFakeTagMapping()[0].Name, listResp.Tags[0].Name)
+	assert.Empty(t, listResp.NextPageToken)
+
+	listResp, err = client.ListTags(context.Background(), &tagservicepb.ListTagsRequest{Prefix: "tag", Limit: 1, Labels: map[string]string{"env": "prod"}})
+
+	assert.Nil(t, err)
+	assert.Len(t, listResp.Tags, 1)
+	assert.NotEmpty(t, listResp.NextPageToken)
+}
+
 func TestResolveTag(t *testing.T) {
 	s := fake.FakeOrchestratorRESTServer{}
 	controllerAddress := s.SetupFakeOrchestratorRESTServer()
-	client := Client{ControllerAddress: controllerAddress}
+	client := NewClient(controllerAddress)
 
 	tagName := "tag"
-	tags, err := client.ResolveTag(tagName)
+	tags, err := client.ResolveTag(context.Background(), tagName)
 
 	assert.Nil(t, err)
 	assert.Equal(t, tagName, tags[0].Name)
@@ -117,11 +174,11 @@ func TestResolveTag(t *testing.T) {
 func TestSetTag(t *testing.T) {
 	s := fake.FakeOrchestratorRESTServer{}
 	controllerAddress := s.SetupFakeOrchestratorRESTServer()
-	client := Client{ControllerAddress: controllerAddress}
+	client := NewClient(controllerAddress)
 
 	tagName := "tag"
 	tagMapping := fake.GetFakeTagMapping(tagName)
-	err := client.SetTag(tagName, tagMapping)
+	err := client.SetTag(context.Background(), tagName, tagMapping)
 
 	assert.Nil(t, err)
 }
@@ -129,10 +186,10 @@ func TestSetTag(t *testing.T) {
 func TestDeleteTag(t *testing.T) {
 	s := fake.FakeOrchestratorRESTServer{}
 	controllerAddress := s.SetupFakeOrchestratorRESTServer()
-	client := Client{ControllerAddress: controllerAddress}
+	client := NewClient(controllerAddress)
 
 	tagName := "tag"
-	err := client.DeleteTag(tagName)
+	err := client.DeleteTag(context.Background(), tagName)
 
 	assert.Nil(t, err)
 }
@@ -140,10 +197,10 @@ func TestDeleteTag(t *testing.T) {
 func TestDeleteTagMembers(t *testing.T) {
 	s := fake.FakeOrchestratorRESTServer{}
 	controllerAddress := s.SetupFakeOrchestratorRESTServer()
-	client := Client{ControllerAddress: controllerAddress}
+	client := NewClient(controllerAddress)
 
 	tagName := "tag"
-	err := client.DeleteTagMembers(tagName, "member1")
+	err := client.DeleteTagMembers(context.Background(), tagName, "member1")
 
 	assert.Nil(t, err)
 }
@@ -151,10 +208,139 @@ func TestDeleteTagMembers(t *testing.T) {
 func TestSetNamespace(t *testing.T) {
 	s := fake.FakeOrchestratorRESTServer{}
 	controllerAddress := s.SetupFakeOrchestratorRESTServer()
-	client := Client{ControllerAddress: controllerAddress}
+	client := NewClient(controllerAddress)
 
-	namespaces, err := client.ListNamespaces()
+	namespaces, err := client.ListNamespaces(context.Background())
 
 	assert.Nil(t, err)
 	assert.Equal(t, fake.GetFakeNamespaces(), namespaces)
 }
+
+func TestGetSubscriptions(t *testing.T) {
+	s := fake.FakeOrchestratorRESTServer{}
+	controllerAddress := s.SetupFakeOrchestratorRESTServer()
+	client := NewClient(controllerAddress)
+
+	tags, err := client.GetSubscriptions(context.Background(), fake.Namespace, fake.CloudName, "resourceName")
+
+	assert.Nil(t, err)
+	assert.Equal(t, fake.GetFakeSubscriptions(), tags)
+}
+
+func TestExportSnapshot(t *testing.T) {
+	s := fake.FakeOrchestratorRESTServer{}
+	controllerAddress := s.SetupFakeOrchestratorRESTServer()
+	client := NewClient(controllerAddress)
+
+	snapshot, err := client.ExportSnapshot(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(fake.GetFakeSnapshot().Tags), len(snapshot.Tags))
+	assert.Equal(t, fake.GetFakeSnapshot().TagRules[0].Tag, snapshot.TagRules[0].Tag)
+}
+
+func TestImportSnapshot(t *testing.T) {
+	s := fake.FakeOrchestratorRESTServer{}
+	controllerAddress := s.SetupFakeOrchestratorRESTServer()
+	client := NewClient(controllerAddress)
+
+	err := client.ImportSnapshot(context.Background(), fake.GetFakeSnapshot(), orchestrator.ImportModeReplace)
+	assert.Nil(t, err)
+
+	err = client.ImportSnapshot(context.Background(), fake.GetFakeSnapshot(), "wrong")
+	assert.NotNil(t, err)
+}
+
+func TestErrors(t *testing.T) {
+	s := fake.FakeOrchestratorRESTServer{}
+	controllerAddress := s.SetupFakeOrchestratorRESTServer()
+	client := NewClient(controllerAddress)
+
+	// Plain text bodies are classified by status code
+	err := client.ImportSnapshot(context.Background(), fake.GetFakeSnapshot(), "wrong")
+	assert.True(t, IsInvalid(err))
+	assert.False(t, IsNotFound(err))
+
+	// Structured bodies take precedence
+	err = parseError(http.StatusBadRequest, []byte(`{"error": "tag missing", "code": "NotFound"}`))
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, "Request failed with status code 400: tag missing", err.Error())
+
+	// Details and the failing component are kept
+	err = parseError(http.StatusServiceUnavailable, []byte(`{"error": "backend unreachable", "code": "Unavailable", "details": ["gcp: CLOUD_API_ERROR status_code=503"], "component": "plugin/gcp"}`))
+	clientErr := &Error{}
+	assert.ErrorAs(t, err, &clientErr)
+	assert.Equal(t, "plugin/gcp", clientErr.Component)
+	assert.Equal(t, []string{"gcp: CLOUD_API_ERROR status_code=503"}, clientErr.Details)
+	assert.ErrorIs(t, parseError(http.StatusGatewayTimeout, []byte(`{"error": "timed out", "code": "Timeout"}`)), ErrUnavailable)
+
+	assert.True(t, IsConflict(parseError(http.StatusConflict, nil)))
+	assert.True(t, IsUnauthorized(parseError(http.StatusForbidden, nil)))
+}
+
+func TestRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"name": "tag"}`))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, WithRetries(3, time.Millisecond))
+
+	// Idempotent requests are retried until they succeed
+	tag, err := client.GetTag(context.Background(), "tag")
+	assert.Nil(t, err)
+	assert.Equal(t, "tag", tag.Name)
+	assert.Equal(t, 3, attempts)
+
+	// Other requests are only attempted once
+	attempts = 0
+	_, err = client.ResolveTag(context.Background(), "tag")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 1, attempts)
+
+	// and so is creating a resource, even though it is a PUT
+	attempts = 0
+	_, err = client.CreateResource(context.Background(), "default", "cloud", "vm", &paragliderpb.ResourceDescriptionString{})
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestNoDefaultTimeout(t *testing.T) {
+	assert.Zero(t, NewClient("localhost").getHTTPClient().Timeout)
+}
+
+func TestContextCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, WithTimeout(time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := client.GetTag(ctx, "tag")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"name": "tag"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).GetTag(context.Background(), "tag")
+	assert.NotNil(t, err)
+
+	tag, err := NewClient(server.URL, WithToken("secret")).GetTag(context.Background(), "tag")
+	assert.Nil(t, err)
+	assert.Equal(t, "tag", tag.Name)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Kinds of failures reported by the controller, to be checked with errors.Is
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrInvalid      = errors.New("invalid request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrUnavailable  = errors.New("unavailable")
+	ErrInternal     = errors.New("internal error")
+)
+
+// Codes the controller may set in error bodies, which take precedence over the status code
+var errorCodes = map[string]error{
+	"NotFound":      ErrNotFound,
+	"Conflict":      ErrConflict,
+	"Invalid":       ErrInvalid,
+	"Unauthorized":  ErrUnauthorized,
+	"Unavailable":   ErrUnavailable,
+	"Timeout":       ErrUnavailable,
+	"Unimplemented": ErrInternal,
+	"Internal":      ErrInternal,
+}
+
+// Body of the controller's error responses
+type errorResponse struct {
+	Error     string   `json:"error"`
+	Code      string   `json:"code,omitempty"`
+	Details   []string `json:"details,omitempty"`
+	Component string   `json:"component,omitempty"`
+}
+
+// A request the controller answered with an error
+// Component is the part of the controller the request failed in (eg, "tag_service" or "plugin/gcp") and Details the cloud errors behind it, when known
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    []string
+	Component  string
+	kind       error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("Request failed with status code %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.kind
+}
+
+func getErrorKind(statusCode int) error {
+	switch statusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return ErrConflict
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return ErrInvalid
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return ErrUnavailable
+	default:
+		return ErrInternal
+	}
+}
+
+// Build the error for a response, using the structured body when there is one
+func parseError(statusCode int, body []byte) error {
+	err := &Error{StatusCode: statusCode, Message: string(body), kind: getErrorKind(statusCode)}
+
+	resp := errorResponse{}
+	if json.Unmarshal(body, &resp) == nil && resp.Error != "" {
+		err.Message = resp.Error
+		err.Code = resp.Code
+		err.Details = resp.Details
+		err.Component = resp.Component
+		if kind, ok := errorCodes[resp.Code]; ok {
+			err.kind = kind
+		}
+	}
+	return err
+}
+
+// Whether the controller could not find what a request referred to
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// Whether a request conflicted with the controller's current state
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// Whether the controller rejected a request as malformed
+func IsInvalid(err error) bool {
+	return errors.Is(err, ErrInvalid)
+}
+
+// Whether the caller is not allowed to make a request
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
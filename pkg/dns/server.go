@@ -0,0 +1,430 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+	"time"
+
+	tagservicepb "github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+	"golang.org/x/net/dns/dnsmessage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	DefaultZone = "paraglider"
+	DefaultTtl  = 5 // Seconds, kept short so clients pick up changes to tags quickly
+)
+
+const (
+	maxUdpSize     = 512  // Size of UDP messages without EDNS
+	ednsUdpSize    = 1232 // Size of UDP messages advertised with EDNS (avoids fragmentation)
+	queryTimeout   = 5 * time.Second
+	tcpIdleTimeout = 10 * time.Second
+)
+
+// Server answers A and AAAA queries for the tags under its zone with the IPs of their resources
+// A tag name is written in reverse under the zone (eg, default.azure.vm1 is vm1.azure.default.paraglider), and parent tags resolve to all the IPs in them
+// Tags are resolved with the tag service on every query, so answers always reflect the current tags
+type Server struct {
+	zone      string // Lowercase and fully qualified
+	ttl       uint32
+	upstreams []string // Addresses (host:port) queries outside of the zone are forwarded to
+	client    tagservicepb.TagServiceClient
+}
+
+// Create a DNS server resolving tags with the tag service, where an empty zone and a zero TTL use the defaults
+func NewServer(tagServiceAddr string, zone string, ttl uint32, upstreams []string) (*Server, error) {
+	if zone == "" {
+		zone = DefaultZone
+	}
+	zone = strings.ToLower(strings.Trim(zone, ".")) + "."
+	if _, err := dnsmessage.NewName(zone); err != nil || strings.Contains(zone, "..") {
+		return nil, fmt.Errorf("invalid zone %s", zone)
+	}
+	if ttl == 0 {
+		ttl = DefaultTtl
+	}
+	s := &Server{zone: zone, ttl: ttl}
+	for _, upstream := range upstreams {
+		if _, _, err := net.SplitHostPort(upstream); err != nil {
+			upstream = net.JoinHostPort(upstream, "53")
+		}
+		if _, _, err := net.SplitHostPort(upstream); err != nil {
+			return nil, fmt.Errorf("invalid upstream %s: %w", upstream, err)
+		}
+		s.upstreams = append(s.upstreams, upstream)
+	}
+
+	conn, err := grpc.NewClient(tagServiceAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	s.client = tagservicepb.NewTagServiceClient(conn)
+	return s, nil
+}
+
+// Get the name of a tag from the labels of a name under the zone (eg, vm1.azure.default is default.azure.vm1)
+func getTagName(name string) string {
+	labels := strings.Split(name, ".")
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return strings.Join(labels, ".")
+}
+
+// Parse the IP of a resolved tag, which may also be a single address prefix
+func parseIp(ip string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		return addr.Unmap(), true
+	}
+	if prefix, err := netip.ParsePrefix(ip); err == nil && prefix.IsSingleIP() {
+		return prefix.Addr().Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+// Resolve a tag to the sorted IPs of its resources, also returning whether the tag exists
+func (s *Server) resolveTag(ctx context.Context, tagName string) ([]netip.Addr, bool, error) {
+	// IPs are tags of themselves in the tag service, but they have no names
+	if _, ok := parseIp(tagName); ok {
+		return nil, false, nil
+	}
+	resp, err := s.client.ResolveTag(ctx, &tagservicepb.ResolveTagRequest{TagName: tagName})
+	if err != nil {
+		return nil, false, err
+	}
+	if len(resp.Tags) == 0 {
+		return nil, false, nil
+	}
+	seen := make(map[netip.Addr]bool)
+	addrs := []netip.Addr{}
+	for _, tag := range resp.Tags {
+		addr, ok := parseIp(tag.GetIp())
+		if !ok || seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Less(addrs[j]) })
+	return addrs, true, nil
+}
+
+// Get the SOA record of the zone, which is also returned with negative answers so they are cached for the TTL
+func (s *Server) getSoaResource() dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(s.zone), Type: dnsmessage.TypeSOA, Class: dnsmessage.ClassINET, TTL: s.ttl},
+		Body: &dnsmessage.SOAResource{
+			NS:      dnsmessage.MustNewName("ns." + s.zone),
+			MBox:    dnsmessage.MustNewName("hostmaster." + s.zone),
+			Serial:  1,
+			Refresh: 3600,
+			Retry:   600,
+			Expire:  86400,
+			MinTTL:  s.ttl,
+		},
+	}
+}
+
+// Answer a question for a name in the zone
+func (s *Server) answer(ctx context.Context, question dnsmessage.Question, resp *dnsmessage.Message) {
+	resp.Authoritative = true
+	name := question.Name.String()
+	if strings.EqualFold(name, s.zone) {
+		if question.Type == dnsmessage.TypeSOA || question.Type == dnsmessage.TypeALL {
+			resp.Answers = append(resp.Answers, s.getSoaResource())
+		} else {
+			resp.Authorities = append(resp.Authorities, s.getSoaResource())
+		}
+		return
+	}
+
+	tagName := getTagName(name[:len(name)-len(s.zone)-1])
+	addrs, found, err := s.resolveTag(ctx, tagName)
+	if err != nil {
+		utils.Log.Printf("Failed to resolve tag %s: %v\n", tagName, err)
+		resp.RCode = dnsmessage.RCodeServerFailure
+		return
+	}
+	if !found {
+		resp.RCode = dnsmessage.RCodeNameError
+		resp.Authorities = append(resp.Authorities, s.getSoaResource())
+		return
+	}
+	header := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: s.ttl}
+	for _, addr := range addrs {
+		if addr.Is4() && (question.Type == dnsmessage.TypeA || question.Type == dnsmessage.TypeALL) {
+			header.Type = dnsmessage.TypeA
+			resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.AResource{A: addr.As4()}})
+		} else if addr.Is6() && (question.Type == dnsmessage.TypeAAAA || question.Type == dnsmessage.TypeALL) {
+			header.Type = dnsmessage.TypeAAAA
+			resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.AAAAResource{AAAA: addr.As16()}})
+		}
+	}
+	// The tag exists but has no addresses of the type
+	if len(resp.Answers) == 0 {
+		resp.Authorities = append(resp.Authorities, s.getSoaResource())
+	}
+}
+
+// Handle a query received over the network (udp or tcp), returning nil if there should be no reply
+func (s *Server) handleQuery(ctx context.Context, network string, query []byte) []byte {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil {
+		// Reply with an error if at least the header can be parsed
+		var parser dnsmessage.Parser
+		header, err := parser.Start(query)
+		if err != nil || header.Response {
+			return nil
+		}
+		return s.pack(network, &dnsmessage.Message{Header: dnsmessage.Header{ID: header.ID, Response: true, OpCode: header.OpCode, RCode: dnsmessage.RCodeFormatError}}, maxUdpSize)
+	}
+	if msg.Response {
+		return nil
+	}
+
+	resp := &dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 msg.ID,
+			Response:           true,
+			OpCode:             msg.OpCode,
+			RecursionDesired:   msg.RecursionDesired,
+			RecursionAvailable: len(s.upstreams) > 0,
+		},
+		Questions: msg.Questions,
+	}
+	udpSize := maxUdpSize
+	for _, additional := range msg.Additionals {
+		if additional.Header.Type == dnsmessage.TypeOPT {
+			// The class of an OPT record is the UDP size the client accepts
+			udpSize = max(int(additional.Header.Class), maxUdpSize)
+			opt := dnsmessage.Resource{Body: &dnsmessage.OPTResource{}}
+			if err := opt.Header.SetEDNS0(ednsUdpSize, dnsmessage.RCodeSuccess, false); err != nil {
+				return nil
+			}
+			resp.Additionals = append(resp.Additionals, opt)
+			break
+		}
+	}
+
+	if msg.OpCode != 0 {
+		resp.RCode = dnsmessage.RCodeNotImplemented
+		return s.pack(network, resp, udpSize)
+	}
+	if len(msg.Questions) != 1 {
+		resp.RCode = dnsmessage.RCodeFormatError
+		return s.pack(network, resp, udpSize)
+	}
+
+	question := msg.Questions[0]
+	name := strings.ToLower(question.Name.String())
+	if name == s.zone || strings.HasSuffix(name, "."+s.zone) {
+		if question.Class != dnsmessage.ClassINET && question.Class != dnsmessage.ClassANY {
+			resp.RCode = dnsmessage.RCodeRefused
+		} else {
+			s.answer(ctx, question, resp)
+		}
+		return s.pack(network, resp, udpSize)
+	}
+
+	// Names outside of the zone are only answered by the upstream servers
+	if len(s.upstreams) == 0 {
+		resp.RCode = dnsmessage.RCodeRefused
+		return s.pack(network, resp, udpSize)
+	}
+	reply, err := s.forward(ctx, network, query)
+	if err != nil {
+		utils.Log.Printf("Failed to forward query for %s: %v\n", question.Name, err)
+		resp.RCode = dnsmessage.RCodeServerFailure
+		return s.pack(network, resp, udpSize)
+	}
+	return reply
+}
+
+// Pack a response, truncating it if it does not fit in a UDP message so the client retries over TCP
+func (s *Server) pack(network string, resp *dnsmessage.Message, udpSize int) []byte {
+	data, err := resp.Pack()
+	if err != nil {
+		utils.Log.Printf("Failed to pack DNS response: %v\n", err)
+		return nil
+	}
+	if network == "udp" && len(data) > udpSize {
+		resp.Truncated = true
+		resp.Answers = nil
+		resp.Authorities = nil
+		if data, err = resp.Pack(); err != nil {
+			utils.Log.Printf("Failed to pack DNS response: %v\n", err)
+			return nil
+		}
+	}
+	return data
+}
+
+// Forward a query to the upstream servers in order until one replies
+func (s *Server) forward(ctx context.Context, network string, query []byte) ([]byte, error) {
+	var err error
+	for _, upstream := range s.upstreams {
+		var reply []byte
+		reply, err = exchange(ctx, network, upstream, query)
+		if err == nil {
+			return reply, nil
+		}
+	}
+	return nil, err
+}
+
+// Send a query to a server and wait for its reply
+func exchange(ctx context.Context, network string, address string, query []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if network == "tcp" {
+		if err := writeTcpMessage(conn, query); err != nil {
+			return nil, err
+		}
+		return readTcpMessage(conn)
+	}
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 0xffff)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		// Ignore stray replies to other queries
+		if n >= 2 && buf[0] == query[0] && buf[1] == query[1] {
+			return buf[:n], nil
+		}
+	}
+}
+
+// Messages over TCP are prefixed with their length
+func readTcpMessage(r io.Reader) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func writeTcpMessage(w io.Writer, msg []byte) error {
+	if len(msg) > 0xffff {
+		return fmt.Errorf("message of %d bytes is too large", len(msg))
+	}
+	_, err := w.Write(binary.BigEndian.AppendUint16(make([]byte, 0, len(msg)+2), uint16(len(msg))))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(msg)
+	return err
+}
+
+func (s *Server) serveUdp(conn net.PacketConn) error {
+	buf := make([]byte, 0xffff)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		query := make([]byte, n)
+		copy(query, buf[:n])
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+			defer cancel()
+			if reply := s.handleQuery(ctx, "udp", query); reply != nil {
+				conn.WriteTo(reply, addr)
+			}
+		}()
+	}
+}
+
+func (s *Server) serveTcp(lis net.Listener) error {
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleTcpConn(conn)
+	}
+}
+
+// Answer the queries of a TCP connection until the client closes it or goes idle
+func (s *Server) handleTcpConn(conn net.Conn) {
+	defer conn.Close()
+	for {
+		conn.SetDeadline(time.Now().Add(tcpIdleTimeout))
+		query, err := readTcpMessage(conn)
+		if err != nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+		reply := s.handleQuery(ctx, "tcp", query)
+		cancel()
+		if reply == nil || writeTcpMessage(conn, reply) != nil {
+			return
+		}
+	}
+}
+
+// Serve DNS over UDP and TCP on the same address, returning the address (eg, with the port chosen for port 0)
+func Setup(address string, server *Server) (string, error) {
+	udpConn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return "", fmt.Errorf("failed to listen: %w", err)
+	}
+	lis, err := net.Listen("tcp", udpConn.LocalAddr().String())
+	if err != nil {
+		udpConn.Close()
+		return "", fmt.Errorf("failed to listen: %w", err)
+	}
+	fmt.Println("Serving DNS for zone", server.zone, "at", udpConn.LocalAddr().String())
+	go func() {
+		if err := server.serveUdp(udpConn); err != nil {
+			fmt.Println(err.Error())
+		}
+	}()
+	go func() {
+		if err := server.serveTcp(lis); err != nil {
+			fmt.Println(err.Error())
+		}
+	}()
+	return udpConn.LocalAddr().String(), nil
+}
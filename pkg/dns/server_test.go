@@ -0,0 +1,286 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	tagservicepb "github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+	"google.golang.org/grpc"
+)
+
+// Fake tag service which resolves leaf tags to their IPs and parent tags to the IPs of their children
+type fakeTagServiceServer struct {
+	tagservicepb.UnimplementedTagServiceServer
+	mu       sync.Mutex
+	ips      map[string]string
+	children map[string][]string
+}
+
+func (f *fakeTagServiceServer) setIp(tag string, ip string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ips[tag] = ip
+}
+
+func (f *fakeTagServiceServer) ResolveTag(ctx context.Context, req *tagservicepb.ResolveTagRequest) (*tagservicepb.ResolveTagResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &tagservicepb.ResolveTagResponse{}
+	if ip, ok := f.ips[req.TagName]; ok {
+		resp.Tags = append(resp.Tags, &tagservicepb.TagMapping{Name: req.TagName, Ip: &ip})
+	}
+	for _, child := range f.children[req.TagName] {
+		ip := f.ips[child]
+		resp.Tags = append(resp.Tags, &tagservicepb.TagMapping{Name: child, Ip: &ip})
+	}
+	return resp, nil
+}
+
+// Sets up a fake tag service and a DNS server using it, returning the address of the DNS server
+func setupServer(t *testing.T, ttl uint32, upstreams []string) (*fakeTagServiceServer, string) {
+	fakeTagService := &fakeTagServiceServer{
+		ips: map[string]string{
+			"default.azure.vm1": "10.0.0.4",
+			"default.gcp.vm2":   "10.1.0.2",
+			"default.aws.vm3":   "fd00::3",
+			"default.ibm.vm4":   "",
+		},
+		children: map[string][]string{
+			"web": {"default.gcp.vm2", "default.azure.vm1", "default.ibm.vm4", "default.azure.vm1"},
+		},
+	}
+	for i := 0; i < 64; i++ {
+		tag := fmt.Sprintf("default.gcp.worker%d", i)
+		fakeTagService.ips[tag] = fmt.Sprintf("10.2.0.%d", i+1)
+		fakeTagService.children["workers"] = append(fakeTagService.children["workers"], tag)
+	}
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	tagservicepb.RegisterTagServiceServer(grpcServer, fakeTagService)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	server, err := NewServer(lis.Addr().String(), "", ttl, upstreams)
+	require.NoError(t, err)
+	addr, err := Setup("127.0.0.1:0", server)
+	require.NoError(t, err)
+	return fakeTagService, addr
+}
+
+func newQuery(name string, qtype dnsmessage.Type) []byte {
+	msg := &dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: 42, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: dnsmessage.MustNewName(name), Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	query, err := msg.Pack()
+	if err != nil {
+		panic(err)
+	}
+	return query
+}
+
+// Sends a query to the server over UDP or TCP and parses the reply
+func query(t *testing.T, network string, addr string, name string, qtype dnsmessage.Type) *dnsmessage.Message {
+	reply, err := exchange(context.Background(), network, addr, newQuery(name, qtype))
+	require.NoError(t, err)
+	msg := &dnsmessage.Message{}
+	require.NoError(t, msg.Unpack(reply))
+	require.Equal(t, uint16(42), msg.ID)
+	require.True(t, msg.Response)
+	return msg
+}
+
+// Gets the addresses of the A and AAAA answers
+func getAddrs(msg *dnsmessage.Message) []string {
+	addrs := []string{}
+	for _, answer := range msg.Answers {
+		switch body := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			addrs = append(addrs, netip.AddrFrom4(body.A).String())
+		case *dnsmessage.AAAAResource:
+			addrs = append(addrs, netip.AddrFrom16(body.AAAA).String())
+		}
+	}
+	return addrs
+}
+
+func TestGetTagName(t *testing.T) {
+	assert.Equal(t, "default.azure.vm1", getTagName("vm1.azure.default"))
+	assert.Equal(t, "web", getTagName("web"))
+}
+
+func TestNewServer(t *testing.T) {
+	server, err := NewServer("localhost:1", "Example.Internal.", 0, []string{"8.8.8.8", "[fd00::53]:5353"})
+	require.NoError(t, err)
+	assert.Equal(t, "example.internal.", server.zone)
+	assert.Equal(t, uint32(DefaultTtl), server.ttl)
+	assert.Equal(t, []string{"8.8.8.8:53", "[fd00::53]:5353"}, server.upstreams)
+
+	_, err = NewServer("localhost:1", "bad..zone", 0, nil)
+	assert.Error(t, err)
+}
+
+func TestResolveTags(t *testing.T) {
+	_, addr := setupServer(t, 30, nil)
+
+	// Leaf tags are the reversed tag names under the zone
+	msg := query(t, "udp", addr, "vm1.azure.default.paraglider.", dnsmessage.TypeA)
+	assert.Equal(t, dnsmessage.RCodeSuccess, msg.RCode)
+	assert.True(t, msg.Authoritative)
+	assert.Equal(t, []string{"10.0.0.4"}, getAddrs(msg))
+	assert.Equal(t, uint32(30), msg.Answers[0].Header.TTL)
+
+	// The zone is case-insensitive
+	msg = query(t, "udp", addr, "vm1.azure.default.PARAGLIDER.", dnsmessage.TypeA)
+	assert.Equal(t, []string{"10.0.0.4"}, getAddrs(msg))
+
+	msg = query(t, "udp", addr, "vm3.aws.default.paraglider.", dnsmessage.TypeAAAA)
+	assert.Equal(t, []string{"fd00::3"}, getAddrs(msg))
+
+	// Parent tags have a record for each distinct IP of their children
+	msg = query(t, "tcp", addr, "web.paraglider.", dnsmessage.TypeA)
+	assert.Equal(t, []string{"10.0.0.4", "10.1.0.2"}, getAddrs(msg))
+
+	// Tags without addresses of the type have no answers
+	msg = query(t, "udp", addr, "vm1.azure.default.paraglider.", dnsmessage.TypeAAAA)
+	assert.Equal(t, dnsmessage.RCodeSuccess, msg.RCode)
+	assert.Empty(t, msg.Answers)
+	require.Len(t, msg.Authorities, 1)
+	assert.Equal(t, dnsmessage.TypeSOA, msg.Authorities[0].Header.Type)
+
+	// Missing tags don't exist
+	msg = query(t, "udp", addr, "missing.azure.default.paraglider.", dnsmessage.TypeA)
+	assert.Equal(t, dnsmessage.RCodeNameError, msg.RCode)
+	require.Len(t, msg.Authorities, 1)
+	assert.Equal(t, dnsmessage.TypeSOA, msg.Authorities[0].Header.Type)
+	msg = query(t, "udp", addr, "4.0.0.10.paraglider.", dnsmessage.TypeA)
+	assert.Equal(t, dnsmessage.RCodeNameError, msg.RCode)
+
+	msg = query(t, "udp", addr, "paraglider.", dnsmessage.TypeSOA)
+	require.Len(t, msg.Answers, 1)
+	assert.Equal(t, dnsmessage.TypeSOA, msg.Answers[0].Header.Type)
+}
+
+func TestTagChanges(t *testing.T) {
+	fakeTagService, addr := setupServer(t, 0, nil)
+	msg := query(t, "udp", addr, "vm2.gcp.default.paraglider.", dnsmessage.TypeA)
+	assert.Equal(t, []string{"10.1.0.2"}, getAddrs(msg))
+	assert.Equal(t, uint32(DefaultTtl), msg.Answers[0].Header.TTL)
+
+	// Changes to tags are answered right away
+	fakeTagService.setIp("default.gcp.vm2", "10.1.0.9")
+	msg = query(t, "udp", addr, "vm2.gcp.default.paraglider.", dnsmessage.TypeA)
+	assert.Equal(t, []string{"10.1.0.9"}, getAddrs(msg))
+	msg = query(t, "udp", addr, "web.paraglider.", dnsmessage.TypeA)
+	assert.Equal(t, []string{"10.0.0.4", "10.1.0.9"}, getAddrs(msg))
+}
+
+func TestTruncation(t *testing.T) {
+	_, addr := setupServer(t, 0, nil)
+
+	// Large answers are truncated over UDP so the client retries over TCP
+	msg := query(t, "udp", addr, "workers.paraglider.", dnsmessage.TypeA)
+	assert.True(t, msg.Truncated)
+	assert.Empty(t, msg.Answers)
+
+	msg = query(t, "tcp", addr, "workers.paraglider.", dnsmessage.TypeA)
+	assert.False(t, msg.Truncated)
+	assert.Len(t, msg.Answers, 64)
+
+	// Clients with EDNS accept larger UDP messages
+	ednsQuery := &dnsmessage.Message{}
+	require.NoError(t, ednsQuery.Unpack(newQuery("workers.paraglider.", dnsmessage.TypeA)))
+	opt := dnsmessage.Resource{Body: &dnsmessage.OPTResource{}}
+	require.NoError(t, opt.Header.SetEDNS0(4096, dnsmessage.RCodeSuccess, false))
+	ednsQuery.Additionals = append(ednsQuery.Additionals, opt)
+	data, err := ednsQuery.Pack()
+	require.NoError(t, err)
+	reply, err := exchange(context.Background(), "udp", addr, data)
+	require.NoError(t, err)
+	msg = &dnsmessage.Message{}
+	require.NoError(t, msg.Unpack(reply))
+	assert.False(t, msg.Truncated)
+	assert.Len(t, msg.Answers, 64)
+	require.Len(t, msg.Additionals, 1)
+	assert.Equal(t, dnsmessage.TypeOPT, msg.Additionals[0].Header.Type)
+}
+
+func TestForwarding(t *testing.T) {
+	// Without upstreams, names outside of the zone are refused
+	_, addr := setupServer(t, 0, nil)
+	msg := query(t, "udp", addr, "example.com.", dnsmessage.TypeA)
+	assert.Equal(t, dnsmessage.RCodeRefused, msg.RCode)
+
+	// Fake upstream which answers every query with one address
+	upstream, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { upstream.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := upstream.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			query := &dnsmessage.Message{}
+			if query.Unpack(buf[:n]) != nil {
+				continue
+			}
+			reply := &dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true},
+				Questions: query.Questions,
+				Answers: []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: query.Questions[0].Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+					Body:   &dnsmessage.AResource{A: [4]byte{93, 184, 215, 14}},
+				}},
+			}
+			data, _ := reply.Pack()
+			upstream.WriteTo(data, from)
+		}
+	}()
+
+	_, addr = setupServer(t, 0, []string{upstream.LocalAddr().String()})
+	msg = query(t, "udp", addr, "example.com.", dnsmessage.TypeA)
+	assert.Equal(t, dnsmessage.RCodeSuccess, msg.RCode)
+	assert.False(t, msg.Authoritative)
+	assert.Equal(t, []string{"93.184.215.14"}, getAddrs(msg))
+
+	// Tags are still answered by the server
+	msg = query(t, "udp", addr, "vm1.azure.default.paraglider.", dnsmessage.TypeA)
+	assert.True(t, msg.RecursionAvailable)
+	assert.Equal(t, []string{"10.0.0.4"}, getAddrs(msg))
+
+	// Unreachable upstreams fail the query
+	_, addr = setupServer(t, 0, []string{"127.0.0.1:1"})
+	start := time.Now()
+	msg = query(t, "tcp", addr, "example.com.", dnsmessage.TypeA)
+	assert.Equal(t, dnsmessage.RCodeServerFailure, msg.RCode)
+	assert.Less(t, time.Since(start), queryTimeout)
+}
@@ -31,6 +31,10 @@ import (
 
 const AddressSpaceAddress = "10.0.0.0/16"
 const Asn = 64512
+const ResourceName = "resource_name"
+const ResourceIp = "10.0.0.5"
+const ResourceSecondaryIp = "10.0.1.5"
+const ResourceState = "RUNNING"
 
 var BgpPeeringIpAddresses = []string{"169.254.21.1", "169.254.22.1"}
 var ExampleRule = &paragliderpb.PermitListRule{Name: "example-rule", Tags: []string{fake.ValidTagName, "1.2.3.4"}, SrcPort: 1, DstPort: 1, Protocol: 1, Direction: paragliderpb.Direction_INBOUND}
@@ -54,7 +58,7 @@ func (s *fakeCloudPluginServer) DeletePermitListRules(c context.Context, req *pa
 }
 
 func (s *fakeCloudPluginServer) CreateResource(c context.Context, req *paragliderpb.CreateResourceRequest) (*paragliderpb.CreateResourceResponse, error) {
-	return &paragliderpb.CreateResourceResponse{Name: "resource_name", Uri: "resource_uri"}, nil
+	return &paragliderpb.CreateResourceResponse{Name: ResourceName, Uri: "resource_uri"}, nil
 }
 
 func (s *fakeCloudPluginServer) GetUsedAddressSpaces(c context.Context, req *paragliderpb.GetUsedAddressSpacesRequest) (*paragliderpb.GetUsedAddressSpacesResponse, error) {
@@ -78,6 +82,22 @@ func (s *fakeCloudPluginServer) GetUsedBgpPeeringIpAddresses(c context.Context,
 	return &paragliderpb.GetUsedBgpPeeringIpAddressesResponse{IpAddresses: BgpPeeringIpAddresses}, nil
 }
 
+func (s *fakeCloudPluginServer) GetResourceInfo(c context.Context, req *paragliderpb.GetResourceInfoRequest) (*paragliderpb.GetResourceInfoResponse, error) {
+	return &paragliderpb.GetResourceInfoResponse{Uri: req.Uri, Ip: ResourceIp, Ips: []string{ResourceIp, ResourceSecondaryIp}, State: ResourceState}, nil
+}
+
+func (s *fakeCloudPluginServer) ListResources(c context.Context, req *paragliderpb.ListResourcesRequest) (*paragliderpb.ListResourcesResponse, error) {
+	return &paragliderpb.ListResourcesResponse{Resources: []*paragliderpb.Resource{getFakeResource(fake.TagUri)}}, nil
+}
+
+func (s *fakeCloudPluginServer) GetResource(c context.Context, req *paragliderpb.GetResourceRequest) (*paragliderpb.GetResourceResponse, error) {
+	return &paragliderpb.GetResourceResponse{Resource: getFakeResource(req.Uri)}, nil
+}
+
+func getFakeResource(uri string) *paragliderpb.Resource {
+	return &paragliderpb.Resource{Name: ResourceName, Uri: uri, Ip: ResourceIp, Region: "region", Network: "network", Subnet: "subnet", State: ResourceState, RuleCount: 1}
+}
+
 func NewFakePluginServer() *fakeCloudPluginServer {
 	s := &fakeCloudPluginServer{}
 	return s
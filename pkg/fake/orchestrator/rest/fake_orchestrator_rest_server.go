@@ -22,6 +22,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 
 	"github.com/paraglider-project/paraglider/pkg/orchestrator"
@@ -129,6 +130,17 @@ func ListFakeTagMapping() []*tagservicepb.TagMapping {
 	}
 }
 
+func GetFakeResources() []*paragliderpb.Resource {
+	return []*paragliderpb.Resource{
+		{Name: "resource1", Uri: "uri1", Ip: "10.0.0.1", Region: "region", Network: "network", Subnet: "subnet", State: "RUNNING", RuleCount: 2},
+		{Name: "resource2", Uri: "uri2", Ip: "10.0.0.2", Region: "region", Network: "network", Subnet: "subnet", State: "STOPPED"},
+	}
+}
+
+func GetFakeSubscriptions() []string {
+	return []string{"tag1", "tag2"}
+}
+
 func GetFakeNamespaces() map[string][]config.CloudDeployment {
 	return map[string][]config.CloudDeployment{
 		"namespace1": {
@@ -140,6 +152,15 @@ func GetFakeNamespaces() map[string][]config.CloudDeployment {
 	}
 }
 
+func GetFakeSnapshot() *orchestrator.Snapshot {
+	return &orchestrator.Snapshot{
+		Tags: ListFakeTagMapping(),
+		TagRules: []*orchestrator.TagRules{
+			{Tag: "tag1", Rules: GetFakePermitListRules()},
+		},
+	}
+}
+
 func (s *FakeOrchestratorRESTServer) writeResponse(w http.ResponseWriter, resp any) error {
 	bytes, err := json.Marshal(resp)
 	if err != nil {
@@ -170,6 +191,22 @@ func (s *FakeOrchestratorRESTServer) SetupFakeOrchestratorRESTServer() string {
 				return
 			}
 			return
+		// List Resources
+		case urlMatches(path, orchestrator.ListResourcesURL) && r.Method == http.MethodGet:
+			err := s.writeResponse(w, &paragliderpb.ListResourcesResponse{Resources: GetFakeResources()})
+			if err != nil {
+				http.Error(w, fmt.Sprintf("error writing response: %s", err), http.StatusInternalServerError)
+			}
+			return
+		// Get Resource
+		case urlMatches(path, orchestrator.GetResourceURL) && r.Method == http.MethodGet:
+			resource := GetFakeResources()[0]
+			resource.Name = strings.Split(path, "/")[len(strings.Split(path, "/"))-1]
+			err := s.writeResponse(w, resource)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("error writing response: %s", err), http.StatusInternalServerError)
+			}
+			return
 		// Create Resources (POST)
 		case urlMatches(path, orchestrator.CreateResourcePOSTURL) && r.Method == http.MethodPost:
 			resource := &paragliderpb.ResourceDescriptionString{}
@@ -234,6 +271,14 @@ func (s *FakeOrchestratorRESTServer) SetupFakeOrchestratorRESTServer() string {
 		case urlMatches(path, orchestrator.PermitListRulePUTURL) && (r.Method == http.MethodDelete):
 			w.WriteHeader(http.StatusOK)
 			return
+		// Get Subscriptions
+		case urlMatches(path, orchestrator.GetSubscriptionsURL) && r.Method == http.MethodGet:
+			err := s.writeResponse(w, GetFakeSubscriptions())
+			if err != nil {
+				http.Error(w, fmt.Sprintf("error writing response: %s", err), http.StatusInternalServerError)
+				return
+			}
+			return
 		// Get Permit List Rules
 		case urlMatches(path, orchestrator.GetPermitListRulesURL) && r.Method == http.MethodGet:
 			permitList := GetFakePermitListRules()
@@ -243,6 +288,14 @@ func (s *FakeOrchestratorRESTServer) SetupFakeOrchestratorRESTServer() string {
 				return
 			}
 			return
+		// Get Permit List Rules Tag
+		case urlMatches(path, orchestrator.RuleOnTagURL) && r.Method == http.MethodGet:
+			err := s.writeResponse(w, GetFakePermitListRules())
+			if err != nil {
+				http.Error(w, fmt.Sprintf("error writing response: %s", err), http.StatusInternalServerError)
+				return
+			}
+			return
 		// Add Permit List Rules Tag
 		case urlMatches(path, orchestrator.RuleOnTagURL) && r.Method == http.MethodPost:
 			rules := []*paragliderpb.PermitListRule{}
@@ -264,7 +317,12 @@ func (s *FakeOrchestratorRESTServer) SetupFakeOrchestratorRESTServer() string {
 		// Tag List
 		case urlMatches(path, orchestrator.ListTagURL):
 			if r.Method == http.MethodGet {
-				err := s.writeResponse(w, ListFakeTagMapping())
+				tags := ListFakeTagMapping()
+				listResp := &tagservicepb.ListTagsResponse{Tags: tags}
+				if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 && limit < len(tags) {
+					listResp = &tagservicepb.ListTagsResponse{Tags: tags[:limit], NextPageToken: "next"}
+				}
+				err := s.writeResponse(w, listResp)
 				if err != nil {
 					http.Error(w, fmt.Sprintf("error writing response: %s", err), http.StatusInternalServerError)
 					return
@@ -300,6 +358,29 @@ func (s *FakeOrchestratorRESTServer) SetupFakeOrchestratorRESTServer() string {
 		case urlMatches(path, orchestrator.DeleteTagMemberURL) && r.Method == http.MethodDelete:
 			w.WriteHeader(http.StatusOK)
 			return
+		// Snapshot Export
+		case urlMatches(path, orchestrator.ExportURL) && r.Method == http.MethodGet:
+			err := s.writeResponse(w, GetFakeSnapshot())
+			if err != nil {
+				http.Error(w, fmt.Sprintf("error writing response: %s", err), http.StatusInternalServerError)
+				return
+			}
+			return
+		// Snapshot Import
+		case urlMatches(path, orchestrator.ImportURL) && r.Method == http.MethodPost:
+			snapshot := &orchestrator.Snapshot{}
+			err := json.Unmarshal(body, snapshot)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("error unmarshalling request body: %s", err), http.StatusBadRequest)
+				return
+			}
+			mode := r.URL.Query().Get("mode")
+			if mode != orchestrator.ImportModeMerge && mode != orchestrator.ImportModeReplace {
+				http.Error(w, fmt.Sprintf("invalid import mode: %s", mode), http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			return
 		// Resolve Tag
 		case urlMatches(path, orchestrator.ResolveTagURL) && r.Method == http.MethodPost:
 			mappings := GetFakeTagMappingLeafTags(getURLParams(path, string(orchestrator.ResolveTagURL))["tag"])
@@ -33,9 +33,10 @@ import (
 // Note: this is only meant to be used with one cloud (i.e. primarily for each cloud plugin's unit/integration tests)
 type FakeOrchestratorRPCServer struct {
 	paragliderpb.UnimplementedControllerServer
-	Cloud   string
-	Counter int
-	kvStore map[string]string
+	Cloud      string
+	Counter    int
+	DnsServers []string // Addresses returned by GetDnsServers
+	kvStore    map[string]string
 }
 
 func (f *FakeOrchestratorRPCServer) FindUnusedAddressSpaces(ctx context.Context, req *paragliderpb.FindUnusedAddressSpacesRequest) (*paragliderpb.FindUnusedAddressSpacesResponse, error) {
@@ -96,6 +97,10 @@ func (f *FakeOrchestratorRPCServer) DeleteValue(ctx context.Context, in *paragli
 	return &paragliderpb.DeleteValueResponse{}, nil
 }
 
+func (f *FakeOrchestratorRPCServer) GetDnsServers(ctx context.Context, _ *emptypb.Empty) (*paragliderpb.GetDnsServersResponse, error) {
+	return &paragliderpb.GetDnsServersResponse{IpAddresses: f.DnsServers}, nil
+}
+
 func SetupFakeOrchestratorRPCServer(cloud string) (*FakeOrchestratorRPCServer, string, error) {
 	fakeControllerServer := &FakeOrchestratorRPCServer{
 		Counter: 0,
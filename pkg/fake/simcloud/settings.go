@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simcloud
+
+import (
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+)
+
+// Name of the simulated cloud when no other is given
+const DefaultCloud = "fake"
+
+// Cloud-specific behavior reproduced by the simulated cloud
+type Quirks struct {
+	NetworkPerRegion    bool   // Separate network per region (Azure VNets, IBM VPCs) instead of one global network with a subnet per region (GCP VPCs)
+	SubnetPerResource   bool   // Every resource gets its own subnet and address space (e.g., AKS clusters)
+	ReservedAddresses   int    // Addresses at the start of a subnet (including the network address) which are never assigned to resources
+	MaxRulesPerResource int    // Maximum number of permit list rules per resource (unlimited if 0)
+	MaxRuleNameLength   int    // Maximum length of permit list rule names (unlimited if 0)
+	BgpSupported        bool   // VPN connections peer over BGP; otherwise they must list the remote address spaces
+	DefaultRegion       string // Region of resources whose description does not specify one
+}
+
+// Faults injected into the simulated cloud's RPCs
+type Faults struct {
+	Latency        time.Duration         // Delay added to every RPC
+	ErrorRate      float64               // Probability of an RPC failing with codes.Unavailable
+	Errors         map[string]codes.Code // RPCs (e.g., "CreateResource") which always fail with the given code
+	PartialFailure bool                  // Mutating RPCs apply only part of their changes before failing
+}
+
+type Settings struct {
+	Cloud  string // Cloud name reported in address space mappings
+	Quirks Quirks
+	Faults Faults
+	Seed   int64 // Seed of the random error injection
+}
+
+// Get the quirks of a cloud (clouds without specific quirks get permissive defaults)
+func QuirksFor(cloud string) Quirks {
+	switch cloud {
+	case utils.AWS:
+		return Quirks{NetworkPerRegion: true, ReservedAddresses: 5, MaxRulesPerResource: 60, MaxRuleNameLength: 256, BgpSupported: true, DefaultRegion: "us-east-1a"}
+	case utils.AZURE:
+		return Quirks{NetworkPerRegion: true, ReservedAddresses: 4, MaxRulesPerResource: 1000, MaxRuleNameLength: 80, BgpSupported: true, DefaultRegion: "eastus"}
+	case utils.GCP:
+		return Quirks{NetworkPerRegion: false, ReservedAddresses: 2, MaxRuleNameLength: 63, BgpSupported: true, DefaultRegion: "us-west1-a"}
+	case utils.IBM:
+		return Quirks{NetworkPerRegion: true, ReservedAddresses: 4, MaxRulesPerResource: 250, MaxRuleNameLength: 63, BgpSupported: false, DefaultRegion: "us-south-1"}
+	default:
+		return Quirks{NetworkPerRegion: true, ReservedAddresses: 1, BgpSupported: true, DefaultRegion: "region-1"}
+	}
+}
+
+// Get the settings of a simulated cloud with the quirks of the given cloud and no faults
+func DefaultSettings(cloud string) Settings {
+	return Settings{Cloud: cloud, Quirks: QuirksFor(cloud)}
+}
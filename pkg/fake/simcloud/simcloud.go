@@ -0,0 +1,792 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+)
+
+const (
+	ResourceState = "RUNNING"
+	DefaultAsn    = 64512
+)
+
+// Public address space which VPN gateway IPs are assigned from (TEST-NET-3)
+var gatewayAddressSpace = netip.MustParsePrefix("203.0.113.0/24")
+
+// Description of a simulated resource (cloud-agnostic ParagliderVM descriptions are accepted as well)
+type ResourceDescription struct {
+	Region string `json:"region"`          // Region of the resource (defaults to the cloud's default region)
+	State  string `json:"state,omitempty"` // State reported for the resource (defaults to RUNNING)
+}
+
+type subnet struct {
+	name         string
+	region       string
+	addressSpace netip.Prefix
+	nextIp       netip.Addr
+}
+
+type network struct {
+	name       string
+	namespace  string
+	deployment string
+	subnets    []*subnet
+}
+
+type resource struct {
+	name       string
+	uri        string
+	namespace  string
+	deployment string
+	region     string
+	network    *network
+	subnet     *subnet
+	ip         string
+	state      string
+	rules      map[string]*paragliderpb.PermitListRule
+}
+
+type vpnGateway struct {
+	namespace     string
+	deployment    string
+	asn           uint32
+	ips           []string
+	bgpPeeringIps []string
+	connections   map[string]*vpnConnection // Keyed by remote gateway IP
+}
+
+type vpnConnection struct {
+	cloud           string
+	remoteGatewayIp string
+	remoteAsn       uint32
+	bgpIp           string
+	sharedKey       string
+	remoteAddresses []string
+}
+
+// Simulated cloud plugin which keeps its networks, resources, permit lists and VPNs in memory
+type SimCloudPluginServer struct {
+	paragliderpb.UnimplementedCloudPluginServer
+	orchestratorServerAddr string
+
+	mu                 sync.Mutex
+	settings           Settings
+	random             *rand.Rand
+	networks           map[string]*network    // Keyed by network key
+	resources          map[string]*resource   // Keyed by URI
+	gateways           map[string]*vpnGateway // Keyed by deployment key
+	localAddressSpaces int                    // Address spaces allocated without an orchestrator
+	nextGatewayIp      netip.Addr
+}
+
+// Create a simulated cloud plugin which gets address spaces and ASNs from the orchestrator (or allocates them itself if the address is empty)
+func NewSimCloudPluginServer(orchestratorServerAddr string, settings Settings) *SimCloudPluginServer {
+	if settings.Cloud == "" {
+		settings.Cloud = DefaultCloud
+	}
+	return &SimCloudPluginServer{
+		orchestratorServerAddr: orchestratorServerAddr,
+		settings:               settings,
+		random:                 rand.New(rand.NewSource(settings.Seed)),
+		networks:               make(map[string]*network),
+		resources:              make(map[string]*resource),
+		gateways:               make(map[string]*vpnGateway),
+		nextGatewayIp:          gatewayAddressSpace.Addr().Next(),
+	}
+}
+
+// Replace the faults injected into the RPCs
+func (s *SimCloudPluginServer) SetFaults(faults Faults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings.Faults = faults
+}
+
+// Apply the injected latency and errors to an RPC
+func (s *SimCloudPluginServer) injectFaults(ctx context.Context, method string) error {
+	s.mu.Lock()
+	faults := s.settings.Faults
+	fail := faults.ErrorRate > 0 && s.random.Float64() < faults.ErrorRate
+	s.mu.Unlock()
+
+	if faults.Latency > 0 {
+		select {
+		case <-time.After(faults.Latency):
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	if code, ok := faults.Errors[method]; ok {
+		return status.Errorf(code, "injected %s failure", method)
+	}
+	if fail {
+		return status.Errorf(codes.Unavailable, "injected %s failure", method)
+	}
+	return nil
+}
+
+// Get how many of a mutating RPC's changes are applied (the first half with partial failures)
+func (s *SimCloudPluginServer) numApplied(numChanges int) int {
+	if s.settings.Faults.PartialFailure {
+		return (numChanges + 1) / 2
+	}
+	return numChanges
+}
+
+// Get the error returned after a mutating RPC applied only part of its changes
+func partialFailure(method string) error {
+	return status.Errorf(codes.Internal, "injected partial %s failure", method)
+}
+
+func getDeploymentKey(deployment string, namespace string) string {
+	return deployment + "|" + namespace
+}
+
+func getResourceUri(deployment string, region string, name string) string {
+	return fmt.Sprintf("%s/regions/%s/resources/%s", deployment, region, name)
+}
+
+// Parse a resource URI into its deployment, region and name
+func parseResourceUri(uri string) (string, string, string, error) {
+	deployment, rest, ok := strings.Cut(uri, "/regions/")
+	if !ok {
+		return "", "", "", status.Errorf(codes.InvalidArgument, "invalid resource URI %s: expected {deployment}/regions/{region}/resources/{name}", uri)
+	}
+	region, name, ok := strings.Cut(rest, "/resources/")
+	if !ok || region == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", "", status.Errorf(codes.InvalidArgument, "invalid resource URI %s: expected {deployment}/regions/{region}/resources/{name}", uri)
+	}
+	return deployment, region, name, nil
+}
+
+func (s *SimCloudPluginServer) getNetworkName(namespace string, region string) string {
+	if s.settings.Quirks.NetworkPerRegion {
+		return fmt.Sprintf("paraglider-%s-%s", namespace, region)
+	}
+	return fmt.Sprintf("paraglider-%s", namespace)
+}
+
+// Get the resource with the given URI if it is in the namespace
+func (s *SimCloudPluginServer) getNamespacedResource(namespace string, uri string) (*resource, error) {
+	if namespace == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "namespace cannot be empty")
+	}
+	res, ok := s.resources[uri]
+	if !ok || res.namespace != namespace {
+		return nil, status.Errorf(codes.NotFound, "resource %s not found in namespace %s", uri, namespace)
+	}
+	return res, nil
+}
+
+// Get unused address spaces from the orchestrator (or allocate them locally without one)
+// This must not hold s.mu since the orchestrator gets the used address spaces from the plugin
+func (s *SimCloudPluginServer) findUnusedAddressSpaces(ctx context.Context, num int) ([]netip.Prefix, error) {
+	addressSpaces := []string{}
+	if s.orchestratorServerAddr == "" {
+		s.mu.Lock()
+		for i := 0; i < num; i++ {
+			if s.localAddressSpaces == 256 {
+				s.mu.Unlock()
+				return nil, status.Errorf(codes.ResourceExhausted, "ran out of address spaces")
+			}
+			addressSpaces = append(addressSpaces, fmt.Sprintf("10.%d.0.0/16", s.localAddressSpaces))
+			s.localAddressSpaces++
+		}
+		s.mu.Unlock()
+	} else {
+		conn, err := grpc.NewClient(s.orchestratorServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		client := paragliderpb.NewControllerClient(conn)
+		resp, err := client.FindUnusedAddressSpaces(ctx, &paragliderpb.FindUnusedAddressSpacesRequest{Num: proto.Int32(int32(num))})
+		if err != nil {
+			return nil, err
+		}
+		addressSpaces = resp.AddressSpaces
+	}
+
+	prefixes := make([]netip.Prefix, len(addressSpaces))
+	for i, addressSpace := range addressSpaces {
+		prefix, err := netip.ParsePrefix(addressSpace)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "invalid address space %s: %v", addressSpace, err)
+		}
+		prefixes[i] = prefix.Masked()
+	}
+	return prefixes, nil
+}
+
+// Get an unused ASN from the orchestrator (or the default ASN without one)
+// This must not hold s.mu since the orchestrator gets the used ASNs from the plugin
+func (s *SimCloudPluginServer) findUnusedAsn(ctx context.Context) (uint32, error) {
+	if s.orchestratorServerAddr == "" {
+		return DefaultAsn, nil
+	}
+	conn, err := grpc.NewClient(s.orchestratorServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	client := paragliderpb.NewControllerClient(conn)
+	resp, err := client.FindUnusedAsn(ctx, &paragliderpb.FindUnusedAsnRequest{})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Asn, nil
+}
+
+func (s *SimCloudPluginServer) getNetworkKey(deployment string, namespace string, region string) string {
+	return getDeploymentKey(deployment, namespace) + "|" + s.getNetworkName(namespace, region)
+}
+
+// Get the existing subnet a new resource in the region goes into, or nil if it needs a new one
+func (s *SimCloudPluginServer) getSubnet(deployment string, namespace string, region string) *subnet {
+	if s.settings.Quirks.SubnetPerResource {
+		return nil
+	}
+	nw, ok := s.networks[s.getNetworkKey(deployment, namespace, region)]
+	if !ok {
+		return nil
+	}
+	for _, sub := range nw.subnets {
+		if sub.region == region {
+			return sub
+		}
+	}
+	return nil
+}
+
+// Get the subnet a new resource in the region goes into, creating the network and a subnet with the given address space if needed
+func (s *SimCloudPluginServer) getOrCreateSubnet(deployment string, namespace string, region string, resourceName string, addressSpace netip.Prefix) (*network, *subnet, error) {
+	networkName := s.getNetworkName(namespace, region)
+	networkKey := s.getNetworkKey(deployment, namespace, region)
+	nw, ok := s.networks[networkKey]
+	if !ok {
+		nw = &network{name: networkName, namespace: namespace, deployment: deployment}
+	}
+	if sub := s.getSubnet(deployment, namespace, region); sub != nil {
+		return nw, sub, nil
+	}
+	if !addressSpace.IsValid() {
+		return nil, nil, status.Errorf(codes.Internal, "no address space allocated for a new subnet in %s", region)
+	}
+
+	sub := &subnet{name: fmt.Sprintf("%s-%s-subnet", networkName, region), region: region, addressSpace: addressSpace}
+	if s.settings.Quirks.SubnetPerResource {
+		sub.name = fmt.Sprintf("%s-%s-subnet", networkName, resourceName)
+	}
+	sub.nextIp = sub.addressSpace.Addr()
+	for i := 0; i < s.settings.Quirks.ReservedAddresses; i++ {
+		sub.nextIp = sub.nextIp.Next()
+	}
+	nw.subnets = append(nw.subnets, sub)
+	s.networks[networkKey] = nw
+	return nw, sub, nil
+}
+
+// Assign the next unused IP of a subnet
+func allocateIp(sub *subnet) (string, error) {
+	if !sub.addressSpace.Contains(sub.nextIp) {
+		return "", status.Errorf(codes.ResourceExhausted, "subnet %s has no unused addresses", sub.name)
+	}
+	ip := sub.nextIp
+	sub.nextIp = sub.nextIp.Next()
+	return ip.String(), nil
+}
+
+// Create a resource in its region's subnet
+// This must not hold s.mu since a new subnet gets its address space from the orchestrator
+func (s *SimCloudPluginServer) createResource(ctx context.Context, deployment *paragliderpb.ParagliderDeployment, name string, region string, state string) (*resource, error) {
+	uri := getResourceUri(deployment.Id, region, name)
+	s.mu.Lock()
+	_, exists := s.resources[uri]
+	needsSubnet := s.getSubnet(deployment.Id, deployment.Namespace, region) == nil
+	s.mu.Unlock()
+	if exists {
+		return nil, status.Errorf(codes.AlreadyExists, "resource %s already exists", uri)
+	}
+
+	var addressSpace netip.Prefix
+	if needsSubnet {
+		addressSpaces, err := s.findUnusedAddressSpaces(ctx, 1)
+		if err != nil {
+			return nil, err
+		}
+		addressSpace = addressSpaces[0]
+	}
+
+	// Check again since the resource may have been created while the lock was released
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.resources[uri]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "resource %s already exists", uri)
+	}
+	nw, sub, err := s.getOrCreateSubnet(deployment.Id, deployment.Namespace, region, name, addressSpace)
+	if err != nil {
+		return nil, err
+	}
+	ip, err := allocateIp(sub)
+	if err != nil {
+		return nil, err
+	}
+	res := &resource{
+		name:       name,
+		uri:        uri,
+		namespace:  deployment.Namespace,
+		deployment: deployment.Id,
+		region:     region,
+		network:    nw,
+		subnet:     sub,
+		ip:         ip,
+		state:      state,
+		rules:      make(map[string]*paragliderpb.PermitListRule),
+	}
+	s.resources[uri] = res
+	return res, nil
+}
+
+func (r *resource) toProto() *paragliderpb.Resource {
+	return &paragliderpb.Resource{
+		Name:      r.name,
+		Uri:       r.uri,
+		Ip:        r.ip,
+		Region:    r.region,
+		Network:   r.network.name,
+		Subnet:    r.subnet.name,
+		State:     r.state,
+		RuleCount: int32(len(r.rules)),
+	}
+}
+
+func (s *SimCloudPluginServer) CreateResource(ctx context.Context, req *paragliderpb.CreateResourceRequest) (*paragliderpb.CreateResourceResponse, error) {
+	if err := s.injectFaults(ctx, "CreateResource"); err != nil {
+		return nil, err
+	}
+	if req.Deployment == nil || req.Deployment.Namespace == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "deployment and namespace must be specified")
+	}
+	if req.Name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "resource name must be specified")
+	}
+	desc := &ResourceDescription{}
+	if len(req.Description) > 0 {
+		if err := json.Unmarshal(req.Description, desc); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unable to parse resource description: %v", err)
+		}
+	}
+
+	s.mu.Lock()
+	if desc.Region == "" {
+		desc.Region = s.settings.Quirks.DefaultRegion
+	}
+	s.mu.Unlock()
+	if desc.State == "" {
+		desc.State = ResourceState
+	}
+	res, err := s.createResource(ctx, req.Deployment, req.Name, desc.Region, desc.State)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.settings.Faults.PartialFailure {
+		return nil, partialFailure("CreateResource")
+	}
+	return &paragliderpb.CreateResourceResponse{Name: res.name, Uri: res.uri, Ip: res.ip}, nil
+}
+
+// Attach a resource created outside of Paraglider (identified by its URI) to the namespace
+func (s *SimCloudPluginServer) AttachResource(ctx context.Context, req *paragliderpb.AttachResourceRequest) (*paragliderpb.AttachResourceResponse, error) {
+	if err := s.injectFaults(ctx, "AttachResource"); err != nil {
+		return nil, err
+	}
+	if req.Deployment == nil || req.Deployment.Namespace == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "deployment and namespace must be specified")
+	}
+	deployment, region, name, err := parseResourceUri(req.Uri)
+	if err != nil {
+		return nil, err
+	}
+	if deployment != req.Deployment.Id {
+		return nil, status.Errorf(codes.InvalidArgument, "resource %s is not in deployment %s", req.Uri, req.Deployment.Id)
+	}
+
+	s.mu.Lock()
+	res, ok := s.resources[req.Uri]
+	s.mu.Unlock()
+	if ok {
+		if res.namespace != req.Deployment.Namespace {
+			return nil, status.Errorf(codes.FailedPrecondition, "resource %s is already attached to namespace %s", req.Uri, res.namespace)
+		}
+	} else {
+		res, err = s.createResource(ctx, req.Deployment, name, region, ResourceState)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &paragliderpb.AttachResourceResponse{Name: res.name, Uri: res.uri, Ip: res.ip}, nil
+}
+
+func (s *SimCloudPluginServer) GetResourceInfo(ctx context.Context, req *paragliderpb.GetResourceInfoRequest) (*paragliderpb.GetResourceInfoResponse, error) {
+	if err := s.injectFaults(ctx, "GetResourceInfo"); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, err := s.getNamespacedResource(req.Namespace, req.Uri)
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.GetResourceInfoResponse{Uri: res.uri, Ip: res.ip, Ips: []string{res.ip}, State: res.state}, nil
+}
+
+func (s *SimCloudPluginServer) GetResource(ctx context.Context, req *paragliderpb.GetResourceRequest) (*paragliderpb.GetResourceResponse, error) {
+	if err := s.injectFaults(ctx, "GetResource"); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, err := s.getNamespacedResource(req.Namespace, req.Uri)
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.GetResourceResponse{Resource: res.toProto()}, nil
+}
+
+func (s *SimCloudPluginServer) ListResources(ctx context.Context, req *paragliderpb.ListResourcesRequest) (*paragliderpb.ListResourcesResponse, error) {
+	if err := s.injectFaults(ctx, "ListResources"); err != nil {
+		return nil, err
+	}
+	if req.Deployment == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "deployment must be specified")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resources := []*paragliderpb.Resource{}
+	for _, res := range s.resources {
+		if res.deployment == req.Deployment.Id && res.namespace == req.Deployment.Namespace {
+			resources = append(resources, res.toProto())
+		}
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Uri < resources[j].Uri })
+	return &paragliderpb.ListResourcesResponse{Resources: resources}, nil
+}
+
+func (s *SimCloudPluginServer) GetPermitList(ctx context.Context, req *paragliderpb.GetPermitListRequest) (*paragliderpb.GetPermitListResponse, error) {
+	if err := s.injectFaults(ctx, "GetPermitList"); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, err := s.getNamespacedResource(req.Namespace, req.Resource)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]*paragliderpb.PermitListRule, 0, len(res.rules))
+	for _, rule := range res.rules {
+		rules = append(rules, proto.Clone(rule).(*paragliderpb.PermitListRule))
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return &paragliderpb.GetPermitListResponse{Rules: rules}, nil
+}
+
+// Check that a rule can be applied to a resource in this cloud
+func (s *SimCloudPluginServer) validateRule(rule *paragliderpb.PermitListRule) error {
+	if rule.Name == "" {
+		return status.Errorf(codes.InvalidArgument, "rule name cannot be empty")
+	}
+	if maxLength := s.settings.Quirks.MaxRuleNameLength; maxLength > 0 && len(rule.Name) > maxLength {
+		return status.Errorf(codes.InvalidArgument, "rule name %s is longer than %d characters", rule.Name, maxLength)
+	}
+	for _, target := range rule.Targets {
+		if _, err := netip.ParsePrefix(target); err != nil {
+			if _, err := netip.ParseAddr(target); err != nil {
+				return status.Errorf(codes.InvalidArgument, "target %s of rule %s is not an IP address or CIDR", target, rule.Name)
+			}
+		}
+	}
+	if rule.SrcPort < -1 || rule.SrcPort > 65535 || rule.DstPort < -1 || rule.DstPort > 65535 {
+		return status.Errorf(codes.InvalidArgument, "rule %s has an invalid port", rule.Name)
+	}
+	return nil
+}
+
+// Add rules to a resource's permit list (rules with the name of an existing rule replace it)
+func (s *SimCloudPluginServer) AddPermitListRules(ctx context.Context, req *paragliderpb.AddPermitListRulesRequest) (*paragliderpb.AddPermitListRulesResponse, error) {
+	if err := s.injectFaults(ctx, "AddPermitListRules"); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, err := s.getNamespacedResource(req.Namespace, req.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	newRules := 0
+	for _, rule := range req.Rules {
+		if err := s.validateRule(rule); err != nil {
+			return nil, err
+		}
+		if _, ok := res.rules[rule.Name]; !ok {
+			newRules++
+		}
+	}
+	if maxRules := s.settings.Quirks.MaxRulesPerResource; maxRules > 0 && len(res.rules)+newRules > maxRules {
+		return nil, status.Errorf(codes.ResourceExhausted, "resource %s cannot have more than %d rules", res.uri, maxRules)
+	}
+
+	for _, rule := range req.Rules[:s.numApplied(len(req.Rules))] {
+		res.rules[rule.Name] = proto.Clone(rule).(*paragliderpb.PermitListRule)
+	}
+	if s.settings.Faults.PartialFailure {
+		return nil, partialFailure("AddPermitListRules")
+	}
+	return &paragliderpb.AddPermitListRulesResponse{}, nil
+}
+
+// Delete rules from a resource's permit list (names of rules which do not exist are ignored)
+func (s *SimCloudPluginServer) DeletePermitListRules(ctx context.Context, req *paragliderpb.DeletePermitListRulesRequest) (*paragliderpb.DeletePermitListRulesResponse, error) {
+	if err := s.injectFaults(ctx, "DeletePermitListRules"); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, err := s.getNamespacedResource(req.Namespace, req.Resource)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range req.RuleNames[:s.numApplied(len(req.RuleNames))] {
+		delete(res.rules, name)
+	}
+	if s.settings.Faults.PartialFailure {
+		return nil, partialFailure("DeletePermitListRules")
+	}
+	return &paragliderpb.DeletePermitListRulesResponse{}, nil
+}
+
+// Get the address spaces of each deployment's networks
+func (s *SimCloudPluginServer) GetUsedAddressSpaces(ctx context.Context, req *paragliderpb.GetUsedAddressSpacesRequest) (*paragliderpb.GetUsedAddressSpacesResponse, error) {
+	if err := s.injectFaults(ctx, "GetUsedAddressSpaces"); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp := &paragliderpb.GetUsedAddressSpacesResponse{AddressSpaceMappings: make([]*paragliderpb.AddressSpaceMapping, len(req.Deployments))}
+	for i, deployment := range req.Deployments {
+		addressSpaces := []string{}
+		for _, nw := range s.networks {
+			if nw.deployment == deployment.Id && nw.namespace == deployment.Namespace {
+				for _, sub := range nw.subnets {
+					addressSpaces = append(addressSpaces, sub.addressSpace.String())
+				}
+			}
+		}
+		sort.Strings(addressSpaces)
+		resp.AddressSpaceMappings[i] = &paragliderpb.AddressSpaceMapping{
+			AddressSpaces: addressSpaces,
+			Cloud:         s.settings.Cloud,
+			Namespace:     deployment.Namespace,
+			Deployment:    proto.String(deployment.Id),
+		}
+	}
+	return resp, nil
+}
+
+// Get the address spaces of the network containing the given address space
+func (s *SimCloudPluginServer) GetNetworkAddressSpaces(ctx context.Context, req *paragliderpb.GetNetworkAddressSpacesRequest) (*paragliderpb.GetNetworkAddressSpacesResponse, error) {
+	if err := s.injectFaults(ctx, "GetNetworkAddressSpaces"); err != nil {
+		return nil, err
+	}
+	if req.Deployment == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "deployment must be specified")
+	}
+	prefix, err := netip.ParsePrefix(req.AddressSpace)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid address space %s: %v", req.AddressSpace, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, nw := range s.networks {
+		if nw.deployment != req.Deployment.Id || nw.namespace != req.Deployment.Namespace {
+			continue
+		}
+		for _, sub := range nw.subnets {
+			if sub.addressSpace.Overlaps(prefix) {
+				addressSpaces := []string{}
+				for _, netSubnet := range nw.subnets {
+					addressSpaces = append(addressSpaces, netSubnet.addressSpace.String())
+				}
+				sort.Strings(addressSpaces)
+				return &paragliderpb.GetNetworkAddressSpacesResponse{AddressSpaces: addressSpaces}, nil
+			}
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "no network in namespace %s contains address space %s", req.Deployment.Namespace, req.AddressSpace)
+}
+
+// Create the namespace's VPN gateway (or return the existing one)
+func (s *SimCloudPluginServer) CreateVpnGateway(ctx context.Context, req *paragliderpb.CreateVpnGatewayRequest) (*paragliderpb.CreateVpnGatewayResponse, error) {
+	if err := s.injectFaults(ctx, "CreateVpnGateway"); err != nil {
+		return nil, err
+	}
+	if req.Deployment == nil || req.Deployment.Namespace == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "deployment and namespace must be specified")
+	}
+
+	key := getDeploymentKey(req.Deployment.Id, req.Deployment.Namespace)
+	s.mu.Lock()
+	_, exists := s.gateways[key]
+	s.mu.Unlock()
+	var asn uint32
+	if !exists {
+		var err error
+		asn, err = s.findUnusedAsn(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Check again since the gateway may have been created while the lock was released
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	gateway, ok := s.gateways[key]
+	if !ok {
+		gateway = &vpnGateway{
+			namespace:     req.Deployment.Namespace,
+			deployment:    req.Deployment.Id,
+			asn:           asn,
+			bgpPeeringIps: req.BgpPeeringIpAddresses,
+			connections:   make(map[string]*vpnConnection),
+		}
+		for i := 0; i < 2; i++ {
+			if !gatewayAddressSpace.Contains(s.nextGatewayIp) {
+				return nil, status.Errorf(codes.ResourceExhausted, "ran out of public IP addresses")
+			}
+			gateway.ips = append(gateway.ips, s.nextGatewayIp.String())
+			s.nextGatewayIp = s.nextGatewayIp.Next()
+		}
+		s.gateways[key] = gateway
+	}
+	return &paragliderpb.CreateVpnGatewayResponse{Asn: gateway.asn, GatewayIpAddresses: gateway.ips}, nil
+}
+
+// Connect the namespace's VPN gateway to the gateway IPs of another cloud (existing connections are updated)
+func (s *SimCloudPluginServer) CreateVpnConnections(ctx context.Context, req *paragliderpb.CreateVpnConnectionsRequest) (*paragliderpb.CreateVpnConnectionsResponse, error) {
+	if err := s.injectFaults(ctx, "CreateVpnConnections"); err != nil {
+		return nil, err
+	}
+	if req.Deployment == nil || req.Deployment.Namespace == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "deployment and namespace must be specified")
+	}
+	if len(req.GatewayIpAddresses) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "no remote gateway IP addresses given")
+	}
+	useBgp := s.settings.Quirks.BgpSupported && !req.IsBgpDisabled
+	if useBgp && len(req.BgpIpAddresses) != len(req.GatewayIpAddresses) {
+		return nil, status.Errorf(codes.InvalidArgument, "expected a BGP IP address for each of the %d gateway IP addresses", len(req.GatewayIpAddresses))
+	}
+	if !useBgp && len(req.RemoteAddresses) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "remote addresses are required for VPN connections without BGP")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	gateway, ok := s.gateways[getDeploymentKey(req.Deployment.Id, req.Deployment.Namespace)]
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "namespace %s has no VPN gateway", req.Deployment.Namespace)
+	}
+	for i, gatewayIp := range req.GatewayIpAddresses[:s.numApplied(len(req.GatewayIpAddresses))] {
+		connection := &vpnConnection{cloud: req.Cloud, remoteGatewayIp: gatewayIp, remoteAsn: req.Asn, sharedKey: req.SharedKey, remoteAddresses: req.RemoteAddresses}
+		if useBgp {
+			connection.bgpIp = req.BgpIpAddresses[i]
+		}
+		gateway.connections[gatewayIp] = connection
+	}
+	if s.settings.Faults.PartialFailure {
+		return nil, partialFailure("CreateVpnConnections")
+	}
+	return &paragliderpb.CreateVpnConnectionsResponse{}, nil
+}
+
+func (s *SimCloudPluginServer) GetUsedAsns(ctx context.Context, req *paragliderpb.GetUsedAsnsRequest) (*paragliderpb.GetUsedAsnsResponse, error) {
+	if err := s.injectFaults(ctx, "GetUsedAsns"); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	asns := []uint32{}
+	for _, deployment := range req.Deployments {
+		if gateway, ok := s.gateways[getDeploymentKey(deployment.Id, deployment.Namespace)]; ok {
+			asns = append(asns, gateway.asn)
+		}
+	}
+	return &paragliderpb.GetUsedAsnsResponse{Asns: asns}, nil
+}
+
+func (s *SimCloudPluginServer) GetUsedBgpPeeringIpAddresses(ctx context.Context, req *paragliderpb.GetUsedBgpPeeringIpAddressesRequest) (*paragliderpb.GetUsedBgpPeeringIpAddressesResponse, error) {
+	if err := s.injectFaults(ctx, "GetUsedBgpPeeringIpAddresses"); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ips := []string{}
+	for _, deployment := range req.Deployments {
+		if gateway, ok := s.gateways[getDeploymentKey(deployment.Id, deployment.Namespace)]; ok {
+			ips = append(ips, gateway.bgpPeeringIps...)
+		}
+	}
+	return &paragliderpb.GetUsedBgpPeeringIpAddressesResponse{IpAddresses: ips}, nil
+}
+
+// Start a simulated cloud plugin on the given port (0 picks a free port) and return its address
+func Setup(port int, server *SimCloudPluginServer) (string, error) {
+	lis, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		return "", fmt.Errorf("failed to listen: %w", err)
+	}
+	grpcServer := grpc.NewServer()
+	paragliderpb.RegisterCloudPluginServer(grpcServer, server)
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			fmt.Println(err.Error())
+		}
+	}()
+	return lis.Addr().String(), nil
+}
@@ -0,0 +1,383 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simcloud
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	fake "github.com/paraglider-project/paraglider/pkg/fake/orchestrator/rpc"
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/resourcespec"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+)
+
+const (
+	testDeployment = "test-deployment"
+	testNamespace  = "default"
+	otherNamespace = "other"
+)
+
+func newTestServer(t *testing.T, cloud string) *SimCloudPluginServer {
+	_, orchestratorAddr, err := fake.SetupFakeOrchestratorRPCServer(cloud)
+	require.NoError(t, err)
+	return NewSimCloudPluginServer(orchestratorAddr, DefaultSettings(cloud))
+}
+
+func createTestResource(t *testing.T, s *SimCloudPluginServer, namespace string, name string, region string) *paragliderpb.CreateResourceResponse {
+	description, err := json.Marshal(&ResourceDescription{Region: region})
+	require.NoError(t, err)
+	resp, err := s.CreateResource(context.Background(), &paragliderpb.CreateResourceRequest{
+		Deployment:  &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: namespace},
+		Name:        name,
+		Description: description,
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+func getTestRule(name string) *paragliderpb.PermitListRule {
+	return &paragliderpb.PermitListRule{Name: name, Targets: []string{"10.1.0.5"}, Direction: paragliderpb.Direction_INBOUND, SrcPort: -1, DstPort: 22, Protocol: 6, Tags: []string{"default.fake.vm"}}
+}
+
+func TestCreateResource(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("NetworkPerRegion", func(t *testing.T) {
+		s := newTestServer(t, utils.AZURE)
+		vm1 := createTestResource(t, s, testNamespace, "vm1", "eastus")
+		vm2 := createTestResource(t, s, testNamespace, "vm2", "eastus")
+		vm3 := createTestResource(t, s, testNamespace, "vm3", "westus")
+		assert.Equal(t, getResourceUri(testDeployment, "eastus", "vm1"), vm1.Uri)
+		assert.Equal(t, "10.0.0.4", vm1.Ip) // Azure reserves the first 4 addresses
+		assert.Equal(t, "10.0.0.5", vm2.Ip)
+		assert.Equal(t, "10.1.0.4", vm3.Ip)
+
+		resp, err := s.GetResource(ctx, &paragliderpb.GetResourceRequest{Namespace: testNamespace, Uri: vm3.Uri})
+		require.NoError(t, err)
+		assert.Equal(t, "paraglider-default-westus", resp.Resource.Network)
+		assert.Equal(t, "westus", resp.Resource.Region)
+		assert.Equal(t, ResourceState, resp.Resource.State)
+	})
+
+	t.Run("GlobalNetwork", func(t *testing.T) {
+		s := newTestServer(t, utils.GCP)
+		vm1 := createTestResource(t, s, testNamespace, "vm1", "us-west1-a")
+		vm2 := createTestResource(t, s, testNamespace, "vm2", "us-east1-b")
+		assert.Equal(t, "10.0.0.2", vm1.Ip) // GCP reserves the first 2 addresses
+		assert.Equal(t, "10.1.0.2", vm2.Ip)
+
+		resp, err := s.ListResources(ctx, &paragliderpb.ListResourcesRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace}})
+		require.NoError(t, err)
+		require.Len(t, resp.Resources, 2)
+		assert.Equal(t, resp.Resources[0].Network, resp.Resources[1].Network)
+		assert.NotEqual(t, resp.Resources[0].Subnet, resp.Resources[1].Subnet)
+	})
+
+	t.Run("SubnetPerResource", func(t *testing.T) {
+		settings := DefaultSettings(DefaultCloud)
+		settings.Quirks.SubnetPerResource = true
+		s := NewSimCloudPluginServer("", settings)
+		vm1 := createTestResource(t, s, testNamespace, "vm1", "region-1")
+		vm2 := createTestResource(t, s, testNamespace, "vm2", "region-1")
+		assert.Equal(t, "10.0.0.1", vm1.Ip)
+		assert.Equal(t, "10.1.0.1", vm2.Ip)
+	})
+
+	t.Run("ParagliderVM", func(t *testing.T) {
+		s := newTestServer(t, DefaultCloud)
+		description, err := json.Marshal(&resourcespec.VM{Kind: resourcespec.VMKind, Size: resourcespec.SizeSmall, Image: resourcespec.ImageDebian12, Region: "region-2", SSHKey: "key"})
+		require.NoError(t, err)
+		resp, err := s.CreateResource(ctx, &paragliderpb.CreateResourceRequest{
+			Deployment:  &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace},
+			Name:        "vm",
+			Description: description,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, getResourceUri(testDeployment, "region-2", "vm"), resp.Uri)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		s := NewSimCloudPluginServer("", DefaultSettings(DefaultCloud))
+		createTestResource(t, s, testNamespace, "vm", "")
+
+		// Existing resource
+		_, err := s.CreateResource(ctx, &paragliderpb.CreateResourceRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace}, Name: "vm"})
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+		// Bad description
+		_, err = s.CreateResource(ctx, &paragliderpb.CreateResourceRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace}, Name: "vm2", Description: []byte("not json")})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestAttachResource(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimCloudPluginServer("", DefaultSettings(DefaultCloud))
+	uri := getResourceUri(testDeployment, "region-1", "existing-vm")
+	deployment := &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace}
+
+	resp, err := s.AttachResource(ctx, &paragliderpb.AttachResourceRequest{Deployment: deployment, Name: "vm", Uri: uri})
+	require.NoError(t, err)
+	assert.Equal(t, uri, resp.Uri)
+	assert.Equal(t, "existing-vm", resp.Name)
+	assert.Equal(t, "10.0.0.1", resp.Ip)
+
+	// Attaching again is a no-op
+	again, err := s.AttachResource(ctx, &paragliderpb.AttachResourceRequest{Deployment: deployment, Name: "vm", Uri: uri})
+	require.NoError(t, err)
+	assert.Equal(t, resp.Ip, again.Ip)
+
+	// Resources cannot move between namespaces
+	_, err = s.AttachResource(ctx, &paragliderpb.AttachResourceRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: otherNamespace}, Uri: uri})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// Invalid URI
+	_, err = s.AttachResource(ctx, &paragliderpb.AttachResourceRequest{Deployment: deployment, Uri: "invalid"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestPermitList(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, DefaultCloud)
+	vm := createTestResource(t, s, testNamespace, "vm", "")
+
+	// Add rules (adding a rule with an existing name replaces it)
+	_, err := s.AddPermitListRules(ctx, &paragliderpb.AddPermitListRulesRequest{Namespace: testNamespace, Resource: vm.Uri, Rules: []*paragliderpb.PermitListRule{getTestRule("rule1"), getTestRule("rule2")}})
+	require.NoError(t, err)
+	updated := getTestRule("rule1")
+	updated.DstPort = 443
+	_, err = s.AddPermitListRules(ctx, &paragliderpb.AddPermitListRulesRequest{Namespace: testNamespace, Resource: vm.Uri, Rules: []*paragliderpb.PermitListRule{updated}})
+	require.NoError(t, err)
+
+	resp, err := s.GetPermitList(ctx, &paragliderpb.GetPermitListRequest{Namespace: testNamespace, Resource: vm.Uri})
+	require.NoError(t, err)
+	require.Len(t, resp.Rules, 2)
+	assert.Equal(t, updated.String(), resp.Rules[0].String())
+	assert.Equal(t, getTestRule("rule2").String(), resp.Rules[1].String())
+
+	// Other namespaces cannot see the resource
+	_, err = s.GetPermitList(ctx, &paragliderpb.GetPermitListRequest{Namespace: otherNamespace, Resource: vm.Uri})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = s.AddPermitListRules(ctx, &paragliderpb.AddPermitListRulesRequest{Namespace: otherNamespace, Resource: vm.Uri, Rules: []*paragliderpb.PermitListRule{getTestRule("rule3")}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Invalid rules
+	invalid := getTestRule("rule3")
+	invalid.Targets = []string{"not-an-ip"}
+	_, err = s.AddPermitListRules(ctx, &paragliderpb.AddPermitListRulesRequest{Namespace: testNamespace, Resource: vm.Uri, Rules: []*paragliderpb.PermitListRule{invalid}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Delete rules (deleting missing rules is a no-op)
+	_, err = s.DeletePermitListRules(ctx, &paragliderpb.DeletePermitListRulesRequest{Namespace: testNamespace, Resource: vm.Uri, RuleNames: []string{"rule1", "missing"}})
+	require.NoError(t, err)
+	resp, err = s.GetPermitList(ctx, &paragliderpb.GetPermitListRequest{Namespace: testNamespace, Resource: vm.Uri})
+	require.NoError(t, err)
+	require.Len(t, resp.Rules, 1)
+	assert.Equal(t, "rule2", resp.Rules[0].Name)
+
+	resource, err := s.GetResource(ctx, &paragliderpb.GetResourceRequest{Namespace: testNamespace, Uri: vm.Uri})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), resource.Resource.RuleCount)
+}
+
+func TestPermitListQuirks(t *testing.T) {
+	ctx := context.Background()
+	settings := DefaultSettings(utils.IBM)
+	settings.Quirks.MaxRulesPerResource = 2
+	s := NewSimCloudPluginServer("", settings)
+	vm := createTestResource(t, s, testNamespace, "vm", "")
+
+	_, err := s.AddPermitListRules(ctx, &paragliderpb.AddPermitListRulesRequest{Namespace: testNamespace, Resource: vm.Uri, Rules: []*paragliderpb.PermitListRule{getTestRule("rule1"), getTestRule("rule2")}})
+	require.NoError(t, err)
+
+	// Replacing rules does not count against the limit
+	_, err = s.AddPermitListRules(ctx, &paragliderpb.AddPermitListRulesRequest{Namespace: testNamespace, Resource: vm.Uri, Rules: []*paragliderpb.PermitListRule{getTestRule("rule2")}})
+	require.NoError(t, err)
+	_, err = s.AddPermitListRules(ctx, &paragliderpb.AddPermitListRulesRequest{Namespace: testNamespace, Resource: vm.Uri, Rules: []*paragliderpb.PermitListRule{getTestRule("rule3")}})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	longName := getTestRule("")
+	for len(longName.Name) <= settings.Quirks.MaxRuleNameLength {
+		longName.Name += "a"
+	}
+	_, err = s.AddPermitListRules(ctx, &paragliderpb.AddPermitListRulesRequest{Namespace: testNamespace, Resource: vm.Uri, Rules: []*paragliderpb.PermitListRule{longName}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAddressSpaces(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, utils.GCP)
+	createTestResource(t, s, testNamespace, "vm1", "us-west1-a")
+	createTestResource(t, s, testNamespace, "vm2", "us-east1-b")
+	createTestResource(t, s, otherNamespace, "vm3", "us-west1-a")
+
+	resp, err := s.GetUsedAddressSpaces(ctx, &paragliderpb.GetUsedAddressSpacesRequest{Deployments: []*paragliderpb.ParagliderDeployment{
+		{Id: testDeployment, Namespace: testNamespace},
+		{Id: testDeployment, Namespace: otherNamespace},
+	}})
+	require.NoError(t, err)
+	require.Len(t, resp.AddressSpaceMappings, 2)
+	assert.Equal(t, []string{"10.0.0.0/16", "10.1.0.0/16"}, resp.AddressSpaceMappings[0].AddressSpaces)
+	assert.Equal(t, utils.GCP, resp.AddressSpaceMappings[0].Cloud)
+	assert.Equal(t, testDeployment, *resp.AddressSpaceMappings[0].Deployment)
+	assert.Equal(t, []string{"10.2.0.0/16"}, resp.AddressSpaceMappings[1].AddressSpaces)
+
+	// All subnets of the global network are reported
+	networkResp, err := s.GetNetworkAddressSpaces(ctx, &paragliderpb.GetNetworkAddressSpacesRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace}, AddressSpace: "10.1.0.0/16"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/16", "10.1.0.0/16"}, networkResp.AddressSpaces)
+
+	_, err = s.GetNetworkAddressSpaces(ctx, &paragliderpb.GetNetworkAddressSpacesRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace}, AddressSpace: "10.2.0.0/16"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestVpn(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, utils.AZURE)
+	deployment := &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace}
+
+	// Connections need a gateway
+	_, err := s.CreateVpnConnections(ctx, &paragliderpb.CreateVpnConnectionsRequest{Deployment: deployment, Cloud: utils.GCP, GatewayIpAddresses: []string{"1.1.1.1"}, BgpIpAddresses: []string{"169.254.21.2"}})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	gateway, err := s.CreateVpnGateway(ctx, &paragliderpb.CreateVpnGatewayRequest{Deployment: deployment, Cloud: utils.GCP, BgpPeeringIpAddresses: []string{"169.254.21.1"}})
+	require.NoError(t, err)
+	assert.Len(t, gateway.GatewayIpAddresses, 2)
+	assert.NotZero(t, gateway.Asn)
+
+	// The gateway is reused
+	again, err := s.CreateVpnGateway(ctx, &paragliderpb.CreateVpnGatewayRequest{Deployment: deployment, Cloud: utils.GCP})
+	require.NoError(t, err)
+	assert.Equal(t, gateway.GatewayIpAddresses, again.GatewayIpAddresses)
+
+	_, err = s.CreateVpnConnections(ctx, &paragliderpb.CreateVpnConnectionsRequest{Deployment: deployment, Cloud: utils.GCP, Asn: 64513, GatewayIpAddresses: []string{"1.1.1.1", "1.1.1.2"}, BgpIpAddresses: []string{"169.254.21.2", "169.254.22.2"}, SharedKey: "key"})
+	require.NoError(t, err)
+	assert.Len(t, s.gateways[getDeploymentKey(testDeployment, testNamespace)].connections, 2)
+
+	// BGP connections need a BGP IP for each gateway IP
+	_, err = s.CreateVpnConnections(ctx, &paragliderpb.CreateVpnConnectionsRequest{Deployment: deployment, Cloud: utils.GCP, GatewayIpAddresses: []string{"1.1.1.3"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	asns, err := s.GetUsedAsns(ctx, &paragliderpb.GetUsedAsnsRequest{Deployments: []*paragliderpb.ParagliderDeployment{deployment}})
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{gateway.Asn}, asns.Asns)
+
+	bgpIps, err := s.GetUsedBgpPeeringIpAddresses(ctx, &paragliderpb.GetUsedBgpPeeringIpAddressesRequest{Deployments: []*paragliderpb.ParagliderDeployment{deployment}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"169.254.21.1"}, bgpIps.IpAddresses)
+}
+
+func TestVpnWithoutBgp(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimCloudPluginServer("", DefaultSettings(utils.IBM))
+	deployment := &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace}
+	_, err := s.CreateVpnGateway(ctx, &paragliderpb.CreateVpnGatewayRequest{Deployment: deployment, Cloud: utils.AZURE})
+	require.NoError(t, err)
+
+	// Remote addresses are needed without BGP
+	_, err = s.CreateVpnConnections(ctx, &paragliderpb.CreateVpnConnectionsRequest{Deployment: deployment, Cloud: utils.AZURE, GatewayIpAddresses: []string{"1.1.1.1"}, BgpIpAddresses: []string{"169.254.21.2"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.CreateVpnConnections(ctx, &paragliderpb.CreateVpnConnectionsRequest{Deployment: deployment, Cloud: utils.AZURE, GatewayIpAddresses: []string{"1.1.1.1"}, RemoteAddresses: []string{"10.5.0.0/16"}})
+	require.NoError(t, err)
+}
+
+func TestFaults(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Errors", func(t *testing.T) {
+		s := NewSimCloudPluginServer("", DefaultSettings(DefaultCloud))
+		s.SetFaults(Faults{Errors: map[string]codes.Code{"CreateResource": codes.PermissionDenied}})
+		_, err := s.CreateResource(ctx, &paragliderpb.CreateResourceRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace}, Name: "vm"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+		// Other RPCs are unaffected
+		_, err = s.ListResources(ctx, &paragliderpb.ListResourcesRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace}})
+		require.NoError(t, err)
+	})
+
+	t.Run("ErrorRate", func(t *testing.T) {
+		settings := DefaultSettings(DefaultCloud)
+		settings.Faults.ErrorRate = 1
+		s := NewSimCloudPluginServer("", settings)
+		_, err := s.ListResources(ctx, &paragliderpb.ListResourcesRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace}})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("Latency", func(t *testing.T) {
+		s := NewSimCloudPluginServer("", DefaultSettings(DefaultCloud))
+		s.SetFaults(Faults{Latency: time.Second})
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := s.ListResources(timeoutCtx, &paragliderpb.ListResourcesRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace}})
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
+
+	t.Run("PartialFailure", func(t *testing.T) {
+		s := NewSimCloudPluginServer("", DefaultSettings(DefaultCloud))
+		vm := createTestResource(t, s, testNamespace, "vm", "")
+		s.SetFaults(Faults{PartialFailure: true})
+
+		_, err := s.AddPermitListRules(ctx, &paragliderpb.AddPermitListRulesRequest{Namespace: testNamespace, Resource: vm.Uri, Rules: []*paragliderpb.PermitListRule{getTestRule("rule1"), getTestRule("rule2"), getTestRule("rule3")}})
+		assert.Equal(t, codes.Internal, status.Code(err))
+
+		s.SetFaults(Faults{})
+		resp, err := s.GetPermitList(ctx, &paragliderpb.GetPermitListRequest{Namespace: testNamespace, Resource: vm.Uri})
+		require.NoError(t, err)
+		assert.Len(t, resp.Rules, 2)
+
+		// Resources are created before failing
+		s.SetFaults(Faults{PartialFailure: true})
+		_, err = s.CreateResource(ctx, &paragliderpb.CreateResourceRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace}, Name: "vm2"})
+		assert.Equal(t, codes.Internal, status.Code(err))
+		s.SetFaults(Faults{})
+		list, err := s.ListResources(ctx, &paragliderpb.ListResourcesRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace}})
+		require.NoError(t, err)
+		assert.Len(t, list.Resources, 2)
+	})
+}
+
+func TestSetup(t *testing.T) {
+	addr, err := Setup(0, NewSimCloudPluginServer("", DefaultSettings(DefaultCloud)))
+	require.NoError(t, err)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := paragliderpb.NewCloudPluginClient(conn)
+
+	deployment := &paragliderpb.ParagliderDeployment{Id: testDeployment, Namespace: testNamespace}
+	created, err := client.CreateResource(context.Background(), &paragliderpb.CreateResourceRequest{Deployment: deployment, Name: "vm"})
+	require.NoError(t, err)
+	info, err := client.GetResourceInfo(context.Background(), &paragliderpb.GetResourceInfoRequest{Namespace: testNamespace, Uri: created.Uri})
+	require.NoError(t, err)
+	assert.Equal(t, created.Ip, info.Ip)
+	assert.Equal(t, []string{created.Ip}, info.Ips)
+	assert.Equal(t, ResourceState, info.State)
+}
@@ -23,8 +23,12 @@ import (
 	"net"
 	"strings"
 
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
 	"github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -39,6 +43,8 @@ var (
 	ResolvedTagIp       = "1.2.3.4"
 	SubscriberCloudName = "cloudName"
 	SubscriberNamespace = "default"
+	TagPolicyName       = "policyRule"
+	TagPolicyRule, _    = proto.Marshal(&paragliderpb.PermitListRule{Name: TagPolicyName, Tags: []string{ResolvedTagIp}, SrcPort: 1, DstPort: 1, Protocol: 1, Direction: paragliderpb.Direction_INBOUND})
 )
 
 type FakeTagServiceServer struct {
@@ -55,18 +61,40 @@ func (s *FakeTagServiceServer) GetTag(c context.Context, req *tagservicepb.GetTa
 	if strings.HasSuffix(req.TagName, ValidLastLevelTagName) {
 		return &tagservicepb.GetTagResponse{Tag: &tagservicepb.TagMapping{Name: req.TagName, Uri: &TagUri, Ip: &TagIp}}, nil
 	}
-	return nil, fmt.Errorf("GetTag: Invalid tag name")
+	return nil, status.Errorf(codes.NotFound, "GetTag: Invalid tag name")
+}
+
+func (s *FakeTagServiceServer) ListTags(c context.Context, req *tagservicepb.ListTagsRequest) (*tagservicepb.ListTagsResponse, error) {
+	tags := []*tagservicepb.TagMapping{
+		{Name: ValidParentTagName, ChildTags: []string{ValidLastLevelTagName}},
+		{Name: ValidLastLevelTagName, Uri: &TagUri, Ip: &TagIp},
+	}
+	if req.Limit > 0 && int(req.Limit) < len(tags) {
+		return &tagservicepb.ListTagsResponse{Tags: tags[:req.Limit], NextPageToken: "next"}, nil
+	}
+	return &tagservicepb.ListTagsResponse{Tags: tags}, nil
 }
 
 func (s *FakeTagServiceServer) ResolveTag(c context.Context, req *tagservicepb.ResolveTagRequest) (*tagservicepb.ResolveTagResponse, error) {
 	if strings.HasPrefix(req.TagName, ValidTagName) || strings.HasSuffix(req.TagName, ValidTagName) {
 		newUri := "uri/" + req.TagName
-		return &tagservicepb.ResolveTagResponse{Tags: []*tagservicepb.TagMapping{{Name: req.TagName, Uri: &newUri, Ip: &ResolvedTagIp}}}, nil
+		resp := &tagservicepb.ResolveTagResponse{Tags: []*tagservicepb.TagMapping{{Name: req.TagName, Uri: &newUri, Ip: &ResolvedTagIp}}}
+		if req.IncludePaths {
+			resp.Paths = []*tagservicepb.TagPath{{Tags: []string{req.TagName}}}
+		}
+		return resp, nil
 	}
-	return nil, fmt.Errorf("ResolveTag: Invalid tag name")
+	return nil, status.Errorf(codes.NotFound, "ResolveTag: Invalid tag name")
 }
 
 func (s *FakeTagServiceServer) SetTag(c context.Context, tagMapping *tagservicepb.SetTagRequest) (*tagservicepb.SetTagResponse, error) {
+	if len(tagMapping.Tag.Labels) > 0 {
+		change := &tagservicepb.SelectorMembershipChange{SelectorTag: ValidTagName + "Selector", Member: tagMapping.Tag.Name, Joined: true}
+		return &tagservicepb.SetTagResponse{SelectorChanges: []*tagservicepb.SelectorMembershipChange{change}}, nil
+	}
+	if tagMapping.Tag.Ip != nil {
+		return &tagservicepb.SetTagResponse{UpdatedAncestors: []string{ValidTagName + "Parent"}, Version: 1}, nil
+	}
 	return &tagservicepb.SetTagResponse{}, nil
 }
 
@@ -74,14 +102,14 @@ func (s *FakeTagServiceServer) DeleteTag(c context.Context, req *tagservicepb.De
 	if strings.HasPrefix(req.TagName, ValidTagName) {
 		return &tagservicepb.DeleteTagResponse{}, nil
 	}
-	return &tagservicepb.DeleteTagResponse{}, fmt.Errorf("tag does not exist")
+	return &tagservicepb.DeleteTagResponse{}, status.Errorf(codes.NotFound, "tag does not exist")
 }
 
 func (s *FakeTagServiceServer) DeleteTagMember(c context.Context, req *tagservicepb.DeleteTagMemberRequest) (*tagservicepb.DeleteTagMemberResponse, error) {
 	if strings.HasPrefix(req.ParentTag, ValidTagName) {
 		return &tagservicepb.DeleteTagMemberResponse{}, nil
 	}
-	return &tagservicepb.DeleteTagMemberResponse{}, fmt.Errorf("parentTag does not exist")
+	return &tagservicepb.DeleteTagMemberResponse{}, status.Errorf(codes.NotFound, "parentTag does not exist")
 }
 
 func (s *FakeTagServiceServer) Subscribe(c context.Context, req *tagservicepb.SubscribeRequest) (*tagservicepb.SubscribeResponse, error) {
@@ -92,14 +120,51 @@ func (s *FakeTagServiceServer) Unsubscribe(c context.Context, req *tagservicepb.
 	if strings.HasPrefix(req.Subscription.TagName, ValidTagName) {
 		return &tagservicepb.UnsubscribeResponse{}, nil
 	}
-	return &tagservicepb.UnsubscribeResponse{}, fmt.Errorf("tag has no subscribers")
+	return &tagservicepb.UnsubscribeResponse{}, status.Errorf(codes.NotFound, "tag has no subscribers")
 }
 
 func (s *FakeTagServiceServer) GetSubscribers(c context.Context, req *tagservicepb.GetSubscribersRequest) (*tagservicepb.GetSubscribersResponse, error) {
 	if strings.HasPrefix(req.TagName, ValidTagName) {
 		return &tagservicepb.GetSubscribersResponse{Subscribers: []string{SubscriberNamespace + ">" + SubscriberCloudName + ">uri"}}, nil
 	}
-	return nil, fmt.Errorf("tag does not exist")
+	return nil, status.Errorf(codes.NotFound, "tag does not exist")
+}
+
+func (s *FakeTagServiceServer) GetSubscriptions(c context.Context, req *tagservicepb.GetSubscriptionsRequest) (*tagservicepb.GetSubscriptionsResponse, error) {
+	return &tagservicepb.GetSubscriptionsResponse{TagNames: []string{ValidTagName + "1", ValidTagName + "2"}}, nil
+}
+
+func (s *FakeTagServiceServer) Watch(req *tagservicepb.WatchRequest, stream tagservicepb.TagService_WatchServer) error {
+	if strings.HasPrefix(req.TagName, ValidTagName) {
+		newUri := "uri/" + req.TagName
+		event := &tagservicepb.TagEvent{
+			Type:         tagservicepb.TagEvent_MEMBERSHIP_CHANGED,
+			WatchedTag:   req.TagName,
+			ChangedTag:   req.TagName,
+			ResolvedTags: []*tagservicepb.TagMapping{{Name: req.TagName, Uri: &newUri, Ip: &ResolvedTagIp}},
+		}
+		return stream.Send(event)
+	}
+	return status.Errorf(codes.NotFound, "Watch: Invalid tag name")
+}
+
+func (s *FakeTagServiceServer) SetTagPolicies(c context.Context, req *tagservicepb.SetTagPoliciesRequest) (*tagservicepb.SetTagPoliciesResponse, error) {
+	return &tagservicepb.SetTagPoliciesResponse{}, nil
+}
+
+func (s *FakeTagServiceServer) GetTagPolicies(c context.Context, req *tagservicepb.GetTagPoliciesRequest) (*tagservicepb.GetTagPoliciesResponse, error) {
+	if strings.HasPrefix(req.TagName, ValidTagName) || strings.HasSuffix(req.TagName, ValidTagName) {
+		return &tagservicepb.GetTagPoliciesResponse{Policies: []*tagservicepb.TagPolicy{{Name: TagPolicyName, Rule: TagPolicyRule}}}, nil
+	}
+	return &tagservicepb.GetTagPoliciesResponse{}, nil
+}
+
+func (s *FakeTagServiceServer) DeleteTagPolicies(c context.Context, req *tagservicepb.DeleteTagPoliciesRequest) (*tagservicepb.DeleteTagPoliciesResponse, error) {
+	return &tagservicepb.DeleteTagPoliciesResponse{}, nil
+}
+
+func (s *FakeTagServiceServer) GetAncestors(c context.Context, req *tagservicepb.GetAncestorsRequest) (*tagservicepb.GetAncestorsResponse, error) {
+	return &tagservicepb.GetAncestorsResponse{}, nil
 }
 
 func NewFakeTagServer() *FakeTagServiceServer {
@@ -0,0 +1,70 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"encoding/json"
+	"testing"
+
+	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/plugintest"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConformance(t *testing.T) {
+	plugintest.Run(t, utils.GCP, setupConformance)
+}
+
+func setupConformance(t *testing.T, orchestratorAddr string) *plugintest.Target {
+	fakeServerState := &fakeServerState{
+		instance: getFakeInstance(true),
+		network: &computepb.Network{
+			Name:        proto.String(getVpcName(fakeNamespace)),
+			Subnetworks: []string{fakeSubnetId},
+		},
+		subnetwork: &computepb.Subnetwork{
+			Name:        proto.String(fakeSubnetName),
+			IpCidrRange: proto.String("10.1.1.0/24"),
+		},
+	}
+	fakeServer, _, fakeClients, fakeGRPCServer := setup(t, fakeServerState)
+	t.Cleanup(func() { teardown(fakeServer, fakeClients, fakeGRPCServer) })
+
+	description, err := json.Marshal(&computepb.InsertInstanceRequest{
+		Project:          fakeProject,
+		Zone:             fakeZone,
+		InstanceResource: getFakeInstance(false),
+	})
+	require.NoError(t, err)
+
+	deployment := &paragliderpb.ParagliderDeployment{Id: "projects/" + fakeProject, Namespace: fakeNamespace}
+	return &plugintest.Target{
+		Server: &GCPPluginServer{
+			orchestratorServerAddr: orchestratorAddr,
+			clientOptions:          fakeClients.clientOptions,
+			clusterClientOptions:   fakeClients.clusterClientOptions,
+		},
+		Deployment:    deployment,
+		Resource:      fakeResourceId,
+		CreateRequest: &paragliderpb.CreateResourceRequest{Deployment: deployment, Name: fakeInstanceName, Description: description},
+	}
+}
@@ -18,6 +18,7 @@ package gcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -26,10 +27,15 @@ import (
 	compute "cloud.google.com/go/compute/apiv1"
 	computepb "cloud.google.com/go/compute/apiv1/computepb"
 	container "cloud.google.com/go/container/apiv1"
+	containerpb "cloud.google.com/go/container/apiv1/containerpb"
 	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
 	utils "github.com/paraglider-project/paraglider/pkg/utils"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
@@ -37,22 +43,25 @@ import (
 type GCPPluginServer struct {
 	paragliderpb.UnimplementedCloudPluginServer
 	orchestratorServerAddr string
+	// Options used to create the compute clients and the cluster manager client (only set by tests)
+	clientOptions        []option.ClientOption
+	clusterClientOptions []option.ClientOption
 }
 
 func (s *GCPPluginServer) GetPermitList(ctx context.Context, req *paragliderpb.GetPermitListRequest) (*paragliderpb.GetPermitListResponse, error) {
-	firewallsClient, err := compute.NewFirewallsRESTClient(ctx)
+	firewallsClient, err := compute.NewFirewallsRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewFirewallsRESTClient: %w", err)
 	}
 	defer firewallsClient.Close()
 
-	instancesClient, err := compute.NewInstancesRESTClient(ctx)
+	instancesClient, err := compute.NewInstancesRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewInstancesRESTClient: %w", err)
 	}
 	defer instancesClient.Close()
 
-	clustersClient, err := container.NewClusterManagerClient(ctx)
+	clustersClient, err := container.NewClusterManagerClient(ctx, s.clusterClientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewClusterManagerClient: %w", err)
 	}
@@ -74,7 +83,7 @@ func (s *GCPPluginServer) _GetPermitList(ctx context.Context, req *paragliderpb.
 	}
 
 	// Get firewalls for the resource
-	firewalls, err := getFirewallRules(ctx, firewallsClient, resourceInfo.Project, *resourceID)
+	firewalls, err := getPermitListFirewalls(ctx, firewallsClient, req.Namespace, resourceInfo.Project, *resourceID)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get firewalls: %w", err)
 	}
@@ -82,43 +91,188 @@ func (s *GCPPluginServer) _GetPermitList(ctx context.Context, req *paragliderpb.
 	permitListRules := []*paragliderpb.PermitListRule{}
 
 	for _, firewall := range firewalls {
-		// Exclude default deny all egress from being included since it applies to every VM
-		if isParagliderPermitListRule(req.Namespace, firewall) && *firewall.Name != getDenyAllIngressFirewallName(req.Namespace) {
-			rule, err := firewallRuleToParagliderRule(req.Namespace, firewall)
-			if err != nil {
-				return nil, fmt.Errorf("could not convert firewall rule to permit list rule: %w", err)
-			}
-			permitListRules = append(permitListRules, rule)
+		rule, err := firewallRuleToParagliderRule(req.Namespace, firewall)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert firewall rule to permit list rule: %w", err)
 		}
+		permitListRules = append(permitListRules, rule)
 	}
 
 	return &paragliderpb.GetPermitListResponse{Rules: permitListRules}, nil
 }
 
+// GetResourceInfo returns the current private IP (or pod CIDR for clusters) and status of the resource
+func (s *GCPPluginServer) GetResourceInfo(ctx context.Context, req *paragliderpb.GetResourceInfoRequest) (*paragliderpb.GetResourceInfoResponse, error) {
+	instancesClient, err := compute.NewInstancesRESTClient(ctx, s.clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("NewInstancesRESTClient: %w", err)
+	}
+	defer instancesClient.Close()
+
+	clustersClient, err := container.NewClusterManagerClient(ctx, s.clusterClientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("NewClusterManagerClient: %w", err)
+	}
+	defer clustersClient.Close()
+
+	return s._GetResourceInfo(ctx, req, instancesClient, clustersClient)
+}
+
+func (s *GCPPluginServer) _GetResourceInfo(ctx context.Context, req *paragliderpb.GetResourceInfoRequest, instancesClient *compute.InstancesClient, clustersClient *container.ClusterManagerClient) (*paragliderpb.GetResourceInfoResponse, error) {
+	resourceInfo, err := parseResourceUrl(req.Uri)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse resource URL: %w", err)
+	}
+	resourceInfo.Namespace = req.Namespace
+
+	netInfo, err := getNamespacedNetworkInfo(ctx, instancesClient, clustersClient, resourceInfo)
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.GetResourceInfoResponse{Uri: req.Uri, Ip: netInfo.Address, Ips: netInfo.Addresses, State: netInfo.State}, nil
+}
+
+// GetResource returns the inventory entry of a Paraglider-managed resource
+func (s *GCPPluginServer) GetResource(ctx context.Context, req *paragliderpb.GetResourceRequest) (*paragliderpb.GetResourceResponse, error) {
+	firewallsClient, err := compute.NewFirewallsRESTClient(ctx, s.clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("NewFirewallsRESTClient: %w", err)
+	}
+	defer firewallsClient.Close()
+
+	instancesClient, err := compute.NewInstancesRESTClient(ctx, s.clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("NewInstancesRESTClient: %w", err)
+	}
+	defer instancesClient.Close()
+
+	clustersClient, err := container.NewClusterManagerClient(ctx, s.clusterClientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("NewClusterManagerClient: %w", err)
+	}
+	defer clustersClient.Close()
+
+	return s._GetResource(ctx, req, firewallsClient, instancesClient, clustersClient)
+}
+
+func (s *GCPPluginServer) _GetResource(ctx context.Context, req *paragliderpb.GetResourceRequest, firewallsClient *compute.FirewallsClient, instancesClient *compute.InstancesClient, clustersClient *container.ClusterManagerClient) (*paragliderpb.GetResourceResponse, error) {
+	resourceInfo, err := parseResourceUrl(req.Uri)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "unable to parse resource URL: %v", err)
+	}
+	resourceInfo.Namespace = req.Namespace
+
+	netInfo, err := getNamespacedNetworkInfo(ctx, instancesClient, clustersClient, resourceInfo)
+	if err != nil {
+		return nil, err
+	}
+	firewalls, err := getPermitListFirewalls(ctx, firewallsClient, req.Namespace, resourceInfo.Project, netInfo.ResourceID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get firewalls: %w", err)
+	}
+	return &paragliderpb.GetResourceResponse{Resource: getResource(req.Uri, resourceInfo, netInfo, len(firewalls))}, nil
+}
+
+// ListResources returns the inventory of Paraglider-managed instances and clusters in the deployment's namespace
+func (s *GCPPluginServer) ListResources(ctx context.Context, req *paragliderpb.ListResourcesRequest) (*paragliderpb.ListResourcesResponse, error) {
+	firewallsClient, err := compute.NewFirewallsRESTClient(ctx, s.clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("NewFirewallsRESTClient: %w", err)
+	}
+	defer firewallsClient.Close()
+
+	instancesClient, err := compute.NewInstancesRESTClient(ctx, s.clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("NewInstancesRESTClient: %w", err)
+	}
+	defer instancesClient.Close()
+
+	clustersClient, err := container.NewClusterManagerClient(ctx, s.clusterClientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("NewClusterManagerClient: %w", err)
+	}
+	defer clustersClient.Close()
+
+	return s._ListResources(ctx, req, firewallsClient, instancesClient, clustersClient)
+}
+
+func (s *GCPPluginServer) _ListResources(ctx context.Context, req *paragliderpb.ListResourcesRequest, firewallsClient *compute.FirewallsClient, instancesClient *compute.InstancesClient, clustersClient *container.ClusterManagerClient) (*paragliderpb.ListResourcesResponse, error) {
+	project := parseUrl(req.Deployment.Id)["projects"]
+	namespace := req.Deployment.Namespace
+	resources := []*paragliderpb.Resource{}
+
+	// Resources are in the namespace if they are attached to its VPC
+	addResource := func(uri string, resourceInfo *resourceInfo, netInfo *resourceNetworkInfo) error {
+		if !resourceIsInNamespace(netInfo.NetworkName, namespace) {
+			return nil
+		}
+		firewalls, err := getPermitListFirewalls(ctx, firewallsClient, namespace, project, netInfo.ResourceID)
+		if err != nil {
+			return fmt.Errorf("unable to get firewalls: %w", err)
+		}
+		resources = append(resources, getResource(uri, resourceInfo, netInfo, len(firewalls)))
+		return nil
+	}
+
+	instancesIterator := instancesClient.AggregatedList(ctx, &computepb.AggregatedListInstancesRequest{Project: project})
+	for {
+		pair, err := instancesIterator.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to list instances: %w", err)
+		}
+		for _, instance := range pair.Value.Instances {
+			if len(instance.NetworkInterfaces) == 0 {
+				continue
+			}
+			zone := getLastUrlSegment(instance.GetZone())
+			resourceInfo := &resourceInfo{Project: project, Zone: zone, Region: getRegionFromZone(zone), Name: instance.GetName(), ResourceType: instanceTypeName}
+			if err := addResource(getInstanceUrl(project, zone, instance.GetName()), resourceInfo, getInstanceNetworkInfo(instance)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	clustersResp, err := clustersClient.ListClusters(ctx, &containerpb.ListClustersRequest{Parent: fmt.Sprintf("projects/%s/locations/-", project)})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list clusters: %w", err)
+	}
+	for _, cluster := range clustersResp.Clusters {
+		resourceInfo := &resourceInfo{Project: project, Zone: cluster.Location, Region: getRegionFromZone(cluster.Location), Name: cluster.Name, ResourceType: clusterTypeName}
+		if err := addResource(getClusterUrl(project, cluster.Location, cluster.Name), resourceInfo, getClusterNetworkInfo(project, resourceInfo.Region, cluster)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &paragliderpb.ListResourcesResponse{Resources: resources}, nil
+}
+
 func (s *GCPPluginServer) AddPermitListRules(ctx context.Context, req *paragliderpb.AddPermitListRulesRequest) (*paragliderpb.AddPermitListRulesResponse, error) {
-	firewallsClient, err := compute.NewFirewallsRESTClient(ctx)
+	firewallsClient, err := compute.NewFirewallsRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewFirewallsRESTClient: %w", err)
 	}
 	defer firewallsClient.Close()
-	instancesClient, err := compute.NewInstancesRESTClient(ctx)
+	instancesClient, err := compute.NewInstancesRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewInstancesRESTClient: %w", err)
 	}
 	defer instancesClient.Close()
 
-	clustersClient, err := container.NewClusterManagerClient(ctx)
+	clustersClient, err := container.NewClusterManagerClient(ctx, s.clusterClientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewClusterManagerClient: %w", err)
 	}
 	defer clustersClient.Close()
 
-	subnetworksClient, err := compute.NewSubnetworksRESTClient(ctx)
+	subnetworksClient, err := compute.NewSubnetworksRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewSubnetworksRESTClient: %w", err)
 	}
 	defer subnetworksClient.Close()
-	networksClient, err := compute.NewNetworksRESTClient(ctx)
+	networksClient, err := compute.NewNetworksRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewNetworksRESTClient: %w", err)
 	}
@@ -260,19 +414,19 @@ func (s *GCPPluginServer) _AddPermitListRules(ctx context.Context, req *paraglid
 }
 
 func (s *GCPPluginServer) DeletePermitListRules(ctx context.Context, req *paragliderpb.DeletePermitListRulesRequest) (*paragliderpb.DeletePermitListRulesResponse, error) {
-	firewallsClient, err := compute.NewFirewallsRESTClient(ctx)
+	firewallsClient, err := compute.NewFirewallsRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewFirewallsRESTClient: %w", err)
 	}
 	defer firewallsClient.Close()
 
-	instancesClient, err := compute.NewInstancesRESTClient(ctx)
+	instancesClient, err := compute.NewInstancesRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewInstancesRESTClient: %w", err)
 	}
 	defer instancesClient.Close()
 
-	clustersClient, err := container.NewClusterManagerClient(ctx)
+	clustersClient, err := container.NewClusterManagerClient(ctx, s.clusterClientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewClusterManagerClient: %w", err)
 	}
@@ -312,27 +466,27 @@ func (s *GCPPluginServer) _DeletePermitListRules(ctx context.Context, req *parag
 }
 
 func (s *GCPPluginServer) CreateResource(ctx context.Context, resourceDescription *paragliderpb.CreateResourceRequest) (*paragliderpb.CreateResourceResponse, error) {
-	instancesClient, err := compute.NewInstancesRESTClient(ctx)
+	instancesClient, err := compute.NewInstancesRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewInstancesRESTClient: %w", err)
 	}
 	defer instancesClient.Close()
-	networksClient, err := compute.NewNetworksRESTClient(ctx)
+	networksClient, err := compute.NewNetworksRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewNetworksRESTClient: %w", err)
 	}
 	defer networksClient.Close()
-	subnetworksClient, err := compute.NewSubnetworksRESTClient(ctx)
+	subnetworksClient, err := compute.NewSubnetworksRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewSubnetworksRESTClient: %w", err)
 	}
 	defer subnetworksClient.Close()
-	firewallsClient, err := compute.NewFirewallsRESTClient(ctx)
+	firewallsClient, err := compute.NewFirewallsRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewFirewallsRESTClient: %w", err)
 	}
 	defer firewallsClient.Close()
-	clustersClient, err := container.NewClusterManagerClient(ctx)
+	clustersClient, err := container.NewClusterManagerClient(ctx, s.clusterClientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewClusterManagerClient: %w", err)
 	}
@@ -344,6 +498,12 @@ func (s *GCPPluginServer) CreateResource(ctx context.Context, resourceDescriptio
 func (s *GCPPluginServer) _CreateResource(ctx context.Context, resourceDescription *paragliderpb.CreateResourceRequest, instancesClient *compute.InstancesClient, networksClient *compute.NetworksClient, subnetworksClient *compute.SubnetworksClient, firewallsClient *compute.FirewallsClient, clustersClient *container.ClusterManagerClient) (*paragliderpb.CreateResourceResponse, error) {
 	project := parseUrl(resourceDescription.Deployment.Id)["projects"]
 
+	// Translate cloud-agnostic descriptions into GCP ones
+	resourceDescription, err := translateResourceDescription(resourceDescription)
+	if err != nil {
+		return nil, err
+	}
+
 	// Read and validate user-provided description
 	resourceInfo, err := IsValidResource(ctx, resourceDescription)
 	if err != nil {
@@ -482,13 +642,13 @@ func (s *GCPPluginServer) _CreateResource(ctx context.Context, resourceDescripti
 }
 
 func (s *GCPPluginServer) GetUsedAddressSpaces(ctx context.Context, req *paragliderpb.GetUsedAddressSpacesRequest) (*paragliderpb.GetUsedAddressSpacesResponse, error) {
-	networksClient, err := compute.NewNetworksRESTClient(ctx)
+	networksClient, err := compute.NewNetworksRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewNetworksRESTClient: %w", err)
 	}
 	defer networksClient.Close()
 
-	subnetworksClient, err := compute.NewSubnetworksRESTClient(ctx)
+	subnetworksClient, err := compute.NewSubnetworksRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewSubnetworksRESTClient: %w", err)
 	}
@@ -544,7 +704,7 @@ func (s *GCPPluginServer) _GetUsedAddressSpaces(ctx context.Context, req *paragl
 }
 
 func (s *GCPPluginServer) GetUsedAsns(ctx context.Context, req *paragliderpb.GetUsedAsnsRequest) (*paragliderpb.GetUsedAsnsResponse, error) {
-	routersClient, err := compute.NewRoutersRESTClient(ctx)
+	routersClient, err := compute.NewRoutersRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewRoutersRESTClient: %w", err)
 	}
@@ -575,7 +735,7 @@ func (s *GCPPluginServer) _GetUsedAsns(ctx context.Context, req *paragliderpb.Ge
 }
 
 func (s *GCPPluginServer) GetUsedBgpPeeringIpAddresses(ctx context.Context, req *paragliderpb.GetUsedBgpPeeringIpAddressesRequest) (*paragliderpb.GetUsedBgpPeeringIpAddressesResponse, error) {
-	routersClient, err := compute.NewRoutersRESTClient(ctx)
+	routersClient, err := compute.NewRoutersRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewRoutersRESTClient: %w", err)
 	}
@@ -608,12 +768,12 @@ func (s *GCPPluginServer) _GetUsedBgpPeeringIpAddresses(ctx context.Context, req
 }
 
 func (s *GCPPluginServer) CreateVpnGateway(ctx context.Context, req *paragliderpb.CreateVpnGatewayRequest) (*paragliderpb.CreateVpnGatewayResponse, error) {
-	vpnGatewaysClient, err := compute.NewVpnGatewaysRESTClient(ctx)
+	vpnGatewaysClient, err := compute.NewVpnGatewaysRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewVpnGatewaysRESTClient: %w", err)
 	}
 	defer vpnGatewaysClient.Close()
-	routersClient, err := compute.NewRoutersRESTClient(ctx)
+	routersClient, err := compute.NewRoutersRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewRoutersRESTClient: %w", err)
 	}
@@ -744,17 +904,17 @@ func (s *GCPPluginServer) _CreateVpnGateway(ctx context.Context, req *paraglider
 }
 
 func (s *GCPPluginServer) CreateVpnConnections(ctx context.Context, req *paragliderpb.CreateVpnConnectionsRequest) (*paragliderpb.CreateVpnConnectionsResponse, error) {
-	externalVpnGatewaysClient, err := compute.NewExternalVpnGatewaysRESTClient(ctx)
+	externalVpnGatewaysClient, err := compute.NewExternalVpnGatewaysRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewExternalVpnGatewaysClient: %w", err)
 	}
 	defer externalVpnGatewaysClient.Close()
-	vpnTunnelsClient, err := compute.NewVpnTunnelsRESTClient(ctx)
+	vpnTunnelsClient, err := compute.NewVpnTunnelsRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewVpnTunnelsRESTClient: %w", err)
 	}
 	defer vpnTunnelsClient.Close()
-	routersClient, err := compute.NewRoutersRESTClient(ctx)
+	routersClient, err := compute.NewRoutersRESTClient(ctx, s.clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("NewRoutersRESTClient: %w", err)
 	}
@@ -868,7 +1028,7 @@ func (s *GCPPluginServer) _CreateVpnConnections(ctx context.Context, req *paragl
 
 // GetNetworkAddressSpaces returns the address spaces in the virtual network containing the provided address space
 func (s *GCPPluginServer) GetNetworkAddressSpaces(ctx context.Context, req *paragliderpb.GetNetworkAddressSpacesRequest) (*paragliderpb.GetNetworkAddressSpacesResponse, error) {
-	return nil, fmt.Errorf("GetNetworkAddressSpaces is currently not implemented by GCP, implying plugin does not support BGP disabled VPN connections")
+	return nil, status.Errorf(codes.Unimplemented, "GetNetworkAddressSpaces is currently not implemented by GCP, implying plugin does not support BGP disabled VPN connections")
 }
 
 func Setup(port int, orchestratorServerAddr string) *GCPPluginServer {
@@ -876,7 +1036,7 @@ func Setup(port int, orchestratorServerAddr string) *GCPPluginServer {
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to listen: %v", err)
 	}
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(utils.StatusErrorInterceptor(utils.GCP, getErrorStatusCode)))
 	gcpServer := &GCPPluginServer{}
 	gcpServer.orchestratorServerAddr = orchestratorServerAddr
 	paragliderpb.RegisterCloudPluginServer(grpcServer, gcpServer)
@@ -28,6 +28,7 @@ import (
 	fake "github.com/paraglider-project/paraglider/pkg/fake/orchestrator/rpc"
 	"github.com/paraglider-project/paraglider/pkg/orchestrator"
 	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/resourcespec"
 	utils "github.com/paraglider-project/paraglider/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -69,6 +70,98 @@ func TestGetPermitList(t *testing.T) {
 	assert.ElementsMatch(t, responseExpected.Rules, responseActual.Rules)
 }
 
+func TestGetResourceInfo(t *testing.T) {
+	instance := getFakeInstance(true)
+	instance.Status = proto.String("RUNNING")
+	fakeServer, ctx, fakeClients, fakeGRPCServer := setup(t, &fakeServerState{instance: instance})
+	defer teardown(fakeServer, fakeClients, fakeGRPCServer)
+
+	s := &GCPPluginServer{}
+	request := &paragliderpb.GetResourceInfoRequest{Uri: fakeResourceId, Namespace: fakeNamespace}
+
+	resp, err := s._GetResourceInfo(ctx, request, fakeClients.instancesClient, fakeClients.clusterClient)
+	require.NoError(t, err)
+	assert.Equal(t, fakeResourceId, resp.Uri)
+	assert.Equal(t, "10.1.1.1", resp.Ip)
+	assert.Equal(t, []string{"10.1.1.1"}, resp.Ips)
+	assert.Equal(t, "RUNNING", resp.State)
+
+	// Addresses of secondary network interfaces and IPv6 addresses are reported after the primary IP
+	instance.NetworkInterfaces[0].Ipv6Address = proto.String("fd20::1")
+	instance.NetworkInterfaces = append(instance.NetworkInterfaces, &computepb.NetworkInterface{NetworkIP: proto.String("10.2.1.1")})
+	resp, err = s._GetResourceInfo(ctx, request, fakeClients.instancesClient, fakeClients.clusterClient)
+	require.NoError(t, err)
+	assert.Equal(t, "10.1.1.1", resp.Ip)
+	assert.Equal(t, []string{"10.1.1.1", "fd20::1", "10.2.1.1"}, resp.Ips)
+
+	request.Namespace = "wrongnamespace"
+	resp, err = s._GetResourceInfo(ctx, request, fakeClients.instancesClient, fakeClients.clusterClient)
+	require.Error(t, err)
+	require.Nil(t, resp)
+}
+
+func TestGetResource(t *testing.T) {
+	instance := getFakeInstance(true)
+	instance.Status = proto.String("RUNNING")
+	fakeServerState := &fakeServerState{
+		instance:    instance,
+		firewallMap: map[string]*computepb.Firewall{*fakeFirewallRule1.Name: fakeFirewallRule1, *fakeFirewallRule2.Name: fakeFirewallRule2},
+	}
+	fakeServer, ctx, fakeClients, fakeGRPCServer := setup(t, fakeServerState)
+	defer teardown(fakeServer, fakeClients, fakeGRPCServer)
+
+	s := &GCPPluginServer{}
+	request := &paragliderpb.GetResourceRequest{Uri: fakeResourceId, Namespace: fakeNamespace}
+
+	resp, err := s._GetResource(ctx, request, fakeClients.firewallsClient, fakeClients.instancesClient, fakeClients.clusterClient)
+	require.NoError(t, err)
+	expected := &paragliderpb.Resource{
+		Name:      fakeInstanceName,
+		Uri:       fakeResourceId,
+		Ip:        "10.1.1.1",
+		Region:    fakeRegion,
+		Network:   getVpcName(fakeNamespace),
+		Subnet:    fakeSubnetName,
+		State:     "RUNNING",
+		RuleCount: 2,
+	}
+	assert.True(t, proto.Equal(expected, resp.Resource))
+
+	request.Namespace = "wrongnamespace"
+	resp, err = s._GetResource(ctx, request, fakeClients.firewallsClient, fakeClients.instancesClient, fakeClients.clusterClient)
+	require.Error(t, err)
+	require.Nil(t, resp)
+}
+
+func TestListResources(t *testing.T) {
+	instance := getFakeInstance(true)
+	instance.Zone = proto.String(computeUrlPrefix + "projects/" + fakeProject + urlZone)
+	fakeServerState := &fakeServerState{
+		instance:    instance,
+		firewallMap: map[string]*computepb.Firewall{*fakeFirewallRule1.Name: fakeFirewallRule1},
+	}
+	fakeServer, ctx, fakeClients, fakeGRPCServer := setup(t, fakeServerState)
+	defer teardown(fakeServer, fakeClients, fakeGRPCServer)
+
+	s := &GCPPluginServer{}
+	request := &paragliderpb.ListResourcesRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: "projects/" + fakeProject, Namespace: fakeNamespace}}
+
+	resp, err := s._ListResources(ctx, request, fakeClients.firewallsClient, fakeClients.instancesClient, fakeClients.clusterClient)
+	require.NoError(t, err)
+	require.Len(t, resp.Resources, 2)
+	assert.Equal(t, fakeResourceId, resp.Resources[0].Uri)
+	assert.Equal(t, fakeSubnetName, resp.Resources[0].Subnet)
+	assert.Equal(t, int32(1), resp.Resources[0].RuleCount)
+	assert.Equal(t, getClusterUrl(fakeProject, fakeZone, fakeClusterName), resp.Resources[1].Uri)
+	assert.Equal(t, fakeRegion, resp.Resources[1].Region)
+
+	// Resources of other namespaces are not listed
+	request.Deployment.Namespace = "othernamespace"
+	resp, err = s._ListResources(ctx, request, fakeClients.firewallsClient, fakeClients.instancesClient, fakeClients.clusterClient)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Resources)
+}
+
 func TestGetPermitListMissingInstance(t *testing.T) {
 	fakeServer, ctx, fakeClients, fakeGRPCServer := setup(t, &fakeServerState{})
 	defer teardown(fakeServer, fakeClients, fakeGRPCServer)
@@ -340,6 +433,43 @@ func TestCreateResource(t *testing.T) {
 	require.NotNil(t, resp)
 }
 
+func TestCreateResourceParagliderVM(t *testing.T) {
+	fakeServerState := &fakeServerState{
+		instance: getFakeInstance(true), // Include instance in server state since CreateResource will fetch after creating to add the tag
+		network: &computepb.Network{
+			Name:        proto.String(getVpcName(fakeNamespace)),
+			Subnetworks: []string{fmt.Sprintf("regions/%s/subnetworks/%s", fakeRegion, "paraglider-"+fakeRegion+"-subnet")},
+		},
+	}
+	fakeServer, ctx, fakeClients, fakeGRPCServer := setup(t, fakeServerState)
+	defer teardown(fakeServer, fakeClients, fakeGRPCServer)
+
+	_, fakeOrchestratorServerAddr, err := fake.SetupFakeOrchestratorRPCServer(utils.GCP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &GCPPluginServer{orchestratorServerAddr: fakeOrchestratorServerAddr}
+	description, err := json.Marshal(&resourcespec.VM{
+		Kind:   resourcespec.VMKind,
+		Size:   resourcespec.SizeSmall,
+		Image:  resourcespec.ImageDebian12,
+		Region: fakeZone,
+		SSHKey: "ssh-ed25519 AAAA",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource := &paragliderpb.CreateResourceRequest{
+		Deployment:  &paragliderpb.ParagliderDeployment{Id: "projects/" + fakeProject, Namespace: fakeNamespace},
+		Name:        fakeInstanceName,
+		Description: description,
+	}
+
+	resp, err := s._CreateResource(ctx, resource, fakeClients.instancesClient, fakeClients.networksClient, fakeClients.subnetworksClient, fakeClients.firewallsClient, fakeClients.clusterClient)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+}
+
 func TestCreateResourceCluster(t *testing.T) {
 	fakeServerState := &fakeServerState{
 		cluster: getFakeCluster(true), // Include cluster in server state since CreateResource will fetch after creating to add the tag
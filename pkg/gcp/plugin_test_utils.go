@@ -35,6 +35,7 @@ import (
 	"google.golang.org/api/option"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -183,6 +184,7 @@ func getFakeServerHandler(fakeServerState *fakeServerState) http.HandlerFunc {
 	// The handler should be written as minimally as possible to minimize maintenance overhead. Modifying requests (e.g. POST, DELETE)
 	// should generally not do anything other than return the operation response. Instead, initialize the fakeServerState as necessary.
 	// Keep in mind these unit tests should rely as little as possible on the functionality of this fake server.
+	// The exception is firewalls, whose writes are recorded so the conformance suite can read rules back.
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
 		body, err := io.ReadAll(r.Body)
@@ -214,6 +216,15 @@ func getFakeServerHandler(fakeServerState *fakeServerState) http.HandlerFunc {
 				sendResponse(w, fakeServerState.instance)
 				return
 			}
+		case path == urlProject+"/aggregated/instances":
+			if r.Method == "GET" {
+				instances := []*computepb.Instance{}
+				if fakeServerState.instance != nil {
+					instances = append(instances, fakeServerState.instance)
+				}
+				sendResponse(w, &computepb.InstanceAggregatedList{Items: map[string]*computepb.InstancesScopedList{"zones/" + fakeZone: {Instances: instances}}})
+				return
+			}
 		case path == urlProject+urlZone+"/instances":
 			if r.Method == "POST" {
 				sendResponseFakeOperation(w)
@@ -222,14 +233,25 @@ func getFakeServerHandler(fakeServerState *fakeServerState) http.HandlerFunc {
 		// Firewalls
 		case strings.HasPrefix(path, urlProject+"/global/firewalls"):
 			if r.Method == "POST" {
+				req := &computepb.Firewall{}
+				err := protojson.Unmarshal(body, req)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("error unmarshalling request body: %s", err), http.StatusBadRequest)
+					return
+				}
+				if fakeServerState.firewallMap == nil {
+					fakeServerState.firewallMap = make(map[string]*computepb.Firewall)
+				}
+				fakeServerState.firewallMap[req.GetName()] = req
 				sendResponseFakeOperation(w)
 				return
 			} else if r.Method == "DELETE" {
+				delete(fakeServerState.firewallMap, strings.TrimPrefix(path, urlProject+"/global/firewalls/"))
 				sendResponseFakeOperation(w)
 				return
 			} else if r.Method == "PATCH" {
 				req := &computepb.Firewall{}
-				err := json.Unmarshal(body, req)
+				err := protojson.Unmarshal(body, req)
 				if err != nil {
 					http.Error(w, fmt.Sprintf("error unmarshalling request body: %s", err), http.StatusBadRequest)
 					return
@@ -238,6 +260,7 @@ func getFakeServerHandler(fakeServerState *fakeServerState) http.HandlerFunc {
 					http.Error(w, fmt.Sprintf("error unmarshalling request body: %s", err), http.StatusBadRequest)
 					return
 				}
+				fakeServerState.firewallMap[*req.Name] = req
 				sendResponseFakeOperation(w)
 				return
 			} else if r.Method == "GET" {
@@ -345,6 +368,12 @@ func (f *fakeClusterManagerServer) GetCluster(ctx context.Context, req *containe
 	return nil, fmt.Errorf("cluster not found")
 }
 
+func (f *fakeClusterManagerServer) ListClusters(ctx context.Context, req *containerpb.ListClustersRequest) (*containerpb.ListClustersResponse, error) {
+	cluster := getFakeCluster(true)
+	cluster.Location = fakeZone
+	return &containerpb.ListClustersResponse{Clusters: []*containerpb.Cluster{cluster}}, nil
+}
+
 func (f *fakeClusterManagerServer) CreateCluster(ctx context.Context, req *containerpb.CreateClusterRequest) (*containerpb.Operation, error) {
 	return &containerpb.Operation{Name: fakeOperation}, nil
 }
@@ -375,6 +404,9 @@ type fakeClients struct {
 	vpnGatewaysClient         *compute.VpnGatewaysClient
 	vpnTunnelsClient          *compute.VpnTunnelsClient
 	clusterClient             *container.ClusterManagerClient
+	// Options to create more clients of the fake servers
+	clientOptions        []option.ClientOption
+	clusterClientOptions []option.ClientOption
 }
 
 // Sets up fake http server and fake GCP compute clients
@@ -384,6 +416,7 @@ func setup(t *testing.T, fakeServerState *fakeServerState) (fakeServer *httptest
 	ctx = context.Background()
 
 	clientOptions := []option.ClientOption{option.WithoutAuthentication(), option.WithEndpoint(fakeServer.URL)}
+	fakeClients.clientOptions = clientOptions
 	var err error
 	fakeClients.externalVpnGatewaysClient, err = compute.NewExternalVpnGatewaysRESTClient(ctx, clientOptions...)
 	if err != nil {
@@ -440,6 +473,7 @@ func setup(t *testing.T, fakeServerState *fakeServerState) (fakeServer *httptest
 	}()
 
 	clusterClientOptions := []option.ClientOption{option.WithoutAuthentication(), option.WithEndpoint(serverAddr), option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials()))}
+	fakeClients.clusterClientOptions = clusterClientOptions
 	fakeClients.clusterClient, err = container.NewClusterManagerClient(ctx, clusterClientOptions...)
 	if err != nil {
 		t.Fatal(err)
@@ -314,6 +314,11 @@ func parseUrl(url string) map[string]string {
 	return parsedUrl
 }
 
+// Get the last segment of a fully qualified or partial GCP resource URL (i.e., the resource name)
+func getLastUrlSegment(url string) string {
+	return url[strings.LastIndex(url, "/")+1:]
+}
+
 // Checks if GCP error response is a not found error
 func isErrorNotFound(err error) bool {
 	var e *googleapi.Error
@@ -327,3 +332,12 @@ func isErrorDuplicate(err error) bool {
 	ok := errors.As(err, &e)
 	return ok && e.Code == http.StatusConflict
 }
+
+// Gets the HTTP status code of a failed GCP API call
+func getErrorStatusCode(err error) (int, bool) {
+	var e *googleapi.Error
+	if errors.As(err, &e) {
+		return e.Code, true
+	}
+	return 0, false
+}
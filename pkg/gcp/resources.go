@@ -29,6 +29,8 @@ import (
 	container "cloud.google.com/go/container/apiv1"
 	containerpb "cloud.google.com/go/container/apiv1/containerpb"
 	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -53,6 +55,9 @@ type resourceNetworkInfo struct {
 	SubnetUrl   string
 	ResourceID  string
 	NetworkName string
+	Address     string   // Private IP of an instance or pod CIDR of a cluster
+	Addresses   []string // All private IPs of an instance (of every network interface, including IPv6) starting with Address
+	State       string
 }
 
 func resourceIsInNamespace(network string, namespace string) bool {
@@ -103,6 +108,21 @@ func getFirewallRules(ctx context.Context, client *compute.FirewallsClient, proj
 	return firewallRules, nil
 }
 
+// Get the firewall rules of a resource which are permit list rules (i.e., excluding the namespace's default deny all rule)
+func getPermitListFirewalls(ctx context.Context, client *compute.FirewallsClient, namespace string, project string, resourceID string) ([]*computepb.Firewall, error) {
+	firewalls, err := getFirewallRules(ctx, client, project, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	permitListFirewalls := []*computepb.Firewall{}
+	for _, firewall := range firewalls {
+		if isParagliderPermitListRule(namespace, firewall) && *firewall.Name != getDenyAllIngressFirewallName(namespace) {
+			permitListFirewalls = append(permitListFirewalls, firewall)
+		}
+	}
+	return permitListFirewalls, nil
+}
+
 // parseResourceUrl parses the resource URL and returns information about the resource (such as project, zone, name, and type)
 func parseResourceUrl(resourceUrl string) (*resourceInfo, error) {
 	parsedResourceId := parseUrl(resourceUrl)
@@ -125,7 +145,7 @@ func getResourceHandlerWithClient(resourceType string, instancesClient *compute.
 		handler.client = clusterClient
 		return handler, nil
 	} else {
-		return nil, fmt.Errorf("unknown resource type")
+		return nil, status.Errorf(codes.InvalidArgument, "unknown resource type")
 	}
 }
 
@@ -139,30 +159,53 @@ func getResourceHandlerFromDescription(resourceDesc []byte) (iGCPResourceHandler
 	} else if err := json.Unmarshal(resourceDesc, createClusterRequest); err == nil {
 		return &gcpGKE{}, nil
 	} else {
-		return nil, fmt.Errorf("resource description contains unknown GCP resource")
+		return nil, status.Errorf(codes.InvalidArgument, "resource description contains unknown GCP resource")
 	}
 }
 
 // Gets network information about a resource and confirms it is in the correct namespace
 // Returns the subnet URL and resource ID (instance ID or cluster ID, not URL since this is used for firewall rule naming)
 func GetResourceNetworkInfo(ctx context.Context, instancesClient *compute.InstancesClient, clusterClient *container.ClusterManagerClient, resourceInfo *resourceInfo) (*string, *string, error) {
+	netInfo, err := getNamespacedNetworkInfo(ctx, instancesClient, clusterClient, resourceInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &netInfo.SubnetUrl, &netInfo.ResourceID, nil
+}
+
+// Gets all network information about a resource and confirms it is in the correct namespace
+func getNamespacedNetworkInfo(ctx context.Context, instancesClient *compute.InstancesClient, clusterClient *container.ClusterManagerClient, resourceInfo *resourceInfo) (*resourceNetworkInfo, error) {
 	if resourceInfo.Namespace == "" {
-		return nil, nil, fmt.Errorf("namespace is empty")
+		return nil, status.Errorf(codes.InvalidArgument, "namespace is empty")
 	}
 
 	handler, err := getResourceHandlerWithClient(resourceInfo.ResourceType, instancesClient, clusterClient)
 	if err != nil {
-		return nil, nil, fmt.Errorf("unable to get resource handler: %w", err)
+		return nil, fmt.Errorf("unable to get resource handler: %w", err)
 	}
 	netInfo, err := handler.getNetworkInfo(ctx, resourceInfo)
 	if err != nil {
-		return nil, nil, fmt.Errorf("unable to get network info: %w", err)
+		return nil, fmt.Errorf("unable to get network info: %w", err)
 	}
 
 	if !resourceIsInNamespace(netInfo.NetworkName, resourceInfo.Namespace) {
-		return nil, nil, fmt.Errorf("resource is not in namespace")
+		return nil, fmt.Errorf("resource is not in namespace")
+	}
+	return netInfo, nil
+}
+
+// Convert a resource and its network information to its inventory entry
+func getResource(uri string, resourceInfo *resourceInfo, netInfo *resourceNetworkInfo, ruleCount int) *paragliderpb.Resource {
+	return &paragliderpb.Resource{
+		Name:      resourceInfo.Name,
+		Uri:       uri,
+		Ip:        netInfo.Address,
+		Region:    resourceInfo.Region,
+		Network:   getLastUrlSegment(netInfo.NetworkName),
+		Subnet:    getLastUrlSegment(netInfo.SubnetUrl),
+		State:     netInfo.State,
+		RuleCount: int32(ruleCount),
 	}
-	return &netInfo.SubnetUrl, &netInfo.ResourceID, nil
 }
 
 // Determine whether the provided resource description is supported
@@ -218,7 +261,7 @@ func (r *gcpInstance) getResourceInfo(ctx context.Context, resource *paragliderp
 	insertInstanceRequest := &computepb.InsertInstanceRequest{}
 	err := json.Unmarshal(resource.Description, insertInstanceRequest)
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse resource description: %w", err)
+		return nil, status.Errorf(codes.InvalidArgument, "unable to parse resource description: %v", err)
 	}
 	return &resourceInfo{Zone: insertInstanceRequest.Zone, NumAdditionalAddressSpaces: r.getNumberAddressSpacesRequired(), ResourceType: instanceTypeName}, nil
 }
@@ -249,10 +292,27 @@ func (r *gcpInstance) getNetworkInfo(ctx context.Context, resourceInfo *resource
 	if err != nil {
 		return nil, fmt.Errorf("unable to get instance: %w", err)
 	}
-	networkName := *instanceResponse.NetworkInterfaces[0].Network
-	subnetUrl := *instanceResponse.NetworkInterfaces[0].Subnetwork
-	resourceID := convertInstanceIdToString(*instanceResponse.Id)
-	return &resourceNetworkInfo{NetworkName: networkName, SubnetUrl: subnetUrl, ResourceID: resourceID}, nil
+	return getInstanceNetworkInfo(instanceResponse), nil
+}
+
+// Get network information from a GCP instance
+func getInstanceNetworkInfo(instance *computepb.Instance) *resourceNetworkInfo {
+	addresses := []string{}
+	for _, networkInterface := range instance.NetworkInterfaces {
+		for _, address := range []string{networkInterface.GetNetworkIP(), networkInterface.GetIpv6Address()} {
+			if address != "" {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return &resourceNetworkInfo{
+		NetworkName: instance.NetworkInterfaces[0].GetNetwork(),
+		SubnetUrl:   instance.NetworkInterfaces[0].GetSubnetwork(),
+		ResourceID:  convertInstanceIdToString(instance.GetId()),
+		Address:     instance.NetworkInterfaces[0].GetNetworkIP(),
+		Addresses:   addresses,
+		State:       instance.GetStatus(),
+	}
 }
 
 // Create a GCP instance with network settings
@@ -321,10 +381,10 @@ func (r *gcpInstance) fromResourceDecription(resourceDesc []byte) (*computepb.In
 	insertInstanceRequest := &computepb.InsertInstanceRequest{}
 	err := json.Unmarshal(resourceDesc, insertInstanceRequest)
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse resource description: %w", err)
+		return nil, status.Errorf(codes.InvalidArgument, "unable to parse resource description: %v", err)
 	}
 	if len(insertInstanceRequest.InstanceResource.NetworkInterfaces) != 0 {
-		return nil, fmt.Errorf("network settings should not be specified")
+		return nil, status.Errorf(codes.InvalidArgument, "network settings should not be specified")
 	}
 	return insertInstanceRequest, nil
 }
@@ -348,7 +408,7 @@ func (r *gcpGKE) getResourceInfo(ctx context.Context, resource *paragliderpb.Cre
 	createClusterRequest := &containerpb.CreateClusterRequest{}
 	err := json.Unmarshal(resource.Description, createClusterRequest)
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse resource description: %w", err)
+		return nil, status.Errorf(codes.InvalidArgument, "unable to parse resource description: %v", err)
 	}
 	zone := strings.Split(createClusterRequest.Parent, "/")[3]
 	return &resourceInfo{Zone: zone, NumAdditionalAddressSpaces: r.getNumberAddressSpacesRequired(), ResourceType: clusterTypeName}, nil
@@ -369,7 +429,19 @@ func (r *gcpGKE) getNetworkInfo(ctx context.Context, resourceInfo *resourceInfo)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get cluster: %w", err)
 	}
-	return &resourceNetworkInfo{SubnetUrl: getSubnetworkUrl(resourceInfo.Project, resourceInfo.Region, clusterResponse.Subnetwork), NetworkName: clusterResponse.Network, ResourceID: shortenClusterId(clusterResponse.Id)}, nil
+	return getClusterNetworkInfo(resourceInfo.Project, resourceInfo.Region, clusterResponse), nil
+}
+
+// Get network information from a GCP cluster
+func getClusterNetworkInfo(project string, region string, cluster *containerpb.Cluster) *resourceNetworkInfo {
+	return &resourceNetworkInfo{
+		SubnetUrl:   getSubnetworkUrl(project, region, cluster.Subnetwork),
+		NetworkName: cluster.Network,
+		ResourceID:  shortenClusterId(cluster.Id),
+		Address:     cluster.ClusterIpv4Cidr,
+		Addresses:   []string{cluster.ClusterIpv4Cidr},
+		State:       cluster.Status.String(),
+	}
 }
 
 // Create a GCP cluster with network settings
@@ -472,18 +544,18 @@ func (r *gcpGKE) fromResourceDecription(resourceDesc []byte) (*containerpb.Creat
 	createClusterRequest := &containerpb.CreateClusterRequest{}
 	err := json.Unmarshal(resourceDesc, createClusterRequest)
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse resource description: %w", err)
+		return nil, status.Errorf(codes.InvalidArgument, "unable to parse resource description: %v", err)
 	}
 	if createClusterRequest.Cluster.Network != "" || createClusterRequest.Cluster.Subnetwork != "" {
-		return nil, fmt.Errorf("network settings (subnets and address spaces) should not be specified")
+		return nil, status.Errorf(codes.InvalidArgument, "network settings (subnets and address spaces) should not be specified")
 	}
 	if createClusterRequest.Cluster.ClusterIpv4Cidr != "" || createClusterRequest.Cluster.ServicesIpv4Cidr != "" {
-		return nil, fmt.Errorf("network settings (subnets and address spaces) should not be specified")
+		return nil, status.Errorf(codes.InvalidArgument, "network settings (subnets and address spaces) should not be specified")
 	}
 	if createClusterRequest.Cluster.PrivateClusterConfig == nil {
 		return createClusterRequest, nil
 	} else if createClusterRequest.Cluster.PrivateClusterConfig.MasterIpv4CidrBlock != "" {
-		return nil, fmt.Errorf("network settings (subnets and address spaces) should not be specified")
+		return nil, status.Errorf(codes.InvalidArgument, "network settings (subnets and address spaces) should not be specified")
 	}
 	return createClusterRequest, nil
 }
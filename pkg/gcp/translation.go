@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/resourcespec"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Default GCP machine types and source images for the cloud-agnostic VM description
+var defaultVMMappings = resourcespec.Mappings{
+	Sizes: map[string]string{
+		resourcespec.SizeSmall:  "e2-small",
+		resourcespec.SizeMedium: "e2-medium",
+		resourcespec.SizeLarge:  "e2-standard-4",
+	},
+	Images: map[string]string{
+		resourcespec.ImageUbuntu2204: "projects/ubuntu-os-cloud/global/images/family/ubuntu-2204-lts",
+		resourcespec.ImageDebian12:   "projects/debian-cloud/global/images/family/debian-12",
+	},
+}
+
+// Translates a cloud-agnostic resource description into a GCP one, leaving native descriptions untouched
+func translateResourceDescription(resource *paragliderpb.CreateResourceRequest) (*paragliderpb.CreateResourceRequest, error) {
+	if !resourcespec.IsVM(resource.Description) {
+		return resource, nil
+	}
+	vm, err := resourcespec.ParseVM(resource.Description)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	insertInstanceRequest, err := translateVM(vm)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	desc, err := json.Marshal(insertInstanceRequest)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to marshal translated description: %v", err)
+	}
+	translated := proto.Clone(resource).(*paragliderpb.CreateResourceRequest)
+	translated.Description = desc
+	return translated, nil
+}
+
+// Builds the GCP instance insertion described by a cloud-agnostic VM description (the region is a zone)
+func translateVM(vm *resourcespec.VM) (*computepb.InsertInstanceRequest, error) {
+	machineType, image, err := vm.Resolve(defaultVMMappings)
+	if err != nil {
+		return nil, err
+	}
+
+	initializeParams := &computepb.AttachedDiskInitializeParams{SourceImage: proto.String(image)}
+	if vm.DiskSizeGB > 0 {
+		initializeParams.DiskSizeGb = proto.Int64(int64(vm.DiskSizeGB))
+	}
+
+	return &computepb.InsertInstanceRequest{
+		Zone: vm.Region,
+		InstanceResource: &computepb.Instance{
+			MachineType: proto.String(fmt.Sprintf("zones/%s/machineTypes/%s", vm.Region, machineType)),
+			Disks: []*computepb.AttachedDisk{
+				{
+					AutoDelete:       proto.Bool(true),
+					Boot:             proto.Bool(true),
+					InitializeParams: initializeParams,
+					Type:             proto.String(computepb.AttachedDisk_PERSISTENT.String()),
+				},
+			},
+			Metadata: &computepb.Metadata{
+				Items: []*computepb.Items{
+					{
+						Key:   proto.String("ssh-keys"),
+						Value: proto.String(fmt.Sprintf("%s:%s", resourcespec.DefaultUsername, vm.SSHKey)),
+					},
+				},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,86 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/resourcespec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func getFakeParagliderVM() *resourcespec.VM {
+	return &resourcespec.VM{
+		Kind:       resourcespec.VMKind,
+		Size:       resourcespec.SizeLarge,
+		Image:      resourcespec.ImageUbuntu2204,
+		Region:     fakeZone,
+		SSHKey:     "ssh-ed25519 AAAA",
+		DiskSizeGB: 20,
+	}
+}
+
+func TestTranslateResourceDescription(t *testing.T) {
+	description, err := json.Marshal(getFakeParagliderVM())
+	require.NoError(t, err)
+	resource := &paragliderpb.CreateResourceRequest{Name: fakeInstanceName, Description: description}
+
+	translated, err := translateResourceDescription(resource)
+	require.NoError(t, err)
+	assert.Equal(t, fakeInstanceName, translated.Name)
+
+	insertInstanceRequest := &computepb.InsertInstanceRequest{}
+	require.NoError(t, json.Unmarshal(translated.Description, insertInstanceRequest))
+	assert.Equal(t, fakeZone, insertInstanceRequest.Zone)
+	assert.Equal(t, "zones/"+fakeZone+"/machineTypes/e2-standard-4", *insertInstanceRequest.InstanceResource.MachineType)
+	disk := insertInstanceRequest.InstanceResource.Disks[0]
+	assert.True(t, *disk.Boot)
+	assert.Equal(t, "projects/ubuntu-os-cloud/global/images/family/ubuntu-2204-lts", *disk.InitializeParams.SourceImage)
+	assert.Equal(t, int64(20), *disk.InitializeParams.DiskSizeGb)
+	assert.Equal(t, resourcespec.DefaultUsername+":ssh-ed25519 AAAA", *insertInstanceRequest.InstanceResource.Metadata.Items[0].Value)
+
+	// The translated description goes through the existing handlers
+	resourceInfo, err := IsValidResource(context.Background(), translated)
+	require.NoError(t, err)
+	assert.Equal(t, fakeZone, resourceInfo.Zone)
+	assert.Equal(t, instanceTypeName, resourceInfo.ResourceType)
+
+	// Native descriptions are untouched
+	native, _, err := getFakeInstanceResourceDescription()
+	require.NoError(t, err)
+	translated, err = translateResourceDescription(native)
+	require.NoError(t, err)
+	assert.Same(t, native, translated)
+
+	// Unknown size class
+	vm := getFakeParagliderVM()
+	vm.Size = "huge"
+	description, err = json.Marshal(vm)
+	require.NoError(t, err)
+	_, err = translateResourceDescription(&paragliderpb.CreateResourceRequest{Description: description})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
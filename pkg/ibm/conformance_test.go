@@ -0,0 +1,67 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ibm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/plugintest"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	plugintest.Run(t, utils.IBM, setupConformance)
+}
+
+func setupConformance(t *testing.T, orchestratorAddr string) *plugintest.Target {
+	fakeIBMServerState := &fakeIBMServerState{
+		VPCs:          createFakeVPC(false),
+		Instance:      createFakeInstance(),
+		SecurityGroup: createFakeSecurityGroup(false),
+		subnetVPC: map[string]string{
+			fakeID: fakeSubnet1,
+		},
+	}
+	fakeServer, _, fakeClient := setup(t, fakeIBMServerState)
+	t.Cleanup(fakeServer.Close)
+
+	description, err := json.Marshal(fakeInstanceOptions)
+	require.NoError(t, err)
+
+	deployment := &paragliderpb.ParagliderDeployment{Id: fakeDeploymentID, Namespace: fakeNamespace}
+	return &plugintest.Target{
+		Server: &IBMPluginServer{
+			orchestratorServerAddr: orchestratorAddr,
+			cloudClient: map[string]*CloudClient{
+				getClientMapKey(fakeID, fakeRegion): fakeClient,
+			},
+		},
+		Deployment:     deployment,
+		Resource:       fakeInstanceID,
+		CreateRequest:  &paragliderpb.CreateResourceRequest{Deployment: deployment, Name: fakeInstance, Description: description},
+		OtherNamespace: wrongNamespace,
+		SymmetricPorts: true,
+		Skip: map[string]string{
+			plugintest.CheckTags: "security group rules do not record tags",
+		},
+	}
+}
@@ -25,7 +25,9 @@ import (
 
 	redis "github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
@@ -80,6 +82,10 @@ func (s *IBMPluginServer) CreateResource(c context.Context, resourceDesc *paragl
 	var vpcID *string
 	var subnetID string
 	utils.Log.Printf("Creating resource %s in deployment %s\n", resourceDesc.Name, resourceDesc.Deployment.Id)
+	resourceDesc, err := s.translateResourceDescription(resourceDesc)
+	if err != nil {
+		return nil, err
+	}
 	zone, err := getZoneFromDesc(resourceDesc.Description)
 	if err != nil {
 		return nil, err
@@ -216,6 +222,169 @@ func (s *IBMPluginServer) GetUsedAddressSpaces(ctx context.Context, req *paragli
 	return resp, nil
 }
 
+// GetResourceInfo returns the current private IP and state of the resource
+func (s *IBMPluginServer) GetResourceInfo(ctx context.Context, req *paragliderpb.GetResourceInfoRequest) (*paragliderpb.GetResourceInfoResponse, error) {
+	rInfo, err := getResourceMeta(req.Uri)
+	if err != nil {
+		return nil, err
+	}
+	region, err := ZoneToRegion(rInfo.Zone)
+	if err != nil {
+		return nil, err
+	}
+
+	cloudClient, err := s.setupCloudClient(rInfo.ResourceGroup, region)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := cloudClient.GetResourceHandlerFromID(req.Uri)
+	if err != nil {
+		return nil, err
+	}
+	// verify specified resource match the specified namespace
+	if isInNamespace, err := res.IsInNamespace(req.Namespace, region); !isInNamespace || err != nil {
+		return nil, fmt.Errorf("specified resource %v doesn't exist in namespace: %v",
+			rInfo.ResourceID, req.Namespace)
+	}
+
+	ips, state, err := res.GetNetworkState()
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.GetResourceInfoResponse{Uri: req.Uri, Ip: getPrimaryIP(ips), Ips: ips, State: state}, nil
+}
+
+// getPrimaryIP returns the first of the IPs reported by a resource, if any
+func getPrimaryIP(ips []string) string {
+	if len(ips) == 0 {
+		return ""
+	}
+	return ips[0]
+}
+
+// getResource builds the inventory entry of the specified resource
+func getResource(cloudClient *CloudClient, res ResourceIntf, uri, region string) (*paragliderpb.Resource, error) {
+	name, err := res.GetName()
+	if err != nil {
+		return nil, err
+	}
+	ips, state, err := res.GetNetworkState()
+	if err != nil {
+		return nil, err
+	}
+	vpc, err := res.GetVPC()
+	if err != nil {
+		return nil, err
+	}
+	subnet, err := res.GetSubnetName()
+	if err != nil {
+		return nil, err
+	}
+	resource := &paragliderpb.Resource{Name: name, Uri: uri, Ip: getPrimaryIP(ips), Region: region, Subnet: subnet, State: state}
+	if vpc.Name != nil {
+		resource.Network = *vpc.Name
+	}
+
+	// Permit list rules are only applied to instances through their paraglider security group
+	if _, ok := res.(*ResourceInstanceType); ok {
+		securityGroupID, err := res.GetSecurityGroupID()
+		if err != nil {
+			return nil, err
+		}
+		sgRules, err := cloudClient.GetSecurityRulesOfSG(securityGroupID)
+		if err != nil {
+			return nil, err
+		}
+		resource.RuleCount = int32(len(sgRules))
+	}
+	return resource, nil
+}
+
+// GetResource returns the inventory entry of the specified resource
+func (s *IBMPluginServer) GetResource(ctx context.Context, req *paragliderpb.GetResourceRequest) (*paragliderpb.GetResourceResponse, error) {
+	rInfo, err := getResourceMeta(req.Uri)
+	if err != nil {
+		return nil, err
+	}
+	region, err := ZoneToRegion(rInfo.Zone)
+	if err != nil {
+		return nil, err
+	}
+
+	cloudClient, err := s.setupCloudClient(rInfo.ResourceGroup, region)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := cloudClient.GetResourceHandlerFromID(req.Uri)
+	if err != nil {
+		return nil, err
+	}
+	// verify specified resource match the specified namespace
+	if isInNamespace, err := res.IsInNamespace(req.Namespace, region); !isInNamespace || err != nil {
+		return nil, status.Errorf(codes.NotFound, "specified resource %v doesn't exist in namespace: %v",
+			rInfo.ResourceID, req.Namespace)
+	}
+
+	resource, err := getResource(cloudClient, res, req.Uri, region)
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.GetResourceResponse{Resource: resource}, nil
+}
+
+// ListResources returns the inventory of paraglider instances and clusters in the deployment's namespace
+func (s *IBMPluginServer) ListResources(ctx context.Context, req *paragliderpb.ListResourcesRequest) (*paragliderpb.ListResourcesResponse, error) {
+	rInfo, err := getResourceMeta(req.Deployment.Id)
+	if err != nil {
+		return nil, err
+	}
+	region, err := ZoneToRegion(rInfo.Zone)
+	if err != nil {
+		// No region specified, use default region
+		region = defaultRegion
+	}
+
+	cloudClient, err := s.setupCloudClient(rInfo.ResourceGroup, region)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &paragliderpb.ListResourcesResponse{}
+	taggedTypes := map[string]taggedResourceType{InstanceResourceType: VM, ClusterResourceType: CLUSTER}
+	for _, resourceType := range []string{InstanceResourceType, ClusterResourceType} {
+		taggedResources, err := cloudClient.GetParagliderTaggedResources(taggedTypes[resourceType], []string{req.Deployment.Namespace}, resourceQuery{})
+		if err != nil {
+			return nil, err
+		}
+		for _, taggedResource := range taggedResources {
+			location := taggedResource.Zone
+			resourceRegion := taggedResource.Region
+			if location == "" {
+				location = resourceRegion
+			} else if resourceRegion, err = ZoneToRegion(location); err != nil {
+				return nil, err
+			}
+			client, err := s.setupCloudClient(rInfo.ResourceGroup, resourceRegion)
+			if err != nil {
+				return nil, err
+			}
+			uri := fmt.Sprintf("/resourcegroup/%s/zone/%s/%s/%s", rInfo.ResourceGroup, location, resourceType, taggedResource.ID)
+			res, err := client.GetResourceHandlerFromID(uri)
+			if err != nil {
+				return nil, err
+			}
+			resource, err := getResource(client, res, uri, resourceRegion)
+			if err != nil {
+				return nil, err
+			}
+			resp.Resources = append(resp.Resources, resource)
+		}
+	}
+	return resp, nil
+}
+
 // GetPermitList returns security rules of security groups associated with the specified resource.
 func (s *IBMPluginServer) GetPermitList(ctx context.Context, req *paragliderpb.GetPermitListRequest) (*paragliderpb.GetPermitListResponse, error) {
 	rInfo, err := getResourceMeta(req.Resource)
@@ -763,7 +932,7 @@ func Setup(port int, orchestratorServerAddr string) *IBMPluginServer {
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to listen: %v", err)
 	}
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(utils.StatusErrorInterceptor(utils.IBM, getErrorStatusCode)))
 	ibmServer := &IBMPluginServer{
 		cloudClient:            make(map[string]*CloudClient),
 		orchestratorServerAddr: orchestratorServerAddr,
@@ -49,6 +49,7 @@ const (
 	fakeInstance   = "vm-paraglider-fake"
 	fakeCluster    = "cluster-paraglider-fake"
 	fakeImage      = "fake-image"
+	fakeImageName  = "fake-image-name"
 	fakeVPC        = "paraglider-fake-vpc"
 	fakeID         = "12345"
 	fakeID2        = "123452"
@@ -229,6 +230,7 @@ func getFakeIBMServerHandler(fakeIBMServerState *fakeIBMServerState) http.Handle
 	// The handler should be written as minimally as possible to minimize maintenance overhead. Modifying requests (e.g. POST, DELETE)
 	// should generally not do anything other than return the operation response. Instead, initialize the fakeIBMServerState as necessary.
 	// Keep in mind these unit tests should rely as little as possible on the functionality of this fake server.
+	// The exception is security group rules, whose writes are recorded so the conformance suite can read rules back.
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
@@ -238,6 +240,10 @@ func getFakeIBMServerHandler(fakeIBMServerState *fakeIBMServerState) http.Handle
 			return
 		}
 		switch {
+		case path == "/v2/endpoints": // Regional endpoints used to validate regions
+			regional := map[string]interface{}{fakeRegion: map[string]interface{}{}, fakeConRegion: map[string]interface{}{}}
+			sendFakeResponse(w, map[string]interface{}{"service-endpoints": map[string]interface{}{"regional": regional}})
+			return
 		case path == "/v3/resources/search":
 			if r.Method == http.MethodPost { // Search resources like VPC, Security-group, instance, etc
 				var req map[string]interface{}
@@ -388,6 +394,15 @@ func getFakeIBMServerHandler(fakeIBMServerState *fakeIBMServerState) http.Handle
 					return
 				}
 			}
+		case path == "/images":
+			if r.Method == http.MethodGet { // List images by name
+				images := vpcv1.ImageCollection{Images: []vpcv1.Image{}}
+				if r.URL.Query().Get("name") == fakeImageName {
+					images.Images = append(images.Images, vpcv1.Image{ID: core.StringPtr(fakeImage), Name: core.StringPtr(fakeImageName)})
+				}
+				sendFakeResponse(w, images)
+				return
+			}
 		case path == "/keys":
 			if r.Method == http.MethodPost { // Create Key
 				key := vpcv1.Key{ID: core.StringPtr(fakeID)}
@@ -416,20 +431,45 @@ func getFakeIBMServerHandler(fakeIBMServerState *fakeIBMServerState) http.Handle
 				return
 			}
 			if r.Method == http.MethodPost { // Add rules to a security group
-				sg := vpcv1.SecurityGroupRuleSecurityGroupRuleProtocolAll{
-					ID:       core.StringPtr(fakeID),
-					Protocol: core.StringPtr(vpcv1.SecurityGroupRuleSecurityGroupRuleProtocolAllProtocolAllConst),
-				}
-				if fakeIBMServerState.rules != 0 {
+				ruleID := fakeID
+				if fakeIBMServerState.rules == 1 {
 					// Return another rule ID for the second rule added
-					sg.ID = core.StringPtr(fakeID2)
+					ruleID = fakeID2
+				} else if fakeIBMServerState.rules > 1 {
+					ruleID = fmt.Sprintf("%s-%d", fakeID, fakeIBMServerState.rules)
 				}
 				fakeIBMServerState.rules++
-				sendFakeResponse(w, sg)
+				var prototype map[string]json.RawMessage
+				err := json.Unmarshal(body, &prototype)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("unable to unmarshal request: %s", err.Error()), http.StatusBadRequest)
+					return
+				}
+				prototype["id"], _ = json.Marshal(ruleID)
+				var rule vpcv1.SecurityGroupRuleIntf
+				err = core.UnmarshalModel(prototype, "", &rule, vpcv1.UnmarshalSecurityGroupRule)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("unable to unmarshal request: %s", err.Error()), http.StatusBadRequest)
+					return
+				}
+				if fakeIBMServerState.SecurityGroup != nil {
+					fakeIBMServerState.SecurityGroup.Rules = append(fakeIBMServerState.SecurityGroup.Rules, rule)
+				}
+				sendFakeResponse(w, rule)
 				return
 			}
 		case strings.Contains(path, "/security_groups/"+fakeID+"/rules/"):
 			if r.Method == http.MethodDelete { // Delete a rule
+				if fakeIBMServerState.SecurityGroup != nil {
+					ruleID := path[strings.LastIndex(path, "/")+1:]
+					rules := []vpcv1.SecurityGroupRuleIntf{}
+					for _, rule := range fakeIBMServerState.SecurityGroup.Rules {
+						if (&CloudClient{}).getIBMRuleID(rule) != ruleID {
+							rules = append(rules, rule)
+						}
+					}
+					fakeIBMServerState.SecurityGroup.Rules = rules
+				}
 				w.WriteHeader(http.StatusOK)
 				return
 			}
@@ -528,6 +568,9 @@ func setup(t *testing.T, fakeIBMServerState *fakeIBMServerState) (fakeServer *ht
 	var err error
 	fakeServer = httptest.NewServer(getFakeIBMServerHandler(fakeIBMServerState))
 	ctx = context.Background()
+	// Validate regions against the fake server instead of IBM Cloud
+	endpointsURL = fakeServer.URL + "/v2/endpoints"
+	regionCache = nil
 	fakeClient, err = FakeIBMCloudClient(fakeServer.URL, fakeID, fakeRegion)
 	if err != nil {
 		t.Fatal(err)
@@ -1169,3 +1212,77 @@ func TestGetPermitListWrongNamespace(t *testing.T) {
 	require.Error(t, err)
 	require.Nil(t, resp)
 }
+
+func TestGetResourceInfo(t *testing.T) {
+	fakeIBMServerState := &fakeIBMServerState{
+		Instance: createFakeInstance(),
+	}
+	fakeServer, ctx, fakeClient := setup(t, fakeIBMServerState)
+	defer fakeServer.Close()
+	s := &IBMPluginServer{
+		cloudClient: map[string]*CloudClient{
+			getClientMapKey(fakeID, fakeRegion): fakeClient,
+		},
+	}
+
+	resp, err := s.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Namespace: fakeNamespace, Uri: fakeInstanceID})
+	require.NoError(t, err)
+	require.Equal(t, fakeInstanceID, resp.Uri)
+	require.Equal(t, fakeIP, resp.Ip)
+	require.Equal(t, []string{fakeIP}, resp.Ips)
+	require.Equal(t, vpcv1.InstanceStatusRunningConst, resp.State)
+
+	// IPs of secondary network interfaces are reported after the primary IP
+	fakeIBMServerState.Instance.NetworkInterfaces = append(fakeIBMServerState.Instance.NetworkInterfaces, vpcv1.NetworkInterfaceInstanceContextReference{
+		PrimaryIP: &vpcv1.ReservedIPReference{Address: core.StringPtr("10.0.1.4")},
+	})
+	resp, err = s.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Namespace: fakeNamespace, Uri: fakeInstanceID})
+	require.NoError(t, err)
+	require.Equal(t, fakeIP, resp.Ip)
+	require.Equal(t, []string{fakeIP, "10.0.1.4"}, resp.Ips)
+}
+
+func TestGetResourceInfoWrongNamespace(t *testing.T) {
+	fakeIBMServerState := &fakeIBMServerState{
+		Instance: createFakeInstance(),
+	}
+	fakeServer, ctx, fakeClient := setup(t, fakeIBMServerState)
+	defer fakeServer.Close()
+	s := &IBMPluginServer{
+		cloudClient: map[string]*CloudClient{
+			getClientMapKey(fakeID, fakeRegion): fakeClient,
+		},
+	}
+
+	resp, err := s.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Namespace: wrongNamespace, Uri: fakeInstanceID})
+	require.Error(t, err)
+	require.Nil(t, resp)
+}
+
+func TestGetResource(t *testing.T) {
+	fakeIBMServerState := &fakeIBMServerState{
+		Instance:      createFakeInstance(),
+		SecurityGroup: createFakeSecurityGroup(true),
+	}
+	fakeServer, ctx, fakeClient := setup(t, fakeIBMServerState)
+	defer fakeServer.Close()
+	s := &IBMPluginServer{
+		cloudClient: map[string]*CloudClient{
+			getClientMapKey(fakeID, fakeRegion): fakeClient,
+		},
+	}
+
+	resp, err := s.GetResource(ctx, &paragliderpb.GetResourceRequest{Namespace: fakeNamespace, Uri: fakeInstanceID})
+	require.NoError(t, err)
+	require.Equal(t, fakeInstance, resp.Resource.Name)
+	require.Equal(t, fakeInstanceID, resp.Resource.Uri)
+	require.Equal(t, fakeIP, resp.Resource.Ip)
+	require.Equal(t, fakeRegion, resp.Resource.Region)
+	require.Equal(t, vpcv1.InstanceStatusRunningConst, resp.Resource.State)
+	require.Equal(t, int32(len(fakeIBMServerState.SecurityGroup.Rules)), resp.Resource.RuleCount)
+
+	// Resource outside of the namespace
+	resp, err = s.GetResource(ctx, &paragliderpb.GetResourceRequest{Namespace: wrongNamespace, Uri: fakeInstanceID})
+	require.Error(t, err)
+	require.Nil(t, resp)
+}
@@ -25,6 +25,8 @@ import (
 	k8sv1 "github.com/IBM-Cloud/container-services-go-sdk/kubernetesserviceapiv1"
 	"github.com/IBM/go-sdk-core/v5/core"
 	"github.com/IBM/vpc-go-sdk/vpcv1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	utils "github.com/paraglider-project/paraglider/pkg/utils"
 )
@@ -60,6 +62,9 @@ type ResourceIntf interface {
 	GetID() string
 	GetSecurityGroupID() (string, error)
 	GetVPC() (*vpcv1.VPCReference, error)
+	GetNetworkState() ([]string, string, error)
+	GetName() (string, error)
+	GetSubnetName() (string, error)
 }
 
 // ResourceInstanceType is the handler for instance type resources
@@ -90,18 +95,7 @@ func (i *ResourceInstanceType) getCRN() (*vpcv1.Instance, error) {
 }
 
 func (i *ResourceInstanceType) getResourceOptions(resourceDesc []byte) (*vpcv1.CreateInstanceOptions, error) {
-	instanceOptions := vpcv1.CreateInstanceOptions{
-		InstancePrototype: &vpcv1.InstancePrototypeInstanceByImage{
-			Image:   &vpcv1.ImageIdentityByID{},
-			Zone:    &vpcv1.ZoneIdentityByName{},
-			Profile: &vpcv1.InstanceProfileIdentityByName{},
-		},
-	}
-	err := json.Unmarshal(resourceDesc, &instanceOptions)
-	if err != nil {
-		return nil, err
-	}
-	return &instanceOptions, nil
+	return parseInstanceOptions(resourceDesc)
 }
 
 func (i *ResourceInstanceType) getInstanceIP() (string, error) {
@@ -271,6 +265,45 @@ func (i *ResourceInstanceType) GetVPC() (*vpcv1.VPCReference, error) {
 	return instance.VPC, nil
 }
 
+// GetNetworkState returns the current private IPs of all network interfaces (starting with the primary one) and status of the instance without waiting for it to be ready
+func (i *ResourceInstanceType) GetNetworkState() ([]string, string, error) {
+	instance, _, err := i.client.vpcService.GetInstance(&vpcv1.GetInstanceOptions{ID: &i.ID})
+	if err != nil {
+		return nil, "", err
+	}
+	ips := []string{}
+	for _, nic := range instance.NetworkInterfaces {
+		if nic.PrimaryIP != nil && nic.PrimaryIP.Address != nil {
+			ips = append(ips, *nic.PrimaryIP.Address)
+		}
+	}
+	return ips, *instance.Status, nil
+}
+
+// GetName returns the name of the instance
+func (i *ResourceInstanceType) GetName() (string, error) {
+	instance, _, err := i.client.vpcService.GetInstance(&vpcv1.GetInstanceOptions{ID: &i.ID})
+	if err != nil {
+		return "", err
+	}
+	return *instance.Name, nil
+}
+
+// GetSubnetName returns the name of the subnet of the instance's primary network interface
+func (i *ResourceInstanceType) GetSubnetName() (string, error) {
+	instance, _, err := i.client.vpcService.GetInstance(&vpcv1.GetInstanceOptions{ID: &i.ID})
+	if err != nil {
+		return "", err
+	}
+	if instance.PrimaryNetworkInterface != nil && instance.PrimaryNetworkInterface.Subnet != nil && instance.PrimaryNetworkInterface.Subnet.Name != nil {
+		return *instance.PrimaryNetworkInterface.Subnet.Name, nil
+	}
+	if len(instance.NetworkInterfaces) > 0 && instance.NetworkInterfaces[0].Subnet != nil && instance.NetworkInterfaces[0].Subnet.Name != nil {
+		return *instance.NetworkInterfaces[0].Subnet.Name, nil
+	}
+	return "", nil
+}
+
 func (c *ResourceClusterType) createURI(resGroup, zone, resName string) string {
 	return fmt.Sprintf("/resourcegroup/%s/zone/%s/%s/%s", resGroup, zone, ClusterResourceType, resName)
 }
@@ -433,6 +466,57 @@ func (c *ResourceClusterType) GetVPC() (*vpcv1.VPCReference, error) {
 	return nil, fmt.Errorf("unable to find the VPC of cluster %s", c.ID)
 }
 
+// GetName returns the name of the cluster
+func (c *ResourceClusterType) GetName() (string, error) {
+	options := c.client.k8sService.NewVpcGetClusterOptions(c.ID)
+	options.XAuthResourceGroup = c.client.resourceGroup.ID
+	cluster, _, err := c.client.k8sService.VpcGetCluster(options)
+	if err != nil {
+		return "", err
+	}
+	return *cluster.Name, nil
+}
+
+// GetSubnetName returns the name of the single subnet in the cluster's exclusive VPC
+func (c *ResourceClusterType) GetSubnetName() (string, error) {
+	vpc, err := c.GetVPC()
+	if err != nil {
+		return "", err
+	}
+	subnets, err := c.client.GetSubnetsInVpcRegionBound(*vpc.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(subnets) == 0 || subnets[0].Name == nil {
+		return "", nil
+	}
+	return *subnets[0].Name, nil
+}
+
+// GetNetworkState returns the address space of the cluster's subnet and the cluster's state
+func (c *ResourceClusterType) GetNetworkState() ([]string, string, error) {
+	options := c.client.k8sService.NewVpcGetClusterOptions(c.ID)
+	options.XAuthResourceGroup = c.client.resourceGroup.ID
+	cluster, _, err := c.client.k8sService.VpcGetCluster(options)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// A cluster is provisioned in an exclusive VPC with a single subnet
+	vpc, err := c.GetVPC()
+	if err != nil {
+		return nil, "", err
+	}
+	subnets, err := c.client.GetSubnetsInVpcRegionBound(*vpc.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(subnets) == 0 {
+		return nil, "", fmt.Errorf("no subnets found in the VPC of cluster %s", c.ID)
+	}
+	return []string{*subnets[0].Ipv4CIDRBlock}, *cluster.State, nil
+}
+
 // NOTE: Currently not in use, as public ips are not provisioned.
 // deletes floating ips marked recyclable, that are attached to
 // any interface associated with given VM
@@ -472,14 +556,6 @@ func (c *CloudClient) waitForInstanceRemoval(vmID string) bool {
 
 // GetResourceHandlerFromDesc gets the resource handler from the resource description
 func (c *CloudClient) GetResourceHandlerFromDesc(resourceDesc []byte) (ResourceIntf, error) {
-	instanceOptions := vpcv1.CreateInstanceOptions{
-		InstancePrototype: &vpcv1.InstancePrototypeInstanceByImage{
-			Image:   &vpcv1.ImageIdentityByID{},
-			Zone:    &vpcv1.ZoneIdentityByName{},
-			Profile: &vpcv1.InstanceProfileIdentityByName{},
-		},
-	}
-
 	clusterOptions := k8sv1.VpcCreateClusterOptions{}
 
 	err := json.Unmarshal(resourceDesc, &clusterOptions)
@@ -487,12 +563,12 @@ func (c *CloudClient) GetResourceHandlerFromDesc(resourceDesc []byte) (ResourceI
 		return &ResourceClusterType{client: c}, nil
 	}
 
-	err = json.Unmarshal(resourceDesc, &instanceOptions)
+	instanceOptions, err := parseInstanceOptions(resourceDesc)
 	if err == nil && instanceOptions.InstancePrototype != nil {
 		return &ResourceInstanceType{client: c}, nil
 	}
 
-	return nil, fmt.Errorf("failed to unmarshal resource description:%+v", err)
+	return nil, status.Errorf(codes.InvalidArgument, "failed to unmarshal resource description: %v", err)
 
 }
 
@@ -509,6 +585,6 @@ func (c *CloudClient) GetResourceHandlerFromID(deploymentID string) (ResourceInt
 		}
 	}
 
-	return nil, fmt.Errorf("invalid resource ID format: expected '/resourcegroup/{ResourceGroup}/zone/{zone}/{resource}/{resource_id}', got '%s'", deploymentID)
+	return nil, status.Errorf(codes.InvalidArgument, "invalid resource ID format: expected '/resourcegroup/{ResourceGroup}/zone/{zone}/{resource}/{resource_id}', got '%s'", deploymentID)
 }
 
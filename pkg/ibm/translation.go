@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ibm
+
+import (
+	"encoding/json"
+
+	"github.com/IBM/vpc-go-sdk/vpcv1"
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/resourcespec"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// boot volume profile used when a disk size is requested
+const bootVolumeProfile = "general-purpose"
+
+// default IBM instance profiles and stock image names for the cloud-agnostic VM description.
+// Image IDs differ between regions, so images are mapped by name and resolved in the instance's region.
+var defaultVMMappings = resourcespec.Mappings{
+	Sizes: map[string]string{
+		resourcespec.SizeSmall:  "bx2-2x8",
+		resourcespec.SizeMedium: "bx2-4x16",
+		resourcespec.SizeLarge:  "bx2-8x32",
+	},
+	Images: map[string]string{
+		resourcespec.ImageUbuntu2204: "ibm-ubuntu-22-04-4-minimal-amd64-3",
+		resourcespec.ImageDebian12:   "ibm-debian-12-6-minimal-amd64-1",
+	},
+}
+
+// translateResourceDescription translates a cloud-agnostic resource description into an IBM one, leaving native descriptions untouched
+func (s *IBMPluginServer) translateResourceDescription(resource *paragliderpb.CreateResourceRequest) (*paragliderpb.CreateResourceRequest, error) {
+	if !resourcespec.IsVM(resource.Description) {
+		return resource, nil
+	}
+	vm, err := resourcespec.ParseVM(resource.Description)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	profile, imageName, err := vm.Resolve(defaultVMMappings)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	// Resolve the image name in the region of the requested zone
+	region, err := ZoneToRegion(vm.Region)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	rInfo, err := getResourceMeta(resource.Deployment.Id)
+	if err != nil {
+		return nil, err
+	}
+	cloudClient, err := s.setupCloudClient(rInfo.ResourceGroup, region)
+	if err != nil {
+		return nil, err
+	}
+	imageID, err := cloudClient.getImageIDByName(imageName)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := json.Marshal(translateVM(vm, profile, imageID))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to marshal translated description: %v", err)
+	}
+	translated := proto.Clone(resource).(*paragliderpb.CreateResourceRequest)
+	translated.Description = desc
+	return translated, nil
+}
+
+// translateVM builds the instance options described by a cloud-agnostic VM description (the region is a zone)
+func translateVM(vm *resourcespec.VM, profile string, imageID string) *vpcv1.CreateInstanceOptions {
+	userData := vm.CloudInit()
+	prototype := &vpcv1.InstancePrototypeInstanceByImage{
+		Image:    &vpcv1.ImageIdentityByID{ID: &imageID},
+		Zone:     &vpcv1.ZoneIdentityByName{Name: &vm.Region},
+		Profile:  &vpcv1.InstanceProfileIdentityByName{Name: &profile},
+		UserData: &userData,
+	}
+	if vm.DiskSizeGB > 0 {
+		capacity := int64(vm.DiskSizeGB)
+		prototype.BootVolumeAttachment = &vpcv1.VolumeAttachmentPrototypeInstanceByImageContext{
+			Volume: &vpcv1.VolumePrototypeInstanceByImageContext{
+				Capacity: &capacity,
+				Profile:  &vpcv1.VolumeProfileIdentityByName{Name: proto.String(bootVolumeProfile)},
+			},
+		}
+	}
+	return &vpcv1.CreateInstanceOptions{InstancePrototype: prototype}
+}
+
+// getImageIDByName returns the ID of the image with the given name in the client's region
+func (c *CloudClient) getImageIDByName(name string) (string, error) {
+	images, _, err := c.vpcService.ListImages(&vpcv1.ListImagesOptions{Name: &name})
+	if err != nil {
+		return "", err
+	}
+	if len(images.Images) == 0 {
+		return "", status.Errorf(codes.InvalidArgument, "image %s not found in region %s", name, c.Region())
+	}
+	return *images.Images[0].ID, nil
+}
@@ -0,0 +1,103 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ibm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/IBM/vpc-go-sdk/vpcv1"
+	"github.com/paraglider-project/paraglider/pkg/resourcespec"
+	"github.com/stretchr/testify/require"
+)
+
+func getFakeParagliderVM() *resourcespec.VM {
+	return &resourcespec.VM{
+		Kind:   resourcespec.VMKind,
+		Size:   resourcespec.SizeSmall,
+		Image:  resourcespec.ImageUbuntu2204,
+		Region: fakeZone,
+		SSHKey: "ssh-ed25519 AAAA",
+	}
+}
+
+func TestTranslateVM(t *testing.T) {
+	vm := getFakeParagliderVM()
+	profile, _, err := vm.Resolve(defaultVMMappings)
+	require.NoError(t, err)
+	require.Equal(t, fakeProfile, profile)
+
+	description, err := json.Marshal(translateVM(vm, profile, fakeImage))
+	require.NoError(t, err)
+
+	// The translated description goes through the existing handlers
+	zone, err := getZoneFromDesc(description)
+	require.NoError(t, err)
+	require.Equal(t, fakeZone, zone)
+
+	res, err := (&CloudClient{}).GetResourceHandlerFromDesc(description)
+	require.NoError(t, err)
+	require.IsType(t, &ResourceInstanceType{}, res)
+
+	instanceOptions, err := parseInstanceOptions(description)
+	require.NoError(t, err)
+	prototype := instanceOptions.InstancePrototype.(*vpcv1.InstancePrototypeInstanceByImage)
+	require.Equal(t, fakeImage, *prototype.Image.(*vpcv1.ImageIdentityByID).ID)
+	require.Equal(t, fakeProfile, *prototype.Profile.(*vpcv1.InstanceProfileIdentityByName).Name)
+	require.Contains(t, *prototype.UserData, "ssh-ed25519 AAAA")
+	require.Nil(t, prototype.BootVolumeAttachment)
+
+	// Boot volume with the requested disk size
+	vm.DiskSizeGB = 250
+	description, err = json.Marshal(translateVM(vm, profile, fakeImage))
+	require.NoError(t, err)
+	instanceOptions, err = parseInstanceOptions(description)
+	require.NoError(t, err)
+	prototype = instanceOptions.InstancePrototype.(*vpcv1.InstancePrototypeInstanceByImage)
+	require.Equal(t, int64(250), *prototype.BootVolumeAttachment.Volume.Capacity)
+	require.Equal(t, bootVolumeProfile, *prototype.BootVolumeAttachment.Volume.Profile.(*vpcv1.VolumeProfileIdentityByName).Name)
+}
+
+func TestParseInstanceOptionsNull(t *testing.T) {
+	// Fields set to null leave no boot volume rather than failing
+	for _, description := range []string{
+		`{"instance_prototype": {"zone": {"name": "us-east-1"}, "boot_volume_attachment": null}}`,
+		`{"instance_prototype": {"zone": {"name": "us-east-1"}, "boot_volume_attachment": {"volume": null}}}`,
+		`{"instance_prototype": {"zone": {"name": "us-east-1"}, "boot_volume_attachment": {"volume": {"profile": null}}}}`,
+	} {
+		instanceOptions, err := parseInstanceOptions([]byte(description))
+		require.NoError(t, err)
+		require.Nil(t, instanceOptions.InstancePrototype.(*vpcv1.InstancePrototypeInstanceByImage).BootVolumeAttachment)
+	}
+
+	_, err := getZoneFromDesc([]byte(`{"instance_prototype": {"zone": null}}`))
+	require.Error(t, err)
+}
+
+func TestGetImageIDByName(t *testing.T) {
+	fakeServer, _, fakeClient := setup(t, &fakeIBMServerState{})
+	defer fakeServer.Close()
+
+	imageID, err := fakeClient.getImageIDByName(fakeImageName)
+	require.NoError(t, err)
+	require.Equal(t, fakeImage, imageID)
+
+	_, err = fakeClient.getImageIDByName("missing-image")
+	require.Error(t, err)
+}
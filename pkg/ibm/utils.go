@@ -19,6 +19,7 @@ package ibm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hash/fnv"
 	"io"
@@ -29,6 +30,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/IBM/go-sdk-core/v5/core"
 	"github.com/google/uuid"
 
 	k8sv1 "github.com/IBM-Cloud/container-services-go-sdk/kubernetesserviceapiv1"
@@ -50,7 +52,6 @@ const (
 	ANY     taggedResourceType = "*"
 
 	credentialsPath = ".ibm/credentials.yaml"
-	endpointsURL    = "https://control.cloud-object-storage.cloud.ibm.com/v2/endpoints" // url containing constantly updated endpoints of regions.
 	publicSSHKey    = ".ibm/keys/paraglider-key.pub"
 	privateSSHKey   = ".ibm/keys/paraglider-key"
 	// paragliderResourcePrefix is used to prefix a resource's name
@@ -62,6 +63,9 @@ const (
 // taggedResourceType indicates the type of tagged resource to fetch
 type taggedResourceType string
 
+// url containing constantly updated endpoints of regions. Replaced by unit tests with the fake server.
+var endpointsURL = "https://control.cloud-object-storage.cloud.ibm.com/v2/endpoints"
+
 // cache of regions, initialized by GetRegions(). Shouldn't be accessed directly outside of file.
 var regionCache []string
 
@@ -238,15 +242,36 @@ func getResourceMeta(deploymentID string) (resourceIDInfo, error) {
 	return info, nil
 }
 
-func getZoneFromDesc(resourceDesc []byte) (string, error) {
-	instanceOptions := vpcv1.CreateInstanceOptions{
-		InstancePrototype: &vpcv1.InstancePrototypeInstanceByImage{
-			Image:   &vpcv1.ImageIdentityByID{},
-			Zone:    &vpcv1.ZoneIdentityByName{},
-			Profile: &vpcv1.InstanceProfileIdentityByName{},
+// parseInstanceOptions unmarshals an instance description into create instance options.
+// The SDK's identity interfaces are filled with concrete types so they can be unmarshalled: images by ID,
+// zones and profiles by name, and an optional boot volume with a capacity and/or profile name.
+func parseInstanceOptions(resourceDesc []byte) (*vpcv1.CreateInstanceOptions, error) {
+	prototype := &vpcv1.InstancePrototypeInstanceByImage{
+		Image:   &vpcv1.ImageIdentityByID{},
+		Zone:    &vpcv1.ZoneIdentityByName{},
+		Profile: &vpcv1.InstanceProfileIdentityByName{},
+		BootVolumeAttachment: &vpcv1.VolumeAttachmentPrototypeInstanceByImageContext{
+			Volume: &vpcv1.VolumePrototypeInstanceByImageContext{Profile: &vpcv1.VolumeProfileIdentityByName{}},
 		},
 	}
+	instanceOptions := vpcv1.CreateInstanceOptions{InstancePrototype: prototype}
+	if err := json.Unmarshal(resourceDesc, &instanceOptions); err != nil {
+		return nil, err
+	}
 
+	// Drop the boot volume unless the description sets its capacity or profile (fields set to null replace the defaults with nil)
+	if attachment := prototype.BootVolumeAttachment; attachment != nil {
+		bootVolume := attachment.Volume
+		if bootVolume == nil {
+			prototype.BootVolumeAttachment = nil
+		} else if profile, ok := bootVolume.Profile.(*vpcv1.VolumeProfileIdentityByName); bootVolume.Capacity == nil && (!ok || profile == nil || profile.Name == nil) {
+			prototype.BootVolumeAttachment = nil
+		}
+	}
+	return &instanceOptions, nil
+}
+
+func getZoneFromDesc(resourceDesc []byte) (string, error) {
 	clusterOptions := k8sv1.VpcCreateClusterOptions{}
 
 	err := json.Unmarshal(resourceDesc, &clusterOptions)
@@ -257,13 +282,17 @@ func getZoneFromDesc(resourceDesc []byte) (string, error) {
 		return *clusterOptions.WorkerPool.Zones[0].ID, nil
 	}
 
-	err = json.Unmarshal(resourceDesc, &instanceOptions)
+	instanceOptions, err := parseInstanceOptions(resourceDesc)
 	if err == nil && instanceOptions.InstancePrototype != nil {
-		zone := instanceOptions.InstancePrototype.(*vpcv1.InstancePrototypeInstanceByImage).Zone
-		if zone.(*vpcv1.ZoneIdentityByName).Name == nil {
+		prototype, ok := instanceOptions.InstancePrototype.(*vpcv1.InstancePrototypeInstanceByImage)
+		if !ok {
+			return "", fmt.Errorf("unsupported instance prototype in instance description")
+		}
+		zone, ok := prototype.Zone.(*vpcv1.ZoneIdentityByName)
+		if !ok || zone == nil || zone.Name == nil {
 			return "", fmt.Errorf("unspecified zone definition in instance description")
 		}
-		return *zone.(*vpcv1.ZoneIdentityByName).Name, nil
+		return *zone.Name, nil
 	}
 
 	return "", fmt.Errorf("failed to unmarshal resource description:%+v", err)
@@ -440,3 +469,12 @@ func GetIBMResourceGroupID() string {
 	}
 	return resourceGroupID
 }
+
+// Gets the HTTP status code of a failed IBM API call
+func getErrorStatusCode(err error) (int, bool) {
+	var problem *core.HTTPProblem
+	if errors.As(err, &problem) && problem.Response != nil {
+		return problem.Response.GetStatusCode(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/validation"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const defaultPodSyncInterval = 30
+
+// Config declares the Kubernetes clusters managed by the kubernetes plugin
+type Config struct {
+	Clusters        []Cluster `yaml:"clusters"`
+	PodSyncInterval int       `yaml:"podSyncInterval"` // Seconds between registrations of pod IPs as tags (default 30, disabled if negative)
+}
+
+// Cluster is a Kubernetes cluster whose workloads are isolated with network policies
+type Cluster struct {
+	Name          string   `yaml:"name"`
+	Kubeconfig    string   `yaml:"kubeconfig"`    // Path to the kubeconfig of the cluster (in-cluster config if empty)
+	Context       string   `yaml:"context"`       // Context of the kubeconfig (default: its current context)
+	AddressSpaces []string `yaml:"addressSpaces"` // Pod CIDRs of the cluster so the orchestrator does not allocate overlapping address spaces
+}
+
+// Read and validate the config of the kubernetes plugin
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// Parse and validate the config of the kubernetes plugin
+func ParseConfig(data []byte) (*Config, error) {
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse config: %w", err)
+	}
+	if cfg.PodSyncInterval == 0 {
+		cfg.PodSyncInterval = defaultPodSyncInterval
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) validate() error {
+	clusters := make(map[string]bool)
+	for _, cluster := range c.Clusters {
+		// Cluster names are used in URIs and label values
+		if errs := validation.IsDNS1123Label(cluster.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid cluster name %q: %s", cluster.Name, errs[0])
+		}
+		if clusters[cluster.Name] {
+			return fmt.Errorf("duplicate cluster %s", cluster.Name)
+		}
+		clusters[cluster.Name] = true
+		for _, addressSpace := range cluster.AddressSpaces {
+			if _, err := netip.ParsePrefix(addressSpace); err != nil {
+				return fmt.Errorf("invalid address space %s of cluster %s: %w", addressSpace, cluster.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Create clients for the clusters keyed by name
+func (c *Config) NewClients() (map[string]k8s.Interface, error) {
+	clients := make(map[string]k8s.Interface)
+	for _, cluster := range c.Clusters {
+		var restConfig *rest.Config
+		var err error
+		if cluster.Kubeconfig == "" {
+			restConfig, err = rest.InClusterConfig()
+		} else {
+			restConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+				&clientcmd.ClientConfigLoadingRules{ExplicitPath: cluster.Kubeconfig},
+				&clientcmd.ConfigOverrides{CurrentContext: cluster.Context},
+			).ClientConfig()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to load config of cluster %s: %w", cluster.Name, err)
+		}
+		client, err := k8s.NewForConfig(restConfig)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create client for cluster %s: %w", cluster.Name, err)
+		}
+		clients[cluster.Name] = client
+	}
+	return clients, nil
+}
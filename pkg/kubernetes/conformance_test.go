@@ -0,0 +1,53 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"encoding/json"
+	"testing"
+
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/plugintest"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+	"github.com/stretchr/testify/require"
+	k8s "k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestConformance(t *testing.T) {
+	plugintest.Run(t, utils.KUBERNETES, setupConformance)
+}
+
+func setupConformance(t *testing.T, orchestratorAddr string) *plugintest.Target {
+	client := k8sfake.NewClientset(
+		getFakePod("web-a", fakeSelector, "10.244.0.5"),
+		getFakePod("api-a", map[string]string{"app": "api"}, "10.244.0.6"),
+	)
+	s := NewKubernetesPluginServer(orchestratorAddr, getFakeConfig(t), map[string]k8s.Interface{fakeCluster: client})
+	createWorkload(t, s, fakeWorkload, fakeSelector)
+
+	description, err := json.Marshal(&WorkloadDescription{Namespace: fakeKubernetesNamespace, Selector: map[string]string{"app": "api"}})
+	require.NoError(t, err)
+	return &plugintest.Target{
+		Server:        s,
+		Deployment:    fakeDeployment,
+		Resource:      fakeWorkloadUri,
+		CreateRequest: &paragliderpb.CreateResourceRequest{Deployment: fakeDeployment, Name: "api", Description: description},
+	}
+}
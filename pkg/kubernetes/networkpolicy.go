@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"fmt"
+	"net/netip"
+
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Protocols which network policies can match
+var networkPolicyProtocols = map[int32]corev1.Protocol{
+	6:   corev1.ProtocolTCP,
+	17:  corev1.ProtocolUDP,
+	132: corev1.ProtocolSCTP,
+}
+
+// Check that a rule can be expressed as a network policy rule
+func validatePermitListRule(rule *paragliderpb.PermitListRule) error {
+	if rule.Name == "" {
+		return status.Errorf(codes.InvalidArgument, "rule name is required")
+	}
+	if len(rule.Targets) == 0 {
+		return status.Errorf(codes.InvalidArgument, "rule %s has no targets", rule.Name)
+	}
+	for _, port := range []int32{rule.SrcPort, rule.DstPort} {
+		if port < -1 || port == 0 || port > 65535 {
+			return status.Errorf(codes.InvalidArgument, "invalid port %d of rule %s", port, rule.Name)
+		}
+	}
+	// Network policies can only match destination ports, and ignoring the source port would allow more traffic than the rule
+	if rule.SrcPort != -1 {
+		return status.Errorf(codes.InvalidArgument, "rule %s has source port %d but network policies can only match destination ports", rule.Name, rule.SrcPort)
+	}
+	if rule.Protocol == -1 {
+		if rule.DstPort != -1 {
+			return status.Errorf(codes.InvalidArgument, "rule %s can't match ports without a protocol", rule.Name)
+		}
+	} else if _, ok := networkPolicyProtocols[rule.Protocol]; !ok {
+		return status.Errorf(codes.InvalidArgument, "rule %s has protocol %d but network policies only support tcp, udp, and sctp", rule.Name, rule.Protocol)
+	}
+	for _, target := range rule.Targets {
+		if _, err := parseTarget(target); err != nil {
+			return status.Errorf(codes.InvalidArgument, "rule %s has invalid target %s: %v", rule.Name, target, err)
+		}
+	}
+	return nil
+}
+
+// Parse a target (an address or a prefix) into a prefix
+func parseTarget(target string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(target); err == nil {
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(target)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
+
+func getNetworkPolicyPeers(rule *paragliderpb.PermitListRule) []networkingv1.NetworkPolicyPeer {
+	peers := make([]networkingv1.NetworkPolicyPeer, len(rule.Targets))
+	for i, target := range rule.Targets {
+		prefix, _ := parseTarget(target)
+		peers[i] = networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: prefix.String()}}
+	}
+	return peers
+}
+
+// Gets the ports of a rule where no ports match all traffic
+func getNetworkPolicyPorts(rule *paragliderpb.PermitListRule) []networkingv1.NetworkPolicyPort {
+	if rule.Protocol == -1 {
+		return nil
+	}
+	protocol := networkPolicyProtocols[rule.Protocol]
+	port := networkingv1.NetworkPolicyPort{Protocol: &protocol}
+	if rule.DstPort != -1 {
+		dstPort := intstr.FromInt32(rule.DstPort)
+		port.Port = &dstPort
+	}
+	return []networkingv1.NetworkPolicyPort{port}
+}
+
+// Set the rules of a workload's network policy from its permit list
+// The permit list is also kept in an annotation since the translation drops rule names and tags
+func setNetworkPolicyRules(policy *networkingv1.NetworkPolicy, rules []*paragliderpb.PermitListRule) error {
+	policy.Spec.PolicyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}
+	policy.Spec.Ingress = nil
+	policy.Spec.Egress = nil
+	for _, rule := range rules {
+		if rule.Direction == paragliderpb.Direction_INBOUND {
+			policy.Spec.Ingress = append(policy.Spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+				From:  getNetworkPolicyPeers(rule),
+				Ports: getNetworkPolicyPorts(rule),
+			})
+		} else {
+			policy.Spec.Egress = append(policy.Spec.Egress, networkingv1.NetworkPolicyEgressRule{
+				To:    getNetworkPolicyPeers(rule),
+				Ports: getNetworkPolicyPorts(rule),
+			})
+		}
+	}
+	data, err := protojson.Marshal(&paragliderpb.GetPermitListResponse{Rules: rules})
+	if err != nil {
+		return fmt.Errorf("unable to marshal permit list: %w", err)
+	}
+	if policy.Annotations == nil {
+		policy.Annotations = make(map[string]string)
+	}
+	policy.Annotations[rulesAnnotationKey] = string(data)
+	return nil
+}
+
+// Get the permit list of a workload from its network policy
+func getNetworkPolicyRules(policy *networkingv1.NetworkPolicy) ([]*paragliderpb.PermitListRule, error) {
+	data, ok := policy.Annotations[rulesAnnotationKey]
+	if !ok {
+		return []*paragliderpb.PermitListRule{}, nil
+	}
+	permitList := &paragliderpb.GetPermitListResponse{}
+	if err := protojson.Unmarshal([]byte(data), permitList); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal permit list of network policy %s/%s: %w", policy.Namespace, policy.Name, err)
+	}
+	if permitList.Rules == nil {
+		return []*paragliderpb.PermitListRule{}, nil
+	}
+	return permitList.Rules, nil
+}
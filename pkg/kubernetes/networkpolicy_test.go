@@ -0,0 +1,112 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"testing"
+
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestValidatePermitListRule(t *testing.T) {
+	valid := &paragliderpb.PermitListRule{Name: "ssh", Direction: paragliderpb.Direction_INBOUND, SrcPort: -1, DstPort: 22, Protocol: 6, Targets: []string{"10.0.0.1"}}
+	require.NoError(t, validatePermitListRule(valid))
+
+	all := &paragliderpb.PermitListRule{Name: "all", Direction: paragliderpb.Direction_OUTBOUND, SrcPort: -1, DstPort: -1, Protocol: -1, Targets: []string{"10.0.0.0/8"}}
+	require.NoError(t, validatePermitListRule(all))
+
+	tests := map[string]func(rule *paragliderpb.PermitListRule){
+		"no name":                  func(rule *paragliderpb.PermitListRule) { rule.Name = "" },
+		"no targets":               func(rule *paragliderpb.PermitListRule) { rule.Targets = nil },
+		"invalid target":           func(rule *paragliderpb.PermitListRule) { rule.Targets = []string{"not an ip"} },
+		"icmp":                     func(rule *paragliderpb.PermitListRule) { rule.Protocol = 1 },
+		"invalid source port":      func(rule *paragliderpb.PermitListRule) { rule.SrcPort = -2 },
+		"source port":              func(rule *paragliderpb.PermitListRule) { rule.SrcPort = 443 },
+		"invalid destination port": func(rule *paragliderpb.PermitListRule) { rule.DstPort = 70000 },
+		"port without protocol":    func(rule *paragliderpb.PermitListRule) { rule.Protocol = -1 },
+		"zero destination port":    func(rule *paragliderpb.PermitListRule) { rule.DstPort = 0 },
+	}
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			rule := proto.Clone(valid).(*paragliderpb.PermitListRule)
+			modify(rule)
+			err := validatePermitListRule(rule)
+			require.Error(t, err)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+func TestSetNetworkPolicyRules(t *testing.T) {
+	rules := []*paragliderpb.PermitListRule{
+		{Name: "ssh", Direction: paragliderpb.Direction_INBOUND, SrcPort: -1, DstPort: 22, Protocol: 6, Targets: []string{"10.0.0.1", "10.1.0.0/16"}, Tags: []string{"default.gcp.vm"}},
+		{Name: "dns", Direction: paragliderpb.Direction_OUTBOUND, SrcPort: -1, DstPort: -1, Protocol: 17, Targets: []string{"10.2.0.5"}},
+		{Name: "all", Direction: paragliderpb.Direction_OUTBOUND, SrcPort: -1, DstPort: -1, Protocol: -1, Targets: []string{"10.3.0.1/16"}},
+	}
+	policy := &networkingv1.NetworkPolicy{}
+	require.NoError(t, setNetworkPolicyRules(policy, rules))
+
+	tcp := corev1.ProtocolTCP
+	udp := corev1.ProtocolUDP
+	ssh := intstr.FromInt32(22)
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}, policy.Spec.PolicyTypes)
+	assert.Equal(t, []networkingv1.NetworkPolicyIngressRule{
+		{
+			From: []networkingv1.NetworkPolicyPeer{
+				{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.1/32"}},
+				{IPBlock: &networkingv1.IPBlock{CIDR: "10.1.0.0/16"}},
+			},
+			Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &ssh}},
+		},
+	}, policy.Spec.Ingress)
+	assert.Equal(t, []networkingv1.NetworkPolicyEgressRule{
+		{
+			To:    []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.2.0.5/32"}}},
+			Ports: []networkingv1.NetworkPolicyPort{{Protocol: &udp}},
+		},
+		{
+			To: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.3.0.0/16"}}},
+		},
+	}, policy.Spec.Egress)
+
+	// The permit list is restored from the annotation with its names and tags
+	restored, err := getNetworkPolicyRules(policy)
+	require.NoError(t, err)
+	require.Len(t, restored, len(rules))
+	for i := range rules {
+		assert.True(t, proto.Equal(rules[i], restored[i]), "rule %s was not restored", rules[i].Name)
+	}
+
+	// Removing all rules denies all traffic
+	require.NoError(t, setNetworkPolicyRules(policy, []*paragliderpb.PermitListRule{}))
+	assert.Empty(t, policy.Spec.Ingress)
+	assert.Empty(t, policy.Spec.Egress)
+	assert.Len(t, policy.Spec.PolicyTypes, 2)
+	restored, err = getNetworkPolicyRules(policy)
+	require.NoError(t, err)
+	assert.Empty(t, restored)
+}
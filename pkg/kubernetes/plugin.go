@@ -0,0 +1,538 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// Labels and annotations of the network policies managed by the plugin
+const (
+	namespaceLabelKey  = "paragliderproject.io/namespace" // Paraglider namespace of the workload
+	workloadLabelKey   = "paragliderproject.io/workload"  // Name of the workload
+	rulesAnnotationKey = "paragliderproject.io/rules"     // Permit list of the workload
+)
+
+// Network policies of workloads are named paraglider-<workload>
+const networkPolicyPrefix = "paraglider-"
+
+const (
+	defaultKubernetesNamespace = "default"
+	noPodsState                = "NO_PODS" // State reported for workloads without running pods
+)
+
+// WorkloadDescription is the description given when creating a workload
+type WorkloadDescription struct {
+	Namespace string            `json:"namespace"` // Kubernetes namespace of the workload (default: default)
+	Selector  map[string]string `json:"selector"`  // Labels of the workload's pods
+}
+
+// KubernetesPluginServer isolates workloads (pods selected by labels) in Kubernetes clusters with network policies (deployments are clusters/<cluster>)
+type KubernetesPluginServer struct {
+	paragliderpb.UnimplementedCloudPluginServer
+	orchestratorServerAddr string
+	config                 *Config
+	clients                map[string]k8s.Interface // Clients of the clusters keyed by name
+	mu                     sync.Mutex               // Guards the registered pod tags
+	podTags                map[string]podTag        // Tags registered for pods keyed by URI
+}
+
+// Create a kubernetes plugin with a client for each cluster in the config
+func NewKubernetesPluginServer(orchestratorServerAddr string, cfg *Config, clients map[string]k8s.Interface) *KubernetesPluginServer {
+	return &KubernetesPluginServer{
+		orchestratorServerAddr: orchestratorServerAddr,
+		config:                 cfg,
+		clients:                clients,
+		podTags:                make(map[string]podTag),
+	}
+}
+
+func getDeploymentId(cluster string) string {
+	return "clusters/" + cluster
+}
+
+func getWorkloadUri(cluster string, namespace string, workload string) string {
+	return fmt.Sprintf("clusters/%s/namespaces/%s/workloads/%s", cluster, namespace, workload)
+}
+
+func getPodUri(cluster string, namespace string, pod string) string {
+	return fmt.Sprintf("clusters/%s/namespaces/%s/pods/%s", cluster, namespace, pod)
+}
+
+// Resource of a cluster identified by a URI of the form clusters/<cluster>/namespaces/<namespace>/<kind>/<name>
+type resourceUri struct {
+	cluster   string
+	namespace string
+	kind      string // workloads or pods
+	name      string
+}
+
+func parseResourceUri(uri string) (*resourceUri, error) {
+	parts := strings.Split(uri, "/")
+	if len(parts) != 6 || parts[0] != "clusters" || parts[2] != "namespaces" || (parts[4] != "workloads" && parts[4] != "pods") {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid uri %s: expected clusters/<cluster>/namespaces/<namespace>/(workloads|pods)/<name>", uri)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid uri %s: expected clusters/<cluster>/namespaces/<namespace>/(workloads|pods)/<name>", uri)
+		}
+	}
+	return &resourceUri{cluster: parts[1], namespace: parts[3], kind: parts[4], name: parts[5]}, nil
+}
+
+// Parse the cluster out of a deployment ID of the form clusters/<cluster>
+func parseDeploymentId(id string) (string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 2 || parts[0] != "clusters" || parts[1] == "" {
+		return "", status.Errorf(codes.InvalidArgument, "invalid deployment %s: expected clusters/<cluster>", id)
+	}
+	return parts[1], nil
+}
+
+func (s *KubernetesPluginServer) getCluster(name string) (*Cluster, k8s.Interface, error) {
+	for i := range s.config.Clusters {
+		if s.config.Clusters[i].Name == name {
+			client, ok := s.clients[name]
+			if !ok {
+				return nil, nil, status.Errorf(codes.Internal, "no client for cluster %s", name)
+			}
+			return &s.config.Clusters[i], client, nil
+		}
+	}
+	return nil, nil, status.Errorf(codes.NotFound, "cluster %s is not declared", name)
+}
+
+func (s *KubernetesPluginServer) getDeploymentCluster(deployment *paragliderpb.ParagliderDeployment) (*Cluster, k8s.Interface, error) {
+	name, err := parseDeploymentId(deployment.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.getCluster(name)
+}
+
+// Convert an error of the Kubernetes API into a gRPC status
+func getStatusError(err error, format string, args ...any) error {
+	msg := fmt.Sprintf(format, args...)
+	switch {
+	case k8serrors.IsNotFound(err):
+		return status.Errorf(codes.NotFound, "%s: %v", msg, err)
+	case k8serrors.IsAlreadyExists(err):
+		return status.Errorf(codes.AlreadyExists, "%s: %v", msg, err)
+	case k8serrors.IsInvalid(err) || k8serrors.IsBadRequest(err):
+		return status.Errorf(codes.InvalidArgument, "%s: %v", msg, err)
+	case k8serrors.IsForbidden(err) || k8serrors.IsUnauthorized(err):
+		return status.Errorf(codes.PermissionDenied, "%s: %v", msg, err)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// Gets the network policy of a workload and checks that the workload is in the namespace
+func (s *KubernetesPluginServer) getWorkload(ctx context.Context, namespace string, uri string) (k8s.Interface, *resourceUri, *networkingv1.NetworkPolicy, error) {
+	parsedUri, err := parseResourceUri(uri)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if parsedUri.kind != "workloads" {
+		return nil, nil, nil, status.Errorf(codes.InvalidArgument, "permit lists are attached to workloads, not %s", parsedUri.kind)
+	}
+	_, client, err := s.getCluster(parsedUri.cluster)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	policy, err := client.NetworkingV1().NetworkPolicies(parsedUri.namespace).Get(ctx, networkPolicyPrefix+parsedUri.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, nil, getStatusError(err, "unable to get workload %s", uri)
+	}
+	if policy.Labels[workloadLabelKey] != parsedUri.name {
+		return nil, nil, nil, status.Errorf(codes.NotFound, "network policy %s/%s is not managed by Paraglider", policy.Namespace, policy.Name)
+	}
+	if policy.Labels[namespaceLabelKey] != namespace {
+		return nil, nil, nil, status.Errorf(codes.InvalidArgument, "workload %s is not in namespace %s", uri, namespace)
+	}
+	return client, parsedUri, policy, nil
+}
+
+// Gets the pods of a workload which have an IP sorted by name
+func getWorkloadPods(ctx context.Context, client k8s.Interface, policy *networkingv1.NetworkPolicy) ([]corev1.Pod, error) {
+	selector := labels.SelectorFromSet(policy.Spec.PodSelector.MatchLabels)
+	podList, err := client.CoreV1().Pods(policy.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, getStatusError(err, "unable to list pods of workload %s", policy.Labels[workloadLabelKey])
+	}
+	pods := []corev1.Pod{}
+	for _, pod := range podList.Items {
+		if pod.Status.PodIP != "" && pod.DeletionTimestamp == nil {
+			pods = append(pods, pod)
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	return pods, nil
+}
+
+// Gets the IPs of a pod, including the one of the other IP family in dual-stack clusters
+func getPodIps(pod *corev1.Pod) []string {
+	ips := []string{}
+	if pod.Status.PodIP != "" {
+		ips = append(ips, pod.Status.PodIP)
+	}
+	for _, podIP := range pod.Status.PodIPs {
+		if podIP.IP != "" && !slices.Contains(ips, podIP.IP) {
+			ips = append(ips, podIP.IP)
+		}
+	}
+	return ips
+}
+
+// Gets the IPs and state of a workload, which are those of its pods since workloads don't have an address of their own
+// The first IP and the state are those of its first pod
+func getWorkloadInfo(ctx context.Context, client k8s.Interface, policy *networkingv1.NetworkPolicy) ([]string, string, error) {
+	pods, err := getWorkloadPods(ctx, client, policy)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(pods) == 0 {
+		return nil, noPodsState, nil
+	}
+	ips := []string{}
+	for i := range pods {
+		ips = append(ips, getPodIps(&pods[i])...)
+	}
+	return ips, string(pods[0].Status.Phase), nil
+}
+
+// Gets the first of the IPs of a resource, if any
+func getPrimaryIp(ips []string) string {
+	if len(ips) == 0 {
+		return ""
+	}
+	return ips[0]
+}
+
+// Gets a pod and checks that it is selected by a workload in the namespace
+func (s *KubernetesPluginServer) getPod(ctx context.Context, namespace string, parsedUri *resourceUri) (*corev1.Pod, error) {
+	_, client, err := s.getCluster(parsedUri.cluster)
+	if err != nil {
+		return nil, err
+	}
+	pod, err := client.CoreV1().Pods(parsedUri.namespace).Get(ctx, parsedUri.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, getStatusError(err, "unable to get pod %s/%s", parsedUri.namespace, parsedUri.name)
+	}
+	policies, err := client.NetworkingV1().NetworkPolicies(parsedUri.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{namespaceLabelKey: namespace}).String(),
+	})
+	if err != nil {
+		return nil, getStatusError(err, "unable to list workloads in %s", parsedUri.namespace)
+	}
+	for _, policy := range policies.Items {
+		if labels.SelectorFromSet(policy.Spec.PodSelector.MatchLabels).Matches(labels.Set(pod.Labels)) {
+			return pod, nil
+		}
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "pod %s/%s is not selected by a workload in namespace %s", pod.Namespace, pod.Name, namespace)
+}
+
+func (s *KubernetesPluginServer) getWorkloadResource(ctx context.Context, cluster string, client k8s.Interface, policy *networkingv1.NetworkPolicy) (*paragliderpb.Resource, error) {
+	rules, err := getNetworkPolicyRules(policy)
+	if err != nil {
+		return nil, err
+	}
+	ips, state, err := getWorkloadInfo(ctx, client, policy)
+	if err != nil {
+		return nil, err
+	}
+	name := policy.Labels[workloadLabelKey]
+	return &paragliderpb.Resource{
+		Name:      name,
+		Uri:       getWorkloadUri(cluster, policy.Namespace, name),
+		Ip:        getPrimaryIp(ips),
+		Network:   cluster,
+		Subnet:    policy.Namespace,
+		State:     state,
+		RuleCount: int32(len(rules)),
+	}, nil
+}
+
+func (s *KubernetesPluginServer) GetPermitList(ctx context.Context, req *paragliderpb.GetPermitListRequest) (*paragliderpb.GetPermitListResponse, error) {
+	_, _, policy, err := s.getWorkload(ctx, req.Namespace, req.Resource)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := getNetworkPolicyRules(policy)
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.GetPermitListResponse{Rules: rules}, nil
+}
+
+// GetResourceInfo returns the IP of a pod or of the first pod of a workload
+func (s *KubernetesPluginServer) GetResourceInfo(ctx context.Context, req *paragliderpb.GetResourceInfoRequest) (*paragliderpb.GetResourceInfoResponse, error) {
+	parsedUri, err := parseResourceUri(req.Uri)
+	if err != nil {
+		return nil, err
+	}
+	if parsedUri.kind == "pods" {
+		pod, err := s.getPod(ctx, req.Namespace, parsedUri)
+		if err != nil {
+			return nil, err
+		}
+		return &paragliderpb.GetResourceInfoResponse{Uri: req.Uri, Ip: pod.Status.PodIP, Ips: getPodIps(pod), State: string(pod.Status.Phase)}, nil
+	}
+	client, parsedUri, policy, err := s.getWorkload(ctx, req.Namespace, req.Uri)
+	if err != nil {
+		return nil, err
+	}
+	ips, state, err := getWorkloadInfo(ctx, client, policy)
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.GetResourceInfoResponse{Uri: req.Uri, Ip: getPrimaryIp(ips), Ips: ips, State: state}, nil
+}
+
+func (s *KubernetesPluginServer) GetResource(ctx context.Context, req *paragliderpb.GetResourceRequest) (*paragliderpb.GetResourceResponse, error) {
+	client, parsedUri, policy, err := s.getWorkload(ctx, req.Namespace, req.Uri)
+	if err != nil {
+		return nil, err
+	}
+	resource, err := s.getWorkloadResource(ctx, parsedUri.cluster, client, policy)
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.GetResourceResponse{Resource: resource}, nil
+}
+
+// ListResources returns the workloads of the namespace across the Kubernetes namespaces of the cluster
+func (s *KubernetesPluginServer) ListResources(ctx context.Context, req *paragliderpb.ListResourcesRequest) (*paragliderpb.ListResourcesResponse, error) {
+	cluster, client, err := s.getDeploymentCluster(req.Deployment)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := client.NetworkingV1().NetworkPolicies(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{namespaceLabelKey: req.Deployment.Namespace}).String(),
+	})
+	if err != nil {
+		return nil, getStatusError(err, "unable to list workloads of cluster %s", cluster.Name)
+	}
+	resources := []*paragliderpb.Resource{}
+	for i := range policies.Items {
+		resource, err := s.getWorkloadResource(ctx, cluster.Name, client, &policies.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Uri < resources[j].Uri })
+	return &paragliderpb.ListResourcesResponse{Resources: resources}, nil
+}
+
+// Update the permit list of a workload, retrying if the network policy was changed concurrently
+func (s *KubernetesPluginServer) updatePermitList(ctx context.Context, namespace string, uri string, update func([]*paragliderpb.PermitListRule) []*paragliderpb.PermitListRule) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		client, _, policy, err := s.getWorkload(ctx, namespace, uri)
+		if err != nil {
+			return err
+		}
+		rules, err := getNetworkPolicyRules(policy)
+		if err != nil {
+			return err
+		}
+		if err := setNetworkPolicyRules(policy, update(rules)); err != nil {
+			return err
+		}
+		_, err = client.NetworkingV1().NetworkPolicies(policy.Namespace).Update(ctx, policy, metav1.UpdateOptions{})
+		if k8serrors.IsConflict(err) {
+			return err
+		}
+		if err != nil {
+			return getStatusError(err, "unable to update network policy of workload %s", uri)
+		}
+		return nil
+	})
+}
+
+func (s *KubernetesPluginServer) AddPermitListRules(ctx context.Context, req *paragliderpb.AddPermitListRulesRequest) (*paragliderpb.AddPermitListRulesResponse, error) {
+	for _, rule := range req.Rules {
+		if err := validatePermitListRule(rule); err != nil {
+			return nil, err
+		}
+	}
+	err := s.updatePermitList(ctx, req.Namespace, req.Resource, func(rules []*paragliderpb.PermitListRule) []*paragliderpb.PermitListRule {
+		for _, rule := range req.Rules {
+			replaced := false
+			for i, existing := range rules {
+				if existing.Name == rule.Name {
+					rules[i] = rule
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				rules = append(rules, rule)
+			}
+		}
+		return rules
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.AddPermitListRulesResponse{}, nil
+}
+
+func (s *KubernetesPluginServer) DeletePermitListRules(ctx context.Context, req *paragliderpb.DeletePermitListRulesRequest) (*paragliderpb.DeletePermitListRulesResponse, error) {
+	deleted := make(map[string]bool)
+	for _, name := range req.RuleNames {
+		deleted[name] = true
+	}
+	err := s.updatePermitList(ctx, req.Namespace, req.Resource, func(rules []*paragliderpb.PermitListRule) []*paragliderpb.PermitListRule {
+		kept := []*paragliderpb.PermitListRule{}
+		for _, rule := range rules {
+			if !deleted[rule.Name] {
+				kept = append(kept, rule)
+			}
+		}
+		return kept
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.DeletePermitListRulesResponse{}, nil
+}
+
+// CreateResource creates a workload from a label selector by creating its network policy, which denies all traffic until rules are added
+// The pods themselves are created with Kubernetes, so the workload may not have an IP yet
+func (s *KubernetesPluginServer) CreateResource(ctx context.Context, req *paragliderpb.CreateResourceRequest) (*paragliderpb.CreateResourceResponse, error) {
+	cluster, client, err := s.getDeploymentCluster(req.Deployment)
+	if err != nil {
+		return nil, err
+	}
+	// The workload name is used in the network policy's name and as a label value
+	if errs := validation.IsDNS1123Label(networkPolicyPrefix + req.Name); len(errs) > 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid workload name %s: %s", req.Name, errs[0])
+	}
+	description := &WorkloadDescription{}
+	if err := json.Unmarshal(req.Description, description); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid workload description: %v", err)
+	}
+	if description.Namespace == "" {
+		description.Namespace = defaultKubernetesNamespace
+	}
+	if len(description.Selector) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "workload %s has no selector", req.Name)
+	}
+	if _, err := labels.ValidatedSelectorFromSet(description.Selector); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid selector of workload %s: %v", req.Name, err)
+	}
+
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      networkPolicyPrefix + req.Name,
+			Namespace: description.Namespace,
+			Labels: map[string]string{
+				namespaceLabelKey: req.Deployment.Namespace,
+				workloadLabelKey:  req.Name,
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: description.Selector},
+		},
+	}
+	if err := setNetworkPolicyRules(policy, []*paragliderpb.PermitListRule{}); err != nil {
+		return nil, err
+	}
+	policy, err = client.NetworkingV1().NetworkPolicies(description.Namespace).Create(ctx, policy, metav1.CreateOptions{})
+	if err != nil {
+		return nil, getStatusError(err, "unable to create workload %s", req.Name)
+	}
+	ips, _, err := getWorkloadInfo(ctx, client, policy)
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.CreateResourceResponse{Name: req.Name, Uri: getWorkloadUri(cluster.Name, description.Namespace, req.Name), Ip: getPrimaryIp(ips)}, nil
+}
+
+// GetUsedAddressSpaces returns the pod CIDRs declared for the clusters
+func (s *KubernetesPluginServer) GetUsedAddressSpaces(ctx context.Context, req *paragliderpb.GetUsedAddressSpacesRequest) (*paragliderpb.GetUsedAddressSpacesResponse, error) {
+	resp := &paragliderpb.GetUsedAddressSpacesResponse{}
+	resp.AddressSpaceMappings = make([]*paragliderpb.AddressSpaceMapping, len(req.Deployments))
+	for i, deployment := range req.Deployments {
+		cluster, _, err := s.getDeploymentCluster(deployment)
+		if err != nil {
+			return nil, err
+		}
+		resp.AddressSpaceMappings[i] = &paragliderpb.AddressSpaceMapping{
+			AddressSpaces: cluster.AddressSpaces,
+			Cloud:         utils.KUBERNETES,
+			Namespace:     deployment.Namespace,
+		}
+	}
+	return resp, nil
+}
+
+// GetUsedAsns returns no ASNs since clusters are not connected with VPNs
+func (s *KubernetesPluginServer) GetUsedAsns(ctx context.Context, req *paragliderpb.GetUsedAsnsRequest) (*paragliderpb.GetUsedAsnsResponse, error) {
+	return &paragliderpb.GetUsedAsnsResponse{}, nil
+}
+
+// GetUsedBgpPeeringIpAddresses returns no addresses since clusters are not connected with VPNs
+func (s *KubernetesPluginServer) GetUsedBgpPeeringIpAddresses(ctx context.Context, req *paragliderpb.GetUsedBgpPeeringIpAddressesRequest) (*paragliderpb.GetUsedBgpPeeringIpAddressesResponse, error) {
+	return &paragliderpb.GetUsedBgpPeeringIpAddressesResponse{}, nil
+}
+
+func getErrorStatusCode(err error) (int, bool) {
+	return 0, false
+}
+
+func Setup(port int, server *KubernetesPluginServer) (string, error) {
+	lis, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		return "", fmt.Errorf("failed to listen: %w", err)
+	}
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(utils.StatusErrorInterceptor(utils.KUBERNETES, getErrorStatusCode)))
+	paragliderpb.RegisterCloudPluginServer(grpcServer, server)
+	fmt.Println("Starting server on port :", port)
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			fmt.Println(err.Error())
+		}
+	}()
+	if server.config.PodSyncInterval > 0 {
+		go server.syncPodTagsPeriodically(context.Background(), time.Duration(server.config.PodSyncInterval)*time.Second)
+	}
+	return lis.Addr().String(), nil
+}
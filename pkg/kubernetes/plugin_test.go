@@ -0,0 +1,334 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	fakeCluster             = "prod"
+	fakeNamespace           = "default"
+	fakeKubernetesNamespace = "shop"
+	fakeWorkload            = "web"
+	fakePodAddressSpace     = "10.244.0.0/16"
+)
+
+var (
+	fakeDeployment  = &paragliderpb.ParagliderDeployment{Id: getDeploymentId(fakeCluster), Namespace: fakeNamespace}
+	fakeWorkloadUri = getWorkloadUri(fakeCluster, fakeKubernetesNamespace, fakeWorkload)
+	fakeSelector    = map[string]string{"app": fakeWorkload}
+)
+
+func getFakeConfig(t *testing.T) *Config {
+	cfg, err := ParseConfig([]byte(fmt.Sprintf(`
+clusters:
+  - name: %s
+    addressSpaces: [%s]
+`, fakeCluster, fakePodAddressSpace)))
+	require.NoError(t, err)
+	return cfg
+}
+
+func getFakePod(name string, podLabels map[string]string, ip string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: fakeKubernetesNamespace, Labels: podLabels},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: ip},
+	}
+}
+
+// Fake orchestrator which records the tags registered by the plugin
+type fakeOrchestratorServer struct {
+	paragliderpb.UnimplementedControllerServer
+	mu                         sync.Mutex
+	refreshResourceTagRequests []*paragliderpb.RefreshResourceTagRequest
+	deleteResourceTagRequests  []*paragliderpb.DeleteResourceTagRequest
+}
+
+func (f *fakeOrchestratorServer) RefreshResourceTag(ctx context.Context, req *paragliderpb.RefreshResourceTagRequest) (*paragliderpb.RefreshResourceTagResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refreshResourceTagRequests = append(f.refreshResourceTagRequests, req)
+	return &paragliderpb.RefreshResourceTagResponse{Version: int64(len(f.refreshResourceTagRequests))}, nil
+}
+
+func (f *fakeOrchestratorServer) DeleteResourceTag(ctx context.Context, req *paragliderpb.DeleteResourceTagRequest) (*paragliderpb.DeleteResourceTagResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleteResourceTagRequests = append(f.deleteResourceTagRequests, req)
+	return &paragliderpb.DeleteResourceTagResponse{}, nil
+}
+
+// Sets up a fake orchestrator and returns a plugin server using it with a fake cluster containing the pods
+func setupPluginServer(t *testing.T, pods ...*corev1.Pod) (*fakeOrchestratorServer, *KubernetesPluginServer, *k8sfake.Clientset) {
+	fakeOrchestrator := &fakeOrchestratorServer{}
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	paragliderpb.RegisterControllerServer(grpcServer, fakeOrchestrator)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	client := k8sfake.NewClientset()
+	for _, pod := range pods {
+		_, err := client.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	s := NewKubernetesPluginServer(lis.Addr().String(), getFakeConfig(t), map[string]k8s.Interface{fakeCluster: client})
+	return fakeOrchestrator, s, client
+}
+
+func createWorkload(t *testing.T, s *KubernetesPluginServer, name string, selector map[string]string) *paragliderpb.CreateResourceResponse {
+	description, err := json.Marshal(&WorkloadDescription{Namespace: fakeKubernetesNamespace, Selector: selector})
+	require.NoError(t, err)
+	resp, err := s.CreateResource(context.Background(), &paragliderpb.CreateResourceRequest{Deployment: fakeDeployment, Name: name, Description: description})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte("clusters:\n  - name: prod\n    kubeconfig: /etc/kubeconfig\n    context: admin\n"))
+	require.NoError(t, err)
+	assert.Equal(t, defaultPodSyncInterval, cfg.PodSyncInterval)
+	assert.Equal(t, "/etc/kubeconfig", cfg.Clusters[0].Kubeconfig)
+
+	invalid := map[string]string{
+		"invalid name":          "clusters:\n  - name: Prod_1\n",
+		"duplicate cluster":     "clusters:\n  - name: prod\n  - name: prod\n",
+		"invalid address space": "clusters:\n  - name: prod\n    addressSpaces: [10.0.0.0]\n",
+		"unknown field":         "clusters:\n  - name: prod\n    region: us\n",
+	}
+	for name, data := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseConfig([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCreateResource(t *testing.T) {
+	_, s, client := setupPluginServer(t,
+		getFakePod("web-b", fakeSelector, "10.244.0.6"),
+		getFakePod("web-a", fakeSelector, "10.244.0.5"),
+		getFakePod("db", map[string]string{"app": "db"}, "10.244.0.7"),
+	)
+	ctx := context.Background()
+
+	resp := createWorkload(t, s, fakeWorkload, fakeSelector)
+	assert.Equal(t, fakeWorkloadUri, resp.Uri)
+	assert.Equal(t, "10.244.0.5", resp.Ip)
+
+	// The workload's network policy denies all traffic of its pods
+	policy, err := client.NetworkingV1().NetworkPolicies(fakeKubernetesNamespace).Get(ctx, networkPolicyPrefix+fakeWorkload, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, fakeSelector, policy.Spec.PodSelector.MatchLabels)
+	assert.Len(t, policy.Spec.PolicyTypes, 2)
+	assert.Empty(t, policy.Spec.Ingress)
+	assert.Empty(t, policy.Spec.Egress)
+	assert.Equal(t, fakeNamespace, policy.Labels[namespaceLabelKey])
+
+	// Workloads can't be created twice, even from another namespace
+	description, _ := json.Marshal(&WorkloadDescription{Namespace: fakeKubernetesNamespace, Selector: fakeSelector})
+	_, err = s.CreateResource(ctx, &paragliderpb.CreateResourceRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: fakeDeployment.Id, Namespace: "other"}, Name: fakeWorkload, Description: description})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	// Workloads without pods have no IP yet
+	resp = createWorkload(t, s, "cache", map[string]string{"app": "cache"})
+	assert.Empty(t, resp.Ip)
+
+	invalid := map[string]*paragliderpb.CreateResourceRequest{
+		"invalid name":        {Deployment: fakeDeployment, Name: "Web_1", Description: description},
+		"no selector":         {Deployment: fakeDeployment, Name: "empty", Description: []byte(`{"namespace": "shop"}`)},
+		"invalid description": {Deployment: fakeDeployment, Name: "broken", Description: []byte(`{`)},
+		"unknown cluster":     {Deployment: &paragliderpb.ParagliderDeployment{Id: getDeploymentId("staging"), Namespace: fakeNamespace}, Name: "web2", Description: description},
+	}
+	for name, req := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := s.CreateResource(ctx, req)
+			assert.Error(t, err)
+		})
+	}
+
+	listResp, err := s.ListResources(ctx, &paragliderpb.ListResourcesRequest{Deployment: fakeDeployment})
+	require.NoError(t, err)
+	require.Len(t, listResp.Resources, 2)
+	assert.Equal(t, "cache", listResp.Resources[0].Name)
+	assert.Equal(t, noPodsState, listResp.Resources[0].State)
+	assert.Equal(t, fakeWorkload, listResp.Resources[1].Name)
+	assert.Equal(t, "10.244.0.5", listResp.Resources[1].Ip)
+	assert.Equal(t, string(corev1.PodRunning), listResp.Resources[1].State)
+}
+
+func TestPermitListRules(t *testing.T) {
+	_, s, client := setupPluginServer(t, getFakePod("web-a", fakeSelector, "10.244.0.5"))
+	ctx := context.Background()
+	createWorkload(t, s, fakeWorkload, fakeSelector)
+
+	rules := []*paragliderpb.PermitListRule{
+		{Name: "http", Direction: paragliderpb.Direction_INBOUND, SrcPort: -1, DstPort: 80, Protocol: 6, Targets: []string{"10.1.0.5"}, Tags: []string{"default.gcp.vm"}},
+		{Name: "db", Direction: paragliderpb.Direction_OUTBOUND, SrcPort: -1, DstPort: 5432, Protocol: 6, Targets: []string{"10.244.0.7"}},
+	}
+	_, err := s.AddPermitListRules(ctx, &paragliderpb.AddPermitListRulesRequest{Namespace: fakeNamespace, Resource: fakeWorkloadUri, Rules: rules})
+	require.NoError(t, err)
+
+	policy, err := client.NetworkingV1().NetworkPolicies(fakeKubernetesNamespace).Get(ctx, networkPolicyPrefix+fakeWorkload, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, policy.Spec.Ingress, 1)
+	assert.Equal(t, "10.1.0.5/32", policy.Spec.Ingress[0].From[0].IPBlock.CIDR)
+	require.Len(t, policy.Spec.Egress, 1)
+	assert.Equal(t, "10.244.0.7/32", policy.Spec.Egress[0].To[0].IPBlock.CIDR)
+
+	resp, err := s.GetResource(ctx, &paragliderpb.GetResourceRequest{Namespace: fakeNamespace, Uri: fakeWorkloadUri})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), resp.Resource.RuleCount)
+
+	// Rules which network policies can't express are rejected
+	icmp := &paragliderpb.PermitListRule{Name: "ping", Direction: paragliderpb.Direction_INBOUND, SrcPort: -1, DstPort: -1, Protocol: 1, Targets: []string{"10.1.0.5"}}
+	_, err = s.AddPermitListRules(ctx, &paragliderpb.AddPermitListRulesRequest{Namespace: fakeNamespace, Resource: fakeWorkloadUri, Rules: []*paragliderpb.PermitListRule{icmp}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.DeletePermitListRules(ctx, &paragliderpb.DeletePermitListRulesRequest{Namespace: fakeNamespace, Resource: fakeWorkloadUri, RuleNames: []string{"http", "db"}})
+	require.NoError(t, err)
+	policy, err = client.NetworkingV1().NetworkPolicies(fakeKubernetesNamespace).Get(ctx, networkPolicyPrefix+fakeWorkload, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, policy.Spec.Ingress)
+	assert.Empty(t, policy.Spec.Egress)
+
+	// Network policies not created by Paraglider are not workloads
+	_, err = client.NetworkingV1().NetworkPolicies(fakeKubernetesNamespace).Create(ctx, &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: networkPolicyPrefix + "manual", Namespace: fakeKubernetesNamespace}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = s.GetPermitList(ctx, &paragliderpb.GetPermitListRequest{Namespace: fakeNamespace, Resource: getWorkloadUri(fakeCluster, fakeKubernetesNamespace, "manual")})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGetPodResourceInfo(t *testing.T) {
+	dualStackPod := getFakePod("web-b", fakeSelector, "10.244.0.6")
+	dualStackPod.Status.PodIPs = []corev1.PodIP{{IP: "10.244.0.6"}, {IP: "fd00:10:244::6"}}
+	_, s, _ := setupPluginServer(t,
+		getFakePod("web-a", fakeSelector, "10.244.0.5"),
+		dualStackPod,
+		getFakePod("db", map[string]string{"app": "db"}, "10.244.0.7"),
+	)
+	ctx := context.Background()
+	createWorkload(t, s, fakeWorkload, fakeSelector)
+
+	info, err := s.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Namespace: fakeNamespace, Uri: getPodUri(fakeCluster, fakeKubernetesNamespace, "web-a")})
+	require.NoError(t, err)
+	assert.Equal(t, "10.244.0.5", info.Ip)
+	assert.Equal(t, []string{"10.244.0.5"}, info.Ips)
+	assert.Equal(t, string(corev1.PodRunning), info.State)
+
+	// Pods of dual-stack clusters have an IP of each family
+	info, err = s.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Namespace: fakeNamespace, Uri: getPodUri(fakeCluster, fakeKubernetesNamespace, "web-b")})
+	require.NoError(t, err)
+	assert.Equal(t, "10.244.0.6", info.Ip)
+	assert.Equal(t, []string{"10.244.0.6", "fd00:10:244::6"}, info.Ips)
+
+	// Workloads have the IPs of all their pods, starting with the one of their first pod
+	info, err = s.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Namespace: fakeNamespace, Uri: fakeWorkloadUri})
+	require.NoError(t, err)
+	assert.Equal(t, "10.244.0.5", info.Ip)
+	assert.Equal(t, []string{"10.244.0.5", "10.244.0.6", "fd00:10:244::6"}, info.Ips)
+
+	// Pods are only resources of the namespaces of the workloads selecting them
+	_, err = s.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Namespace: "other", Uri: getPodUri(fakeCluster, fakeKubernetesNamespace, "web-a")})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = s.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Namespace: fakeNamespace, Uri: getPodUri(fakeCluster, fakeKubernetesNamespace, "db")})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = s.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Namespace: fakeNamespace, Uri: getPodUri(fakeCluster, fakeKubernetesNamespace, "missing")})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestSyncPodTags(t *testing.T) {
+	fakeOrchestrator, s, client := setupPluginServer(t,
+		getFakePod("web-a", fakeSelector, "10.244.0.5"),
+		getFakePod("web.b", fakeSelector, "10.244.0.6"),
+		getFakePod("web-pending", fakeSelector, ""),
+		getFakePod("db", map[string]string{"app": "db"}, "10.244.0.7"),
+	)
+	ctx := context.Background()
+	createWorkload(t, s, fakeWorkload, fakeSelector)
+
+	require.NoError(t, s.syncPodTags(ctx))
+	require.Len(t, fakeOrchestrator.refreshResourceTagRequests, 2)
+	req := fakeOrchestrator.refreshResourceTagRequests[0]
+	assert.Equal(t, fakeNamespace, req.Namespace)
+	assert.Equal(t, utils.KUBERNETES, req.Cloud)
+	assert.Equal(t, "shop_web-a", req.Name)
+	assert.Equal(t, getPodUri(fakeCluster, fakeKubernetesNamespace, "web-a"), req.Uri)
+	assert.Equal(t, "10.244.0.5", req.Ip)
+	assert.Equal(t, map[string]string{clusterTagLabel: fakeCluster, namespaceTagLabel: fakeKubernetesNamespace, workloadTagLabel: fakeWorkload}, req.Labels)
+	assert.Equal(t, "shop_web_b", fakeOrchestrator.refreshResourceTagRequests[1].Name)
+
+	// Unchanged pods are not registered again
+	require.NoError(t, s.syncPodTags(ctx))
+	assert.Len(t, fakeOrchestrator.refreshResourceTagRequests, 2)
+
+	// Pods which get a new IP are registered again
+	pod, err := client.CoreV1().Pods(fakeKubernetesNamespace).Get(ctx, "web-a", metav1.GetOptions{})
+	require.NoError(t, err)
+	pod.Status.PodIP = "10.244.0.8"
+	_, err = client.CoreV1().Pods(fakeKubernetesNamespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, s.syncPodTags(ctx))
+	require.Len(t, fakeOrchestrator.refreshResourceTagRequests, 3)
+	assert.Equal(t, "10.244.0.8", fakeOrchestrator.refreshResourceTagRequests[2].Ip)
+	assert.Empty(t, fakeOrchestrator.deleteResourceTagRequests)
+
+	// The tags of deleted pods are deleted once
+	require.NoError(t, client.CoreV1().Pods(fakeKubernetesNamespace).Delete(ctx, "web.b", metav1.DeleteOptions{}))
+	require.NoError(t, s.syncPodTags(ctx))
+	require.Len(t, fakeOrchestrator.deleteResourceTagRequests, 1)
+	deleteReq := fakeOrchestrator.deleteResourceTagRequests[0]
+	assert.Equal(t, fakeNamespace, deleteReq.Namespace)
+	assert.Equal(t, utils.KUBERNETES, deleteReq.Cloud)
+	assert.Equal(t, "shop_web_b", deleteReq.Name)
+	require.NoError(t, s.syncPodTags(ctx))
+	assert.Len(t, fakeOrchestrator.deleteResourceTagRequests, 1)
+	assert.Len(t, fakeOrchestrator.refreshResourceTagRequests, 3)
+}
+
+func TestGetPodTagName(t *testing.T) {
+	pod := func(namespace string, name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+	assert.Equal(t, "shop_web_b", getPodTagName(pod("shop", "web.b")))
+
+	// Names which only differ in their separators get different tags
+	assert.NotEqual(t, getPodTagName(pod("shop", "web.b")), getPodTagName(pod("shop", "web-b")))
+	assert.NotEqual(t, getPodTagName(pod("a", "b-c")), getPodTagName(pod("a-b", "c")))
+}
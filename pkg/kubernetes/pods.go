@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Labels of the tags of pods, so a tag with a selector (eg, workload=web) resolves to the IPs of all pods of a workload
+const (
+	clusterTagLabel   = "cluster"
+	namespaceTagLabel = "k8s-namespace"
+	workloadTagLabel  = "workload"
+)
+
+// Tag registered for a pod
+type podTag struct {
+	namespace string // Paraglider namespace of the pod's workload
+	name      string
+	ip        string
+}
+
+// Gets the name of a pod's tag, which can't contain dots since they separate the parts of tag names
+// Underscores can't appear in Kubernetes namespaces or pod names, so they separate the namespace and replace the dots of the pod name
+// This keeps the names of different pods distinct (eg, web.b and web-b, or pod b-c in namespace a and pod c in namespace a-b)
+func getPodTagName(pod *corev1.Pod) string {
+	return pod.Namespace + "_" + strings.ReplaceAll(pod.Name, ".", "_")
+}
+
+// Register the IPs of the pods of all workloads as tags (namespace.kubernetes.<k8s namespace>_<pod>)
+// Pods whose IP has not changed since they were last registered are skipped, and the tags of pods which no longer exist are deleted
+func (s *KubernetesPluginServer) syncPodTags(ctx context.Context) error {
+	conn, err := grpc.NewClient(s.orchestratorServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	orchestratorClient := paragliderpb.NewControllerClient(conn)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	podTags := make(map[string]podTag)
+	for _, cluster := range s.config.Clusters {
+		client := s.clients[cluster.Name]
+		policies, err := client.NetworkingV1().NetworkPolicies(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: namespaceLabelKey})
+		if err != nil {
+			return getStatusError(err, "unable to list workloads of cluster %s", cluster.Name)
+		}
+		sort.Slice(policies.Items, func(i, j int) bool { return policies.Items[i].Name < policies.Items[j].Name })
+		for i := range policies.Items {
+			policy := &policies.Items[i]
+			pods, err := getWorkloadPods(ctx, client, policy)
+			if err != nil {
+				return err
+			}
+			for j := range pods {
+				pod := &pods[j]
+				uri := getPodUri(cluster.Name, pod.Namespace, pod.Name)
+				tag := podTag{namespace: policy.Labels[namespaceLabelKey], name: getPodTagName(pod), ip: pod.Status.PodIP}
+				podTags[uri] = tag
+				if s.podTags[uri] == tag {
+					continue
+				}
+				_, err := orchestratorClient.RefreshResourceTag(ctx, &paragliderpb.RefreshResourceTagRequest{
+					Namespace: tag.namespace,
+					Cloud:     utils.KUBERNETES,
+					Name:      tag.name,
+					Uri:       uri,
+					Ip:        pod.Status.PodIP,
+					Labels: map[string]string{
+						clusterTagLabel:   cluster.Name,
+						namespaceTagLabel: pod.Namespace,
+						workloadTagLabel:  policy.Labels[workloadLabelKey],
+					},
+				})
+				if err != nil {
+					return err
+				}
+				s.podTags[uri] = tag
+			}
+		}
+	}
+
+	// Delete the tags of pods which were deleted or left their workload, so a pod which reuses the IP gets none of their access
+	for uri, tag := range s.podTags {
+		if _, ok := podTags[uri]; ok {
+			continue
+		}
+		_, err := orchestratorClient.DeleteResourceTag(ctx, &paragliderpb.DeleteResourceTagRequest{Namespace: tag.namespace, Cloud: utils.KUBERNETES, Name: tag.name})
+		if err != nil {
+			// Keep the tag so deleting it is retried by the next sync
+			podTags[uri] = tag
+			utils.Log.Printf("Failed to delete tag %s of pod %s: %v\n", tag.name, uri, err)
+		}
+	}
+	s.podTags = podTags
+	return nil
+}
+
+// Periodically register the IPs of pods as tags
+func (s *KubernetesPluginServer) syncPodTagsPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.syncPodTags(ctx); err != nil {
+			utils.Log.Printf("Failed to register pod tags: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
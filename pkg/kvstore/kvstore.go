@@ -23,7 +23,7 @@ import (
 	"net"
 
 	storepb "github.com/paraglider-project/paraglider/pkg/kvstore/storepb"
-	redis "github.com/redis/go-redis/v9"
+	storage "github.com/paraglider-project/paraglider/pkg/storage"
 	"google.golang.org/grpc"
 )
 
@@ -33,17 +33,17 @@ func GetFullKey(key string, cloud string, namespace string) string {
 
 type kvStoreServer struct {
 	storepb.UnimplementedKVStoreServer
-	client *redis.Client
+	store storage.Store
 }
 
-func NewKVStoreServer(client *redis.Client) *kvStoreServer {
+func NewKVStoreServer(store storage.Store) *kvStoreServer {
 	return &kvStoreServer{
-		client: client,
+		store: store,
 	}
 }
 
 func (s *kvStoreServer) Get(ctx context.Context, req *storepb.GetRequest) (*storepb.GetResponse, error) {
-	value, err := s.client.Get(ctx, GetFullKey(req.Key, req.Cloud, req.Namespace)).Result()
+	value, err := s.store.Get(ctx, GetFullKey(req.Key, req.Cloud, req.Namespace))
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +53,7 @@ func (s *kvStoreServer) Get(ctx context.Context, req *storepb.GetRequest) (*stor
 }
 
 func (s *kvStoreServer) Set(ctx context.Context, req *storepb.SetRequest) (*storepb.SetResponse, error) {
-	err := s.client.Set(ctx, GetFullKey(req.Key, req.Cloud, req.Namespace), req.Value, 0).Err()
+	err := s.store.Set(ctx, GetFullKey(req.Key, req.Cloud, req.Namespace), req.Value)
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +61,7 @@ func (s *kvStoreServer) Set(ctx context.Context, req *storepb.SetRequest) (*stor
 }
 
 func (s *kvStoreServer) Delete(ctx context.Context, req *storepb.DeleteRequest) (*storepb.DeleteResponse, error) {
-	err := s.client.Del(ctx, GetFullKey(req.Key, req.Cloud, req.Namespace)).Err()
+	err := s.store.Del(ctx, GetFullKey(req.Key, req.Cloud, req.Namespace))
 	if err != nil {
 		return nil, err
 	}
@@ -69,15 +69,12 @@ func (s *kvStoreServer) Delete(ctx context.Context, req *storepb.DeleteRequest)
 }
 
 // Setup and run the server
-func Setup(dbPort int, serverPort int, clearKeys bool) {
-	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("localhost:%d", dbPort),
-		Password: "", // no password set
-		DB:       0,  // use default DB
-	})
+func Setup(store storage.Store, serverPort int, clearKeys bool) {
 	if clearKeys {
 		fmt.Printf("Flushed all keys.")
-		client.FlushAll(context.Background())
+		if err := store.FlushAll(context.Background()); err != nil {
+			fmt.Printf("Failed to flush keys: %v\n", err)
+		}
 	}
 
 	lis, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", serverPort))
@@ -86,7 +83,7 @@ func Setup(dbPort int, serverPort int, clearKeys bool) {
 	}
 	var opts []grpc.ServerOption
 	grpcServer := grpc.NewServer(opts...)
-	storepb.RegisterKVStoreServer(grpcServer, NewKVStoreServer(client))
+	storepb.RegisterKVStoreServer(grpcServer, NewKVStoreServer(store))
 	fmt.Printf("Serving KV Store at localhost:%d", serverPort)
 	go func(){
 		err = grpcServer.Serve(lis)
@@ -24,13 +24,14 @@ import (
 
 	"github.com/go-redis/redismock/v9"
 	storepb "github.com/paraglider-project/paraglider/pkg/kvstore/storepb"
+	storage "github.com/paraglider-project/paraglider/pkg/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestSet(t *testing.T) {
 	db, mock := redismock.NewClientMock()
-	server := NewKVStoreServer(db)
+	server := NewKVStoreServer(storage.NewRedisStore(db))
 
 	key := "test"
 	value := "value"
@@ -50,7 +51,7 @@ func TestSet(t *testing.T) {
 
 func TestGet(t *testing.T) {
 	db, mock := redismock.NewClientMock()
-	server := NewKVStoreServer(db)
+	server := NewKVStoreServer(storage.NewRedisStore(db))
 
 	key := "test"
 	value := "value"
@@ -71,7 +72,7 @@ func TestGet(t *testing.T) {
 
 func TestDelete(t *testing.T) {
 	db, mock := redismock.NewClientMock()
-	server := NewKVStoreServer(db)
+	server := NewKVStoreServer(storage.NewRedisStore(db))
 
 	key := "test"
 	cloud := "cloud"
@@ -0,0 +1,249 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func copyStrings(in []string) []string {
+	if in == nil {
+		return nil
+	}
+	out := make([]string, len(in))
+	copy(out, in)
+	return out
+}
+
+func copyString(in *string) *string {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	return &out
+}
+
+func copyConditions(in []metav1.Condition) []metav1.Condition {
+	if in == nil {
+		return nil
+	}
+	out := make([]metav1.Condition, len(in))
+	for i := range in {
+		in[i].DeepCopyInto(&out[i])
+	}
+	return out
+}
+
+func (in *ParagliderTagSpec) DeepCopyInto(out *ParagliderTagSpec) {
+	*out = *in
+	out.Members = copyStrings(in.Members)
+	out.Uri = copyString(in.Uri)
+	out.Ip = copyString(in.Ip)
+	out.Selector = copyString(in.Selector)
+	if in.Labels != nil {
+		out.Labels = make(map[string]string, len(in.Labels))
+		for key, value := range in.Labels {
+			out.Labels[key] = value
+		}
+	}
+}
+
+func (in *ParagliderTagStatus) DeepCopyInto(out *ParagliderTagStatus) {
+	*out = *in
+	out.Members = copyStrings(in.Members)
+	out.Conditions = copyConditions(in.Conditions)
+}
+
+func (in *ParagliderTag) DeepCopyInto(out *ParagliderTag) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+func (in *ParagliderTag) DeepCopy() *ParagliderTag {
+	if in == nil {
+		return nil
+	}
+	out := new(ParagliderTag)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ParagliderTag) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+func (in *ParagliderTagList) DeepCopyInto(out *ParagliderTagList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]ParagliderTag, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+func (in *ParagliderTagList) DeepCopy() *ParagliderTagList {
+	if in == nil {
+		return nil
+	}
+	out := new(ParagliderTagList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ParagliderTagList) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+func (in *ResourceReference) DeepCopy() *ResourceReference {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	return &out
+}
+
+func (in *PermitListRule) DeepCopyInto(out *PermitListRule) {
+	*out = *in
+	out.Tags = copyStrings(in.Tags)
+}
+
+func (in *ParagliderPermitListSpec) DeepCopyInto(out *ParagliderPermitListSpec) {
+	*out = *in
+	out.Resource = in.Resource.DeepCopy()
+	if in.Rules != nil {
+		out.Rules = make([]PermitListRule, len(in.Rules))
+		for i := range in.Rules {
+			in.Rules[i].DeepCopyInto(&out.Rules[i])
+		}
+	}
+}
+
+func (in *ParagliderPermitListStatus) DeepCopyInto(out *ParagliderPermitListStatus) {
+	*out = *in
+	out.Resource = in.Resource.DeepCopy()
+	out.Rules = copyStrings(in.Rules)
+	out.Conditions = copyConditions(in.Conditions)
+}
+
+func (in *ParagliderPermitList) DeepCopyInto(out *ParagliderPermitList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+func (in *ParagliderPermitList) DeepCopy() *ParagliderPermitList {
+	if in == nil {
+		return nil
+	}
+	out := new(ParagliderPermitList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ParagliderPermitList) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+func (in *ParagliderPermitListList) DeepCopyInto(out *ParagliderPermitListList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]ParagliderPermitList, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+func (in *ParagliderPermitListList) DeepCopy() *ParagliderPermitListList {
+	if in == nil {
+		return nil
+	}
+	out := new(ParagliderPermitListList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ParagliderPermitListList) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+func (in *ParagliderResourceSpec) DeepCopyInto(out *ParagliderResourceSpec) {
+	*out = *in
+	in.Description.DeepCopyInto(&out.Description)
+}
+
+func (in *ParagliderResourceStatus) DeepCopyInto(out *ParagliderResourceStatus) {
+	*out = *in
+	out.Conditions = copyConditions(in.Conditions)
+}
+
+func (in *ParagliderResource) DeepCopyInto(out *ParagliderResource) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+func (in *ParagliderResource) DeepCopy() *ParagliderResource {
+	if in == nil {
+		return nil
+	}
+	out := new(ParagliderResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ParagliderResource) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+func (in *ParagliderResourceList) DeepCopyInto(out *ParagliderResourceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]ParagliderResource, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+func (in *ParagliderResourceList) DeepCopy() *ParagliderResourceList {
+	if in == nil {
+		return nil
+	}
+	out := new(ParagliderResourceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ParagliderResourceList) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Condition set on every custom resource once it has been reconciled
+const ConditionReady = "Ready"
+
+// A Paraglider tag grouping other tags, or naming a URI and/or IP
+type ParagliderTag struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ParagliderTagSpec   `json:"spec,omitempty"`
+	Status ParagliderTagStatus `json:"status,omitempty"`
+}
+
+type ParagliderTagSpec struct {
+	// Name of the tag in Paraglider, defaults to the object's name
+	TagName  string            `json:"tagName,omitempty"`
+	Members  []string          `json:"members,omitempty"`
+	Uri      *string           `json:"uri,omitempty"`
+	Ip       *string           `json:"ip,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Selector *string           `json:"selector,omitempty"`
+}
+
+type ParagliderTagStatus struct {
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Name of the tag that was set, and its members, so that they can be removed later
+	TagName    string             `json:"tagName,omitempty"`
+	Members    []string           `json:"members,omitempty"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+type ParagliderTagList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ParagliderTag `json:"items"`
+}
+
+// Rules attached to a tag or added to the permit list of a resource
+type ParagliderPermitList struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ParagliderPermitListSpec   `json:"spec,omitempty"`
+	Status ParagliderPermitListStatus `json:"status,omitempty"`
+}
+
+// Exactly one of Tag and Resource is set
+type ParagliderPermitListSpec struct {
+	Tag      string             `json:"tag,omitempty"`
+	Resource *ResourceReference `json:"resource,omitempty"`
+	Rules    []PermitListRule   `json:"rules,omitempty"`
+}
+
+// A resource in Paraglider, in the operator's default namespace unless one is given
+type ResourceReference struct {
+	Namespace string `json:"namespace,omitempty"`
+	Cloud     string `json:"cloud"`
+	Name      string `json:"name"`
+}
+
+type PermitListRule struct {
+	Name string `json:"name"`
+	// Tags, IPs or CIDRs the rule allows traffic to or from
+	Tags []string `json:"tags"`
+	// INBOUND (default) or OUTBOUND
+	Direction string `json:"direction,omitempty"`
+	SrcPort   int32  `json:"srcPort"`
+	DstPort   int32  `json:"dstPort"`
+	Protocol  int32  `json:"protocol"`
+}
+
+type ParagliderPermitListStatus struct {
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Where the rules were last applied and their names, so that they can be removed later
+	Tag        string             `json:"tag,omitempty"`
+	Resource   *ResourceReference `json:"resource,omitempty"`
+	Rules      []string           `json:"rules,omitempty"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+type ParagliderPermitListList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ParagliderPermitList `json:"items"`
+}
+
+// A cloud resource created through Paraglider
+type ParagliderResource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ParagliderResourceSpec   `json:"spec,omitempty"`
+	Status ParagliderResourceStatus `json:"status,omitempty"`
+}
+
+type ParagliderResourceSpec struct {
+	// Paraglider namespace, defaults to the operator's
+	Namespace string `json:"namespace,omitempty"`
+	Cloud     string `json:"cloud"`
+	// Name of the resource in Paraglider, defaults to the object's name
+	Name string `json:"name,omitempty"`
+	// Cloud-specific description of the resource (same format as glide resource create)
+	Description runtime.RawExtension `json:"description"`
+}
+
+type ParagliderResourceStatus struct {
+	ObservedGeneration int64              `json:"observedGeneration,omitempty"`
+	Tag                string             `json:"tag,omitempty"`
+	Uri                string             `json:"uri,omitempty"`
+	Ip                 string             `json:"ip,omitempty"`
+	Conditions         []metav1.Condition `json:"conditions,omitempty"`
+}
+
+type ParagliderResourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ParagliderResource `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ParagliderTag{}, &ParagliderTagList{})
+	SchemeBuilder.Register(&ParagliderPermitList{}, &ParagliderPermitListList{})
+	SchemeBuilder.Register(&ParagliderResource{}, &ParagliderResourceList{})
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	paragliderclient "github.com/paraglider-project/paraglider/pkg/client"
+	"github.com/paraglider-project/paraglider/pkg/operator/api/v1alpha1"
+)
+
+// Finalizer added to tags, permit lists and resources so they are removed from Paraglider before being deleted
+const Finalizer = "paragliderproject.io/finalizer"
+
+// Create a scheme with the built-in types and the Paraglider custom resources
+func NewScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
+
+// Run the operator against the cluster in the current kubeconfig until it is interrupted
+func Setup(controllerAddress string, namespace string, metricsAddress string) error {
+	scheme, err := NewScheme()
+	if err != nil {
+		return err
+	}
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return fmt.Errorf("could not get kubeconfig: %w", err)
+	}
+	mgr, err := ctrl.NewManager(config, ctrl.Options{Scheme: scheme, Metrics: metricsserver.Options{BindAddress: metricsAddress}})
+	if err != nil {
+		return err
+	}
+
+	paraglider := paragliderclient.NewClient(controllerAddress)
+	if err := (&TagReconciler{Client: mgr.GetClient(), Paraglider: paraglider}).SetupWithManager(mgr); err != nil {
+		return err
+	}
+	if err := (&PermitListReconciler{Client: mgr.GetClient(), Paraglider: paraglider, Namespace: namespace}).SetupWithManager(mgr); err != nil {
+		return err
+	}
+	if err := (&ResourceReconciler{Client: mgr.GetClient(), Paraglider: paraglider, Namespace: namespace}).SetupWithManager(mgr); err != nil {
+		return err
+	}
+
+	return mgr.Start(ctrl.SetupSignalHandler())
+}
+
+// Record the outcome of a reconciliation in the Ready condition
+func setReadyCondition(conditions *[]metav1.Condition, generation int64, err error) {
+	condition := metav1.Condition{
+		Type:               v1alpha1.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "Synced",
+		Message:            "Synced with the Paraglider controller",
+	}
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "SyncFailed"
+		condition.Message = err.Error()
+	}
+	meta.SetStatusCondition(conditions, condition)
+}
+
+// Run the cleanup and remove the finalizer of an object being deleted
+func finalize(ctx context.Context, c client.Client, obj client.Object, cleanup func() error) error {
+	if !controllerutil.ContainsFinalizer(obj, Finalizer) {
+		return nil
+	}
+	if err := cleanup(); err != nil {
+		return err
+	}
+	controllerutil.RemoveFinalizer(obj, Finalizer)
+	return c.Update(ctx, obj)
+}
+
+// Add the finalizer to an object which does not have it yet
+func addFinalizer(ctx context.Context, c client.Client, obj client.Object) error {
+	if !controllerutil.AddFinalizer(obj, Finalizer) {
+		return nil
+	}
+	return c.Update(ctx, obj)
+}
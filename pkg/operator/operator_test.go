@@ -0,0 +1,189 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	paragliderclient "github.com/paraglider-project/paraglider/pkg/client"
+	fakerest "github.com/paraglider-project/paraglider/pkg/fake/orchestrator/rest"
+	"github.com/paraglider-project/paraglider/pkg/operator/api/v1alpha1"
+)
+
+const objectNamespace = "paraglider"
+
+// Set up a fake Kubernetes client with the given objects and a client for a fake controller
+func setupFakes(t *testing.T, objs ...client.Object) (client.Client, *paragliderclient.Client) {
+	scheme, err := NewScheme()
+	require.Nil(t, err)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithStatusSubresource(objs...).Build()
+
+	s := fakerest.FakeOrchestratorRESTServer{}
+	return k8sClient, paragliderclient.NewClient(s.SetupFakeOrchestratorRESTServer())
+}
+
+func getRequest(name string) ctrl.Request {
+	return ctrl.Request{NamespacedName: types.NamespacedName{Namespace: objectNamespace, Name: name}}
+}
+
+func getObjectMeta(name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Namespace: objectNamespace, Name: name, Generation: 1}
+}
+
+func assertReady(t *testing.T, conditions []metav1.Condition, ready bool) {
+	condition := meta.FindStatusCondition(conditions, v1alpha1.ConditionReady)
+	require.NotNil(t, condition)
+	assert.Equal(t, ready, condition.Status == metav1.ConditionTrue, condition.Message)
+	assert.Equal(t, int64(1), condition.ObservedGeneration)
+}
+
+// Delete an object and reconcile it, checking that the finalizer lets it go
+func deleteAndReconcile(t *testing.T, k8sClient client.Client, reconciler interface {
+	Reconcile(context.Context, ctrl.Request) (ctrl.Result, error)
+}, obj client.Object) {
+	ctx := context.Background()
+	require.Nil(t, k8sClient.Delete(ctx, obj))
+	_, err := reconciler.Reconcile(ctx, getRequest(obj.GetName()))
+	require.Nil(t, err)
+
+	err = k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+	assert.True(t, client.IgnoreNotFound(err) == nil && err != nil)
+}
+
+func TestTagReconciler(t *testing.T) {
+	ctx := context.Background()
+	tag := &v1alpha1.ParagliderTag{ObjectMeta: getObjectMeta("group"), Spec: v1alpha1.ParagliderTagSpec{Members: []string{"member1", "member2"}}}
+	k8sClient, paraglider := setupFakes(t, tag)
+	reconciler := &TagReconciler{Client: k8sClient, Paraglider: paraglider}
+
+	_, err := reconciler.Reconcile(ctx, getRequest("group"))
+	require.Nil(t, err)
+
+	require.Nil(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(tag), tag))
+	assert.Contains(t, tag.Finalizers, Finalizer)
+	assert.Equal(t, "group", tag.Status.TagName)
+	assert.Equal(t, []string{"member1", "member2"}, tag.Status.Members)
+	assertReady(t, tag.Status.Conditions, true)
+
+	// Removed members are deleted
+	tag.Spec.Members = []string{"member1"}
+	require.Nil(t, k8sClient.Update(ctx, tag))
+	_, err = reconciler.Reconcile(ctx, getRequest("group"))
+	require.Nil(t, err)
+	require.Nil(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(tag), tag))
+	assert.Equal(t, []string{"member1"}, tag.Status.Members)
+
+	deleteAndReconcile(t, k8sClient, reconciler, tag)
+
+	// Missing objects are ignored
+	_, err = reconciler.Reconcile(ctx, getRequest("missing"))
+	assert.Nil(t, err)
+}
+
+func TestPermitListReconciler(t *testing.T) {
+	ctx := context.Background()
+	rule := v1alpha1.PermitListRule{Name: "ssh", Tags: []string{"1.2.3.4"}, SrcPort: -1, DstPort: 22, Protocol: 6}
+	onTag := &v1alpha1.ParagliderPermitList{ObjectMeta: getObjectMeta("on-tag"), Spec: v1alpha1.ParagliderPermitListSpec{Tag: "group", Rules: []v1alpha1.PermitListRule{rule}}}
+	onResource := &v1alpha1.ParagliderPermitList{ObjectMeta: getObjectMeta("on-resource"), Spec: v1alpha1.ParagliderPermitListSpec{
+		Resource: &v1alpha1.ResourceReference{Cloud: fakerest.CloudName, Name: "vm"},
+		Rules:    []v1alpha1.PermitListRule{rule},
+	}}
+	invalid := &v1alpha1.ParagliderPermitList{ObjectMeta: getObjectMeta("invalid"), Spec: v1alpha1.ParagliderPermitListSpec{Rules: []v1alpha1.PermitListRule{rule}}}
+	k8sClient, paraglider := setupFakes(t, onTag, onResource, invalid)
+	reconciler := &PermitListReconciler{Client: k8sClient, Paraglider: paraglider, Namespace: fakerest.Namespace}
+
+	for _, permitList := range []*v1alpha1.ParagliderPermitList{onTag, onResource} {
+		_, err := reconciler.Reconcile(ctx, getRequest(permitList.Name))
+		require.Nil(t, err)
+		require.Nil(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(permitList), permitList))
+		assert.Equal(t, []string{"ssh"}, permitList.Status.Rules)
+		assertReady(t, permitList.Status.Conditions, true)
+	}
+	assert.Equal(t, fakerest.Namespace, onResource.Status.Resource.Namespace)
+
+	// A permit list needs a tag or a resource
+	_, err := reconciler.Reconcile(ctx, getRequest("invalid"))
+	assert.NotNil(t, err)
+	require.Nil(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(invalid), invalid))
+	assertReady(t, invalid.Status.Conditions, false)
+
+	// Rules removed from the spec are deleted
+	onTag.Spec.Rules = nil
+	require.Nil(t, k8sClient.Update(ctx, onTag))
+	_, err = reconciler.Reconcile(ctx, getRequest("on-tag"))
+	require.Nil(t, err)
+	require.Nil(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(onTag), onTag))
+	assert.Empty(t, onTag.Status.Rules)
+
+	deleteAndReconcile(t, k8sClient, reconciler, onResource)
+}
+
+func TestConvertRule(t *testing.T) {
+	rule, err := convertRule(v1alpha1.PermitListRule{Name: "out", Tags: []string{"tag"}, Direction: "OUTBOUND", Protocol: 1})
+	require.Nil(t, err)
+	assert.Equal(t, "OUTBOUND", rule.Direction.String())
+
+	_, err = convertRule(v1alpha1.PermitListRule{Name: "bad", Direction: "SIDEWAYS"})
+	assert.NotNil(t, err)
+}
+
+func TestResourceReconciler(t *testing.T) {
+	ctx := context.Background()
+	resource := &v1alpha1.ParagliderResource{ObjectMeta: getObjectMeta("vm"), Spec: v1alpha1.ParagliderResourceSpec{
+		Cloud:       fakerest.CloudName,
+		Description: runtime.RawExtension{Raw: []byte(`{"name": "vm"}`)},
+	}}
+	k8sClient, paraglider := setupFakes(t, resource)
+	reconciler := &ResourceReconciler{Client: k8sClient, Paraglider: paraglider, Namespace: fakerest.Namespace}
+
+	_, err := reconciler.Reconcile(ctx, getRequest("vm"))
+	require.Nil(t, err)
+	require.Nil(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(resource), resource))
+	assert.Equal(t, fakerest.Namespace+"."+fakerest.CloudName+".vm", resource.Status.Tag)
+	assert.Contains(t, resource.Finalizers, Finalizer)
+	assertReady(t, resource.Status.Conditions, true)
+
+	// Resources are only refreshed once created
+	_, err = reconciler.Reconcile(ctx, getRequest("vm"))
+	require.Nil(t, err)
+
+	// and cannot be moved
+	require.Nil(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(resource), resource))
+	resource.Spec.Name = "other"
+	require.Nil(t, k8sClient.Update(ctx, resource))
+	_, err = reconciler.Reconcile(ctx, getRequest("vm"))
+	assert.NotNil(t, err)
+	require.Nil(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(resource), resource))
+	assertReady(t, resource.Status.Conditions, false)
+
+	// Deleting the resource removes its tag
+	deleteAndReconcile(t, k8sClient, reconciler, resource)
+}
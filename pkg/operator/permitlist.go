@@ -0,0 +1,165 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	paragliderclient "github.com/paraglider-project/paraglider/pkg/client"
+	"github.com/paraglider-project/paraglider/pkg/operator/api/v1alpha1"
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+)
+
+// Reconciles ParagliderPermitLists with rules on tags or resources in Paraglider
+type PermitListReconciler struct {
+	client.Client
+	Paraglider *paragliderclient.Client
+	// Paraglider namespace of resources which do not set one
+	Namespace string
+}
+
+func (r *PermitListReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).For(&v1alpha1.ParagliderPermitList{}).Complete(r)
+}
+
+func (r *PermitListReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	permitList := &v1alpha1.ParagliderPermitList{}
+	if err := r.Get(ctx, req.NamespacedName, permitList); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !permitList.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, finalize(ctx, r.Client, permitList, func() error {
+			return r.deleteRules(ctx, permitList.Status.Tag, permitList.Status.Resource, permitList.Status.Rules)
+		})
+	}
+	if err := addFinalizer(ctx, r.Client, permitList); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	syncErr := r.sync(ctx, permitList)
+	setReadyCondition(&permitList.Status.Conditions, permitList.Generation, syncErr)
+	permitList.Status.ObservedGeneration = permitList.Generation
+	if err := r.Status().Update(ctx, permitList); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, syncErr
+}
+
+// Fill in the default namespace of a resource reference
+func (r *PermitListReconciler) getResource(resource *v1alpha1.ResourceReference) *v1alpha1.ResourceReference {
+	if resource == nil {
+		return nil
+	}
+	resource = resource.DeepCopy()
+	if resource.Namespace == "" {
+		resource.Namespace = r.Namespace
+	}
+	return resource
+}
+
+func convertRule(rule v1alpha1.PermitListRule) (*paragliderpb.PermitListRule, error) {
+	direction := paragliderpb.Direction_INBOUND
+	if rule.Direction != "" {
+		value, ok := paragliderpb.Direction_value[rule.Direction]
+		if !ok {
+			return nil, fmt.Errorf("rule %s has an invalid direction %s", rule.Name, rule.Direction)
+		}
+		direction = paragliderpb.Direction(value)
+	}
+	return &paragliderpb.PermitListRule{
+		Name:      rule.Name,
+		Tags:      rule.Tags,
+		Direction: direction,
+		SrcPort:   rule.SrcPort,
+		DstPort:   rule.DstPort,
+		Protocol:  rule.Protocol,
+	}, nil
+}
+
+// Add the rules to their target and remove the ones which are no longer in the spec
+func (r *PermitListReconciler) sync(ctx context.Context, permitList *v1alpha1.ParagliderPermitList) error {
+	spec := permitList.Spec
+	resource := r.getResource(spec.Resource)
+	if (spec.Tag == "") == (resource == nil) {
+		return fmt.Errorf("exactly one of tag and resource must be set")
+	}
+
+	rules := make([]*paragliderpb.PermitListRule, len(spec.Rules))
+	names := make([]string, len(spec.Rules))
+	for i, rule := range spec.Rules {
+		converted, err := convertRule(rule)
+		if err != nil {
+			return err
+		}
+		rules[i] = converted
+		names[i] = rule.Name
+	}
+
+	// Rules applied to a different target, or no longer declared, are removed
+	status := &permitList.Status
+	stale, kept := []string{}, []string{}
+	if status.Tag != spec.Tag || (status.Resource == nil) != (resource == nil) || (resource != nil && *status.Resource != *resource) {
+		stale = status.Rules
+	} else {
+		declared := map[string]bool{}
+		for _, name := range names {
+			declared[name] = true
+		}
+		for _, name := range status.Rules {
+			if declared[name] {
+				kept = append(kept, name)
+			} else {
+				stale = append(stale, name)
+			}
+		}
+	}
+	if err := r.deleteRules(ctx, status.Tag, status.Resource, stale); err != nil {
+		return err
+	}
+	status.Tag = spec.Tag
+	status.Resource = resource
+	status.Rules = kept
+
+	if len(rules) > 0 {
+		var err error
+		if resource != nil {
+			err = r.Paraglider.AddPermitListRules(ctx, resource.Namespace, resource.Cloud, resource.Name, rules)
+		} else {
+			err = r.Paraglider.AddPermitListRulesTag(ctx, spec.Tag, rules)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	status.Rules = names
+	return nil
+}
+
+func (r *PermitListReconciler) deleteRules(ctx context.Context, tag string, resource *v1alpha1.ResourceReference, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	if resource != nil {
+		return r.Paraglider.DeletePermitListRules(ctx, resource.Namespace, resource.Cloud, resource.Name, names)
+	}
+	return r.Paraglider.DeletePermitListRulesTag(ctx, tag, names)
+}
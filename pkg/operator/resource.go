@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	paragliderclient "github.com/paraglider-project/paraglider/pkg/client"
+	"github.com/paraglider-project/paraglider/pkg/operator/api/v1alpha1"
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+)
+
+// Reconciles ParagliderResources by creating them through Paraglider
+// The controller cannot modify or delete cloud resources, so resources are only created once and deleting one only removes its tag
+type ResourceReconciler struct {
+	client.Client
+	Paraglider *paragliderclient.Client
+	// Paraglider namespace of resources which do not set one
+	Namespace string
+}
+
+func (r *ResourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).For(&v1alpha1.ParagliderResource{}).Complete(r)
+}
+
+func (r *ResourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	resource := &v1alpha1.ParagliderResource{}
+	if err := r.Get(ctx, req.NamespacedName, resource); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !resource.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, finalize(ctx, r.Client, resource, func() error {
+			if resource.Status.Tag == "" {
+				return nil
+			}
+			err := r.Paraglider.DeleteTag(ctx, resource.Status.Tag)
+			// Already removed from Paraglider, so there is nothing left to clean up
+			if paragliderclient.IsNotFound(err) {
+				return nil
+			}
+			return err
+		})
+	}
+	if err := addFinalizer(ctx, r.Client, resource); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	syncErr := r.sync(ctx, resource)
+	setReadyCondition(&resource.Status.Conditions, resource.Generation, syncErr)
+	resource.Status.ObservedGeneration = resource.Generation
+	if err := r.Status().Update(ctx, resource); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, syncErr
+}
+
+// Create the resource if it does not exist yet, and refresh its URI and IP
+func (r *ResourceReconciler) sync(ctx context.Context, resource *v1alpha1.ParagliderResource) error {
+	namespace := resource.Spec.Namespace
+	if namespace == "" {
+		namespace = r.Namespace
+	}
+	name := resource.Spec.Name
+	if name == "" {
+		name = resource.Name
+	}
+	tagName := namespace + "." + resource.Spec.Cloud + "." + name
+
+	if resource.Status.Tag == "" {
+		description := &paragliderpb.ResourceDescriptionString{Description: string(resource.Spec.Description.Raw)}
+		info, err := r.Paraglider.CreateResource(ctx, namespace, resource.Spec.Cloud, name, description)
+		if err != nil {
+			return err
+		}
+		resource.Status.Tag = tagName
+		resource.Status.Uri = info["uri"]
+		resource.Status.Ip = info["ip"]
+		return nil
+	}
+	if resource.Status.Tag != tagName {
+		return fmt.Errorf("resource %s cannot be moved to %s since resources cannot be modified", resource.Status.Tag, tagName)
+	}
+
+	tag, err := r.Paraglider.GetTag(ctx, tagName)
+	if err != nil {
+		return err
+	}
+	if tag.Uri != nil {
+		resource.Status.Uri = tag.GetUri()
+	}
+	if tag.Ip != nil {
+		resource.Status.Ip = tag.GetIp()
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	paragliderclient "github.com/paraglider-project/paraglider/pkg/client"
+	"github.com/paraglider-project/paraglider/pkg/operator/api/v1alpha1"
+	"github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
+)
+
+// Reconciles ParagliderTags with tags in Paraglider
+type TagReconciler struct {
+	client.Client
+	Paraglider *paragliderclient.Client
+}
+
+func (r *TagReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).For(&v1alpha1.ParagliderTag{}).Complete(r)
+}
+
+func getTagName(tag *v1alpha1.ParagliderTag) string {
+	if tag.Spec.TagName != "" {
+		return tag.Spec.TagName
+	}
+	return tag.Name
+}
+
+func (r *TagReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	tag := &v1alpha1.ParagliderTag{}
+	if err := r.Get(ctx, req.NamespacedName, tag); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !tag.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, finalize(ctx, r.Client, tag, func() error {
+			if tag.Status.TagName == "" {
+				return nil
+			}
+			err := r.Paraglider.DeleteTag(ctx, tag.Status.TagName)
+			// Already removed from Paraglider, so there is nothing left to clean up
+			if paragliderclient.IsNotFound(err) {
+				return nil
+			}
+			return err
+		})
+	}
+	if err := addFinalizer(ctx, r.Client, tag); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	syncErr := r.sync(ctx, tag)
+	setReadyCondition(&tag.Status.Conditions, tag.Generation, syncErr)
+	tag.Status.ObservedGeneration = tag.Generation
+	if err := r.Status().Update(ctx, tag); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, syncErr
+}
+
+// Set the tag and remove the members which are no longer in the spec
+func (r *TagReconciler) sync(ctx context.Context, tag *v1alpha1.ParagliderTag) error {
+	name := getTagName(tag)
+
+	// A renamed tag replaces the old one
+	if tag.Status.TagName != "" && tag.Status.TagName != name {
+		if err := r.Paraglider.DeleteTag(ctx, tag.Status.TagName); err != nil {
+			return err
+		}
+		tag.Status.TagName = ""
+		tag.Status.Members = nil
+	}
+
+	mapping := &tagservicepb.TagMapping{
+		Name:      name,
+		ChildTags: tag.Spec.Members,
+		Uri:       tag.Spec.Uri,
+		Ip:        tag.Spec.Ip,
+		Labels:    tag.Spec.Labels,
+		Selector:  tag.Spec.Selector,
+	}
+	if err := r.Paraglider.SetTag(ctx, name, mapping); err != nil {
+		return err
+	}
+	tag.Status.TagName = name
+
+	declared := map[string]bool{}
+	for _, member := range tag.Spec.Members {
+		declared[member] = true
+	}
+	for _, member := range tag.Status.Members {
+		if declared[member] {
+			continue
+		}
+		if err := r.Paraglider.DeleteTagMembers(ctx, name, member); err != nil {
+			return err
+		}
+	}
+	tag.Status.Members = tag.Spec.Members
+	return nil
+}
@@ -25,6 +25,12 @@ type CloudPlugin struct {
 	Name string `yaml:"name"`
 	Host string `yaml:"host"`
 	Port string `yaml:"port"`
+
+	// Overrides of the plugin's mappings for cloud-agnostic VM descriptions
+	Sizes  map[string]string `yaml:"sizes"`  // Size class to cloud machine type
+	Images map[string]string `yaml:"images"` // Image family to cloud image
+
+	Config string `yaml:"config"` // Path to the plugin's own config (e.g., the sites of the static plugin)
 }
 
 type Server struct {
@@ -38,9 +44,36 @@ type TagService struct {
 	Host string `yaml:"host"`
 }
 
+// Storage backend shared by the tag service and the KV store
+type Storage struct {
+	Backend string `yaml:"backend"` // redis (default), memory, or bolt
+	Host    string `yaml:"host"`    // Redis host (default localhost)
+	Port    string `yaml:"port"`    // Redis port (default 6379)
+	Path    string `yaml:"path"`    // Database file for the bolt backend
+}
+
+// Periodic sync of resource IPs from the clouds to their tags
+type ResourceSync struct {
+	Interval int `yaml:"interval"` // Seconds between syncs (disabled if 0)
+}
+
+// DNS server which resolves tag names to the IPs of their resources
+type DnsServer struct {
+	Host                string   `yaml:"host"`                // Address to listen on (default localhost)
+	Port                string   `yaml:"port"`                // Port to listen on for UDP and TCP queries (disabled if empty)
+	Zone                string   `yaml:"zone"`                // Zone the tags are served under (default paraglider)
+	Ttl                 uint32   `yaml:"ttl"`                 // TTL of answers in seconds (default 5)
+	Upstreams           []string `yaml:"upstreams"`           // Servers that queries outside of the zone are forwarded to (refused if empty)
+	AdvertisedAddresses []string `yaml:"advertisedAddresses"` // IPs the DNS settings of Paraglider networks point at (eg, the server's address reachable from the clouds)
+}
+
 type Config struct {
 	Server     Server     `yaml:"server"`
 	TagService TagService `yaml:"tagService"`
+	Storage    Storage    `yaml:"storage"`
+	DnsServer  DnsServer  `yaml:"dnsServer"`
+
+	ResourceSync ResourceSync `yaml:"resourceSync"`
 
 	KVStore struct {
 		Port string `yaml:"port"`
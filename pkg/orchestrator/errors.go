@@ -0,0 +1,196 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Codes set in error responses, so clients can tell failures apart without parsing messages
+const (
+	ErrorCodeInvalid       = "Invalid"
+	ErrorCodeNotFound      = "NotFound"
+	ErrorCodeConflict      = "Conflict"
+	ErrorCodeUnauthorized  = "Unauthorized"
+	ErrorCodeUnavailable   = "Unavailable"
+	ErrorCodeTimeout       = "Timeout"
+	ErrorCodeUnimplemented = "Unimplemented"
+	ErrorCodeInternal      = "Internal"
+)
+
+// Components a request can fail in (plugins are named with getPluginComponent)
+const (
+	ComponentOrchestrator = "orchestrator"
+	ComponentTagService   = "tag_service"
+)
+
+// Body of every error response of the REST API
+type ErrorResponse struct {
+	Error     string   `json:"error"`
+	Code      string   `json:"code"`
+	Details   []string `json:"details,omitempty"`
+	Component string   `json:"component,omitempty"`
+}
+
+// An error in a request itself (eg, a malformed body or an unknown cloud), which is not worth forwarding to any other component
+type requestError struct {
+	statusCode int
+	code       string
+	err        error
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+// Mark an error as caused by a malformed request
+func newInvalidRequestError(err error) error {
+	return &requestError{statusCode: http.StatusBadRequest, code: ErrorCodeInvalid, err: err}
+}
+
+// Create an error for something a request refers to which does not exist
+func newNotFoundError(format string, a ...any) error {
+	return &requestError{statusCode: http.StatusNotFound, code: ErrorCodeNotFound, err: fmt.Errorf(format, a...)}
+}
+
+// An error returned by another component, such as the tag service or a cloud plugin
+type componentError struct {
+	component string
+	err       error
+}
+
+func (e *componentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *componentError) Unwrap() error {
+	return e.err
+}
+
+// Attribute an error to the component it was returned by
+func withComponent(component string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &componentError{component: component, err: err}
+}
+
+func getPluginComponent(cloud string) string {
+	return "plugin/" + cloud
+}
+
+// Get the HTTP status code and error code matching a gRPC status code
+func getHTTPStatus(code codes.Code) (int, string) {
+	switch code {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusUnprocessableEntity, ErrorCodeInvalid
+	case codes.NotFound:
+		return http.StatusNotFound, ErrorCodeNotFound
+	case codes.AlreadyExists, codes.Aborted, codes.FailedPrecondition:
+		return http.StatusConflict, ErrorCodeConflict
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized, ErrorCodeUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden, ErrorCodeUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests, ErrorCodeUnavailable
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable, ErrorCodeUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout, ErrorCodeTimeout
+	case codes.Unimplemented:
+		return http.StatusNotImplemented, ErrorCodeUnimplemented
+	default:
+		return http.StatusInternalServerError, ErrorCodeInternal
+	}
+}
+
+// Format the details attached to a gRPC status (eg, the cloud API error or quota behind a plugin failure)
+func getStatusDetails(st *status.Status) []string {
+	details := []string{}
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			keys := make([]string, 0, len(detail.Metadata))
+			for key := range detail.Metadata {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			info := detail.Domain + ": " + detail.Reason
+			for _, key := range keys {
+				info += fmt.Sprintf(" %s=%s", key, detail.Metadata[key])
+			}
+			details = append(details, info)
+		case *errdetails.QuotaFailure:
+			for _, violation := range detail.Violations {
+				details = append(details, violation.Subject+": "+violation.Description)
+			}
+		case *errdetails.BadRequest:
+			for _, violation := range detail.FieldViolations {
+				details = append(details, violation.Field+": "+violation.Description)
+			}
+		}
+	}
+	if len(details) == 0 {
+		return nil
+	}
+	return details
+}
+
+// Get the status code and body of the response to a failed request
+// Errors which did not come from a request or gRPC status are internal errors of the orchestrator
+func getErrorResponse(err error) (int, *ErrorResponse) {
+	response := &ErrorResponse{Error: err.Error(), Code: ErrorCodeInternal, Component: ComponentOrchestrator}
+
+	var compErr *componentError
+	if errors.As(err, &compErr) {
+		response.Component = compErr.component
+	}
+
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		response.Code = reqErr.code
+		return reqErr.statusCode, response
+	}
+
+	if st, ok := status.FromError(err); ok {
+		var statusCode int
+		statusCode, response.Code = getHTTPStatus(st.Code())
+		response.Details = getStatusDetails(st)
+		return statusCode, response
+	}
+
+	return http.StatusInternalServerError, response
+}
+
+// Abort a request with the response for the error
+func abortWithError(c *gin.Context, err error) {
+	statusCode, response := getErrorResponse(err)
+	c.AbortWithStatusJSON(statusCode, response)
+}
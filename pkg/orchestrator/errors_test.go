@@ -0,0 +1,83 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetErrorResponse(t *testing.T) {
+	// Request errors
+	statusCode, resp := getErrorResponse(newInvalidRequestError(errors.New("bad body")))
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	assert.Equal(t, &ErrorResponse{Error: "bad body", Code: ErrorCodeInvalid, Component: ComponentOrchestrator}, resp)
+
+	statusCode, resp = getErrorResponse(newNotFoundError("invalid cloud name: %s", "badcloud"))
+	assert.Equal(t, http.StatusNotFound, statusCode)
+	assert.Equal(t, ErrorCodeNotFound, resp.Code)
+
+	// Untyped errors are internal
+	statusCode, resp = getErrorResponse(errors.New("something broke"))
+	assert.Equal(t, http.StatusInternalServerError, statusCode)
+	assert.Equal(t, ErrorCodeInternal, resp.Code)
+
+	// Wrapped plugin status errors keep their code, component and details
+	st, _ := status.New(codes.Unavailable, "backend unreachable").WithDetails(&errdetails.ErrorInfo{Reason: utils.CloudAPIErrorReason, Domain: utils.GCP, Metadata: map[string]string{"status_code": "503"}})
+	err := withComponent(getPluginComponent(utils.GCP), fmt.Errorf("unable to add rules: %w", st.Err()))
+	statusCode, resp = getErrorResponse(err)
+	assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+	assert.Equal(t, ErrorCodeUnavailable, resp.Code)
+	assert.Equal(t, "plugin/gcp", resp.Component)
+	assert.Equal(t, []string{"gcp: CLOUD_API_ERROR status_code=503"}, resp.Details)
+
+	// Tag service errors
+	statusCode, resp = getErrorResponse(withComponent(ComponentTagService, status.Error(codes.NotFound, "tag does not exist")))
+	assert.Equal(t, http.StatusNotFound, statusCode)
+	assert.Equal(t, ComponentTagService, resp.Component)
+	assert.Nil(t, resp.Details)
+}
+
+func TestGetHTTPStatus(t *testing.T) {
+	tests := map[codes.Code]int{
+		codes.InvalidArgument:  http.StatusUnprocessableEntity,
+		codes.NotFound:         http.StatusNotFound,
+		codes.AlreadyExists:    http.StatusConflict,
+		codes.Aborted:          http.StatusConflict,
+		codes.PermissionDenied: http.StatusForbidden,
+		codes.Unavailable:      http.StatusServiceUnavailable,
+		codes.DeadlineExceeded: http.StatusGatewayTimeout,
+		codes.Unimplemented:    http.StatusNotImplemented,
+		codes.Unknown:          http.StatusInternalServerError,
+	}
+	for code, expected := range tests {
+		statusCode, _ := getHTTPStatus(code)
+		assert.Equal(t, expected, statusCode, code.String())
+	}
+}
@@ -20,13 +20,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"net"
 	"net/http"
 	"net/netip"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 
@@ -40,6 +43,7 @@ import (
 	"github.com/paraglider-project/paraglider/pkg/kvstore/storepb"
 	config "github.com/paraglider-project/paraglider/pkg/orchestrator/config"
 	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/resourcespec"
 	tagservicepb "github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
 	utils "github.com/paraglider-project/paraglider/pkg/utils"
 )
@@ -52,6 +56,8 @@ const (
 	DeletePermitListRulesURL string = "/namespaces/:namespace/clouds/:cloud/resources/:resourceName/deleteRules"
 	CreateResourcePUTURL     string = "/namespaces/:namespace/clouds/:cloud/resources/:resourceName"
 	CreateResourcePOSTURL    string = "/namespaces/:namespace/clouds/:cloud/resources"
+	ListResourcesURL         string = "/namespaces/:namespace/clouds/:cloud/resources"
+	GetResourceURL           string = "/namespaces/:namespace/clouds/:cloud/resources/:resourceName"
 	RuleOnTagURL             string = "/tags/:tag/rules"
 	ListTagURL               string = "/tags"
 	GetTagURL                string = "/tags/:tag"
@@ -59,7 +65,11 @@ const (
 	SetTagURL                string = "/tags/:tag/applyMembers"
 	DeleteTagURL             string = "/tags/:tag"
 	DeleteTagMemberURL       string = "/tags/:tag/members/:member"
+	WatchTagURL              string = "/tags/:tag/watch"
 	ListNamespacesURL        string = "/namespaces"
+	GetSubscriptionsURL      string = "/namespaces/:namespace/clouds/:cloud/resources/:resourceName/subscriptions"
+	ExportURL                string = "/export"
+	ImportURL                string = "/import"
 )
 
 type Warning struct {
@@ -98,10 +108,6 @@ func GetFormatterString(url string) string {
 	return strings.Join(new_tokens, "/")
 }
 
-func createErrorResponse(message string) gin.H {
-	return gin.H{"error": message}
-}
-
 // Returns whether the string provided is a valid IP/CIDR
 func isIpAddrOrCidr(value string) bool {
 	if strings.Contains(value, "/") {
@@ -164,7 +170,7 @@ func parseTag(tag string) (string, string, string, error) {
 func (s *ControllerServer) getTagUri(tag string) (string, error) {
 	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		return "", fmt.Errorf("could not contact tag server: %s", err.Error())
+		return "", withComponent(ComponentTagService, fmt.Errorf("could not contact tag server: %w", err))
 	}
 	defer conn.Close()
 
@@ -172,11 +178,11 @@ func (s *ControllerServer) getTagUri(tag string) (string, error) {
 	client := tagservicepb.NewTagServiceClient(conn)
 	response, err := client.GetTag(context.Background(), &tagservicepb.GetTagRequest{TagName: tag})
 	if err != nil {
-		return "", fmt.Errorf("could not get tag: %s", err.Error())
+		return "", withComponent(ComponentTagService, fmt.Errorf("could not get tag: %w", err))
 	}
 
 	if response.Tag.Uri == nil || *response.Tag.Uri == "" {
-		return "", fmt.Errorf("tag %s is not an individual resource tag", tag)
+		return "", newNotFoundError("tag %s is not an individual resource tag", tag)
 	}
 	return *response.Tag.Uri, nil
 }
@@ -190,7 +196,7 @@ func (s *ControllerServer) getAndValidateResourceURLParams(c *gin.Context, resol
 	// Ensure correct cloud name
 	cloudClient, ok := s.pluginAddresses[cloud]
 	if !ok {
-		return nil, "", fmt.Errorf("invalid cloud name: %s", cloud)
+		return nil, "", newNotFoundError("invalid cloud name: %s", cloud)
 	}
 
 	if resolveTag {
@@ -211,14 +217,14 @@ func (s *ControllerServer) resolvePermitListRules(rules []*paragliderpb.PermitLi
 		// Check rule validity and clean fields
 		rule, _, err := checkAndCleanRule(rule) // TODO @smcclure20: use the warning and report it to the user
 		if err != nil {
-			return nil, fmt.Errorf("invalid rule: %s", err.Error())
+			return nil, newInvalidRequestError(fmt.Errorf("invalid rule: %w", err))
 		}
 
 		for _, tag := range rule.Tags {
 			if !isIpAddrOrCidr(tag) {
 				conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
 				if err != nil {
-					return nil, fmt.Errorf("could not contact tag server: %s", err.Error())
+					return nil, withComponent(ComponentTagService, fmt.Errorf("could not contact tag server: %w", err))
 				}
 				defer conn.Close()
 
@@ -226,7 +232,7 @@ func (s *ControllerServer) resolvePermitListRules(rules []*paragliderpb.PermitLi
 				client := tagservicepb.NewTagServiceClient(conn)
 				resolvedTag, err := client.ResolveTag(context.Background(), &tagservicepb.ResolveTagRequest{TagName: tag})
 				if err != nil {
-					return nil, fmt.Errorf("could not resolve tag: %s", err.Error())
+					return nil, withComponent(ComponentTagService, fmt.Errorf("could not resolve tag: %w", err))
 				}
 
 				// Subscribe self to tag
@@ -235,7 +241,7 @@ func (s *ControllerServer) resolvePermitListRules(rules []*paragliderpb.PermitLi
 						&tagservicepb.SubscribeRequest{Subscription: &tagservicepb.Subscription{TagName: tag,
 							Subscriber: createSubscriberName(resource.namespace, resource.cloud, resource.uri)}})
 					if err != nil {
-						return nil, fmt.Errorf("could not subscribe to tag: %s", err.Error())
+						return nil, withComponent(ComponentTagService, fmt.Errorf("could not subscribe to tag: %w", err))
 					}
 				}
 
@@ -273,19 +279,46 @@ func (s *ControllerServer) _permitListGet(namespace string, resourceId string, p
 func (s *ControllerServer) permitListGet(c *gin.Context) {
 	resourceInfo, cloudClient, err := s.getAndValidateResourceURLParams(c, true)
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, err)
 		return
 	}
 
 	response, err := s._permitListGet(resourceInfo.namespace, resourceInfo.uri, cloudClient)
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, withComponent(getPluginComponent(resourceInfo.cloud), err))
 		return
 	}
 
 	c.JSON(http.StatusOK, response.Rules)
 }
 
+// Get the tags a resource is subscribed to (ie, every tag its permit list depends on)
+func (s *ControllerServer) subscriptionsGet(c *gin.Context) {
+	resourceInfo, _, err := s.getAndValidateResourceURLParams(c, true)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		abortWithError(c, withComponent(ComponentTagService, err))
+		return
+	}
+	defer conn.Close()
+
+	// Send RPC to get the subscriptions
+	client := tagservicepb.NewTagServiceClient(conn)
+	subscriber := createSubscriberName(resourceInfo.namespace, resourceInfo.cloud, resourceInfo.uri)
+	response, err := client.GetSubscriptions(context.Background(), &tagservicepb.GetSubscriptionsRequest{Subscriber: subscriber})
+	if err != nil {
+		abortWithError(c, withComponent(ComponentTagService, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.TagNames)
+}
+
 // Add rules to a resource specified in the permit list in the given cloud
 func (s *ControllerServer) _permitListRulesAdd(req *paragliderpb.AddPermitListRulesRequest, resource *ResourceInfo, pluginAddress string) (*paragliderpb.AddPermitListRulesResponse, error) {
 	// Resolve tags referenced in rules
@@ -297,7 +330,7 @@ func (s *ControllerServer) _permitListRulesAdd(req *paragliderpb.AddPermitListRu
 	// Create connection to cloud plugin
 	conn, err := grpc.NewClient(pluginAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		return nil, err
+		return nil, withComponent(getPluginComponent(resource.cloud), err)
 	}
 	defer conn.Close()
 
@@ -305,7 +338,7 @@ func (s *ControllerServer) _permitListRulesAdd(req *paragliderpb.AddPermitListRu
 	client := paragliderpb.NewCloudPluginClient(conn)
 	response, err := client.AddPermitListRules(context.Background(), req)
 	if err != nil {
-		return nil, err
+		return nil, withComponent(getPluginComponent(resource.cloud), err)
 	}
 
 	return response, nil
@@ -315,14 +348,14 @@ func (s *ControllerServer) _permitListRulesAdd(req *paragliderpb.AddPermitListRu
 func (s *ControllerServer) permitListRulesBulkAdd(c *gin.Context) {
 	resourceInfo, cloudClient, err := s.getAndValidateResourceURLParams(c, true)
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, err)
 		return
 	}
 
 	// Parse permit list rules to add
 	var rules []*paragliderpb.PermitListRule
 	if err := c.BindJSON(&rules); err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, newInvalidRequestError(err))
 		return
 	}
 
@@ -330,7 +363,7 @@ func (s *ControllerServer) permitListRulesBulkAdd(c *gin.Context) {
 
 	_, err = s._permitListRulesAdd(request, resourceInfo, cloudClient)
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, err)
 		return
 	}
 }
@@ -339,14 +372,14 @@ func (s *ControllerServer) permitListRulesBulkAdd(c *gin.Context) {
 func (s *ControllerServer) permitListRuleAdd(c *gin.Context) {
 	resourceInfo, cloudClient, err := s.getAndValidateResourceURLParams(c, true)
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, err)
 		return
 	}
 
 	// Parse permit list rules to add
 	var rule *paragliderpb.PermitListRule
 	if err := c.BindJSON(&rule); err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, newInvalidRequestError(err))
 		return
 	}
 
@@ -354,7 +387,7 @@ func (s *ControllerServer) permitListRuleAdd(c *gin.Context) {
 		// Get rule name from URL
 		ruleName := c.Param("ruleName")
 		if ruleName == "" {
-			c.AbortWithStatusJSON(400, createErrorResponse("rule name not specified"))
+			abortWithError(c, newInvalidRequestError(errors.New("rule name not specified")))
 			return
 		}
 		rule.Name = ruleName // Note: if the name is provided in the request body, it is just overwritten
@@ -364,162 +397,393 @@ func (s *ControllerServer) permitListRuleAdd(c *gin.Context) {
 
 	_, err = s._permitListRulesAdd(request, resourceInfo, cloudClient)
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, err)
 		return
 	}
 }
 
-// Add permit list rules to all resources within a tag
-func (s *ControllerServer) permitListRuleAddTag(c *gin.Context) {
-	tag := c.Param("tag")
+// Serialize permit list rules into policies that can be attached to a tag
+func createTagPolicies(rules []*paragliderpb.PermitListRule) ([]*tagservicepb.TagPolicy, error) {
+	policies := make([]*tagservicepb.TagPolicy, len(rules))
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return nil, newInvalidRequestError(fmt.Errorf("rule for tag policy must have a name"))
+		}
+		// Targets are resolved separately for each member of the tag, so they are never stored
+		rule = proto.Clone(rule).(*paragliderpb.PermitListRule)
+		rule.Targets = []string{}
+		ruleBytes, err := proto.Marshal(rule)
+		if err != nil {
+			return nil, fmt.Errorf("could not serialize rule %s: %w", rule.Name, err)
+		}
+		policies[i] = &tagservicepb.TagPolicy{Name: rule.Name, Rule: ruleBytes}
+	}
+	return policies, nil
+}
 
-	// Parse permit list rules to add
-	var rule *paragliderpb.PermitListRule
-	if err := c.BindJSON(&rule); err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-		return
+// Deserialize the policies attached to a tag into permit list rules
+func parseTagPolicies(policies []*tagservicepb.TagPolicy) ([]*paragliderpb.PermitListRule, error) {
+	rules := make([]*paragliderpb.PermitListRule, len(policies))
+	for i, policy := range policies {
+		rule := &paragliderpb.PermitListRule{}
+		if err := proto.Unmarshal(policy.Rule, rule); err != nil {
+			return nil, fmt.Errorf("could not parse policy %s: %w", policy.Name, err)
+		}
+		rules[i] = rule
 	}
+	return rules, nil
+}
 
-	// Resolve the tag to URIs
-	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// Resolve a tag into the resources it contains, keyed by their tag names
+// Members which are only IPs/CIDRs are skipped since there is no permit list to apply rules to
+func resolveTagResources(client tagservicepb.TagServiceClient, tag string) (map[string]*tagservicepb.TagMapping, error) {
+	resolvedTag, err := client.ResolveTag(context.Background(), &tagservicepb.ResolveTagRequest{TagName: tag})
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-		return
+		return nil, withComponent(ComponentTagService, err)
 	}
-	defer conn.Close()
 
-	// Send RPC to resolve tag
-	client := tagservicepb.NewTagServiceClient(conn)
-	resolvedTag, err := client.ResolveTag(context.Background(), &tagservicepb.ResolveTagRequest{TagName: tag})
+	resources := make(map[string]*tagservicepb.TagMapping)
+	for _, mapping := range resolvedTag.Tags {
+		if mapping.Uri == nil || *mapping.Uri == "" {
+			continue
+		}
+		resources[mapping.Name] = mapping
+	}
+	return resources, nil
+}
+
+// Get the resource info of a leaf tag and the address of the plugin for its cloud
+func (s *ControllerServer) getTagResourceInfo(mapping *tagservicepb.TagMapping) (*ResourceInfo, string, error) {
+	// Get the cloud and namespace from the tag
+	namespace, cloud, name, err := parseTag(mapping.Name)
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-		return
+		return nil, "", fmt.Errorf("could not parse tag %s: %w", mapping.Name, err)
+	}
+
+	cloudClient, ok := s.pluginAddresses[cloud]
+	if !ok {
+		return nil, "", fmt.Errorf("invalid cloud name: %s", cloud)
 	}
 
-	initializedClientConns := make(map[string]*grpc.ClientConn)
+	return &ResourceInfo{name: name, uri: *mapping.Uri, namespace: namespace, cloud: cloud}, cloudClient, nil
+}
 
-	// Add rule to each URI in the resolved tag
-	for _, mapping := range resolvedTag.Tags {
-		// Get the cloud and namespace from the tag
-		namespace, cloud, _, err := parseTag(mapping.Name)
+// Add rules to the permit list of every resource in a tag
+func (s *ControllerServer) addRulesToTagResources(resources map[string]*tagservicepb.TagMapping, rules []*paragliderpb.PermitListRule) error {
+	for _, mapping := range resources {
+		resourceInfo, cloudClient, err := s.getTagResourceInfo(mapping)
 		if err != nil {
-			c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-			return
+			return err
 		}
 
-		// Create  or get connection to cloud plugin
-		conn, ok := initializedClientConns[cloud]
-		if !ok {
-			cloudClientAddress, ok := s.pluginAddresses[cloud]
-			if !ok {
-				c.AbortWithStatusJSON(400, createErrorResponse("invalid cloud name"))
-				return
-			}
+		// Each resource gets its own copy of the rules since resolving the tags fills in the targets
+		resourceRules := make([]*paragliderpb.PermitListRule, len(rules))
+		for i, rule := range rules {
+			resourceRules[i] = proto.Clone(rule).(*paragliderpb.PermitListRule)
+		}
+
+		request := &paragliderpb.AddPermitListRulesRequest{Rules: resourceRules, Namespace: resourceInfo.namespace, Resource: resourceInfo.uri}
+		_, err = s._permitListRulesAdd(request, resourceInfo, cloudClient)
+		if err != nil {
+			return fmt.Errorf("could not add rules to %s: %w", mapping.Name, err)
+		}
+	}
+	return nil
+}
+
+// Delete rules from the permit list of every resource in a tag
+func (s *ControllerServer) deleteRulesFromTagResources(resources map[string]*tagservicepb.TagMapping, ruleNames []string) error {
+	for _, mapping := range resources {
+		resourceInfo, cloudClient, err := s.getTagResourceInfo(mapping)
+		if err != nil {
+			return err
+		}
+
+		if err := s._permitListRulesDelete(resourceInfo, ruleNames, cloudClient); err != nil {
+			return fmt.Errorf("could not delete rules from %s: %w", mapping.Name, err)
+		}
+	}
+	return nil
+}
+
+// Apply the policies of a tag to resources which joined it and remove them from resources which left it
+func (s *ControllerServer) updateTagPolicyMembers(rules []*paragliderpb.PermitListRule, before map[string]*tagservicepb.TagMapping, after map[string]*tagservicepb.TagMapping) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	// Resources whose IP changed get the rules again so clouds which match on the resource's address pick it up
+	joined := make(map[string]*tagservicepb.TagMapping)
+	for name, mapping := range after {
+		if prev, ok := before[name]; !ok || *prev.Uri != *mapping.Uri || prev.GetIp() != mapping.GetIp() {
+			joined[name] = mapping
+		}
+	}
+
+	left := make(map[string]*tagservicepb.TagMapping)
+	for name, mapping := range before {
+		if next, ok := after[name]; !ok || *next.Uri != *mapping.Uri {
+			left[name] = mapping
+		}
+	}
+
+	ruleNames := make([]string, len(rules))
+	for i, rule := range rules {
+		ruleNames[i] = rule.Name
+	}
 
-			conn, err = grpc.NewClient(cloudClientAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err := s.deleteRulesFromTagResources(left, ruleNames); err != nil {
+		return err
+	}
+	return s.addRulesToTagResources(joined, rules)
+}
+
+// Get the policies of a tag as permit list rules
+func getTagPolicyRules(client tagservicepb.TagServiceClient, tag string) ([]*paragliderpb.PermitListRule, error) {
+	response, err := client.GetTagPolicies(context.Background(), &tagservicepb.GetTagPoliciesRequest{TagName: tag})
+	if err != nil {
+		return nil, withComponent(ComponentTagService, err)
+	}
+	return parseTagPolicies(response.Policies)
+}
+
+// Get the policies of a tag and, if there are any, the resources they currently apply to
+func getTagPoliciesAndResources(client tagservicepb.TagServiceClient, tag string) ([]*paragliderpb.PermitListRule, map[string]*tagservicepb.TagMapping, error) {
+	rules, err := getTagPolicyRules(client, tag)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil, nil
+	}
+
+	resources, err := resolveTagResources(client, tag)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rules, resources, nil
+}
+
+// Policies of a tag and the resources they applied to before a membership change
+type tagPolicySnapshot struct {
+	tag       string
+	rules     []*paragliderpb.PermitListRule
+	resources map[string]*tagservicepb.TagMapping
+}
+
+// Record the policies and current resources of a tag and of every tag containing it
+// Changing the members of a tag also changes the members of its ancestors, so their policies must be updated too
+func getTagPolicySnapshots(client tagservicepb.TagServiceClient, tag string) ([]*tagPolicySnapshot, error) {
+	response, err := client.GetAncestors(context.Background(), &tagservicepb.GetAncestorsRequest{TagName: tag})
+	if err != nil {
+		return nil, withComponent(ComponentTagService, err)
+	}
+
+	var snapshots []*tagPolicySnapshot
+	for _, name := range append([]string{tag}, response.Ancestors...) {
+		rules, resources, err := getTagPoliciesAndResources(client, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(rules) == 0 {
+			continue
+		}
+		snapshots = append(snapshots, &tagPolicySnapshot{tag: name, rules: rules, resources: resources})
+	}
+	return snapshots, nil
+}
+
+// Re-resolve the tags of policy snapshots after a membership change and update their policies
+func (s *ControllerServer) updateTagPolicySnapshots(client tagservicepb.TagServiceClient, snapshots []*tagPolicySnapshot) error {
+	for _, snapshot := range snapshots {
+		if err := s.updateTagPolicies(client, snapshot.tag, snapshot.rules, snapshot.resources); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Re-resolve a tag after a membership change and update its policies on the resources which joined or left it
+func (s *ControllerServer) updateTagPolicies(client tagservicepb.TagServiceClient, tag string, rules []*paragliderpb.PermitListRule, before map[string]*tagservicepb.TagMapping) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	after, err := resolveTagResources(client, tag)
+	if err != nil {
+		return err
+	}
+	return s.updateTagPolicyMembers(rules, before, after)
+}
+
+// Update subscribers and policies of selector tags which a tag joined or left after its labels changed
+// Selector tags the tag left were among its ancestors, so callers remove their policies with the tag's policy snapshots
+func (s *ControllerServer) updateSelectorMembers(client tagservicepb.TagServiceClient, changes []*tagservicepb.SelectorMembershipChange) error {
+	for _, change := range changes {
+		if err := s.updateSubscribers(change.SelectorTag); err != nil {
+			return err
+		}
+		if !change.Joined {
+			continue
+		}
+
+		// The member also joined the tags containing the selector tag
+		response, err := client.GetAncestors(context.Background(), &tagservicepb.GetAncestorsRequest{TagName: change.SelectorTag})
+		if err != nil {
+			return withComponent(ComponentTagService, err)
+		}
+		var rules []*paragliderpb.PermitListRule
+		for _, tag := range append([]string{change.SelectorTag}, response.Ancestors...) {
+			tagRules, err := getTagPolicyRules(client, tag)
 			if err != nil {
-				c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-				return
+				return err
 			}
-			defer conn.Close()
-
-			initializedClientConns[cloud] = conn
+			rules = append(rules, tagRules...)
+		}
+		if len(rules) == 0 {
+			continue
 		}
 
-		// Send RPC to add rule
-		client := paragliderpb.NewCloudPluginClient(conn)
-		_, err = client.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{Rules: []*paragliderpb.PermitListRule{rule}, Namespace: namespace, Resource: *mapping.Uri})
+		// Only the resources of the member which joined are affected
+		resources, err := resolveTagResources(client, change.Member)
 		if err != nil {
-			c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-			return
+			return err
+		}
+		if err := s.addRulesToTagResources(resources, rules); err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
-// Delete permit list rules to from resources within a tag
-func (s *ControllerServer) permitListRuleDeleteTag(c *gin.Context) {
+// Get the policies attached to a tag
+func (s *ControllerServer) permitListRulesGetTag(c *gin.Context) {
+	tag := c.Param("tag")
+
+	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		abortWithError(c, withComponent(ComponentTagService, err))
+		return
+	}
+	defer conn.Close()
+
+	// Send RPC to get the policies
+	client := tagservicepb.NewTagServiceClient(conn)
+	rules, err := getTagPolicyRules(client, tag)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// Attach permit list rules to a tag as policies and add them to all resources within the tag
+func (s *ControllerServer) permitListRuleAddTag(c *gin.Context) {
 	tag := c.Param("tag")
 
 	// Parse permit list rules to add
-	var rules []string
+	var rules []*paragliderpb.PermitListRule
 	if err := c.BindJSON(&rules); err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, newInvalidRequestError(err))
 		return
 	}
 
-	// Resolve the tag to URIs
 	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, withComponent(ComponentTagService, err))
 		return
 	}
 	defer conn.Close()
-
-	// Send RPC to resolve tag
 	client := tagservicepb.NewTagServiceClient(conn)
-	resolvedTag, err := client.ResolveTag(context.Background(), &tagservicepb.ResolveTagRequest{TagName: tag})
-	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+
+	if err := s._permitListRuleAddTag(client, tag, rules); err != nil {
+		abortWithError(c, err)
 		return
 	}
+}
 
-	// Add rule to each URI in the resolved tag
-	for _, mapping := range resolvedTag.Tags {
-		// Get the cloud and namespace from the tag
-		namespace, cloud, _, err := parseTag(mapping.Name)
-		if err != nil {
-			c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-			return
-		}
+// Attach rules to a tag and add them to all resources within the tag
+func (s *ControllerServer) _permitListRuleAddTag(client tagservicepb.TagServiceClient, tag string, rules []*paragliderpb.PermitListRule) error {
+	policies, err := createTagPolicies(rules)
+	if err != nil {
+		return err
+	}
 
-		// Create connection to cloud plugin
-		cloudClient, ok := s.pluginAddresses[cloud]
-		if !ok {
-			c.AbortWithStatusJSON(400, createErrorResponse("invalid cloud name"))
-			return
-		}
-		conn, err := grpc.NewClient(cloudClient, grpc.WithTransportCredentials(insecure.NewCredentials()))
-		if err != nil {
-			c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-			return
-		}
-		defer conn.Close()
+	// Resolve the tag to the resources it currently contains
+	resources, err := resolveTagResources(client, tag)
+	if err != nil {
+		return err
+	}
 
-		// Send RPC to add rule
-		client := paragliderpb.NewCloudPluginClient(conn)
-		_, err = client.DeletePermitListRules(context.Background(), &paragliderpb.DeletePermitListRulesRequest{RuleNames: rules, Namespace: namespace, Resource: *mapping.Uri})
-		if err != nil {
-			c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-			return
-		}
+	// Store the policies so that they also apply to resources that join the tag later
+	_, err = client.SetTagPolicies(context.Background(), &tagservicepb.SetTagPoliciesRequest{TagName: tag, Policies: policies})
+	if err != nil {
+		return withComponent(ComponentTagService, err)
 	}
+
+	// Add rules to each resource in the resolved tag
+	return s.addRulesToTagResources(resources, rules)
 }
 
-// Find the tags dereferenced between two versions of a permit list
-func diffTagReferences(beforeList []*paragliderpb.PermitListRule, afterList []*paragliderpb.PermitListRule) []string {
-	beforeListSet := make(map[string]bool)
-	afterListSet := make(map[string]bool)
-	tagsDereferenced := []string{}
+// Detach policies from a tag and delete their rules from resources within the tag
+func (s *ControllerServer) permitListRuleDeleteTag(c *gin.Context) {
+	tag := c.Param("tag")
 
-	for _, rule := range beforeList {
-		for _, tag := range rule.Tags {
-			if !isIpAddrOrCidr(tag) {
-				beforeListSet[tag] = true
-			}
-		}
+	// Parse permit list rules to delete
+	var rules []string
+	if err := c.BindJSON(&rules); err != nil {
+		abortWithError(c, newInvalidRequestError(err))
+		return
 	}
 
-	for _, rule := range afterList {
+	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		abortWithError(c, withComponent(ComponentTagService, err))
+		return
+	}
+	defer conn.Close()
+	client := tagservicepb.NewTagServiceClient(conn)
+
+	if err := s._permitListRuleDeleteTag(client, tag, rules); err != nil {
+		abortWithError(c, err)
+		return
+	}
+}
+
+// Detach rules from a tag and delete them from all resources within the tag
+func (s *ControllerServer) _permitListRuleDeleteTag(client tagservicepb.TagServiceClient, tag string, ruleNames []string) error {
+	// Resolve the tag to the resources it currently contains
+	resources, err := resolveTagResources(client, tag)
+	if err != nil {
+		return err
+	}
+
+	// Remove the policies so that they no longer apply to resources that join the tag later
+	_, err = client.DeleteTagPolicies(context.Background(), &tagservicepb.DeleteTagPoliciesRequest{TagName: tag, Names: ruleNames})
+	if err != nil {
+		return withComponent(ComponentTagService, err)
+	}
+
+	// Delete rules from each resource in the resolved tag
+	return s.deleteRulesFromTagResources(resources, ruleNames)
+}
+
+// Find the subscribed tags which are no longer referenced by a permit list
+func findUnreferencedTags(subscriptions []string, permitList []*paragliderpb.PermitListRule) []string {
+	referencedTags := make(map[string]bool)
+	tagsDereferenced := []string{}
+
+	for _, rule := range permitList {
 		for _, tag := range rule.Tags {
 			if !isIpAddrOrCidr(tag) {
-				afterListSet[tag] = true
+				referencedTags[tag] = true
 			}
 		}
 	}
 
-	// Find tags no longer referenced after the change
-	for tag := range beforeListSet {
-		if _, ok := afterListSet[tag]; !ok {
+	for _, tag := range subscriptions {
+		if !referencedTags[tag] {
 			tagsDereferenced = append(tagsDereferenced, tag)
 		}
 	}
@@ -527,141 +791,100 @@ func diffTagReferences(beforeList []*paragliderpb.PermitListRule, afterList []*p
 	return tagsDereferenced
 }
 
-// Check whether any tags have been dereferenced by the permit list and unsubscribe from any that have
-func (s *ControllerServer) checkAndUnsubscribe(resource *ResourceInfo, beforeList []*paragliderpb.PermitListRule, afterList []*paragliderpb.PermitListRule) error {
-	// Find the dereferenced tags
-	tagsToUnsubscribe := diffTagReferences(beforeList, afterList)
-
-	// If none dereferenced, return early
-	if len(tagsToUnsubscribe) == 0 {
-		return nil
-	}
-
+// Check whether any tags the resource is subscribed to are no longer referenced by its permit list and unsubscribe from any that are not
+func (s *ControllerServer) checkAndUnsubscribe(resource *ResourceInfo, permitList []*paragliderpb.PermitListRule) error {
 	// Dial the tag service
 	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		return err
+		return withComponent(ComponentTagService, err)
 	}
 	defer conn.Close()
 	client := tagservicepb.NewTagServiceClient(conn)
 
-	// Send RPC to unsubscribe from each tag
-	for _, tag := range tagsToUnsubscribe {
-		_, err := client.Unsubscribe(context.Background(), &tagservicepb.UnsubscribeRequest{Subscription: &tagservicepb.Subscription{TagName: tag, Subscriber: createSubscriberName(resource.namespace, resource.cloud, resource.uri)}})
+	// Look up the tags the resource is subscribed to
+	subscriber := createSubscriberName(resource.namespace, resource.cloud, resource.uri)
+	subscriptions, err := client.GetSubscriptions(context.Background(), &tagservicepb.GetSubscriptionsRequest{Subscriber: subscriber})
+	if err != nil {
+		return withComponent(ComponentTagService, err)
+	}
+
+	// Send RPC to unsubscribe from each tag that is no longer referenced
+	for _, tag := range findUnreferencedTags(subscriptions.TagNames, permitList) {
+		_, err := client.Unsubscribe(context.Background(), &tagservicepb.UnsubscribeRequest{Subscription: &tagservicepb.Subscription{TagName: tag, Subscriber: subscriber}})
 		if err != nil {
-			return err
+			return withComponent(ComponentTagService, err)
 		}
 	}
 
 	return nil
 }
 
-// Delete permit list rules to specified resource
-func (s *ControllerServer) permitListRulesDelete(c *gin.Context) {
-	resourceInfo, cloudClient, err := s.getAndValidateResourceURLParams(c, true)
-	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-		return
-	}
-
-	// Parse rules to delete
-	var ruleNames []string
-	if err := c.BindJSON(&ruleNames); err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-		return
-	}
-
+// Delete rules from a resource permit list and unsubscribe from any tags that are no longer referenced
+func (s *ControllerServer) _permitListRulesDelete(resource *ResourceInfo, ruleNames []string, pluginAddress string) error {
 	// Create connection to cloud plugin
-	conn, err := grpc.NewClient(cloudClient, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(pluginAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-		return
+		return withComponent(getPluginComponent(resource.cloud), err)
 	}
 	defer conn.Close()
 	client := paragliderpb.NewCloudPluginClient(conn)
 
-	// First, get the original list
-	permitListBefore, err := client.GetPermitList(context.Background(), &paragliderpb.GetPermitListRequest{Resource: resourceInfo.uri, Namespace: resourceInfo.namespace})
-	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-		return
-	}
-
 	// Send RPC to delete the rules
-	request := &paragliderpb.DeletePermitListRulesRequest{RuleNames: ruleNames, Namespace: resourceInfo.namespace, Resource: resourceInfo.uri}
+	request := &paragliderpb.DeletePermitListRulesRequest{RuleNames: ruleNames, Namespace: resource.namespace, Resource: resource.uri}
 	_, err = client.DeletePermitListRules(context.Background(), request)
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-		return
+		return withComponent(getPluginComponent(resource.cloud), err)
 	}
 
-	// Then get the final list to tell which tags should be unsubscribed
-	permitListAfter, err := client.GetPermitList(context.Background(), &paragliderpb.GetPermitListRequest{Resource: resourceInfo.uri, Namespace: resourceInfo.namespace})
+	// Then get the remaining rules to tell which tags should be unsubscribed
+	permitListAfter, err := client.GetPermitList(context.Background(), &paragliderpb.GetPermitListRequest{Resource: resource.uri, Namespace: resource.namespace})
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-		return
+		return withComponent(getPluginComponent(resource.cloud), err)
 	}
 
-	// Determine which tags have been dereferenced from the permit list and unsubscribe
-	// TODO @smcclure20: Have to do a permit list diff since there is no reverse lookup to see which tags a URI is subscribed to.
-	// 					 Supporting this will probably require a database migration (non-KV store)
-	if err := s.checkAndUnsubscribe(resourceInfo, permitListBefore.Rules, permitListAfter.Rules); err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-		return
-	}
+	return s.checkAndUnsubscribe(resource, permitListAfter.Rules)
 }
 
-// Delete a single rule from a resource permit list
-func (s *ControllerServer) permitListRuleDelete(c *gin.Context) {
+// Delete permit list rules to specified resource
+func (s *ControllerServer) permitListRulesDelete(c *gin.Context) {
 	resourceInfo, cloudClient, err := s.getAndValidateResourceURLParams(c, true)
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-		return
-	}
-
-	// Get rule name from URL
-	ruleName := c.Param("ruleName")
-	if ruleName == "" {
-		c.AbortWithStatusJSON(400, createErrorResponse("rule name not specified"))
+		abortWithError(c, err)
 		return
 	}
 
-	// Create connection to cloud plugin
-	conn, err := grpc.NewClient(cloudClient, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+	// Parse rules to delete
+	var ruleNames []string
+	if err := c.BindJSON(&ruleNames); err != nil {
+		abortWithError(c, newInvalidRequestError(err))
 		return
 	}
-	defer conn.Close()
-	client := paragliderpb.NewCloudPluginClient(conn)
 
-	// First, get the original list
-	permitListBefore, err := client.GetPermitList(context.Background(), &paragliderpb.GetPermitListRequest{Resource: resourceInfo.uri, Namespace: resourceInfo.namespace})
-	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+	// Delete the rules and unsubscribe from tags which are no longer referenced
+	if err := s._permitListRulesDelete(resourceInfo, ruleNames, cloudClient); err != nil {
+		abortWithError(c, err)
 		return
 	}
+}
 
-	// Send RPC to delete the rules
-	request := &paragliderpb.DeletePermitListRulesRequest{RuleNames: []string{ruleName}, Namespace: resourceInfo.namespace, Resource: resourceInfo.uri}
-	_, err = client.DeletePermitListRules(context.Background(), request)
+// Delete a single rule from a resource permit list
+func (s *ControllerServer) permitListRuleDelete(c *gin.Context) {
+	resourceInfo, cloudClient, err := s.getAndValidateResourceURLParams(c, true)
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, err)
 		return
 	}
 
-	// Then get the final list to tell which tags should be unsubscribed
-	permitListAfter, err := client.GetPermitList(context.Background(), &paragliderpb.GetPermitListRequest{Resource: resourceInfo.uri, Namespace: resourceInfo.namespace})
-	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+	// Get rule name from URL
+	ruleName := c.Param("ruleName")
+	if ruleName == "" {
+		abortWithError(c, newInvalidRequestError(errors.New("rule name not specified")))
 		return
 	}
 
-	// Determine which tags have been dereferenced from the permit list and unsubscribe
-	// TODO @smcclure20: Have to do a permit list diff since there is no reverse lookup to see which tags a URI is subscribed to.
-	// 					 Supporting this will probably require a database migration (non-KV store)
-	if err := s.checkAndUnsubscribe(resourceInfo, permitListBefore.Rules, permitListAfter.Rules); err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+	// Delete the rules and unsubscribe from tags which are no longer referenced
+	if err := s._permitListRulesDelete(resourceInfo, []string{ruleName}, cloudClient); err != nil {
+		abortWithError(c, err)
 		return
 	}
 }
@@ -891,6 +1114,10 @@ func (s *ControllerServer) findUnusedBgpPeeringIpAddresses(ctx context.Context,
 		// Azure has a more restrictive APIPA range
 		minIp = netip.MustParseAddr("169.254.21.1")
 		maxIp = netip.MustParseAddr("169.254.22.253")
+	} else if cloud1 == utils.AWS || cloud2 == utils.AWS {
+		// AWS reserves 169.254.0.0/30 through 169.254.5.0/30 and 169.254.169.252/30
+		minIp = netip.MustParseAddr("169.254.6.1")
+		maxIp = netip.MustParseAddr("169.254.169.249")
 	} else {
 		minIp = netip.MustParseAddr("169.254.0.1")
 		maxIp = netip.MustParseAddr("169.254.255.253")
@@ -932,12 +1159,18 @@ func (s *ControllerServer) findUnusedBgpPeeringIpAddresses(ctx context.Context,
 func generateSharedKey() string {
 	const length = 24
 	// characters allowed in the random string
-	// '/' is prohibited as part of the pre-shared key for IBM VPN connections
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*()"
+	// '/' is prohibited as part of the pre-shared key for IBM VPN connections, and AWS VPN connections only allow
+	// alphanumeric characters, periods and underscores in keys which must not start with zero (hence the leading letter)
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	const charset = letters + "0123456789._"
 	generatedRunes := make([]rune, length)
 
 	for i := range generatedRunes {
-		generatedRunes[i] = rune(charset[rand.Intn(len(charset))])
+		if i == 0 {
+			generatedRunes[i] = rune(letters[rand.Intn(len(letters))])
+		} else {
+			generatedRunes[i] = rune(charset[rand.Intn(len(charset))])
+		}
 	}
 
 	return string(generatedRunes)
@@ -967,8 +1200,19 @@ func (s *ControllerServer) ConnectClouds(ctx context.Context, req *paragliderpb.
 		return nil, fmt.Errorf("must specify different clouds to connect")
 	}
 
+	// AWS only allocates its tunnel IP addresses when creating VPN connections and always takes the first address of
+	// each BGP peering subnet, so it has to be cloud A to create its connections first
+	if req.CloudB == utils.AWS {
+		req.CloudA, req.CloudB = req.CloudB, req.CloudA
+		req.CloudANamespace, req.CloudBNamespace = req.CloudBNamespace, req.CloudANamespace
+		req.AddressSpacesCloudA, req.AddressSpacesCloudB = req.AddressSpacesCloudB, req.AddressSpacesCloudA
+	}
+
 	// TODO @seankimkdy: cloudA and cloudB naming seems to be very prone to typos, so perhaps use another naming scheme[?
-	if utils.MatchCloudProviders(req.CloudA, req.CloudB, utils.AZURE, utils.GCP) || utils.MatchCloudProviders(req.CloudA, req.CloudB, utils.AZURE, utils.IBM) {
+	if utils.MatchCloudProviders(req.CloudA, req.CloudB, utils.AZURE, utils.GCP) || utils.MatchCloudProviders(req.CloudA, req.CloudB, utils.AZURE, utils.IBM) ||
+		utils.MatchCloudProviders(req.CloudA, req.CloudB, utils.AWS, utils.AZURE) || utils.MatchCloudProviders(req.CloudA, req.CloudB, utils.AWS, utils.GCP) ||
+		utils.MatchCloudProviders(req.CloudA, req.CloudB, utils.STATIC, utils.AZURE) || utils.MatchCloudProviders(req.CloudA, req.CloudB, utils.STATIC, utils.GCP) ||
+		utils.MatchCloudProviders(req.CloudA, req.CloudB, utils.STATIC, utils.IBM) || utils.MatchCloudProviders(req.CloudA, req.CloudB, utils.STATIC, utils.AWS) {
 		if req.CloudA == utils.IBM || req.CloudB == utils.IBM {
 			isBGPDisabledConnection = true
 		}
@@ -1061,15 +1305,20 @@ func (s *ControllerServer) ConnectClouds(ctx context.Context, req *paragliderpb.
 			IsBgpDisabled:      isBGPDisabledConnection,    // informs cloud A that BGP is disabled on peer cloud
 			AddressSpace:       addressSpaceCloudA,         // Address space of a subnet/resource's IP in cloud A.
 		}
-		_, err = cloudAClient.CreateVpnConnections(ctx, cloudACreateVpnConnectionsReq)
+		cloudACreateVpnConnectionsResp, err := cloudAClient.CreateVpnConnections(ctx, cloudACreateVpnConnectionsReq)
 		if err != nil {
 			return nil, fmt.Errorf("unable to create vpn connections in cloud %s: %w", req.CloudA, err)
 		}
+		// Clouds which allocate gateway IP addresses with the connections (e.g., AWS) only return them now
+		cloudAGatewayIpAddresses := cloudACreateVpnGatewayResp.GatewayIpAddresses
+		if len(cloudACreateVpnConnectionsResp.GatewayIpAddresses) != 0 {
+			cloudAGatewayIpAddresses = cloudACreateVpnConnectionsResp.GatewayIpAddresses
+		}
 		cloudBCreateVpnConnectionsReq := &paragliderpb.CreateVpnConnectionsRequest{
 			Deployment:         cloudBParagliderDeployment,
 			Cloud:              req.CloudA,
 			Asn:                cloudACreateVpnGatewayResp.Asn,
-			GatewayIpAddresses: cloudACreateVpnGatewayResp.GatewayIpAddresses,
+			GatewayIpAddresses: cloudAGatewayIpAddresses,
 			BgpIpAddresses:     cloudABgpPeeringIpAddresses,
 			SharedKey:          sharedKey,
 			RemoteAddresses:    req.AddressSpacesCloudA, // provides non BGP connections with remote address target
@@ -1102,14 +1351,14 @@ func (s *ControllerServer) getParagliderDeployments(cloud string) []*paragliderp
 func (s *ControllerServer) resourceCreate(c *gin.Context) {
 	resourceInfo, cloudClient, err := s.getAndValidateResourceURLParams(c, false)
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, err)
 		return
 	}
 
 	// Parse the resource description provided
 	var resourceWithString paragliderpb.ResourceDescriptionString
 	if err := c.BindJSON(&resourceWithString); err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, newInvalidRequestError(err))
 		return
 	}
 
@@ -1118,10 +1367,17 @@ func (s *ControllerServer) resourceCreate(c *gin.Context) {
 		resourceInfo.name = resourceWithString.Name
 	}
 
+	// Pass the configured mappings along with cloud-agnostic descriptions
+	description, err := s.addResourceSpecMappings(resourceInfo.cloud, []byte(resourceWithString.Description))
+	if err != nil {
+		abortWithError(c, newInvalidRequestError(err))
+		return
+	}
+
 	// Create connection to cloud plugin
 	conn, err := grpc.NewClient(cloudClient, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, withComponent(getPluginComponent(resourceInfo.cloud), err))
 		return
 	}
 	defer conn.Close()
@@ -1130,19 +1386,19 @@ func (s *ControllerServer) resourceCreate(c *gin.Context) {
 	resource := paragliderpb.CreateResourceRequest{
 		Deployment:  &paragliderpb.ParagliderDeployment{Id: s.getCloudDeployment(resourceInfo.cloud, resourceInfo.namespace), Namespace: resourceInfo.namespace},
 		Name:        resourceInfo.name,
-		Description: []byte(resourceWithString.Description),
+		Description: description,
 	}
 	client := paragliderpb.NewCloudPluginClient(conn)
 	resourceResp, err := client.CreateResource(context.Background(), &resource)
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, withComponent(getPluginComponent(resourceInfo.cloud), err))
 		return
 	}
 
 	// Automatically set tag (need the IP address, we have the name and URI)
 	conn, err = grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, withComponent(ComponentTagService, err))
 		return
 	}
 	defer conn.Close()
@@ -1151,7 +1407,7 @@ func (s *ControllerServer) resourceCreate(c *gin.Context) {
 	tagClient := tagservicepb.NewTagServiceClient(conn)
 	_, err = tagClient.SetTag(context.Background(), &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: tagName, Uri: &resourceResp.Uri, Ip: &resourceResp.Ip}})
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error())) // TODO @smcclure20: change this to a warning?
+		abortWithError(c, withComponent(ComponentTagService, err)) // TODO @smcclure20: change this to a warning?
 		return
 	}
 
@@ -1160,24 +1416,172 @@ func (s *ControllerServer) resourceCreate(c *gin.Context) {
 	c.JSON(http.StatusOK, resourceResp)
 }
 
-// List all tags from local tag service
+// Add the size and image mappings configured for a cloud plugin to a cloud-agnostic VM description
+func (s *ControllerServer) addResourceSpecMappings(cloud string, description []byte) ([]byte, error) {
+	if !resourcespec.IsVM(description) {
+		return description, nil
+	}
+	for _, plugin := range s.config.CloudPlugins {
+		if plugin.Name == cloud && (len(plugin.Sizes) > 0 || len(plugin.Images) > 0) {
+			return resourcespec.WithMappings(description, resourcespec.Mappings{Sizes: plugin.Sizes, Images: plugin.Images})
+		}
+	}
+	return description, nil
+}
+
+// Get the names of the resource tags in a namespace and cloud keyed by resource URI
+func (s *ControllerServer) getResourceTagNames(namespace string, cloud string) (map[string]string, error) {
+	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, withComponent(ComponentTagService, fmt.Errorf("could not contact tag server: %w", err))
+	}
+	defer conn.Close()
+
+	client := tagservicepb.NewTagServiceClient(conn)
+	tagNames := make(map[string]string)
+	req := &tagservicepb.ListTagsRequest{Prefix: createTagName(namespace, cloud, "")}
+	for {
+		response, err := client.ListTags(context.Background(), req)
+		if err != nil {
+			return nil, withComponent(ComponentTagService, fmt.Errorf("could not list tags: %w", err))
+		}
+		for _, tag := range response.Tags {
+			if tag.Uri != nil && *tag.Uri != "" {
+				tagNames[*tag.Uri] = tag.Name
+			}
+		}
+		if response.NextPageToken == "" {
+			return tagNames, nil
+		}
+		req.PageToken = response.NextPageToken
+	}
+}
+
+// List the Paraglider resources of a cloud in a namespace
+func (s *ControllerServer) resourceList(c *gin.Context) {
+	cloud := c.Param("cloud")
+	namespace := c.Param("namespace")
+
+	cloudClient, ok := s.pluginAddresses[cloud]
+	if !ok {
+		abortWithError(c, newNotFoundError("invalid cloud name: %s", cloud))
+		return
+	}
+
+	// Create connection to cloud plugin
+	conn, err := grpc.NewClient(cloudClient, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		abortWithError(c, withComponent(getPluginComponent(cloud), err))
+		return
+	}
+	defer conn.Close()
+
+	// Send RPC to list the resources
+	client := paragliderpb.NewCloudPluginClient(conn)
+	deployment := &paragliderpb.ParagliderDeployment{Id: s.getCloudDeployment(cloud, namespace), Namespace: namespace}
+	response, err := client.ListResources(context.Background(), &paragliderpb.ListResourcesRequest{Deployment: deployment})
+	if err != nil {
+		abortWithError(c, withComponent(getPluginComponent(cloud), err))
+		return
+	}
+
+	// Report the tags of the resources which have one
+	tagNames, err := s.getResourceTagNames(namespace, cloud)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	for _, resource := range response.Resources {
+		resource.Tag = tagNames[resource.Uri]
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Get a single Paraglider resource by its name
+func (s *ControllerServer) resourceGet(c *gin.Context) {
+	resourceInfo, cloudClient, err := s.getAndValidateResourceURLParams(c, true)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	// Create connection to cloud plugin
+	conn, err := grpc.NewClient(cloudClient, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		abortWithError(c, withComponent(getPluginComponent(resourceInfo.cloud), err))
+		return
+	}
+	defer conn.Close()
+
+	// Send RPC to get the resource
+	client := paragliderpb.NewCloudPluginClient(conn)
+	response, err := client.GetResource(context.Background(), &paragliderpb.GetResourceRequest{Namespace: resourceInfo.namespace, Uri: resourceInfo.uri})
+	if err != nil {
+		abortWithError(c, withComponent(getPluginComponent(resourceInfo.cloud), err))
+		return
+	}
+
+	if response.Resource == nil {
+		abortWithError(c, withComponent(getPluginComponent(resourceInfo.cloud), fmt.Errorf("plugin returned no resource for %s", resourceInfo.uri)))
+		return
+	}
+	response.Resource.Tag = createTagName(resourceInfo.namespace, resourceInfo.cloud, resourceInfo.name)
+
+	c.JSON(http.StatusOK, response.Resource)
+}
+
+// Build a list tags request from the query parameters (prefix, limit, page_token, and label=key=value)
+func parseListTagsQuery(c *gin.Context) (*tagservicepb.ListTagsRequest, error) {
+	req := &tagservicepb.ListTagsRequest{Prefix: c.Query("prefix"), PageToken: c.Query("page_token")}
+
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.ParseInt(limit, 10, 32)
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid limit %s", limit)
+		}
+		req.Limit = int32(value)
+	}
+
+	labels := c.QueryArray("label")
+	if len(labels) > 0 {
+		req.Labels = make(map[string]string)
+		for _, label := range labels {
+			key, value, found := strings.Cut(label, "=")
+			if !found || key == "" {
+				return nil, fmt.Errorf("invalid label %s: expected key=value", label)
+			}
+			req.Labels[key] = value
+		}
+	}
+	return req, nil
+}
+
+// List tags from local tag service, one page at a time
 func (s *ControllerServer) listTags(c *gin.Context) {
+	req, err := parseListTagsQuery(c)
+	if err != nil {
+		abortWithError(c, newInvalidRequestError(err))
+		return
+	}
+
 	// Call listTags locally
 	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, withComponent(ComponentTagService, err))
 		return
 	}
 	defer conn.Close()
 
 	// Send RPC to list tags
 	client := tagservicepb.NewTagServiceClient(conn)
-	response, err := client.ListTags(context.Background(), &tagservicepb.ListTagsRequest{})
+	response, err := client.ListTags(context.Background(), req)
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, withComponent(ComponentTagService, err))
+		return
 	}
 
-	c.JSON(http.StatusOK, response.Tags)
+	c.JSON(http.StatusOK, response)
 }
 
 // Get tag from local tag service
@@ -1185,7 +1589,7 @@ func (s *ControllerServer) getTag(c *gin.Context) {
 	// Call getTag locally
 	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, withComponent(ComponentTagService, err))
 		return
 	}
 	defer conn.Close()
@@ -1195,7 +1599,7 @@ func (s *ControllerServer) getTag(c *gin.Context) {
 	client := tagservicepb.NewTagServiceClient(conn)
 	response, err := client.GetTag(context.Background(), &tagservicepb.GetTagRequest{TagName: tag})
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, withComponent(ComponentTagService, err))
 		return
 	}
 
@@ -1207,23 +1611,61 @@ func (s *ControllerServer) resolveTag(c *gin.Context) {
 	// Call resolveTag locally
 	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, withComponent(ComponentTagService, err))
 		return
 	}
 	defer conn.Close()
 
 	// Send RPC to get tag
 	tag := c.Param("tag")
+	includePaths := c.Query("include_paths") == "true"
 	client := tagservicepb.NewTagServiceClient(conn)
-	response, err := client.ResolveTag(context.Background(), &tagservicepb.ResolveTagRequest{TagName: tag})
+	response, err := client.ResolveTag(context.Background(), &tagservicepb.ResolveTagRequest{TagName: tag, IncludePaths: includePaths})
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, withComponent(ComponentTagService, err))
 		return
 	}
 
+	// Only return the paths alongside the tags when asked so the response stays a list of tags otherwise
+	if includePaths {
+		c.JSON(http.StatusOK, response)
+		return
+	}
 	c.JSON(http.StatusOK, response.Tags)
 }
 
+// Relay changes to a tag (and its descendents) from the local tag service as server-sent events
+func (s *ControllerServer) watchTag(c *gin.Context) {
+	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		abortWithError(c, withComponent(ComponentTagService, err))
+		return
+	}
+	defer conn.Close()
+
+	// Open the stream of events, which ends when the HTTP client goes away
+	tag := c.Param("tag")
+	client := tagservicepb.NewTagServiceClient(conn)
+	stream, err := client.Watch(c.Request.Context(), &tagservicepb.WatchRequest{TagName: tag})
+	if err != nil {
+		abortWithError(c, withComponent(ComponentTagService, err))
+		return
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		event, err := stream.Recv()
+		if err != nil {
+			if err != io.EOF && c.Request.Context().Err() == nil {
+				_, response := getErrorResponse(withComponent(ComponentTagService, err))
+				c.SSEvent("error", response)
+			}
+			return false
+		}
+		c.SSEvent("tag", event)
+		return true
+	})
+}
+
 // Clear targets from rules provided by the user
 func clearRuleTargets(rules []*paragliderpb.PermitListRule) []*paragliderpb.PermitListRule {
 	for _, rule := range rules {
@@ -1237,14 +1679,14 @@ func (s *ControllerServer) updateSubscribers(tag string) error {
 	// Get the subscribers to the tag
 	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		return err
+		return withComponent(ComponentTagService, err)
 	}
 	defer conn.Close()
 
 	client := tagservicepb.NewTagServiceClient(conn)
 	response, err := client.GetSubscribers(context.Background(), &tagservicepb.GetSubscribersRequest{TagName: tag})
 	if err != nil {
-		return err
+		return withComponent(ComponentTagService, err)
 	}
 
 	// For each subscriber, get the current permit list, clear target fields, and re-apply the resolved rules
@@ -1257,7 +1699,7 @@ func (s *ControllerServer) updateSubscribers(tag string) error {
 
 		getResp, err := s._permitListGet(namespace, uri, cloudClient)
 		if err != nil {
-			return err
+			return withComponent(getPluginComponent(cloud), err)
 		}
 
 		rules := clearRuleTargets(getResp.Rules)
@@ -1272,32 +1714,62 @@ func (s *ControllerServer) updateSubscribers(tag string) error {
 	return nil
 }
 
+// Set a tag mapping and update the subscribers and policies of every tag affected by the change
+func (s *ControllerServer) _setTag(client tagservicepb.TagServiceClient, tag *tagservicepb.TagMapping) (*tagservicepb.SetTagResponse, error) {
+	// Record the members the policies of the tag and its ancestors currently apply to
+	policySnapshots, err := getTagPolicySnapshots(client, tag.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	setResp, err := client.SetTag(context.Background(), &tagservicepb.SetTagRequest{Tag: tag})
+	if err != nil {
+		return nil, withComponent(ComponentTagService, err)
+	}
+	// Look up subscribers and re-resolve the tag
+	if err := s.updateSubscribers(tag.Name); err != nil {
+		return nil, err
+	}
+
+	// A leaf tag's new URI/IP also changes what the tags containing it resolve to
+	for _, ancestor := range setResp.UpdatedAncestors {
+		if err := s.updateSubscribers(ancestor); err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply the policies of the tag and its ancestors to any new members (or members with a new IP)
+	if err := s.updateTagPolicySnapshots(client, policySnapshots); err != nil {
+		return nil, err
+	}
+
+	// Label changes may have moved the tag in or out of selector tags
+	if err := s.updateSelectorMembers(client, setResp.SelectorChanges); err != nil {
+		return nil, err
+	}
+	return setResp, nil
+}
+
 // Set tag mapping in local db and update subscribers to membership change
 func (s *ControllerServer) setTag(c *gin.Context) {
 	// Parse data
 	var tag tagservicepb.TagMapping
 	if err := c.BindJSON(&tag); err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, newInvalidRequestError(err))
 		return
 	}
 
 	// Call SetTag
 	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, withComponent(ComponentTagService, err))
 		return
 	}
 	defer conn.Close()
 
 	client := tagservicepb.NewTagServiceClient(conn)
-	_, err = client.SetTag(context.Background(), &tagservicepb.SetTagRequest{Tag: &tag})
-	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-		return
-	}
-	// Look up subscribers and re-resolve the tag
-	if err := s.updateSubscribers(tag.Name); err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+	if _, err := s._setTag(client, &tag); err != nil {
+		abortWithError(c, err)
 		return
 	}
 
@@ -1311,56 +1783,91 @@ func (s *ControllerServer) deleteTag(c *gin.Context) {
 	// Call DeleteTag
 	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, withComponent(ComponentTagService, err))
 		return
 	}
 	defer conn.Close()
 
 	client := tagservicepb.NewTagServiceClient(conn)
-	_, err = client.DeleteTag(context.Background(), &tagservicepb.DeleteTagRequest{TagName: tagName})
-	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+	if err := s._deleteTag(client, tagName); err != nil {
+		abortWithError(c, err)
 		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// Delete a tag and update its subscribers and the resources its policies applied to
+func (s *ControllerServer) _deleteTag(client tagservicepb.TagServiceClient, tagName string) error {
+	// Record the members the policies of the tag and its ancestors currently apply to
+	policySnapshots, err := getTagPolicySnapshots(client, tagName)
+	if err != nil {
+		return err
+	}
+
+	deleteResp, err := client.DeleteTag(context.Background(), &tagservicepb.DeleteTagRequest{TagName: tagName})
+	if err != nil {
+		return withComponent(ComponentTagService, err)
+	}
+
 	// Look up subscribers and re-resolve the tags
 	// Note that deleting the tag does not remove it from the list, but it does resolve to nothing
 	if err := s.updateSubscribers(tagName); err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-		return
+		return err
 	}
 
-	c.JSON(http.StatusOK, gin.H{})
+	// The tag's labels were deleted with it, so it left any selector tags it was a member of
+	if err := s.updateSelectorMembers(client, deleteResp.SelectorChanges); err != nil {
+		return err
+	}
+
+	// Remove the policies of the tag and its ancestors from the tag's former members
+	// Like subscriptions, the policies themselves are kept and apply again if the tag is recreated
+	return s.updateTagPolicySnapshots(client, policySnapshots)
 }
 
 // Delete members of tag in local db and update subscribers to membership change
 func (s *ControllerServer) deleteTagMember(c *gin.Context) {
 	parentTag := c.Param("tag")
 	memberTag := c.Param("member")
-	tag := &tagservicepb.TagMapping{Name: parentTag, ChildTags: []string{memberTag}}
 
 	// Call DeleteTagMember
 	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
+		abortWithError(c, withComponent(ComponentTagService, err))
 		return
 	}
 	defer conn.Close()
 
 	client := tagservicepb.NewTagServiceClient(conn)
+	if err := s._deleteTagMember(client, parentTag, memberTag); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// Delete a member of a tag and update the tag's subscribers and the resources its policies applied to
+func (s *ControllerServer) _deleteTagMember(client tagservicepb.TagServiceClient, parentTag string, memberTag string) error {
+	// Record the members the policies of the tag and its ancestors currently apply to
+	policySnapshots, err := getTagPolicySnapshots(client, parentTag)
+	if err != nil {
+		return err
+	}
+
 	_, err = client.DeleteTagMember(context.Background(), &tagservicepb.DeleteTagMemberRequest{ParentTag: parentTag, ChildTag: memberTag})
 	if err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-		return
+		return withComponent(ComponentTagService, err)
 	}
 
 	// Look up subscribers and re-resolve the tag
-	if err := s.updateSubscribers(tag.Name); err != nil {
-		c.AbortWithStatusJSON(400, createErrorResponse(err.Error()))
-		return
+	if err := s.updateSubscribers(parentTag); err != nil {
+		return err
 	}
 
-	c.JSON(http.StatusOK, gin.H{})
+	// Remove the policies of the tag and its ancestors from any members which left
+	return s.updateTagPolicySnapshots(client, policySnapshots)
 }
 
 // List all configured namespaces
@@ -1385,6 +1892,149 @@ func (s *ControllerServer) GetValue(c context.Context, req *paragliderpb.GetValu
 	return &paragliderpb.GetValueResponse{Value: response.Value}, nil
 }
 
+// Update the tag of a resource after a plugin finds its URI or IP changed (eg, a VM came back with a new private IP)
+// Plugins also use it to register resources they discover (eg, the pods of a Kubernetes workload)
+func (s *ControllerServer) RefreshResourceTag(c context.Context, req *paragliderpb.RefreshResourceTagRequest) (*paragliderpb.RefreshResourceTagResponse, error) {
+	if _, ok := s.pluginAddresses[req.Cloud]; !ok {
+		return nil, fmt.Errorf("invalid cloud name: %s", req.Cloud)
+	}
+
+	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	client := tagservicepb.NewTagServiceClient(conn)
+	tag := &tagservicepb.TagMapping{Name: createTagName(req.Namespace, req.Cloud, req.Name), Uri: &req.Uri, Ip: &req.Ip, Labels: req.Labels}
+	setResp, err := s._setTag(client, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.RefreshResourceTagResponse{Version: setResp.Version}, nil
+}
+
+// Delete the tag of a resource which no longer exists and remove the policies it had
+func (s *ControllerServer) DeleteResourceTag(c context.Context, req *paragliderpb.DeleteResourceTagRequest) (*paragliderpb.DeleteResourceTagResponse, error) {
+	if _, ok := s.pluginAddresses[req.Cloud]; !ok {
+		return nil, fmt.Errorf("invalid cloud name: %s", req.Cloud)
+	}
+
+	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	client := tagservicepb.NewTagServiceClient(conn)
+	if err := s._deleteTag(client, createTagName(req.Namespace, req.Cloud, req.Name)); err != nil {
+		return nil, err
+	}
+	return &paragliderpb.DeleteResourceTagResponse{}, nil
+}
+
+// Get the addresses of the DNS server for tags so plugins can point the DNS settings of Paraglider networks at it
+func (s *ControllerServer) GetDnsServers(c context.Context, _ *emptypb.Empty) (*paragliderpb.GetDnsServersResponse, error) {
+	return &paragliderpb.GetDnsServersResponse{IpAddresses: s.config.DnsServer.AdvertisedAddresses}, nil
+}
+
+// Get the current IP and state of a resource from its cloud plugin
+func (s *ControllerServer) getResourceInfo(namespace string, uri string, pluginAddress string) (*paragliderpb.GetResourceInfoResponse, error) {
+	conn, err := grpc.NewClient(pluginAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	client := paragliderpb.NewCloudPluginClient(conn)
+	return client.GetResourceInfo(context.Background(), &paragliderpb.GetResourceInfoRequest{Namespace: namespace, Uri: uri})
+}
+
+// Compare the IP of a resource's tag with the ones its cloud reports and fix the tag if it is stale
+// A tag holding any current IP of the resource (e.g., of a secondary interface) is not stale
+// Returns whether the tag was updated
+func (s *ControllerServer) syncResourceTag(client tagservicepb.TagServiceClient, tag *tagservicepb.TagMapping) (bool, error) {
+	// Only leaf tags of resources (namespace.cloud.name) can be looked up in a cloud
+	if tag.GetUri() == "" {
+		return false, nil
+	}
+	namespace, cloud, _, err := parseTag(tag.Name)
+	if err != nil {
+		return false, nil
+	}
+	pluginAddress, ok := s.pluginAddresses[cloud]
+	if !ok {
+		return false, nil
+	}
+
+	info, err := s.getResourceInfo(namespace, tag.GetUri(), pluginAddress)
+	if err != nil {
+		return false, err
+	}
+	if info.Ip == "" || info.Ip == tag.GetIp() || slices.Contains(info.Ips, tag.GetIp()) {
+		return false, nil
+	}
+
+	// Only update the version that was read so a concurrent update is not overwritten
+	ip := info.Ip
+	if _, err := s._setTag(client, &tagservicepb.TagMapping{Name: tag.Name, Ip: &ip, Version: tag.Version}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Sync the IPs of all resource tags with their clouds
+// Returns the names of the tags which were updated
+func (s *ControllerServer) syncResourceTags() ([]string, error) {
+	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	client := tagservicepb.NewTagServiceClient(conn)
+	updated := []string{}
+	pageToken := ""
+	for {
+		resp, err := client.ListTags(context.Background(), &tagservicepb.ListTagsRequest{PageToken: pageToken})
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range resp.Tags {
+			// A resource which cannot be looked up should not stop the others from syncing
+			ok, err := s.syncResourceTag(client, tag)
+			if err != nil {
+				utils.Log.Printf("Failed to sync tag %s: %v\n", tag.Name, err)
+				continue
+			}
+			if ok {
+				updated = append(updated, tag.Name)
+			}
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+	return updated, nil
+}
+
+// Periodically sync the IPs of resource tags with their clouds
+func (s *ControllerServer) runResourceSync(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		updated, err := s.syncResourceTags()
+		if err != nil {
+			utils.Log.Printf("Failed to sync resource tags: %v\n", err)
+			continue
+		}
+		if len(updated) > 0 {
+			utils.Log.Printf("Updated the IPs of resource tags %v\n", updated)
+		}
+	}
+}
+
 // Set a value in the KV store
 func (s *ControllerServer) SetValue(c context.Context, req *paragliderpb.SetValueRequest) (*paragliderpb.SetValueResponse, error) {
 	conn, err := grpc.NewClient(s.localKVStoreService, grpc.WithTransportCredentials(insecure.NewCredentials()))
@@ -1469,6 +2119,11 @@ func Setup(cfg config.Config, background bool) {
 		}
 	}()
 
+	// Keep resource tags in sync with the IPs the clouds report
+	if cfg.ResourceSync.Interval > 0 {
+		go server.runResourceSync(time.Duration(cfg.ResourceSync.Interval) * time.Second)
+	}
+
 	// Setup URL router
 	router := gin.Default()
 	router.GET("/ping", func(c *gin.Context) {
@@ -1484,6 +2139,9 @@ func Setup(cfg config.Config, background bool) {
 	router.DELETE(PermitListRulePUTURL, server.permitListRuleDelete)
 	router.PUT(CreateResourcePUTURL, server.resourceCreate)
 	router.POST(CreateResourcePOSTURL, server.resourceCreate)
+	router.GET(ListResourcesURL, server.resourceList)
+	router.GET(GetResourceURL, server.resourceGet)
+	router.GET(RuleOnTagURL, server.permitListRulesGetTag)
 	router.POST(RuleOnTagURL, server.permitListRuleAddTag)
 	router.DELETE(RuleOnTagURL, server.permitListRuleDeleteTag)
 	router.GET(ListTagURL, server.listTags)
@@ -1492,7 +2150,11 @@ func Setup(cfg config.Config, background bool) {
 	router.POST(SetTagURL, server.setTag)
 	router.DELETE(DeleteTagURL, server.deleteTag)
 	router.DELETE(DeleteTagMemberURL, server.deleteTagMember)
+	router.GET(WatchTagURL, server.watchTag)
 	router.GET(ListNamespacesURL, server.listNamespaces)
+	router.GET(GetSubscriptionsURL, server.subscriptionsGet)
+	router.GET(ExportURL, server.snapshotExport)
+	router.POST(ImportURL, server.snapshotImport)
 
 	// Run server
 	if background {
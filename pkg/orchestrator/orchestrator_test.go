@@ -24,21 +24,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	config "github.com/paraglider-project/paraglider/pkg/orchestrator/config"
 	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/resourcespec"
+	"github.com/paraglider-project/paraglider/pkg/storage"
+	tagservice "github.com/paraglider-project/paraglider/pkg/tag_service"
 	tagservicepb "github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
 
 	fakeplugin "github.com/paraglider-project/paraglider/pkg/fake/cloudplugin"
 	fakekvstore "github.com/paraglider-project/paraglider/pkg/fake/kvstore"
+	"github.com/paraglider-project/paraglider/pkg/fake/simcloud"
 	faketagservice "github.com/paraglider-project/paraglider/pkg/fake/tagservice"
 	utils "github.com/paraglider-project/paraglider/pkg/utils"
 
@@ -110,7 +119,7 @@ func TestPermitListGet(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
 func TestPermitListRulesAdd(t *testing.T) {
@@ -156,7 +165,7 @@ func TestPermitListRulesAdd(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	// Invalid tag name (cannot be resolved)
 	tags = []string{"tag"}
@@ -175,7 +184,7 @@ func TestPermitListRulesAdd(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	// Bad cloud name
 	url = fmt.Sprintf(GetFormatterString(AddPermitListRulesURL), defaultNamespace, "wrong", name)
@@ -183,7 +192,7 @@ func TestPermitListRulesAdd(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	badRequest := "{\"test\": 1}"
 	jsonValue, _ = json.Marshal(&badRequest)
@@ -237,7 +246,7 @@ func TestPermitListRulePut(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	// Invalid tag name (cannot be resolved)
 	tags = []string{"tag"}
@@ -255,7 +264,7 @@ func TestPermitListRulePut(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	// Bad cloud name
 	url = fmt.Sprintf(GetFormatterString(PermitListRulePUTURL), defaultNamespace, "wrong", name, rule.Name)
@@ -263,7 +272,7 @@ func TestPermitListRulePut(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	badRequest := "{\"test\": 1}"
 	jsonValue, _ = json.Marshal(&badRequest)
@@ -318,7 +327,7 @@ func TestPermitListRulePost(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	// Invalid tag name (cannot be resolved)
 	tags = []string{"tag"}
@@ -336,7 +345,7 @@ func TestPermitListRulePost(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	// Bad cloud name
 	url = fmt.Sprintf(GetFormatterString(PermitListRulePOSTURL), defaultNamespace, "wrong", name)
@@ -344,7 +353,7 @@ func TestPermitListRulePost(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	badRequest := "{\"test\": 1}"
 	jsonValue, _ = json.Marshal(&badRequest)
@@ -380,7 +389,7 @@ func TestPermitListRuleTagAdd(t *testing.T) {
 		SrcPort:   1,
 		DstPort:   2,
 		Protocol:  1}
-	jsonValue, _ := json.Marshal(rule)
+	jsonValue, _ := json.Marshal([]*paragliderpb.PermitListRule{rule})
 
 	url := fmt.Sprintf(GetFormatterString(RuleOnTagURL), defaultNamespace+"."+exampleCloudName+"."+faketagservice.ValidTagName)
 	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonValue))
@@ -397,7 +406,34 @@ func TestPermitListRuleTagAdd(t *testing.T) {
 
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPermitListRulesTagGet(t *testing.T) {
+	// Setup
+	orchestratorServer := newOrchestratorServer()
+	tagServerPort := getNewPortNumber()
+	orchestratorServer.localTagService = fmt.Sprintf("localhost:%d", tagServerPort)
+
+	faketagservice.SetupFakeTagServer(tagServerPort)
+
+	r := SetUpRouter()
+	r.GET(RuleOnTagURL, orchestratorServer.permitListRulesGetTag)
+
+	// Well-formed request
+	url := fmt.Sprintf(GetFormatterString(RuleOnTagURL), faketagservice.ValidTagName)
+	req, _ := http.NewRequest("GET", url, nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	responseData, _ := io.ReadAll(w.Body)
+	var rules []*paragliderpb.PermitListRule
+	err := json.Unmarshal(responseData, &rules)
+
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, rules, 1)
+	assert.Equal(t, faketagservice.TagPolicyName, rules[0].Name)
 }
 
 func TestPermitListRuleTagDelete(t *testing.T) {
@@ -433,7 +469,7 @@ func TestPermitListRuleTagDelete(t *testing.T) {
 
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
 func TestPermitListRulesDelete(t *testing.T) {
@@ -473,7 +509,7 @@ func TestPermitListRulesDelete(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	// Bad cloud name
 	url = fmt.Sprintf(GetFormatterString(DeletePermitListRulesURL), defaultNamespace, "wrong", name)
@@ -481,7 +517,7 @@ func TestPermitListRulesDelete(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	badRequest := "{\"test\": 1}"
 	jsonValue, _ = json.Marshal(&badRequest)
@@ -528,7 +564,7 @@ func TestPermitListRuleDelete(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	// Bad cloud name
 	url = fmt.Sprintf(GetFormatterString(PermitListRulePUTURL), defaultNamespace, "wrong", name, ruleName)
@@ -536,7 +572,7 @@ func TestPermitListRuleDelete(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
 func TestCreateResourcePost(t *testing.T) {
@@ -581,7 +617,7 @@ func TestCreateResourcePost(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	badRequest := "{\"test\": 1}"
 	jsonValue, _ = json.Marshal(&badRequest)
@@ -635,7 +671,7 @@ func TestCreateResourcePut(t *testing.T) {
 	w = httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	badRequest := "{\"test\": 1}"
 	jsonValue, _ = json.Marshal(&badRequest)
@@ -648,6 +684,156 @@ func TestCreateResourcePut(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestAddResourceSpecMappings(t *testing.T) {
+	orchestratorServer := newOrchestratorServer()
+	orchestratorServer.config = config.Config{CloudPlugins: []config.CloudPlugin{
+		{Name: exampleCloudName, Sizes: map[string]string{resourcespec.SizeSmall: "custom-size"}, Images: map[string]string{resourcespec.ImageDebian12: "custom-image"}},
+		{Name: "other"},
+	}}
+	vm := &resourcespec.VM{Kind: resourcespec.VMKind, Size: resourcespec.SizeSmall, Image: resourcespec.ImageDebian12, Region: "region", SSHKey: "key"}
+	description, err := json.Marshal(vm)
+	require.NoError(t, err)
+
+	// Mappings are added for clouds with configured overrides
+	withMappings, err := orchestratorServer.addResourceSpecMappings(exampleCloudName, description)
+	require.NoError(t, err)
+	parsed, err := resourcespec.ParseVM(withMappings)
+	require.NoError(t, err)
+	assert.Equal(t, "custom-size", parsed.Mappings.Sizes[resourcespec.SizeSmall])
+	assert.Equal(t, "custom-image", parsed.Mappings.Images[resourcespec.ImageDebian12])
+
+	// Descriptions are untouched for clouds without overrides
+	unchanged, err := orchestratorServer.addResourceSpecMappings("other", description)
+	require.NoError(t, err)
+	assert.Equal(t, description, unchanged)
+
+	// Native descriptions are untouched
+	native := []byte(`{"location": "westus"}`)
+	unchanged, err = orchestratorServer.addResourceSpecMappings(exampleCloudName, native)
+	require.NoError(t, err)
+	assert.Equal(t, native, unchanged)
+
+	// Invalid cloud-agnostic descriptions are rejected
+	_, err = orchestratorServer.addResourceSpecMappings(exampleCloudName, []byte(`{"kind": "ParagliderVM"}`))
+	require.Error(t, err)
+}
+
+func TestResourceList(t *testing.T) {
+	// Setup
+	orchestratorServer := newOrchestratorServer()
+	port := getNewPortNumber()
+	tagServerPort := getNewPortNumber()
+	orchestratorServer.localTagService = fmt.Sprintf("localhost:%d", tagServerPort)
+	orchestratorServer.pluginAddresses[exampleCloudName] = fmt.Sprintf("localhost:%d", port)
+
+	fakeplugin.SetupFakePluginServer(port)
+	faketagservice.SetupFakeTagServer(tagServerPort)
+
+	r := SetUpRouter()
+	r.GET(ListResourcesURL, orchestratorServer.resourceList)
+
+	// Well-formed request
+	url := fmt.Sprintf(GetFormatterString(ListResourcesURL), defaultNamespace, exampleCloudName)
+	req, _ := http.NewRequest("GET", url, nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response paragliderpb.ListResourcesResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.Nil(t, err)
+	require.Len(t, response.Resources, 1)
+	assert.Equal(t, fakeplugin.ResourceName, response.Resources[0].Name)
+	assert.Equal(t, faketagservice.ValidLastLevelTagName, response.Resources[0].Tag)
+	assert.Equal(t, faketagservice.TagUri, response.Resources[0].Uri)
+	assert.Equal(t, fakeplugin.ResourceIp, response.Resources[0].Ip)
+	assert.Equal(t, fakeplugin.ResourceState, response.Resources[0].State)
+
+	// Bad cloud name
+	url = fmt.Sprintf(GetFormatterString(ListResourcesURL), defaultNamespace, "wrong")
+	req, _ = http.NewRequest("GET", url, nil)
+	w = httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestResourceGet(t *testing.T) {
+	// Setup
+	orchestratorServer := newOrchestratorServer()
+	port := getNewPortNumber()
+	tagServerPort := getNewPortNumber()
+	orchestratorServer.localTagService = fmt.Sprintf("localhost:%d", tagServerPort)
+	orchestratorServer.pluginAddresses[exampleCloudName] = fmt.Sprintf("localhost:%d", port)
+
+	fakeplugin.SetupFakePluginServer(port)
+	faketagservice.SetupFakeTagServer(tagServerPort)
+
+	r := SetUpRouter()
+	r.GET(GetResourceURL, orchestratorServer.resourceGet)
+
+	// Well-formed request
+	url := fmt.Sprintf(GetFormatterString(GetResourceURL), defaultNamespace, exampleCloudName, faketagservice.ValidLastLevelTagName)
+	req, _ := http.NewRequest("GET", url, nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resource paragliderpb.Resource
+	err := json.Unmarshal(w.Body.Bytes(), &resource)
+	require.Nil(t, err)
+	assert.Equal(t, fakeplugin.ResourceName, resource.Name)
+	assert.Equal(t, createTagName(defaultNamespace, exampleCloudName, faketagservice.ValidLastLevelTagName), resource.Tag)
+	assert.Equal(t, faketagservice.TagUri, resource.Uri)
+	assert.Equal(t, fakeplugin.ResourceIp, resource.Ip)
+
+	// Resource without a tag
+	url = fmt.Sprintf(GetFormatterString(GetResourceURL), defaultNamespace, exampleCloudName, "missing")
+	req, _ = http.NewRequest("GET", url, nil)
+	w = httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Bad cloud name
+	url = fmt.Sprintf(GetFormatterString(GetResourceURL), defaultNamespace, "wrong", faketagservice.ValidLastLevelTagName)
+	req, _ = http.NewRequest("GET", url, nil)
+	w = httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Plugin which returns no resource
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.Nil(t, err)
+	grpcServer := grpc.NewServer()
+	paragliderpb.RegisterCloudPluginServer(grpcServer, &emptyResourcePlugin{})
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	orchestratorServer.pluginAddresses[exampleCloudName] = lis.Addr().String()
+
+	url = fmt.Sprintf(GetFormatterString(GetResourceURL), defaultNamespace, exampleCloudName, faketagservice.ValidLastLevelTagName)
+	req, _ = http.NewRequest("GET", url, nil)
+	w = httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	var errResponse ErrorResponse
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &errResponse))
+	assert.Equal(t, getPluginComponent(exampleCloudName), errResponse.Component)
+}
+
+// Plugin whose GetResource responses have no resource
+type emptyResourcePlugin struct {
+	paragliderpb.UnimplementedCloudPluginServer
+}
+
+func (p *emptyResourcePlugin) GetResource(ctx context.Context, req *paragliderpb.GetResourceRequest) (*paragliderpb.GetResourceResponse, error) {
+	return &paragliderpb.GetResourceResponse{}, nil
+}
+
 func TestGetAddressSpaces(t *testing.T) {
 	// Setup
 	orchestratorServer := newOrchestratorServer()
@@ -889,6 +1075,14 @@ func TestFindUnusedBgpPeeringSubnets(t *testing.T) {
 	subnets, err = orchestratorServer.findUnusedBgpPeeringIpAddresses(ctx, utils.AZURE, utils.GCP, defaultNamespace)
 	require.NoError(t, err)
 	require.ElementsMatch(t, []string{"169.254.21.13", "169.254.21.14", "169.254.21.17", "169.254.21.18"}, subnets)
+
+	// AWS reserves the start of the APIPA range
+	orchestratorServer.usedBgpPeeringIpAddresses[utils.AZURE] = []string{}
+	orchestratorServer.usedBgpPeeringIpAddresses[utils.AWS] = []string{"169.254.6.1"}
+	orchestratorServer.usedBgpPeeringIpAddresses[utils.GCP] = []string{"169.254.6.2"}
+	subnets, err = orchestratorServer.findUnusedBgpPeeringIpAddresses(ctx, utils.AWS, utils.GCP, defaultNamespace)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"169.254.6.5", "169.254.6.6", "169.254.6.9", "169.254.6.10"}, subnets)
 }
 
 func TestGetTag(t *testing.T) {
@@ -944,7 +1138,55 @@ func TestGetTag(t *testing.T) {
 
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestListTags(t *testing.T) {
+	orchestratorServer := newOrchestratorServer()
+	tagServerPort := getNewPortNumber()
+	orchestratorServer.localTagService = fmt.Sprintf("localhost:%d", tagServerPort)
+
+	faketagservice.SetupFakeTagServer(tagServerPort)
+
+	r := SetUpRouter()
+	r.GET(ListTagURL, orchestratorServer.listTags)
+
+	// All tags
+	req, _ := http.NewRequest("GET", GetFormatterString(ListTagURL), nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	responseData, _ := io.ReadAll(w.Body)
+	var listResp *tagservicepb.ListTagsResponse
+	err := json.Unmarshal(responseData, &listResp)
+
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, listResp.Tags, 2)
+	assert.Empty(t, listResp.NextPageToken)
+
+	// One page
+	req, _ = http.NewRequest("GET", GetFormatterString(ListTagURL)+"?prefix=tag&limit=1&label=env=prod", nil)
+	w = httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	responseData, _ = io.ReadAll(w.Body)
+	err = json.Unmarshal(responseData, &listResp)
+
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, listResp.Tags, 1)
+	assert.NotEmpty(t, listResp.NextPageToken)
+
+	// Invalid limit and label
+	for _, query := range []string{"?limit=abc", "?label=env"} {
+		req, _ = http.NewRequest("GET", GetFormatterString(ListTagURL)+query, nil)
+		w = httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
 }
 
 func TestResolveTag(t *testing.T) {
@@ -975,6 +1217,20 @@ func TestResolveTag(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, expectedResult, nameMaps[0])
 
+	// Well-formed request with paths
+	req, _ = http.NewRequest("GET", url+"?include_paths=true", nil)
+	w = httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	responseData, _ = io.ReadAll(w.Body)
+	resolveResp := &tagservicepb.ResolveTagResponse{}
+	err = json.Unmarshal(responseData, resolveResp)
+
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, expectedResult, resolveResp.Tags[0])
+	assert.Equal(t, []string{tag}, resolveResp.Paths[0].Tags)
+
 	// Resolve non-existent tag
 	tag = "badtag"
 
@@ -984,7 +1240,41 @@ func TestResolveTag(t *testing.T) {
 
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestWatchTag(t *testing.T) {
+	orchestratorServer := newOrchestratorServer()
+	tagServerPort := getNewPortNumber()
+	orchestratorServer.localTagService = fmt.Sprintf("localhost:%d", tagServerPort)
+
+	faketagservice.SetupFakeTagServer(tagServerPort)
+
+	r := SetUpRouter()
+	r.GET(WatchTagURL, orchestratorServer.watchTag)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Well-formed request
+	url := fmt.Sprintf(GetFormatterString(WatchTagURL), faketagservice.ValidTagName)
+	resp, err := http.Get(server.URL + url)
+	require.Nil(t, err)
+	responseData, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Contains(t, string(responseData), "event:tag")
+	assert.Contains(t, string(responseData), faketagservice.ResolvedTagIp)
+
+	// Non-existent tag
+	url = fmt.Sprintf(GetFormatterString(WatchTagURL), "badtag")
+	resp, err = http.Get(server.URL + url)
+	require.Nil(t, err)
+	responseData, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	assert.Contains(t, string(responseData), "event:error")
 }
 
 func TestSetTag(t *testing.T) {
@@ -1070,7 +1360,7 @@ func TestDeleteTagMember(t *testing.T) {
 
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
 func TestDeleteTag(t *testing.T) {
@@ -1111,7 +1401,7 @@ func TestDeleteTag(t *testing.T) {
 
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
 func TestResolvePermitListRules(t *testing.T) {
@@ -1230,17 +1520,10 @@ func TestCreateSubscriberName(t *testing.T) {
 	assert.Equal(t, origUri, uri)
 }
 
-func TestDiffTagReferences(t *testing.T) {
-	beforePermitList := []*paragliderpb.PermitListRule{
-		&paragliderpb.PermitListRule{
-			Tags: []string{"tag1", "1.2.3.4"},
-		},
-		&paragliderpb.PermitListRule{
-			Tags: []string{"tag1", "tag2", "tag3"},
-		},
-	}
+func TestFindUnreferencedTags(t *testing.T) {
+	subscriptions := []string{"tag1", "tag2", "tag3"}
 
-	afterPermitList := []*paragliderpb.PermitListRule{
+	permitList := []*paragliderpb.PermitListRule{
 		&paragliderpb.PermitListRule{
 			Tags: []string{"tag1", "1.2.3.4"},
 		},
@@ -1249,7 +1532,7 @@ func TestDiffTagReferences(t *testing.T) {
 		},
 	}
 
-	tagDiff := diffTagReferences(beforePermitList, afterPermitList)
+	tagDiff := findUnreferencedTags(subscriptions, permitList)
 	expectedDiff := []string{"tag2"}
 
 	assert.Equal(t, expectedDiff, tagDiff)
@@ -1265,16 +1548,7 @@ func TestCheckAndUnsubscribe(t *testing.T) {
 
 	resource := ResourceInfo{uri: "uri", cloud: exampleCloudName, namespace: defaultNamespace}
 
-	beforePermitList := []*paragliderpb.PermitListRule{
-		&paragliderpb.PermitListRule{
-			Tags: []string{faketagservice.ValidTagName + "1", "1.2.3.4"},
-		},
-		&paragliderpb.PermitListRule{
-			Tags: []string{faketagservice.ValidTagName + "1", faketagservice.ValidTagName + "2", faketagservice.ValidTagName + "2"},
-		},
-	}
-
-	afterPermitList := []*paragliderpb.PermitListRule{
+	permitList := []*paragliderpb.PermitListRule{
 		&paragliderpb.PermitListRule{
 			Tags: []string{faketagservice.ValidTagName + "1", "1.2.3.4"},
 		},
@@ -1283,10 +1557,46 @@ func TestCheckAndUnsubscribe(t *testing.T) {
 		},
 	}
 
-	err := orchestratorServer.checkAndUnsubscribe(&resource, beforePermitList, afterPermitList)
+	err := orchestratorServer.checkAndUnsubscribe(&resource, permitList)
 	assert.Nil(t, err)
 }
 
+func TestSubscriptionsGet(t *testing.T) {
+	// Setup
+	orchestratorServer := newOrchestratorServer()
+	tagServerPort := getNewPortNumber()
+	cloudPluginPort := getNewPortNumber()
+	orchestratorServer.pluginAddresses[exampleCloudName] = fmt.Sprintf("localhost:%d", cloudPluginPort)
+	orchestratorServer.localTagService = fmt.Sprintf("localhost:%d", tagServerPort)
+
+	faketagservice.SetupFakeTagServer(tagServerPort)
+
+	r := SetUpRouter()
+	r.GET(GetSubscriptionsURL, orchestratorServer.subscriptionsGet)
+
+	// Well-formed request
+	url := fmt.Sprintf(GetFormatterString(GetSubscriptionsURL), defaultNamespace, exampleCloudName, faketagservice.ValidLastLevelTagName)
+	req, _ := http.NewRequest("GET", url, nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	responseData, _ := io.ReadAll(w.Body)
+	var tags []string
+	err := json.Unmarshal(responseData, &tags)
+
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{faketagservice.ValidTagName + "1", faketagservice.ValidTagName + "2"}, tags)
+
+	// Bad cloud name
+	url = fmt.Sprintf(GetFormatterString(GetSubscriptionsURL), defaultNamespace, "wrong", faketagservice.ValidLastLevelTagName)
+	req, _ = http.NewRequest("GET", url, nil)
+	w = httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
 func TestClearRuleTargets(t *testing.T) {
 	permitList := []*paragliderpb.PermitListRule{
 		&paragliderpb.PermitListRule{
@@ -1452,6 +1762,137 @@ func TestSetValue(t *testing.T) {
 	require.Nil(t, resp)
 }
 
+func TestRefreshResourceTag(t *testing.T) {
+	orchestratorServer := newOrchestratorServer()
+	tagServerPort := getNewPortNumber()
+	cloudPluginPort := getNewPortNumber()
+	orchestratorServer.pluginAddresses[exampleCloudName] = fmt.Sprintf("localhost:%d", cloudPluginPort)
+	orchestratorServer.localTagService = fmt.Sprintf("localhost:%d", tagServerPort)
+
+	fakeplugin.SetupFakePluginServer(cloudPluginPort)
+	faketagservice.SetupFakeTagServer(tagServerPort)
+	faketagservice.SubscriberCloudName = exampleCloudName
+
+	// Well-formed call
+	req := &paragliderpb.RefreshResourceTagRequest{Namespace: faketagservice.ValidTagName, Cloud: exampleCloudName, Name: "vm", Uri: "uri/vm", Ip: "2.2.2.2"}
+	resp, err := orchestratorServer.RefreshResourceTag(context.Background(), req)
+	require.Nil(t, err)
+	assert.Equal(t, int64(1), resp.Version)
+
+	// Bad cloud
+	req.Cloud = "wrong"
+	resp, err = orchestratorServer.RefreshResourceTag(context.Background(), req)
+	require.NotNil(t, err)
+	require.Nil(t, resp)
+}
+
+func TestDeleteResourceTag(t *testing.T) {
+	orchestratorServer := newOrchestratorServer()
+	tagServerPort := getNewPortNumber()
+	cloudPluginPort := getNewPortNumber()
+	orchestratorServer.pluginAddresses[exampleCloudName] = fmt.Sprintf("localhost:%d", cloudPluginPort)
+	orchestratorServer.localTagService = fmt.Sprintf("localhost:%d", tagServerPort)
+
+	fakeplugin.SetupFakePluginServer(cloudPluginPort)
+	faketagservice.SetupFakeTagServer(tagServerPort)
+	faketagservice.SubscriberCloudName = exampleCloudName
+
+	// Well-formed call
+	req := &paragliderpb.DeleteResourceTagRequest{Namespace: faketagservice.ValidTagName, Cloud: exampleCloudName, Name: "vm"}
+	_, err := orchestratorServer.DeleteResourceTag(context.Background(), req)
+	require.Nil(t, err)
+
+	// Tag service error
+	req.Namespace = "badtag"
+	_, err = orchestratorServer.DeleteResourceTag(context.Background(), req)
+	require.NotNil(t, err)
+
+	// Bad cloud
+	req.Cloud = "wrong"
+	_, err = orchestratorServer.DeleteResourceTag(context.Background(), req)
+	require.NotNil(t, err)
+}
+
+func TestGetDnsServers(t *testing.T) {
+	orchestratorServer := newOrchestratorServer()
+
+	// No DNS server configured
+	resp, err := orchestratorServer.GetDnsServers(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Empty(t, resp.IpAddresses)
+
+	orchestratorServer.config.DnsServer = config.DnsServer{Port: "53", AdvertisedAddresses: []string{"10.0.0.53"}}
+	resp, err = orchestratorServer.GetDnsServers(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.53"}, resp.IpAddresses)
+}
+
+func TestSyncResourceTag(t *testing.T) {
+	orchestratorServer := newOrchestratorServer()
+	tagServerPort := getNewPortNumber()
+	cloudPluginPort := getNewPortNumber()
+	orchestratorServer.pluginAddresses[exampleCloudName] = fmt.Sprintf("localhost:%d", cloudPluginPort)
+	orchestratorServer.localTagService = fmt.Sprintf("localhost:%d", tagServerPort)
+
+	fakeplugin.SetupFakePluginServer(cloudPluginPort)
+	faketagservice.SetupFakeTagServer(tagServerPort)
+	faketagservice.SubscriberCloudName = exampleCloudName
+
+	conn, err := grpc.NewClient(orchestratorServer.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.Nil(t, err)
+	defer conn.Close()
+	client := tagservicepb.NewTagServiceClient(conn)
+
+	uri := "uri/vm"
+	staleIp := "2.2.2.2"
+	version := int64(3)
+	tagName := createTagName(faketagservice.ValidTagName, exampleCloudName, "vm")
+
+	// Stale IP is updated
+	updated, err := orchestratorServer.syncResourceTag(client, &tagservicepb.TagMapping{Name: tagName, Uri: &uri, Ip: &staleIp, Version: &version})
+	require.Nil(t, err)
+	assert.True(t, updated)
+
+	// Current IP is left alone
+	currentIp := fakeplugin.ResourceIp
+	updated, err = orchestratorServer.syncResourceTag(client, &tagservicepb.TagMapping{Name: tagName, Uri: &uri, Ip: &currentIp})
+	require.Nil(t, err)
+	assert.False(t, updated)
+
+	// Secondary IPs of the resource are not stale either
+	secondaryIp := fakeplugin.ResourceSecondaryIp
+	updated, err = orchestratorServer.syncResourceTag(client, &tagservicepb.TagMapping{Name: tagName, Uri: &uri, Ip: &secondaryIp})
+	require.Nil(t, err)
+	assert.False(t, updated)
+
+	// Tags which are not resources of a known cloud are skipped
+	updated, err = orchestratorServer.syncResourceTag(client, &tagservicepb.TagMapping{Name: faketagservice.ValidLastLevelTagName, Uri: &uri, Ip: &staleIp})
+	require.Nil(t, err)
+	assert.False(t, updated)
+	updated, err = orchestratorServer.syncResourceTag(client, &tagservicepb.TagMapping{Name: createTagName(defaultNamespace, "wrong", "vm"), Uri: &uri, Ip: &staleIp})
+	require.Nil(t, err)
+	assert.False(t, updated)
+	updated, err = orchestratorServer.syncResourceTag(client, &tagservicepb.TagMapping{Name: tagName, ChildTags: []string{"child"}})
+	require.Nil(t, err)
+	assert.False(t, updated)
+}
+
+func TestSyncResourceTags(t *testing.T) {
+	orchestratorServer := newOrchestratorServer()
+	tagServerPort := getNewPortNumber()
+	cloudPluginPort := getNewPortNumber()
+	orchestratorServer.pluginAddresses[exampleCloudName] = fmt.Sprintf("localhost:%d", cloudPluginPort)
+	orchestratorServer.localTagService = fmt.Sprintf("localhost:%d", tagServerPort)
+
+	fakeplugin.SetupFakePluginServer(cloudPluginPort)
+	faketagservice.SetupFakeTagServer(tagServerPort)
+
+	// None of the fake tags are resource tags
+	updated, err := orchestratorServer.syncResourceTags()
+	require.Nil(t, err)
+	assert.Empty(t, updated)
+}
+
 func TestDeleteValue(t *testing.T) {
 	orchestratorServer := newOrchestratorServer()
 	kvStorePort := getNewPortNumber()
@@ -1468,3 +1909,244 @@ func TestDeleteValue(t *testing.T) {
 	require.NotNil(t, err)
 	require.Nil(t, resp)
 }
+
+func TestCreateAndParseTagPolicies(t *testing.T) {
+	rule := &paragliderpb.PermitListRule{Name: "rule", Tags: []string{"tag"}, Targets: []string{"1.1.1.1"}, SrcPort: 1, DstPort: 2, Protocol: 6, Direction: paragliderpb.Direction_OUTBOUND}
+
+	policies, err := createTagPolicies([]*paragliderpb.PermitListRule{rule})
+	require.Nil(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, rule.Name, policies[0].Name)
+	assert.Equal(t, []string{"1.1.1.1"}, rule.Targets) // Original rule is left untouched
+
+	rules, err := parseTagPolicies(policies)
+	require.Nil(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, rule.Name, rules[0].Name)
+	assert.Equal(t, rule.Tags, rules[0].Tags)
+	assert.Equal(t, rule.Direction, rules[0].Direction)
+	assert.Empty(t, rules[0].Targets)
+
+	// Rule without a name
+	_, err = createTagPolicies([]*paragliderpb.PermitListRule{{Tags: []string{"tag"}}})
+	assert.NotNil(t, err)
+}
+
+func TestUpdateSelectorMembers(t *testing.T) {
+	// Setup
+	orchestratorServer := newOrchestratorServer()
+	tagServerPort := getNewPortNumber()
+	cloudPluginPort := getNewPortNumber()
+	orchestratorServer.pluginAddresses[exampleCloudName] = fmt.Sprintf("localhost:%d", cloudPluginPort)
+	orchestratorServer.localTagService = fmt.Sprintf("localhost:%d", tagServerPort)
+
+	fakeplugin.SetupFakePluginServer(cloudPluginPort)
+	faketagservice.SetupFakeTagServer(tagServerPort)
+	faketagservice.SubscriberCloudName = exampleCloudName
+
+	conn, err := grpc.NewClient(orchestratorServer.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.Nil(t, err)
+	defer conn.Close()
+	client := tagservicepb.NewTagServiceClient(conn)
+
+	// Members joining and leaving a selector tag with policies
+	member := createTagName(defaultNamespace, exampleCloudName, faketagservice.ValidTagName)
+	changes := []*tagservicepb.SelectorMembershipChange{
+		{SelectorTag: faketagservice.ValidTagName + "Selector", Member: member, Joined: true},
+		{SelectorTag: faketagservice.ValidTagName + "Selector", Member: member, Joined: false},
+	}
+	err = orchestratorServer.updateSelectorMembers(client, changes)
+	assert.Nil(t, err)
+
+	// Member which cannot be resolved
+	changes = []*tagservicepb.SelectorMembershipChange{{SelectorTag: faketagservice.ValidTagName + "Selector", Member: "invalid", Joined: true}}
+	err = orchestratorServer.updateSelectorMembers(client, changes)
+	assert.NotNil(t, err)
+}
+
+func TestUpdateTagPolicyMembers(t *testing.T) {
+	// Setup
+	orchestratorServer := newOrchestratorServer()
+	tagServerPort := getNewPortNumber()
+	cloudPluginPort := getNewPortNumber()
+	orchestratorServer.pluginAddresses[exampleCloudName] = fmt.Sprintf("localhost:%d", cloudPluginPort)
+	orchestratorServer.localTagService = fmt.Sprintf("localhost:%d", tagServerPort)
+
+	fakeplugin.SetupFakePluginServer(cloudPluginPort)
+	faketagservice.SetupFakeTagServer(tagServerPort)
+
+	rules := []*paragliderpb.PermitListRule{{Name: "rule", Tags: []string{"1.1.1.1"}, Direction: paragliderpb.Direction_INBOUND}}
+	member := func(name string) *tagservicepb.TagMapping {
+		return &tagservicepb.TagMapping{Name: createTagName(defaultNamespace, exampleCloudName, name), Uri: proto.String("uri/" + name), Ip: proto.String("2.2.2.2")}
+	}
+	before := map[string]*tagservicepb.TagMapping{}
+	for _, m := range []*tagservicepb.TagMapping{member("stays"), member("leaves")} {
+		before[m.Name] = m
+	}
+	after := map[string]*tagservicepb.TagMapping{}
+	for _, m := range []*tagservicepb.TagMapping{member("stays"), member("joins")} {
+		after[m.Name] = m
+	}
+
+	err := orchestratorServer.updateTagPolicyMembers(rules, before, after)
+	assert.Nil(t, err)
+
+	// Member with an invalid cloud
+	after["bad"] = &tagservicepb.TagMapping{Name: createTagName(defaultNamespace, "wrong", "bad"), Uri: proto.String("uri/bad")}
+	err = orchestratorServer.updateTagPolicyMembers(rules, before, after)
+	assert.NotNil(t, err)
+}
+
+// Set up an orchestrator backed by a real tag service and a simulated cloud with a VM (and its leaf tag) for each name
+// Returns a tag service client, the leaf tags of the VMs, and a function listing the names of the rules on a VM
+func setupTagPolicyServer(t *testing.T, names ...string) (*ControllerServer, tagservicepb.TagServiceClient, map[string]*tagservicepb.TagMapping, func(string) []string) {
+	orchestratorServer := newOrchestratorServer()
+	tagServerPort := getNewPortNumber()
+	orchestratorServer.localTagService = fmt.Sprintf("localhost:%d", tagServerPort)
+	tagservice.Setup(storage.NewMemoryStore(), tagServerPort, false)
+
+	pluginAddress, err := simcloud.Setup(0, simcloud.NewSimCloudPluginServer("", simcloud.DefaultSettings(exampleCloudName)))
+	require.Nil(t, err)
+	orchestratorServer.pluginAddresses[exampleCloudName] = pluginAddress
+
+	conn, err := grpc.NewClient(orchestratorServer.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+	client := tagservicepb.NewTagServiceClient(conn)
+
+	pluginConn, err := grpc.NewClient(pluginAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.Nil(t, err)
+	t.Cleanup(func() { pluginConn.Close() })
+	pluginClient := paragliderpb.NewCloudPluginClient(pluginConn)
+
+	vms := make(map[string]*tagservicepb.TagMapping)
+	for _, name := range names {
+		resp, err := pluginClient.CreateResource(context.Background(), &paragliderpb.CreateResourceRequest{Deployment: &paragliderpb.ParagliderDeployment{Id: "deployment", Namespace: defaultNamespace}, Name: name})
+		require.Nil(t, err)
+		vms[name] = &tagservicepb.TagMapping{Name: createTagName(defaultNamespace, exampleCloudName, name), Uri: proto.String(resp.Uri), Ip: proto.String(resp.Ip)}
+		_, err = orchestratorServer._setTag(client, vms[name])
+		require.Nil(t, err)
+	}
+	ruleNames := func(vm string) []string {
+		resp, err := pluginClient.GetPermitList(context.Background(), &paragliderpb.GetPermitListRequest{Namespace: defaultNamespace, Resource: *vms[vm].Uri})
+		require.Nil(t, err)
+		names := []string{}
+		for _, rule := range resp.Rules {
+			names = append(names, rule.Name)
+		}
+		return names
+	}
+	return orchestratorServer, client, vms, ruleNames
+}
+
+func TestNestedTagPolicies(t *testing.T) {
+	orchestratorServer, client, vms, ruleNames := setupTagPolicyServer(t, "vm1", "vm2")
+
+	// parent contains child, which contains vm1, and the policy is attached to parent
+	_, err := orchestratorServer._setTag(client, &tagservicepb.TagMapping{Name: "child", ChildTags: []string{vms["vm1"].Name}})
+	require.Nil(t, err)
+	_, err = orchestratorServer._setTag(client, &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{"child"}})
+	require.Nil(t, err)
+	rule := &paragliderpb.PermitListRule{Name: "parent-rule", Tags: []string{"1.1.1.1"}, SrcPort: 1, DstPort: 1, Protocol: 6, Direction: paragliderpb.Direction_INBOUND}
+	require.Nil(t, orchestratorServer._permitListRuleAddTag(client, "parent", []*paragliderpb.PermitListRule{rule}))
+	assert.Equal(t, []string{"parent-rule"}, ruleNames("vm1"))
+	assert.Empty(t, ruleNames("vm2"))
+
+	// Adding a VM to child applies parent's policy to it
+	_, err = orchestratorServer._setTag(client, &tagservicepb.TagMapping{Name: "child", ChildTags: []string{vms["vm2"].Name}})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"parent-rule"}, ruleNames("vm2"))
+
+	// A new IP for a VM re-applies the policies of the tags containing it
+	_, err = orchestratorServer._setTag(client, &tagservicepb.TagMapping{Name: vms["vm2"].Name, Ip: proto.String("10.0.0.100")})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"parent-rule"}, ruleNames("vm2"))
+
+	// Removing a VM from child removes parent's policy from it
+	require.Nil(t, orchestratorServer._deleteTagMember(client, "child", vms["vm2"].Name))
+	assert.Empty(t, ruleNames("vm2"))
+	assert.Equal(t, []string{"parent-rule"}, ruleNames("vm1"))
+
+	// Deleting child removes parent's policy from its former members
+	require.Nil(t, orchestratorServer._deleteTag(client, "child"))
+	assert.Empty(t, ruleNames("vm1"))
+}
+
+func TestSelectorTagPolicies(t *testing.T) {
+	orchestratorServer, client, vms, ruleNames := setupTagPolicyServer(t, "vm1")
+
+	// The prod selector tag is nested in a group with a policy
+	selector := "env=prod"
+	_, err := orchestratorServer._setTag(client, &tagservicepb.TagMapping{Name: "prod", Selector: &selector})
+	require.Nil(t, err)
+	_, err = orchestratorServer._setTag(client, &tagservicepb.TagMapping{Name: "group", ChildTags: []string{"prod"}})
+	require.Nil(t, err)
+	rule := &paragliderpb.PermitListRule{Name: "group-rule", Tags: []string{"1.1.1.1"}, SrcPort: 1, DstPort: 1, Protocol: 6, Direction: paragliderpb.Direction_INBOUND}
+	require.Nil(t, orchestratorServer._permitListRuleAddTag(client, "group", []*paragliderpb.PermitListRule{rule}))
+	assert.Empty(t, ruleNames("vm1"))
+
+	// Joining the selector tag applies the policies of the tags containing it
+	_, err = orchestratorServer._setTag(client, &tagservicepb.TagMapping{Name: vms["vm1"].Name, Labels: map[string]string{"env": "prod"}})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"group-rule"}, ruleNames("vm1"))
+
+	// Leaving it removes them
+	_, err = orchestratorServer._setTag(client, &tagservicepb.TagMapping{Name: vms["vm1"].Name, Labels: map[string]string{"env": "dev"}})
+	require.Nil(t, err)
+	assert.Empty(t, ruleNames("vm1"))
+
+	// Deleting a tag removes it from its selector tags along with their policies
+	_, err = orchestratorServer._setTag(client, &tagservicepb.TagMapping{Name: vms["vm1"].Name, Labels: map[string]string{"env": "prod"}})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"group-rule"}, ruleNames("vm1"))
+	require.Nil(t, orchestratorServer._deleteTag(client, vms["vm1"].Name))
+	assert.Empty(t, ruleNames("vm1"))
+
+	resolved, err := client.ResolveTag(context.Background(), &tagservicepb.ResolveTagRequest{TagName: "prod"})
+	require.Nil(t, err)
+	assert.Empty(t, resolved.Tags)
+}
+
+// A simulated cloud behind a real orchestrator gets address spaces and ASNs from it while the orchestrator gets the used ones back from the plugin
+func TestSimCloudAllocation(t *testing.T) {
+	orchestratorServer := newOrchestratorServer()
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.Nil(t, err)
+	grpcServer := grpc.NewServer()
+	paragliderpb.RegisterControllerServer(grpcServer, orchestratorServer)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	pluginAddress, err := simcloud.Setup(0, simcloud.NewSimCloudPluginServer(lis.Addr().String(), simcloud.DefaultSettings(exampleCloudName)))
+	require.Nil(t, err)
+	host, port, err := net.SplitHostPort(pluginAddress)
+	require.Nil(t, err)
+	orchestratorServer.pluginAddresses[exampleCloudName] = pluginAddress
+	orchestratorServer.config = config.Config{
+		CloudPlugins: []config.CloudPlugin{{Name: exampleCloudName, Host: host, Port: port}},
+		Namespaces:   map[string][]config.CloudDeployment{defaultNamespace: {{Name: exampleCloudName, Deployment: "deployment"}}},
+	}
+
+	pluginConn, err := grpc.NewClient(pluginAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.Nil(t, err)
+	t.Cleanup(func() { pluginConn.Close() })
+	pluginClient := paragliderpb.NewCloudPluginClient(pluginConn)
+
+	// The plugin would deadlock if it held its lock while waiting for the orchestrator
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	deployment := &paragliderpb.ParagliderDeployment{Id: "deployment", Namespace: defaultNamespace}
+	addressSpaces := map[string]bool{}
+	for _, region := range []string{"region1", "region2"} {
+		description, err := json.Marshal(&simcloud.ResourceDescription{Region: region})
+		require.Nil(t, err)
+		resp, err := pluginClient.CreateResource(ctx, &paragliderpb.CreateResourceRequest{Deployment: deployment, Name: "vm-" + region, Description: description})
+		require.Nil(t, err)
+		addressSpaces[strings.Join(strings.Split(resp.Ip, ".")[:2], ".")] = true
+	}
+	assert.Len(t, addressSpaces, 2)
+
+	gateway, err := pluginClient.CreateVpnGateway(ctx, &paragliderpb.CreateVpnGatewayRequest{Deployment: deployment})
+	require.Nil(t, err)
+	assert.Equal(t, uint32(MIN_PRIVATE_ASN_2BYTE), gateway.Asn)
+}
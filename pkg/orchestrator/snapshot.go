@@ -0,0 +1,525 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	grpc "google.golang.org/grpc"
+	insecure "google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	tagservicepb "github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
+)
+
+// Modes for importing a snapshot
+const (
+	ImportModeMerge   = "merge"   // Add and update what is in the snapshot and keep everything else
+	ImportModeReplace = "replace" // Also delete tags and rules which are not in the snapshot
+)
+
+// Rules attached to a tag
+type TagRules struct {
+	Tag   string                         `json:"tag"`
+	Rules []*paragliderpb.PermitListRule `json:"rules"`
+}
+
+// Rules in the permit list of a resource (other than those added by its tags)
+type ResourceRules struct {
+	Namespace string                         `json:"namespace"`
+	Cloud     string                         `json:"cloud"`
+	Name      string                         `json:"name"`
+	Rules     []*paragliderpb.PermitListRule `json:"rules"`
+}
+
+// Declarative snapshot of the tags and rules managed by the controller
+// Rules refer to other resources by tag name rather than by IP
+type Snapshot struct {
+	Tags          []*tagservicepb.TagMapping `json:"tags"`
+	TagRules      []*TagRules                `json:"tag_rules,omitempty"`
+	ResourceRules []*ResourceRules           `json:"resource_rules,omitempty"`
+}
+
+// Get every tag from the tag service
+func listAllTags(client tagservicepb.TagServiceClient) ([]*tagservicepb.TagMapping, error) {
+	tags := []*tagservicepb.TagMapping{}
+	pageToken := ""
+	for {
+		resp, err := client.ListTags(context.Background(), &tagservicepb.ListTagsRequest{PageToken: pageToken})
+		if err != nil {
+			return nil, withComponent(ComponentTagService, err)
+		}
+		tags = append(tags, resp.Tags...)
+		if resp.NextPageToken == "" {
+			return tags, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// Check whether a tag mapping is a leaf (a resource or an address)
+func isLeafSnapshotTag(tag *tagservicepb.TagMapping) bool {
+	return tag.Uri != nil || tag.Ip != nil
+}
+
+// Copy the parts of a tag which are set by users
+// Versions and the members of selector tags are derived, so they are dropped
+func snapshotTag(tag *tagservicepb.TagMapping, exists map[string]bool) *tagservicepb.TagMapping {
+	snapshot := &tagservicepb.TagMapping{Name: tag.Name, Uri: tag.Uri, Ip: tag.Ip, Labels: tag.Labels, Selector: tag.Selector}
+	if tag.Selector == nil {
+		// Deleting a tag does not remove it from its parents, but such members resolve to nothing
+		for _, child := range tag.ChildTags {
+			if exists[child] {
+				snapshot.ChildTags = append(snapshot.ChildTags, child)
+			}
+		}
+		sort.Strings(snapshot.ChildTags)
+	}
+	return snapshot
+}
+
+// Copy rules without the targets their tags were resolved to
+func snapshotRules(rules []*paragliderpb.PermitListRule) []*paragliderpb.PermitListRule {
+	copies := make([]*paragliderpb.PermitListRule, len(rules))
+	for i, rule := range rules {
+		copies[i] = proto.Clone(rule).(*paragliderpb.PermitListRule)
+	}
+	sort.Slice(copies, func(i, j int) bool { return copies[i].Name < copies[j].Name })
+	return clearRuleTargets(copies)
+}
+
+// Export all tags, tag rules and resource permit lists
+func (s *ControllerServer) exportSnapshot(client tagservicepb.TagServiceClient) (*Snapshot, error) {
+	tags, err := listAllTags(client)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+
+	exists := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		exists[tag.Name] = true
+	}
+
+	snapshot := &Snapshot{Tags: make([]*tagservicepb.TagMapping, len(tags))}
+	// Names of the rules each resource gets from its tags
+	inheritedRules := make(map[string]map[string]bool)
+	for i, tag := range tags {
+		snapshot.Tags[i] = snapshotTag(tag, exists)
+
+		rules, resources, err := getTagPoliciesAndResources(client, tag.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(rules) == 0 {
+			continue
+		}
+		snapshot.TagRules = append(snapshot.TagRules, &TagRules{Tag: tag.Name, Rules: snapshotRules(rules)})
+		for name := range resources {
+			if inheritedRules[name] == nil {
+				inheritedRules[name] = make(map[string]bool)
+			}
+			for _, rule := range rules {
+				inheritedRules[name][rule.Name] = true
+			}
+		}
+	}
+
+	for _, tag := range tags {
+		if tag.GetUri() == "" {
+			continue
+		}
+		namespace, cloud, name, err := parseTag(tag.Name)
+		if err != nil {
+			continue
+		}
+		pluginAddress, ok := s.pluginAddresses[cloud]
+		if !ok {
+			continue
+		}
+
+		permitList, err := s._permitListGet(namespace, tag.GetUri(), pluginAddress)
+		if err != nil {
+			return nil, fmt.Errorf("could not get permit list of %s: %w", tag.Name, err)
+		}
+		rules := []*paragliderpb.PermitListRule{}
+		for _, rule := range permitList.Rules {
+			if !inheritedRules[tag.Name][rule.Name] {
+				rules = append(rules, rule)
+			}
+		}
+		if len(rules) > 0 {
+			snapshot.ResourceRules = append(snapshot.ResourceRules, &ResourceRules{Namespace: namespace, Cloud: cloud, Name: name, Rules: snapshotRules(rules)})
+		}
+	}
+	return snapshot, nil
+}
+
+// Check that the rules have names and only refer to known tags
+func validateSnapshotRules(owner string, rules []*paragliderpb.PermitListRule, known map[string]*tagservicepb.TagMapping) error {
+	names := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rule of %s must have a name", owner)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("rule %s of %s is defined more than once", rule.Name, owner)
+		}
+		names[rule.Name] = true
+		for _, tag := range rule.Tags {
+			if _, ok := known[tag]; !ok && !isIpAddrOrCidr(tag) {
+				return fmt.Errorf("rule %s of %s refers to unknown tag %s", rule.Name, owner, tag)
+			}
+		}
+	}
+	return nil
+}
+
+// Check that the members of the snapshot's tags do not form a cycle
+func checkSnapshotCycles(tags map[string]*tagservicepb.TagMapping) error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(tags))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("cycle detected: %s", strings.Join(append(path, name), " -> "))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		if tag, ok := tags[name]; ok {
+			for _, child := range tag.ChildTags {
+				if err := visit(child, append(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check that everything the snapshot refers to exists before anything is written
+// Tags which already exist only count as known when merging
+func (s *ControllerServer) validateSnapshot(snapshot *Snapshot, mode string, existing []*tagservicepb.TagMapping) error {
+	if mode != ImportModeMerge && mode != ImportModeReplace {
+		return fmt.Errorf("invalid import mode %s (must be %s or %s)", mode, ImportModeMerge, ImportModeReplace)
+	}
+
+	tags := make(map[string]*tagservicepb.TagMapping, len(snapshot.Tags))
+	for _, tag := range snapshot.Tags {
+		if tag.Name == "" {
+			return fmt.Errorf("tag must have a name")
+		}
+		if _, ok := tags[tag.Name]; ok {
+			return fmt.Errorf("tag %s is defined more than once", tag.Name)
+		}
+		if isLeafSnapshotTag(tag) && (len(tag.ChildTags) > 0 || tag.Selector != nil) {
+			return fmt.Errorf("tag %s cannot have both an address and members", tag.Name)
+		}
+		if len(tag.ChildTags) > 0 && tag.Selector != nil {
+			return fmt.Errorf("selector tag %s cannot have explicit members", tag.Name)
+		}
+		tags[tag.Name] = tag
+	}
+	if err := checkSnapshotCycles(tags); err != nil {
+		return err
+	}
+
+	known := make(map[string]*tagservicepb.TagMapping, len(tags))
+	if mode == ImportModeMerge {
+		for _, tag := range existing {
+			known[tag.Name] = tag
+		}
+	}
+	for name, tag := range tags {
+		known[name] = tag
+	}
+
+	for _, tag := range snapshot.Tags {
+		for _, child := range tag.ChildTags {
+			if _, ok := known[child]; !ok {
+				return fmt.Errorf("tag %s has unknown member %s", tag.Name, child)
+			}
+		}
+	}
+
+	ruleTags := make(map[string]bool, len(snapshot.TagRules))
+	for _, tagRules := range snapshot.TagRules {
+		if _, ok := known[tagRules.Tag]; !ok {
+			return fmt.Errorf("rules refer to unknown tag %s", tagRules.Tag)
+		}
+		if ruleTags[tagRules.Tag] {
+			return fmt.Errorf("rules of tag %s are defined more than once", tagRules.Tag)
+		}
+		ruleTags[tagRules.Tag] = true
+		if err := validateSnapshotRules("tag "+tagRules.Tag, tagRules.Rules, known); err != nil {
+			return err
+		}
+	}
+
+	resources := make(map[string]bool, len(snapshot.ResourceRules))
+	for _, resourceRules := range snapshot.ResourceRules {
+		if _, ok := s.pluginAddresses[resourceRules.Cloud]; !ok {
+			return fmt.Errorf("invalid cloud name: %s", resourceRules.Cloud)
+		}
+		tagName := createTagName(resourceRules.Namespace, resourceRules.Cloud, resourceRules.Name)
+		if tag, ok := known[tagName]; !ok || tag.GetUri() == "" {
+			return fmt.Errorf("rules refer to unknown resource %s", tagName)
+		}
+		if resources[tagName] {
+			return fmt.Errorf("rules of resource %s are defined more than once", tagName)
+		}
+		resources[tagName] = true
+		if err := validateSnapshotRules("resource "+tagName, resourceRules.Rules, known); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Order tags so that members are set before the tags containing them
+func sortSnapshotTags(tags []*tagservicepb.TagMapping) []*tagservicepb.TagMapping {
+	byName := make(map[string]*tagservicepb.TagMapping, len(tags))
+	for _, tag := range tags {
+		byName[tag.Name] = tag
+	}
+
+	sorted := make([]*tagservicepb.TagMapping, 0, len(tags))
+	added := make(map[string]bool, len(tags))
+	var add func(tag *tagservicepb.TagMapping)
+	add = func(tag *tagservicepb.TagMapping) {
+		if added[tag.Name] {
+			return
+		}
+		added[tag.Name] = true
+		for _, child := range tag.ChildTags {
+			if childTag, ok := byName[child]; ok {
+				add(childTag)
+			}
+		}
+		sorted = append(sorted, tag)
+	}
+	for _, tag := range tags {
+		add(tag)
+	}
+	return sorted
+}
+
+// Get the names of rules which are not in a set of desired rules
+func missingRuleNames(current []*paragliderpb.PermitListRule, desired []*paragliderpb.PermitListRule) []string {
+	keep := make(map[string]bool, len(desired))
+	for _, rule := range desired {
+		keep[rule.Name] = true
+	}
+	names := []string{}
+	for _, rule := range current {
+		if !keep[rule.Name] {
+			names = append(names, rule.Name)
+		}
+	}
+	return names
+}
+
+// Delete the tags and rules which are currently set but not in the snapshot
+func (s *ControllerServer) pruneSnapshot(client tagservicepb.TagServiceClient, snapshot *Snapshot) error {
+	current, err := s.exportSnapshot(client)
+	if err != nil {
+		return err
+	}
+
+	// Rules are deleted before the tags so the resources they apply to can still be resolved
+	desiredResourceRules := make(map[string][]*paragliderpb.PermitListRule)
+	for _, resourceRules := range snapshot.ResourceRules {
+		desiredResourceRules[createTagName(resourceRules.Namespace, resourceRules.Cloud, resourceRules.Name)] = resourceRules.Rules
+	}
+	for _, resourceRules := range current.ResourceRules {
+		tagName := createTagName(resourceRules.Namespace, resourceRules.Cloud, resourceRules.Name)
+		ruleNames := missingRuleNames(resourceRules.Rules, desiredResourceRules[tagName])
+		if len(ruleNames) == 0 {
+			continue
+		}
+		uri, err := s.getTagUri(tagName)
+		if err != nil {
+			return err
+		}
+		resource := &ResourceInfo{name: resourceRules.Name, uri: uri, namespace: resourceRules.Namespace, cloud: resourceRules.Cloud}
+		if err := s._permitListRulesDelete(resource, ruleNames, s.pluginAddresses[resourceRules.Cloud]); err != nil {
+			return fmt.Errorf("could not delete rules from %s: %w", tagName, err)
+		}
+	}
+
+	desiredTagRules := make(map[string][]*paragliderpb.PermitListRule)
+	for _, tagRules := range snapshot.TagRules {
+		desiredTagRules[tagRules.Tag] = tagRules.Rules
+	}
+	for _, tagRules := range current.TagRules {
+		ruleNames := missingRuleNames(tagRules.Rules, desiredTagRules[tagRules.Tag])
+		if len(ruleNames) == 0 {
+			continue
+		}
+		if err := s._permitListRuleDeleteTag(client, tagRules.Tag, ruleNames); err != nil {
+			return fmt.Errorf("could not delete rules from tag %s: %w", tagRules.Tag, err)
+		}
+	}
+
+	desiredTags := make(map[string]*tagservicepb.TagMapping, len(snapshot.Tags))
+	for _, tag := range snapshot.Tags {
+		desiredTags[tag.Name] = tag
+	}
+	for _, tag := range current.Tags {
+		desired, ok := desiredTags[tag.Name]
+		if !ok {
+			if err := s._deleteTag(client, tag.Name); err != nil {
+				return fmt.Errorf("could not delete tag %s: %w", tag.Name, err)
+			}
+			continue
+		}
+
+		// Members are only ever added when setting a tag, so extra ones are deleted
+		keep := make(map[string]bool, len(desired.ChildTags))
+		for _, child := range desired.ChildTags {
+			keep[child] = true
+		}
+		for _, child := range tag.ChildTags {
+			if keep[child] {
+				continue
+			}
+			if err := s._deleteTagMember(client, tag.Name, child); err != nil {
+				return fmt.Errorf("could not delete member %s from tag %s: %w", child, tag.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Write the tags and rules of a snapshot
+func (s *ControllerServer) importSnapshot(client tagservicepb.TagServiceClient, snapshot *Snapshot, mode string) error {
+	existing, err := listAllTags(client)
+	if err != nil {
+		return err
+	}
+	if err := s.validateSnapshot(snapshot, mode, existing); err != nil {
+		return newInvalidRequestError(err)
+	}
+
+	if mode == ImportModeReplace {
+		if err := s.pruneSnapshot(client, snapshot); err != nil {
+			return err
+		}
+	}
+
+	for _, tag := range sortSnapshotTags(snapshot.Tags) {
+		tag = proto.Clone(tag).(*tagservicepb.TagMapping)
+		tag.Version = nil
+		if _, err := s._setTag(client, tag); err != nil {
+			return fmt.Errorf("could not set tag %s: %w", tag.Name, err)
+		}
+	}
+
+	for _, tagRules := range snapshot.TagRules {
+		if len(tagRules.Rules) == 0 {
+			continue
+		}
+		if err := s._permitListRuleAddTag(client, tagRules.Tag, snapshotRules(tagRules.Rules)); err != nil {
+			return fmt.Errorf("could not add rules to tag %s: %w", tagRules.Tag, err)
+		}
+	}
+
+	for _, resourceRules := range snapshot.ResourceRules {
+		if len(resourceRules.Rules) == 0 {
+			continue
+		}
+		tagName := createTagName(resourceRules.Namespace, resourceRules.Cloud, resourceRules.Name)
+		uri, err := s.getTagUri(tagName)
+		if err != nil {
+			return err
+		}
+		resource := &ResourceInfo{name: resourceRules.Name, uri: uri, namespace: resourceRules.Namespace, cloud: resourceRules.Cloud}
+		req := &paragliderpb.AddPermitListRulesRequest{Rules: snapshotRules(resourceRules.Rules), Namespace: resource.namespace, Resource: uri}
+		if _, err := s._permitListRulesAdd(req, resource, s.pluginAddresses[resource.cloud]); err != nil {
+			return fmt.Errorf("could not add rules to %s: %w", tagName, err)
+		}
+	}
+	return nil
+}
+
+// Export all tags and rules as a snapshot
+func (s *ControllerServer) snapshotExport(c *gin.Context) {
+	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		abortWithError(c, withComponent(ComponentTagService, err))
+		return
+	}
+	defer conn.Close()
+
+	client := tagservicepb.NewTagServiceClient(conn)
+	snapshot, err := s.exportSnapshot(client)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// Import a snapshot of tags and rules
+func (s *ControllerServer) snapshotImport(c *gin.Context) {
+	var snapshot Snapshot
+	if err := c.BindJSON(&snapshot); err != nil {
+		abortWithError(c, newInvalidRequestError(err))
+		return
+	}
+	mode := c.DefaultQuery("mode", ImportModeMerge)
+
+	conn, err := grpc.NewClient(s.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		abortWithError(c, withComponent(ComponentTagService, err))
+		return
+	}
+	defer conn.Close()
+
+	client := tagservicepb.NewTagServiceClient(conn)
+	if err := s.importSnapshot(client, &snapshot, mode); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{})
+}
@@ -0,0 +1,217 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	fakeplugin "github.com/paraglider-project/paraglider/pkg/fake/cloudplugin"
+	faketagservice "github.com/paraglider-project/paraglider/pkg/fake/tagservice"
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/storage"
+	tagservice "github.com/paraglider-project/paraglider/pkg/tag_service"
+	tagservicepb "github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Set up an orchestrator backed by a tag service with an in-memory store and a fake plugin
+func setupSnapshotServer(t *testing.T) (*ControllerServer, tagservicepb.TagServiceClient) {
+	orchestratorServer := newOrchestratorServer()
+	tagServerPort := getNewPortNumber()
+	cloudPluginPort := getNewPortNumber()
+	orchestratorServer.pluginAddresses[exampleCloudName] = fmt.Sprintf("localhost:%d", cloudPluginPort)
+	orchestratorServer.localTagService = fmt.Sprintf("localhost:%d", tagServerPort)
+
+	fakeplugin.SetupFakePluginServer(cloudPluginPort)
+	tagservice.Setup(storage.NewMemoryStore(), tagServerPort, false)
+
+	conn, err := grpc.NewClient(orchestratorServer.localTagService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return orchestratorServer, tagservicepb.NewTagServiceClient(conn)
+}
+
+// A snapshot with two resources in a group, a rule on the group and the tag the fake plugin's rule refers to
+func getExampleSnapshot() *Snapshot {
+	vm1 := createTagName(defaultNamespace, exampleCloudName, "vm1")
+	vm2 := createTagName(defaultNamespace, exampleCloudName, "vm2")
+	uri1, ip1 := "uri/vm1", "10.0.0.1"
+	uri2, ip2 := "uri/vm2", "10.0.0.2"
+	ip3 := "10.1.0.0/16"
+	return &Snapshot{
+		Tags: []*tagservicepb.TagMapping{
+			{Name: vm1, Uri: &uri1, Ip: &ip1},
+			{Name: vm2, Uri: &uri2, Ip: &ip2},
+			{Name: "group", ChildTags: []string{vm1, vm2}},
+			{Name: faketagservice.ValidTagName, Ip: &ip3},
+		},
+		TagRules: []*TagRules{
+			{Tag: "group", Rules: []*paragliderpb.PermitListRule{{Name: "group-rule", Tags: []string{"group"}, Protocol: 6, DstPort: 22, SrcPort: -1}}},
+		},
+		ResourceRules: []*ResourceRules{
+			{Namespace: defaultNamespace, Cloud: exampleCloudName, Name: "vm1", Rules: []*paragliderpb.PermitListRule{fakeplugin.ExampleRule}},
+		},
+	}
+}
+
+func TestExportSnapshot(t *testing.T) {
+	orchestratorServer, client := setupSnapshotServer(t)
+	require.Nil(t, orchestratorServer.importSnapshot(client, getExampleSnapshot(), ImportModeMerge))
+
+	snapshot, err := orchestratorServer.exportSnapshot(client)
+	require.Nil(t, err)
+
+	require.Len(t, snapshot.Tags, 4)
+	assert.Equal(t, createTagName(defaultNamespace, exampleCloudName, "vm1"), snapshot.Tags[0].Name)
+	assert.Equal(t, "group", snapshot.Tags[2].Name)
+	assert.Equal(t, []string{createTagName(defaultNamespace, exampleCloudName, "vm1"), createTagName(defaultNamespace, exampleCloudName, "vm2")}, snapshot.Tags[2].ChildTags)
+	for _, tag := range snapshot.Tags {
+		assert.Nil(t, tag.Version)
+	}
+
+	require.Len(t, snapshot.TagRules, 1)
+	assert.Equal(t, "group", snapshot.TagRules[0].Tag)
+	assert.Equal(t, "group-rule", snapshot.TagRules[0].Rules[0].Name)
+	assert.Empty(t, snapshot.TagRules[0].Rules[0].Targets)
+
+	// Every resource reports the fake plugin's rule, which refers to tags rather than IPs
+	require.Len(t, snapshot.ResourceRules, 2)
+	assert.Equal(t, "vm1", snapshot.ResourceRules[0].Name)
+	assert.Equal(t, fakeplugin.ExampleRule.Name, snapshot.ResourceRules[0].Rules[0].Name)
+	assert.Equal(t, fakeplugin.ExampleRule.Tags, snapshot.ResourceRules[0].Rules[0].Tags)
+	assert.Empty(t, snapshot.ResourceRules[0].Rules[0].Targets)
+
+	// An export can be imported as is
+	require.Nil(t, orchestratorServer.importSnapshot(client, snapshot, ImportModeReplace))
+}
+
+func TestImportSnapshotValidation(t *testing.T) {
+	orchestratorServer, client := setupSnapshotServer(t)
+
+	tests := map[string]func(snapshot *Snapshot){
+		"unknown member":    func(snapshot *Snapshot) { snapshot.Tags[2].ChildTags = append(snapshot.Tags[2].ChildTags, "missing") },
+		"duplicate tag":     func(snapshot *Snapshot) { snapshot.Tags = append(snapshot.Tags, snapshot.Tags[0]) },
+		"leaf with members": func(snapshot *Snapshot) { snapshot.Tags[0].ChildTags = []string{"group"} },
+		"cycle": func(snapshot *Snapshot) {
+			snapshot.Tags = append(snapshot.Tags, &tagservicepb.TagMapping{Name: "a", ChildTags: []string{"b"}}, &tagservicepb.TagMapping{Name: "b", ChildTags: []string{"a"}})
+		},
+		"unknown rule tag": func(snapshot *Snapshot) { snapshot.TagRules[0].Rules[0].Tags = []string{"missing"} },
+		"unnamed rule":     func(snapshot *Snapshot) { snapshot.TagRules[0].Rules[0].Name = "" },
+		"unknown resource": func(snapshot *Snapshot) { snapshot.ResourceRules[0].Name = "missing" },
+		"unknown cloud":    func(snapshot *Snapshot) { snapshot.ResourceRules[0].Cloud = "wrong" },
+	}
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			snapshot := getExampleSnapshot()
+			modify(snapshot)
+			require.NotNil(t, orchestratorServer.importSnapshot(client, snapshot, ImportModeMerge))
+
+			// Nothing is written when validation fails
+			tags, err := listAllTags(client)
+			require.Nil(t, err)
+			assert.Empty(t, tags)
+		})
+	}
+
+	require.NotNil(t, orchestratorServer.importSnapshot(client, getExampleSnapshot(), "wrong"))
+}
+
+func TestImportSnapshotModes(t *testing.T) {
+	orchestratorServer, client := setupSnapshotServer(t)
+	require.Nil(t, orchestratorServer.importSnapshot(client, getExampleSnapshot(), ImportModeMerge))
+
+	// Merging can refer to tags which already exist
+	otherIp := "10.2.0.1"
+	merge := &Snapshot{Tags: []*tagservicepb.TagMapping{
+		{Name: "other", Ip: &otherIp},
+		{Name: "group", ChildTags: []string{"other"}},
+	}}
+	require.Nil(t, orchestratorServer.importSnapshot(client, merge, ImportModeMerge))
+	group, err := client.GetTag(context.Background(), &tagservicepb.GetTagRequest{TagName: "group"})
+	require.Nil(t, err)
+	assert.Len(t, group.Tag.ChildTags, 3)
+
+	// Replacing cannot since they would be deleted
+	require.NotNil(t, orchestratorServer.importSnapshot(client, &Snapshot{Tags: merge.Tags[1:]}, ImportModeReplace))
+
+	// Replacing deletes tags, members and rules which are not in the snapshot
+	replace := getExampleSnapshot()
+	replace.Tags = replace.Tags[:3]
+	replace.Tags[2].ChildTags = replace.Tags[2].ChildTags[:1]
+	replace.TagRules = nil
+	replace.ResourceRules = nil
+	require.Nil(t, orchestratorServer.importSnapshot(client, replace, ImportModeReplace))
+
+	tags, err := listAllTags(client)
+	require.Nil(t, err)
+	assert.Len(t, tags, 3)
+	group, err = client.GetTag(context.Background(), &tagservicepb.GetTagRequest{TagName: "group"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{createTagName(defaultNamespace, exampleCloudName, "vm1")}, group.Tag.ChildTags)
+	rules, err := getTagPolicyRules(client, "group")
+	require.Nil(t, err)
+	assert.Empty(t, rules)
+}
+
+func TestSnapshotHandlers(t *testing.T) {
+	orchestratorServer, client := setupSnapshotServer(t)
+	r := SetUpRouter()
+	r.GET(ExportURL, orchestratorServer.snapshotExport)
+	r.POST(ImportURL, orchestratorServer.snapshotImport)
+
+	// Import
+	body, err := json.Marshal(getExampleSnapshot())
+	require.Nil(t, err)
+	req, _ := http.NewRequest("POST", ImportURL, bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	tags, err := listAllTags(client)
+	require.Nil(t, err)
+	assert.Len(t, tags, 4)
+
+	// Export
+	req, _ = http.NewRequest("GET", ExportURL, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var snapshot Snapshot
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &snapshot))
+	assert.Len(t, snapshot.Tags, 4)
+	assert.Len(t, snapshot.TagRules, 1)
+
+	// Bad mode
+	req, _ = http.NewRequest("POST", ImportURL+"?mode=wrong", bytes.NewBuffer(body))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
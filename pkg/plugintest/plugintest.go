@@ -0,0 +1,339 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package plugintest is a conformance suite for cloud plugins. It drives a
+// paragliderpb.CloudPluginServer backed by a fake of its cloud and checks the
+// behavior the orchestrator relies on, independent of the cloud underneath.
+package plugintest
+
+import (
+	"context"
+	"net/netip"
+	"sort"
+	"testing"
+
+	fake "github.com/paraglider-project/paraglider/pkg/fake/orchestrator/rpc"
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Names of the checks run by the suite, which can be used to skip checks a plugin does not support
+const (
+	CheckCreateResource     = "CreateResource"
+	CheckResourceInfo       = "ResourceInfo"
+	CheckRuleRoundTrip      = "RuleRoundTrip"
+	CheckTags               = "Tags"
+	CheckAddIdempotent      = "AddIdempotent"
+	CheckReplaceRule        = "ReplaceRule"
+	CheckDeleteRules        = "DeleteRules"
+	CheckDeleteIdempotent   = "DeleteIdempotent"
+	CheckNamespaceIsolation = "NamespaceIsolation"
+	CheckUsedAddressSpaces  = "UsedAddressSpaces"
+)
+
+// OtherNamespace is the default namespace used to check that resources are not visible outside of their own
+const OtherNamespace = "plugintest-other"
+
+// DefaultRuleTargets are used as rule targets when a target does not specify its own.
+// They are outside of any address space handed out by the fake orchestrator so rules never trigger peering.
+var DefaultRuleTargets = []string{"192.0.2.0/24", "198.51.100.0/24"}
+
+// Target is a plugin under test together with the state of the fake cloud backing it
+type Target struct {
+	// Server is the plugin under test
+	Server paragliderpb.CloudPluginServer
+	// Deployment is the deployment the fake cloud is set up for
+	Deployment *paragliderpb.ParagliderDeployment
+	// Resource is the URI of a resource which exists in the fake cloud in the deployment's namespace
+	Resource string
+	// CreateRequest creates a resource in the fake cloud (the create check is skipped when nil)
+	CreateRequest *paragliderpb.CreateResourceRequest
+	// RuleTargets are the addresses rules are written against (defaults to DefaultRuleTargets)
+	RuleTargets []string
+	// OtherNamespace is a namespace the resource is not in (defaults to OtherNamespace)
+	OtherNamespace string
+	// SymmetricPorts is set for clouds with stateful rules which only support equal source and destination ports
+	SymmetricPorts bool
+	// Skip maps the names of unsupported checks to the reason they are skipped
+	Skip map[string]string
+}
+
+// Setup creates the plugin under test connected to the orchestrator at orchestratorAddr, backed by a fresh fake of its cloud.
+// It should register any cleanup of the fake cloud with t.Cleanup.
+type Setup func(t *testing.T, orchestratorAddr string) *Target
+
+// Run runs every check against a fresh target created by setup, next to a fake orchestrator for the given cloud
+func Run(t *testing.T, cloud string, setup Setup) {
+	checks := []struct {
+		name  string
+		check func(t *testing.T, target *Target)
+	}{
+		{CheckCreateResource, testCreateResource},
+		{CheckResourceInfo, testResourceInfo},
+		{CheckRuleRoundTrip, testRuleRoundTrip},
+		{CheckTags, testTags},
+		{CheckAddIdempotent, testAddIdempotent},
+		{CheckReplaceRule, testReplaceRule},
+		{CheckDeleteRules, testDeleteRules},
+		{CheckDeleteIdempotent, testDeleteIdempotent},
+		{CheckNamespaceIsolation, testNamespaceIsolation},
+		{CheckUsedAddressSpaces, testUsedAddressSpaces},
+	}
+	for _, c := range checks {
+		t.Run(c.name, func(t *testing.T) {
+			_, orchestratorAddr, err := fake.SetupFakeOrchestratorRPCServer(cloud)
+			require.NoError(t, err)
+			target := setup(t, orchestratorAddr)
+			if reason, ok := target.Skip[c.name]; ok {
+				t.Skip(reason)
+			}
+			c.check(t, target)
+		})
+	}
+}
+
+// Rules used by the checks
+func (target *Target) newRule(name string) *paragliderpb.PermitListRule {
+	return target.withPorts(&paragliderpb.PermitListRule{
+		Name:      name,
+		Direction: paragliderpb.Direction_INBOUND,
+		DstPort:   22,
+		Protocol:  6,
+		Targets:   []string{target.ruleTargets()[0]},
+	})
+}
+
+func (target *Target) newOutboundRule(name string) *paragliderpb.PermitListRule {
+	targets := target.ruleTargets()
+	return target.withPorts(&paragliderpb.PermitListRule{
+		Name:      name,
+		Direction: paragliderpb.Direction_OUTBOUND,
+		DstPort:   53,
+		Protocol:  17,
+		Targets:   []string{targets[len(targets)-1]},
+	})
+}
+
+// withPorts sets the source port of the rule to match what the cloud supports
+func (target *Target) withPorts(rule *paragliderpb.PermitListRule) *paragliderpb.PermitListRule {
+	rule.SrcPort = -1
+	if target.SymmetricPorts {
+		rule.SrcPort = rule.DstPort
+	}
+	return rule
+}
+
+func (target *Target) ruleTargets() []string {
+	if len(target.RuleTargets) > 0 {
+		return target.RuleTargets
+	}
+	return DefaultRuleTargets
+}
+
+func (target *Target) otherNamespace() string {
+	if target.OtherNamespace != "" {
+		return target.OtherNamespace
+	}
+	return OtherNamespace
+}
+
+func (target *Target) addRules(t *testing.T, rules ...*paragliderpb.PermitListRule) {
+	_, err := target.Server.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{
+		Namespace: target.Deployment.Namespace,
+		Resource:  target.Resource,
+		Rules:     rules,
+	})
+	require.NoError(t, err)
+}
+
+func (target *Target) deleteRules(t *testing.T, names ...string) {
+	_, err := target.Server.DeletePermitListRules(context.Background(), &paragliderpb.DeletePermitListRulesRequest{
+		Namespace: target.Deployment.Namespace,
+		Resource:  target.Resource,
+		RuleNames: names,
+	})
+	require.NoError(t, err)
+}
+
+// getRules returns the rules of the target's resource by name
+func (target *Target) getRules(t *testing.T) map[string][]*paragliderpb.PermitListRule {
+	resp, err := target.Server.GetPermitList(context.Background(), &paragliderpb.GetPermitListRequest{
+		Namespace: target.Deployment.Namespace,
+		Resource:  target.Resource,
+	})
+	require.NoError(t, err)
+	rules := make(map[string][]*paragliderpb.PermitListRule)
+	for _, rule := range resp.Rules {
+		rules[rule.Name] = append(rules[rule.Name], rule)
+	}
+	return rules
+}
+
+// requireRule checks that exactly one rule with the expected name exists and that it matches the expected rule
+func requireRule(t *testing.T, rules map[string][]*paragliderpb.PermitListRule, expected *paragliderpb.PermitListRule) {
+	require.Len(t, rules[expected.Name], 1, "expected exactly one rule named %s", expected.Name)
+	actual := rules[expected.Name][0]
+	assert.Equal(t, expected.Direction, actual.Direction, "direction of rule %s", expected.Name)
+	assert.Equal(t, expected.SrcPort, actual.SrcPort, "source port of rule %s", expected.Name)
+	assert.Equal(t, expected.DstPort, actual.DstPort, "destination port of rule %s", expected.Name)
+	assert.Equal(t, expected.Protocol, actual.Protocol, "protocol of rule %s", expected.Name)
+	assert.ElementsMatch(t, expected.Targets, actual.Targets, "targets of rule %s", expected.Name)
+	assert.ElementsMatch(t, expected.Tags, actual.Tags, "tags of rule %s", expected.Name)
+}
+
+func testCreateResource(t *testing.T, target *Target) {
+	if target.CreateRequest == nil {
+		t.Skip("target does not support creating resources")
+	}
+	ctx := context.Background()
+	created, err := target.Server.CreateResource(ctx, target.CreateRequest)
+	require.NoError(t, err)
+	require.NotEmpty(t, created.Uri)
+	assert.Equal(t, target.CreateRequest.Name, created.Name)
+	_, err = netip.ParseAddr(created.Ip)
+	assert.NoError(t, err, "created resource has an invalid IP")
+
+	info, err := target.Server.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Namespace: target.CreateRequest.Deployment.Namespace, Uri: created.Uri})
+	require.NoError(t, err)
+	assert.Equal(t, created.Uri, info.Uri)
+	assert.Equal(t, created.Ip, info.Ip)
+}
+
+func testResourceInfo(t *testing.T, target *Target) {
+	ctx := context.Background()
+	info, err := target.Server.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Namespace: target.Deployment.Namespace, Uri: target.Resource})
+	require.NoError(t, err)
+	assert.Equal(t, target.Resource, info.Uri)
+	_, err = netip.ParseAddr(info.Ip)
+	assert.NoError(t, err, "resource has an invalid IP")
+}
+
+func testRuleRoundTrip(t *testing.T, target *Target) {
+	inbound := target.newRule("plugintest-inbound")
+	outbound := target.newOutboundRule("plugintest-outbound")
+	target.addRules(t, inbound, outbound)
+
+	rules := target.getRules(t)
+	requireRule(t, rules, inbound)
+	requireRule(t, rules, outbound)
+}
+
+func testTags(t *testing.T, target *Target) {
+	rule := target.newRule("plugintest-tagged")
+	rule.Tags = []string{"plugintest.tag1", "plugintest.tag2"}
+	target.addRules(t, rule)
+
+	requireRule(t, target.getRules(t), rule)
+}
+
+func testAddIdempotent(t *testing.T, target *Target) {
+	rule := target.newRule("plugintest-idempotent")
+	target.addRules(t, rule)
+	target.addRules(t, rule)
+
+	requireRule(t, target.getRules(t), rule)
+}
+
+func testReplaceRule(t *testing.T, target *Target) {
+	rule := target.newRule("plugintest-replaced")
+	target.addRules(t, rule)
+
+	rule.DstPort = 443
+	target.addRules(t, target.withPorts(rule))
+
+	requireRule(t, target.getRules(t), rule)
+}
+
+func testDeleteRules(t *testing.T, target *Target) {
+	kept := target.newRule("plugintest-kept")
+	deleted := target.newOutboundRule("plugintest-deleted")
+	target.addRules(t, kept, deleted)
+
+	target.deleteRules(t, deleted.Name)
+
+	rules := target.getRules(t)
+	requireRule(t, rules, kept)
+	assert.Empty(t, rules[deleted.Name])
+}
+
+func testDeleteIdempotent(t *testing.T, target *Target) {
+	rule := target.newRule("plugintest-deleted")
+	target.addRules(t, rule)
+
+	target.deleteRules(t, rule.Name)
+	target.deleteRules(t, rule.Name)
+
+	assert.Empty(t, target.getRules(t)[rule.Name])
+}
+
+func testNamespaceIsolation(t *testing.T, target *Target) {
+	ctx := context.Background()
+	rule := target.newRule("plugintest-isolated")
+	target.addRules(t, rule)
+
+	_, err := target.Server.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Namespace: target.otherNamespace(), Uri: target.Resource})
+	assert.Error(t, err, "resource is visible from another namespace")
+
+	_, err = target.Server.GetPermitList(ctx, &paragliderpb.GetPermitListRequest{Namespace: target.otherNamespace(), Resource: target.Resource})
+	assert.Error(t, err, "permit list is visible from another namespace")
+
+	other := target.newOutboundRule("plugintest-other")
+	_, err = target.Server.AddPermitListRules(ctx, &paragliderpb.AddPermitListRulesRequest{Namespace: target.otherNamespace(), Resource: target.Resource, Rules: []*paragliderpb.PermitListRule{other}})
+	assert.Error(t, err, "rules can be added from another namespace")
+
+	_, err = target.Server.DeletePermitListRules(ctx, &paragliderpb.DeletePermitListRulesRequest{Namespace: target.otherNamespace(), Resource: target.Resource, RuleNames: []string{rule.Name}})
+	assert.Error(t, err, "rules can be deleted from another namespace")
+
+	rules := target.getRules(t)
+	requireRule(t, rules, rule)
+	assert.Empty(t, rules[other.Name])
+}
+
+func testUsedAddressSpaces(t *testing.T, target *Target) {
+	ctx := context.Background()
+	resp, err := target.Server.GetUsedAddressSpaces(ctx, &paragliderpb.GetUsedAddressSpacesRequest{Deployments: []*paragliderpb.ParagliderDeployment{target.Deployment}})
+	require.NoError(t, err)
+	require.Len(t, resp.AddressSpaceMappings, 1)
+	mapping := resp.AddressSpaceMappings[0]
+	assert.Equal(t, target.Deployment.Namespace, mapping.Namespace)
+	require.NotEmpty(t, mapping.AddressSpaces)
+
+	prefixes := make([]netip.Prefix, 0, len(mapping.AddressSpaces))
+	for _, addressSpace := range mapping.AddressSpaces {
+		prefix, err := netip.ParsePrefix(addressSpace)
+		require.NoError(t, err, "invalid address space %s", addressSpace)
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return prefixes[i].Addr().Less(prefixes[j].Addr()) })
+	for i := 1; i < len(prefixes); i++ {
+		assert.False(t, prefixes[i-1].Overlaps(prefixes[i]), "address spaces %s and %s overlap", prefixes[i-1], prefixes[i])
+	}
+
+	// The resource's IP must be within one of the reported address spaces
+	info, err := target.Server.GetResourceInfo(ctx, &paragliderpb.GetResourceInfoRequest{Namespace: target.Deployment.Namespace, Uri: target.Resource})
+	require.NoError(t, err)
+	ip, err := netip.ParseAddr(info.Ip)
+	require.NoError(t, err)
+	contained := false
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			contained = true
+			break
+		}
+	}
+	assert.True(t, contained, "resource IP %s is not within the reported address spaces %v", ip, mapping.AddressSpaces)
+}
@@ -0,0 +1,53 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugintest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/paraglider-project/paraglider/pkg/fake/simcloud"
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/stretchr/testify/require"
+)
+
+func newSimCloudTarget(t *testing.T, cloud string, orchestratorAddr string) *Target {
+	server := simcloud.NewSimCloudPluginServer(orchestratorAddr, simcloud.DefaultSettings(cloud))
+
+	deployment := &paragliderpb.ParagliderDeployment{Id: "test-deployment", Namespace: "default"}
+	resp, err := server.CreateResource(context.Background(), &paragliderpb.CreateResourceRequest{Deployment: deployment, Name: "vm"})
+	require.NoError(t, err)
+
+	return &Target{
+		Server:        server,
+		Deployment:    deployment,
+		Resource:      resp.Uri,
+		CreateRequest: &paragliderpb.CreateResourceRequest{Deployment: deployment, Name: "vm-created"},
+	}
+}
+
+func TestSimCloud(t *testing.T) {
+	for _, cloud := range []string{simcloud.DefaultCloud, "aws", "azure", "gcp", "ibm"} {
+		t.Run(cloud, func(t *testing.T) {
+			Run(t, cloud, func(t *testing.T, orchestratorAddr string) *Target {
+				return newSimCloudTarget(t, cloud, orchestratorAddr)
+			})
+		})
+	}
+}
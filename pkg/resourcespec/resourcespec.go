@@ -0,0 +1,165 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcespec
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kind of a cloud-agnostic virtual machine description
+const VMKind = "ParagliderVM"
+
+// Size classes which every cloud maps to one of its machine types
+const (
+	SizeSmall  = "small"
+	SizeMedium = "medium"
+	SizeLarge  = "large"
+)
+
+// Image families which every cloud maps to one of its images
+const (
+	ImageUbuntu2204 = "ubuntu-22.04"
+	ImageDebian12   = "debian-12"
+)
+
+// User which the SSH key is installed for
+const DefaultUsername = "paraglider"
+
+// Cloud-agnostic description of a virtual machine which each plugin translates into its native description
+type VM struct {
+	Kind       string    `json:"kind"`
+	Size       string    `json:"size"`                 // Size class (e.g., small)
+	Image      string    `json:"image"`                // Image family (e.g., ubuntu-22.04)
+	Region     string    `json:"region"`               // Cloud-specific region or zone (e.g., westus, us-west1-a, us-south-1)
+	SSHKey     string    `json:"sshKey"`               // Public SSH key for DefaultUsername
+	DiskSizeGB int32     `json:"diskSizeGb,omitempty"` // Boot disk size (cloud default if unset)
+	Mappings   *Mappings `json:"mappings,omitempty"`   // Overrides of the plugin's mappings (set by the orchestrator from its config)
+}
+
+// Mapping tables from size classes and image families to cloud-specific values
+type Mappings struct {
+	Sizes  map[string]string `json:"sizes,omitempty"`
+	Images map[string]string `json:"images,omitempty"`
+}
+
+// Checks whether a resource description is a cloud-agnostic virtual machine
+func IsVM(desc []byte) bool {
+	header := struct {
+		Kind string `json:"kind"`
+	}{}
+	if err := json.Unmarshal(desc, &header); err != nil {
+		return false
+	}
+	return header.Kind == VMKind
+}
+
+// Parses and validates a cloud-agnostic virtual machine description
+func ParseVM(desc []byte) (*VM, error) {
+	vm := &VM{}
+	if err := json.Unmarshal(desc, vm); err != nil {
+		return nil, fmt.Errorf("unable to parse %s description: %w", VMKind, err)
+	}
+	if vm.Kind != VMKind {
+		return nil, fmt.Errorf("description kind %q is not %s", vm.Kind, VMKind)
+	}
+	missing := []string{}
+	if vm.Size == "" {
+		missing = append(missing, "size")
+	}
+	if vm.Image == "" {
+		missing = append(missing, "image")
+	}
+	if vm.Region == "" {
+		missing = append(missing, "region")
+	}
+	if vm.SSHKey == "" {
+		missing = append(missing, "sshKey")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s description is missing %s", VMKind, strings.Join(missing, ", "))
+	}
+	if vm.DiskSizeGB < 0 {
+		return nil, fmt.Errorf("disk size must not be negative")
+	}
+	return vm, nil
+}
+
+// Returns a copy of the mappings with the overrides applied on top
+func (m Mappings) Merge(overrides *Mappings) Mappings {
+	merged := Mappings{Sizes: make(map[string]string), Images: make(map[string]string)}
+	for k, v := range m.Sizes {
+		merged.Sizes[k] = v
+	}
+	for k, v := range m.Images {
+		merged.Images[k] = v
+	}
+	if overrides != nil {
+		for k, v := range overrides.Sizes {
+			merged.Sizes[k] = v
+		}
+		for k, v := range overrides.Images {
+			merged.Images[k] = v
+		}
+	}
+	return merged
+}
+
+// Looks up the cloud-specific size and image of the virtual machine, applying the description's overrides to the given defaults
+func (vm *VM) Resolve(defaults Mappings) (string, string, error) {
+	mappings := defaults.Merge(vm.Mappings)
+	size, ok := mappings.Sizes[vm.Size]
+	if !ok {
+		return "", "", fmt.Errorf("unknown size class %q (known: %s)", vm.Size, strings.Join(sortedKeys(mappings.Sizes), ", "))
+	}
+	image, ok := mappings.Images[vm.Image]
+	if !ok {
+		return "", "", fmt.Errorf("unknown image family %q (known: %s)", vm.Image, strings.Join(sortedKeys(mappings.Images), ", "))
+	}
+	return size, image, nil
+}
+
+// Returns cloud-init user data which installs the SSH key for DefaultUsername
+func (vm *VM) CloudInit() string {
+	return fmt.Sprintf("#cloud-config\nusers:\n  - name: %s\n    sudo: ALL=(ALL) NOPASSWD:ALL\n    shell: /bin/bash\n    ssh_authorized_keys:\n      - %s\n", DefaultUsername, vm.SSHKey)
+}
+
+// Adds mapping overrides to a cloud-agnostic virtual machine description, keeping any overrides it already has for other keys
+func WithMappings(desc []byte, overrides Mappings) ([]byte, error) {
+	vm, err := ParseVM(desc)
+	if err != nil {
+		return nil, err
+	}
+	existing := Mappings{}
+	if vm.Mappings != nil {
+		existing = *vm.Mappings
+	}
+	merged := existing.Merge(&overrides)
+	vm.Mappings = &merged
+	return json.Marshal(vm)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,127 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcespec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMappings = Mappings{
+	Sizes:  map[string]string{SizeSmall: "small-type", SizeLarge: "large-type"},
+	Images: map[string]string{ImageUbuntu2204: "ubuntu-image"},
+}
+
+func getTestVM() *VM {
+	return &VM{Kind: VMKind, Size: SizeSmall, Image: ImageUbuntu2204, Region: "region", SSHKey: "ssh-ed25519 AAAA"}
+}
+
+func TestIsVM(t *testing.T) {
+	desc, err := json.Marshal(getTestVM())
+	require.NoError(t, err)
+	assert.True(t, IsVM(desc))
+
+	assert.False(t, IsVM([]byte(`{"location": "westus"}`)))
+	assert.False(t, IsVM([]byte(`{"kind": "Other"}`)))
+	assert.False(t, IsVM([]byte(`not json`)))
+}
+
+func TestParseVM(t *testing.T) {
+	desc, err := json.Marshal(getTestVM())
+	require.NoError(t, err)
+	vm, err := ParseVM(desc)
+	require.NoError(t, err)
+	assert.Equal(t, getTestVM(), vm)
+
+	// Missing fields
+	_, err = ParseVM([]byte(`{"kind": "ParagliderVM", "size": "small"}`))
+	require.ErrorContains(t, err, "missing image, region, sshKey")
+
+	// Wrong kind
+	_, err = ParseVM([]byte(`{"kind": "Other"}`))
+	require.Error(t, err)
+
+	// Negative disk size
+	invalid := getTestVM()
+	invalid.DiskSizeGB = -1
+	desc, err = json.Marshal(invalid)
+	require.NoError(t, err)
+	_, err = ParseVM(desc)
+	require.Error(t, err)
+}
+
+func TestMerge(t *testing.T) {
+	merged := testMappings.Merge(&Mappings{Sizes: map[string]string{SizeSmall: "override"}, Images: map[string]string{ImageDebian12: "debian-image"}})
+	assert.Equal(t, map[string]string{SizeSmall: "override", SizeLarge: "large-type"}, merged.Sizes)
+	assert.Equal(t, map[string]string{ImageUbuntu2204: "ubuntu-image", ImageDebian12: "debian-image"}, merged.Images)
+
+	// Defaults are left untouched
+	assert.Equal(t, "small-type", testMappings.Sizes[SizeSmall])
+	assert.Equal(t, testMappings.Sizes, testMappings.Merge(nil).Sizes)
+}
+
+func TestResolve(t *testing.T) {
+	vm := getTestVM()
+	size, image, err := vm.Resolve(testMappings)
+	require.NoError(t, err)
+	assert.Equal(t, "small-type", size)
+	assert.Equal(t, "ubuntu-image", image)
+
+	// Overrides from the description
+	vm.Mappings = &Mappings{Sizes: map[string]string{SizeSmall: "override"}}
+	size, _, err = vm.Resolve(testMappings)
+	require.NoError(t, err)
+	assert.Equal(t, "override", size)
+
+	// Unknown size class and image family
+	vm.Size = SizeMedium
+	_, _, err = vm.Resolve(testMappings)
+	require.ErrorContains(t, err, "known: large, small")
+	vm.Size = SizeSmall
+	vm.Image = ImageDebian12
+	_, _, err = vm.Resolve(testMappings)
+	require.ErrorContains(t, err, "unknown image family")
+}
+
+func TestCloudInit(t *testing.T) {
+	userData := getTestVM().CloudInit()
+	assert.Contains(t, userData, "#cloud-config")
+	assert.Contains(t, userData, "name: "+DefaultUsername)
+	assert.Contains(t, userData, "- ssh-ed25519 AAAA")
+}
+
+func TestWithMappings(t *testing.T) {
+	vm := getTestVM()
+	vm.Mappings = &Mappings{Sizes: map[string]string{SizeSmall: "request", SizeLarge: "request"}}
+	desc, err := json.Marshal(vm)
+	require.NoError(t, err)
+
+	desc, err = WithMappings(desc, Mappings{Sizes: map[string]string{SizeSmall: "config"}, Images: map[string]string{ImageUbuntu2204: "config"}})
+	require.NoError(t, err)
+	vm, err = ParseVM(desc)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{SizeSmall: "config", SizeLarge: "request"}, vm.Mappings.Sizes)
+	assert.Equal(t, map[string]string{ImageUbuntu2204: "config"}, vm.Mappings.Images)
+
+	_, err = WithMappings([]byte(`{"kind": "ParagliderVM"}`), Mappings{})
+	require.Error(t, err)
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package static
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Firewalls the permit lists of hosts can be rendered for
+const (
+	nftablesFirewall = "nftables"
+	iptablesFirewall = "iptables"
+)
+
+const (
+	defaultNamespace = "default"
+	defaultInterface = "eth0"
+)
+
+// Config declares the on-prem sites managed by the static plugin
+type Config struct {
+	OutputDir   string `yaml:"outputDir"`   // Directory the rendered configs and the plugin's state are written to
+	HttpAddress string `yaml:"httpAddress"` // Address the rendered configs are served on (disabled if empty)
+	Firewall    string `yaml:"firewall"`    // nftables (default) or iptables
+	Sites       []Site `yaml:"sites"`
+}
+
+// Site is an on-prem network whose address spaces, hosts, and VPN gateway already exist
+type Site struct {
+	Name          string   `yaml:"name"`
+	Namespace     string   `yaml:"namespace"` // Paraglider namespace of the site (default: default)
+	AddressSpaces []string `yaml:"addressSpaces"`
+	Gateway       Gateway  `yaml:"gateway"`
+	Hosts         []Host   `yaml:"hosts"`
+}
+
+// Gateway is the host running strongSwan (and FRR for BGP) which terminates the VPN connections of a site
+type Gateway struct {
+	PublicIp  string `yaml:"publicIp"`  // Address the VPN gateways of other clouds connect to
+	LocalIp   string `yaml:"localIp"`   // Address strongSwan listens on if the gateway is behind NAT (default: any)
+	Asn       uint32 `yaml:"asn"`       // BGP ASN of the gateway (allocated by the orchestrator if unset)
+	Interface string `yaml:"interface"` // Uplink the XFRM interfaces of route-based tunnels are bound to (default: eth0)
+}
+
+// Host is a machine in a site whose firewall is managed by Paraglider
+type Host struct {
+	Name string `yaml:"name"`
+	Ip   string `yaml:"ip"`
+}
+
+// Read and validate the config of the static plugin
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// Parse and validate the config of the static plugin
+func ParseConfig(data []byte) (*Config, error) {
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse config: %w", err)
+	}
+	cfg.setDefaults()
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) setDefaults() {
+	if c.Firewall == "" {
+		c.Firewall = nftablesFirewall
+	}
+	for i := range c.Sites {
+		if c.Sites[i].Namespace == "" {
+			c.Sites[i].Namespace = defaultNamespace
+		}
+		if c.Sites[i].Gateway.Interface == "" {
+			c.Sites[i].Gateway.Interface = defaultInterface
+		}
+	}
+}
+
+// Names are used in URIs and file names, so they can't contain separators
+func validateName(kind string, name string) error {
+	if name == "" {
+		return fmt.Errorf("%s name is required", kind)
+	}
+	if strings.ContainsAny(name, "/\\ ") || name == "." || name == ".." {
+		return fmt.Errorf("invalid %s name %q", kind, name)
+	}
+	return nil
+}
+
+func (c *Config) validate() error {
+	if c.OutputDir == "" {
+		return fmt.Errorf("outputDir is required")
+	}
+	if c.Firewall != nftablesFirewall && c.Firewall != iptablesFirewall {
+		return fmt.Errorf("unsupported firewall %s (expected %s or %s)", c.Firewall, nftablesFirewall, iptablesFirewall)
+	}
+	sites := make(map[string]bool)
+	for _, site := range c.Sites {
+		if err := validateName("site", site.Name); err != nil {
+			return err
+		}
+		if sites[site.Name] {
+			return fmt.Errorf("duplicate site %s", site.Name)
+		}
+		sites[site.Name] = true
+		if err := site.validate(); err != nil {
+			return fmt.Errorf("invalid site %s: %w", site.Name, err)
+		}
+	}
+	return nil
+}
+
+func (site *Site) validate() error {
+	if len(site.AddressSpaces) == 0 {
+		return fmt.Errorf("at least one address space is required")
+	}
+	prefixes := make([]netip.Prefix, len(site.AddressSpaces))
+	for i, addressSpace := range site.AddressSpaces {
+		prefix, err := netip.ParsePrefix(addressSpace)
+		if err != nil {
+			return fmt.Errorf("invalid address space %s: %w", addressSpace, err)
+		}
+		prefixes[i] = prefix
+	}
+	for _, ip := range []string{site.Gateway.PublicIp, site.Gateway.LocalIp} {
+		if ip == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(ip); err != nil || !addr.Is4() {
+			return fmt.Errorf("invalid gateway address %s: expected an IPv4 address", ip)
+		}
+	}
+	hosts := make(map[string]bool)
+	for _, host := range site.Hosts {
+		if err := validateName("host", host.Name); err != nil {
+			return err
+		}
+		if hosts[host.Name] {
+			return fmt.Errorf("duplicate host %s", host.Name)
+		}
+		hosts[host.Name] = true
+		addr, err := netip.ParseAddr(host.Ip)
+		if err != nil {
+			return fmt.Errorf("invalid address %s of host %s: %w", host.Ip, host.Name, err)
+		}
+		contained := false
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			return fmt.Errorf("address %s of host %s is not in the address spaces of the site", host.Ip, host.Name)
+		}
+	}
+	return nil
+}
+
+// Get a host of the site by name
+func (site *Site) getHost(name string) *Host {
+	for i := range site.Hosts {
+		if site.Hosts[i].Name == name {
+			return &site.Hosts[i]
+		}
+	}
+	return nil
+}
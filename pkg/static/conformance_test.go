@@ -0,0 +1,45 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package static
+
+import (
+	"testing"
+
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/paraglider-project/paraglider/pkg/plugintest"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	plugintest.Run(t, utils.STATIC, setupConformance)
+}
+
+func setupConformance(t *testing.T, orchestratorAddr string) *plugintest.Target {
+	s, err := NewStaticPluginServer(orchestratorAddr, getFakeConfig(t, nftablesFirewall))
+	require.NoError(t, err)
+
+	return &plugintest.Target{
+		Server:     s,
+		Deployment: fakeDeployment,
+		Resource:   fakeHostUri,
+		// Creating a host registers one which is declared in the config
+		CreateRequest: &paragliderpb.CreateResourceRequest{Deployment: fakeDeployment, Name: fakeOtherHost},
+	}
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package static
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Longest comment nftables accepts, which holds the name of the rule
+const maxRuleNameLength = 128
+
+// Protocols referred to by name in the rendered rules
+var protocolNames = map[int32]string{
+	1:   "icmp",
+	6:   "tcp",
+	17:  "udp",
+	132: "sctp",
+}
+
+// Protocols whose rules may match on ports
+var portProtocols = map[int32]bool{6: true, 17: true, 132: true}
+
+// Parse a rule target (an address or an address space) into an address space
+func parseTarget(target string) (netip.Prefix, error) {
+	if strings.Contains(target, "/") {
+		prefix, err := netip.ParsePrefix(target)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(target)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Check that a permit list rule can be rendered for the firewall
+func validatePermitListRule(rule *paragliderpb.PermitListRule, firewall string) error {
+	if rule.Name == "" || len(rule.Name) > maxRuleNameLength {
+		return status.Errorf(codes.InvalidArgument, "rule names must have between 1 and %d characters", maxRuleNameLength)
+	}
+	for _, c := range rule.Name {
+		if c == '"' || c == '\\' || c < ' ' || c == 0x7f {
+			return status.Errorf(codes.InvalidArgument, "rule name %q contains unsupported characters", rule.Name)
+		}
+	}
+	if rule.Protocol < -1 || rule.Protocol > 255 {
+		return status.Errorf(codes.InvalidArgument, "invalid protocol %d of rule %s", rule.Protocol, rule.Name)
+	}
+	for _, port := range []int32{rule.SrcPort, rule.DstPort} {
+		if port < -1 || port > 65535 {
+			return status.Errorf(codes.InvalidArgument, "invalid port %d of rule %s", port, rule.Name)
+		}
+		if port != -1 && !portProtocols[rule.Protocol] {
+			return status.Errorf(codes.InvalidArgument, "rule %s can only match on ports with tcp, udp, or sctp", rule.Name)
+		}
+	}
+	if len(rule.Targets) == 0 {
+		return status.Errorf(codes.InvalidArgument, "rule %s has no targets", rule.Name)
+	}
+	for _, target := range rule.Targets {
+		prefix, err := parseTarget(target)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid target %s of rule %s", target, rule.Name)
+		}
+		if firewall == iptablesFirewall && !prefix.Addr().Is4() {
+			return status.Errorf(codes.InvalidArgument, "target %s of rule %s is not an IPv4 address, which is the only family rendered for iptables", target, rule.Name)
+		}
+	}
+	return nil
+}
+
+// Get the name of the file the firewall rules of a host are rendered to
+func getHostFirewallFileName(host string, firewall string) string {
+	if firewall == iptablesFirewall {
+		return host + ".rules"
+	}
+	return host + ".nft"
+}
+
+// Render the permit list of a host as firewall rules which drop all traffic not permitted by a rule
+func renderFirewall(firewall string, site *Site, host *Host, rules []*permitListRule) []byte {
+	if firewall == iptablesFirewall {
+		return renderIptables(site, host, rules)
+	}
+	return renderNftables(site, host, rules)
+}
+
+// Render a ruleset for nft -f which replaces the paraglider table and leaves other tables untouched
+func renderNftables(site *Site, host *Host, rules []*permitListRule) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Permit list of host %s (%s) in site %s, rendered by Paraglider\n", host.Name, host.Ip, site.Name)
+	fmt.Fprintf(&b, "# Apply with: nft -f %s\n", getHostFirewallFileName(host.Name, nftablesFirewall))
+	b.WriteString("table inet paraglider\n")
+	b.WriteString("delete table inet paraglider\n")
+	b.WriteString("table inet paraglider {\n")
+	for _, chain := range []struct {
+		name      string
+		direction string
+		iface     string
+		address   string
+	}{
+		{"input", paragliderpb.Direction_INBOUND.String(), "iif", "saddr"},
+		{"output", paragliderpb.Direction_OUTBOUND.String(), "oif", "daddr"},
+	} {
+		fmt.Fprintf(&b, "\tchain %s {\n", chain.name)
+		fmt.Fprintf(&b, "\t\ttype filter hook %s priority filter; policy drop;\n", chain.name)
+		b.WriteString("\t\tct state established,related accept\n")
+		fmt.Fprintf(&b, "\t\t%s \"lo\" accept\n", chain.iface)
+		for _, rule := range rules {
+			if rule.Direction != chain.direction {
+				continue
+			}
+			// Each address family needs its own match
+			var ipv4Targets, ipv6Targets []string
+			for _, target := range rule.Targets {
+				prefix, _ := parseTarget(target)
+				if prefix.Addr().Is4() {
+					ipv4Targets = append(ipv4Targets, prefix.String())
+				} else {
+					ipv6Targets = append(ipv6Targets, prefix.String())
+				}
+			}
+			for _, family := range []struct {
+				name    string
+				targets []string
+			}{{"ip", ipv4Targets}, {"ip6", ipv6Targets}} {
+				if len(family.targets) == 0 {
+					continue
+				}
+				fmt.Fprintf(&b, "\t\t%s %s %s", family.name, chain.address, nftablesSet(family.targets))
+				if rule.Protocol != -1 {
+					fmt.Fprintf(&b, " meta l4proto %s", getProtocolName(rule.Protocol))
+				}
+				if rule.SrcPort != -1 {
+					fmt.Fprintf(&b, " th sport %d", rule.SrcPort)
+				}
+				if rule.DstPort != -1 {
+					fmt.Fprintf(&b, " th dport %d", rule.DstPort)
+				}
+				fmt.Fprintf(&b, " accept comment \"%s\"\n", rule.Name)
+			}
+		}
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+func nftablesSet(elements []string) string {
+	if len(elements) == 1 {
+		return elements[0]
+	}
+	return "{ " + strings.Join(elements, ", ") + " }"
+}
+
+// Render a filter table for iptables-restore which drops all inbound and outbound traffic not permitted by a rule
+func renderIptables(site *Site, host *Host, rules []*permitListRule) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Permit list of host %s (%s) in site %s, rendered by Paraglider\n", host.Name, host.Ip, site.Name)
+	fmt.Fprintf(&b, "# Apply with: iptables-restore %s\n", getHostFirewallFileName(host.Name, iptablesFirewall))
+	b.WriteString("*filter\n")
+	b.WriteString(":INPUT DROP [0:0]\n")
+	b.WriteString(":FORWARD ACCEPT [0:0]\n")
+	b.WriteString(":OUTPUT DROP [0:0]\n")
+	for _, chain := range []struct {
+		name      string
+		direction string
+		iface     string
+		address   string
+	}{
+		{"INPUT", paragliderpb.Direction_INBOUND.String(), "-i", "-s"},
+		{"OUTPUT", paragliderpb.Direction_OUTBOUND.String(), "-o", "-d"},
+	} {
+		fmt.Fprintf(&b, "-A %s -m conntrack --ctstate ESTABLISHED,RELATED -j ACCEPT\n", chain.name)
+		fmt.Fprintf(&b, "-A %s %s lo -j ACCEPT\n", chain.name, chain.iface)
+		for _, rule := range rules {
+			if rule.Direction != chain.direction {
+				continue
+			}
+			for _, target := range rule.Targets {
+				prefix, _ := parseTarget(target)
+				fmt.Fprintf(&b, "-A %s %s %s", chain.name, chain.address, prefix)
+				if rule.Protocol != -1 {
+					fmt.Fprintf(&b, " -p %s", getProtocolName(rule.Protocol))
+				}
+				if rule.SrcPort != -1 {
+					fmt.Fprintf(&b, " --sport %d", rule.SrcPort)
+				}
+				if rule.DstPort != -1 {
+					fmt.Fprintf(&b, " --dport %d", rule.DstPort)
+				}
+				fmt.Fprintf(&b, " -m comment --comment \"%s\" -j ACCEPT\n", rule.Name)
+			}
+		}
+	}
+	b.WriteString("COMMIT\n")
+	return []byte(b.String())
+}
+
+func getProtocolName(protocol int32) string {
+	if name, ok := protocolNames[protocol]; ok {
+		return name
+	}
+	return fmt.Sprint(protocol)
+}
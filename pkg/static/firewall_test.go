@@ -0,0 +1,117 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package static
+
+import (
+	"testing"
+
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	fakeFirewallSite  = &Site{Name: "lab"}
+	fakeFirewallHost  = &Host{Name: "web-1", Ip: "10.250.0.5"}
+	fakeFirewallRules = []*permitListRule{
+		{Name: "ssh", Direction: "INBOUND", SrcPort: -1, DstPort: 22, Protocol: 6, Targets: []string{"192.0.2.0/24", "10.1.0.4"}},
+		{Name: "dns", Direction: "OUTBOUND", SrcPort: -1, DstPort: 53, Protocol: 17, Targets: []string{"198.51.100.53"}},
+		{Name: "all", Direction: "INBOUND", SrcPort: -1, DstPort: -1, Protocol: -1, Targets: []string{"2001:db8::/32"}},
+	}
+)
+
+func TestRenderNftables(t *testing.T) {
+	expected := `# Permit list of host web-1 (10.250.0.5) in site lab, rendered by Paraglider
+# Apply with: nft -f web-1.nft
+table inet paraglider
+delete table inet paraglider
+table inet paraglider {
+	chain input {
+		type filter hook input priority filter; policy drop;
+		ct state established,related accept
+		iif "lo" accept
+		ip saddr { 192.0.2.0/24, 10.1.0.4/32 } meta l4proto tcp th dport 22 accept comment "ssh"
+		ip6 saddr 2001:db8::/32 accept comment "all"
+	}
+	chain output {
+		type filter hook output priority filter; policy drop;
+		ct state established,related accept
+		oif "lo" accept
+		ip daddr 198.51.100.53/32 meta l4proto udp th dport 53 accept comment "dns"
+	}
+}
+`
+	assert.Equal(t, expected, string(renderFirewall(nftablesFirewall, fakeFirewallSite, fakeFirewallHost, fakeFirewallRules)))
+}
+
+func TestRenderIptables(t *testing.T) {
+	rules := []*permitListRule{
+		{Name: "ssh", Direction: "INBOUND", SrcPort: 1024, DstPort: 22, Protocol: 6, Targets: []string{"192.0.2.0/24", "10.1.0.4"}},
+		{Name: "gre", Direction: "OUTBOUND", SrcPort: -1, DstPort: -1, Protocol: 47, Targets: []string{"198.51.100.0/24"}},
+	}
+	expected := `# Permit list of host web-1 (10.250.0.5) in site lab, rendered by Paraglider
+# Apply with: iptables-restore web-1.rules
+*filter
+:INPUT DROP [0:0]
+:FORWARD ACCEPT [0:0]
+:OUTPUT DROP [0:0]
+-A INPUT -m conntrack --ctstate ESTABLISHED,RELATED -j ACCEPT
+-A INPUT -i lo -j ACCEPT
+-A INPUT -s 192.0.2.0/24 -p tcp --sport 1024 --dport 22 -m comment --comment "ssh" -j ACCEPT
+-A INPUT -s 10.1.0.4/32 -p tcp --sport 1024 --dport 22 -m comment --comment "ssh" -j ACCEPT
+-A OUTPUT -m conntrack --ctstate ESTABLISHED,RELATED -j ACCEPT
+-A OUTPUT -o lo -j ACCEPT
+-A OUTPUT -d 198.51.100.0/24 -p 47 -m comment --comment "gre" -j ACCEPT
+COMMIT
+`
+	assert.Equal(t, expected, string(renderFirewall(iptablesFirewall, fakeFirewallSite, fakeFirewallHost, rules)))
+}
+
+func TestValidatePermitListRule(t *testing.T) {
+	valid := func() *paragliderpb.PermitListRule {
+		return &paragliderpb.PermitListRule{Name: "ssh", Direction: paragliderpb.Direction_INBOUND, SrcPort: -1, DstPort: 22, Protocol: 6, Targets: []string{"192.0.2.0/24"}}
+	}
+	require.NoError(t, validatePermitListRule(valid(), nftablesFirewall))
+	require.NoError(t, validatePermitListRule(valid(), iptablesFirewall))
+
+	tests := []struct {
+		name     string
+		firewall string
+		modify   func(rule *paragliderpb.PermitListRule)
+	}{
+		{"quoted name", nftablesFirewall, func(rule *paragliderpb.PermitListRule) { rule.Name = `ssh"` }},
+		{"invalid protocol", nftablesFirewall, func(rule *paragliderpb.PermitListRule) { rule.Protocol = 256 }},
+		{"invalid port", nftablesFirewall, func(rule *paragliderpb.PermitListRule) { rule.DstPort = 65536 }},
+		{"port without transport protocol", nftablesFirewall, func(rule *paragliderpb.PermitListRule) { rule.Protocol = 1 }},
+		{"no targets", nftablesFirewall, func(rule *paragliderpb.PermitListRule) { rule.Targets = nil }},
+		{"invalid target", nftablesFirewall, func(rule *paragliderpb.PermitListRule) { rule.Targets = []string{"web-1"} }},
+		{"ipv6 target with iptables", iptablesFirewall, func(rule *paragliderpb.PermitListRule) { rule.Targets = []string{"2001:db8::1"} }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule := valid()
+			test.modify(rule)
+			err := validatePermitListRule(rule, test.firewall)
+			require.Error(t, err)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
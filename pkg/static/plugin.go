@@ -0,0 +1,604 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package static
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// State reported for hosts, which exist independently of Paraglider
+const hostState = "DECLARED"
+
+// StaticPluginServer renders the host firewalls and VPN gateway configs of the on-prem sites declared in its config (deployments are sites/<site>)
+type StaticPluginServer struct {
+	paragliderpb.UnimplementedCloudPluginServer
+	orchestratorServerAddr string
+	config                 *Config
+	mu                     sync.Mutex            // Guards the states of the sites and the files rendered from them
+	states                 map[string]*siteState // States of the sites keyed by name
+}
+
+// Create a static plugin which restores the states of the sites from the output directory and renders their configs
+func NewStaticPluginServer(orchestratorServerAddr string, cfg *Config) (*StaticPluginServer, error) {
+	s := &StaticPluginServer{
+		orchestratorServerAddr: orchestratorServerAddr,
+		config:                 cfg,
+		states:                 make(map[string]*siteState),
+	}
+	for i := range cfg.Sites {
+		site := &cfg.Sites[i]
+		state, err := readSiteState(cfg.OutputDir, site.Name)
+		if err != nil {
+			return nil, err
+		}
+		s.states[site.Name] = state
+		if err := s.render(site, state); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func getDeploymentId(site string) string {
+	return "sites/" + site
+}
+
+func getHostUri(site string, host string) string {
+	return fmt.Sprintf("sites/%s/hosts/%s", site, host)
+}
+
+// Parse the site out of a deployment ID of the form sites/<site>
+func parseDeploymentId(id string) (string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 2 || parts[0] != "sites" || parts[1] == "" {
+		return "", status.Errorf(codes.InvalidArgument, "invalid deployment %s: expected sites/<site>", id)
+	}
+	return parts[1], nil
+}
+
+// Parse the site and host out of a URI of the form sites/<site>/hosts/<host>
+func parseHostUri(uri string) (string, string, error) {
+	parts := strings.Split(uri, "/")
+	if len(parts) != 4 || parts[0] != "sites" || parts[1] == "" || parts[2] != "hosts" || parts[3] == "" {
+		return "", "", status.Errorf(codes.InvalidArgument, "invalid uri %s: expected sites/<site>/hosts/<host>", uri)
+	}
+	return parts[1], parts[3], nil
+}
+
+// Gets a site and checks that it is in the namespace
+func (s *StaticPluginServer) getSite(name string, namespace string) (*Site, error) {
+	for i := range s.config.Sites {
+		site := &s.config.Sites[i]
+		if site.Name != name {
+			continue
+		}
+		if site.Namespace != namespace {
+			return nil, status.Errorf(codes.InvalidArgument, "site %s is not in namespace %s", name, namespace)
+		}
+		return site, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "site %s is not declared", name)
+}
+
+func (s *StaticPluginServer) getDeploymentSite(deployment *paragliderpb.ParagliderDeployment) (*Site, error) {
+	name, err := parseDeploymentId(deployment.Id)
+	if err != nil {
+		return nil, err
+	}
+	return s.getSite(name, deployment.Namespace)
+}
+
+// Gets a host and its site, checking that the site is in the namespace
+func (s *StaticPluginServer) getHost(namespace string, uri string) (*Site, *Host, error) {
+	siteName, hostName, err := parseHostUri(uri)
+	if err != nil {
+		return nil, nil, err
+	}
+	site, err := s.getSite(siteName, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	host := site.getHost(hostName)
+	if host == nil {
+		return nil, nil, status.Errorf(codes.NotFound, "host %s is not declared in site %s", hostName, siteName)
+	}
+	return site, host, nil
+}
+
+func getResource(site *Site, host *Host, ruleCount int) *paragliderpb.Resource {
+	return &paragliderpb.Resource{
+		Name:      host.Name,
+		Uri:       getHostUri(site.Name, host.Name),
+		Ip:        host.Ip,
+		Network:   site.Name,
+		State:     hostState,
+		RuleCount: int32(ruleCount),
+	}
+}
+
+// Gets the ASN of the gateway of a site (0 if it has not been allocated yet)
+func getAsn(site *Site, state *siteState) uint32 {
+	if site.Gateway.Asn != 0 {
+		return site.Gateway.Asn
+	}
+	return state.Asn
+}
+
+// Render the host firewalls and the VPN configs of a site
+func (s *StaticPluginServer) render(site *Site, state *siteState) error {
+	for i := range site.Hosts {
+		host := &site.Hosts[i]
+		hostPath := filepath.Join(getSiteDir(s.config.OutputDir, site.Name), "hosts", getHostFirewallFileName(host.Name, s.config.Firewall))
+		if err := writeFile(hostPath, renderFirewall(s.config.Firewall, site, host, state.PermitLists[host.Name]), 0644); err != nil {
+			return err
+		}
+	}
+	return writeVpnConfigs(s.config.OutputDir, site, getAsn(site, state), state.Connections)
+}
+
+// Persist the state of a site and render its configs (must hold s.mu)
+func (s *StaticPluginServer) commit(site *Site, state *siteState) error {
+	if err := writeSiteState(s.config.OutputDir, site.Name, state); err != nil {
+		return err
+	}
+	return s.render(site, state)
+}
+
+func (s *StaticPluginServer) GetPermitList(ctx context.Context, req *paragliderpb.GetPermitListRequest) (*paragliderpb.GetPermitListResponse, error) {
+	site, host, err := s.getHost(req.Namespace, req.Resource)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	permitListRules := []*paragliderpb.PermitListRule{}
+	for _, rule := range s.states[site.Name].PermitLists[host.Name] {
+		permitListRules = append(permitListRules, rule.toProto())
+	}
+	return &paragliderpb.GetPermitListResponse{Rules: permitListRules}, nil
+}
+
+// GetResourceInfo returns the declared IP of the host
+func (s *StaticPluginServer) GetResourceInfo(ctx context.Context, req *paragliderpb.GetResourceInfoRequest) (*paragliderpb.GetResourceInfoResponse, error) {
+	_, host, err := s.getHost(req.Namespace, req.Uri)
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.GetResourceInfoResponse{Uri: req.Uri, Ip: host.Ip, Ips: []string{host.Ip}, State: hostState}, nil
+}
+
+// GetResource returns the inventory entry of a host
+func (s *StaticPluginServer) GetResource(ctx context.Context, req *paragliderpb.GetResourceRequest) (*paragliderpb.GetResourceResponse, error) {
+	site, host, err := s.getHost(req.Namespace, req.Uri)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &paragliderpb.GetResourceResponse{Resource: getResource(site, host, len(s.states[site.Name].PermitLists[host.Name]))}, nil
+}
+
+// ListResources returns all hosts declared in the site
+func (s *StaticPluginServer) ListResources(ctx context.Context, req *paragliderpb.ListResourcesRequest) (*paragliderpb.ListResourcesResponse, error) {
+	site, err := s.getDeploymentSite(req.Deployment)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resources := []*paragliderpb.Resource{}
+	for i := range site.Hosts {
+		host := &site.Hosts[i]
+		resources = append(resources, getResource(site, host, len(s.states[site.Name].PermitLists[host.Name])))
+	}
+	return &paragliderpb.ListResourcesResponse{Resources: resources}, nil
+}
+
+// Connect the site to the clouds which the private targets of the rules are in
+// This must not hold s.mu since the orchestrator calls back into the plugin to create the VPN gateway
+func (s *StaticPluginServer) connectClouds(ctx context.Context, site *Site, rules []*paragliderpb.PermitListRule) error {
+	orchestratorConn, err := grpc.NewClient(s.orchestratorServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("unable to establish connection with orchestrator: %w", err)
+	}
+	defer orchestratorConn.Close()
+	orchestratorClient := paragliderpb.NewControllerClient(orchestratorConn)
+	getUsedAddressSpacesResp, err := orchestratorClient.GetUsedAddressSpaces(ctx, &emptypb.Empty{})
+	if err != nil {
+		return fmt.Errorf("unable to get used address spaces: %w", err)
+	}
+
+	// Clouds already connected to the site
+	s.mu.Lock()
+	connected := map[string]bool{}
+	for _, connection := range s.states[site.Name].Connections {
+		connected[connection.Cloud] = true
+	}
+	s.mu.Unlock()
+
+	for _, rule := range rules {
+		peeringCloudInfos, err := utils.GetPermitListRulePeeringCloudInfo(rule, getUsedAddressSpacesResp.AddressSpaceMappings)
+		if err != nil {
+			return fmt.Errorf("unable to get peering cloud infos: %w", err)
+		}
+		for i, peeringCloudInfo := range peeringCloudInfos {
+			if peeringCloudInfo == nil {
+				continue
+			}
+			// Targets within the site are reached without a VPN
+			inSite, err := utils.IsPermitListRuleTagInAddressSpace(rule.Targets[i], site.AddressSpaces)
+			if err != nil {
+				return fmt.Errorf("unable to determine if target is in site: %w", err)
+			}
+			if inSite {
+				continue
+			}
+			if peeringCloudInfo.Cloud == utils.STATIC {
+				return status.Errorf(codes.Unimplemented, "target %s is in another static site, which sites can't be connected to", rule.Targets[i])
+			}
+			if connected[peeringCloudInfo.Cloud] {
+				continue
+			}
+			_, err = orchestratorClient.ConnectClouds(ctx, &paragliderpb.ConnectCloudsRequest{
+				CloudA:              utils.STATIC,
+				CloudANamespace:     site.Namespace,
+				CloudB:              peeringCloudInfo.Cloud,
+				CloudBNamespace:     peeringCloudInfo.Namespace,
+				AddressSpacesCloudA: site.AddressSpaces,
+			})
+			if err != nil {
+				return fmt.Errorf("unable to connect clouds : %w", err)
+			}
+			connected[peeringCloudInfo.Cloud] = true
+		}
+	}
+	return nil
+}
+
+func (s *StaticPluginServer) AddPermitListRules(ctx context.Context, req *paragliderpb.AddPermitListRulesRequest) (*paragliderpb.AddPermitListRulesResponse, error) {
+	site, host, err := s.getHost(req.Namespace, req.Resource)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range req.Rules {
+		if err := validatePermitListRule(rule, s.config.Firewall); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.connectClouds(ctx, site, req.Rules); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.states[site.Name]
+	if state.PermitLists == nil {
+		state.PermitLists = make(map[string][]*permitListRule)
+	}
+	rules := state.PermitLists[host.Name]
+	for _, rule := range req.Rules {
+		replaced := false
+		for i, existing := range rules {
+			if existing.Name == rule.Name {
+				rules[i] = newPermitListRule(rule)
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			rules = append(rules, newPermitListRule(rule))
+		}
+	}
+	state.PermitLists[host.Name] = rules
+	if err := s.commit(site, state); err != nil {
+		return nil, err
+	}
+	return &paragliderpb.AddPermitListRulesResponse{}, nil
+}
+
+func (s *StaticPluginServer) DeletePermitListRules(ctx context.Context, req *paragliderpb.DeletePermitListRulesRequest) (*paragliderpb.DeletePermitListRulesResponse, error) {
+	site, host, err := s.getHost(req.Namespace, req.Resource)
+	if err != nil {
+		return nil, err
+	}
+	deleted := make(map[string]bool)
+	for _, name := range req.RuleNames {
+		deleted[name] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.states[site.Name]
+	rules := []*permitListRule{}
+	for _, rule := range state.PermitLists[host.Name] {
+		if !deleted[rule.Name] {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		delete(state.PermitLists, host.Name)
+	} else {
+		state.PermitLists[host.Name] = rules
+	}
+	if err := s.commit(site, state); err != nil {
+		return nil, err
+	}
+	return &paragliderpb.DeletePermitListRulesResponse{}, nil
+}
+
+// CreateResource registers a host declared in the site config since hosts can't be created by the plugin
+func (s *StaticPluginServer) CreateResource(ctx context.Context, req *paragliderpb.CreateResourceRequest) (*paragliderpb.CreateResourceResponse, error) {
+	site, err := s.getDeploymentSite(req.Deployment)
+	if err != nil {
+		return nil, err
+	}
+	host := site.getHost(req.Name)
+	if host == nil {
+		return nil, status.Errorf(codes.NotFound, "host %s is not declared in site %s (hosts of static sites are added to the plugin config)", req.Name, site.Name)
+	}
+	return &paragliderpb.CreateResourceResponse{Name: host.Name, Uri: getHostUri(site.Name, host.Name), Ip: host.Ip}, nil
+}
+
+func (s *StaticPluginServer) GetUsedAddressSpaces(ctx context.Context, req *paragliderpb.GetUsedAddressSpacesRequest) (*paragliderpb.GetUsedAddressSpacesResponse, error) {
+	resp := &paragliderpb.GetUsedAddressSpacesResponse{}
+	resp.AddressSpaceMappings = make([]*paragliderpb.AddressSpaceMapping, len(req.Deployments))
+	for i, deployment := range req.Deployments {
+		site, err := s.getDeploymentSite(deployment)
+		if err != nil {
+			return nil, err
+		}
+		resp.AddressSpaceMappings[i] = &paragliderpb.AddressSpaceMapping{
+			AddressSpaces: site.AddressSpaces,
+			Cloud:         utils.STATIC,
+			Namespace:     deployment.Namespace,
+		}
+	}
+	return resp, nil
+}
+
+func (s *StaticPluginServer) GetUsedAsns(ctx context.Context, req *paragliderpb.GetUsedAsnsRequest) (*paragliderpb.GetUsedAsnsResponse, error) {
+	resp := &paragliderpb.GetUsedAsnsResponse{}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, deployment := range req.Deployments {
+		site, err := s.getDeploymentSite(deployment)
+		if err != nil {
+			return nil, err
+		}
+		if asn := getAsn(site, s.states[site.Name]); asn != 0 {
+			resp.Asns = append(resp.Asns, asn)
+		}
+	}
+	return resp, nil
+}
+
+func (s *StaticPluginServer) GetUsedBgpPeeringIpAddresses(ctx context.Context, req *paragliderpb.GetUsedBgpPeeringIpAddressesRequest) (*paragliderpb.GetUsedBgpPeeringIpAddressesResponse, error) {
+	resp := &paragliderpb.GetUsedBgpPeeringIpAddressesResponse{}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, deployment := range req.Deployments {
+		site, err := s.getDeploymentSite(deployment)
+		if err != nil {
+			return nil, err
+		}
+		bgpPeeringIpAddresses := s.states[site.Name].BgpPeeringIpAddresses
+		clouds := make([]string, 0, len(bgpPeeringIpAddresses))
+		for cloud := range bgpPeeringIpAddresses {
+			clouds = append(clouds, cloud)
+		}
+		sort.Strings(clouds)
+		for _, cloud := range clouds {
+			resp.IpAddresses = append(resp.IpAddresses, bgpPeeringIpAddresses[cloud]...)
+		}
+	}
+	return resp, nil
+}
+
+// Gets the ASN of the gateway of a site, allocating one with the orchestrator if the config does not set it
+// This must not hold s.mu since the orchestrator gets the used ASNs from the plugin
+func (s *StaticPluginServer) getOrAllocateAsn(ctx context.Context, site *Site) (uint32, error) {
+	s.mu.Lock()
+	asn := getAsn(site, s.states[site.Name])
+	s.mu.Unlock()
+	if asn != 0 {
+		return asn, nil
+	}
+
+	conn, err := grpc.NewClient(s.orchestratorServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return 0, fmt.Errorf("unable to establish connection with orchestrator: %w", err)
+	}
+	defer conn.Close()
+	orchestratorClient := paragliderpb.NewControllerClient(conn)
+	findUnusedAsnResp, err := orchestratorClient.FindUnusedAsn(ctx, &paragliderpb.FindUnusedAsnRequest{})
+	if err != nil {
+		return 0, fmt.Errorf("unable to find unused asn: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.states[site.Name]
+	if state.Asn == 0 {
+		state.Asn = findUnusedAsnResp.Asn
+		if err := s.commit(site, state); err != nil {
+			return 0, err
+		}
+	}
+	return state.Asn, nil
+}
+
+// CreateVpnGateway records the BGP peering addresses of the site's gateway for the cloud and returns its public address
+func (s *StaticPluginServer) CreateVpnGateway(ctx context.Context, req *paragliderpb.CreateVpnGatewayRequest) (*paragliderpb.CreateVpnGatewayResponse, error) {
+	site, err := s.getDeploymentSite(req.Deployment)
+	if err != nil {
+		return nil, err
+	}
+	if site.Gateway.PublicIp == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "site %s has no gateway", site.Name)
+	}
+	asn, err := s.getOrAllocateAsn(ctx, site)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.states[site.Name]
+	if state.BgpPeeringIpAddresses == nil {
+		state.BgpPeeringIpAddresses = make(map[string][]string)
+	}
+	state.BgpPeeringIpAddresses[req.Cloud] = req.BgpPeeringIpAddresses
+	if err := s.commit(site, state); err != nil {
+		return nil, err
+	}
+	return &paragliderpb.CreateVpnGatewayResponse{Asn: asn, GatewayIpAddresses: []string{site.Gateway.PublicIp}}, nil
+}
+
+// CreateVpnConnections renders a strongSwan connection to each VPN gateway interface of the other cloud
+func (s *StaticPluginServer) CreateVpnConnections(ctx context.Context, req *paragliderpb.CreateVpnConnectionsRequest) (*paragliderpb.CreateVpnConnectionsResponse, error) {
+	site, err := s.getDeploymentSite(req.Deployment)
+	if err != nil {
+		return nil, err
+	}
+	vpnNumConnections := utils.GetNumVpnConnections(req.Cloud, utils.STATIC)
+	if len(req.GatewayIpAddresses) < vpnNumConnections {
+		return nil, status.Errorf(codes.InvalidArgument, "%d gateway ip addresses are required for connections to %s", vpnNumConnections, req.Cloud)
+	}
+	if req.IsBgpDisabled && len(req.RemoteAddresses) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "remote addresses are required for connections without bgp")
+	}
+	if !req.IsBgpDisabled && len(req.BgpIpAddresses) < vpnNumConnections {
+		return nil, status.Errorf(codes.InvalidArgument, "%d bgp ip addresses are required for connections to %s", vpnNumConnections, req.Cloud)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.states[site.Name]
+	localBgpIpAddresses := state.BgpPeeringIpAddresses[req.Cloud]
+	if !req.IsBgpDisabled && len(localBgpIpAddresses) < vpnNumConnections {
+		return nil, status.Errorf(codes.FailedPrecondition, "vpn gateway of site %s has no bgp peering ip addresses for %s", site.Name, req.Cloud)
+	}
+	for i := 0; i < vpnNumConnections; i++ {
+		name := getVpnConnectionName(req.Cloud, i)
+		var connection *vpnConnection
+		nextInterfaceId := uint32(1)
+		for _, existing := range state.Connections {
+			if existing.Name == name {
+				connection = existing
+			}
+			if existing.InterfaceId >= nextInterfaceId {
+				nextInterfaceId = existing.InterfaceId + 1
+			}
+		}
+		if connection == nil {
+			connection = &vpnConnection{Name: name, InterfaceId: nextInterfaceId}
+			state.Connections = append(state.Connections, connection)
+		}
+		connection.Cloud = req.Cloud
+		connection.RemoteGatewayIp = req.GatewayIpAddresses[i]
+		connection.RemoteAsn = req.Asn
+		connection.SharedKey = req.SharedKey
+		connection.BgpDisabled = req.IsBgpDisabled
+		connection.RemoteAddresses = nil
+		connection.LocalBgpIpAddress = ""
+		connection.RemoteBgpIpAddress = ""
+		if req.IsBgpDisabled {
+			connection.RemoteAddresses = req.RemoteAddresses
+		} else {
+			connection.LocalBgpIpAddress = localBgpIpAddresses[i]
+			connection.RemoteBgpIpAddress = req.BgpIpAddresses[i]
+		}
+	}
+	if err := s.commit(site, state); err != nil {
+		return nil, err
+	}
+	return &paragliderpb.CreateVpnConnectionsResponse{}, nil
+}
+
+// GetNetworkAddressSpaces returns the address spaces of the site
+func (s *StaticPluginServer) GetNetworkAddressSpaces(ctx context.Context, req *paragliderpb.GetNetworkAddressSpacesRequest) (*paragliderpb.GetNetworkAddressSpacesResponse, error) {
+	site, err := s.getDeploymentSite(req.Deployment)
+	if err != nil {
+		return nil, err
+	}
+	return &paragliderpb.GetNetworkAddressSpacesResponse{AddressSpaces: site.AddressSpaces}, nil
+}
+
+// Errors of the plugin never come from a cloud API
+func getErrorStatusCode(err error) (int, bool) {
+	return 0, false
+}
+
+// Serve the rendered configs of the sites without their state files
+func newOutputHandler(outputDir string) http.Handler {
+	fileServer := http.FileServer(http.Dir(outputDir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Base(r.URL.Path)
+		if name == stateFileName || strings.HasPrefix(name, ".") {
+			http.NotFound(w, r)
+			return
+		}
+		fileServer.ServeHTTP(w, r)
+	})
+}
+
+// Start the static plugin on the given port (0 picks a free port) and return its address
+// The rendered configs are also served over HTTP if the config sets an address for them
+func Setup(port int, server *StaticPluginServer) (string, error) {
+	lis, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		return "", fmt.Errorf("failed to listen: %w", err)
+	}
+	if server.config.HttpAddress != "" {
+		httpLis, err := net.Listen("tcp", server.config.HttpAddress)
+		if err != nil {
+			lis.Close()
+			return "", fmt.Errorf("failed to listen: %w", err)
+		}
+		fmt.Println("Serving rendered configs on", httpLis.Addr().String())
+		go func() {
+			if err := http.Serve(httpLis, newOutputHandler(server.config.OutputDir)); err != nil {
+				fmt.Println(err.Error())
+			}
+		}()
+	}
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(utils.StatusErrorInterceptor(utils.STATIC, getErrorStatusCode)))
+	paragliderpb.RegisterCloudPluginServer(grpcServer, server)
+	fmt.Println("Starting server on port :", port)
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			fmt.Println(err.Error())
+		}
+	}()
+	return lis.Addr().String(), nil
+}
@@ -0,0 +1,449 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package static
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/paraglider-project/paraglider/pkg/orchestrator"
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	fakeSite            = "lab"
+	fakeNamespace       = "default"
+	fakeHost            = "web-1"
+	fakeHostIp          = "10.250.0.5"
+	fakeOtherHost       = "db-1"
+	fakeAddressSpace    = "10.250.0.0/16"
+	fakeGatewayIp       = "203.0.113.10"
+	fakeGcpAddressSpace = "10.1.0.0/16"
+)
+
+var (
+	fakeDeployment = &paragliderpb.ParagliderDeployment{Id: getDeploymentId(fakeSite), Namespace: fakeNamespace}
+	fakeHostUri    = getHostUri(fakeSite, fakeHost)
+)
+
+// Gets the config of a site with two hosts whose configs are rendered to a temporary directory
+func getFakeConfig(t *testing.T, firewall string) *Config {
+	cfg, err := ParseConfig([]byte(fmt.Sprintf(`
+outputDir: %s
+firewall: %s
+sites:
+  - name: %s
+    addressSpaces: [%s]
+    gateway:
+      publicIp: %s
+    hosts:
+      - name: %s
+        ip: %s
+      - name: %s
+        ip: 10.250.1.5
+`, t.TempDir(), firewall, fakeSite, fakeAddressSpace, fakeGatewayIp, fakeHost, fakeHostIp, fakeOtherHost)))
+	require.NoError(t, err)
+	return cfg
+}
+
+// Fake orchestrator which knows about the site and a GCP network and records the clouds the plugin connects to
+type fakeOrchestratorServer struct {
+	paragliderpb.UnimplementedControllerServer
+	mu                    sync.Mutex
+	connectCloudsRequests []*paragliderpb.ConnectCloudsRequest
+}
+
+func (f *fakeOrchestratorServer) GetUsedAddressSpaces(ctx context.Context, _ *emptypb.Empty) (*paragliderpb.GetUsedAddressSpacesResponse, error) {
+	return &paragliderpb.GetUsedAddressSpacesResponse{
+		AddressSpaceMappings: []*paragliderpb.AddressSpaceMapping{
+			{AddressSpaces: []string{fakeAddressSpace}, Cloud: utils.STATIC, Namespace: fakeNamespace, Deployment: proto.String(getDeploymentId(fakeSite))},
+			{AddressSpaces: []string{fakeGcpAddressSpace}, Cloud: utils.GCP, Namespace: fakeNamespace, Deployment: proto.String("projects/fake")},
+			{AddressSpaces: []string{"10.2.0.0/16"}, Cloud: utils.STATIC, Namespace: fakeNamespace, Deployment: proto.String(getDeploymentId("other"))},
+		},
+	}, nil
+}
+
+func (f *fakeOrchestratorServer) FindUnusedAsn(ctx context.Context, _ *paragliderpb.FindUnusedAsnRequest) (*paragliderpb.FindUnusedAsnResponse, error) {
+	return &paragliderpb.FindUnusedAsnResponse{Asn: orchestrator.MIN_PRIVATE_ASN_2BYTE}, nil
+}
+
+func (f *fakeOrchestratorServer) ConnectClouds(ctx context.Context, req *paragliderpb.ConnectCloudsRequest) (*paragliderpb.ConnectCloudsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.connectCloudsRequests = append(f.connectCloudsRequests, req)
+	return &paragliderpb.ConnectCloudsResponse{}, nil
+}
+
+// Sets up a fake orchestrator and returns a plugin server using it
+func setupPluginServer(t *testing.T, cfg *Config) (*fakeOrchestratorServer, *StaticPluginServer) {
+	fakeOrchestrator := &fakeOrchestratorServer{}
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	paragliderpb.RegisterControllerServer(grpcServer, fakeOrchestrator)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	s, err := NewStaticPluginServer(lis.Addr().String(), cfg)
+	require.NoError(t, err)
+	return fakeOrchestrator, s
+}
+
+func readOutputFile(t *testing.T, cfg *Config, name ...string) string {
+	data, err := os.ReadFile(filepath.Join(append([]string{cfg.OutputDir, fakeSite}, name...)...))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+outputDir: /tmp/paraglider
+sites:
+  - name: lab
+    addressSpaces: [10.250.0.0/16]
+    hosts:
+      - name: web-1
+        ip: 10.250.0.5
+`))
+	require.NoError(t, err)
+	assert.Equal(t, nftablesFirewall, cfg.Firewall)
+	require.Len(t, cfg.Sites, 1)
+	assert.Equal(t, defaultNamespace, cfg.Sites[0].Namespace)
+	assert.Equal(t, defaultInterface, cfg.Sites[0].Gateway.Interface)
+
+	invalid := map[string]string{
+		"missing output directory": "sites: []",
+		"unknown firewall":         "outputDir: /tmp\nfirewall: pf",
+		"unknown field":            "outputDir: /tmp\nsite: []",
+		"invalid site name":        "outputDir: /tmp\nsites: [{name: a/b, addressSpaces: [10.0.0.0/16]}]",
+		"duplicate site":           "outputDir: /tmp\nsites: [{name: a, addressSpaces: [10.0.0.0/16]}, {name: a, addressSpaces: [10.1.0.0/16]}]",
+		"no address spaces":        "outputDir: /tmp\nsites: [{name: a}]",
+		"ipv6 gateway":             "outputDir: /tmp\nsites: [{name: a, addressSpaces: [10.0.0.0/16], gateway: {publicIp: '2001:db8::1'}}]",
+		"host outside site":        "outputDir: /tmp\nsites: [{name: a, addressSpaces: [10.0.0.0/16], hosts: [{name: h, ip: 10.1.0.1}]}]",
+		"duplicate host":           "outputDir: /tmp\nsites: [{name: a, addressSpaces: [10.0.0.0/16], hosts: [{name: h, ip: 10.0.0.1}, {name: h, ip: 10.0.0.2}]}]",
+	}
+	for name, config := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseConfig([]byte(config))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGetResources(t *testing.T) {
+	_, s := setupPluginServer(t, getFakeConfig(t, nftablesFirewall))
+
+	info, err := s.GetResourceInfo(context.Background(), &paragliderpb.GetResourceInfoRequest{Namespace: fakeNamespace, Uri: fakeHostUri})
+	require.NoError(t, err)
+	assert.Equal(t, fakeHostIp, info.Ip)
+	assert.Equal(t, []string{fakeHostIp}, info.Ips)
+	assert.Equal(t, hostState, info.State)
+
+	listResp, err := s.ListResources(context.Background(), &paragliderpb.ListResourcesRequest{Deployment: fakeDeployment})
+	require.NoError(t, err)
+	require.Len(t, listResp.Resources, 2)
+	assert.Equal(t, fakeHostUri, listResp.Resources[0].Uri)
+	assert.Equal(t, fakeSite, listResp.Resources[0].Network)
+	assert.Equal(t, getHostUri(fakeSite, fakeOtherHost), listResp.Resources[1].Uri)
+
+	// Hosts are registered with the orchestrator by creating them
+	created, err := s.CreateResource(context.Background(), &paragliderpb.CreateResourceRequest{Deployment: fakeDeployment, Name: fakeHost})
+	require.NoError(t, err)
+	assert.Equal(t, fakeHostUri, created.Uri)
+	assert.Equal(t, fakeHostIp, created.Ip)
+
+	_, err = s.CreateResource(context.Background(), &paragliderpb.CreateResourceRequest{Deployment: fakeDeployment, Name: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = s.GetResourceInfo(context.Background(), &paragliderpb.GetResourceInfoRequest{Namespace: fakeNamespace, Uri: getHostUri(fakeSite, "missing")})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = s.GetResourceInfo(context.Background(), &paragliderpb.GetResourceInfoRequest{Namespace: "other", Uri: fakeHostUri})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = s.GetResourceInfo(context.Background(), &paragliderpb.GetResourceInfoRequest{Namespace: fakeNamespace, Uri: "hosts/" + fakeHost})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestPermitListRules(t *testing.T) {
+	cfg := getFakeConfig(t, nftablesFirewall)
+	_, s := setupPluginServer(t, cfg)
+
+	// Hosts deny all traffic until rules are added
+	assert.NotContains(t, readOutputFile(t, cfg, "hosts", "web-1.nft"), "comment")
+
+	rules := []*paragliderpb.PermitListRule{
+		{Name: "https", Direction: paragliderpb.Direction_INBOUND, SrcPort: -1, DstPort: 443, Protocol: 6, Targets: []string{"192.0.2.0/24"}, Tags: []string{"web"}},
+		{Name: "dns", Direction: paragliderpb.Direction_OUTBOUND, SrcPort: -1, DstPort: 53, Protocol: 17, Targets: []string{"198.51.100.53"}},
+	}
+	_, err := s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{Namespace: fakeNamespace, Resource: fakeHostUri, Rules: rules})
+	require.NoError(t, err)
+
+	getResp, err := s.GetPermitList(context.Background(), &paragliderpb.GetPermitListRequest{Namespace: fakeNamespace, Resource: fakeHostUri})
+	require.NoError(t, err)
+	require.Len(t, getResp.Rules, 2)
+	for i, rule := range rules {
+		assert.True(t, proto.Equal(rule, getResp.Rules[i]), "rule %s", rule.Name)
+	}
+	rendered := readOutputFile(t, cfg, "hosts", "web-1.nft")
+	assert.Contains(t, rendered, "ip saddr 192.0.2.0/24 meta l4proto tcp th dport 443 accept comment \"https\"\n")
+	assert.Contains(t, rendered, "ip daddr 198.51.100.53/32 meta l4proto udp th dport 53 accept comment \"dns\"\n")
+	assert.NotContains(t, readOutputFile(t, cfg, "hosts", "db-1.nft"), "comment")
+
+	// Rules are replaced by name
+	rules[0].DstPort = 8443
+	_, err = s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{Namespace: fakeNamespace, Resource: fakeHostUri, Rules: rules[:1]})
+	require.NoError(t, err)
+	rendered = readOutputFile(t, cfg, "hosts", "web-1.nft")
+	assert.Contains(t, rendered, "th dport 8443")
+	assert.NotContains(t, rendered, "th dport 443 ")
+
+	_, err = s.DeletePermitListRules(context.Background(), &paragliderpb.DeletePermitListRulesRequest{Namespace: fakeNamespace, Resource: fakeHostUri, RuleNames: []string{"dns", "missing"}})
+	require.NoError(t, err)
+	assert.NotContains(t, readOutputFile(t, cfg, "hosts", "web-1.nft"), "\"dns\"")
+
+	// The rules are restored from the output directory
+	_, restarted := setupPluginServer(t, cfg)
+	getResp, err = restarted.GetPermitList(context.Background(), &paragliderpb.GetPermitListRequest{Namespace: fakeNamespace, Resource: fakeHostUri})
+	require.NoError(t, err)
+	require.Len(t, getResp.Rules, 1)
+	assert.True(t, proto.Equal(rules[0], getResp.Rules[0]))
+	resource, err := restarted.GetResource(context.Background(), &paragliderpb.GetResourceRequest{Namespace: fakeNamespace, Uri: fakeHostUri})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), resource.Resource.RuleCount)
+}
+
+func TestPermitListRulesIptables(t *testing.T) {
+	cfg := getFakeConfig(t, iptablesFirewall)
+	_, s := setupPluginServer(t, cfg)
+
+	rules := []*paragliderpb.PermitListRule{
+		{Name: "ssh", Direction: paragliderpb.Direction_INBOUND, SrcPort: -1, DstPort: 22, Protocol: 6, Targets: []string{"192.0.2.0/24"}},
+	}
+	_, err := s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{Namespace: fakeNamespace, Resource: fakeHostUri, Rules: rules})
+	require.NoError(t, err)
+	assert.Contains(t, readOutputFile(t, cfg, "hosts", "web-1.rules"), "-A INPUT -s 192.0.2.0/24 -p tcp --dport 22 -m comment --comment \"ssh\" -j ACCEPT\n")
+
+	// Rules which can't be rendered are rejected without changing the permit list
+	rules[0].Targets = []string{"2001:db8::1"}
+	_, err = s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{Namespace: fakeNamespace, Resource: fakeHostUri, Rules: rules})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	getResp, err := s.GetPermitList(context.Background(), &paragliderpb.GetPermitListRequest{Namespace: fakeNamespace, Resource: fakeHostUri})
+	require.NoError(t, err)
+	require.Len(t, getResp.Rules, 1)
+	assert.Equal(t, []string{"192.0.2.0/24"}, getResp.Rules[0].Targets)
+}
+
+func TestAddPermitListRulesConnectClouds(t *testing.T) {
+	fakeOrchestrator, s := setupPluginServer(t, getFakeConfig(t, nftablesFirewall))
+
+	// Only the target in GCP requires a VPN connection
+	rules := []*paragliderpb.PermitListRule{
+		{Name: "ssh", Direction: paragliderpb.Direction_INBOUND, SrcPort: -1, DstPort: 22, Protocol: 6, Targets: []string{"10.1.0.4", "10.250.1.5"}},
+		{Name: "http", Direction: paragliderpb.Direction_OUTBOUND, SrcPort: -1, DstPort: 80, Protocol: 6, Targets: []string{"10.1.0.5"}},
+	}
+	_, err := s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{Namespace: fakeNamespace, Resource: fakeHostUri, Rules: rules})
+	require.NoError(t, err)
+	require.Len(t, fakeOrchestrator.connectCloudsRequests, 1)
+	connectCloudsReq := fakeOrchestrator.connectCloudsRequests[0]
+	assert.Equal(t, utils.STATIC, connectCloudsReq.CloudA)
+	assert.Equal(t, fakeNamespace, connectCloudsReq.CloudANamespace)
+	assert.Equal(t, utils.GCP, connectCloudsReq.CloudB)
+	assert.Equal(t, []string{fakeAddressSpace}, connectCloudsReq.AddressSpacesCloudA)
+
+	// Sites already connected to the cloud are not connected again
+	_, err = s.CreateVpnGateway(context.Background(), &paragliderpb.CreateVpnGatewayRequest{Deployment: fakeDeployment, Cloud: utils.GCP, BgpPeeringIpAddresses: []string{"169.254.0.1"}})
+	require.NoError(t, err)
+	_, err = s.CreateVpnConnections(context.Background(), &paragliderpb.CreateVpnConnectionsRequest{Deployment: fakeDeployment, Cloud: utils.GCP, Asn: 64513, GatewayIpAddresses: []string{"198.51.100.1"}, BgpIpAddresses: []string{"169.254.0.2"}, SharedKey: "abcd"})
+	require.NoError(t, err)
+	_, err = s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{Namespace: fakeNamespace, Resource: fakeHostUri, Rules: rules})
+	require.NoError(t, err)
+	assert.Len(t, fakeOrchestrator.connectCloudsRequests, 1)
+
+	// Sites can't be connected to each other
+	rules[0].Targets = []string{"10.2.0.4"}
+	_, err = s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{Namespace: fakeNamespace, Resource: fakeHostUri, Rules: rules})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestPermitListRulesWrongNamespace(t *testing.T) {
+	_, s := setupPluginServer(t, getFakeConfig(t, nftablesFirewall))
+
+	_, err := s.GetPermitList(context.Background(), &paragliderpb.GetPermitListRequest{Namespace: "other", Resource: fakeHostUri})
+	require.Error(t, err)
+	_, err = s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{Namespace: "other", Resource: fakeHostUri})
+	require.Error(t, err)
+	_, err = s.DeletePermitListRules(context.Background(), &paragliderpb.DeletePermitListRulesRequest{Namespace: "other", Resource: fakeHostUri})
+	require.Error(t, err)
+}
+
+func TestGetUsedAddressSpaces(t *testing.T) {
+	_, s := setupPluginServer(t, getFakeConfig(t, nftablesFirewall))
+
+	resp, err := s.GetUsedAddressSpaces(context.Background(), &paragliderpb.GetUsedAddressSpacesRequest{Deployments: []*paragliderpb.ParagliderDeployment{fakeDeployment}})
+	require.NoError(t, err)
+	require.Len(t, resp.AddressSpaceMappings, 1)
+	assert.Equal(t, utils.STATIC, resp.AddressSpaceMappings[0].Cloud)
+	assert.Equal(t, fakeNamespace, resp.AddressSpaceMappings[0].Namespace)
+	assert.Equal(t, []string{fakeAddressSpace}, resp.AddressSpaceMappings[0].AddressSpaces)
+
+	networkResp, err := s.GetNetworkAddressSpaces(context.Background(), &paragliderpb.GetNetworkAddressSpacesRequest{Deployment: fakeDeployment, AddressSpace: fakeAddressSpace})
+	require.NoError(t, err)
+	assert.Equal(t, []string{fakeAddressSpace}, networkResp.AddressSpaces)
+
+	_, err = s.GetUsedAddressSpaces(context.Background(), &paragliderpb.GetUsedAddressSpacesRequest{Deployments: []*paragliderpb.ParagliderDeployment{{Id: getDeploymentId("missing"), Namespace: fakeNamespace}}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestCreateVpnGateway(t *testing.T) {
+	cfg := getFakeConfig(t, nftablesFirewall)
+	_, s := setupPluginServer(t, cfg)
+
+	resp, err := s.CreateVpnGateway(context.Background(), &paragliderpb.CreateVpnGatewayRequest{Deployment: fakeDeployment, Cloud: utils.AZURE, BgpPeeringIpAddresses: []string{"169.254.21.2"}})
+	require.NoError(t, err)
+	assert.Equal(t, orchestrator.MIN_PRIVATE_ASN_2BYTE, resp.Asn)
+	assert.Equal(t, []string{fakeGatewayIp}, resp.GatewayIpAddresses)
+
+	asnResp, err := s.GetUsedAsns(context.Background(), &paragliderpb.GetUsedAsnsRequest{Deployments: []*paragliderpb.ParagliderDeployment{fakeDeployment}})
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{orchestrator.MIN_PRIVATE_ASN_2BYTE}, asnResp.Asns)
+	bgpResp, err := s.GetUsedBgpPeeringIpAddresses(context.Background(), &paragliderpb.GetUsedBgpPeeringIpAddressesRequest{Deployments: []*paragliderpb.ParagliderDeployment{fakeDeployment}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"169.254.21.2"}, bgpResp.IpAddresses)
+
+	// The ASN set in the config takes precedence
+	cfg.Sites[0].Gateway.Asn = 65010
+	resp, err = s.CreateVpnGateway(context.Background(), &paragliderpb.CreateVpnGatewayRequest{Deployment: fakeDeployment, Cloud: utils.AZURE})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(65010), resp.Asn)
+
+	// Sites without a gateway can't be connected
+	cfg.Sites[0].Gateway.PublicIp = ""
+	_, err = s.CreateVpnGateway(context.Background(), &paragliderpb.CreateVpnGatewayRequest{Deployment: fakeDeployment, Cloud: utils.AZURE})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestCreateVpnConnections(t *testing.T) {
+	cfg := getFakeConfig(t, nftablesFirewall)
+	_, s := setupPluginServer(t, cfg)
+
+	req := &paragliderpb.CreateVpnConnectionsRequest{
+		Deployment:         fakeDeployment,
+		Cloud:              utils.AWS,
+		Asn:                64513,
+		GatewayIpAddresses: []string{"198.51.100.1"},
+		BgpIpAddresses:     []string{"169.254.6.1"},
+		SharedKey:          "abcd",
+	}
+
+	// The VPN gateway must be created first
+	_, err := s.CreateVpnConnections(context.Background(), req)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = s.CreateVpnGateway(context.Background(), &paragliderpb.CreateVpnGatewayRequest{Deployment: fakeDeployment, Cloud: utils.AWS, BgpPeeringIpAddresses: []string{"169.254.6.2"}})
+	require.NoError(t, err)
+	_, err = s.CreateVpnConnections(context.Background(), req)
+	require.NoError(t, err)
+
+	swanctl := readOutputFile(t, cfg, swanctlFileName)
+	assert.Contains(t, swanctl, "remote_addrs = 198.51.100.1\n")
+	assert.Contains(t, swanctl, "if_id_in = 1\n")
+	assert.Contains(t, swanctl, "secret = \"abcd\"\n")
+	assert.Contains(t, readOutputFile(t, cfg, frrFileName), fmt.Sprintf("router bgp %d\n no bgp ebgp-requires-policy\n neighbor 169.254.6.1 remote-as 64513\n", orchestrator.MIN_PRIVATE_ASN_2BYTE))
+	assert.Contains(t, readOutputFile(t, cfg, interfacesFileName), "ip address replace 169.254.6.2/30 dev pgxfrm1\n")
+
+	// Shared keys are only readable by the plugin
+	info, err := os.Stat(filepath.Join(cfg.OutputDir, fakeSite, swanctlFileName))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Creating the VPN connections again updates them in place
+	req.SharedKey = "efgh"
+	_, err = s.CreateVpnConnections(context.Background(), req)
+	require.NoError(t, err)
+	swanctl = readOutputFile(t, cfg, swanctlFileName)
+	assert.Contains(t, swanctl, "secret = \"efgh\"\n")
+	assert.NotContains(t, swanctl, "abcd")
+	assert.NotContains(t, swanctl, "if_id_in = 2\n")
+
+	// Too few addresses
+	req.BgpIpAddresses = nil
+	_, err = s.CreateVpnConnections(context.Background(), req)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCreateVpnConnectionsBgpDisabled(t *testing.T) {
+	cfg := getFakeConfig(t, nftablesFirewall)
+	_, s := setupPluginServer(t, cfg)
+
+	_, err := s.CreateVpnGateway(context.Background(), &paragliderpb.CreateVpnGatewayRequest{Deployment: fakeDeployment, Cloud: utils.IBM})
+	require.NoError(t, err)
+	_, err = s.CreateVpnConnections(context.Background(), &paragliderpb.CreateVpnConnectionsRequest{
+		Deployment:         fakeDeployment,
+		Cloud:              utils.IBM,
+		GatewayIpAddresses: []string{"198.51.100.2"},
+		SharedKey:          "abcd",
+		RemoteAddresses:    []string{"10.3.0.0/16"},
+		IsBgpDisabled:      true,
+	})
+	require.NoError(t, err)
+
+	swanctl := readOutputFile(t, cfg, swanctlFileName)
+	assert.Contains(t, swanctl, "local_ts = 10.250.0.0/16\n")
+	assert.Contains(t, swanctl, "remote_ts = 10.3.0.0/16\n")
+	assert.NotContains(t, swanctl, "if_id_in")
+	assert.NotContains(t, readOutputFile(t, cfg, frrFileName), "router bgp")
+}
+
+func TestOutputHandler(t *testing.T) {
+	cfg := getFakeConfig(t, nftablesFirewall)
+	_, s := setupPluginServer(t, cfg)
+	_, err := s.AddPermitListRules(context.Background(), &paragliderpb.AddPermitListRulesRequest{
+		Namespace: fakeNamespace,
+		Resource:  fakeHostUri,
+		Rules:     []*paragliderpb.PermitListRule{{Name: "ssh", SrcPort: -1, DstPort: 22, Protocol: 6, Targets: []string{"192.0.2.0/24"}}},
+	})
+	require.NoError(t, err)
+
+	server := httptest.NewServer(newOutputHandler(cfg.OutputDir))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/lab/hosts/web-1.nft")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The state is not served
+	resp, err = http.Get(server.URL + "/lab/" + stateFileName)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package static
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	paragliderpb "github.com/paraglider-project/paraglider/pkg/paragliderpb"
+)
+
+const stateFileName = "state.json"
+
+// State of a site which is not declared in the config, persisted next to the site's rendered configs
+type siteState struct {
+	PermitLists           map[string][]*permitListRule `json:"permitLists,omitempty"`           // Permit list rules keyed by host
+	Asn                   uint32                       `json:"asn,omitempty"`                   // ASN allocated by the orchestrator if the config does not set one
+	BgpPeeringIpAddresses map[string][]string          `json:"bgpPeeringIpAddresses,omitempty"` // BGP addresses of the gateway keyed by peer cloud
+	Connections           []*vpnConnection             `json:"connections,omitempty"`
+}
+
+// Permit list rule as stored in the state
+type permitListRule struct {
+	Name      string   `json:"name"`
+	Direction string   `json:"direction"`
+	SrcPort   int32    `json:"srcPort"`
+	DstPort   int32    `json:"dstPort"`
+	Protocol  int32    `json:"protocol"`
+	Targets   []string `json:"targets"`
+	Tags      []string `json:"tags,omitempty"`
+}
+
+// VPN connection from the gateway of a site to a VPN gateway of another cloud
+type vpnConnection struct {
+	Name               string   `json:"name"`
+	Cloud              string   `json:"cloud"`
+	RemoteGatewayIp    string   `json:"remoteGatewayIp"`
+	RemoteAsn          uint32   `json:"remoteAsn,omitempty"`
+	LocalBgpIpAddress  string   `json:"localBgpIpAddress,omitempty"`
+	RemoteBgpIpAddress string   `json:"remoteBgpIpAddress,omitempty"`
+	SharedKey          string   `json:"sharedKey"`
+	BgpDisabled        bool     `json:"bgpDisabled,omitempty"`
+	RemoteAddresses    []string `json:"remoteAddresses,omitempty"` // Traffic selectors of connections without BGP
+	InterfaceId        uint32   `json:"interfaceId,omitempty"`     // XFRM interface ID of route-based connections
+}
+
+func newPermitListRule(rule *paragliderpb.PermitListRule) *permitListRule {
+	return &permitListRule{
+		Name:      rule.Name,
+		Direction: rule.Direction.String(),
+		SrcPort:   rule.SrcPort,
+		DstPort:   rule.DstPort,
+		Protocol:  rule.Protocol,
+		Targets:   rule.Targets,
+		Tags:      rule.Tags,
+	}
+}
+
+func (rule *permitListRule) toProto() *paragliderpb.PermitListRule {
+	return &paragliderpb.PermitListRule{
+		Name:      rule.Name,
+		Direction: paragliderpb.Direction(paragliderpb.Direction_value[rule.Direction]),
+		SrcPort:   rule.SrcPort,
+		DstPort:   rule.DstPort,
+		Protocol:  rule.Protocol,
+		Targets:   rule.Targets,
+		Tags:      rule.Tags,
+	}
+}
+
+func getSiteDir(outputDir string, site string) string {
+	return filepath.Join(outputDir, site)
+}
+
+// Read the state of a site (a site without a state file has an empty state)
+func readSiteState(outputDir string, site string) (*siteState, error) {
+	state := &siteState{}
+	data, err := os.ReadFile(filepath.Join(getSiteDir(outputDir, site), stateFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read state of site %s: %w", site, err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("unable to parse state of site %s: %w", site, err)
+	}
+	return state, nil
+}
+
+// Write the state of a site, which is only readable by the plugin since it contains the shared keys of the VPN connections
+func writeSiteState(outputDir string, site string, state *siteState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to serialize state of site %s: %w", site, err)
+	}
+	return writeFile(filepath.Join(getSiteDir(outputDir, site), stateFileName), data, 0600)
+}
+
+// Replace a file atomically so that hosts fetching it never see a partial file
+func writeFile(path string, data []byte, perm fs.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("unable to create directory %s: %w", dir, err)
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("unable to create %s: %w", path, err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package static
+
+import (
+	"fmt"
+	"net/netip"
+	"path/filepath"
+	"strings"
+)
+
+// Files the VPN configuration of the gateway of a site is rendered to
+const (
+	swanctlFileName    = "swanctl.conf"  // strongSwan connections and shared keys
+	frrFileName        = "frr.conf"      // FRR BGP sessions with the other clouds
+	interfacesFileName = "interfaces.sh" // Script creating the XFRM interfaces of route-based connections
+)
+
+// Number of addresses in a BGP peering subnet as allocated by the orchestrator
+const bgpPeeringPrefixLength = 30
+
+func getVpnConnectionName(cloud string, i int) string {
+	return fmt.Sprintf("paraglider-%s-%d", cloud, i)
+}
+
+// Interface names are limited to 15 characters
+func getInterfaceName(id uint32) string {
+	return fmt.Sprintf("pgxfrm%d", id)
+}
+
+// Render the strongSwan connections of a site for swanctl --load-all
+// Connections with BGP are route-based (i.e., bound to an XFRM interface) while connections without are policy-based
+func renderSwanctl(site *Site, connections []*vpnConnection) []byte {
+	localAddrs := site.Gateway.LocalIp
+	if localAddrs == "" {
+		localAddrs = "%any"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# VPN connections of site %s, rendered by Paraglider\n", site.Name)
+	fmt.Fprintf(&b, "# Load with: swanctl --load-all --file %s\n", swanctlFileName)
+	b.WriteString("connections {\n")
+	for _, connection := range connections {
+		fmt.Fprintf(&b, "\t%s {\n", connection.Name)
+		b.WriteString("\t\tversion = 2\n")
+		fmt.Fprintf(&b, "\t\tlocal_addrs = %s\n", localAddrs)
+		fmt.Fprintf(&b, "\t\tremote_addrs = %s\n", connection.RemoteGatewayIp)
+		b.WriteString("\t\tdpd_delay = 30s\n")
+		fmt.Fprintf(&b, "\t\tlocal {\n\t\t\tauth = psk\n\t\t\tid = %s\n\t\t}\n", site.Gateway.PublicIp)
+		fmt.Fprintf(&b, "\t\tremote {\n\t\t\tauth = psk\n\t\t\tid = %s\n\t\t}\n", connection.RemoteGatewayIp)
+		b.WriteString("\t\tchildren {\n")
+		fmt.Fprintf(&b, "\t\t\t%s {\n", connection.Name)
+		if connection.BgpDisabled {
+			fmt.Fprintf(&b, "\t\t\t\tlocal_ts = %s\n", strings.Join(site.AddressSpaces, ","))
+			fmt.Fprintf(&b, "\t\t\t\tremote_ts = %s\n", strings.Join(connection.RemoteAddresses, ","))
+		} else {
+			b.WriteString("\t\t\t\tlocal_ts = 0.0.0.0/0\n")
+			b.WriteString("\t\t\t\tremote_ts = 0.0.0.0/0\n")
+			fmt.Fprintf(&b, "\t\t\t\tif_id_in = %d\n", connection.InterfaceId)
+			fmt.Fprintf(&b, "\t\t\t\tif_id_out = %d\n", connection.InterfaceId)
+		}
+		b.WriteString("\t\t\t\tstart_action = start\n")
+		b.WriteString("\t\t\t\tdpd_action = restart\n")
+		b.WriteString("\t\t\t}\n")
+		b.WriteString("\t\t}\n")
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("}\n")
+	b.WriteString("secrets {\n")
+	for _, connection := range connections {
+		fmt.Fprintf(&b, "\tike-%s {\n", connection.Name)
+		fmt.Fprintf(&b, "\t\tid = %s\n", connection.RemoteGatewayIp)
+		fmt.Fprintf(&b, "\t\tsecret = \"%s\"\n", connection.SharedKey)
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+// Render the BGP sessions of a site, which advertise its address spaces to the other clouds
+func renderFrr(site *Site, asn uint32, connections []*vpnConnection) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "! BGP sessions of site %s, rendered by Paraglider\n", site.Name)
+	fmt.Fprintf(&b, "! Include in the FRR configuration of the gateway\n")
+	var bgpConnections []*vpnConnection
+	for _, connection := range connections {
+		if !connection.BgpDisabled {
+			bgpConnections = append(bgpConnections, connection)
+		}
+	}
+	if len(bgpConnections) == 0 {
+		return []byte(b.String())
+	}
+	fmt.Fprintf(&b, "router bgp %d\n", asn)
+	b.WriteString(" no bgp ebgp-requires-policy\n")
+	for _, connection := range bgpConnections {
+		fmt.Fprintf(&b, " neighbor %s remote-as %d\n", connection.RemoteBgpIpAddress, connection.RemoteAsn)
+		fmt.Fprintf(&b, " neighbor %s description %s\n", connection.RemoteBgpIpAddress, connection.Name)
+	}
+	b.WriteString(" address-family ipv4 unicast\n")
+	for _, addressSpace := range site.AddressSpaces {
+		if prefix, err := netip.ParsePrefix(addressSpace); err == nil && prefix.Addr().Is4() {
+			fmt.Fprintf(&b, "  network %s\n", prefix.Masked())
+		}
+	}
+	b.WriteString(" exit-address-family\n")
+	b.WriteString("exit\n")
+	return []byte(b.String())
+}
+
+// Render a script creating the XFRM interface of each route-based connection with its BGP peering address
+func renderInterfaces(site *Site, connections []*vpnConnection) []byte {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# XFRM interfaces of the VPN connections of site %s, rendered by Paraglider\n", site.Name)
+	b.WriteString("set -e\n")
+	for _, connection := range connections {
+		if connection.BgpDisabled {
+			continue
+		}
+		name := getInterfaceName(connection.InterfaceId)
+		fmt.Fprintf(&b, "ip link add %s type xfrm dev %s if_id %d 2>/dev/null || true\n", name, site.Gateway.Interface, connection.InterfaceId)
+		fmt.Fprintf(&b, "ip address replace %s/%d dev %s\n", connection.LocalBgpIpAddress, bgpPeeringPrefixLength, name)
+		fmt.Fprintf(&b, "ip link set %s up\n", name)
+	}
+	return []byte(b.String())
+}
+
+// Render the VPN configuration of the gateway of a site into the site's directory
+func writeVpnConfigs(outputDir string, site *Site, asn uint32, connections []*vpnConnection) error {
+	siteDir := getSiteDir(outputDir, site.Name)
+	if err := writeFile(filepath.Join(siteDir, swanctlFileName), renderSwanctl(site, connections), 0600); err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(siteDir, frrFileName), renderFrr(site, asn, connections), 0644); err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(siteDir, interfacesFileName), renderInterfaces(site, connections), 0755)
+}
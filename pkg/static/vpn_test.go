@@ -0,0 +1,136 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package static
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	fakeVpnSite = &Site{
+		Name:          "lab",
+		AddressSpaces: []string{"10.250.0.0/16", "fd00:250::/48"},
+		Gateway:       Gateway{PublicIp: "203.0.113.10", Interface: "eth1"},
+	}
+	fakeVpnConnections = []*vpnConnection{
+		{Name: "paraglider-gcp-0", Cloud: "gcp", RemoteGatewayIp: "198.51.100.1", RemoteAsn: 64513, LocalBgpIpAddress: "169.254.0.1", RemoteBgpIpAddress: "169.254.0.2", SharedKey: "key1", InterfaceId: 1},
+		{Name: "paraglider-ibm-0", Cloud: "ibm", RemoteGatewayIp: "198.51.100.2", SharedKey: "key2", BgpDisabled: true, RemoteAddresses: []string{"10.3.0.0/16", "10.4.0.0/16"}, InterfaceId: 2},
+	}
+)
+
+func TestRenderSwanctl(t *testing.T) {
+	expected := `# VPN connections of site lab, rendered by Paraglider
+# Load with: swanctl --load-all --file swanctl.conf
+connections {
+	paraglider-gcp-0 {
+		version = 2
+		local_addrs = %any
+		remote_addrs = 198.51.100.1
+		dpd_delay = 30s
+		local {
+			auth = psk
+			id = 203.0.113.10
+		}
+		remote {
+			auth = psk
+			id = 198.51.100.1
+		}
+		children {
+			paraglider-gcp-0 {
+				local_ts = 0.0.0.0/0
+				remote_ts = 0.0.0.0/0
+				if_id_in = 1
+				if_id_out = 1
+				start_action = start
+				dpd_action = restart
+			}
+		}
+	}
+	paraglider-ibm-0 {
+		version = 2
+		local_addrs = %any
+		remote_addrs = 198.51.100.2
+		dpd_delay = 30s
+		local {
+			auth = psk
+			id = 203.0.113.10
+		}
+		remote {
+			auth = psk
+			id = 198.51.100.2
+		}
+		children {
+			paraglider-ibm-0 {
+				local_ts = 10.250.0.0/16,fd00:250::/48
+				remote_ts = 10.3.0.0/16,10.4.0.0/16
+				start_action = start
+				dpd_action = restart
+			}
+		}
+	}
+}
+secrets {
+	ike-paraglider-gcp-0 {
+		id = 198.51.100.1
+		secret = "key1"
+	}
+	ike-paraglider-ibm-0 {
+		id = 198.51.100.2
+		secret = "key2"
+	}
+}
+`
+	assert.Equal(t, expected, string(renderSwanctl(fakeVpnSite, fakeVpnConnections)))
+
+	// Gateways behind NAT listen on their local address
+	site := *fakeVpnSite
+	site.Gateway.LocalIp = "192.168.1.10"
+	assert.Contains(t, string(renderSwanctl(&site, fakeVpnConnections)), "local_addrs = 192.168.1.10\n")
+}
+
+func TestRenderFrr(t *testing.T) {
+	expected := `! BGP sessions of site lab, rendered by Paraglider
+! Include in the FRR configuration of the gateway
+router bgp 64512
+ no bgp ebgp-requires-policy
+ neighbor 169.254.0.2 remote-as 64513
+ neighbor 169.254.0.2 description paraglider-gcp-0
+ address-family ipv4 unicast
+  network 10.250.0.0/16
+ exit-address-family
+exit
+`
+	assert.Equal(t, expected, string(renderFrr(fakeVpnSite, 64512, fakeVpnConnections)))
+
+	// Sites only connected without BGP have no BGP sessions
+	assert.NotContains(t, string(renderFrr(fakeVpnSite, 64512, fakeVpnConnections[1:])), "router bgp")
+}
+
+func TestRenderInterfaces(t *testing.T) {
+	expected := `#!/bin/sh
+# XFRM interfaces of the VPN connections of site lab, rendered by Paraglider
+set -e
+ip link add pgxfrm1 type xfrm dev eth1 if_id 1 2>/dev/null || true
+ip address replace 169.254.0.1/30 dev pgxfrm1
+ip link set pgxfrm1 up
+`
+	assert.Equal(t, expected, string(renderInterfaces(fakeVpnSite, fakeVpnConnections)))
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Name of the bucket holding all values
+var boltBucket = []byte("paraglider")
+
+// How long to wait for another process to release the database file
+const boltOpenTimeout = 5 * time.Second
+
+// Transaction over the values in a bolt database file
+type boltTxn struct {
+	tx *bolt.Tx
+}
+
+func (t *boltTxn) bucket() *bolt.Bucket {
+	return t.tx.Bucket(boltBucket)
+}
+
+func (t *boltTxn) get(key string) (*value, error) {
+	data := t.bucket().Get([]byte(key))
+	if data == nil {
+		return nil, nil
+	}
+	v := &value{}
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("could not decode value of %s: %w", key, err)
+	}
+	return v, nil
+}
+
+func (t *boltTxn) put(key string, v *value) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("could not encode value of %s: %w", key, err)
+	}
+	return t.bucket().Put([]byte(key), data)
+}
+
+func (t *boltTxn) delete(key string) error {
+	return t.bucket().Delete([]byte(key))
+}
+
+func (t *boltTxn) keys() ([]string, error) {
+	var keys []string
+	err := t.bucket().ForEach(func(k, v []byte) error {
+		keys = append(keys, string(k))
+		return nil
+	})
+	return keys, err
+}
+
+func (t *boltTxn) clear() error {
+	if err := t.tx.DeleteBucket(boltBucket); err != nil {
+		return err
+	}
+	_, err := t.tx.CreateBucket(boltBucket)
+	return err
+}
+
+type boltDB struct {
+	db *bolt.DB
+}
+
+func (b *boltDB) view(fn func(txn valueTxn) error) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		return fn(&boltTxn{tx: tx})
+	})
+}
+
+func (b *boltDB) update(fn func(txn valueTxn) error) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltTxn{tx: tx})
+	})
+}
+
+func (b *boltDB) close() error {
+	return b.db.Close()
+}
+
+// Create a store which keeps values in an embedded database file
+func NewBoltStore(path string) (Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not initialize %s: %w", path, err)
+	}
+
+	return &valueStore{db: &boltDB{db: db}}, nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"sync"
+)
+
+// Copy a value so it can be modified without affecting the stored value
+func (v *value) clone() *value {
+	c := &value{Type: v.Type, String: v.String}
+	if v.Hash != nil {
+		c.Hash = make(map[string]string, len(v.Hash))
+		for field, val := range v.Hash {
+			c.Hash[field] = val
+		}
+	}
+	if v.Set != nil {
+		c.Set = make(map[string]bool, len(v.Set))
+		for member := range v.Set {
+			c.Set[member] = true
+		}
+	}
+	return c
+}
+
+// Transaction over the in-memory values
+// Writes are staged and only applied once the transaction commits
+type memoryTxn struct {
+	values  map[string]*value
+	staged  map[string]*value // nil values are deletions
+	cleared bool
+}
+
+func (t *memoryTxn) get(key string) (*value, error) {
+	if v, ok := t.staged[key]; ok {
+		return v, nil
+	}
+	if t.cleared {
+		return nil, nil
+	}
+	if v, ok := t.values[key]; ok {
+		return v.clone(), nil
+	}
+	return nil, nil
+}
+
+func (t *memoryTxn) put(key string, v *value) error {
+	t.staged[key] = v
+	return nil
+}
+
+func (t *memoryTxn) delete(key string) error {
+	t.staged[key] = nil
+	return nil
+}
+
+func (t *memoryTxn) keys() ([]string, error) {
+	var keys []string
+	if !t.cleared {
+		for key := range t.values {
+			if _, ok := t.staged[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	for key, v := range t.staged {
+		if v != nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (t *memoryTxn) clear() error {
+	t.cleared = true
+	t.staged = make(map[string]*value)
+	return nil
+}
+
+type memoryDB struct {
+	mu     sync.RWMutex
+	values map[string]*value
+}
+
+func (db *memoryDB) view(fn func(txn valueTxn) error) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return fn(&memoryTxn{values: db.values, staged: make(map[string]*value)})
+}
+
+func (db *memoryDB) update(fn func(txn valueTxn) error) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	txn := &memoryTxn{values: db.values, staged: make(map[string]*value)}
+	if err := fn(txn); err != nil {
+		return err
+	}
+
+	if txn.cleared {
+		db.values = make(map[string]*value)
+	}
+	for key, v := range txn.staged {
+		if v == nil {
+			delete(db.values, key)
+		} else {
+			db.values[key] = v
+		}
+	}
+	return nil
+}
+
+func (db *memoryDB) close() error {
+	return nil
+}
+
+// Create a store which only keeps values in memory (they are lost when the process exits)
+func NewMemoryStore() Store {
+	return &valueStore{db: &memoryDB{values: make(map[string]*value)}}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// Writes made through either a client or a transaction pipeline
+type redisWriter struct {
+	cmd redis.Cmdable
+}
+
+func (w redisWriter) Set(ctx context.Context, key string, value string) error {
+	return w.cmd.Set(ctx, key, value, 0).Err()
+}
+
+func (w redisWriter) Del(ctx context.Context, keys ...string) error {
+	return w.cmd.Del(ctx, keys...).Err()
+}
+
+func (w redisWriter) HSet(ctx context.Context, key string, values map[string]string) error {
+	return w.cmd.HSet(ctx, key, values).Err()
+}
+
+func (w redisWriter) HDel(ctx context.Context, key string, fields ...string) error {
+	return w.cmd.HDel(ctx, key, fields...).Err()
+}
+
+func (w redisWriter) SAdd(ctx context.Context, key string, members ...string) error {
+	return w.cmd.SAdd(ctx, key, members).Err()
+}
+
+func (w redisWriter) SRem(ctx context.Context, key string, members ...string) error {
+	return w.cmd.SRem(ctx, key, members).Err()
+}
+
+// Store backed by a Redis server
+type redisStore struct {
+	redisWriter
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) Store {
+	return &redisStore{redisWriter: redisWriter{cmd: client}, client: client}
+}
+
+func (s *redisStore) Type(ctx context.Context, key string) (string, error) {
+	return s.client.Type(ctx, key).Result()
+}
+
+func (s *redisStore) Keys(ctx context.Context, pattern string) ([]string, error) {
+	return s.client.Keys(ctx, pattern).Result()
+}
+
+// The Redis cursor is a number, where 0 both starts and ends a scan
+func (s *redisStore) Scan(ctx context.Context, cursor string, pattern string, count int64) ([]string, string, error) {
+	var start uint64
+	if cursor != "" {
+		var err error
+		start, err = strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid cursor %s: %w", cursor, err)
+		}
+	}
+
+	keys, next, err := s.client.Scan(ctx, start, pattern, count).Result()
+	if err != nil {
+		return nil, "", err
+	}
+	if next == 0 {
+		return keys, "", nil
+	}
+	return keys, strconv.FormatUint(next, 10), nil
+}
+
+func (s *redisStore) Get(ctx context.Context, key string) (string, error) {
+	return s.client.Get(ctx, key).Result()
+}
+
+func (s *redisStore) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return s.client.HGetAll(ctx, key).Result()
+}
+
+func (s *redisStore) HExists(ctx context.Context, key string, field string) (bool, error) {
+	return s.client.HExists(ctx, key, field).Result()
+}
+
+func (s *redisStore) HKeys(ctx context.Context, key string) ([]string, error) {
+	return s.client.HKeys(ctx, key).Result()
+}
+
+func (s *redisStore) HLen(ctx context.Context, key string) (int64, error) {
+	return s.client.HLen(ctx, key).Result()
+}
+
+func (s *redisStore) SMembers(ctx context.Context, key string) ([]string, error) {
+	return s.client.SMembers(ctx, key).Result()
+}
+
+func (s *redisStore) SIsMember(ctx context.Context, key string, member string) (bool, error) {
+	return s.client.SIsMember(ctx, key, member).Result()
+}
+
+func (s *redisStore) SInter(ctx context.Context, keys ...string) ([]string, error) {
+	return s.client.SInter(ctx, keys...).Result()
+}
+
+// Queue the writes in a MULTI/EXEC transaction
+func (s *redisStore) Tx(ctx context.Context, fn func(w Writer) error) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		return fn(redisWriter{cmd: pipe})
+	})
+	return err
+}
+
+func (s *redisStore) FlushAll(ctx context.Context) error {
+	return s.client.FlushAll(ctx).Err()
+}
+
+func (s *redisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// Supported storage backends
+const (
+	RedisBackend  = "redis"
+	MemoryBackend = "memory"
+	BoltBackend   = "bolt"
+)
+
+// Types of values which can be stored at a key (the same names Redis uses)
+const (
+	TypeNone   = "none"
+	TypeString = "string"
+	TypeHash   = "hash"
+	TypeSet    = "set"
+)
+
+// Returned by Get when a key does not exist
+// It is the same error Redis returns so existing checks for redis.Nil keep working with every backend
+var Nil = redis.Nil
+
+// Returned when an operation is used on a key holding a different type of value
+var ErrWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+// Operations which modify the store
+// Hashes and sets which become empty are deleted
+type Writer interface {
+	Set(ctx context.Context, key string, value string) error
+	Del(ctx context.Context, keys ...string) error
+	HSet(ctx context.Context, key string, values map[string]string) error
+	HDel(ctx context.Context, key string, fields ...string) error
+	SAdd(ctx context.Context, key string, members ...string) error
+	SRem(ctx context.Context, key string, members ...string) error
+}
+
+// Store of strings, hashes, and sets by key used by the tag service and the KV store
+type Store interface {
+	Writer
+
+	// Get the type of the value at a key (TypeNone if it does not exist)
+	Type(ctx context.Context, key string) (string, error)
+	// Get all keys matching a glob-style pattern
+	Keys(ctx context.Context, pattern string) ([]string, error)
+	// Get a batch of keys matching a glob-style pattern, continuing from a cursor ("" starts a new scan)
+	// Returns the cursor of the next batch, which is "" once all keys have been scanned
+	Scan(ctx context.Context, cursor string, pattern string, count int64) ([]string, string, error)
+
+	Get(ctx context.Context, key string) (string, error)
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	HExists(ctx context.Context, key string, field string) (bool, error)
+	HKeys(ctx context.Context, key string) ([]string, error)
+	HLen(ctx context.Context, key string) (int64, error)
+	SMembers(ctx context.Context, key string) ([]string, error)
+	SIsMember(ctx context.Context, key string, member string) (bool, error)
+	SInter(ctx context.Context, keys ...string) ([]string, error)
+
+	// Apply all writes made by fn atomically
+	Tx(ctx context.Context, fn func(w Writer) error) error
+
+	// Delete all keys
+	FlushAll(ctx context.Context) error
+	Close() error
+}
+
+// Open a store for a backend
+// The address is only used by the Redis backend and the path is only used by the bolt backend
+func Open(backend string, address string, path string) (Store, error) {
+	switch backend {
+	case RedisBackend, "":
+		client := redis.NewClient(&redis.Options{
+			Addr:     address,
+			Password: "", // no password set
+			DB:       0,  // use default DB
+		})
+		return NewRedisStore(client), nil
+	case MemoryBackend:
+		return NewMemoryStore(), nil
+	case BoltBackend:
+		if path == "" {
+			return nil, fmt.Errorf("bolt storage backend requires a path")
+		}
+		return NewBoltStore(path)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %s", backend)
+	}
+}
@@ -0,0 +1,277 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run a test against each of the embedded backends
+func forEachEmbeddedStore(t *testing.T, test func(t *testing.T, store Store)) {
+	t.Run(MemoryBackend, func(t *testing.T) {
+		test(t, NewMemoryStore())
+	})
+	t.Run(BoltBackend, func(t *testing.T) {
+		store, err := NewBoltStore(filepath.Join(t.TempDir(), "paraglider.db"))
+		require.Nil(t, err)
+		defer store.Close()
+		test(t, store)
+	})
+}
+
+func TestStrings(t *testing.T) {
+	forEachEmbeddedStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+
+		_, err := store.Get(ctx, "key")
+		assert.Equal(t, Nil, err)
+
+		require.Nil(t, store.Set(ctx, "key", "value"))
+		value, err := store.Get(ctx, "key")
+		require.Nil(t, err)
+		assert.Equal(t, "value", value)
+
+		valType, err := store.Type(ctx, "key")
+		require.Nil(t, err)
+		assert.Equal(t, TypeString, valType)
+
+		require.Nil(t, store.Del(ctx, "key"))
+		valType, err = store.Type(ctx, "key")
+		require.Nil(t, err)
+		assert.Equal(t, TypeNone, valType)
+	})
+}
+
+func TestHashes(t *testing.T) {
+	forEachEmbeddedStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+
+		require.Nil(t, store.HSet(ctx, "hash", map[string]string{"uri": "uri", "ip": "1.1.1.1"}))
+
+		values, err := store.HGetAll(ctx, "hash")
+		require.Nil(t, err)
+		assert.Equal(t, map[string]string{"uri": "uri", "ip": "1.1.1.1"}, values)
+
+		exists, err := store.HExists(ctx, "hash", "uri")
+		require.Nil(t, err)
+		assert.True(t, exists)
+
+		fields, err := store.HKeys(ctx, "hash")
+		require.Nil(t, err)
+		assert.Equal(t, []string{"ip", "uri"}, fields)
+
+		length, err := store.HLen(ctx, "hash")
+		require.Nil(t, err)
+		assert.Equal(t, int64(2), length)
+
+		// Deleting every field deletes the hash
+		require.Nil(t, store.HDel(ctx, "hash", "uri", "ip"))
+		valType, err := store.Type(ctx, "hash")
+		require.Nil(t, err)
+		assert.Equal(t, TypeNone, valType)
+
+		values, err = store.HGetAll(ctx, "hash")
+		require.Nil(t, err)
+		assert.Empty(t, values)
+	})
+}
+
+func TestSets(t *testing.T) {
+	forEachEmbeddedStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+
+		require.Nil(t, store.SAdd(ctx, "a", "1", "2", "3"))
+		require.Nil(t, store.SAdd(ctx, "b", "2", "3", "4"))
+
+		members, err := store.SMembers(ctx, "a")
+		require.Nil(t, err)
+		assert.Equal(t, []string{"1", "2", "3"}, members)
+
+		isMember, err := store.SIsMember(ctx, "a", "1")
+		require.Nil(t, err)
+		assert.True(t, isMember)
+
+		members, err = store.SInter(ctx, "a", "b")
+		require.Nil(t, err)
+		assert.Equal(t, []string{"2", "3"}, members)
+
+		members, err = store.SInter(ctx, "a", "missing")
+		require.Nil(t, err)
+		assert.Empty(t, members)
+
+		// Removing every member deletes the set
+		require.Nil(t, store.SRem(ctx, "a", "1", "2", "3"))
+		valType, err := store.Type(ctx, "a")
+		require.Nil(t, err)
+		assert.Equal(t, TypeNone, valType)
+	})
+}
+
+func TestWrongType(t *testing.T) {
+	forEachEmbeddedStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+
+		require.Nil(t, store.SAdd(ctx, "set", "member"))
+		assert.Equal(t, ErrWrongType, store.HSet(ctx, "set", map[string]string{"field": "value"}))
+		_, err := store.Get(ctx, "set")
+		assert.Equal(t, ErrWrongType, err)
+		_, err = store.HGetAll(ctx, "set")
+		assert.Equal(t, ErrWrongType, err)
+	})
+}
+
+func TestKeys(t *testing.T) {
+	forEachEmbeddedStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+
+		require.Nil(t, store.Set(ctx, "SUB:tag", "value"))
+		require.Nil(t, store.Set(ctx, "tag.1", "value"))
+		require.Nil(t, store.Set(ctx, "tag.2", "value"))
+
+		keys, err := store.Keys(ctx, "tag.?")
+		require.Nil(t, err)
+		assert.ElementsMatch(t, []string{"tag.1", "tag.2"}, keys)
+
+		keys, err = store.Keys(ctx, "*")
+		require.Nil(t, err)
+		assert.Len(t, keys, 3)
+
+		require.Nil(t, store.FlushAll(ctx))
+		keys, err = store.Keys(ctx, "*")
+		require.Nil(t, err)
+		assert.Empty(t, keys)
+	})
+}
+
+func TestScan(t *testing.T) {
+	forEachEmbeddedStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+
+		require.Nil(t, store.Set(ctx, "SUB:tag", "value"))
+		for _, key := range []string{"TAG:a", "TAG:b", "TAG:c", "TAG:*"} {
+			require.Nil(t, store.Set(ctx, key, "value"))
+		}
+
+		keys := []string{}
+		cursor := ""
+		for {
+			batch, next, err := store.Scan(ctx, cursor, "TAG:*", 2)
+			require.Nil(t, err)
+			assert.LessOrEqual(t, len(batch), 2)
+			keys = append(keys, batch...)
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+		assert.Equal(t, []string{"TAG:*", "TAG:a", "TAG:b", "TAG:c"}, keys)
+
+		keys, _, err := store.Scan(ctx, "", `TAG:\*`, 0)
+		require.Nil(t, err)
+		assert.Equal(t, []string{"TAG:*"}, keys)
+	})
+}
+
+func TestTx(t *testing.T) {
+	forEachEmbeddedStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+
+		err := store.Tx(ctx, func(w Writer) error {
+			require.Nil(t, w.SAdd(ctx, "SUB:tag", "subscriber"))
+			require.Nil(t, w.SAdd(ctx, "SUBSCRIBER:subscriber", "tag"))
+			return nil
+		})
+		require.Nil(t, err)
+
+		members, err := store.SMembers(ctx, "SUBSCRIBER:subscriber")
+		require.Nil(t, err)
+		assert.Equal(t, []string{"tag"}, members)
+
+		// Writes are discarded when the transaction fails
+		err = store.Tx(ctx, func(w Writer) error {
+			require.Nil(t, w.SRem(ctx, "SUB:tag", "subscriber"))
+			return errors.New("failed")
+		})
+		assert.NotNil(t, err)
+
+		members, err = store.SMembers(ctx, "SUB:tag")
+		require.Nil(t, err)
+		assert.Equal(t, []string{"subscriber"}, members)
+	})
+}
+
+func TestBoltStorePersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "paraglider.db")
+
+	store, err := NewBoltStore(path)
+	require.Nil(t, err)
+	require.Nil(t, store.SAdd(ctx, "parent", "child"))
+	require.Nil(t, store.Close())
+
+	store, err = NewBoltStore(path)
+	require.Nil(t, err)
+	defer store.Close()
+
+	members, err := store.SMembers(ctx, "parent")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"child"}, members)
+}
+
+func TestRedisStoreTx(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	store := NewRedisStore(db)
+	ctx := context.Background()
+
+	mock.ExpectTxPipeline()
+	mock.ExpectSAdd("SUB:tag", "subscriber").SetVal(1)
+	mock.ExpectSAdd("SUBSCRIBER:subscriber", "tag").SetVal(1)
+	mock.ExpectTxPipelineExec()
+
+	err := store.Tx(ctx, func(w Writer) error {
+		if err := w.SAdd(ctx, "SUB:tag", "subscriber"); err != nil {
+			return err
+		}
+		return w.SAdd(ctx, "SUBSCRIBER:subscriber", "tag")
+	})
+	assert.Nil(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	store, err := Open(MemoryBackend, "", "")
+	require.Nil(t, err)
+	assert.NotNil(t, store)
+
+	_, err = Open(BoltBackend, "", "")
+	assert.NotNil(t, err)
+
+	_, err = Open("unknown", "", "")
+	assert.NotNil(t, err)
+}
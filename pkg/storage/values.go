@@ -0,0 +1,400 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A value stored at a key by the embedded backends
+type value struct {
+	Type   string            `json:"type"`
+	String string            `json:"string,omitempty"`
+	Hash   map[string]string `json:"hash,omitempty"`
+	Set    map[string]bool   `json:"set,omitempty"`
+}
+
+// Access to the values of an embedded backend within a transaction
+type valueTxn interface {
+	// Get the value at a key (nil if it does not exist)
+	get(key string) (*value, error)
+	put(key string, v *value) error
+	delete(key string) error
+	keys() ([]string, error)
+	clear() error
+}
+
+// An embedded backend which runs read-only and read-write transactions
+type valueDB interface {
+	view(fn func(txn valueTxn) error) error
+	update(fn func(txn valueTxn) error) error
+	close() error
+}
+
+// Get the value at a key if it has the expected type
+func getTyped(txn valueTxn, key string, valType string) (*value, error) {
+	v, err := txn.get(key)
+	if err != nil {
+		return nil, err
+	}
+	if v != nil && v.Type != valType {
+		return nil, ErrWrongType
+	}
+	return v, nil
+}
+
+// Convert a glob-style pattern (with * and ? wildcards and \ escapes) to a regular expression
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '*':
+			expr.WriteString(".*")
+		case r == '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+// Writes applied directly to the values within a transaction
+type valueWriter struct {
+	txn valueTxn
+}
+
+func (w valueWriter) Set(ctx context.Context, key string, val string) error {
+	return w.txn.put(key, &value{Type: TypeString, String: val})
+}
+
+func (w valueWriter) Del(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := w.txn.delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w valueWriter) HSet(ctx context.Context, key string, values map[string]string) error {
+	v, err := getTyped(w.txn, key, TypeHash)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		v = &value{Type: TypeHash, Hash: make(map[string]string)}
+	}
+	for field, val := range values {
+		v.Hash[field] = val
+	}
+	return w.txn.put(key, v)
+}
+
+func (w valueWriter) HDel(ctx context.Context, key string, fields ...string) error {
+	v, err := getTyped(w.txn, key, TypeHash)
+	if err != nil || v == nil {
+		return err
+	}
+	for _, field := range fields {
+		delete(v.Hash, field)
+	}
+	if len(v.Hash) == 0 {
+		return w.txn.delete(key)
+	}
+	return w.txn.put(key, v)
+}
+
+func (w valueWriter) SAdd(ctx context.Context, key string, members ...string) error {
+	v, err := getTyped(w.txn, key, TypeSet)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		v = &value{Type: TypeSet, Set: make(map[string]bool)}
+	}
+	for _, member := range members {
+		v.Set[member] = true
+	}
+	return w.txn.put(key, v)
+}
+
+func (w valueWriter) SRem(ctx context.Context, key string, members ...string) error {
+	v, err := getTyped(w.txn, key, TypeSet)
+	if err != nil || v == nil {
+		return err
+	}
+	for _, member := range members {
+		delete(v.Set, member)
+	}
+	if len(v.Set) == 0 {
+		return w.txn.delete(key)
+	}
+	return w.txn.put(key, v)
+}
+
+// Store implemented on top of an embedded backend
+type valueStore struct {
+	db valueDB
+}
+
+func (s *valueStore) Set(ctx context.Context, key string, val string) error {
+	return s.Tx(ctx, func(w Writer) error { return w.Set(ctx, key, val) })
+}
+
+func (s *valueStore) Del(ctx context.Context, keys ...string) error {
+	return s.Tx(ctx, func(w Writer) error { return w.Del(ctx, keys...) })
+}
+
+func (s *valueStore) HSet(ctx context.Context, key string, values map[string]string) error {
+	return s.Tx(ctx, func(w Writer) error { return w.HSet(ctx, key, values) })
+}
+
+func (s *valueStore) HDel(ctx context.Context, key string, fields ...string) error {
+	return s.Tx(ctx, func(w Writer) error { return w.HDel(ctx, key, fields...) })
+}
+
+func (s *valueStore) SAdd(ctx context.Context, key string, members ...string) error {
+	return s.Tx(ctx, func(w Writer) error { return w.SAdd(ctx, key, members...) })
+}
+
+func (s *valueStore) SRem(ctx context.Context, key string, members ...string) error {
+	return s.Tx(ctx, func(w Writer) error { return w.SRem(ctx, key, members...) })
+}
+
+func (s *valueStore) Type(ctx context.Context, key string) (string, error) {
+	valType := TypeNone
+	err := s.db.view(func(txn valueTxn) error {
+		v, err := txn.get(key)
+		if err != nil {
+			return err
+		}
+		if v != nil {
+			valType = v.Type
+		}
+		return nil
+	})
+	return valType, err
+}
+
+func (s *valueStore) Keys(ctx context.Context, pattern string) ([]string, error) {
+	expr, err := globToRegexp(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	err = s.db.view(func(txn valueTxn) error {
+		keys, err := txn.keys()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if expr.MatchString(key) {
+				matches = append(matches, key)
+			}
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// The cursor is the last key of the previous batch since keys are scanned in order
+func (s *valueStore) Scan(ctx context.Context, cursor string, pattern string, count int64) ([]string, string, error) {
+	keys, err := s.Keys(ctx, pattern)
+	if err != nil {
+		return nil, "", err
+	}
+	sort.Strings(keys)
+
+	start := sort.SearchStrings(keys, cursor)
+	if start < len(keys) && keys[start] == cursor {
+		start++
+	}
+	end := len(keys)
+	if count > 0 && start+int(count) < end {
+		end = start + int(count)
+	}
+
+	batch := keys[start:end]
+	if end == len(keys) {
+		return batch, "", nil
+	}
+	return batch, batch[len(batch)-1], nil
+}
+
+func (s *valueStore) Get(ctx context.Context, key string) (string, error) {
+	var val string
+	err := s.db.view(func(txn valueTxn) error {
+		v, err := getTyped(txn, key, TypeString)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return Nil
+		}
+		val = v.String
+		return nil
+	})
+	return val, err
+}
+
+// Read a hash (nil if it does not exist)
+func (s *valueStore) viewHash(key string, fn func(hash map[string]string)) error {
+	return s.db.view(func(txn valueTxn) error {
+		v, err := getTyped(txn, key, TypeHash)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			fn(nil)
+		} else {
+			fn(v.Hash)
+		}
+		return nil
+	})
+}
+
+func (s *valueStore) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	values := make(map[string]string)
+	err := s.viewHash(key, func(hash map[string]string) {
+		for field, val := range hash {
+			values[field] = val
+		}
+	})
+	return values, err
+}
+
+func (s *valueStore) HExists(ctx context.Context, key string, field string) (bool, error) {
+	var exists bool
+	err := s.viewHash(key, func(hash map[string]string) {
+		_, exists = hash[field]
+	})
+	return exists, err
+}
+
+func (s *valueStore) HKeys(ctx context.Context, key string) ([]string, error) {
+	fields := []string{}
+	err := s.viewHash(key, func(hash map[string]string) {
+		for field := range hash {
+			fields = append(fields, field)
+		}
+	})
+	sort.Strings(fields)
+	return fields, err
+}
+
+func (s *valueStore) HLen(ctx context.Context, key string) (int64, error) {
+	var length int64
+	err := s.viewHash(key, func(hash map[string]string) {
+		length = int64(len(hash))
+	})
+	return length, err
+}
+
+// Read a set (nil if it does not exist)
+func (s *valueStore) viewSet(txn valueTxn, key string) (map[string]bool, error) {
+	v, err := getTyped(txn, key, TypeSet)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return v.Set, nil
+}
+
+func (s *valueStore) SMembers(ctx context.Context, key string) ([]string, error) {
+	members := []string{}
+	err := s.db.view(func(txn valueTxn) error {
+		set, err := s.viewSet(txn, key)
+		for member := range set {
+			members = append(members, member)
+		}
+		return err
+	})
+	sort.Strings(members)
+	return members, err
+}
+
+func (s *valueStore) SIsMember(ctx context.Context, key string, member string) (bool, error) {
+	var isMember bool
+	err := s.db.view(func(txn valueTxn) error {
+		set, err := s.viewSet(txn, key)
+		isMember = set[member]
+		return err
+	})
+	return isMember, err
+}
+
+func (s *valueStore) SInter(ctx context.Context, keys ...string) ([]string, error) {
+	members := []string{}
+	if len(keys) == 0 {
+		return members, nil
+	}
+
+	err := s.db.view(func(txn valueTxn) error {
+		sets := make([]map[string]bool, len(keys))
+		for i, key := range keys {
+			set, err := s.viewSet(txn, key)
+			if err != nil {
+				return err
+			}
+			sets[i] = set
+		}
+
+		for member := range sets[0] {
+			inAll := true
+			for _, set := range sets[1:] {
+				if !set[member] {
+					inAll = false
+					break
+				}
+			}
+			if inAll {
+				members = append(members, member)
+			}
+		}
+		return nil
+	})
+	sort.Strings(members)
+	return members, err
+}
+
+func (s *valueStore) Tx(ctx context.Context, fn func(w Writer) error) error {
+	return s.db.update(func(txn valueTxn) error {
+		return fn(valueWriter{txn: txn})
+	})
+}
+
+func (s *valueStore) FlushAll(ctx context.Context) error {
+	return s.db.update(func(txn valueTxn) error {
+		return txn.clear()
+	})
+}
+
+func (s *valueStore) Close() error {
+	return s.db.close()
+}
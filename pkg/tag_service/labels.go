@@ -0,0 +1,310 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tagservice
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	storage "github.com/paraglider-project/paraglider/pkg/storage"
+	tagservicepb "github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
+)
+
+// Key of the set of all selector tags
+const selectorTagsKey = "SELECTORS"
+
+// Key of the hash holding the labels of a tag
+func getLabelsKey(tagName string) string {
+	return "LABELS:" + tagName
+}
+
+// Key of the index from a label to the tags which have it
+func getLabelIndexKey(key string, value string) string {
+	return "LABEL:" + key + "=" + value
+}
+
+// Label keys and values cannot contain the characters used to separate them in selectors
+func validateLabel(key string, value string) error {
+	if key == "" {
+		return fmt.Errorf("label key cannot be empty")
+	}
+	if strings.ContainsAny(key, "=,") || strings.ContainsAny(value, "=,") {
+		return fmt.Errorf("label %s=%s cannot contain '=' or ','", key, value)
+	}
+	return nil
+}
+
+// Parse a selector of the form "key1=value1,key2=value2" into the labels it requires
+func parseSelector(selector string) (map[string]string, error) {
+	required := make(map[string]string)
+	for _, term := range strings.Split(selector, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(term), "=")
+		if !found {
+			return nil, fmt.Errorf("invalid selector term %q, expected key=value", term)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if err := validateLabel(key, value); err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %v", selector, err)
+		}
+		if existing, ok := required[key]; ok && existing != value {
+			return nil, fmt.Errorf("invalid selector %q: conflicting values for %s", selector, key)
+		}
+		required[key] = value
+	}
+	return required, nil
+}
+
+// Format the labels required by a selector in a canonical (sorted) form
+func formatSelector(required map[string]string) string {
+	terms := make([]string, 0, len(required))
+	for key, value := range required {
+		terms = append(terms, key+"="+value)
+	}
+	sort.Strings(terms)
+	return strings.Join(terms, ",")
+}
+
+// Determines if a set of labels satisfies all labels required by a selector
+func selectorMatches(required map[string]string, labels map[string]string) bool {
+	for key, value := range required {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Get the labels of a tag
+func (s *tagServiceServer) getLabels(c context.Context, tag string) (map[string]string, error) {
+	labels, err := s.store.HGetAll(c, getLabelsKey(tag))
+	if err != nil {
+		return nil, fmt.Errorf("getLabels %s: %v", tag, err)
+	}
+	return labels, nil
+}
+
+// Get the labels required by a selector tag
+func (s *tagServiceServer) getSelector(c context.Context, tag string) (map[string]string, error) {
+	selector, err := s.store.Get(c, getTagKey(tag))
+	if err != nil {
+		return nil, fmt.Errorf("getSelector %s: %v", tag, err)
+	}
+	return parseSelector(selector)
+}
+
+// Get the tags which currently have all the labels required by a selector tag
+func (s *tagServiceServer) getSelectorMembers(c context.Context, tag string) ([]string, error) {
+	required, err := s.getSelector(c, tag)
+	if err != nil {
+		return nil, err
+	}
+	return s.selectTags(c, tag, required)
+}
+
+// Get the tags which currently have all the required labels
+func (s *tagServiceServer) selectTags(c context.Context, tag string, required map[string]string) ([]string, error) {
+	indexKeys := make([]string, 0, len(required))
+	for key, value := range required {
+		indexKeys = append(indexKeys, getLabelIndexKey(key, value))
+	}
+	sort.Strings(indexKeys)
+
+	members, err := s.store.SInter(c, indexKeys...)
+	if err != nil {
+		return nil, fmt.Errorf("selectTags %s: %v", tag, err)
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// Record a selector tag whose members are all tags with the labels it requires
+func (s *tagServiceServer) _setSelectorTag(c context.Context, tag *tagservicepb.TagMapping) error {
+	required, err := parseSelector(*tag.Selector)
+	if err != nil {
+		return err
+	}
+
+	// Selector tags are stored as plain strings so they cannot replace an existing leaf or parent tag
+	valType, err := s.store.Type(c, getTagKey(tag.Name))
+	if err != nil {
+		return err
+	}
+	if valType != "none" && valType != "string" {
+		return fmt.Errorf("Cannot set tag %s as a selector tag because it already exists.", tag.Name)
+	}
+
+	// Selector tags cannot be selected themselves, so they cannot have labels
+	numLabels, err := s.store.HLen(c, getLabelsKey(tag.Name))
+	if err != nil {
+		return err
+	}
+	if numLabels > 0 {
+		return fmt.Errorf("Cannot set tag %s as a selector tag because it has labels.", tag.Name)
+	}
+
+	return s.store.Tx(c, func(w storage.Writer) error {
+		if err := w.Set(c, getTagKey(tag.Name), formatSelector(required)); err != nil {
+			return err
+		}
+		return w.SAdd(c, selectorTagsKey, tag.Name)
+	})
+}
+
+// Delete a selector tag
+func (s *tagServiceServer) _deleteSelectorTag(c context.Context, w storage.Writer, tag string) error {
+	if err := w.Del(c, getTagKey(tag)); err != nil {
+		return err
+	}
+	return w.SRem(c, selectorTagsKey, tag)
+}
+
+// Delete all labels of a tag and remove it from the label index
+func (s *tagServiceServer) _deleteLabels(c context.Context, w storage.Writer, tag string, labels map[string]string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(labels))
+	for key, value := range labels {
+		if err := w.SRem(c, getLabelIndexKey(key, value), tag); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return w.HDel(c, getLabelsKey(tag), keys...)
+}
+
+// Find the selector tags which a tag joins or leaves when its labels change
+func (s *tagServiceServer) getSelectorChanges(c context.Context, tag string, oldLabels map[string]string, newLabels map[string]string) ([]*tagservicepb.SelectorMembershipChange, error) {
+	selectorTags, err := s.store.SMembers(c, selectorTagsKey)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(selectorTags)
+
+	var changes []*tagservicepb.SelectorMembershipChange
+	for _, selectorTag := range selectorTags {
+		required, err := s.getSelector(c, selectorTag)
+		if err != nil {
+			return nil, err
+		}
+		before := selectorMatches(required, oldLabels)
+		after := selectorMatches(required, newLabels)
+		if before != after {
+			changes = append(changes, &tagservicepb.SelectorMembershipChange{SelectorTag: selectorTag, Member: tag, Joined: after})
+		}
+	}
+	return changes, nil
+}
+
+// Update the labels of a tag (labels with an empty value are removed)
+// Returns the selector tags which the tag joined or left as a result
+func (s *tagServiceServer) _setLabels(c context.Context, tag string, labels map[string]string) ([]*tagservicepb.SelectorMembershipChange, error) {
+	keys := make([]string, 0, len(labels))
+	for key, value := range labels {
+		if err := validateLabel(key, value); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	isSelector, err := s.store.SIsMember(c, selectorTagsKey, tag)
+	if err != nil {
+		return nil, err
+	}
+	if isSelector {
+		return nil, fmt.Errorf("Cannot set labels on selector tag %s.", tag)
+	}
+
+	// Labels of tags which do not exist would be inherited by a tag created later with the same name
+	valType, err := s.store.Type(c, getTagKey(tag))
+	if err != nil {
+		return nil, err
+	}
+	if valType == storage.TypeNone {
+		return nil, fmt.Errorf("Cannot set labels on tag %s because it does not exist.", tag)
+	}
+
+	oldLabels, err := s.getLabels(c, tag)
+	if err != nil {
+		return nil, err
+	}
+	newLabels := make(map[string]string, len(oldLabels))
+	for key, value := range oldLabels {
+		newLabels[key] = value
+	}
+	for _, key := range keys {
+		if labels[key] == "" {
+			delete(newLabels, key)
+		} else {
+			newLabels[key] = labels[key]
+		}
+	}
+
+	// Find the selector tags whose membership changes
+	changes, err := s.getSelectorChanges(c, tag, oldLabels, newLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prevent cycles by checking if any selector tag the tag joins is a descendent of it
+	for _, change := range changes {
+		if !change.Joined {
+			continue
+		}
+		selectorIsDescendent, err := s.isDescendent(c, tag, change.SelectorTag)
+		if err != nil {
+			return nil, err
+		}
+		if selectorIsDescendent {
+			return nil, fmt.Errorf("Cannot set labels on tag %s because it would join selector tag %s, which is already a descendent of it, and create a cycle.", tag, change.SelectorTag)
+		}
+	}
+
+	// Update the labels and the label index together
+	err = s.store.Tx(c, func(w storage.Writer) error {
+		for _, key := range keys {
+			value := labels[key]
+			if oldValue, ok := oldLabels[key]; ok {
+				if err := w.SRem(c, getLabelIndexKey(key, oldValue), tag); err != nil {
+					return err
+				}
+			}
+			if value == "" {
+				if err := w.HDel(c, getLabelsKey(tag), key); err != nil {
+					return err
+				}
+			} else {
+				if err := w.HSet(c, getLabelsKey(tag), map[string]string{key: value}); err != nil {
+					return err
+				}
+				if err := w.SAdd(c, getLabelIndexKey(key, value), tag); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
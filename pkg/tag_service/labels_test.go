@@ -0,0 +1,255 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tagservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	redismock "github.com/go-redis/redismock/v9"
+
+	storage "github.com/paraglider-project/paraglider/pkg/storage"
+	tagservicepb "github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
+)
+
+func TestParseSelector(t *testing.T) {
+	required, err := parseSelector("env=prod, cloud=azure")
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{"env": "prod", "cloud": "azure"}, required)
+	assert.Equal(t, "cloud=azure,env=prod", formatSelector(required))
+
+	// Invalid selectors
+	for _, selector := range []string{"", "env", "=prod", "env=prod,env=dev", "env=prod,,"} {
+		_, err = parseSelector(selector)
+		assert.NotNil(t, err, selector)
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	required := map[string]string{"env": "prod", "cloud": "azure"}
+	assert.True(t, selectorMatches(required, map[string]string{"env": "prod", "cloud": "azure", "team": "a"}))
+	assert.False(t, selectorMatches(required, map[string]string{"env": "prod"}))
+	assert.False(t, selectorMatches(required, map[string]string{"env": "dev", "cloud": "azure"}))
+	assert.False(t, selectorMatches(required, nil))
+}
+
+func TestGetSelectorMembers(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	server := newTagServiceServer(db)
+
+	mock.ExpectGet(getTagKey("selector")).SetVal("env=prod,cloud=azure")
+	mock.ExpectSInter(getLabelIndexKey("cloud", "azure"), getLabelIndexKey("env", "prod")).SetVal([]string{"vm2", "vm1"})
+
+	members, err := server.getSelectorMembers(context.Background(), "selector")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"vm1", "vm2"}, members)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSetSelectorTag(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	server := newTagServiceServer(db)
+
+	selector := "env=prod,cloud=azure"
+	tag := &tagservicepb.TagMapping{Name: "selector", Selector: &selector}
+	mock.ExpectType(getTagKey(tag.Name)).SetVal("none")
+	mock.ExpectHLen(getLabelsKey(tag.Name)).SetVal(0)
+	mock.ExpectTxPipeline()
+	mock.ExpectSet(getTagKey(tag.Name), "cloud=azure,env=prod", 0).SetVal("OK")
+	mock.ExpectSAdd(selectorTagsKey, tag.Name).SetVal(1)
+	mock.ExpectTxPipelineExec()
+
+	_, err := server.SetTag(context.Background(), &tagservicepb.SetTagRequest{Tag: tag})
+	assert.Nil(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Cannot replace a leaf or parent tag
+	mock.ExpectType(getTagKey(tag.Name)).SetVal("set")
+	_, err = server.SetTag(context.Background(), &tagservicepb.SetTagRequest{Tag: tag})
+	assert.NotNil(t, err)
+
+	// Cannot select a tag with labels
+	mock.ExpectType(getTagKey(tag.Name)).SetVal("none")
+	mock.ExpectHLen(getLabelsKey(tag.Name)).SetVal(1)
+	_, err = server.SetTag(context.Background(), &tagservicepb.SetTagRequest{Tag: tag})
+	assert.NotNil(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Cannot have a selector alongside children
+	tag.ChildTags = []string{"child"}
+	_, err = server.SetTag(context.Background(), &tagservicepb.SetTagRequest{Tag: tag})
+	assert.NotNil(t, err)
+}
+
+func TestSetTagLabels(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	server := newTagServiceServer(db)
+
+	// vm1 moves from env=dev to env=prod and drops its team label
+	tag := &tagservicepb.TagMapping{Name: "vm1", Labels: map[string]string{"env": "prod", "team": ""}}
+	mock.ExpectSIsMember(selectorTagsKey, tag.Name).SetVal(false)
+	mock.ExpectType(getTagKey(tag.Name)).SetVal("hash")
+	mock.ExpectHGetAll(getLabelsKey(tag.Name)).SetVal(map[string]string{"env": "dev", "team": "a"})
+	mock.ExpectSMembers(selectorTagsKey).SetVal([]string{"prod", "dev", "team-a"})
+	mock.ExpectGet(getTagKey("dev")).SetVal("env=dev")
+	mock.ExpectGet(getTagKey("prod")).SetVal("env=prod")
+	mock.ExpectGet(getTagKey("team-a")).SetVal("team=a")
+	mock.ExpectType(getTagKey(tag.Name)).SetVal("hash")
+	mock.ExpectTxPipeline()
+	mock.ExpectSRem(getLabelIndexKey("env", "dev"), tag.Name).SetVal(1)
+	mock.ExpectHSet(getLabelsKey(tag.Name), "env", "prod").SetVal(0)
+	mock.ExpectSAdd(getLabelIndexKey("env", "prod"), tag.Name).SetVal(1)
+	mock.ExpectSRem(getLabelIndexKey("team", "a"), tag.Name).SetVal(1)
+	mock.ExpectHDel(getLabelsKey(tag.Name), "team").SetVal(1)
+	mock.ExpectTxPipelineExec()
+
+	resp, err := server.SetTag(context.Background(), &tagservicepb.SetTagRequest{Tag: tag})
+	require.Nil(t, err)
+	expected := []*tagservicepb.SelectorMembershipChange{
+		{SelectorTag: "dev", Member: tag.Name, Joined: false},
+		{SelectorTag: "prod", Member: tag.Name, Joined: true},
+		{SelectorTag: "team-a", Member: tag.Name, Joined: false},
+	}
+	assert.Equal(t, expected, resp.SelectorChanges)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Selector tags cannot have labels
+	mock.ExpectSIsMember(selectorTagsKey, "prod").SetVal(true)
+	_, err = server.SetTag(context.Background(), &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "prod", Labels: map[string]string{"env": "prod"}}})
+	assert.NotNil(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Tags which do not exist cannot have labels
+	mock.ExpectSIsMember(selectorTagsKey, "missing").SetVal(false)
+	mock.ExpectType(getTagKey("missing")).SetVal("none")
+	_, err = server.SetTag(context.Background(), &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "missing", Labels: map[string]string{"env": "prod"}}})
+	assert.ErrorContains(t, err, "does not exist")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Invalid labels
+	_, err = server.SetTag(context.Background(), &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "vm1", Labels: map[string]string{"env": "a=b"}}})
+	assert.NotNil(t, err)
+}
+
+func TestGetSelectorTag(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	server := newTagServiceServer(db)
+
+	selector := "env=prod"
+	tag := &tagservicepb.TagMapping{Name: "selector", Selector: &selector, ChildTags: []string{"vm1", "vm2"}}
+	mock.ExpectType(getTagKey(tag.Name)).SetVal("string")
+	mock.ExpectGet(getTagKey(tag.Name)).SetVal(selector)
+	mock.ExpectSInter(getLabelIndexKey("env", "prod")).SetVal(tag.ChildTags)
+	mock.ExpectHGetAll(getLabelsKey(tag.Name)).SetVal(map[string]string{})
+
+	resp, err := server.GetTag(context.Background(), &tagservicepb.GetTagRequest{TagName: tag.Name})
+	require.Nil(t, err)
+	assert.Equal(t, tag, resp.Tag)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestResolveSelectorTag(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	server := newTagServiceServer(db)
+
+	childMapping := &tagservicepb.TagMapping{Name: "vm1", Uri: &uriVal, Ip: &ipVal}
+	mock.ExpectType(getTagKey("selector")).SetVal("string")
+	mock.ExpectGet(getTagKey("selector")).SetVal("env=prod")
+	mock.ExpectSInter(getLabelIndexKey("env", "prod")).SetVal([]string{childMapping.Name})
+	mock.ExpectType(getTagKey(childMapping.Name)).SetVal("hash")
+	mock.ExpectHGetAll(getTagKey(childMapping.Name)).SetVal(map[string]string{"uri": *childMapping.Uri, "ip": *childMapping.Ip})
+
+	resp, err := server.ResolveTag(context.Background(), &tagservicepb.ResolveTagRequest{TagName: "selector"})
+	require.Nil(t, err)
+	assert.Equal(t, childMapping, resp.Tags[0])
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeleteSelectorTag(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	server := newTagServiceServer(db)
+
+	mock.ExpectType(getTagKey("selector")).SetVal("string")
+	mock.ExpectHGetAll(getLabelsKey("selector")).SetVal(map[string]string{})
+	mock.ExpectTxPipeline()
+	mock.ExpectDel(getTagKey("selector")).SetVal(1)
+	mock.ExpectSRem(selectorTagsKey, "selector").SetVal(1)
+	mock.ExpectTxPipelineExec()
+
+	_, err := server.DeleteTag(context.Background(), &tagservicepb.DeleteTagRequest{TagName: "selector"})
+	assert.Nil(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeleteTagLabels(t *testing.T) {
+	server := newServer(storage.NewMemoryStore())
+	ctx := context.Background()
+
+	selector := "env=prod"
+	_, err := server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "prod", Selector: &selector}})
+	require.Nil(t, err)
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "vm1", Uri: &uriVal, Ip: &ipVal, Labels: map[string]string{"env": "prod"}}})
+	require.Nil(t, err)
+
+	// Deleting the tag removes it from the selector tags it was a member of
+	resp, err := server.DeleteTag(ctx, &tagservicepb.DeleteTagRequest{TagName: "vm1"})
+	require.Nil(t, err)
+	assert.Equal(t, []*tagservicepb.SelectorMembershipChange{{SelectorTag: "prod", Member: "vm1", Joined: false}}, resp.SelectorChanges)
+
+	members, err := server.getSelectorMembers(ctx, "prod")
+	require.Nil(t, err)
+	assert.Empty(t, members)
+
+	// A tag recreated with the same name does not inherit the old labels
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "vm1", Uri: &uriVal, Ip: &ipVal}})
+	require.Nil(t, err)
+	getResp, err := server.GetTag(ctx, &tagservicepb.GetTagRequest{TagName: "vm1"})
+	require.Nil(t, err)
+	assert.Empty(t, getResp.Tag.Labels)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tagservice
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tagservicepb "github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+)
+
+// Number of keys requested from each scan when listing tags
+const listTagsScanCount = 100
+
+// Escape the characters which have a special meaning in glob-style patterns
+func escapeGlob(value string) string {
+	var escaped strings.Builder
+	for _, r := range value {
+		if strings.ContainsRune(`*?[]\`, r) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
+
+// Encode the position to continue listing from: the scan cursor and how many keys of its batch were already listed
+func formatPageToken(cursor string, skip int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", skip, cursor)))
+}
+
+func parsePageToken(token string) (string, int, error) {
+	if token == "" {
+		return "", 0, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid page token: %v", err)
+	}
+	skipValue, cursor, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return "", 0, fmt.Errorf("invalid page token")
+	}
+	skip, err := strconv.Atoi(skipValue)
+	if err != nil || skip < 0 {
+		return "", 0, fmt.Errorf("invalid page token")
+	}
+	return cursor, skip, nil
+}
+
+// List the tags matching a prefix and labels, one page at a time
+func (s *tagServiceServer) ListTags(c context.Context, req *tagservicepb.ListTagsRequest) (*tagservicepb.ListTagsResponse, error) {
+	cursor, skip, err := parsePageToken(req.PageToken)
+	if err != nil {
+		return nil, fmt.Errorf("ListTags: %v", err)
+	}
+
+	pattern := getTagKey(escapeGlob(req.Prefix)) + "*"
+	var tagList []*tagservicepb.TagMapping
+	for {
+		keys, next, err := s.store.Scan(c, cursor, pattern, listTagsScanCount)
+		if err != nil {
+			return nil, fmt.Errorf("ListTags: %v", err)
+		}
+
+		for i := min(skip, len(keys)); i < len(keys); i++ {
+			tag := strings.TrimPrefix(keys[i], tagKeyPrefix)
+			resp, err := s.GetTag(c, &tagservicepb.GetTagRequest{TagName: tag})
+			if err != nil {
+				// Ignore errors
+				utils.Log.Printf("Failed to get tag mapping of %s: %v\n", tag, err)
+				continue
+			}
+			if !selectorMatches(req.Labels, resp.Tag.Labels) {
+				continue
+			}
+			tagList = append(tagList, resp.Tag)
+
+			// Stop once the page is full and continue from the following key
+			if req.Limit > 0 && len(tagList) == int(req.Limit) {
+				nextPageToken := ""
+				if i+1 < len(keys) {
+					nextPageToken = formatPageToken(cursor, i+1)
+				} else if next != "" {
+					nextPageToken = formatPageToken(next, 0)
+				}
+				return &tagservicepb.ListTagsResponse{Tags: tagList, NextPageToken: nextPageToken}, nil
+			}
+		}
+
+		if next == "" {
+			break
+		}
+		cursor, skip = next, 0
+	}
+
+	return &tagservicepb.ListTagsResponse{Tags: tagList}, nil
+}
@@ -0,0 +1,126 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tagservice
+
+import (
+	"context"
+	"testing"
+
+	redismock "github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	storage "github.com/paraglider-project/paraglider/pkg/storage"
+	tagservicepb "github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
+)
+
+func TestEscapeGlob(t *testing.T) {
+	assert.Equal(t, "ns.tag", escapeGlob("ns.tag"))
+	assert.Equal(t, `a\*b\?c\[d\]\\`, escapeGlob(`a*b?c[d]\`))
+}
+
+func TestPageToken(t *testing.T) {
+	cursor, skip, err := parsePageToken(formatPageToken("TAG:ns.tag", 3))
+	require.Nil(t, err)
+	assert.Equal(t, "TAG:ns.tag", cursor)
+	assert.Equal(t, 3, skip)
+
+	cursor, skip, err = parsePageToken("")
+	require.Nil(t, err)
+	assert.Equal(t, "", cursor)
+	assert.Equal(t, 0, skip)
+
+	_, _, err = parsePageToken("not a token")
+	assert.NotNil(t, err)
+}
+
+func TestListTags(t *testing.T) {
+	server := newServer(storage.NewMemoryStore())
+	ctx := context.Background()
+
+	for _, name := range []string{"ns.a.vm1", "ns.a.vm2", "ns.b.vm3", "other.vm4"} {
+		labels := map[string]string{"env": "prod"}
+		if name == "ns.a.vm2" {
+			labels["env"] = "dev"
+		}
+		_, err := server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: name, Uri: &uriVal, Ip: &ipVal, Labels: labels}})
+		require.Nil(t, err)
+	}
+	_, err := server.Subscribe(ctx, &tagservicepb.SubscribeRequest{Subscription: &tagservicepb.Subscription{TagName: "ns.a.vm1", Subscriber: "subscriber"}})
+	require.Nil(t, err)
+	_, err = server.SetTagPolicies(ctx, &tagservicepb.SetTagPoliciesRequest{TagName: "ns.a.vm1", Policies: []*tagservicepb.TagPolicy{{Name: "rule", Rule: []byte("rule")}}})
+	require.Nil(t, err)
+
+	// Internal keys are not listed
+	resp, err := server.ListTags(ctx, &tagservicepb.ListTagsRequest{})
+	require.Nil(t, err)
+	names := []string{}
+	for _, tag := range resp.Tags {
+		names = append(names, tag.Name)
+	}
+	assert.Equal(t, []string{"ns.a.vm1", "ns.a.vm2", "ns.b.vm3", "other.vm4"}, names)
+	assert.Empty(t, resp.NextPageToken)
+
+	// Prefix and labels
+	resp, err = server.ListTags(ctx, &tagservicepb.ListTagsRequest{Prefix: "ns.", Labels: map[string]string{"env": "prod"}})
+	require.Nil(t, err)
+	require.Len(t, resp.Tags, 2)
+	assert.Equal(t, "ns.a.vm1", resp.Tags[0].Name)
+	assert.Equal(t, "ns.b.vm3", resp.Tags[1].Name)
+
+	// Pages
+	names = []string{}
+	token := ""
+	for {
+		resp, err = server.ListTags(ctx, &tagservicepb.ListTagsRequest{Prefix: "ns.", Limit: 2, PageToken: token})
+		require.Nil(t, err)
+		assert.LessOrEqual(t, len(resp.Tags), 2)
+		for _, tag := range resp.Tags {
+			names = append(names, tag.Name)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		token = resp.NextPageToken
+	}
+	assert.Equal(t, []string{"ns.a.vm1", "ns.a.vm2", "ns.b.vm3"}, names)
+
+	_, err = server.ListTags(ctx, &tagservicepb.ListTagsRequest{PageToken: "not a token"})
+	assert.NotNil(t, err)
+}
+
+func TestListTagsScan(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	server := newTagServiceServer(db)
+
+	mock.ExpectScan(0, getTagKey("ns.")+"*", listTagsScanCount).SetVal([]string{getTagKey("ns.vm1")}, 7)
+	mock.ExpectType(getTagKey("ns.vm1")).SetVal("hash")
+	mock.ExpectHGetAll(getTagKey("ns.vm1")).SetVal(map[string]string{"uri": uriVal, "ip": ipVal})
+	mock.ExpectHGetAll(getLabelsKey("ns.vm1")).SetVal(map[string]string{})
+	mock.ExpectScan(7, getTagKey("ns.")+"*", listTagsScanCount).SetVal([]string{}, 0)
+
+	resp, err := server.ListTags(context.Background(), &tagservicepb.ListTagsRequest{Prefix: "ns."})
+	require.Nil(t, err)
+	require.Len(t, resp.Tags, 1)
+	assert.Equal(t, "ns.vm1", resp.Tags[0].Name)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tagservice
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	storage "github.com/paraglider-project/paraglider/pkg/storage"
+	utils "github.com/paraglider-project/paraglider/pkg/utils"
+)
+
+// Key of the number of migrations which have been applied to the store
+const schemaVersionKey = "TAG_SERVICE_SCHEMA_VERSION"
+
+// Prefixes of the keys of records which are not tags
+var recordKeyPrefixes = []string{tagKeyPrefix, "SUB:", "SUBSCRIBER:", "POLICY:", "PARENTS:", "LABELS:", "LABEL:"}
+
+// A change to the records written by an older version of the tag service
+type migration struct {
+	description string
+	apply       func(c context.Context, store storage.Store) error
+}
+
+// Migrations in the order they are applied
+// Only append to this list since the store records how many of them have been applied
+var migrations = []migration{
+	{description: "move tags under the tag key prefix", apply: migrateLegacyTagKeys},
+	{description: "index the parents of tags", apply: migrateParentIndex},
+	{description: "index the subscriptions of subscribers", apply: migrateSubscriberIndex},
+}
+
+// Apply the migrations which have not been applied to the store yet
+func migrate(c context.Context, store storage.Store) error {
+	version := 0
+	value, err := store.Get(c, schemaVersionKey)
+	if err != nil && err != storage.Nil {
+		return err
+	}
+	if err == nil {
+		version, err = strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid schema version %q: %v", value, err)
+		}
+	}
+
+	for i := version; i < len(migrations); i++ {
+		utils.Log.Printf("Applying tag service migration %d: %s", i+1, migrations[i].description)
+		if err := migrations[i].apply(c, store); err != nil {
+			return fmt.Errorf("migration %d (%s): %v", i+1, migrations[i].description, err)
+		}
+		if err := store.Set(c, schemaVersionKey, strconv.Itoa(i+1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get all keys matching a pattern
+// Keys are collected before any are changed since renaming keys during a scan can skip or repeat them
+func scanAllKeys(c context.Context, store storage.Store, pattern string) ([]string, error) {
+	var keys []string
+	cursor := ""
+	for {
+		batch, next, err := store.Scan(c, cursor, pattern, listTagsScanCount)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, batch...)
+		if next == "" {
+			return keys, nil
+		}
+		cursor = next
+	}
+}
+
+// Determines if a key could be a tag stored before tags had their own keyspace
+func isLegacyTagKey(key string) bool {
+	if key == selectorTagsKey || key == schemaVersionKey {
+		return false
+	}
+	for _, prefix := range recordKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// Move tags stored under their bare name to the tag keyspace
+// Tags used to be leaf hashes or sets of children, so strings (such as KV store values) are left alone
+func migrateLegacyTagKeys(c context.Context, store storage.Store) error {
+	keys, err := scanAllKeys(c, store, "*")
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if !isLegacyTagKey(key) {
+			continue
+		}
+		valType, err := store.Type(c, key)
+		if err != nil {
+			return err
+		}
+		if valType != storage.TypeHash && valType != storage.TypeSet {
+			continue
+		}
+
+		// Never overwrite a tag which was already written under the new key
+		newType, err := store.Type(c, getTagKey(key))
+		if err != nil {
+			return err
+		}
+		if newType != storage.TypeNone {
+			utils.Log.Printf("Not migrating tag %s because it already exists under %s", key, getTagKey(key))
+			continue
+		}
+
+		var fields map[string]string
+		var members []string
+		if valType == storage.TypeHash {
+			fields, err = store.HGetAll(c, key)
+		} else {
+			members, err = store.SMembers(c, key)
+		}
+		if err != nil {
+			return err
+		}
+		err = store.Tx(c, func(w storage.Writer) error {
+			if valType == storage.TypeHash {
+				if err := w.HSet(c, getTagKey(key), fields); err != nil {
+					return err
+				}
+			} else if err := w.SAdd(c, getTagKey(key), members...); err != nil {
+				return err
+			}
+			return w.Del(c, key)
+		})
+		if err != nil {
+			return fmt.Errorf("could not migrate tag %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// Record the parents of the children of every tag
+func migrateParentIndex(c context.Context, store storage.Store) error {
+	keys, err := scanAllKeys(c, store, tagKeyPrefix+"*")
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		valType, err := store.Type(c, key)
+		if err != nil {
+			return err
+		}
+		if valType != storage.TypeSet {
+			continue
+		}
+		children, err := store.SMembers(c, key)
+		if err != nil {
+			return err
+		}
+		parent := strings.TrimPrefix(key, tagKeyPrefix)
+		for _, child := range children {
+			if err := store.SAdd(c, getParentsKey(child), parent); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Record the tags each subscriber is subscribed to from the subscribers of every tag
+func migrateSubscriberIndex(c context.Context, store storage.Store) error {
+	keys, err := scanAllKeys(c, store, getSubscriptionKey("*"))
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		subscribers, err := store.SMembers(c, key)
+		if err != nil {
+			return err
+		}
+		tag := strings.TrimPrefix(key, getSubscriptionKey(""))
+		for _, subscriber := range subscribers {
+			if err := store.SAdd(c, getSubscriberKey(subscriber), tag); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
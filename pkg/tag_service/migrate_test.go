@@ -0,0 +1,105 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tagservice
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	storage "github.com/paraglider-project/paraglider/pkg/storage"
+	tagservicepb "github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
+)
+
+func TestIsLegacyTagKey(t *testing.T) {
+	assert.True(t, isLegacyTagKey("default.gcp.vm"))
+	assert.True(t, isLegacyTagKey("group"))
+	assert.False(t, isLegacyTagKey(getTagKey("group")))
+	assert.False(t, isLegacyTagKey(getSubscriptionKey("group")))
+	assert.False(t, isLegacyTagKey(getSubscriberKey("default>gcp>uri")))
+	assert.False(t, isLegacyTagKey(getPolicyKey("group")))
+	assert.False(t, isLegacyTagKey(getParentsKey("group")))
+	assert.False(t, isLegacyTagKey(getLabelsKey("group")))
+	assert.False(t, isLegacyTagKey(getLabelIndexKey("env", "prod")))
+	assert.False(t, isLegacyTagKey(selectorTagsKey))
+	assert.False(t, isLegacyTagKey(schemaVersionKey))
+}
+
+func TestMigrate(t *testing.T) {
+	store := storage.NewMemoryStore()
+	ctx := context.Background()
+
+	// Records written before tags had their own keyspace
+	require.Nil(t, store.HSet(ctx, "vm1", map[string]string{"uri": uriVal, "ip": ipVal}))
+	require.Nil(t, store.SAdd(ctx, "group", "vm1"))
+	require.Nil(t, store.SAdd(ctx, "parent", "group"))
+	require.Nil(t, store.SAdd(ctx, getSubscriptionKey("group"), "default>gcp>uri"))
+	require.Nil(t, store.Set(ctx, "default:gcp:key", "value"))
+
+	// A tag which was already written under the new key is not overwritten
+	require.Nil(t, store.SAdd(ctx, "existing", "old"))
+	require.Nil(t, store.SAdd(ctx, getTagKey("existing"), "new"))
+
+	require.Nil(t, migrate(ctx, store))
+
+	server := newServer(store)
+	resolved, err := server.ResolveTag(ctx, &tagservicepb.ResolveTagRequest{TagName: "parent"})
+	require.Nil(t, err)
+	require.Len(t, resolved.Tags, 1)
+	assert.Equal(t, "vm1", resolved.Tags[0].Name)
+	assert.Equal(t, ipVal, *resolved.Tags[0].Ip)
+
+	valType, err := store.Type(ctx, "vm1")
+	require.Nil(t, err)
+	assert.Equal(t, storage.TypeNone, valType)
+
+	// The parents of existing tags are indexed
+	ancestors, err := server.getAncestors(ctx, "vm1")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"group", "parent"}, ancestors)
+
+	// Subscriptions are indexed by subscriber
+	subscriptions, err := server.GetSubscriptions(ctx, &tagservicepb.GetSubscriptionsRequest{Subscriber: "default>gcp>uri"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"group"}, subscriptions.TagNames)
+
+	// Other records are untouched
+	subscribers, err := store.SMembers(ctx, getSubscriptionKey("group"))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"default>gcp>uri"}, subscribers)
+	value, err := store.Get(ctx, "default:gcp:key")
+	require.Nil(t, err)
+	assert.Equal(t, "value", value)
+	members, err := store.SMembers(ctx, getTagKey("existing"))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"new"}, members)
+
+	// Migrations are only applied once
+	require.Nil(t, store.SAdd(ctx, "later", "vm1"))
+	require.Nil(t, migrate(ctx, store))
+	valType, err = store.Type(ctx, "later")
+	require.Nil(t, err)
+	assert.Equal(t, storage.TypeSet, valType)
+	version, err := store.Get(ctx, schemaVersionKey)
+	require.Nil(t, err)
+	assert.Equal(t, strconv.Itoa(len(migrations)), version)
+}
@@ -22,25 +22,53 @@ import (
 	"log"
 	"net"
 	"net/netip"
-	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
+	storage "github.com/paraglider-project/paraglider/pkg/storage"
 	tagservicepb "github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
-	utils "github.com/paraglider-project/paraglider/pkg/utils"
-	redis "github.com/redis/go-redis/v9"
 
 	"google.golang.org/grpc"
 )
 
+const tagKeyPrefix = "TAG:"
+
+// Maximum number of levels of nested tags which are resolved
+const maxResolveDepth = 32
+
 type tagServiceServer struct {
 	tagservicepb.UnimplementedTagServiceServer
-	client *redis.Client
+	store       storage.Store
+	watchers    tagWatchers
+	leafTagLock sync.Mutex // Serializes the read-modify-write of leaf tag versions
+}
+
+// Key of the record holding the members of a tag
+// Tags have their own keyspace so other records in the store are never mistaken for tags
+func getTagKey(tagName string) string {
+	return tagKeyPrefix + tagName
 }
 
 func getSubscriptionKey(tagName string) string {
 	return "SUB:" + tagName
 }
 
+// Key of the reverse index from a subscriber to the tags it is subscribed to
+func getSubscriberKey(subscriber string) string {
+	return "SUBSCRIBER:" + subscriber
+}
+
+func getPolicyKey(tagName string) string {
+	return "POLICY:" + tagName
+}
+
+// Key of the index from a tag to the tags which directly contain it as a child
+func getParentsKey(tagName string) string {
+	return "PARENTS:" + tagName
+}
+
 // Returns true if the string is a valid IP or CIDR
 func isIpAddrOrCidr(value string) bool {
 	if strings.Contains(value, "/") {
@@ -54,16 +82,31 @@ func isIpAddrOrCidr(value string) bool {
 
 // Determines if a tag is a descendent of another tag
 func (s *tagServiceServer) isDescendent(c context.Context, tag string, potentialChild string) (bool, error) {
-	// Only do SMEMBERS if the tag is a set, otherwise it cannot be a parent
-	valType, err := s.client.Type(c, tag).Result()
+	return s._isDescendent(c, tag, potentialChild, make(map[string]bool), 0)
+}
+
+// Search the descendents of a tag, skipping tags already searched so cycles cannot recurse forever
+func (s *tagServiceServer) _isDescendent(c context.Context, tag string, potentialChild string, searched map[string]bool, depth int) (bool, error) {
+	if depth > maxResolveDepth {
+		return false, fmt.Errorf("isDescendent %s: tags are nested more than %d levels deep", tag, maxResolveDepth)
+	}
+	searched[tag] = true
+
+	// Only sets and selectors have children, otherwise the tag cannot be a parent
+	valType, err := s.store.Type(c, getTagKey(tag))
 	if err != nil {
 		return false, fmt.Errorf("isDescendent TYPE %s: %v", tag, err)
 	}
-	if valType != "set" {
+
+	var childrenTags []string
+	switch valType {
+	case "set":
+		childrenTags, err = s.store.SMembers(c, getTagKey(tag))
+	case "string":
+		childrenTags, err = s.getSelectorMembers(c, tag)
+	default:
 		return false, nil
 	}
-
-	childrenTags, err := s.client.SMembers(c, tag).Result()
 	if err != nil {
 		return false, fmt.Errorf("isDescendent %s: %v", tag, err)
 	}
@@ -71,7 +114,10 @@ func (s *tagServiceServer) isDescendent(c context.Context, tag string, potential
 		if child == potentialChild {
 			return true, nil
 		}
-		isDescendent, err := s.isDescendent(c, child, potentialChild)
+		if searched[child] {
+			continue
+		}
+		isDescendent, err := s._isDescendent(c, child, potentialChild, searched, depth+1)
 		if err != nil {
 			return false, fmt.Errorf("isDescendent %s: %v", tag, err)
 		}
@@ -88,231 +134,506 @@ func isLeafTagMapping(tag *tagservicepb.TagMapping) (bool, error) {
 	if hasChildren && hasUriOrIp {
 		return false, fmt.Errorf("TagMapping %s has both children and URI/IP", tag.Name)
 	}
+	if tag.Selector != nil && (hasChildren || hasUriOrIp) {
+		return false, fmt.Errorf("TagMapping %s has a selector and children or URI/IP", tag.Name)
+	}
+	if tag.Selector != nil && len(tag.Labels) > 0 {
+		return false, fmt.Errorf("TagMapping %s has both a selector and labels", tag.Name)
+	}
 
 	return !hasChildren && hasUriOrIp, nil
 }
 
 func (s *tagServiceServer) isLeafTag(c context.Context, tag string) (bool, error) {
-	recordType, err := s.client.Type(c, tag).Result()
+	recordType, err := s.store.Type(c, getTagKey(tag))
 	if err != nil {
 		return false, fmt.Errorf("isLeafTag TYPE %s: %v", tag, err)
 	}
 	return recordType == "hash", nil
 }
 
-// Record tag by storing mapping to URI and IP
-func (s *tagServiceServer) _setLeafTag(c context.Context, tag *tagservicepb.TagMapping) error {
-	exists, err := s.client.HExists(c, tag.Name, "uri").Result()
+// Determines if a tag mapping only updates the labels of a tag
+func isLabelOnlyTagMapping(tag *tagservicepb.TagMapping) bool {
+	return len(tag.Labels) > 0 && len(tag.ChildTags) == 0 && tag.Uri == nil && tag.Ip == nil && tag.Selector == nil
+}
+
+// Get the version of a leaf tag's record (0 if it does not exist)
+// Records written before leaf tags were versioned are version 1
+func getLeafTagVersion(info map[string]string) (int64, error) {
+	if len(info) == 0 {
+		return 0, nil
+	}
+	value, ok := info["version"]
+	if !ok {
+		return 1, nil
+	}
+	version, err := strconv.ParseInt(value, 10, 64)
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("invalid version %s", value)
 	}
-	if exists {
-		return fmt.Errorf("Cannot set tag %s as a leaf tag because it already exists.", tag.Name)
+	return version, nil
+}
+
+// Record tag by storing mapping to URI and IP, or update the URI and IP of an existing leaf tag
+// If the mapping has a version, the tag must still be at that version (0 if it does not exist yet)
+// Returns the version of the tag and whether its URI or IP changed
+func (s *tagServiceServer) _setLeafTag(c context.Context, tag *tagservicepb.TagMapping) (int64, bool, error) {
+	s.leafTagLock.Lock()
+	defer s.leafTagLock.Unlock()
+
+	info, err := s.store.HGetAll(c, getTagKey(tag.Name))
+	if err != nil {
+		return 0, false, fmt.Errorf("Cannot set tag %s as a leaf tag: %v", tag.Name, err)
+	}
+	version, err := getLeafTagVersion(info)
+	if err != nil {
+		return 0, false, fmt.Errorf("Cannot set tag %s as a leaf tag: %v", tag.Name, err)
+	}
+	if tag.Version != nil && *tag.Version != version {
+		return 0, false, fmt.Errorf("Cannot set tag %s because it is at version %d, not %d.", tag.Name, version, *tag.Version)
 	}
 
-	uri := ""
+	// Fields which are not set keep their current value
+	uri := info["uri"]
 	if tag.Uri != nil {
 		uri = *tag.Uri
 	}
-	ip := ""
+	ip := info["ip"]
 	if tag.Ip != nil {
 		ip = *tag.Ip
 	}
+	if version > 0 && uri == info["uri"] && ip == info["ip"] {
+		return version, false, nil
+	}
 
-	err = s.client.HSet(c, tag.Name, map[string]string{"uri": uri, "ip": ip}).Err()
+	version++
+	err = s.store.HSet(c, getTagKey(tag.Name), map[string]string{"uri": uri, "ip": ip, "version": strconv.FormatInt(version, 10)})
 	if err != nil {
-		return err
+		return 0, false, err
 	}
-	return nil
+	return version, true, nil
 }
 
-// Set tag relationship by adding child tag to parent tag's set
-func (s *tagServiceServer) SetTag(c context.Context, req *tagservicepb.SetTagRequest) (*tagservicepb.SetTagResponse, error) {
-	// If tag is leaf entry (no children), set as a hash record and return
-	isLeaf, err := isLeafTagMapping(req.Tag)
+// Find the tags which directly contain a tag, as a child or because its labels match their selector
+func (s *tagServiceServer) getParents(c context.Context, tag string) ([]string, error) {
+	parents, err := s.store.SMembers(c, getParentsKey(tag))
 	if err != nil {
-		return &tagservicepb.SetTagResponse{}, fmt.Errorf("SetTag: %v", err)
+		return nil, err
+	}
+
+	labels, err := s.getLabels(c, tag)
+	if err != nil {
+		return nil, err
+	}
+	if len(labels) == 0 {
+		return parents, nil
+	}
+	selectorTags, err := s.store.SMembers(c, selectorTagsKey)
+	if err != nil {
+		return nil, err
+	}
+	for _, selectorTag := range selectorTags {
+		required, err := s.getSelector(c, selectorTag)
+		if err != nil {
+			return nil, err
+		}
+		if selectorMatches(required, labels) {
+			parents = append(parents, selectorTag)
+		}
+	}
+	return parents, nil
+}
+
+// Find the tags which contain a tag, directly or through other tags
+func (s *tagServiceServer) getAncestors(c context.Context, tag string) ([]string, error) {
+	found := map[string]bool{tag: true}
+	var ancestors []string
+	pending := []string{tag}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		parents, err := s.getParents(c, current)
+		if err != nil {
+			return nil, fmt.Errorf("getAncestors %s: %v", tag, err)
+		}
+		for _, parent := range parents {
+			if found[parent] {
+				continue
+			}
+			found[parent] = true
+			ancestors = append(ancestors, parent)
+			pending = append(pending, parent)
+		}
+	}
+	sort.Strings(ancestors)
+	return ancestors, nil
+}
+
+// Record the members of a tag (its URI/IP, children, or selector)
+func (s *tagServiceServer) _setTagMembers(c context.Context, tag *tagservicepb.TagMapping) (*tagservicepb.SetTagResponse, error) {
+	resp := &tagservicepb.SetTagResponse{}
+
+	// If tag is leaf entry (no children), set or update the hash record and return
+	isLeaf, err := isLeafTagMapping(tag)
+	if err != nil {
+		return nil, err
 	}
 	if isLeaf {
-		err := s._setLeafTag(c, req.Tag)
+		version, changed, err := s._setLeafTag(c, tag)
 		if err != nil {
-			return &tagservicepb.SetTagResponse{}, fmt.Errorf("SetTag: %v", err)
+			return nil, err
+		}
+		resp.Version = version
+		if !changed {
+			return resp, nil
 		}
-		return &tagservicepb.SetTagResponse{}, nil
+
+		// Tags containing the leaf tag now resolve to different addresses
+		resp.UpdatedAncestors, err = s.getAncestors(c, tag.Name)
+		if err != nil {
+			return nil, err
+		}
+		s.watchers.publish(tagservicepb.TagEvent_ADDRESS_CHANGED, tag.Name)
+		return resp, nil
+	}
+
+	// If tag is a selector, its members are determined by labels
+	if tag.Selector != nil {
+		err := s._setSelectorTag(c, tag)
+		if err != nil {
+			return nil, err
+		}
+		s.watchers.publish(tagservicepb.TagEvent_MEMBERSHIP_CHANGED, tag.Name)
+		return resp, nil
 	}
 
 	// If tag is not leaf entry, set as a set record and return
 	// Prevent cycles by checking if the parent tag is a descendent of any child tags
-	for _, child := range req.Tag.ChildTags {
-		parentTagIsDescendent, err := s.isDescendent(c, child, req.Tag.Name)
+	for _, child := range tag.ChildTags {
+		if child == tag.Name {
+			return nil, fmt.Errorf("Cannot add tag %s as a child of itself.", tag.Name)
+		}
+		parentTagIsDescendent, err := s.isDescendent(c, child, tag.Name)
 		if err != nil {
-			return &tagservicepb.SetTagResponse{}, fmt.Errorf("SetTag: %v", err)
+			return nil, err
 		}
 		if parentTagIsDescendent {
-			return &tagservicepb.SetTagResponse{}, nil
+			return nil, fmt.Errorf("Cannot add tag %s as a child of %s because %s is already a descendent of it, which would create a cycle.", child, tag.Name, tag.Name)
 		}
 	}
 
-	// Add the tags
-	err = s.client.SAdd(c, req.Tag.Name, req.Tag.ChildTags).Err()
+	// Add the tags and record the tag as their parent
+	err = s.store.Tx(c, func(w storage.Writer) error {
+		if err := w.SAdd(c, getTagKey(tag.Name), tag.ChildTags...); err != nil {
+			return err
+		}
+		for _, child := range tag.ChildTags {
+			if err := w.SAdd(c, getParentsKey(child), tag.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.watchers.publish(tagservicepb.TagEvent_MEMBERSHIP_CHANGED, tag.Name)
+	return resp, nil
+}
+
+// Set tag relationship by adding child tag to parent tag's set and update the tag's labels
+func (s *tagServiceServer) SetTag(c context.Context, req *tagservicepb.SetTagRequest) (*tagservicepb.SetTagResponse, error) {
+	resp := &tagservicepb.SetTagResponse{}
+	if !isLabelOnlyTagMapping(req.Tag) {
+		var err error
+		resp, err = s._setTagMembers(c, req.Tag)
+		if err != nil {
+			return &tagservicepb.SetTagResponse{}, fmt.Errorf("SetTag: %v", err)
+		}
+	}
+	if len(req.Tag.Labels) == 0 {
+		return resp, nil
+	}
+
+	// Label changes may move the tag in or out of selector tags
+	changes, err := s._setLabels(c, req.Tag.Name, req.Tag.Labels)
 	if err != nil {
 		return &tagservicepb.SetTagResponse{}, fmt.Errorf("SetTag: %v", err)
 	}
+	for _, change := range changes {
+		s.watchers.publish(tagservicepb.TagEvent_MEMBERSHIP_CHANGED, change.SelectorTag)
+	}
+	resp.SelectorChanges = changes
 
-	return &tagservicepb.SetTagResponse{}, nil
+	return resp, nil
 }
 
 // Get the members of a tag
 func (s *tagServiceServer) GetTag(c context.Context, req *tagservicepb.GetTagRequest) (*tagservicepb.GetTagResponse, error) {
-	// Determine if the tag is a leaf tag or not
-	isLeaf, err := s.isLeafTag(c, req.TagName)
+	// Determine if the tag is a leaf tag, a selector tag, or a parent tag
+	valType, err := s.store.Type(c, getTagKey(req.TagName))
 	if err != nil {
-		return nil, fmt.Errorf("GetTag %s: %v", req.TagName, err)
+		return nil, fmt.Errorf("GetTag TYPE %s: %v", req.TagName, err)
 	}
 
-	// If it is, retrieve the hash record
-	if isLeaf {
-		info, err := s.client.HGetAll(c, req.TagName).Result()
+	tag := &tagservicepb.TagMapping{Name: req.TagName}
+	switch valType {
+	case "hash": // If it is a leaf tag, retrieve the hash record
+		info, err := s.store.HGetAll(c, getTagKey(req.TagName))
 		if err != nil {
 			return nil, fmt.Errorf("GetTag %s: %v", req.TagName, err)
 		}
 		uri := info["uri"]
 		ip := info["ip"]
-		return &tagservicepb.GetTagResponse{Tag: &tagservicepb.TagMapping{Name: req.TagName, Uri: &uri, Ip: &ip}}, nil
+		tag.Uri = &uri
+		tag.Ip = &ip
+		if _, ok := info["version"]; ok {
+			version, err := getLeafTagVersion(info)
+			if err != nil {
+				return nil, fmt.Errorf("GetTag %s: %v", req.TagName, err)
+			}
+			tag.Version = &version
+		}
+	case "string": // If it is a selector tag, retrieve the selector and the tags currently matching it
+		required, err := s.getSelector(c, req.TagName)
+		if err != nil {
+			return nil, fmt.Errorf("GetTag %s: %v", req.TagName, err)
+		}
+		selector := formatSelector(required)
+		tag.Selector = &selector
+		tag.ChildTags, err = s.selectTags(c, req.TagName, required)
+		if err != nil {
+			return nil, fmt.Errorf("GetTag %s: %v", req.TagName, err)
+		}
+	default: // Otherwise, retrieve set of child tags
+		tag.ChildTags, err = s.store.SMembers(c, getTagKey(req.TagName))
+		if err != nil {
+			return nil, fmt.Errorf("GetTag %s: %v", req.TagName, err)
+		}
 	}
 
-	// Otherwise, retrieve set of child tags
-	childrenTags, err := s.client.SMembers(c, req.TagName).Result()
+	labels, err := s.getLabels(c, req.TagName)
 	if err != nil {
 		return nil, fmt.Errorf("GetTag %s: %v", req.TagName, err)
 	}
-	return &tagservicepb.GetTagResponse{Tag: &tagservicepb.TagMapping{Name: req.TagName, ChildTags: childrenTags}}, nil
+	if len(labels) > 0 {
+		tag.Labels = labels
+	}
+	return &tagservicepb.GetTagResponse{Tag: tag}, nil
+}
+
+// Leaves found while resolving a tag
+type resolvedTags struct {
+	tags  []*tagservicepb.TagMapping
+	paths [][]string // Path of tag names from the resolved tag to each leaf
+	seen  map[string]bool
 }
 
 // Resolve a list of tags into all base-level IPs
-func (s *tagServiceServer) _resolveTags(c context.Context, tags []string, resolvedTags []*tagservicepb.TagMapping) ([]*tagservicepb.TagMapping, error) {
+// Each leaf is only returned once and parent tags which were already resolved are skipped
+// Returns an error if the tags contain a cycle or are nested too deeply
+func (s *tagServiceServer) _resolveTags(c context.Context, tags []string, path []string, resolved *resolvedTags) error {
+	if len(path) > maxResolveDepth {
+		return fmt.Errorf("ResolveTag %s: tags are nested more than %d levels deep", path[0], maxResolveDepth)
+	}
+
 	for _, tag := range tags {
+		tagPath := append(path[:len(path):len(path)], tag)
+
 		// If the tag is an IP, it is already resolved
 		isIP := isIpAddrOrCidr(tag)
 		if isIP {
-			ipTag := &tagservicepb.TagMapping{Name: "", Uri: nil, Ip: &tag}
-			resolvedTags = append(resolvedTags, ipTag)
-		} else {
-			// Get the tag record type since may be hash (if name value) or set (if parent tag)
-			valType, err := s.client.Type(c, tag).Result()
+			if !resolved.seen[tag] {
+				resolved.seen[tag] = true
+				ipTag := &tagservicepb.TagMapping{Name: "", Uri: nil, Ip: &tag}
+				resolved.tags = append(resolved.tags, ipTag)
+				resolved.paths = append(resolved.paths, tagPath)
+			}
+			continue
+		}
+
+		for _, ancestor := range path {
+			if ancestor == tag {
+				return fmt.Errorf("ResolveTag: cycle detected: %s", strings.Join(tagPath, " -> "))
+			}
+		}
+		if resolved.seen[tag] {
+			continue
+		}
+
+		// Get the tag record type since may be hash (if name value) or set (if parent tag)
+		valType, err := s.store.Type(c, getTagKey(tag))
+		if err != nil {
+			return fmt.Errorf("ResolveTag TYPE %s: %v", tag, err)
+		}
+
+		if valType == "none" { // The tag is not present
+			continue
+		} else if valType == "hash" { // The tag is a name record
+			info, err := s.store.HGetAll(c, getTagKey(tag))
 			if err != nil {
-				return nil, fmt.Errorf("ResolveTag TYPE %s: %v", tag, err)
+				return fmt.Errorf("ResolveTag HGETALL %s: %v", tag, err)
+			}
+			uri := info["uri"]
+			ip := info["ip"]
+			resolved.seen[tag] = true
+			resolved.tags = append(resolved.tags, &tagservicepb.TagMapping{Name: tag, Uri: &uri, Ip: &ip})
+			resolved.paths = append(resolved.paths, tagPath)
+		} else { // The tag has children (explicit or selected by labels) that may also need resolved
+			var childrenTags []string
+			if valType == "string" {
+				childrenTags, err = s.getSelectorMembers(c, tag)
+			} else {
+				childrenTags, err = s.store.SMembers(c, getTagKey(tag))
+			}
+			if err != nil {
+				return fmt.Errorf("ResolveTag SMEMBERS %s: %v", tag, err)
 			}
 
-			if valType == "none" { // The tag is not present
-				continue
-			} else if valType == "hash" { // The tag is a name record
-				info, err := s.client.HGetAll(c, tag).Result()
-				if err != nil {
-					return nil, fmt.Errorf("ResolveTag HGETALL %s: %v", tag, err)
-				}
-				uri := info["uri"]
-				ip := info["ip"]
-				resolvedTags = append(resolvedTags, &tagservicepb.TagMapping{Name: tag, Uri: &uri, Ip: &ip})
-			} else { // The tag has children that may also need resolved
-				childrenTags, err := s.client.SMembers(c, tag).Result()
-				if err != nil {
-					return nil, fmt.Errorf("ResolveTag SMEMBERS %s: %v", tag, err)
-				}
-
-				resolvedChildTags, err := s._resolveTags(c, childrenTags, resolvedTags)
-				if err != nil {
-					return nil, fmt.Errorf("ResolveTag %s: %v", tag, err)
-				}
-				resolvedTags = append(resolvedTags, resolvedChildTags...)
+			err = s._resolveTags(c, childrenTags, tagPath, resolved)
+			if err != nil {
+				return err
 			}
+			// Only mark the tag once its children are resolved so cycles through it are still detected
+			resolved.seen[tag] = true
 		}
 	}
-	return resolvedTags, nil
+	return nil
+}
+
+// Resolve a tag into its unique leaves
+func (s *tagServiceServer) resolveTag(c context.Context, tagName string) (*resolvedTags, error) {
+	resolved := &resolvedTags{seen: make(map[string]bool)}
+	err := s._resolveTags(c, []string{tagName}, nil, resolved)
+	if err != nil {
+		return nil, err
+	}
+	return resolved, nil
 }
 
 // Resolve a tag down to all the IPs in it
 func (s *tagServiceServer) ResolveTag(c context.Context, req *tagservicepb.ResolveTagRequest) (*tagservicepb.ResolveTagResponse, error) {
-	var emptyTagList []*tagservicepb.TagMapping
-	resolvedTags, err := s._resolveTags(c, []string{req.TagName}, emptyTagList)
+	resolved, err := s.resolveTag(c, req.TagName)
 	if err != nil {
 		return nil, err
 	}
 
-	return &tagservicepb.ResolveTagResponse{Tags: resolvedTags}, nil
-}
-
-// Resolve a list of tags into all base-level IPs
-func (s *tagServiceServer) ListTags(c context.Context, req *tagservicepb.ListTagsRequest) (*tagservicepb.ListTagsResponse, error) {
-	var resolvedTagList []*tagservicepb.TagMapping
-	tags := s.client.Keys(c, "*").Val()
-	for _, tag := range tags {
-		resp, err := s.GetTag(c, &tagservicepb.GetTagRequest{TagName: tag})
-		if err != nil {
-			// Ignore errors
-			utils.Log.Printf("Failed to get tag mapping of %s: %v\n", tag, err)
-			continue
+	resp := &tagservicepb.ResolveTagResponse{Tags: resolved.tags}
+	if req.IncludePaths {
+		for _, path := range resolved.paths {
+			resp.Paths = append(resp.Paths, &tagservicepb.TagPath{Tags: path})
 		}
-		resolvedTagList = append(resolvedTagList, resp.Tag)
 	}
-
-	return &tagservicepb.ListTagsResponse{Tags: resolvedTagList}, nil
+	return resp, nil
 }
 
 // Delete a member of a tag
 func (s *tagServiceServer) DeleteTagMember(c context.Context, req *tagservicepb.DeleteTagMemberRequest) (*tagservicepb.DeleteTagMemberResponse, error) {
-	err := s.client.SRem(c, req.ParentTag, req.ChildTag).Err()
+	err := s.store.Tx(c, func(w storage.Writer) error {
+		if err := w.SRem(c, getTagKey(req.ParentTag), req.ChildTag); err != nil {
+			return err
+		}
+		return w.SRem(c, getParentsKey(req.ChildTag), req.ParentTag)
+	})
 	if err != nil {
 		return &tagservicepb.DeleteTagMemberResponse{}, fmt.Errorf("DeleteTagMember %s: %v", req.ParentTag, err)
 	}
+	s.watchers.publish(tagservicepb.TagEvent_MEMBERSHIP_CHANGED, req.ParentTag)
 	return &tagservicepb.DeleteTagMemberResponse{}, nil
 }
 
 // Delete a leaf record for a tag
-func (s *tagServiceServer) _deleteLeafTag(c context.Context, tag *tagservicepb.TagMapping) error {
-	keys, err := s.client.HKeys(c, tag.Name).Result()
-	if err != nil {
-		return err
+func (s *tagServiceServer) _deleteLeafTag(c context.Context, w storage.Writer, tag string, fields []string) error {
+	if len(fields) == 0 {
+		return nil
 	}
+	return w.HDel(c, getTagKey(tag), fields...)
+}
 
-	err = s.client.HDel(c, tag.Name, keys...).Err()
-	if err != nil {
+// Delete the children of a tag and its entries in their parent index
+func (s *tagServiceServer) _deleteParentTag(c context.Context, w storage.Writer, tag string, children []string) error {
+	if len(children) == 0 {
+		return nil
+	}
+	if err := w.SRem(c, getTagKey(tag), children...); err != nil {
 		return err
 	}
+	for _, child := range children {
+		if err := w.SRem(c, getParentsKey(child), tag); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// Delete a tag and its relationship to its children tags
+// Delete a tag, its relationship to its children tags, and its labels
+// Returns the selector tags which the tag left because its labels were deleted
 func (s *tagServiceServer) DeleteTag(c context.Context, req *tagservicepb.DeleteTagRequest) (*tagservicepb.DeleteTagResponse, error) {
-	// If the tag is a leaf tag, delete the hash record
-	isLeaf, err := s.isLeafTag(c, req.TagName)
+	valType, err := s.store.Type(c, getTagKey(req.TagName))
+	if err != nil {
+		return &tagservicepb.DeleteTagResponse{}, fmt.Errorf("DeleteTag TYPE %s: %v", req.TagName, err)
+	}
+
+	// Read everything which is deleted up front so the records are removed together
+	var members []string
+	switch valType {
+	case storage.TypeHash:
+		members, err = s.store.HKeys(c, getTagKey(req.TagName))
+	case storage.TypeSet:
+		members, err = s.store.SMembers(c, getTagKey(req.TagName))
+	}
 	if err != nil {
 		return &tagservicepb.DeleteTagResponse{}, fmt.Errorf("DeleteTag %s: %v", req.TagName, err)
 	}
-	if isLeaf {
-		err := s._deleteLeafTag(c, &tagservicepb.TagMapping{Name: req.TagName})
+
+	labels, err := s.getLabels(c, req.TagName)
+	if err != nil {
+		return &tagservicepb.DeleteTagResponse{}, fmt.Errorf("DeleteTag %s: %v", req.TagName, err)
+	}
+	var changes []*tagservicepb.SelectorMembershipChange
+	if len(labels) > 0 {
+		changes, err = s.getSelectorChanges(c, req.TagName, labels, nil)
 		if err != nil {
 			return &tagservicepb.DeleteTagResponse{}, fmt.Errorf("DeleteTag %s: %v", req.TagName, err)
 		}
-		return &tagservicepb.DeleteTagResponse{}, nil
 	}
 
-	// Delete all children in mapping
-	childrenTags, err := s.client.SMembers(c, req.TagName).Result()
+	err = s.store.Tx(c, func(w storage.Writer) error {
+		var err error
+		switch valType {
+		case storage.TypeHash:
+			err = s._deleteLeafTag(c, w, req.TagName, members)
+		case storage.TypeString:
+			// The labels of the selector's members are untouched
+			err = s._deleteSelectorTag(c, w, req.TagName)
+		default:
+			err = s._deleteParentTag(c, w, req.TagName, members)
+		}
+		if err != nil {
+			return err
+		}
+		return s._deleteLabels(c, w, req.TagName, labels)
+	})
 	if err != nil {
 		return &tagservicepb.DeleteTagResponse{}, fmt.Errorf("DeleteTag %s: %v", req.TagName, err)
 	}
 
-	err = s.client.SRem(c, req.TagName, childrenTags).Err()
-	if err != nil {
-		return &tagservicepb.DeleteTagResponse{}, fmt.Errorf("DeleteTag %s: %v", req.TagName, err)
+	s.watchers.publish(tagservicepb.TagEvent_DELETED, req.TagName)
+	for _, change := range changes {
+		s.watchers.publish(tagservicepb.TagEvent_MEMBERSHIP_CHANGED, change.SelectorTag)
 	}
-	return &tagservicepb.DeleteTagResponse{}, nil
+	return &tagservicepb.DeleteTagResponse{SelectorChanges: changes}, nil
 }
 
 // Subscribe to a tag
 func (s *tagServiceServer) Subscribe(c context.Context, req *tagservicepb.SubscribeRequest) (*tagservicepb.SubscribeResponse, error) {
-	err := s.client.SAdd(c, getSubscriptionKey(req.Subscription.TagName), req.Subscription.Subscriber).Err()
+	// Update the subscription and its reverse index together
+	err := s.store.Tx(c, func(w storage.Writer) error {
+		if err := w.SAdd(c, getSubscriptionKey(req.Subscription.TagName), req.Subscription.Subscriber); err != nil {
+			return err
+		}
+		return w.SAdd(c, getSubscriberKey(req.Subscription.Subscriber), req.Subscription.TagName)
+	})
 	if err != nil {
 		return &tagservicepb.SubscribeResponse{}, fmt.Errorf("Subscribe: %v", err)
 	}
@@ -322,7 +643,13 @@ func (s *tagServiceServer) Subscribe(c context.Context, req *tagservicepb.Subscr
 
 // Unsubscribe from a tag
 func (s *tagServiceServer) Unsubscribe(c context.Context, req *tagservicepb.UnsubscribeRequest) (*tagservicepb.UnsubscribeResponse, error) {
-	err := s.client.SRem(c, getSubscriptionKey(req.Subscription.TagName), req.Subscription.Subscriber).Err()
+	// Update the subscription and its reverse index together
+	err := s.store.Tx(c, func(w storage.Writer) error {
+		if err := w.SRem(c, getSubscriptionKey(req.Subscription.TagName), req.Subscription.Subscriber); err != nil {
+			return err
+		}
+		return w.SRem(c, getSubscriberKey(req.Subscription.Subscriber), req.Subscription.TagName)
+	})
 	if err != nil {
 		return &tagservicepb.UnsubscribeResponse{}, fmt.Errorf("Unsubscribe: %v", err)
 	}
@@ -332,7 +659,7 @@ func (s *tagServiceServer) Unsubscribe(c context.Context, req *tagservicepb.Unsu
 
 // Get all subscribers to a tag
 func (s *tagServiceServer) GetSubscribers(c context.Context, req *tagservicepb.GetSubscribersRequest) (*tagservicepb.GetSubscribersResponse, error) {
-	subs, err := s.client.SMembers(c, getSubscriptionKey(req.TagName)).Result()
+	subs, err := s.store.SMembers(c, getSubscriptionKey(req.TagName))
 	if err != nil {
 		return nil, fmt.Errorf("GetSubscribers: %v", err)
 	}
@@ -340,36 +667,101 @@ func (s *tagServiceServer) GetSubscribers(c context.Context, req *tagservicepb.G
 	return &tagservicepb.GetSubscribersResponse{Subscribers: subs}, nil
 }
 
+// Get all tags a subscriber is subscribed to
+func (s *tagServiceServer) GetSubscriptions(c context.Context, req *tagservicepb.GetSubscriptionsRequest) (*tagservicepb.GetSubscriptionsResponse, error) {
+	tags, err := s.store.SMembers(c, getSubscriberKey(req.Subscriber))
+	if err != nil {
+		return nil, fmt.Errorf("GetSubscriptions: %v", err)
+	}
+	sort.Strings(tags)
+
+	return &tagservicepb.GetSubscriptionsResponse{TagNames: tags}, nil
+}
+
+// Attach policies to a tag (policies with the same name are overwritten)
+func (s *tagServiceServer) SetTagPolicies(c context.Context, req *tagservicepb.SetTagPoliciesRequest) (*tagservicepb.SetTagPoliciesResponse, error) {
+	if len(req.Policies) == 0 {
+		return &tagservicepb.SetTagPoliciesResponse{}, nil
+	}
+
+	policies := make(map[string]string, len(req.Policies))
+	for _, policy := range req.Policies {
+		if policy.Name == "" {
+			return &tagservicepb.SetTagPoliciesResponse{}, fmt.Errorf("SetTagPolicies %s: policy has no name", req.TagName)
+		}
+		policies[policy.Name] = string(policy.Rule)
+	}
+
+	err := s.store.HSet(c, getPolicyKey(req.TagName), policies)
+	if err != nil {
+		return &tagservicepb.SetTagPoliciesResponse{}, fmt.Errorf("SetTagPolicies %s: %v", req.TagName, err)
+	}
+
+	return &tagservicepb.SetTagPoliciesResponse{}, nil
+}
+
+// Get all policies attached to a tag
+func (s *tagServiceServer) GetTagPolicies(c context.Context, req *tagservicepb.GetTagPoliciesRequest) (*tagservicepb.GetTagPoliciesResponse, error) {
+	policies, err := s.store.HGetAll(c, getPolicyKey(req.TagName))
+	if err != nil {
+		return nil, fmt.Errorf("GetTagPolicies %s: %v", req.TagName, err)
+	}
+
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tagPolicies := make([]*tagservicepb.TagPolicy, len(names))
+	for i, name := range names {
+		tagPolicies[i] = &tagservicepb.TagPolicy{Name: name, Rule: []byte(policies[name])}
+	}
+
+	return &tagservicepb.GetTagPoliciesResponse{Policies: tagPolicies}, nil
+}
+
+// Detach policies from a tag
+func (s *tagServiceServer) DeleteTagPolicies(c context.Context, req *tagservicepb.DeleteTagPoliciesRequest) (*tagservicepb.DeleteTagPoliciesResponse, error) {
+	if len(req.Names) == 0 {
+		return &tagservicepb.DeleteTagPoliciesResponse{}, nil
+	}
+
+	err := s.store.HDel(c, getPolicyKey(req.TagName), req.Names...)
+	if err != nil {
+		return &tagservicepb.DeleteTagPoliciesResponse{}, fmt.Errorf("DeleteTagPolicies %s: %v", req.TagName, err)
+	}
+
+	return &tagservicepb.DeleteTagPoliciesResponse{}, nil
+}
+
+// Get the tags which contain a tag, directly or through other tags
+func (s *tagServiceServer) GetAncestors(c context.Context, req *tagservicepb.GetAncestorsRequest) (*tagservicepb.GetAncestorsResponse, error) {
+	ancestors, err := s.getAncestors(c, req.TagName)
+	if err != nil {
+		return &tagservicepb.GetAncestorsResponse{}, fmt.Errorf("GetAncestors %s: %v", req.TagName, err)
+	}
+	return &tagservicepb.GetAncestorsResponse{Ancestors: ancestors}, nil
+}
+
 // Create a server for the tag service
-func newServer(database *redis.Client) *tagServiceServer {
-	s := &tagServiceServer{client: database}
+func newServer(store storage.Store) *tagServiceServer {
+	s := &tagServiceServer{store: store}
 	return s
 }
 
 // Setup and run the server
-func Setup(dbPort int, serverPort int, clearKeys bool) {
-	// Start the Redis server if it's not already running
-	pgrepCmd := exec.Command("pgrep", "redis-server")
-	if err := pgrepCmd.Run(); err != nil {
-		if err.Error() == "exit status 1" {
-			// According to man pgrep, exit status 1 means "no processes matched or none of them could be signalled"
-			redisServerCmd := exec.Command("redis-server")
-			if err := redisServerCmd.Start(); err != nil {
-				fmt.Println("Failed to start redis server")
-			}
-		} else {
-			fmt.Printf("Failed to check if redis-server is already running: %v\n", err.Error())
+func Setup(store storage.Store, serverPort int, clearKeys bool) {
+	if clearKeys {
+		fmt.Println("Flushed all keys")
+		if err := store.FlushAll(context.Background()); err != nil {
+			fmt.Printf("Failed to flush keys: %v\n", err)
 		}
 	}
 
-	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("localhost:%d", dbPort),
-		Password: "", // no password set
-		DB:       0,  // use default DB
-	})
-	if clearKeys {
-		fmt.Println("Flushed all keys")
-		client.FlushAll(context.Background())
+	// Bring records written by older versions of the tag service up to date
+	if err := migrate(context.Background(), store); err != nil {
+		log.Fatalf("failed to migrate tag service records: %v", err)
 	}
 
 	lis, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", serverPort))
@@ -378,7 +770,7 @@ func Setup(dbPort int, serverPort int, clearKeys bool) {
 	}
 	var opts []grpc.ServerOption
 	grpcServer := grpc.NewServer(opts...)
-	tagservicepb.RegisterTagServiceServer(grpcServer, newServer(client))
+	tagservicepb.RegisterTagServiceServer(grpcServer, newServer(store))
 	fmt.Printf("Serving TagService at localhost:%d\n", serverPort)
 	go func() {
 		err = grpcServer.Serve(lis)
@@ -21,13 +21,16 @@ package tagservice
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	redismock "github.com/go-redis/redismock/v9"
 	redis "github.com/redis/go-redis/v9"
 
+	storage "github.com/paraglider-project/paraglider/pkg/storage"
 	tagservicepb "github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
 )
 
@@ -37,7 +40,7 @@ var (
 )
 
 func newTagServiceServer(database *redis.Client) *tagServiceServer {
-	s := &tagServiceServer{client: database}
+	s := &tagServiceServer{store: storage.NewRedisStore(database)}
 	return s
 }
 
@@ -48,8 +51,8 @@ func TestIsDescendent(t *testing.T) {
 	// Test simple case of direct parent/child relationship
 	parent := "parent"
 	child := "child"
-	mock.ExpectType(parent).SetVal("set")
-	mock.ExpectSMembers(parent).SetVal([]string{child})
+	mock.ExpectType(getTagKey(parent)).SetVal("set")
+	mock.ExpectSMembers(getTagKey(parent)).SetVal([]string{child})
 	resp, _ := server.isDescendent(context.Background(), parent, child)
 	assert.True(t, resp)
 
@@ -59,10 +62,10 @@ func TestIsDescendent(t *testing.T) {
 
 	// Test multiple levels of parent/child relationship
 	grandchild := "grandchild"
-	mock.ExpectType(parent).SetVal("set")
-	mock.ExpectSMembers(parent).SetVal([]string{child})
-	mock.ExpectType(child).SetVal("set")
-	mock.ExpectSMembers(child).SetVal([]string{grandchild})
+	mock.ExpectType(getTagKey(parent)).SetVal("set")
+	mock.ExpectSMembers(getTagKey(parent)).SetVal([]string{child})
+	mock.ExpectType(getTagKey(child)).SetVal("set")
+	mock.ExpectSMembers(getTagKey(child)).SetVal([]string{grandchild})
 	resp, _ = server.isDescendent(context.Background(), parent, grandchild)
 	assert.True(t, resp)
 
@@ -71,9 +74,9 @@ func TestIsDescendent(t *testing.T) {
 	}
 
 	// Test not a descendent
-	mock.ExpectType(parent).SetVal("set")
-	mock.ExpectSMembers(parent).SetVal([]string{child})
-	mock.ExpectType(child).SetVal("hash")
+	mock.ExpectType(getTagKey(parent)).SetVal("set")
+	mock.ExpectSMembers(getTagKey(parent)).SetVal([]string{child})
+	mock.ExpectType(getTagKey(child)).SetVal("hash")
 	resp, _ = server.isDescendent(context.Background(), parent, "not-a-descendent")
 	assert.False(t, resp)
 
@@ -105,13 +108,13 @@ func TestIsLeafTag(t *testing.T) {
 	server := newTagServiceServer(db)
 
 	leafTag := "leaf"
-	mock.ExpectType(leafTag).SetVal("hash")
+	mock.ExpectType(getTagKey(leafTag)).SetVal("hash")
 
 	result, _ := server.isLeafTag(context.Background(), leafTag)
 	assert.True(t, result)
 
 	nonLeafTag := "nonleaflevel"
-	mock.ExpectType(nonLeafTag).SetVal("set")
+	mock.ExpectType(getTagKey(nonLeafTag)).SetVal("set")
 
 	result, _ = server.isLeafTag(context.Background(), nonLeafTag)
 	assert.False(t, result)
@@ -126,21 +129,54 @@ func TestSetLeafTag(t *testing.T) {
 	server := newTagServiceServer(db)
 
 	newTag := tagservicepb.TagMapping{Name: "tag", Uri: &uriVal, Ip: &ipVal}
-	mock.ExpectHExists(newTag.Name, "uri").SetVal(false)
-	mock.ExpectHSet(newTag.Name, map[string]string{"uri": *newTag.Uri, "ip": *newTag.Ip}).SetVal(0)
+	mock.ExpectHGetAll(getTagKey(newTag.Name)).SetVal(map[string]string{})
+	mock.ExpectHSet(getTagKey(newTag.Name), map[string]string{"uri": *newTag.Uri, "ip": *newTag.Ip, "version": "1"}).SetVal(0)
 
-	err := server._setLeafTag(context.Background(), &newTag)
+	version, changed, err := server._setLeafTag(context.Background(), &newTag)
 
 	assert.Nil(t, err)
+	assert.Equal(t, int64(1), version)
+	assert.True(t, changed)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Error(err)
 	}
 
-	// Test tag already exists
-	mock.ExpectHExists(newTag.Name, "uri").SetVal(true)
+	// Test tag already exists with the same URI and IP
+	mock.ExpectHGetAll(getTagKey(newTag.Name)).SetVal(map[string]string{"uri": uriVal, "ip": ipVal, "version": "1"})
 
-	err = server._setLeafTag(context.Background(), &newTag)
+	version, changed, err = server._setLeafTag(context.Background(), &newTag)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), version)
+	assert.False(t, changed)
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Test updating the IP of a tag written before versions (which keeps its URI)
+	newIp := "2.2.2.2"
+	update := tagservicepb.TagMapping{Name: "tag", Ip: &newIp}
+	mock.ExpectHGetAll(getTagKey(newTag.Name)).SetVal(map[string]string{"uri": uriVal, "ip": ipVal})
+	mock.ExpectHSet(getTagKey(newTag.Name), map[string]string{"uri": uriVal, "ip": newIp, "version": "2"}).SetVal(0)
+
+	version, changed, err = server._setLeafTag(context.Background(), &update)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), version)
+	assert.True(t, changed)
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Test update based on an old version
+	oldVersion := int64(1)
+	update.Version = &oldVersion
+	mock.ExpectHGetAll(getTagKey(newTag.Name)).SetVal(map[string]string{"uri": uriVal, "ip": newIp, "version": "2"})
+
+	_, _, err = server._setLeafTag(context.Background(), &update)
 
 	assert.NotNil(t, err)
 
@@ -155,8 +191,11 @@ func TestSetTag(t *testing.T) {
 
 	// Tag mapping to children tags
 	newTag := tagservicepb.TagMapping{Name: "parent", ChildTags: []string{"child"}}
-	mock.ExpectType(newTag.ChildTags[0]).SetVal("hash")
-	mock.ExpectSAdd(newTag.Name, newTag.ChildTags).SetVal(0)
+	mock.ExpectType(getTagKey(newTag.ChildTags[0])).SetVal("hash")
+	mock.ExpectTxPipeline()
+	mock.ExpectSAdd(getTagKey(newTag.Name), newTag.ChildTags).SetVal(0)
+	mock.ExpectSAdd(getParentsKey(newTag.ChildTags[0]), newTag.Name).SetVal(1)
+	mock.ExpectTxPipelineExec()
 
 	_, err := server.SetTag(context.Background(), &tagservicepb.SetTagRequest{Tag: &newTag})
 
@@ -168,12 +207,18 @@ func TestSetTag(t *testing.T) {
 
 	// Leaf tag mapping
 	newTag = tagservicepb.TagMapping{Name: "tag", Uri: &uriVal, Ip: &ipVal}
-	mock.ExpectHExists(newTag.Name, "uri").SetVal(false)
-	mock.ExpectHSet(newTag.Name, map[string]string{"uri": *newTag.Uri, "ip": *newTag.Ip}).SetVal(0)
+	mock.ExpectHGetAll(getTagKey(newTag.Name)).SetVal(map[string]string{})
+	mock.ExpectHSet(getTagKey(newTag.Name), map[string]string{"uri": *newTag.Uri, "ip": *newTag.Ip, "version": "1"}).SetVal(0)
+	mock.ExpectSMembers(getParentsKey(newTag.Name)).SetVal([]string{"parent"})
+	mock.ExpectHGetAll(getLabelsKey(newTag.Name)).SetVal(map[string]string{})
+	mock.ExpectSMembers(getParentsKey("parent")).SetVal([]string{})
+	mock.ExpectHGetAll(getLabelsKey("parent")).SetVal(map[string]string{})
 
-	_, err = server.SetTag(context.Background(), &tagservicepb.SetTagRequest{Tag: &newTag})
+	resp, err := server.SetTag(context.Background(), &tagservicepb.SetTagRequest{Tag: &newTag})
 
 	assert.Nil(t, err)
+	assert.Equal(t, int64(1), resp.Version)
+	assert.Equal(t, []string{"parent"}, resp.UpdatedAncestors)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Error(err)
@@ -186,8 +231,9 @@ func TestGetTag(t *testing.T) {
 
 	// Non-leaf tag
 	tag := &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{"child"}}
-	mock.ExpectType(tag.Name).SetVal("set")
-	mock.ExpectSMembers(tag.Name).SetVal(tag.ChildTags)
+	mock.ExpectType(getTagKey(tag.Name)).SetVal("set")
+	mock.ExpectSMembers(getTagKey(tag.Name)).SetVal(tag.ChildTags)
+	mock.ExpectHGetAll(getLabelsKey(tag.Name)).SetVal(map[string]string{})
 	resp, err := server.GetTag(context.Background(), &tagservicepb.GetTagRequest{TagName: tag.Name})
 	assert.Nil(t, err)
 	assert.Equal(t, resp.Tag, tag)
@@ -196,10 +242,11 @@ func TestGetTag(t *testing.T) {
 		t.Error(err)
 	}
 
-	// Leaf tag
-	tag = &tagservicepb.TagMapping{Name: "tag", Uri: &uriVal, Ip: &ipVal}
-	mock.ExpectType(tag.Name).SetVal("hash")
-	mock.ExpectHGetAll(tag.Name).SetVal(map[string]string{"uri": *tag.Uri, "ip": *tag.Ip})
+	// Leaf tag with labels
+	tag = &tagservicepb.TagMapping{Name: "tag", Uri: &uriVal, Ip: &ipVal, Labels: map[string]string{"env": "prod"}}
+	mock.ExpectType(getTagKey(tag.Name)).SetVal("hash")
+	mock.ExpectHGetAll(getTagKey(tag.Name)).SetVal(map[string]string{"uri": *tag.Uri, "ip": *tag.Ip})
+	mock.ExpectHGetAll(getLabelsKey(tag.Name)).SetVal(tag.Labels)
 	resp, err = server.GetTag(context.Background(), &tagservicepb.GetTagRequest{TagName: tag.Name})
 	assert.Nil(t, err)
 	assert.Equal(t, resp.Tag, tag)
@@ -214,8 +261,8 @@ func TestGetTagNotPresent(t *testing.T) {
 	server := newTagServiceServer(db)
 
 	tag := &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{"child"}}
-	mock.ExpectType(tag.Name).SetVal("set")
-	mock.ExpectSMembers(tag.Name).SetErr(errors.New("no such tag present"))
+	mock.ExpectType(getTagKey(tag.Name)).SetVal("set")
+	mock.ExpectSMembers(getTagKey(tag.Name)).SetErr(errors.New("no such tag present"))
 	resp, err := server.GetTag(context.Background(), &tagservicepb.GetTagRequest{TagName: tag.Name})
 	var nilresult *tagservicepb.GetTagResponse
 	assert.ErrorContains(t, err, "no such tag present")
@@ -233,10 +280,10 @@ func TestResolveTag(t *testing.T) {
 	childMapping := &tagservicepb.TagMapping{Name: "child1", Uri: &uriVal, Ip: &ipVal}
 	childIp := "1.2.3.4"
 	mapping := &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{childMapping.Name, childIp}}
-	mock.ExpectType(mapping.Name).SetVal("set")
-	mock.ExpectSMembers(mapping.Name).SetVal(mapping.ChildTags)
-	mock.ExpectType(childMapping.Name).SetVal("hash")
-	mock.ExpectHGetAll(childMapping.Name).SetVal(map[string]string{"uri": *childMapping.Uri, "ip": *childMapping.Ip})
+	mock.ExpectType(getTagKey(mapping.Name)).SetVal("set")
+	mock.ExpectSMembers(getTagKey(mapping.Name)).SetVal(mapping.ChildTags)
+	mock.ExpectType(getTagKey(childMapping.Name)).SetVal("hash")
+	mock.ExpectHGetAll(getTagKey(childMapping.Name)).SetVal(map[string]string{"uri": *childMapping.Uri, "ip": *childMapping.Ip})
 
 	resp, err := server.ResolveTag(context.Background(), &tagservicepb.ResolveTagRequest{TagName: mapping.Name})
 	assert.Nil(t, err)
@@ -255,11 +302,11 @@ func TestResolveTagMemberNotPresent(t *testing.T) {
 	childMapping := &tagservicepb.TagMapping{Name: "child1", Uri: &uriVal, Ip: &ipVal}
 	childIp := "1.2.3.4"
 	mapping := &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{childMapping.Name, "non-existent-tag", childIp}}
-	mock.ExpectType(mapping.Name).SetVal("set")
-	mock.ExpectSMembers(mapping.Name).SetVal(mapping.ChildTags)
-	mock.ExpectType(childMapping.Name).SetVal("hash")
-	mock.ExpectHGetAll(childMapping.Name).SetVal(map[string]string{"uri": *childMapping.Uri, "ip": *childMapping.Ip})
-	mock.ExpectType("non-existent-tag").SetVal("none")
+	mock.ExpectType(getTagKey(mapping.Name)).SetVal("set")
+	mock.ExpectSMembers(getTagKey(mapping.Name)).SetVal(mapping.ChildTags)
+	mock.ExpectType(getTagKey(childMapping.Name)).SetVal("hash")
+	mock.ExpectHGetAll(getTagKey(childMapping.Name)).SetVal(map[string]string{"uri": *childMapping.Uri, "ip": *childMapping.Ip})
+	mock.ExpectType(getTagKey("non-existent-tag")).SetVal("none")
 
 	resp, err := server.ResolveTag(context.Background(), &tagservicepb.ResolveTagRequest{TagName: mapping.Name})
 	assert.Nil(t, err)
@@ -276,7 +323,10 @@ func TestDeleteTagMember(t *testing.T) {
 	server := newTagServiceServer(db)
 
 	tag := &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{"child1", "child2"}}
-	mock.ExpectSRem(tag.Name, tag.ChildTags[0]).SetVal(0)
+	mock.ExpectTxPipeline()
+	mock.ExpectSRem(getTagKey(tag.Name), tag.ChildTags[0]).SetVal(0)
+	mock.ExpectSRem(getParentsKey(tag.ChildTags[0]), tag.Name).SetVal(0)
+	mock.ExpectTxPipelineExec()
 	_, err := server.DeleteTagMember(context.Background(), &tagservicepb.DeleteTagMemberRequest{ParentTag: tag.Name, ChildTag: tag.ChildTags[0]})
 	assert.Nil(t, err)
 
@@ -290,7 +340,8 @@ func TestDeleteTagMemberNotPresent(t *testing.T) {
 	server := newTagServiceServer(db)
 
 	tag := &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{"child"}}
-	mock.ExpectSRem(tag.Name, tag.ChildTags).SetErr(errors.New("no such tag present"))
+	mock.ExpectTxPipeline()
+	mock.ExpectSRem(getTagKey(tag.Name), tag.ChildTags).SetErr(errors.New("no such tag present"))
 	_, err := server.DeleteTagMember(context.Background(), &tagservicepb.DeleteTagMemberRequest{ParentTag: tag.Name, ChildTag: tag.ChildTags[0]})
 	assert.NotNil(t, err)
 	assert.ErrorContains(t, err, "no such tag present")
@@ -306,9 +357,14 @@ func TestDeleteTag(t *testing.T) {
 
 	// Non-leaf tag
 	tag := &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{"child1", "child2"}}
-	mock.ExpectType(tag.Name).SetVal("set")
-	mock.ExpectSMembers(tag.Name).SetVal(tag.ChildTags)
-	mock.ExpectSRem(tag.Name, tag.ChildTags).SetVal(0)
+	mock.ExpectType(getTagKey(tag.Name)).SetVal("set")
+	mock.ExpectSMembers(getTagKey(tag.Name)).SetVal(tag.ChildTags)
+	mock.ExpectHGetAll(getLabelsKey(tag.Name)).SetVal(map[string]string{})
+	mock.ExpectTxPipeline()
+	mock.ExpectSRem(getTagKey(tag.Name), tag.ChildTags).SetVal(0)
+	mock.ExpectSRem(getParentsKey(tag.ChildTags[0]), tag.Name).SetVal(0)
+	mock.ExpectSRem(getParentsKey(tag.ChildTags[1]), tag.Name).SetVal(0)
+	mock.ExpectTxPipelineExec()
 	_, err := server.DeleteTag(context.Background(), &tagservicepb.DeleteTagRequest{TagName: tag.Name})
 	assert.Nil(t, err)
 
@@ -319,11 +375,19 @@ func TestDeleteTag(t *testing.T) {
 	// Leaf tag
 	tag = &tagservicepb.TagMapping{Name: "tag", Uri: &uriVal, Ip: &ipVal}
 	keys := []string{"uri", "ip"}
-	mock.ExpectType(tag.Name).SetVal("hash")
-	mock.ExpectHKeys(tag.Name).SetVal(keys)
-	mock.ExpectHDel(tag.Name, keys...).SetVal(0)
-	_, err = server.DeleteTag(context.Background(), &tagservicepb.DeleteTagRequest{TagName: tag.Name})
+	mock.ExpectType(getTagKey(tag.Name)).SetVal("hash")
+	mock.ExpectHKeys(getTagKey(tag.Name)).SetVal(keys)
+	mock.ExpectHGetAll(getLabelsKey(tag.Name)).SetVal(map[string]string{"env": "prod"})
+	mock.ExpectSMembers(selectorTagsKey).SetVal([]string{"prod"})
+	mock.ExpectGet(getTagKey("prod")).SetVal("env=prod")
+	mock.ExpectTxPipeline()
+	mock.ExpectHDel(getTagKey(tag.Name), keys...).SetVal(0)
+	mock.ExpectSRem(getLabelIndexKey("env", "prod"), tag.Name).SetVal(1)
+	mock.ExpectHDel(getLabelsKey(tag.Name), "env").SetVal(1)
+	mock.ExpectTxPipelineExec()
+	resp, err := server.DeleteTag(context.Background(), &tagservicepb.DeleteTagRequest{TagName: tag.Name})
 	assert.Nil(t, err)
+	assert.Equal(t, []*tagservicepb.SelectorMembershipChange{{SelectorTag: "prod", Member: tag.Name, Joined: false}}, resp.SelectorChanges)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Error(err)
@@ -335,8 +399,8 @@ func TestDeleteTagNotPresent(t *testing.T) {
 	server := newTagServiceServer(db)
 
 	tag := &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{"child1", "child2"}}
-	mock.ExpectType(tag.Name).SetVal("set")
-	mock.ExpectSMembers(tag.Name).SetErr(errors.New("no such tag present"))
+	mock.ExpectType(getTagKey(tag.Name)).SetVal("set")
+	mock.ExpectSMembers(getTagKey(tag.Name)).SetErr(errors.New("no such tag present"))
 	_, err := server.DeleteTag(context.Background(), &tagservicepb.DeleteTagRequest{TagName: tag.Name})
 	assert.NotNil(t, err)
 	assert.ErrorContains(t, err, "no such tag present")
@@ -352,9 +416,12 @@ func TestDeleteLeafTag(t *testing.T) {
 
 	keys := []string{"uri", "ip"}
 	nameMapping := &tagservicepb.TagMapping{Name: "example", Uri: &uriVal, Ip: &ipVal}
-	mock.ExpectHKeys(nameMapping.Name).SetVal(keys)
-	mock.ExpectHDel(nameMapping.Name, keys...).SetVal(0)
-	err := server._deleteLeafTag(context.Background(), &tagservicepb.TagMapping{Name: nameMapping.Name})
+	mock.ExpectTxPipeline()
+	mock.ExpectHDel(getTagKey(nameMapping.Name), keys...).SetVal(0)
+	mock.ExpectTxPipelineExec()
+	err := server.store.Tx(context.Background(), func(w storage.Writer) error {
+		return server._deleteLeafTag(context.Background(), w, nameMapping.Name, keys)
+	})
 	assert.Nil(t, err)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -367,7 +434,10 @@ func TestSubscribe(t *testing.T) {
 	server := newTagServiceServer(db)
 
 	sub := &tagservicepb.Subscription{TagName: "example", Subscriber: "sub/uri"}
-	mock.ExpectSAdd("SUB:"+sub.TagName, sub.Subscriber).SetVal(0)
+	mock.ExpectTxPipeline()
+	mock.ExpectSAdd("SUB:"+sub.TagName, sub.Subscriber).SetVal(1)
+	mock.ExpectSAdd("SUBSCRIBER:"+sub.Subscriber, sub.TagName).SetVal(1)
+	mock.ExpectTxPipelineExec()
 	_, err := server.Subscribe(context.Background(), &tagservicepb.SubscribeRequest{Subscription: sub})
 	assert.Nil(t, err)
 
@@ -381,7 +451,10 @@ func TestUnsubscribe(t *testing.T) {
 	server := newTagServiceServer(db)
 
 	sub := &tagservicepb.Subscription{TagName: "example", Subscriber: "sub/uri"}
-	mock.ExpectSRem("SUB:"+sub.TagName, sub.Subscriber).SetVal(0)
+	mock.ExpectTxPipeline()
+	mock.ExpectSRem("SUB:"+sub.TagName, sub.Subscriber).SetVal(1)
+	mock.ExpectSRem("SUBSCRIBER:"+sub.Subscriber, sub.TagName).SetVal(1)
+	mock.ExpectTxPipelineExec()
 	_, err := server.Unsubscribe(context.Background(), &tagservicepb.UnsubscribeRequest{Subscription: sub})
 	assert.Nil(t, err)
 
@@ -404,3 +477,280 @@ func TestGetSubscribers(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestGetSubscriptions(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	server := newTagServiceServer(db)
+
+	subscriber := "sub/uri"
+	mock.ExpectSMembers("SUBSCRIBER:" + subscriber).SetVal([]string{"tag2", "tag1"})
+	resp, err := server.GetSubscriptions(context.Background(), &tagservicepb.GetSubscriptionsRequest{Subscriber: subscriber})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tag1", "tag2"}, resp.TagNames)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSetTagPolicies(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	server := newTagServiceServer(db)
+
+	tag := "example"
+	policy := &tagservicepb.TagPolicy{Name: "rule1", Rule: []byte("rule")}
+	mock.ExpectHSet("POLICY:"+tag, map[string]string{policy.Name: string(policy.Rule)}).SetVal(1)
+	_, err := server.SetTagPolicies(context.Background(), &tagservicepb.SetTagPoliciesRequest{TagName: tag, Policies: []*tagservicepb.TagPolicy{policy}})
+	assert.Nil(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Policy without a name
+	_, err = server.SetTagPolicies(context.Background(), &tagservicepb.SetTagPoliciesRequest{TagName: tag, Policies: []*tagservicepb.TagPolicy{{Rule: []byte("rule")}}})
+	assert.NotNil(t, err)
+}
+
+func TestGetTagPolicies(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	server := newTagServiceServer(db)
+
+	tag := "example"
+	mock.ExpectHGetAll("POLICY:" + tag).SetVal(map[string]string{"rule2": "rule-b", "rule1": "rule-a"})
+	resp, err := server.GetTagPolicies(context.Background(), &tagservicepb.GetTagPoliciesRequest{TagName: tag})
+	assert.Nil(t, err)
+	assert.Equal(t, []*tagservicepb.TagPolicy{{Name: "rule1", Rule: []byte("rule-a")}, {Name: "rule2", Rule: []byte("rule-b")}}, resp.Policies)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeleteTagPolicies(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	server := newTagServiceServer(db)
+
+	tag := "example"
+	mock.ExpectHDel("POLICY:"+tag, "rule1").SetVal(1)
+	_, err := server.DeleteTagPolicies(context.Background(), &tagservicepb.DeleteTagPoliciesRequest{TagName: tag, Names: []string{"rule1"}})
+	assert.Nil(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTagServiceMemoryStore(t *testing.T) {
+	server := newServer(storage.NewMemoryStore())
+	ctx := context.Background()
+
+	// Leaf, parent, and selector tags
+	_, err := server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "vm1", Uri: &uriVal, Ip: &ipVal, Labels: map[string]string{"env": "prod"}}})
+	require.Nil(t, err)
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{"vm1", "1.2.3.4"}}})
+	require.Nil(t, err)
+	selector := "env=prod"
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "prod", Selector: &selector}})
+	require.Nil(t, err)
+
+	resolved, err := server.ResolveTag(ctx, &tagservicepb.ResolveTagRequest{TagName: "parent"})
+	require.Nil(t, err)
+	require.Len(t, resolved.Tags, 2)
+	ips := []string{*resolved.Tags[0].Ip, *resolved.Tags[1].Ip}
+	assert.ElementsMatch(t, []string{ipVal, "1.2.3.4"}, ips)
+
+	selected, err := server.GetTag(ctx, &tagservicepb.GetTagRequest{TagName: "prod"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"vm1"}, selected.Tag.ChildTags)
+
+	// Subscriptions
+	subscription := &tagservicepb.Subscription{TagName: "parent", Subscriber: "subscriber"}
+	_, err = server.Subscribe(ctx, &tagservicepb.SubscribeRequest{Subscription: subscription})
+	require.Nil(t, err)
+	subscriptions, err := server.GetSubscriptions(ctx, &tagservicepb.GetSubscriptionsRequest{Subscriber: "subscriber"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"parent"}, subscriptions.TagNames)
+
+	// Deleting the leaf tag leaves nothing to resolve except the IP
+	_, err = server.DeleteTag(ctx, &tagservicepb.DeleteTagRequest{TagName: "vm1"})
+	require.Nil(t, err)
+	resolved, err = server.ResolveTag(ctx, &tagservicepb.ResolveTagRequest{TagName: "parent"})
+	require.Nil(t, err)
+	require.Len(t, resolved.Tags, 1)
+	assert.Equal(t, "1.2.3.4", *resolved.Tags[0].Ip)
+}
+
+func TestResolveTagUniqueLeaves(t *testing.T) {
+	server := newServer(storage.NewMemoryStore())
+	ctx := context.Background()
+
+	// Both groups contain vm1 and the same IP
+	_, err := server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "vm1", Uri: &uriVal, Ip: &ipVal}})
+	require.Nil(t, err)
+	for _, group := range []string{"group1", "group2"} {
+		_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: group, ChildTags: []string{"vm1", "1.2.3.4"}}})
+		require.Nil(t, err)
+	}
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{"group1", "group2"}}})
+	require.Nil(t, err)
+
+	resp, err := server.ResolveTag(ctx, &tagservicepb.ResolveTagRequest{TagName: "parent"})
+	require.Nil(t, err)
+	require.Len(t, resp.Tags, 2)
+	assert.Equal(t, "1.2.3.4", *resp.Tags[0].Ip)
+	assert.Equal(t, "vm1", resp.Tags[1].Name)
+	assert.Empty(t, resp.Paths)
+
+	// Paths from the resolved tag to each leaf
+	resp, err = server.ResolveTag(ctx, &tagservicepb.ResolveTagRequest{TagName: "parent", IncludePaths: true})
+	require.Nil(t, err)
+	require.Len(t, resp.Paths, 2)
+	assert.Equal(t, []string{"parent", "group1", "1.2.3.4"}, resp.Paths[0].Tags)
+	assert.Equal(t, []string{"parent", "group1", "vm1"}, resp.Paths[1].Tags)
+}
+
+func TestResolveTagCycle(t *testing.T) {
+	store := storage.NewMemoryStore()
+	server := newServer(store)
+	ctx := context.Background()
+
+	// Cycles cannot be created through SetTag, so write one to the store directly
+	require.Nil(t, store.SAdd(ctx, getTagKey("a"), "b"))
+	require.Nil(t, store.SAdd(ctx, getTagKey("b"), "a"))
+
+	_, err := server.ResolveTag(ctx, &tagservicepb.ResolveTagRequest{TagName: "a"})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "a -> b -> a")
+
+	isDescendent, err := server.isDescendent(ctx, "a", "c")
+	require.Nil(t, err)
+	assert.False(t, isDescendent)
+}
+
+func TestResolveTagMaxDepth(t *testing.T) {
+	store := storage.NewMemoryStore()
+	server := newServer(store)
+	ctx := context.Background()
+
+	for i := 0; i <= maxResolveDepth; i++ {
+		require.Nil(t, store.SAdd(ctx, getTagKey(fmt.Sprintf("tag%d", i)), fmt.Sprintf("tag%d", i+1)))
+	}
+
+	_, err := server.ResolveTag(ctx, &tagservicepb.ResolveTagRequest{TagName: "tag0"})
+	assert.NotNil(t, err)
+}
+
+func TestSetTagCycle(t *testing.T) {
+	server := newServer(storage.NewMemoryStore())
+	ctx := context.Background()
+
+	_, err := server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{"child"}}})
+	require.Nil(t, err)
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "child", ChildTags: []string{"grandchild"}}})
+	require.Nil(t, err)
+
+	// The tag already contains its parent
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "grandchild", ChildTags: []string{"parent"}}})
+	assert.NotNil(t, err)
+
+	// The tag contains itself
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{"parent"}}})
+	assert.NotNil(t, err)
+
+	// The tag would join a selector tag it contains
+	selector := "env=prod"
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "prod", Selector: &selector}})
+	require.Nil(t, err)
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{"prod"}}})
+	require.Nil(t, err)
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "parent", Labels: map[string]string{"env": "prod"}}})
+	assert.NotNil(t, err)
+
+	resp, err := server.GetTag(ctx, &tagservicepb.GetTagRequest{TagName: "parent"})
+	require.Nil(t, err)
+	assert.Empty(t, resp.Tag.Labels)
+}
+
+func TestSetTagUpdatesLeafAddress(t *testing.T) {
+	server := newServer(storage.NewMemoryStore())
+	ctx := context.Background()
+
+	_, err := server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "vm1", Uri: &uriVal, Ip: &ipVal}})
+	require.Nil(t, err)
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "group", ChildTags: []string{"vm1"}}})
+	require.Nil(t, err)
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{"group"}}})
+	require.Nil(t, err)
+
+	// The VM came back with a new IP
+	newIp := "2.2.2.2"
+	version := int64(1)
+	resp, err := server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "vm1", Ip: &newIp, Version: &version}})
+	require.Nil(t, err)
+	assert.Equal(t, int64(2), resp.Version)
+	assert.Equal(t, []string{"group", "parent"}, resp.UpdatedAncestors)
+
+	getResp, err := server.GetTag(ctx, &tagservicepb.GetTagRequest{TagName: "vm1"})
+	require.Nil(t, err)
+	assert.Equal(t, uriVal, *getResp.Tag.Uri)
+	assert.Equal(t, newIp, *getResp.Tag.Ip)
+	assert.Equal(t, int64(2), *getResp.Tag.Version)
+
+	resolved, err := server.ResolveTag(ctx, &tagservicepb.ResolveTagRequest{TagName: "parent"})
+	require.Nil(t, err)
+	require.Len(t, resolved.Tags, 1)
+	assert.Equal(t, newIp, *resolved.Tags[0].Ip)
+
+	// Updates based on an old version are rejected
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "vm1", Ip: &ipVal, Version: &version}})
+	assert.NotNil(t, err)
+}
+
+func TestGetAncestors(t *testing.T) {
+	server := newServer(storage.NewMemoryStore())
+	ctx := context.Background()
+
+	_, err := server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "vm1", Uri: &uriVal, Ip: &ipVal}})
+	require.Nil(t, err)
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "group", ChildTags: []string{"vm1"}}})
+	require.Nil(t, err)
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "parent", ChildTags: []string{"group"}}})
+	require.Nil(t, err)
+
+	resp, err := server.GetAncestors(ctx, &tagservicepb.GetAncestorsRequest{TagName: "vm1"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"group", "parent"}, resp.Ancestors)
+
+	// Selector tags contain the tags whose labels match
+	selector := "env=prod"
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "prod", Selector: &selector}})
+	require.Nil(t, err)
+	_, err = server.SetTag(ctx, &tagservicepb.SetTagRequest{Tag: &tagservicepb.TagMapping{Name: "vm1", Labels: map[string]string{"env": "prod"}}})
+	require.Nil(t, err)
+	resp, err = server.GetAncestors(ctx, &tagservicepb.GetAncestorsRequest{TagName: "vm1"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"group", "parent", "prod"}, resp.Ancestors)
+
+	// Removing a member removes its parent from the index
+	_, err = server.DeleteTagMember(ctx, &tagservicepb.DeleteTagMemberRequest{ParentTag: "group", ChildTag: "vm1"})
+	require.Nil(t, err)
+	resp, err = server.GetAncestors(ctx, &tagservicepb.GetAncestorsRequest{TagName: "vm1"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"prod"}, resp.Ancestors)
+
+	// Deleting a tag removes it as the parent of its children
+	_, err = server.DeleteTag(ctx, &tagservicepb.DeleteTagRequest{TagName: "parent"})
+	require.Nil(t, err)
+	resp, err = server.GetAncestors(ctx, &tagservicepb.GetAncestorsRequest{TagName: "group"})
+	require.Nil(t, err)
+	assert.Empty(t, resp.Ancestors)
+
+	// Top-level and unknown tags have no ancestors
+	resp, err = server.GetAncestors(ctx, &tagservicepb.GetAncestorsRequest{TagName: "parent"})
+	require.Nil(t, err)
+	assert.Empty(t, resp.Ancestors)
+	resp, err = server.GetAncestors(ctx, &tagservicepb.GetAncestorsRequest{TagName: "missing"})
+	require.Nil(t, err)
+	assert.Empty(t, resp.Ancestors)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tagservice
+
+import (
+	"fmt"
+	"sync"
+
+	tagservicepb "github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
+)
+
+// Number of changes buffered for a watcher before it is considered too slow and dropped
+const watcherBufferSize = 64
+
+// A change to a single tag, before it is matched against what each watcher is watching
+type tagChange struct {
+	eventType tagservicepb.TagEvent_Type
+	tag       string
+}
+
+type tagWatcher struct {
+	changes chan tagChange
+	dropped chan struct{}
+}
+
+// Fans out tag changes to all active watchers (the zero value is ready to use)
+type tagWatchers struct {
+	mu       sync.Mutex
+	watchers map[*tagWatcher]bool
+}
+
+func (w *tagWatchers) add() *tagWatcher {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.watchers == nil {
+		w.watchers = make(map[*tagWatcher]bool)
+	}
+	watcher := &tagWatcher{changes: make(chan tagChange, watcherBufferSize), dropped: make(chan struct{})}
+	w.watchers[watcher] = true
+	return watcher
+}
+
+func (w *tagWatchers) remove(watcher *tagWatcher) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.watchers, watcher)
+}
+
+// Notify all watchers of a change without blocking the caller
+// Watchers which cannot keep up are dropped rather than silently missing changes
+func (w *tagWatchers) publish(eventType tagservicepb.TagEvent_Type, tag string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for watcher := range w.watchers {
+		select {
+		case watcher.changes <- tagChange{eventType: eventType, tag: tag}:
+		default:
+			close(watcher.dropped)
+			delete(w.watchers, watcher)
+		}
+	}
+}
+
+// Watch a tag and stream an event every time it or one of its descendents changes
+func (s *tagServiceServer) Watch(req *tagservicepb.WatchRequest, stream tagservicepb.TagService_WatchServer) error {
+	c := stream.Context()
+	watcher := s.watchers.add()
+	defer s.watchers.remove(watcher)
+
+	for {
+		select {
+		case <-c.Done():
+			return nil
+		case <-watcher.dropped:
+			return fmt.Errorf("Watch %s: watcher fell behind on changes", req.TagName)
+		case change := <-watcher.changes:
+			// Only changes to the watched tag or its descendents are relevant
+			relevant := change.tag == req.TagName
+			if !relevant {
+				isDescendent, err := s.isDescendent(c, req.TagName, change.tag)
+				if err != nil {
+					return fmt.Errorf("Watch %s: %v", req.TagName, err)
+				}
+				relevant = isDescendent
+			}
+			if !relevant {
+				continue
+			}
+
+			resolved, err := s.resolveTag(c, req.TagName)
+			if err != nil {
+				return fmt.Errorf("Watch %s: %v", req.TagName, err)
+			}
+
+			event := &tagservicepb.TagEvent{Type: change.eventType, WatchedTag: req.TagName, ChangedTag: change.tag, ResolvedTags: resolved.tags}
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
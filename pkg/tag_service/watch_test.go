@@ -0,0 +1,113 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tagservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	redismock "github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	tagservicepb "github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
+)
+
+type fakeWatchServer struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *tagservicepb.TagEvent
+}
+
+func (f *fakeWatchServer) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeWatchServer) Send(event *tagservicepb.TagEvent) error {
+	f.events <- event
+	return nil
+}
+
+func waitForWatchers(t *testing.T, watchers *tagWatchers, num int) {
+	require.Eventually(t, func() bool {
+		watchers.mu.Lock()
+		defer watchers.mu.Unlock()
+		return len(watchers.watchers) == num
+	}, time.Second, time.Millisecond)
+}
+
+func TestWatch(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	server := newTagServiceServer(db)
+
+	// Change to an unrelated tag is skipped
+	mock.ExpectType(getTagKey("parent")).SetVal("set")
+	mock.ExpectSMembers(getTagKey("parent")).SetVal([]string{"child"})
+	mock.ExpectType(getTagKey("child")).SetVal("hash")
+
+	// Change to a child is streamed along with the resolved watched tag
+	mock.ExpectType(getTagKey("parent")).SetVal("set")
+	mock.ExpectSMembers(getTagKey("parent")).SetVal([]string{"child"})
+	mock.ExpectType(getTagKey("parent")).SetVal("set")
+	mock.ExpectSMembers(getTagKey("parent")).SetVal([]string{"child"})
+	mock.ExpectType(getTagKey("child")).SetVal("hash")
+	mock.ExpectHGetAll(getTagKey("child")).SetVal(map[string]string{"uri": uriVal, "ip": ipVal})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &fakeWatchServer{ctx: ctx, events: make(chan *tagservicepb.TagEvent, 1)}
+	done := make(chan error)
+	go func() {
+		done <- server.Watch(&tagservicepb.WatchRequest{TagName: "parent"}, stream)
+	}()
+	waitForWatchers(t, &server.watchers, 1)
+
+	server.watchers.publish(tagservicepb.TagEvent_ADDRESS_CHANGED, "other")
+	server.watchers.publish(tagservicepb.TagEvent_ADDRESS_CHANGED, "child")
+
+	event := <-stream.events
+	assert.Equal(t, tagservicepb.TagEvent_ADDRESS_CHANGED, event.Type)
+	assert.Equal(t, "parent", event.WatchedTag)
+	assert.Equal(t, "child", event.ChangedTag)
+	require.Len(t, event.ResolvedTags, 1)
+	assert.Equal(t, ipVal, *event.ResolvedTags[0].Ip)
+
+	// Watch ends when the client goes away
+	cancel()
+	assert.Nil(t, <-done)
+	waitForWatchers(t, &server.watchers, 0)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTagWatchersDropSlowWatcher(t *testing.T) {
+	watchers := &tagWatchers{}
+	watcher := watchers.add()
+
+	for i := 0; i <= watcherBufferSize; i++ {
+		watchers.publish(tagservicepb.TagEvent_MEMBERSHIP_CHANGED, "tag")
+	}
+
+	_, ok := <-watcher.dropped
+	assert.False(t, ok)
+	assert.Empty(t, watchers.watchers)
+}
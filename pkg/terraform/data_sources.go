@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terraform
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// The namespaces configured on the controller and the cloud deployments in each
+func dataSourceNamespaces() *schema.Resource {
+	return &schema.Resource{
+		Description: "The namespaces configured on the Paraglider controller",
+		ReadContext: dataSourceNamespacesRead,
+		Schema: map[string]*schema.Schema{
+			"namespaces": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"clouds": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"name": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"deployment": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceNamespacesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*providerConfig)
+	namespaces, err := config.client.ListNamespaces(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	names := make([]string, 0, len(namespaces))
+	for name := range namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([]map[string]interface{}, len(names))
+	for i, name := range names {
+		clouds := make([]map[string]interface{}, len(namespaces[name]))
+		for j, deployment := range namespaces[name] {
+			clouds[j] = map[string]interface{}{"name": deployment.Name, "deployment": deployment.Deployment}
+		}
+		values[i] = map[string]interface{}{"name": name, "clouds": clouds}
+	}
+
+	if err := d.Set("namespaces", values); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId("namespaces")
+	return nil
+}
+
+// The last-level tags (URIs and IPs) a tag resolves to
+func dataSourceResolvedTag() *schema.Resource {
+	return &schema.Resource{
+		Description: "A tag resolved down to its last-level entries",
+		ReadContext: dataSourceResolvedTagRead,
+		Schema: map[string]*schema.Schema{
+			"tag": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"entries": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"uri": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"ip": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"ips": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceResolvedTagRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*providerConfig)
+	tagName := d.Get("tag").(string)
+	tags, err := config.client.ResolveTag(ctx, tagName)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	entries := make([]map[string]interface{}, len(tags))
+	ips := []string{}
+	for i, tag := range tags {
+		entries[i] = map[string]interface{}{"name": tag.Name, "uri": tag.GetUri(), "ip": tag.GetIp()}
+		if tag.GetIp() != "" {
+			ips = append(ips, tag.GetIp())
+		}
+	}
+
+	if err := d.Set("entries", entries); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("ips", ips); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(tagName)
+	return nil
+}
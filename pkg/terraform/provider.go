@@ -0,0 +1,82 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terraform
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/paraglider-project/paraglider/pkg/client"
+)
+
+const (
+	defaultAddress   = "http://localhost:8080"
+	defaultNamespace = "default"
+)
+
+// Client and defaults shared by all resources and data sources
+type providerConfig struct {
+	client    *client.Client
+	namespace string
+}
+
+// Get the namespace set on a resource, falling back to the provider's
+func (p *providerConfig) getNamespace(d *schema.ResourceData) string {
+	if namespace, ok := d.GetOk("namespace"); ok {
+		return namespace.(string)
+	}
+	return p.namespace
+}
+
+// Create the Paraglider Terraform provider
+func Provider() *schema.Provider {
+	return &schema.Provider{
+		Schema: map[string]*schema.Schema{
+			"address": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PARAGLIDER_ADDRESS", defaultAddress),
+				Description: "Address of the Paraglider controller",
+			},
+			"namespace": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PARAGLIDER_NAMESPACE", defaultNamespace),
+				Description: "Namespace used by resources which do not set one",
+			},
+		},
+		ResourcesMap: map[string]*schema.Resource{
+			"paraglider_resource":         resourceResource(),
+			"paraglider_tag":              resourceTag(),
+			"paraglider_permit_list_rule": resourcePermitListRule(),
+			"paraglider_tag_rule":         resourceTagRule(),
+		},
+		DataSourcesMap: map[string]*schema.Resource{
+			"paraglider_namespaces":   dataSourceNamespaces(),
+			"paraglider_resolved_tag": dataSourceResolvedTag(),
+		},
+		ConfigureContextFunc: configureProvider,
+	}
+}
+
+func configureProvider(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+	return &providerConfig{
+		client:    client.NewClient(d.Get("address").(string)),
+		namespace: d.Get("namespace").(string),
+	}, nil
+}
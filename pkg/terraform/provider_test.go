@@ -0,0 +1,205 @@
+//go:build unit
+
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/paraglider-project/paraglider/pkg/client"
+	fake "github.com/paraglider-project/paraglider/pkg/fake/orchestrator/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupFakeProvider() (*providerConfig, string) {
+	s := fake.FakeOrchestratorRESTServer{}
+	address := s.SetupFakeOrchestratorRESTServer()
+	return &providerConfig{client: client.NewClient(address), namespace: fake.Namespace}, address
+}
+
+func TestProvider(t *testing.T) {
+	require.Nil(t, Provider().InternalValidate())
+}
+
+func TestResourceResource(t *testing.T) {
+	config, _ := setupFakeProvider()
+	d := schema.TestResourceDataRaw(t, resourceResource().Schema, map[string]interface{}{
+		"cloud":       fake.CloudName,
+		"name":        "vm",
+		"description": `{"name": "vm"}`,
+	})
+
+	diags := resourceResourceCreate(context.Background(), d, config)
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, fake.Namespace+"."+fake.CloudName+".vm", d.Id())
+	assert.Equal(t, fake.Namespace, d.Get("namespace"))
+	assert.Equal(t, d.Id(), d.Get("tag"))
+
+	// Destroying only removes the resource from the state
+	diags = resourceResourceDelete(context.Background(), d, config)
+	assert.False(t, diags.HasError())
+	assert.Len(t, diags, 1)
+	assert.Equal(t, "", d.Id())
+}
+
+func TestResourceTag(t *testing.T) {
+	config, _ := setupFakeProvider()
+	d := schema.TestResourceDataRaw(t, resourceTag().Schema, map[string]interface{}{
+		"name":    "tag",
+		"members": []interface{}{"member1", "member2"},
+	})
+
+	diags := resourceTagCreate(context.Background(), d, config)
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, "tag", d.Id())
+	assert.ElementsMatch(t, []string{"member1", "member2"}, getStringSet(d.Get("members")))
+
+	tag := getTagMapping(d, []string{"member3"})
+	assert.Equal(t, "tag", tag.Name)
+	assert.Equal(t, []string{"member3"}, tag.ChildTags)
+	assert.Nil(t, tag.Uri)
+
+	diags = resourceTagDelete(context.Background(), d, config)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, "", d.Id())
+}
+
+func TestResourcePermitListRule(t *testing.T) {
+	config, _ := setupFakeProvider()
+	rule := fake.GetFakePermitListRules()[0]
+	d := schema.TestResourceDataRaw(t, resourcePermitListRule().Schema, map[string]interface{}{
+		"cloud":    fake.CloudName,
+		"resource": "vm",
+		"name":     rule.Name,
+		"tags":     []interface{}{"tag1"},
+		"protocol": 6,
+	})
+
+	assert.Equal(t, int32(-1), getRule(d).SrcPort)
+	assert.Equal(t, "INBOUND", getRule(d).Direction.String())
+
+	// The rule is read back from the permit list
+	diags := resourcePermitListRuleWrite(context.Background(), d, config)
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, fmt.Sprintf("%s/%s/vm/%s", fake.Namespace, fake.CloudName, rule.Name), d.Id())
+	assert.Equal(t, int(rule.Protocol), d.Get("protocol"))
+	assert.Equal(t, []interface{}{"tag1", "tag2"}, d.Get("tags"))
+
+	diags = resourcePermitListRuleDelete(context.Background(), d, config)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, "", d.Id())
+
+	// Rules missing from the permit list are removed from the state
+	d.SetId("id")
+	d.Set("name", "missing")
+	diags = resourcePermitListRuleRead(context.Background(), d, config)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, "", d.Id())
+}
+
+func TestResourceTagRule(t *testing.T) {
+	config, _ := setupFakeProvider()
+	rule := fake.GetFakePermitListRules()[0]
+	d := schema.TestResourceDataRaw(t, resourceTagRule().Schema, map[string]interface{}{
+		"tag":       "tag",
+		"name":      rule.Name,
+		"tags":      []interface{}{"tag1", "tag2"},
+		"direction": "OUTBOUND",
+		"protocol":  1,
+	})
+
+	diags := resourceTagRuleWrite(context.Background(), d, config)
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, "tag/"+rule.Name, d.Id())
+	assert.Equal(t, rule.Direction.String(), d.Get("direction"))
+
+	diags = resourceTagRuleDelete(context.Background(), d, config)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, "", d.Id())
+}
+
+func TestDataSources(t *testing.T) {
+	config, _ := setupFakeProvider()
+
+	d := schema.TestResourceDataRaw(t, dataSourceNamespaces().Schema, map[string]interface{}{})
+	diags := dataSourceNamespacesRead(context.Background(), d, config)
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, "namespace1", d.Get("namespaces.0.name"))
+	assert.Equal(t, "deployment1", d.Get("namespaces.0.clouds.0.deployment"))
+
+	d = schema.TestResourceDataRaw(t, dataSourceResolvedTag().Schema, map[string]interface{}{"tag": "tag"})
+	diags = dataSourceResolvedTagRead(context.Background(), d, config)
+	require.False(t, diags.HasError(), diags)
+	assert.Equal(t, "resource/uri", d.Get("entries.0.uri"))
+	assert.Equal(t, []interface{}{"3.3.3.3"}, d.Get("ips"))
+}
+
+// Acceptance tests run terraform against the fake controller and only run when TF_ACC is set
+func TestAccProvider(t *testing.T) {
+	_, address := setupFakeProvider()
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: map[string]func() (*schema.Provider, error){
+			"paraglider": func() (*schema.Provider, error) { return Provider(), nil },
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "paraglider" {
+  address   = %q
+  namespace = %q
+}
+
+resource "paraglider_resource" "vm" {
+  cloud       = %q
+  name        = "vm"
+  description = jsonencode({ name = "vm" })
+}
+
+resource "paraglider_tag" "group" {
+  name    = "group"
+  members = ["member1", "member2"]
+}
+
+resource "paraglider_tag_rule" "rule" {
+  tag       = paraglider_tag.group.name
+  name      = "name"
+  tags      = ["tag1", "tag2"]
+  src_port  = 1
+  dst_port  = 1
+  protocol  = 1
+}
+
+data "paraglider_resolved_tag" "group" {
+  tag = paraglider_tag.group.name
+}
+`, address, fake.Namespace, fake.CloudName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("paraglider_resource.vm", "tag", fake.Namespace+"."+fake.CloudName+".vm"),
+					resource.TestCheckResourceAttr("paraglider_tag.group", "members.#", "2"),
+					resource.TestCheckResourceAttr("paraglider_tag_rule.rule", "direction", "INBOUND"),
+					resource.TestCheckResourceAttr("data.paraglider_resolved_tag.group", "ips.0", "3.3.3.3"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terraform
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/paraglider-project/paraglider/pkg/client"
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+)
+
+// A cloud resource created through Paraglider
+// The controller cannot modify or delete resources, so every change replaces the resource and destroying it only removes it from the state
+func resourceResource() *schema.Resource {
+	return &schema.Resource{
+		Description:   "A cloud resource (eg, a VM) created by the Paraglider controller",
+		CreateContext: resourceResourceCreate,
+		ReadContext:   resourceResourceRead,
+		DeleteContext: resourceResourceDelete,
+		Schema: map[string]*schema.Schema{
+			"namespace": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"cloud": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"description": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateFunc:     validation.StringIsJSON,
+				DiffSuppressFunc: structure.SuppressJsonDiff,
+				Description:      "Cloud-specific JSON description of the resource (same format as glide resource create)",
+			},
+			"tag": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"uri": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"ip": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceResourceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*providerConfig)
+	namespace := config.getNamespace(d)
+	cloud := d.Get("cloud").(string)
+	name := d.Get("name").(string)
+
+	description := &paragliderpb.ResourceDescriptionString{Description: d.Get("description").(string)}
+	resourceInfo, err := config.client.CreateResource(ctx, namespace, cloud, name, description)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(getResourceTagName(namespace, cloud, name))
+	d.Set("namespace", namespace)
+	d.Set("uri", resourceInfo["uri"])
+	d.Set("ip", resourceInfo["ip"])
+	return resourceResourceRead(ctx, d, meta)
+}
+
+func resourceResourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*providerConfig)
+	tag, err := config.client.GetTag(ctx, d.Id())
+	if client.IsNotFound(err) {
+		// Removed outside of Terraform, so it will be recreated
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("tag", d.Id())
+	if tag.Uri != nil {
+		d.Set("uri", tag.GetUri())
+	}
+	if tag.Ip != nil {
+		d.Set("ip", tag.GetIp())
+	}
+	return nil
+}
+
+func resourceResourceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf("Resource %s was not deleted", d.Get("tag").(string)),
+		Detail:   "Paraglider does not delete cloud resources. It was only removed from the Terraform state.",
+	}}
+}
+
+// Name of the tag the controller creates for a resource
+func getResourceTagName(namespace string, cloud string, name string) string {
+	return namespace + "." + cloud + "." + name
+}
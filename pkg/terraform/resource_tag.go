@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terraform
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/paraglider-project/paraglider/pkg/client"
+	"github.com/paraglider-project/paraglider/pkg/tag_service/tagservicepb"
+)
+
+// A tag grouping other tags, or a last-level tag naming a URI and/or IP
+func resourceTag() *schema.Resource {
+	return &schema.Resource{
+		Description:   "A Paraglider tag",
+		CreateContext: resourceTagCreate,
+		ReadContext:   resourceTagRead,
+		UpdateContext: resourceTagUpdate,
+		DeleteContext: resourceTagDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"members": {
+				Type:          schema.TypeSet,
+				Optional:      true,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				ConflictsWith: []string{"uri", "ip", "selector"},
+			},
+			"uri": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"ip": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"labels": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"selector": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Comma-separated key=value labels that members must have",
+			},
+		},
+	}
+}
+
+// Build the tag mapping from the configuration with the given members
+func getTagMapping(d *schema.ResourceData, members []string) *tagservicepb.TagMapping {
+	tag := &tagservicepb.TagMapping{Name: d.Get("name").(string), ChildTags: members}
+	if uri, ok := d.GetOk("uri"); ok {
+		value := uri.(string)
+		tag.Uri = &value
+	}
+	if ip, ok := d.GetOk("ip"); ok {
+		value := ip.(string)
+		tag.Ip = &value
+	}
+	if selector, ok := d.GetOk("selector"); ok {
+		value := selector.(string)
+		tag.Selector = &value
+	}
+	if labels, ok := d.GetOk("labels"); ok {
+		tag.Labels = map[string]string{}
+		for key, value := range labels.(map[string]interface{}) {
+			tag.Labels[key] = value.(string)
+		}
+	}
+	return tag
+}
+
+func getStringSet(set interface{}) []string {
+	values := []string{}
+	for _, value := range set.(*schema.Set).List() {
+		values = append(values, value.(string))
+	}
+	return values
+}
+
+func resourceTagCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*providerConfig)
+	tag := getTagMapping(d, getStringSet(d.Get("members")))
+	if err := config.client.SetTag(ctx, tag.Name, tag); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(tag.Name)
+	return resourceTagRead(ctx, d, meta)
+}
+
+func resourceTagRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*providerConfig)
+	tag, err := config.client.GetTag(ctx, d.Id())
+	if client.IsNotFound(err) {
+		// Removed outside of Terraform, so it will be recreated
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("name", d.Id())
+	// Selector members are derived from labels
+	if tag.Selector == nil {
+		d.Set("members", tag.ChildTags)
+	}
+	d.Set("uri", tag.GetUri())
+	d.Set("ip", tag.GetIp())
+	d.Set("labels", tag.Labels)
+	d.Set("selector", tag.GetSelector())
+	return nil
+}
+
+func resourceTagUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*providerConfig)
+	oldMembers, newMembers := d.GetChange("members")
+	added := newMembers.(*schema.Set).Difference(oldMembers.(*schema.Set))
+	removed := oldMembers.(*schema.Set).Difference(newMembers.(*schema.Set))
+
+	// Setting a tag only adds members, so removed ones are deleted separately
+	tag := getTagMapping(d, getStringSet(added))
+	if err := config.client.SetTag(ctx, tag.Name, tag); err != nil {
+		return diag.FromErr(err)
+	}
+	for _, member := range getStringSet(removed) {
+		if err := config.client.DeleteTagMembers(ctx, tag.Name, member); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return resourceTagRead(ctx, d, meta)
+}
+
+func resourceTagDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*providerConfig)
+	if err := config.client.DeleteTag(ctx, d.Id()); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId("")
+	return nil
+}
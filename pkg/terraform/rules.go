@@ -0,0 +1,222 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+)
+
+// Fields describing a permit list rule, extended with the fields identifying where it is attached
+// Rules are replaced by name, so any change updates the rule in place
+func getRuleSchema(owner map[string]*schema.Schema) map[string]*schema.Schema {
+	rule := map[string]*schema.Schema{
+		"name": {
+			Type:     schema.TypeString,
+			Required: true,
+			ForceNew: true,
+		},
+		"tags": {
+			Type:        schema.TypeList,
+			Required:    true,
+			MinItems:    1,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Tags, IPs or CIDRs the rule allows traffic to or from",
+		},
+		"direction": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      paragliderpb.Direction_INBOUND.String(),
+			ValidateFunc: validation.StringInSlice([]string{paragliderpb.Direction_INBOUND.String(), paragliderpb.Direction_OUTBOUND.String()}, false),
+		},
+		"src_port": {
+			Type:     schema.TypeInt,
+			Optional: true,
+			Default:  -1,
+		},
+		"dst_port": {
+			Type:     schema.TypeInt,
+			Optional: true,
+			Default:  -1,
+		},
+		"protocol": {
+			Type:     schema.TypeInt,
+			Required: true,
+		},
+	}
+	for key, value := range owner {
+		rule[key] = value
+	}
+	return rule
+}
+
+func getRule(d *schema.ResourceData) *paragliderpb.PermitListRule {
+	tags := []string{}
+	for _, tag := range d.Get("tags").([]interface{}) {
+		tags = append(tags, tag.(string))
+	}
+	return &paragliderpb.PermitListRule{
+		Name:      d.Get("name").(string),
+		Tags:      tags,
+		Direction: paragliderpb.Direction(paragliderpb.Direction_value[d.Get("direction").(string)]),
+		SrcPort:   int32(d.Get("src_port").(int)),
+		DstPort:   int32(d.Get("dst_port").(int)),
+		Protocol:  int32(d.Get("protocol").(int)),
+	}
+}
+
+// Set the rule's fields from the rule with the same name in a permit list, or remove it from the state if there is none
+func setRule(d *schema.ResourceData, rules []*paragliderpb.PermitListRule, name string) {
+	for _, rule := range rules {
+		if rule.Name != name {
+			continue
+		}
+		d.Set("name", rule.Name)
+		d.Set("tags", rule.Tags)
+		d.Set("direction", rule.Direction.String())
+		d.Set("src_port", rule.SrcPort)
+		d.Set("dst_port", rule.DstPort)
+		d.Set("protocol", rule.Protocol)
+		return
+	}
+	d.SetId("")
+}
+
+// A rule in the permit list of a single resource
+func resourcePermitListRule() *schema.Resource {
+	return &schema.Resource{
+		Description:   "A rule in the permit list of a resource",
+		CreateContext: resourcePermitListRuleWrite,
+		ReadContext:   resourcePermitListRuleRead,
+		UpdateContext: resourcePermitListRuleWrite,
+		DeleteContext: resourcePermitListRuleDelete,
+		Schema: getRuleSchema(map[string]*schema.Schema{
+			"namespace": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"cloud": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"resource": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the resource whose permit list contains the rule",
+			},
+		}),
+	}
+}
+
+func resourcePermitListRuleWrite(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*providerConfig)
+	namespace := config.getNamespace(d)
+	cloud := d.Get("cloud").(string)
+	resource := d.Get("resource").(string)
+	rule := getRule(d)
+
+	err := config.client.AddPermitListRules(ctx, namespace, cloud, resource, []*paragliderpb.PermitListRule{rule})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(strings.Join([]string{namespace, cloud, resource, rule.Name}, "/"))
+	d.Set("namespace", namespace)
+	return resourcePermitListRuleRead(ctx, d, meta)
+}
+
+func resourcePermitListRuleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*providerConfig)
+	rules, err := config.client.GetPermitList(ctx, config.getNamespace(d), d.Get("cloud").(string), d.Get("resource").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	setRule(d, rules, d.Get("name").(string))
+	return nil
+}
+
+func resourcePermitListRuleDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*providerConfig)
+	err := config.client.DeletePermitListRules(ctx, config.getNamespace(d), d.Get("cloud").(string), d.Get("resource").(string), []string{d.Get("name").(string)})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId("")
+	return nil
+}
+
+// A rule attached to a tag and applied to every resource in it
+func resourceTagRule() *schema.Resource {
+	return &schema.Resource{
+		Description:   "A rule attached to a tag and added to the permit list of every resource in it",
+		CreateContext: resourceTagRuleWrite,
+		ReadContext:   resourceTagRuleRead,
+		UpdateContext: resourceTagRuleWrite,
+		DeleteContext: resourceTagRuleDelete,
+		Schema: getRuleSchema(map[string]*schema.Schema{
+			"tag": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+		}),
+	}
+}
+
+func resourceTagRuleWrite(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*providerConfig)
+	tag := d.Get("tag").(string)
+	rule := getRule(d)
+
+	err := config.client.AddPermitListRulesTag(ctx, tag, []*paragliderpb.PermitListRule{rule})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", tag, rule.Name))
+	return resourceTagRuleRead(ctx, d, meta)
+}
+
+func resourceTagRuleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*providerConfig)
+	rules, err := config.client.GetPermitListRulesTag(ctx, d.Get("tag").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	setRule(d, rules, d.Get("name").(string))
+	return nil
+}
+
+func resourceTagRuleDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*providerConfig)
+	err := config.client.DeletePermitListRulesTag(ctx, d.Get("tag").(string), []string{d.Get("name").(string)})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId("")
+	return nil
+}
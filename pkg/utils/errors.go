@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The Paraglider Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Reason of the error info attached to plugin errors caused by a failed cloud API call
+const CloudAPIErrorReason = "CLOUD_API_ERROR"
+
+// Get the gRPC code matching the HTTP status code of a failed cloud API call
+func GetCodeFromHTTPStatus(statusCode int) codes.Code {
+	switch statusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable, http.StatusBadGateway:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+// Convert an error returned by a plugin into a gRPC status error
+// Errors which already have a status keep it, and failed cloud API calls (found with getStatusCode) get the code matching their HTTP status
+func ToStatusError(cloud string, err error, getStatusCode func(error) (int, bool)) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, err.Error())
+	}
+
+	statusCode, ok := getStatusCode(err)
+	if !ok {
+		return status.Error(codes.Internal, err.Error())
+	}
+	st := status.New(GetCodeFromHTTPStatus(statusCode), err.Error())
+	info := &errdetails.ErrorInfo{Reason: CloudAPIErrorReason, Domain: cloud, Metadata: map[string]string{"status_code": strconv.Itoa(statusCode)}}
+	if withDetails, detailsErr := st.WithDetails(info); detailsErr == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// Create an interceptor which converts the errors returned by a plugin's RPCs with ToStatusError
+func StatusErrorInterceptor(cloud string, getStatusCode func(error) (int, bool)) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, ToStatusError(cloud, err, getStatusCode)
+		}
+		return resp, nil
+	}
+}
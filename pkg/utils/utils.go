@@ -17,6 +17,7 @@ limitations under the License.
 package log
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/netip"
@@ -24,6 +25,9 @@ import (
 	"strings"
 
 	"github.com/paraglider-project/paraglider/pkg/paragliderpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 var (
@@ -33,9 +37,12 @@ var (
 // Cloud names
 // TODO @seankimkdy: turn these into its own type and use enums
 const (
-	GCP   = "gcp"
-	AZURE = "azure"
-	IBM   = "ibm"
+	GCP        = "gcp"
+	AZURE      = "azure"
+	IBM        = "ibm"
+	AWS        = "aws"
+	STATIC     = "static"     // On-prem sites declared in the static plugin's config
+	KUBERNETES = "kubernetes" // Workloads (pods selected by labels) in Kubernetes clusters
 )
 
 // Private address spaces as defined in RFC 1918
@@ -167,7 +174,8 @@ func MatchCloudProviders(cloud1, cloud2, target1, target2 string) bool {
 
 // Returns the number of VPN connections needed between cloud1 and cloud2
 func GetNumVpnConnections(cloud1, cloud2 string) int {
-	if MatchCloudProviders(cloud1, cloud2, AZURE, GCP) || MatchCloudProviders(cloud1, cloud2, AZURE, IBM) {
+	if MatchCloudProviders(cloud1, cloud2, AZURE, GCP) || MatchCloudProviders(cloud1, cloud2, AZURE, IBM) ||
+		MatchCloudProviders(cloud1, cloud2, AWS, AZURE) || MatchCloudProviders(cloud1, cloud2, AWS, GCP) {
 		return 2
 	}
 	return 1
@@ -190,3 +198,19 @@ func DoesCIDROverlap(cidr1, cidr2 string) (bool, error) {
 
 	return false, nil
 }
+
+// Gets the DNS servers new networks should use from the orchestrator.
+// An orchestrator which does not serve DNS (no addresses or an Unimplemented RPC) means the cloud's default resolver is kept.
+func GetDnsServers(ctx context.Context, client paragliderpb.ControllerClient) ([]string, error) {
+	resp, err := client.GetDnsServers(ctx, &emptypb.Empty{})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get dns servers: %w", err)
+	}
+	if len(resp.IpAddresses) == 0 {
+		return nil, nil
+	}
+	return resp.IpAddresses, nil
+}